@@ -0,0 +1,79 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/artifacts"
+)
+
+// SetArtifactRegistry overrides the artifact registry used by the artifact endpoints.
+func (h *Handler) SetArtifactRegistry(registry *artifacts.Registry) { h.artifacts = registry }
+
+func (h *Handler) artifactRegistry() *artifacts.Registry {
+	if h.artifacts != nil {
+		return h.artifacts
+	}
+	return artifacts.Default()
+}
+
+// ListArtifacts lists managed per-request artifacts (netlogs, captures).
+// An optional request_id query parameter (or :id path parameter) filters the result.
+func (h *Handler) ListArtifacts(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return
+	}
+	requestID := strings.TrimSpace(c.Param("id"))
+	if requestID == "" {
+		requestID = strings.TrimSpace(c.Query("request_id"))
+	}
+	if strings.ContainsAny(requestID, "/\\") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+		return
+	}
+
+	items := h.artifactRegistry().List(requestID)
+	if items == nil {
+		items = []artifacts.Artifact{}
+	}
+	c.JSON(http.StatusOK, gin.H{"artifacts": items})
+}
+
+// DownloadArtifact downloads a single artifact by request ID and name.
+func (h *Handler) DownloadArtifact(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return
+	}
+	requestID := strings.TrimSpace(c.Param("id"))
+	name := strings.TrimSpace(c.Param("name"))
+	if requestID == "" || name == "" || strings.ContainsAny(requestID+name, "/\\") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid artifact reference"})
+		return
+	}
+
+	item, ok := h.artifactRegistry().Lookup(requestID, name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "artifact not found"})
+		return
+	}
+	info, errStat := os.Stat(item.Path)
+	if errStat != nil {
+		if os.IsNotExist(errStat) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "artifact not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read artifact: %v", errStat)})
+		return
+	}
+	if info.IsDir() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid artifact"})
+		return
+	}
+
+	c.FileAttachment(item.Path, item.Name)
+}
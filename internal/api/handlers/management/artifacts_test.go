@@ -0,0 +1,69 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/artifacts"
+)
+
+func newArtifactTestRouter(registry *artifacts.Registry) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+	h.SetArtifactRegistry(registry)
+	r := gin.New()
+	r.GET("/artifacts", h.ListArtifacts)
+	r.GET("/artifacts/:id", h.ListArtifacts)
+	r.GET("/artifacts/:id/:name", h.DownloadArtifact)
+	return r
+}
+
+func TestArtifactEndpoints_ListAndFetchByRequestID(t *testing.T) {
+	registry := artifacts.NewRegistry(t.TempDir(), 0, 0)
+	path, err := registry.Allocate("abc123", "capture", ".log")
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("data: hello\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.Allocate("other", "netlog", ".json"); err != nil {
+		t.Fatal(err)
+	}
+	router := newArtifactTestRouter(registry)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/artifacts/abc123", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	var listed struct {
+		Artifacts []artifacts.Artifact `json:"artifacts"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(listed.Artifacts) != 1 || listed.Artifacts[0].Name != filepath.Base(path) {
+		t.Fatalf("unexpected list result: %+v", listed.Artifacts)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/artifacts/abc123/"+filepath.Base(path), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("fetch status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Body.String(); got != "data: hello\n\n" {
+		t.Fatalf("fetch body = %q", got)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/artifacts/other/"+filepath.Base(path), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("cross-request fetch status = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,206 @@
+package management
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/gjson"
+)
+
+// authExportFormat identifies an encrypted auth store export.
+const authExportFormat = "cliproxy-auth-export"
+
+// authExportBundle is the document returned by ExportAuthFiles. Only the file count is
+// visible; names and contents live inside the encrypted envelope.
+type authExportBundle struct {
+	Format     string                   `json:"format"`
+	ExportedAt time.Time                `json:"exported_at"`
+	Count      int                      `json:"count"`
+	Envelope   *util.PassphraseEnvelope `json:"envelope"`
+}
+
+// authExportPayload is the plaintext sealed inside the envelope.
+type authExportPayload struct {
+	Files []authExportFile `json:"files"`
+}
+
+// authExportFile is one auth file, byte for byte as stored on disk.
+type authExportFile struct {
+	Name    string `json:"name"`
+	Content []byte `json:"content"`
+}
+
+// ExportAuthFiles returns every auth file, encrypted with the passphrase from the request body.
+func (h *Handler) ExportAuthFiles(c *gin.Context) {
+	var req struct {
+		Passphrase string `json:"passphrase"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Passphrase == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "passphrase is required"})
+		return
+	}
+	files, err := h.readAuthFilesForExport()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	plaintext, err := json.Marshal(authExportPayload{Files: files})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to encode export: %v", err)})
+		return
+	}
+	envelope, err := util.SealWithPassphrase(plaintext, req.Passphrase)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, authExportBundle{
+		Format:     authExportFormat,
+		ExportedAt: time.Now().UTC(),
+		Count:      len(files),
+		Envelope:   envelope,
+	})
+}
+
+// ImportAuthFiles decrypts an export produced by ExportAuthFiles and writes its auth files.
+// Every file is validated before any is written, and the set is written as one transaction
+// so either all files are imported or none; existing files with the same name are replaced.
+func (h *Handler) ImportAuthFiles(c *gin.Context) {
+	var req struct {
+		Passphrase string           `json:"passphrase"`
+		Bundle     authExportBundle `json:"bundle"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	if req.Passphrase == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "passphrase is required"})
+		return
+	}
+	if req.Bundle.Format != authExportFormat || req.Bundle.Envelope == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bundle is not an auth export"})
+		return
+	}
+	plaintext, err := req.Bundle.Envelope.Open(req.Passphrase)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, util.ErrEnvelopeDecrypt) {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	var payload authExportPayload
+	if err = json.Unmarshal(plaintext, &payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export payload"})
+		return
+	}
+	seen := make(map[string]bool, len(payload.Files))
+	for _, file := range payload.Files {
+		if errValidate := validateImportedAuthFile(file); errValidate != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errValidate.Error()})
+			return
+		}
+		if seen[file.Name] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s is listed twice", file.Name)})
+			return
+		}
+		seen[file.Name] = true
+	}
+
+	authDir := h.cfg.AuthDir
+	if !filepath.IsAbs(authDir) {
+		if abs, errAbs := filepath.Abs(authDir); errAbs == nil {
+			authDir = abs
+		}
+	}
+	// Stage the whole set first so a failed write leaves none of the files behind.
+	txn, err := util.NewFileTransaction(authDir)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, file := range payload.Files {
+		if errStage := txn.Stage(file.Name, file.Content, 0o600); errStage != nil {
+			txn.Abort()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to write %s: %v", file.Name, errStage)})
+			return
+		}
+	}
+	if err = txn.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	imported := make([]string, 0, len(payload.Files))
+	var errs []error
+	for _, file := range payload.Files {
+		imported = append(imported, file.Name)
+		if errReg := h.registerAuthFromFile(ctx, filepath.Join(authDir, file.Name), file.Content); errReg != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", file.Name, errReg))
+		}
+	}
+	if len(errs) > 0 {
+		// The files are on disk; the watcher still picks up the ones that failed to register.
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errors.Join(errs...).Error(), "imported": imported})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "imported": imported})
+}
+
+// readAuthFilesForExport reads the auth files listed by the auth-files endpoints.
+func (h *Handler) readAuthFilesForExport() ([]authExportFile, error) {
+	entries, err := os.ReadDir(h.cfg.AuthDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []authExportFile{}, nil
+		}
+		return nil, fmt.Errorf("failed to read auth dir: %w", err)
+	}
+	files := make([]authExportFile, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(strings.ToLower(name), ".json") {
+			continue
+		}
+		data, errRead := os.ReadFile(filepath.Join(h.cfg.AuthDir, name))
+		if errRead != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, errRead)
+		}
+		if !json.Valid(data) {
+			return nil, fmt.Errorf("auth file %s is not valid json", name)
+		}
+		files = append(files, authExportFile{Name: name, Content: data})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func validateImportedAuthFile(file authExportFile) error {
+	name := file.Name
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid auth file name %q", name)
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".json") {
+		return fmt.Errorf("auth file %s must end with .json", name)
+	}
+	content := gjson.ParseBytes(file.Content)
+	if !json.Valid(file.Content) || !content.IsObject() {
+		return fmt.Errorf("auth file %s is not a json object", name)
+	}
+	if strings.TrimSpace(content.Get("type").String()) == "" {
+		return fmt.Errorf("auth file %s has no provider type", name)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package management
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func newAuthExportTestRouter(authDir string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{cfg: &config.Config{AuthDir: authDir}}
+	r := gin.New()
+	r.POST("/auth-files/export", h.ExportAuthFiles)
+	r.POST("/auth-files/import", h.ImportAuthFiles)
+	return r
+}
+
+func postJSON(t *testing.T, router *gin.Engine, path string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	raw, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(raw))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthExportImport_RoundTripPreservesFiles(t *testing.T) {
+	source := t.TempDir()
+	files := map[string]string{
+		"codex-a@example.com.json": `{"type":"codex","email":"a@example.com","access_token":"at","refresh_token":"rt","expired":"2026-05-01T00:00:00Z","disabled":false}`,
+		"copilot-b.json":           "{\n  \"type\": \"copilot\",\n  \"github_token\": \"ghu_x\",\n  \"nested\": {\"k\": [1, 2.5, null]}\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(source, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(source, "notes.txt"), []byte("skip"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := postJSON(t, newAuthExportTestRouter(source), "/auth-files/export", gin.H{"passphrase": "correct horse"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	if bytes.Contains(rec.Body.Bytes(), []byte("refresh_token")) || bytes.Contains(rec.Body.Bytes(), []byte("ghu_x")) {
+		t.Fatalf("export leaks plaintext credentials: %s", rec.Body.String())
+	}
+	var bundle authExportBundle
+	if err := json.Unmarshal(rec.Body.Bytes(), &bundle); err != nil {
+		t.Fatalf("decode export: %v", err)
+	}
+	if bundle.Count != len(files) {
+		t.Fatalf("export count = %d, want %d", bundle.Count, len(files))
+	}
+
+	target := t.TempDir()
+	rec = postJSON(t, newAuthExportTestRouter(target), "/auth-files/import", gin.H{"passphrase": "correct horse", "bundle": bundle})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	for name, want := range files {
+		path := filepath.Join(target, name)
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read imported %s: %v", name, err)
+		}
+		if string(got) != want {
+			t.Fatalf("%s = %q, want %q", name, got, want)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+			t.Fatalf("%s mode = %v, want 0600", name, info.Mode().Perm())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(target, "notes.txt")); !os.IsNotExist(err) {
+		t.Fatalf("non-auth files must not be exported")
+	}
+	entries, err := os.ReadDir(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(files) {
+		t.Fatalf("import left %d entries in the auth dir, want %d", len(entries), len(files))
+	}
+}
+
+func TestAuthImport_WrongPassphraseWritesNothing(t *testing.T) {
+	source := t.TempDir()
+	if err := os.WriteFile(filepath.Join(source, "claude.json"), []byte(`{"type":"claude"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	rec := postJSON(t, newAuthExportTestRouter(source), "/auth-files/export", gin.H{"passphrase": "right"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export status = %d, body=%s", rec.Code, rec.Body.String())
+	}
+	var bundle authExportBundle
+	if err := json.Unmarshal(rec.Body.Bytes(), &bundle); err != nil {
+		t.Fatal(err)
+	}
+
+	target := t.TempDir()
+	rec = postJSON(t, newAuthExportTestRouter(target), "/auth-files/import", gin.H{"passphrase": "wrong", "bundle": bundle})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("import status = %d, want 401, body=%s", rec.Code, rec.Body.String())
+	}
+	entries, err := os.ReadDir(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("failed import wrote %d entries", len(entries))
+	}
+
+	rec = postJSON(t, newAuthExportTestRouter(source), "/auth-files/export", gin.H{})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("export without passphrase status = %d, want 400", rec.Code)
+	}
+}
@@ -318,6 +318,9 @@ func (h *Handler) GetAuthFileModels(c *gin.Context) {
 		if m.OwnedBy != "" {
 			entry["owned_by"] = m.OwnedBy
 		}
+		if m.Billing != nil {
+			entry["billing"] = m.Billing
+		}
 		result = append(result, entry)
 	}
 
@@ -0,0 +1,99 @@
+package management
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	grokauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/grok"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// GetAuthQuota reports the known quota and rate-limit state of one auth: the cooldown
+// after the last 429 (with the provider's Retry-After), the next retry time, per-model
+// cooldowns, and provider-specific limits such as Grok's remaining queries and the Copilot
+// account type. Tokens and other credentials are never included.
+func (h *Handler) GetAuthQuota(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		return
+	}
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth manager unavailable"})
+		return
+	}
+	// GetByID and List return clones taken under the manager lock; the shared token
+	// storage is read through its own locked accessors.
+	auth, ok := h.authManager.GetByID(id)
+	if !ok {
+		for _, candidate := range h.authManager.List() {
+			if candidate.FileName == id {
+				auth, ok = candidate, true
+				break
+			}
+		}
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth not found"})
+		return
+	}
+	c.JSON(http.StatusOK, authQuotaReport(auth))
+}
+
+func authQuotaReport(auth *coreauth.Auth) gin.H {
+	out := gin.H{
+		"id":          auth.ID,
+		"provider":    auth.Provider,
+		"status":      auth.Status,
+		"disabled":    auth.Disabled,
+		"unavailable": auth.Unavailable,
+		"quota":       auth.Quota,
+	}
+	if auth.Label != "" {
+		out["label"] = auth.Label
+	}
+	if !auth.NextRetryAfter.IsZero() {
+		out["next_retry_after"] = auth.NextRetryAfter
+	}
+	if auth.LastError != nil {
+		out["last_error"] = gin.H{
+			"code":        auth.LastError.Code,
+			"message":     util.RedactSecrets(auth.LastError.Message),
+			"http_status": auth.LastError.HTTPStatus,
+		}
+	}
+
+	// failure_count counts the models currently in a failed state.
+	now := time.Now()
+	failures := 0
+	models := make([]gin.H, 0, len(auth.ModelStates))
+	for name, state := range auth.ModelStates {
+		if state == nil || state.Status != coreauth.StatusError {
+			continue
+		}
+		failures++
+		model := gin.H{"model": name, "quota": state.Quota}
+		if state.NextRetryAfter.After(now) {
+			model["next_retry_after"] = state.NextRetryAfter
+		}
+		if state.LastError != nil {
+			model["http_status"] = state.LastError.HTTPStatus
+		}
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i]["model"].(string) < models[j]["model"].(string) })
+	out["models"] = models
+
+	if storage, ok := auth.Storage.(*grokauth.GrokTokenStorage); ok && storage != nil {
+		out["grok"] = storage.Quota()
+	}
+	if strings.EqualFold(auth.Provider, "copilot") {
+		out["copilot"] = gin.H{"account_type": auth.Attributes["account_type"]}
+	}
+	out["failure_count"] = failures
+	return out
+}
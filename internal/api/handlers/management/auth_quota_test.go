@@ -0,0 +1,68 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	grokauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/grok"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestGetAuthQuota_GrokRemainingQueries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(&memoryAuthStore{}, nil, nil)
+	storage := &grokauth.GrokTokenStorage{
+		SSOToken:              "sso-secret-token-value",
+		CFClearance:           "cf-secret",
+		FailedCount:           1,
+		RemainingQueries:      42,
+		HeavyRemainingQueries: 7,
+		QuotaResetAt:          "2026-01-01T02:00:00Z",
+	}
+	auth := &coreauth.Auth{
+		ID:         "grok-test.json",
+		FileName:   "grok-test.json",
+		Provider:   "grok",
+		Storage:    storage,
+		Attributes: map[string]string{"sso_token": storage.SSOToken, "cf_clearance": storage.CFClearance},
+	}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	h := &Handler{authManager: manager}
+	r := gin.New()
+	r.GET("/auth/:id/quota", h.GetAuthQuota)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/grok-test.json/quota", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+	for _, secret := range []string{"sso-secret-token-value", "cf-secret"} {
+		if strings.Contains(rec.Body.String(), secret) {
+			t.Fatalf("response leaks %q: %s", secret, rec.Body.String())
+		}
+	}
+	var body struct {
+		Provider string             `json:"provider"`
+		Grok     grokauth.GrokQuota `json:"grok"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := grokauth.GrokQuota{FailedCount: 1, RemainingQueries: 42, HeavyRemainingQueries: 7, QuotaResetAt: "2026-01-01T02:00:00Z"}
+	if body.Provider != "grok" || body.Grok != want {
+		t.Fatalf("quota = %+v (provider %q), want %+v", body.Grok, body.Provider, want)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/missing.json/quota", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("missing auth status = %d, want 404", rec.Code)
+	}
+}
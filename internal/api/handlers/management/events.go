@@ -0,0 +1,78 @@
+package management
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// eventStreamHeartbeat is how often an idle event stream sends an SSE comment so
+// proxies keep the connection open.
+const eventStreamHeartbeat = 15 * time.Second
+
+// StreamEvents streams dispatch events (auth selection, retries, provider fallbacks and
+// auth state changes) as server-sent events until the client disconnects. The optional
+// request_id and type query parameters filter the stream; type accepts a comma list.
+func (h *Handler) StreamEvents(c *gin.Context) {
+	if h.events == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "event stream unavailable"})
+		return
+	}
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "streaming not supported"})
+		return
+	}
+	requestID := strings.TrimSpace(c.Query("request_id"))
+	types := make(map[coreauth.EventType]bool)
+	for _, t := range strings.Split(c.Query("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types[coreauth.EventType(t)] = true
+		}
+	}
+
+	events, cancel := h.events.Subscribe(256)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	_, _ = fmt.Fprint(c.Writer, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return
+		case <-heartbeat.C:
+			_, _ = fmt.Fprint(c.Writer, ": ping\n\n")
+			flusher.Flush()
+		case event, open := <-events:
+			if !open {
+				return
+			}
+			if requestID != "" && event.RequestID != requestID {
+				continue
+			}
+			if len(types) > 0 && !types[event.Type] {
+				continue
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			_, _ = fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Type, data)
+			flusher.Flush()
+		}
+	}
+}
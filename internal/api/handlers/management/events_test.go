@@ -0,0 +1,68 @@
+package management
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestStreamEvents_WritesFilteredServerSentEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{events: coreauth.NewEventBroadcaster()}
+	r := gin.New()
+	r.GET("/events", h.StreamEvents)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/events?request_id=req-1&type=retry,auth_selected", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /events: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("content type = %q", ct)
+	}
+	reader := bufio.NewReader(resp.Body)
+	if line, _ := reader.ReadString('\n'); line != ": connected\n" {
+		t.Fatalf("first line = %q", line)
+	}
+
+	h.events.Emit(ctx, coreauth.Event{Type: coreauth.EventRetry, RequestID: "req-other", Attempt: 9})
+	h.events.Emit(ctx, coreauth.Event{Type: coreauth.EventAuthState, RequestID: "req-1", State: "suspended"})
+	h.events.Emit(ctx, coreauth.Event{Type: coreauth.EventRetry, RequestID: "req-1", Attempt: 2, ErrorClass: "rate_limited"})
+
+	var eventLine, dataLine string
+	for dataLine == "" {
+		line, errRead := reader.ReadString('\n')
+		if errRead != nil {
+			t.Fatalf("read stream: %v", errRead)
+		}
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			eventLine = strings.TrimSpace(strings.TrimPrefix(line, "event: "))
+		case strings.HasPrefix(line, "data: "):
+			dataLine = strings.TrimSpace(strings.TrimPrefix(line, "data: "))
+		}
+	}
+	if eventLine != string(coreauth.EventRetry) {
+		t.Fatalf("event = %q, want retry", eventLine)
+	}
+	var ev coreauth.Event
+	if err = json.Unmarshal([]byte(dataLine), &ev); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if ev.RequestID != "req-1" || ev.Attempt != 2 || ev.ErrorClass != "rate_limited" {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+}
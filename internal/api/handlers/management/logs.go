@@ -14,6 +14,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -22,12 +23,18 @@ const (
 	logScannerMaxBuffer     = 8 * 1024 * 1024
 )
 
-// GetLogs returns log lines with optional incremental loading.
+// GetLogs returns log lines with optional incremental loading. Requests filtering by
+// level or request_id, or made while file logging is off, are served from the in-memory
+// log buffer when it is enabled; source=memory|file selects explicitly.
 func (h *Handler) GetLogs(c *gin.Context) {
 	if h == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
 		return
 	}
+	if h.wantsLogRing(c) {
+		h.getRingLogs(c)
+		return
+	}
 	if h.cfg == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "configuration unavailable"})
 		return
@@ -487,6 +494,53 @@ func parseCutoff(raw string) int64 {
 	return ts
 }
 
+// wantsLogRing reports whether GetLogs should read from the in-memory log buffer.
+func (h *Handler) wantsLogRing(c *gin.Context) bool {
+	switch strings.ToLower(strings.TrimSpace(c.Query("source"))) {
+	case "memory":
+		return true
+	case "file":
+		return false
+	}
+	if logging.ActiveLogRing() == nil {
+		return false
+	}
+	if c.Query("level") != "" || c.Query("request_id") != "" {
+		return true
+	}
+	return h.cfg == nil || !h.cfg.LoggingToFile
+}
+
+// getRingLogs serves recent entries from the in-memory log buffer.
+func (h *Handler) getRingLogs(c *gin.Context) {
+	ring := logging.ActiveLogRing()
+	if ring == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "log buffer disabled"})
+		return
+	}
+	query := logging.LogRingQuery{Level: log.TraceLevel, RequestID: strings.TrimSpace(c.Query("request_id"))}
+	if raw := strings.TrimSpace(c.Query("level")); raw != "" {
+		level, errLevel := log.ParseLevel(raw)
+		if errLevel != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid level: %v", errLevel)})
+			return
+		}
+		query.Level = level
+	}
+	limit, errLimit := parseLimit(c.Query("limit"))
+	if errLimit != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", errLimit)})
+		return
+	}
+	query.Limit = limit
+	entries := ring.Entries(query)
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+		"dropped": ring.Dropped(),
+	})
+}
+
 func parseLimit(raw string) (int, error) {
 	value := strings.TrimSpace(raw)
 	if value == "" {
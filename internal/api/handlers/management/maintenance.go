@@ -0,0 +1,100 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// ListMaintenanceWindows returns active maintenance windows (configured and manual)
+// plus upcoming manual windows.
+func (h *Handler) ListMaintenanceWindows(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	windows := h.authManager.MaintenanceWindows()
+	if windows == nil {
+		windows = []coreauth.MaintenanceWindow{}
+	}
+	c.JSON(http.StatusOK, gin.H{"maintenance-windows": windows})
+}
+
+// CreateMaintenanceWindow adds a one-off maintenance window for a provider.
+// The window is given either as an explicit end time or as a duration from start (default now).
+func (h *Handler) CreateMaintenanceWindow(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+
+	var req struct {
+		Provider string     `json:"provider"`
+		Start    *time.Time `json:"start"`
+		End      *time.Time `json:"end"`
+		Duration string     `json:"duration"`
+		Drain    bool       `json:"drain"`
+		Reason   string     `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	window := coreauth.MaintenanceWindow{
+		Provider: req.Provider,
+		Drain:    req.Drain,
+		Reason:   req.Reason,
+	}
+	if req.Start != nil {
+		window.Start = *req.Start
+	}
+	switch {
+	case req.End != nil:
+		window.End = *req.End
+	case strings.TrimSpace(req.Duration) != "":
+		duration, err := time.ParseDuration(strings.TrimSpace(req.Duration))
+		if err != nil || duration <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid duration"})
+			return
+		}
+		start := window.Start
+		if start.IsZero() {
+			start = time.Now()
+			window.Start = start
+		}
+		window.End = start.Add(duration)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end or duration is required"})
+		return
+	}
+
+	created, err := h.authManager.AddMaintenanceWindow(window)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"maintenance-window": created})
+}
+
+// DeleteMaintenanceWindow removes a manually added maintenance window by ID.
+// Windows derived from the maintenance-windows config block cannot be removed here.
+func (h *Handler) DeleteMaintenanceWindow(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	id := strings.TrimSpace(c.Param("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is required"})
+		return
+	}
+	if !h.authManager.RemoveMaintenanceWindow(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "maintenance window not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,50 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/clockskew"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/connretry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/startup"
+)
+
+// GetClockSkew reports the rolling per-provider estimate of upstream clock skew,
+// measured from the Date header of upstream responses.
+func (h *Handler) GetClockSkew(c *gin.Context) {
+	tracker := clockskew.Default()
+	c.JSON(http.StatusOK, gin.H{
+		"warn_threshold_ms": tracker.Threshold().Milliseconds(),
+		"providers":         tracker.Snapshot(),
+	})
+}
+
+// GetConnectionChurn reports per-provider counts of upstream connections torn down
+// (HTTP/2 GOAWAY, connection reset) before a response, and of the transparent retries.
+func (h *Handler) GetConnectionChurn(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": connretry.Snapshot()})
+}
+
+// GetCopilotTransport reports how many Copilot requests each transport served, and how
+// often a failed Electron attempt was retried on the Go transport.
+func (h *Handler) GetCopilotTransport(c *gin.Context) {
+	c.JSON(http.StatusOK, executor.CopilotTransportSnapshot())
+}
+
+// GetCodexCache reports the size of the Codex prompt cache ID cache and its hit, miss,
+// eviction and expiry counters.
+func (h *Handler) GetCodexCache(c *gin.Context) {
+	c.JSON(http.StatusOK, executor.CodexCacheSnapshot())
+}
+
+// GetStatus returns the startup summary: listeners, loaded credentials per provider,
+// transports, the masked proxy and configuration warnings. It is refreshed on config reload.
+func (h *Handler) GetStatus(c *gin.Context) {
+	summary := startup.Current()
+	if summary == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "startup summary not available yet"})
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
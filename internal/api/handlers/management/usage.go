@@ -3,6 +3,7 @@ package management
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -26,10 +27,26 @@ func (h *Handler) GetUsageStatistics(c *gin.Context) {
 	if h != nil && h.usageStats != nil {
 		snapshot = h.usageStats.Snapshot()
 	}
-	c.JSON(http.StatusOK, gin.H{
+	budgets := usage.GetBudgetMonitor().Statuses()
+	// ?tenant= scopes the report to the API keys and budgets of one tenant.
+	if tenant := strings.TrimSpace(c.Query("tenant")); tenant != "" && h.cfg != nil {
+		snapshot = snapshot.FilterAPIs(func(apiKey string) bool { return h.cfg.TenantForAPIKey(apiKey) == tenant })
+		scoped := budgets[:0:0]
+		for _, budget := range budgets {
+			if budget.Tenant == tenant {
+				scoped = append(scoped, budget)
+			}
+		}
+		budgets = scoped
+	}
+	resp := gin.H{
 		"usage":           snapshot,
 		"failed_requests": snapshot.FailureCount,
-	})
+	}
+	if len(budgets) > 0 {
+		resp["budgets"] = budgets
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // ExportUsageStatistics returns a complete usage snapshot for backup/migration.
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SSECompressionMiddleware gzips text/event-stream responses for clients that send
+// Accept-Encoding: gzip. Every Flush also flushes the compressor, so events still reach
+// the client one at a time. Other responses, such as JSON bodies and errors, are small
+// enough that they pass through uncompressed.
+//
+// It must run before RequestLoggingMiddleware so request logs keep the plain stream.
+func SSECompressionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+		w := &sseGzipWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		defer w.close()
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		q, found := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !found {
+			return true
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(q), 64)
+		return err != nil || weight > 0
+	}
+	return false
+}
+
+// sseGzipWriter decides on the first header or body write whether the response is an
+// event stream and, if so, compresses everything written after that.
+type sseGzipWriter struct {
+	gin.ResponseWriter
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (w *sseGzipWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	if !strings.HasPrefix(strings.ToLower(header.Get("Content-Type")), "text/event-stream") || header.Get("Content-Encoding") != "" {
+		return
+	}
+	header.Set("Content-Encoding", "gzip")
+	header.Add("Vary", "Accept-Encoding")
+	header.Del("Content-Length")
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+}
+
+func (w *sseGzipWriter) WriteHeader(code int) {
+	if code != http.StatusNoContent && code != http.StatusNotModified {
+		w.decide()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sseGzipWriter) WriteHeaderNow() {
+	w.decide()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *sseGzipWriter) Write(data []byte) (int, error) {
+	w.decide()
+	if w.gz == nil {
+		return w.ResponseWriter.Write(data)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+	return w.gz.Write(data)
+}
+
+func (w *sseGzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// Flush pushes the compressed bytes of everything written so far to the client.
+func (w *sseGzipWriter) Flush() {
+	if w.gz != nil {
+		_ = w.gz.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// close writes the gzip trailer once the handler has finished.
+func (w *sseGzipWriter) close() {
+	if w.gz == nil {
+		return
+	}
+	_ = w.gz.Close()
+	w.ResponseWriter.Flush()
+}
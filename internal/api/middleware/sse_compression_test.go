@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newSSECompressionServer(t *testing.T, next chan struct{}) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(SSECompressionMiddleware())
+	engine.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		for i := 1; i <= 3; i++ {
+			if i > 1 {
+				<-next
+			}
+			_, _ = fmt.Fprintf(c.Writer, "data: event-%d\n\n", i)
+			c.Writer.Flush()
+		}
+	})
+	engine.GET("/json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	srv := httptest.NewServer(engine)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSSECompressionMiddleware_StreamsGzipPerEvent(t *testing.T) {
+	next := make(chan struct{})
+	srv := newSSECompressionServer(t, next)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/stream", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if got := resp.Header.Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+
+	zr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	reader := bufio.NewReader(zr)
+	for i := 1; i <= 3; i++ {
+		// The handler only writes the next event after this one was decoded, so each
+		// event must arrive on its own flush rather than when the stream closes.
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event %d: %v", i, err)
+		}
+		if want := fmt.Sprintf("data: event-%d\n", i); line != want {
+			t.Fatalf("event %d = %q, want %q", i, line, want)
+		}
+		if blank, _ := reader.ReadString('\n'); blank != "\n" {
+			t.Fatalf("event %d not terminated by a blank line: %q", i, blank)
+		}
+		if i < 3 {
+			next <- struct{}{}
+		}
+	}
+	if rest, err := io.ReadAll(reader); err != nil || len(rest) != 0 {
+		t.Fatalf("trailing data %q, err %v; want a cleanly closed gzip stream", rest, err)
+	}
+}
+
+func TestSSECompressionMiddleware_SkipsWhenNotApplicable(t *testing.T) {
+	next := make(chan struct{})
+	close(next)
+	srv := newSSECompressionServer(t, next)
+	client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+
+	cases := []struct {
+		name, path, acceptEncoding string
+	}{
+		{name: "client without gzip", path: "/stream", acceptEncoding: ""},
+		{name: "gzip refused with q=0", path: "/stream", acceptEncoding: "gzip;q=0, identity"},
+		{name: "non-stream response", path: "/json", acceptEncoding: "gzip"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tc.path, nil)
+			if tc.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+			if got := resp.Header.Get("Content-Encoding"); got != "" {
+				t.Fatalf("Content-Encoding = %q, want none", got)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if len(body) == 0 || body[0] == 0x1f {
+				t.Fatalf("body looks compressed or empty: %q", body)
+			}
+		})
+	}
+}
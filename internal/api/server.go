@@ -207,6 +207,10 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		engine.Use(mw)
 	}
 
+	// Compress event streams for gzip-accepting clients; it wraps the writer before the
+	// request logger does so logs keep the uncompressed stream.
+	engine.Use(middleware.SSECompressionMiddleware())
+
 	// Add request logging middleware (positioned after recovery, before auth)
 	// Resolve logs directory relative to the configuration file directory.
 	var requestLogger logging.RequestLogger
@@ -324,6 +328,7 @@ func (s *Server) setupRoutes() {
 		v1.POST("/completions", openaiHandlers.Completions)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
+		v1.POST("/tokenize", s.tokenizeHandler)
 		v1.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
@@ -497,6 +502,12 @@ func (s *Server) registerManagementRoutes() {
 	mgmt := s.engine.Group("/v0/management")
 	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
 	{
+		mgmt.GET("/events", s.mgmt.StreamEvents)
+		mgmt.GET("/status", s.mgmt.GetStatus)
+		mgmt.GET("/clock-skew", s.mgmt.GetClockSkew)
+		mgmt.GET("/connection-churn", s.mgmt.GetConnectionChurn)
+		mgmt.GET("/copilot-transport", s.mgmt.GetCopilotTransport)
+		mgmt.GET("/codex-cache", s.mgmt.GetCodexCache)
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
@@ -555,6 +566,9 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/request-error-logs", s.mgmt.GetRequestErrorLogs)
 		mgmt.GET("/request-error-logs/:name", s.mgmt.DownloadRequestErrorLog)
 		mgmt.GET("/request-log-by-id/:id", s.mgmt.GetRequestLogByID)
+		mgmt.GET("/artifacts", s.mgmt.ListArtifacts)
+		mgmt.GET("/artifacts/:id", s.mgmt.ListArtifacts)
+		mgmt.GET("/artifacts/:id/:name", s.mgmt.DownloadArtifact)
 		mgmt.GET("/request-log", s.mgmt.GetRequestLog)
 		mgmt.PUT("/request-log", s.mgmt.PutRequestLog)
 		mgmt.PATCH("/request-log", s.mgmt.PutRequestLog)
@@ -641,9 +655,12 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/model-definitions/:channel", s.mgmt.GetStaticModelDefinitions)
 		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
+		mgmt.POST("/auth-files/export", s.mgmt.ExportAuthFiles)
+		mgmt.POST("/auth-files/import", s.mgmt.ImportAuthFiles)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
 		mgmt.PATCH("/auth-files/status", s.mgmt.PatchAuthFileStatus)
 		mgmt.PATCH("/auth-files/fields", s.mgmt.PatchAuthFileFields)
+		mgmt.GET("/auth/:id/quota", s.mgmt.GetAuthQuota)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
 
 		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
@@ -658,6 +675,10 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/kiro-auth-url", s.mgmt.RequestKiroToken)
 		mgmt.POST("/oauth-callback", s.mgmt.PostOAuthCallback)
 		mgmt.GET("/get-auth-status", s.mgmt.GetAuthStatus)
+
+		mgmt.GET("/maintenance-windows", s.mgmt.ListMaintenanceWindows)
+		mgmt.POST("/maintenance-windows", s.mgmt.CreateMaintenanceWindow)
+		mgmt.DELETE("/maintenance-windows/:id", s.mgmt.DeleteMaintenanceWindow)
 	}
 }
 
@@ -918,10 +939,22 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		}
 	}
 
+	if oldCfg == nil || oldCfg.LogBufferSize != cfg.LogBufferSize {
+		logging.ConfigureLogRing(cfg.LogBufferSize)
+	}
+
 	if oldCfg == nil || oldCfg.UsageStatisticsEnabled != cfg.UsageStatisticsEnabled {
 		usage.SetStatisticsEnabled(cfg.UsageStatisticsEnabled)
 	}
 
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.ModelPricing, cfg.ModelPricing) || !reflect.DeepEqual(oldCfg.UsageBudgets, cfg.UsageBudgets) {
+		usage.SetBudgetConfig(cfg)
+	}
+
+	if oldCfg == nil || oldCfg.TimingHeader != cfg.TimingHeader {
+		logging.SetTimingHeaderEnabled(cfg.TimingHeader)
+	}
+
 	if s.requestLogger != nil && (oldCfg == nil || oldCfg.ErrorLogsMaxFiles != cfg.ErrorLogsMaxFiles) {
 		if setter, ok := s.requestLogger.(interface{ SetErrorLogsMaxFiles(int) }); ok {
 			setter.SetErrorLogsMaxFiles(cfg.ErrorLogsMaxFiles)
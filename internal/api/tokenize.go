@@ -0,0 +1,39 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// tokenizeHandler serves POST /v1/tokenize: it counts the input tokens of a Responses or
+// chat completions request (or {"text": ...}) with the encoding of its Codex/OpenAI model,
+// locally and without calling upstream. The count uses the same estimation as the Codex
+// executor, so it lines up with the usage the proxy reports for that request.
+func (s *Server) tokenizeHandler(c *gin.Context) {
+	rawJSON, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.Data(http.StatusBadRequest, "application/json", handlers.BuildErrorResponseBody(http.StatusBadRequest, "invalid request body: "+err.Error()))
+		return
+	}
+	if !gjson.ValidBytes(rawJSON) {
+		c.Data(http.StatusBadRequest, "application/json", handlers.BuildErrorResponseBody(http.StatusBadRequest, "request body must be JSON"))
+		return
+	}
+	model := strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String())
+	if model == "" {
+		c.Data(http.StatusBadRequest, "application/json", handlers.BuildErrorResponseBody(http.StatusBadRequest, "missing model"))
+		return
+	}
+	count, err := executor.CountCodexPromptTokens(s.cfg, model, rawJSON)
+	if err != nil {
+		c.Data(http.StatusInternalServerError, "application/json", handlers.BuildErrorResponseBody(http.StatusInternalServerError, err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, count)
+}
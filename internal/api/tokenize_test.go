@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestTokenizeRoute(t *testing.T) {
+	server := newTestServer(t)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/tokenize", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer test-key")
+		rr := httptest.NewRecorder()
+		server.engine.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := post(`{"model":"gpt-4o","messages":[{"role":"user","content":"hello world"}]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	body := rr.Body.Bytes()
+	if got := gjson.GetBytes(body, "encoding").String(); got != "o200k_base" {
+		t.Fatalf("encoding = %q, want o200k_base", got)
+	}
+	if got := gjson.GetBytes(body, "input_tokens").Int(); got != 2 {
+		t.Fatalf("input_tokens = %d, want 2", got)
+	}
+
+	if rr := post(`{"messages":[]}`); rr.Code != http.StatusBadRequest {
+		t.Fatalf("missing model status = %d, want 400", rr.Code)
+	}
+}
@@ -0,0 +1,410 @@
+// Package artifacts manages per-request debug artifacts (Electron netlogs, response
+// captures, and similar files) written under a single managed directory.
+// Every artifact is registered with its request ID, size, and creation time so a
+// background sweeper can enforce total-size and max-age limits, and the management
+// API can list and fetch artifacts by request ID.
+package artifacts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMaxTotalBytes caps the combined size of all managed artifacts.
+	DefaultMaxTotalBytes int64 = 256 * 1024 * 1024
+	// DefaultMaxAge is how long an artifact is kept before the sweeper removes it.
+	DefaultMaxAge = time.Hour
+	// DefaultSweepInterval controls how often the background sweeper runs.
+	DefaultSweepInterval = time.Minute
+
+	dirName = "artifacts"
+)
+
+// Artifact describes a single managed file.
+type Artifact struct {
+	RequestID string    `json:"request_id"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	Path      string    `json:"-"`
+}
+
+// Registry tracks managed artifacts rooted at a single directory.
+type Registry struct {
+	root          string
+	maxTotalBytes int64
+	maxAge        time.Duration
+
+	mu      sync.Mutex
+	entries map[string][]*Artifact // keyed by request ID
+	now     func() time.Time
+}
+
+// NewRegistry creates a registry rooted at dir. Non-positive limits disable the
+// corresponding sweep rule. Files already present under dir (for example from a
+// previous process) are adopted so they are subject to the same limits.
+func NewRegistry(dir string, maxTotalBytes int64, maxAge time.Duration) *Registry {
+	r := &Registry{
+		root:          filepath.Clean(dir),
+		maxTotalBytes: maxTotalBytes,
+		maxAge:        maxAge,
+		entries:       make(map[string][]*Artifact),
+		now:           time.Now,
+	}
+	r.adoptExisting()
+	return r
+}
+
+// Root returns the managed artifact directory.
+func (r *Registry) Root() string {
+	if r == nil {
+		return ""
+	}
+	return r.root
+}
+
+// Allocate reserves a fresh artifact path for requestID and registers it.
+// The kind is used as the file name prefix (e.g. "netlog") and ext as its
+// extension (e.g. ".json"). The parent directory is created; the file is not.
+func (r *Registry) Allocate(requestID, kind, ext string) (string, error) {
+	if r == nil {
+		return "", fmt.Errorf("artifacts: registry unavailable")
+	}
+	requestID = sanitizeComponent(requestID)
+	if requestID == "" {
+		requestID = "anon-" + randomSuffix()
+	}
+	kind = sanitizeComponent(kind)
+	if kind == "" {
+		kind = "artifact"
+	}
+	ext = strings.TrimSpace(ext)
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	dir := filepath.Join(r.root, requestID)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("artifacts: create directory: %w", err)
+	}
+
+	now := r.now()
+	name := fmt.Sprintf("%s-%s-%s%s", kind, now.UTC().Format("20060102T150405"), randomSuffix(), ext)
+	entry := &Artifact{
+		RequestID: requestID,
+		Name:      name,
+		Kind:      kind,
+		CreatedAt: now,
+		Path:      filepath.Join(dir, name),
+	}
+
+	r.mu.Lock()
+	r.entries[requestID] = append(r.entries[requestID], entry)
+	r.mu.Unlock()
+	return entry.Path, nil
+}
+
+// Create allocates an artifact path and opens it for writing.
+func (r *Registry) Create(requestID, kind, ext string) (*os.File, error) {
+	path, err := r.Allocate(requestID, kind, ext)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("artifacts: create file: %w", err)
+	}
+	return f, nil
+}
+
+// List returns artifacts for requestID, or all artifacts when requestID is empty.
+// Results are ordered newest first and sizes reflect the current file on disk.
+func (r *Registry) List(requestID string) []Artifact {
+	if r == nil {
+		return nil
+	}
+	requestID = strings.TrimSpace(requestID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []Artifact
+	collect := func(items []*Artifact) {
+		for _, entry := range items {
+			refreshSize(entry)
+			out = append(out, *entry)
+		}
+	}
+	if requestID != "" {
+		collect(r.entries[requestID])
+	} else {
+		for _, items := range r.entries {
+			collect(items)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Lookup returns the artifact registered under requestID with the given name.
+func (r *Registry) Lookup(requestID, name string) (Artifact, bool) {
+	if r == nil {
+		return Artifact{}, false
+	}
+	requestID = strings.TrimSpace(requestID)
+	name = strings.TrimSpace(name)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, entry := range r.entries[requestID] {
+		if entry.Name == name {
+			refreshSize(entry)
+			return *entry, true
+		}
+	}
+	return Artifact{}, false
+}
+
+// Sweep removes expired artifacts and, if the total size still exceeds the limit,
+// the oldest artifacts until it fits. It returns the number of artifacts removed.
+func (r *Registry) Sweep() int {
+	if r == nil {
+		return 0
+	}
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	all := make([]*Artifact, 0)
+	for _, items := range r.entries {
+		all = append(all, items...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].CreatedAt.Before(all[j].CreatedAt) })
+
+	removed := 0
+	var total int64
+	kept := all[:0]
+	for _, entry := range all {
+		refreshSize(entry)
+		if r.maxAge > 0 && now.Sub(entry.CreatedAt) > r.maxAge {
+			r.removeLocked(entry)
+			removed++
+			continue
+		}
+		total += entry.Size
+		kept = append(kept, entry)
+	}
+
+	if r.maxTotalBytes > 0 {
+		for i := 0; i < len(kept) && total > r.maxTotalBytes; i++ {
+			total -= kept[i].Size
+			r.removeLocked(kept[i])
+			removed++
+		}
+	}
+	return removed
+}
+
+// Prune removes the oldest artifacts of kind until at most keep remain and, when
+// maxBytes > 0, their total size is within maxBytes. Only files named with the kind
+// prefix are touched. A non-positive keep disables the count rule. It returns the
+// number of artifacts removed.
+func (r *Registry) Prune(kind string, keep int, maxBytes int64) int {
+	if r == nil {
+		return 0
+	}
+	kind = sanitizeComponent(kind)
+	if kind == "" {
+		return 0
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matched []*Artifact
+	for _, items := range r.entries {
+		for _, entry := range items {
+			if entry.Kind == kind && strings.HasPrefix(entry.Name, kind+"-") {
+				matched = append(matched, entry)
+			}
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].CreatedAt.Before(matched[j].CreatedAt) })
+
+	var total int64
+	for _, entry := range matched {
+		refreshSize(entry)
+		total += entry.Size
+	}
+	removed := 0
+	for _, entry := range matched {
+		overCount := keep > 0 && len(matched)-removed > keep
+		overSize := maxBytes > 0 && total > maxBytes
+		if !overCount && !overSize {
+			break
+		}
+		total -= entry.Size
+		r.removeLocked(entry)
+		removed++
+	}
+	return removed
+}
+
+// Start runs Sweep on the given interval until ctx is cancelled.
+func (r *Registry) Start(ctx context.Context, interval time.Duration) {
+	if r == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if removed := r.Sweep(); removed > 0 {
+					log.Debugf("artifacts: removed %d artifact(s) to enforce retention limits", removed)
+				}
+			}
+		}
+	}()
+}
+
+// removeLocked deletes the artifact file and drops it from the registry.
+// Callers must hold r.mu.
+func (r *Registry) removeLocked(target *Artifact) {
+	if err := os.Remove(target.Path); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Warnf("artifacts: failed to remove %s", target.Path)
+	}
+	items := r.entries[target.RequestID]
+	for i, entry := range items {
+		if entry == target {
+			items = append(items[:i], items[i+1:]...)
+			break
+		}
+	}
+	if len(items) == 0 {
+		delete(r.entries, target.RequestID)
+		_ = os.Remove(filepath.Join(r.root, target.RequestID))
+		return
+	}
+	r.entries[target.RequestID] = items
+}
+
+func (r *Registry) adoptExisting() {
+	dirs, err := os.ReadDir(r.root)
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		requestID := dir.Name()
+		if sanitizeComponent(requestID) != requestID {
+			continue
+		}
+		files, errRead := os.ReadDir(filepath.Join(r.root, requestID))
+		if errRead != nil {
+			continue
+		}
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			info, errInfo := file.Info()
+			if errInfo != nil {
+				continue
+			}
+			kind := file.Name()
+			if idx := strings.Index(kind, "-"); idx > 0 {
+				kind = kind[:idx]
+			}
+			r.entries[requestID] = append(r.entries[requestID], &Artifact{
+				RequestID: requestID,
+				Name:      file.Name(),
+				Kind:      kind,
+				Size:      info.Size(),
+				CreatedAt: info.ModTime(),
+				Path:      filepath.Join(r.root, requestID, file.Name()),
+			})
+		}
+	}
+}
+
+func refreshSize(entry *Artifact) {
+	if info, err := os.Stat(entry.Path); err == nil {
+		entry.Size = info.Size()
+	}
+}
+
+// sanitizeComponent keeps only characters that are safe in a single path element.
+func sanitizeComponent(raw string) string {
+	raw = strings.TrimSpace(raw)
+	var b strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func randomSuffix() string {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(buf)
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRegistry *Registry
+)
+
+// Default returns the process-wide registry. It is rooted at
+// $WRITABLE_PATH/artifacts (or the system temp dir when WRITABLE_PATH is unset)
+// and starts the background sweeper on first use.
+//
+// Limits can be tuned with ARTIFACTS_MAX_TOTAL_MB and ARTIFACTS_MAX_AGE_MINUTES.
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		base := util.WritablePath()
+		if base == "" {
+			base = filepath.Join(os.TempDir(), "cli-proxy-api")
+		}
+		maxTotal := DefaultMaxTotalBytes
+		if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv("ARTIFACTS_MAX_TOTAL_MB"))); err == nil && v >= 0 {
+			maxTotal = int64(v) * 1024 * 1024
+		}
+		maxAge := DefaultMaxAge
+		if v, err := strconv.Atoi(strings.TrimSpace(os.Getenv("ARTIFACTS_MAX_AGE_MINUTES"))); err == nil && v >= 0 {
+			maxAge = time.Duration(v) * time.Minute
+		}
+		defaultRegistry = NewRegistry(filepath.Join(base, dirName), maxTotal, maxAge)
+		defaultRegistry.Sweep()
+		defaultRegistry.Start(context.Background(), DefaultSweepInterval)
+	})
+	return defaultRegistry
+}
@@ -0,0 +1,170 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeArtifact(t *testing.T, r *Registry, requestID string, size int) string {
+	t.Helper()
+	path, err := r.Allocate(requestID, "netlog", ".json")
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0o600); err != nil {
+		t.Fatalf("write artifact: %v", err)
+	}
+	return path
+}
+
+func TestAllocate_PerRequestDirectory(t *testing.T) {
+	root := t.TempDir()
+	r := NewRegistry(root, 0, 0)
+
+	first := writeArtifact(t, r, "req-1", 10)
+	second := writeArtifact(t, r, "req-1", 10)
+	if first == second {
+		t.Fatalf("expected unique artifact paths, got %q twice", first)
+	}
+	if filepath.Dir(first) != filepath.Join(root, "req-1") {
+		t.Fatalf("artifact not placed under request dir: %q", first)
+	}
+
+	escaped, err := r.Allocate("../../etc", "netlog", ".json")
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if !strings.HasPrefix(escaped, root+string(os.PathSeparator)) {
+		t.Fatalf("artifact escaped root: %q", escaped)
+	}
+
+	items := r.List("req-1")
+	if len(items) != 2 {
+		t.Fatalf("List(req-1) = %d items, want 2", len(items))
+	}
+	if items[0].Size != 10 {
+		t.Fatalf("size = %d, want 10", items[0].Size)
+	}
+}
+
+func TestSweep_EnforcesTotalSize(t *testing.T) {
+	root := t.TempDir()
+	r := NewRegistry(root, 250, 0)
+	base := time.Now()
+	tick := 0
+	r.now = func() time.Time {
+		tick++
+		return base.Add(time.Duration(tick) * time.Second)
+	}
+
+	oldest := writeArtifact(t, r, "req-a", 100)
+	middle := writeArtifact(t, r, "req-b", 100)
+	newest := writeArtifact(t, r, "req-c", 100)
+
+	if removed := r.Sweep(); removed != 1 {
+		t.Fatalf("Sweep removed %d, want 1", removed)
+	}
+	if _, err := os.Stat(oldest); !os.IsNotExist(err) {
+		t.Fatalf("expected oldest artifact removed, stat err=%v", err)
+	}
+	for _, p := range []string{middle, newest} {
+		if _, err := os.Stat(p); err != nil {
+			t.Fatalf("expected %s kept: %v", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "req-a")); !os.IsNotExist(err) {
+		t.Fatalf("expected empty request dir removed, stat err=%v", err)
+	}
+	if got := len(r.List("")); got != 2 {
+		t.Fatalf("List() = %d items, want 2", got)
+	}
+}
+
+func TestSweep_EnforcesMaxAge(t *testing.T) {
+	root := t.TempDir()
+	r := NewRegistry(root, 0, time.Hour)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	stale := writeArtifact(t, r, "req-old", 5)
+	now = now.Add(2 * time.Hour)
+	fresh := writeArtifact(t, r, "req-new", 5)
+
+	if removed := r.Sweep(); removed != 1 {
+		t.Fatalf("Sweep removed %d, want 1", removed)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("expected stale artifact removed, stat err=%v", err)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Fatalf("expected fresh artifact kept: %v", err)
+	}
+}
+
+func TestNewRegistry_AdoptsExistingFiles(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "req-prev")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "netlog-old.json"), []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry(root, 0, 0)
+	item, ok := r.Lookup("req-prev", "netlog-old.json")
+	if !ok {
+		t.Fatal("expected leftover artifact to be adopted")
+	}
+	if item.Kind != "netlog" || item.Size != 2 {
+		t.Fatalf("unexpected adopted artifact: %+v", item)
+	}
+}
+
+func TestPrune_KeepsNewestOfKind(t *testing.T) {
+	root := t.TempDir()
+	r := NewRegistry(root, 0, 0)
+	base := time.Now()
+	tick := 0
+	r.now = func() time.Time {
+		tick++
+		return base.Add(time.Duration(tick) * time.Second)
+	}
+
+	var netlogs []string
+	for i := 0; i < 4; i++ {
+		netlogs = append(netlogs, writeArtifact(t, r, "req-a", 100))
+	}
+	capture, err := r.Allocate("req-a", "capture", ".bin")
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if err = os.WriteFile(capture, make([]byte, 1000), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	unrelated := filepath.Join(root, "req-a", "notes.txt")
+	if err = os.WriteFile(unrelated, []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if removed := r.Prune("netlog", 3, 0); removed != 1 {
+		t.Fatalf("Prune(keep=3) removed %d, want 1", removed)
+	}
+	if removed := r.Prune("netlog", 3, 150); removed != 2 {
+		t.Fatalf("Prune(maxBytes=150) removed %d, want 2", removed)
+	}
+	for i, p := range netlogs {
+		_, errStat := os.Stat(p)
+		if kept := errStat == nil; kept != (i == 3) {
+			t.Fatalf("netlog %d kept = %v, want only the newest kept", i, kept)
+		}
+	}
+	for _, p := range []string{capture, unrelated} {
+		if _, errStat := os.Stat(p); errStat != nil {
+			t.Fatalf("expected %s untouched: %v", p, errStat)
+		}
+	}
+}
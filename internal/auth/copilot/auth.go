@@ -356,6 +356,13 @@ type CopilotModel struct {
 	Preview            bool                `json:"preview"`
 	ModelPickerEnabled bool                `json:"model_picker_enabled"`
 	Capabilities       CopilotCapabilities `json:"capabilities"`
+	Billing            *CopilotBilling     `json:"billing,omitempty"`
+}
+
+// CopilotBilling describes how requests to a Copilot model count against premium requests.
+type CopilotBilling struct {
+	IsPremium  bool    `json:"is_premium"`
+	Multiplier float64 `json:"multiplier"`
 }
 
 // CopilotCapabilities describes the capabilities of a Copilot model.
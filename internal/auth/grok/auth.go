@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	log "github.com/sirupsen/logrus"
@@ -55,33 +56,29 @@ func (g *GrokAuth) CheckRateLimits(ctx context.Context, storage *GrokTokenStorag
 	data := resp.Bytes()
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusUnauthorized {
-			storage.FailedCount++
-			if storage.FailedCount >= MaxFailures {
-				storage.Status = "expired"
-			}
+			storage.RecordAuthFailure()
 		}
 		log.Warnf("grok auth: rate-limit check failed (status=%d token=%s model=%s): %s", resp.StatusCode, MaskToken(storage.SSOToken), model, summarizeBody(data))
 		return -1, fmt.Errorf("grok auth: rate-limit check failed with status %d", resp.StatusCode)
 	}
 
+	heavy := model == "grok-4-heavy" || strings.Contains(modelCfg.RateLimitModel, "heavy")
+	field := "remainingTokens"
+	if heavy {
+		field = "remainingQueries"
+	}
 	remaining := -1
-	if model == "grok-4-heavy" || strings.Contains(modelCfg.RateLimitModel, "heavy") {
-		val := gjson.GetBytes(data, "remainingQueries")
-		storage.HeavyRemainingQueries = -1
-		if val.Exists() {
-			storage.HeavyRemainingQueries = int(val.Int())
-		}
-		remaining = storage.HeavyRemainingQueries
-	} else {
-		val := gjson.GetBytes(data, "remainingTokens")
-		storage.RemainingQueries = -1
-		if val.Exists() {
-			storage.RemainingQueries = int(val.Int())
-		}
-		remaining = storage.RemainingQueries
+	if val := gjson.GetBytes(data, field); val.Exists() {
+		remaining = int(val.Int())
+	}
+	var resetAt time.Time
+	if window := gjson.GetBytes(data, "windowSizeSeconds").Int(); window > 0 {
+		resetAt = time.Now().Add(time.Duration(window) * time.Second)
 	}
+	storage.SetRemainingQueries(heavy, remaining, resetAt)
+	quota := storage.Quota()
 
-	log.Debugf("grok auth: rate-limit refresh ok (model=%s token=%s remaining=%d heavy=%d)", model, MaskToken(storage.SSOToken), storage.RemainingQueries, storage.HeavyRemainingQueries)
+	log.Debugf("grok auth: rate-limit refresh ok (model=%s token=%s remaining=%d heavy=%d)", model, MaskToken(storage.SSOToken), quota.RemainingQueries, quota.HeavyRemainingQueries)
 	return remaining, nil
 }
 
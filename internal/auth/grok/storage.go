@@ -3,6 +3,8 @@ package grok
 import (
 	"encoding/json"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
@@ -17,8 +19,77 @@ type GrokTokenStorage struct {
 	FailedCount           int    `json:"failed_count"`
 	RemainingQueries      int    `json:"remaining_queries"`
 	HeavyRemainingQueries int    `json:"heavy_remaining_queries"`
-	Note                  string `json:"note"`
-	Type                  string `json:"type"`
+	// QuotaResetAt is the RFC3339 time the rate-limit window of the last check ends.
+	QuotaResetAt string `json:"quota_reset_at,omitempty"`
+	Note         string `json:"note"`
+	Type         string `json:"type"`
+
+	// mu guards the status and quota fields, which executors update while the
+	// management API reads them.
+	mu sync.Mutex
+}
+
+// GrokQuota is a snapshot of the rate-limit state of a Grok token.
+type GrokQuota struct {
+	Status                string `json:"status,omitempty"`
+	FailedCount           int    `json:"failed_count"`
+	RemainingQueries      int    `json:"remaining_queries"`
+	HeavyRemainingQueries int    `json:"heavy_remaining_queries"`
+	QuotaResetAt          string `json:"quota_reset_at,omitempty"`
+}
+
+// Quota returns the current rate-limit state.
+func (g *GrokTokenStorage) Quota() GrokQuota {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return GrokQuota{
+		Status:                g.Status,
+		FailedCount:           g.FailedCount,
+		RemainingQueries:      g.RemainingQueries,
+		HeavyRemainingQueries: g.HeavyRemainingQueries,
+		QuotaResetAt:          g.QuotaResetAt,
+	}
+}
+
+// Expired reports whether the token was marked expired after repeated auth failures.
+func (g *GrokTokenStorage) Expired() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.Status == "expired"
+}
+
+// RecordAuthFailure counts a rejected token, marking it expired after MaxFailures, and
+// returns the new failure count.
+func (g *GrokTokenStorage) RecordAuthFailure() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.FailedCount++
+	if g.FailedCount >= MaxFailures {
+		g.Status = "expired"
+	}
+	return g.FailedCount
+}
+
+// ResetFailures clears the failure count after an accepted request.
+func (g *GrokTokenStorage) ResetFailures() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.FailedCount = 0
+}
+
+// SetRemainingQueries records the result of a rate-limit check: the remaining queries
+// (-1 when unknown) for the heavy or regular bucket, and the end of the window when known.
+func (g *GrokTokenStorage) SetRemainingQueries(heavy bool, remaining int, resetAt time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if heavy {
+		g.HeavyRemainingQueries = remaining
+	} else {
+		g.RemainingQueries = remaining
+	}
+	if !resetAt.IsZero() {
+		g.QuotaResetAt = resetAt.UTC().Format(time.RFC3339)
+	}
 }
 
 // SaveTokenToFile persists Grok token data to the provided path.
@@ -28,7 +99,9 @@ func (g *GrokTokenStorage) SaveTokenToFile(authFilePath string) error {
 	g.Type = "grok"
 	g.SSOToken = NormalizeSSOToken(g.SSOToken)
 
+	g.mu.Lock()
 	data, err := json.MarshalIndent(g, "", "  ")
+	g.mu.Unlock()
 	if err != nil {
 		return fmt.Errorf("failed to marshal grok token: %w", err)
 	}
@@ -0,0 +1,181 @@
+// Package clockskew estimates the offset between the local clock and upstream provider
+// clocks from the Date header of upstream responses.
+//
+// Token expiry checks and Retry-After date parsing assume synchronized clocks; a large
+// skew makes them fire too early or too late, so the estimate is exposed for operators
+// and a warning is logged when it crosses a threshold.
+package clockskew
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultWarnThreshold is the absolute skew above which a warning is logged.
+const DefaultWarnThreshold = 30 * time.Second
+
+// smoothing is the weight of a new sample in the rolling (exponentially weighted) estimate.
+const smoothing = 0.2
+
+// Estimate is the rolling skew estimate for one provider. Skew is upstream minus local
+// time, so a positive value means the upstream clock is ahead.
+type Estimate struct {
+	Provider     string    `json:"provider"`
+	SkewMs       int64     `json:"skew_ms"`
+	LastSampleMs int64     `json:"last_sample_ms"`
+	Samples      int64     `json:"samples"`
+	LastObserved time.Time `json:"last_observed"`
+	Exceeded     bool      `json:"exceeded"`
+}
+
+// Tracker keeps per-provider skew estimates. It is safe for concurrent use.
+type Tracker struct {
+	mu        sync.Mutex
+	threshold time.Duration
+	estimates map[string]*estimateState
+}
+
+type estimateState struct {
+	skew         float64 // milliseconds
+	last         int64
+	samples      int64
+	lastObserved time.Time
+	warned       bool
+}
+
+var defaultTracker = NewTracker(DefaultWarnThreshold)
+
+// Default returns the process-wide tracker fed by upstream executors.
+func Default() *Tracker { return defaultTracker }
+
+// NewTracker returns an empty tracker that warns above threshold (DefaultWarnThreshold
+// when threshold is not positive).
+func NewTracker(threshold time.Duration) *Tracker {
+	if threshold <= 0 {
+		threshold = DefaultWarnThreshold
+	}
+	return &Tracker{threshold: threshold, estimates: make(map[string]*estimateState)}
+}
+
+// Threshold reports the warning threshold.
+func (t *Tracker) Threshold() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.threshold
+}
+
+// ObserveHeader records a skew sample from the Date header of a response received at
+// local. Responses without a parsable Date header are ignored.
+func (t *Tracker) ObserveHeader(provider string, header http.Header, local time.Time) {
+	if t == nil || header == nil {
+		return
+	}
+	raw := strings.TrimSpace(header.Get("Date"))
+	if raw == "" {
+		return
+	}
+	upstream, err := http.ParseTime(raw)
+	if err != nil {
+		return
+	}
+	// Date has one-second resolution; compare against the middle of that second.
+	t.Observe(provider, upstream.Add(500*time.Millisecond).Sub(local))
+}
+
+// Observe folds a skew sample (upstream minus local time) into the provider estimate.
+func (t *Tracker) Observe(provider string, skew time.Duration) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if t == nil || provider == "" {
+		return
+	}
+	sample := float64(skew.Milliseconds())
+	t.mu.Lock()
+	state, ok := t.estimates[provider]
+	if !ok {
+		state = &estimateState{skew: sample}
+		t.estimates[provider] = state
+	} else {
+		state.skew += smoothing * (sample - state.skew)
+	}
+	state.last = skew.Milliseconds()
+	state.samples++
+	state.lastObserved = time.Now()
+	current := time.Duration(state.skew) * time.Millisecond
+	exceeded := current > t.threshold || current < -t.threshold
+	warn := exceeded && !state.warned
+	recovered := !exceeded && state.warned
+	state.warned = exceeded
+	t.mu.Unlock()
+
+	if warn {
+		log.Warnf("clock skew: %s clock differs from local time by %s (threshold %s); token expiry and retry-after handling may be inaccurate", provider, current, t.threshold)
+	} else if recovered {
+		log.Infof("clock skew: %s clock is back within %s of local time (%s)", provider, t.threshold, current)
+	}
+}
+
+// Estimate returns the current estimate for provider.
+func (t *Tracker) Estimate(provider string) (Estimate, bool) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state, ok := t.estimates[provider]
+	if !ok {
+		return Estimate{}, false
+	}
+	return state.snapshot(provider), true
+}
+
+// Snapshot returns every provider estimate ordered by provider name.
+func (t *Tracker) Snapshot() []Estimate {
+	t.mu.Lock()
+	out := make([]Estimate, 0, len(t.estimates))
+	for provider, state := range t.estimates {
+		out = append(out, state.snapshot(provider))
+	}
+	t.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
+
+func (s *estimateState) snapshot(provider string) Estimate {
+	return Estimate{
+		Provider:     provider,
+		SkewMs:       int64(s.skew),
+		LastSampleMs: s.last,
+		Samples:      s.samples,
+		LastObserved: s.lastObserved,
+		Exceeded:     s.warned,
+	}
+}
+
+// Transport records the Date header of every response into a tracker under a fixed
+// provider name.
+type Transport struct {
+	Base     http.RoundTripper
+	Provider string
+	Tracker  *Tracker
+}
+
+// NewTransport wraps base (http.DefaultTransport when nil) so responses feed the default tracker.
+func NewTransport(base http.RoundTripper, provider string) *Transport {
+	return &Transport{Base: base, Provider: provider, Tracker: defaultTracker}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err == nil && resp != nil {
+		t.Tracker.ObserveHeader(t.Provider, resp.Header, time.Now())
+	}
+	return resp, err
+}
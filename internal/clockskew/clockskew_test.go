@@ -0,0 +1,53 @@
+package clockskew
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTransport_SkewedDateHeaderUpdatesEstimate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Date", time.Now().Add(2*time.Minute).UTC().Format(http.TimeFormat))
+	}))
+	defer srv.Close()
+
+	tracker := NewTracker(30 * time.Second)
+	client := &http.Client{Transport: &Transport{Provider: "Codex", Tracker: tracker}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	est, ok := tracker.Estimate("codex")
+	if !ok {
+		t.Fatalf("no estimate recorded")
+	}
+	if est.Samples != 1 || est.SkewMs < 118_000 || est.SkewMs > 122_000 {
+		t.Fatalf("estimate = %+v, want ~120000ms after one sample", est)
+	}
+	if !est.Exceeded {
+		t.Fatalf("estimate should exceed the 30s threshold: %+v", est)
+	}
+}
+
+func TestTracker_RollingEstimate(t *testing.T) {
+	tracker := NewTracker(time.Minute)
+	tracker.Observe("claude", 10*time.Second)
+	tracker.Observe("claude", 0)
+	est, _ := tracker.Estimate("claude")
+	if est.Samples != 2 || est.SkewMs != 8000 || est.LastSampleMs != 0 {
+		t.Fatalf("estimate = %+v, want smoothed 8000ms", est)
+	}
+
+	tracker.ObserveHeader("claude", http.Header{"Date": []string{"not a date"}}, time.Now())
+	tracker.ObserveHeader("claude", http.Header{}, time.Now())
+	if est, _ = tracker.Estimate("claude"); est.Samples != 2 {
+		t.Fatalf("unparsable or missing Date headers must be ignored: %+v", est)
+	}
+	if snap := tracker.Snapshot(); len(snap) != 1 || snap[0].Provider != "claude" {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+}
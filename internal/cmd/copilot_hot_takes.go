@@ -11,18 +11,99 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
+	"golang.org/x/sync/singleflight"
 )
 
 const (
 	hnTopStoriesURL = "https://hacker-news.firebaseio.com/v0/topstories.json"
 	hnItemURLFmt    = "https://hacker-news.firebaseio.com/v0/item/%d.json"
+
+	// hnTitleCacheTTL bounds how long fetched HN titles are reused across runs.
+	hnTitleCacheTTL = 30 * time.Minute
 )
 
+// hnTitles caches HN titles so overlapping or near-in-time hot-takes runs
+// (e.g. an admin-triggered run racing a scheduled one) reuse earlier fetches.
+var hnTitles = newHNTitleCache(hnTitleCacheTTL)
+
+type hnTitleEntry struct {
+	title   string
+	expires time.Time
+}
+
+// hnTitleCache is a TTL cache of HN item titles keyed by item ID. Concurrent
+// lookups of the same uncached ID share a single fetch.
+type hnTitleCache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	group   singleflight.Group
+	mu      sync.Mutex
+	entries map[int64]hnTitleEntry
+}
+
+func newHNTitleCache(ttl time.Duration) *hnTitleCache {
+	return &hnTitleCache{ttl: ttl, now: time.Now, entries: make(map[int64]hnTitleEntry)}
+}
+
+// get returns the cached title for id or calls fetch to load it. Only successful
+// fetches are cached. A shared fetch is detached from any single caller's context,
+// so one caller giving up does not fail the others; each caller still stops
+// waiting as soon as its own ctx is done.
+func (c *hnTitleCache) get(ctx context.Context, id int64, fetch func(context.Context) (string, error)) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	now := c.now()
+	c.mu.Lock()
+	entry, ok := c.entries[id]
+	if ok && now.After(entry.expires) {
+		delete(c.entries, id)
+		ok = false
+	}
+	c.mu.Unlock()
+	if ok {
+		return entry.title, nil
+	}
+
+	ch := c.group.DoChan(strconv.FormatInt(id, 10), func() (any, error) {
+		title, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			return "", err
+		}
+		c.mu.Lock()
+		c.entries[id] = hnTitleEntry{title: title, expires: c.now().Add(c.ttl)}
+		c.pruneLocked(c.now())
+		c.mu.Unlock()
+		return title, nil
+	})
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return "", res.Err
+		}
+		return res.Val.(string), nil
+	}
+}
+
+// pruneLocked drops expired entries so the cache stays bounded by what one TTL of
+// runs can fetch. Callers must hold c.mu.
+func (c *hnTitleCache) pruneLocked(now time.Time) {
+	for id, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, id)
+		}
+	}
+}
+
 func hotTakesInterval() (time.Duration, bool) {
 	raw := strings.TrimSpace(os.Getenv("COPILOT_HOT_TAKES_INTERVAL_MINS"))
 	if raw == "" {
@@ -46,12 +127,37 @@ func hotTakesModel() string {
 		raw = "claude-haiku-4.5"
 	}
 	raw = strings.TrimSpace(raw)
-	if !strings.HasPrefix(strings.ToLower(raw), "copilot-") {
+	if hotTakesNeedsCopilotPrefix(raw) {
 		raw = "copilot-" + raw
 	}
 	return raw
 }
 
+// hotTakesProviderPrefixes are model ID prefixes that already select a provider.
+var hotTakesProviderPrefixes = []string{"copilot-", "codex-"}
+
+// hotTakesNeedsCopilotPrefix reports whether model should be routed to Copilot by
+// prefixing it. Models that already carry a provider prefix, use a "prefix/model"
+// credential route, or are registered under their exact name are left alone so
+// hot takes can target other providers; COPILOT_HOT_TAKES_NO_PREFIX turns the
+// prefixing off entirely.
+func hotTakesNeedsCopilotPrefix(model string) bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("COPILOT_HOT_TAKES_NO_PREFIX"))) {
+	case "1", "true", "yes", "on":
+		return false
+	}
+	lower := strings.ToLower(model)
+	for _, prefix := range hotTakesProviderPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return false
+		}
+	}
+	if strings.Contains(model, "/") {
+		return false
+	}
+	return len(registry.GetGlobalRegistry().GetModelProviders(model)) == 0
+}
+
 func pickRandomUnique(ids []int64, n int) []int64 {
 	if n <= 0 || len(ids) == 0 {
 		return nil
@@ -152,8 +258,13 @@ func doCopilotHotTakesOnce(ctx context.Context, cfg *config.Config) error {
 	shuffled := pickRandomUnique(ids, len(ids))
 	titles := make([]string, 0, 7)
 	for _, id := range shuffled {
-		title, err := fetchHNTitle(ctx, hnClient, id)
+		title, err := hnTitles.get(ctx, id, func(fetchCtx context.Context) (string, error) {
+			return fetchHNTitle(fetchCtx, hnClient, id)
+		})
 		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			log.Debugf("copilot hot takes: skip HN item %d: %v", id, err)
 			continue
 		}
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+func TestHNTitleCache_ReusesFetchWithinTTL(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache := newHNTitleCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	var fetches atomic.Int32
+	fetch := func(context.Context) (string, error) {
+		fetches.Add(1)
+		return "Show HN: a title", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		title, err := cache.get(context.Background(), 42, fetch)
+		if err != nil || title != "Show HN: a title" {
+			t.Fatalf("get #%d = %q, %v", i+1, title, err)
+		}
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("fetches within TTL = %d, want 1", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, err := cache.get(context.Background(), 42, fetch); err != nil {
+		t.Fatalf("get after TTL: %v", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Fatalf("fetches after TTL = %d, want 2", got)
+	}
+}
+
+func TestHNTitleCache_SharesConcurrentFetchAndSkipsErrors(t *testing.T) {
+	cache := newHNTitleCache(time.Minute)
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	fetch := func(context.Context) (string, error) {
+		fetches.Add(1)
+		<-release
+		return "shared", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if title, err := cache.get(context.Background(), 7, fetch); err != nil || title != "shared" {
+				t.Errorf("get = %q, %v", title, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if got := fetches.Load(); got != 1 {
+		t.Fatalf("concurrent fetches = %d, want 1", got)
+	}
+
+	failing := func(context.Context) (string, error) {
+		fetches.Add(1)
+		return "", errors.New("boom")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := cache.get(context.Background(), 8, failing); err == nil {
+			t.Fatal("expected fetch error")
+		}
+	}
+	if got := fetches.Load(); got != 3 {
+		t.Fatalf("failed fetches should not be cached: total fetches = %d, want 3", got)
+	}
+}
+
+func TestHNTitleCache_RespectsContextCancellation(t *testing.T) {
+	cache := newHNTitleCache(time.Minute)
+	block := make(chan struct{})
+	defer close(block)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.get(ctx, 9, func(context.Context) (string, error) {
+			<-block
+			return "late", nil
+		})
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("get error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("get did not return after its context was canceled")
+	}
+
+	if _, err := cache.get(ctx, 10, func(context.Context) (string, error) { return "x", nil }); !errors.Is(err, context.Canceled) {
+		t.Fatalf("get with canceled ctx = %v, want context.Canceled", err)
+	}
+}
+
+func TestHotTakesModel_Prefixing(t *testing.T) {
+	cases := []struct {
+		name     string
+		model    string
+		noPrefix string
+		want     string
+	}{
+		{name: "bare model gets copilot prefix", model: "claude-haiku-4.5", want: "copilot-claude-haiku-4.5"},
+		{name: "default model gets copilot prefix", want: "copilot-claude-haiku-4.5"},
+		{name: "copilot prefix kept", model: "copilot-gpt-4o", want: "copilot-gpt-4o"},
+		{name: "other provider prefix kept", model: "codex-gpt-5", want: "codex-gpt-5"},
+		{name: "credential route kept", model: "local/gpt-4o", want: "local/gpt-4o"},
+		{name: "no-prefix flag", model: "gpt-4o", noPrefix: "true", want: "gpt-4o"},
+		{name: "no-prefix flag off", model: "gpt-4o", noPrefix: "0", want: "copilot-gpt-4o"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("COPILOT_HOT_TAKES_MODEL", tc.model)
+			t.Setenv("COPILOT_HOT_TAKES_MOEL", "")
+			t.Setenv("COPILOT_HOT_TAKES_NO_PREFIX", tc.noPrefix)
+			if got := hotTakesModel(); got != tc.want {
+				t.Fatalf("hotTakesModel() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHotTakesModel_RegisteredModelUnprefixed(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("hot-takes-test-client", "openai-compatibility", []*registry.ModelInfo{{ID: "hot-takes-local-model"}})
+	t.Cleanup(func() { reg.UnregisterClient("hot-takes-test-client") })
+
+	t.Setenv("COPILOT_HOT_TAKES_MODEL", "hot-takes-local-model")
+	t.Setenv("COPILOT_HOT_TAKES_NO_PREFIX", "")
+	if got := hotTakesModel(); got != "hot-takes-local-model" {
+		t.Fatalf("hotTakesModel() = %q, want the registered model unprefixed", got)
+	}
+}
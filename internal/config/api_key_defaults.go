@@ -0,0 +1,41 @@
+package config
+
+import "strings"
+
+// APIKeyDefaults steers one client API key: a default model substituted for missing or
+// placeholder model names, and sampling parameters always set on its requests.
+type APIKeyDefaults struct {
+	// APIKey is the client API key the entry applies to.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// DefaultModel replaces the request model when it is missing or matches
+	// PlaceholderModels.
+	DefaultModel string `yaml:"default-model,omitempty" json:"default-model,omitempty"`
+
+	// PlaceholderModels lists model names ('*' matches any substring, case-insensitive)
+	// that clients send because they cannot be configured, e.g. "gpt-4".
+	PlaceholderModels []string `yaml:"placeholder-models,omitempty" json:"placeholder-models,omitempty"`
+
+	// ForceModel replaces every request model with DefaultModel.
+	ForceModel bool `yaml:"force-model,omitempty" json:"force-model,omitempty"`
+
+	// Temperature, when set, overrides the request temperature.
+	Temperature *float64 `yaml:"temperature,omitempty" json:"temperature,omitempty"`
+
+	// MaxTokens, when > 0, overrides the request output token limit.
+	MaxTokens int `yaml:"max-tokens,omitempty" json:"max-tokens,omitempty"`
+}
+
+// APIKeyDefaultsFor returns the api-key-defaults entry for the client API key, or nil.
+func (c *SDKConfig) APIKeyDefaultsFor(apiKey string) *APIKeyDefaults {
+	apiKey = strings.TrimSpace(apiKey)
+	if c == nil || apiKey == "" {
+		return nil
+	}
+	for i := range c.APIKeyDefaults {
+		if strings.TrimSpace(c.APIKeyDefaults[i].APIKey) == apiKey {
+			return &c.APIKeyDefaults[i]
+		}
+	}
+	return nil
+}
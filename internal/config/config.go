@@ -44,6 +44,10 @@ type Config struct {
 	// AuthDir is the directory where authentication token files are stored.
 	AuthDir string `yaml:"auth-dir" json:"-"`
 
+	// FixAuthFilePermissions chmods credential files in AuthDir that group or other users can
+	// read to 0600 at startup. When false (default) such files are only reported.
+	FixAuthFilePermissions bool `yaml:"fix-auth-file-permissions,omitempty" json:"fix-auth-file-permissions,omitempty"`
+
 	// Debug enables or disables debug-level logging and other debug features.
 	Debug bool `yaml:"debug" json:"debug"`
 
@@ -64,9 +68,26 @@ type Config struct {
 	// When exceeded, the oldest error log files are deleted. Default is 10. Set to 0 to disable cleanup.
 	ErrorLogsMaxFiles int `yaml:"error-logs-max-files" json:"error-logs-max-files"`
 
+	// LogBufferSize keeps the most recent N application log entries in memory so they can be
+	// retrieved through the management API. Set to 0 (default) to disable.
+	LogBufferSize int `yaml:"log-buffer-size,omitempty" json:"log-buffer-size,omitempty"`
+
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// Persistence selects the backend for state that should survive restarts (usage statistics and
+	// budget spend): "json" for per-feature JSON files, "sqlite" for one shared
+	// embedded database. Empty (default) keeps that state in memory only.
+	Persistence string `yaml:"persistence,omitempty" json:"persistence,omitempty"`
+
+	// PersistencePath is the JSON directory or SQLite database file. Relative paths resolve
+	// against WRITABLE_PATH (or the config directory) and must stay inside it.
+	PersistencePath string `yaml:"persistence-path,omitempty" json:"persistence-path,omitempty"`
+
+	// TimingHeader adds an X-Cliproxy-Timing response header with the per-phase
+	// timing breakdown (Server-Timing syntax) to AI API responses.
+	TimingHeader bool `yaml:"timing-header" json:"timing-header"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
@@ -153,9 +174,224 @@ type Config struct {
 	// from your current session. Default: false.
 	IncognitoBrowser bool `yaml:"incognito-browser" json:"incognito-browser"`
 
+	// Audit configures the credential-use audit trail (which auth served which request).
+	Audit AuditConfig `yaml:"audit" json:"audit"`
+
+	// MaintenanceWindows pause background credential refreshes for a provider on a recurring schedule.
+	MaintenanceWindows []MaintenanceWindow `yaml:"maintenance-windows,omitempty" json:"maintenance-windows,omitempty"`
+
+	// ModelPricing lists per-model token prices used to estimate spend for usage budgets,
+	// the per-request cost in usage statistics and the X-Estimated-Cost-USD header.
+	ModelPricing []ModelPrice `yaml:"model-pricing,omitempty" json:"model-pricing,omitempty"`
+
+	// CopilotPremiumRequestUSD is the USD price of one Copilot premium request. Copilot
+	// traffic is costed as premium requests times this price instead of by tokens.
+	// Defaults to DefaultCopilotPremiumRequestUSD when zero.
+	CopilotPremiumRequestUSD float64 `yaml:"copilot-premium-request-usd,omitempty" json:"copilot-premium-request-usd,omitempty"`
+
+	// CopilotElectronPoolSize keeps this many warm Electron shim processes (1-16) serving
+	// Copilot requests instead of spawning one per request. 0 disables the pool. The
+	// COPILOT_ELECTRON_POOL_SIZE environment variable takes precedence.
+	CopilotElectronPoolSize int `yaml:"copilot-electron-pool-size,omitempty" json:"copilot-electron-pool-size,omitempty"`
+
+	// CopilotElectronExtraArgs are Chromium switches ("--name" or "--name=value") appended to
+	// the Electron shim command line. The COPILOT_ELECTRON_EXTRA_ARGS environment variable
+	// takes precedence.
+	CopilotElectronExtraArgs []string `yaml:"copilot-electron-extra-args,omitempty" json:"copilot-electron-extra-args,omitempty"`
+
+	// CopilotTransportTelemetry attaches the transport diagnostics of non-streaming Copilot
+	// responses (transport, Electron attempt, resolved proxy, header latency, Chromium
+	// version) to the client response as a "cliproxy_transport" object and headers.
+	CopilotTransportTelemetry bool `yaml:"copilot-transport-telemetry,omitempty" json:"copilot-transport-telemetry,omitempty"`
+
+	// UpstreamTLS overrides upstream certificate verification per provider (e.g. "codex" or
+	// an openai-compatibility entry name), with a custom CA or, loudly, no verification at all. There is
+	// no global default.
+	UpstreamTLS map[string]UpstreamTLS `yaml:"upstream-tls,omitempty" json:"upstream-tls,omitempty"`
+
+	// TLSMinVersion is the lowest TLS version negotiated with upstreams on the Go transport:
+	// "1.2" (default) or "1.3". The Electron transport follows Chromium's own policy.
+	TLSMinVersion string `yaml:"tls-min-version,omitempty" json:"tls-min-version,omitempty"`
+
+	// MaxConnsPerHost caps the simultaneous connections to each upstream host per provider
+	// (e.g. "codex" or an openai-compatibility entry name) on the Go transport. Requests
+	// beyond the cap wait for a free connection instead of opening a new one.
+	MaxConnsPerHost map[string]int `yaml:"max-conns-per-host,omitempty" json:"max-conns-per-host,omitempty"`
+
+	// DNSRetry retries failed DNS lookups of upstream hosts on the direct Go transport.
+	// Proxied requests resolve at the proxy and are not affected.
+	DNSRetry DNSRetryConfig `yaml:"dns-retry,omitempty" json:"dns-retry,omitempty"`
+
+	// ModelsOverrideFile is the path of a YAML file overriding model metadata per provider,
+	// such as Copilot premium request multipliers. See ModelsOverride.
+	ModelsOverrideFile string `yaml:"models-override-file,omitempty" json:"models-override-file,omitempty"`
+
+	// UsageBudgets configures monthly spend budgets, projection alerts, and optional hard caps.
+	UsageBudgets UsageBudgetConfig `yaml:"usage-budgets,omitempty" json:"usage-budgets,omitempty"`
+
+	// Codex holds settings of the Codex executor.
+	Codex CodexConfig `yaml:"codex,omitempty" json:"codex,omitempty"`
+
+	// CodexExposeAliases lists a model entry for every Codex effort alias, including
+	// codex.aliases, when a Codex auth registers its models.
+	CodexExposeAliases bool `yaml:"codex-expose-aliases,omitempty" json:"codex-expose-aliases,omitempty"`
+
+	// CodexCache bounds the in-process cache of Codex prompt cache IDs.
+	CodexCache CodexCacheConfig `yaml:"codex-cache,omitempty" json:"codex-cache,omitempty"`
+
+	// UnsafeFaultInjection makes upstream calls fail on purpose for chaos testing. Never enable
+	// it in production.
+	UnsafeFaultInjection FaultInjection `yaml:"unsafe-fault-injection,omitempty" json:"unsafe-fault-injection,omitempty"`
+
 	legacyMigrationPending bool `yaml:"-" json:"-"`
 }
 
+// CodexConfig holds settings of the Codex executor.
+type CodexConfig struct {
+	// ReasoningEffort maps a model name (case-insensitive) to the reasoning.effort sent
+	// upstream when the client sets none itself. Effort aliases such as "gpt-5-codex-low",
+	// thinking suffixes and an effort in the request body take precedence.
+	ReasoningEffort map[string]string `yaml:"reasoning-effort,omitempty" json:"reasoning-effort,omitempty"`
+
+	// Aliases adds effort aliases: a model named "<base>-<effort>" is sent upstream as base
+	// with that reasoning.effort. An entry replaces the built-in efforts of the same base,
+	// so new model families work without a release.
+	Aliases []CodexAlias `yaml:"aliases,omitempty" json:"aliases,omitempty"`
+}
+
+// CodexAlias declares a Codex base model and the reasoning efforts usable as its suffix.
+type CodexAlias struct {
+	Base    string   `yaml:"base" json:"base"`
+	Efforts []string `yaml:"efforts" json:"efforts"`
+}
+
+// SanitizeCodexAliases trims and lower-cases the codex.aliases efforts and drops entries
+// without a base or any effort.
+func (cfg *Config) SanitizeCodexAliases() {
+	if cfg == nil || len(cfg.Codex.Aliases) == 0 {
+		return
+	}
+	out := cfg.Codex.Aliases[:0]
+	for _, alias := range cfg.Codex.Aliases {
+		alias.Base = strings.TrimSpace(alias.Base)
+		efforts := make([]string, 0, len(alias.Efforts))
+		for _, effort := range alias.Efforts {
+			if effort = strings.ToLower(strings.TrimSpace(effort)); effort != "" {
+				efforts = append(efforts, effort)
+			}
+		}
+		if alias.Base == "" || len(efforts) == 0 {
+			continue
+		}
+		alias.Efforts = efforts
+		out = append(out, alias)
+	}
+	cfg.Codex.Aliases = out
+}
+
+// SanitizeCodexReasoningEffort trims the codex.reasoning-effort model names, lower-cases
+// their efforts and drops entries missing either.
+func (cfg *Config) SanitizeCodexReasoningEffort() {
+	if cfg == nil || len(cfg.Codex.ReasoningEffort) == 0 {
+		return
+	}
+	out := make(map[string]string, len(cfg.Codex.ReasoningEffort))
+	for model, effort := range cfg.Codex.ReasoningEffort {
+		model = strings.TrimSpace(model)
+		effort = strings.ToLower(strings.TrimSpace(effort))
+		if model == "" || effort == "" {
+			continue
+		}
+		out[model] = effort
+	}
+	cfg.Codex.ReasoningEffort = out
+}
+
+// CodexCacheConfig bounds the cache of Codex prompt cache IDs keyed by model and user.
+type CodexCacheConfig struct {
+	// MaxEntries caps the entries kept; the least recently used one is evicted beyond it.
+	// Defaults to 10000 when <= 0.
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+
+	// TTL is how long an entry is kept (Go duration syntax, e.g. "2h"). Defaults to 1h.
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+}
+
+// AuditConfig configures where credential-use audit records are written.
+// Records are redacted: they never include tokens or inbound API keys.
+type AuditConfig struct {
+	// File is the path of an append-only JSON Lines audit log. Empty disables the file sink.
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+
+	// WebhookURL receives each audit record as a JSON POST. Empty disables the webhook sink.
+	WebhookURL string `yaml:"webhook-url,omitempty" json:"webhook-url,omitempty"`
+}
+
+// MaintenanceWindow describes a recurring provider maintenance window.
+// While a window is active, background token refreshes for the provider are skipped;
+// user traffic keeps flowing unless Drain is set.
+type MaintenanceWindow struct {
+	// Provider is the provider key the window applies to (e.g. "copilot", "codex").
+	Provider string `yaml:"provider" json:"provider"`
+
+	// Schedule is a five-field cron expression (minute hour day-of-month month day-of-week),
+	// evaluated in UTC, that marks when each window starts.
+	Schedule string `yaml:"schedule" json:"schedule"`
+
+	// Duration is how long each window lasts (Go duration syntax, e.g. "30m", "2h").
+	Duration string `yaml:"duration" json:"duration"`
+
+	// Drain also rejects user requests routed to the provider while the window is active.
+	Drain bool `yaml:"drain,omitempty" json:"drain,omitempty"`
+}
+
+// ModelPrice is the USD price of a model per one million tokens.
+type ModelPrice struct {
+	// Model is the model name. A trailing "*" matches any model with that prefix.
+	Model string `yaml:"model" json:"model"`
+
+	// Input is the price of prompt tokens.
+	Input float64 `yaml:"input" json:"input"`
+
+	// Output is the price of completion tokens; reasoning tokens are billed at this rate.
+	Output float64 `yaml:"output" json:"output"`
+
+	// CachedInput is the price of cached prompt tokens. Zero bills them at the Input rate.
+	CachedInput float64 `yaml:"cached-input,omitempty" json:"cached-input,omitempty"`
+}
+
+// UsageBudgetConfig configures monthly spend budgets evaluated against recorded usage.
+type UsageBudgetConfig struct {
+	// Thresholds are the percentages of a budget at which projected spend raises an alert.
+	// Defaults to 50, 80 and 100 when empty.
+	Thresholds []float64 `yaml:"thresholds,omitempty" json:"thresholds,omitempty"`
+
+	// WebhookURL receives each budget alert as a JSON POST. Alerts are always logged.
+	WebhookURL string `yaml:"webhook-url,omitempty" json:"webhook-url,omitempty"`
+
+	// Budgets lists the monthly budgets. Each entry targets a provider, an API key or a tenant.
+	Budgets []UsageBudget `yaml:"budgets,omitempty" json:"budgets,omitempty"`
+}
+
+// UsageBudget is a monthly (UTC calendar month) spend budget in USD.
+type UsageBudget struct {
+	// Provider limits the budget to requests served by this provider.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+
+	// APIKey limits the budget to requests made with this client API key.
+	APIKey string `yaml:"api-key,omitempty" json:"api-key,omitempty"`
+
+	// Tenant limits the budget to requests made with API keys of this tenant.
+	Tenant string `yaml:"tenant,omitempty" json:"tenant,omitempty"`
+
+	// MonthlyUSD is the budget amount.
+	MonthlyUSD float64 `yaml:"monthly-usd" json:"monthly-usd"`
+
+	// HardCap rejects further requests for the provider, API key or tenant once actual
+	// month-to-date spend reaches the budget.
+	HardCap bool `yaml:"hard-cap,omitempty" json:"hard-cap,omitempty"`
+}
+
 // PassthruRoute maps a local model name to an upstream provider endpoint.
 // These routes synthesize runtime Auth entries (like other config API keys),
 // so they participate in normal selection, retries, proxies, and logging.
@@ -390,6 +626,19 @@ type PayloadConfig struct {
 	OverrideRaw []PayloadRule `yaml:"override-raw" json:"override-raw"`
 	// Filter defines rules that remove parameters from the payload by JSON path.
 	Filter []PayloadFilterRule `yaml:"filter" json:"filter"`
+	// Metadata defines rules that copy client-supplied metadata into upstream fields.
+	Metadata []PayloadMetadataRule `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+}
+
+// PayloadMetadataRule maps keys of the client request "metadata" object onto upstream
+// payload fields for matching models, e.g. metadata.session -> safety_identifier.
+type PayloadMetadataRule struct {
+	// Models lists model entries with name pattern and protocol constraint.
+	Models []PayloadModelRule `yaml:"models" json:"models"`
+	// Map maps metadata keys (gjson paths below "metadata") to JSON paths in the upstream
+	// payload. A field is only filled when the client sent the key and the payload does not
+	// already set the field.
+	Map map[string]string `yaml:"map" json:"map"`
 }
 
 // PayloadFilterRule describes a rule to remove specific JSON paths from matching model payloads.
@@ -505,6 +754,14 @@ type CodexKey struct {
 	// If empty, the default Codex API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
 
+	// BasePath is a path prefix joined between BaseURL and /responses, for gateways that
+	// mount the API below the host root (e.g. "/openai/v1").
+	BasePath string `yaml:"base-path,omitempty" json:"base-path,omitempty"`
+
+	// ResponsesURL is the full URL of the Responses endpoint; it overrides BaseURL and
+	// BasePath for requests.
+	ResponsesURL string `yaml:"responses-url,omitempty" json:"responses-url,omitempty"`
+
 	// Websockets enables the Responses API websocket transport for this credential.
 	Websockets bool `yaml:"websockets,omitempty" json:"websockets,omitempty"`
 
@@ -1020,6 +1277,19 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Validate raw payload rules and drop invalid entries.
 	cfg.SanitizePayloadRules()
 
+	// Normalize per-provider upstream TLS overrides.
+	cfg.SanitizeUpstreamTLS()
+	cfg.SanitizeTLSMinVersion()
+	cfg.SanitizeMaxConnsPerHost()
+	cfg.SanitizeDNSRetry()
+
+	// Validate chaos-testing fault injection settings.
+	cfg.SanitizeFaultInjection()
+
+	// Normalize user-defined Codex effort aliases.
+	cfg.SanitizeCodexAliases()
+	cfg.SanitizeCodexReasoningEffort()
+
 	// NOTE: Legacy migration persistence is intentionally disabled together with
 	// startup legacy migration to keep startup read-only for config.yaml.
 	// Re-enable the block below if automatic startup migration is needed again.
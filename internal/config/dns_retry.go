@@ -0,0 +1,63 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultDNSRetryAttempts is the number of lookups tried per dial without dns-retry.attempts.
+	DefaultDNSRetryAttempts = 3
+	// DefaultDNSRetryBackoff is the wait before the first repeated lookup without
+	// dns-retry.backoff; it doubles for every further attempt.
+	DefaultDNSRetryBackoff = 200 * time.Millisecond
+)
+
+// DNSRetryConfig retries failed DNS lookups of upstream hosts on the direct Go transport.
+type DNSRetryConfig struct {
+	// Attempts is the number of lookups per dial, including the first; 1 disables retries.
+	Attempts int `yaml:"attempts,omitempty" json:"attempts,omitempty"`
+	// Backoff is the wait before the first retry, as a duration such as "200ms".
+	Backoff string `yaml:"backoff,omitempty" json:"backoff,omitempty"`
+}
+
+// SanitizeDNSRetry drops a negative dns-retry.attempts and an unparsable or negative
+// dns-retry.backoff, so the defaults apply.
+func (cfg *Config) SanitizeDNSRetry() {
+	if cfg == nil {
+		return
+	}
+	if cfg.DNSRetry.Attempts < 0 {
+		log.Warnf("dns-retry: ignoring attempts %d, using %d", cfg.DNSRetry.Attempts, DefaultDNSRetryAttempts)
+		cfg.DNSRetry.Attempts = 0
+	}
+	raw := strings.TrimSpace(cfg.DNSRetry.Backoff)
+	if raw == "" {
+		cfg.DNSRetry.Backoff = ""
+		return
+	}
+	if d, err := time.ParseDuration(raw); err != nil || d < 0 {
+		log.Warnf("dns-retry: ignoring backoff %q, using %s", raw, DefaultDNSRetryBackoff)
+		cfg.DNSRetry.Backoff = ""
+		return
+	}
+	cfg.DNSRetry.Backoff = raw
+}
+
+// DNSRetrySettings returns the lookups per dial and the initial retry backoff, with
+// defaults for unset values.
+func (cfg *Config) DNSRetrySettings() (attempts int, backoff time.Duration) {
+	attempts, backoff = DefaultDNSRetryAttempts, DefaultDNSRetryBackoff
+	if cfg == nil {
+		return attempts, backoff
+	}
+	if cfg.DNSRetry.Attempts > 0 {
+		attempts = cfg.DNSRetry.Attempts
+	}
+	if d, err := time.ParseDuration(strings.TrimSpace(cfg.DNSRetry.Backoff)); err == nil && d >= 0 {
+		backoff = d
+	}
+	return attempts, backoff
+}
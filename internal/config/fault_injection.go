@@ -0,0 +1,63 @@
+package config
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// FaultInjection makes a share of upstream calls on the Go transport fail on purpose, to
+// exercise client and proxy retry behavior. It is for chaos testing only and must never be
+// enabled in production.
+type FaultInjection struct {
+	// Enabled turns fault injection on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Probability is the chance (0-1) that an upstream call gets the configured faults.
+	Probability float64 `yaml:"probability" json:"probability"`
+
+	// Latency delays an affected call before it is sent (Go duration syntax, e.g. "2s").
+	Latency string `yaml:"latency,omitempty" json:"latency,omitempty"`
+
+	// Status answers an affected call with this HTTP status instead of calling upstream.
+	Status int `yaml:"status,omitempty" json:"status,omitempty"`
+
+	// TruncateAfterBytes cuts an affected response body after this many bytes with an
+	// unexpected EOF.
+	TruncateAfterBytes int64 `yaml:"truncate-after-bytes,omitempty" json:"truncate-after-bytes,omitempty"`
+}
+
+// SanitizeFaultInjection clamps the probability to 0-1 and drops an invalid latency or status.
+func (cfg *Config) SanitizeFaultInjection() {
+	if cfg == nil {
+		return
+	}
+	f := &cfg.UnsafeFaultInjection
+	f.Probability = min(max(f.Probability, 0), 1)
+	f.Latency = strings.TrimSpace(f.Latency)
+	if f.Latency != "" && f.LatencyDuration() == 0 {
+		log.Warnf("unsafe-fault-injection: ignoring invalid latency %q", f.Latency)
+		f.Latency = ""
+	}
+	if f.Status != 0 && (f.Status < 100 || f.Status > 599) {
+		log.Warnf("unsafe-fault-injection: ignoring invalid status %d", f.Status)
+		f.Status = 0
+	}
+	if f.TruncateAfterBytes < 0 {
+		f.TruncateAfterBytes = 0
+	}
+}
+
+// Active reports whether fault injection is enabled with a fault that can trigger.
+func (f FaultInjection) Active() bool {
+	return f.Enabled && f.Probability > 0 && (f.LatencyDuration() > 0 || f.Status != 0 || f.TruncateAfterBytes > 0)
+}
+
+// LatencyDuration returns the parsed Latency, or 0 when unset or invalid.
+func (f FaultInjection) LatencyDuration() time.Duration {
+	if d, err := time.ParseDuration(strings.TrimSpace(f.Latency)); err == nil && d > 0 {
+		return d
+	}
+	return 0
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultCopilotPremiumRequestUSD is the list price of one Copilot premium request.
+const DefaultCopilotPremiumRequestUSD = 0.04
+
+// ModelsOverride is the content of models-override-file: model metadata overrides keyed
+// by provider, then by model ID.
+//
+//	copilot:
+//	  claude-opus-4.6-fast:
+//	    premium-multiplier: 9
+type ModelsOverride map[string]map[string]ModelOverride
+
+// ModelOverride overrides metadata of a single model.
+type ModelOverride struct {
+	// PremiumMultiplier is the number of Copilot premium requests one request consumes.
+	PremiumMultiplier *float64 `yaml:"premium-multiplier,omitempty" json:"premium-multiplier,omitempty"`
+}
+
+// LoadModelsOverride reads a models override file. An empty path yields no overrides.
+func LoadModelsOverride(path string) (ModelsOverride, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read models override file: %w", err)
+	}
+	var overrides ModelsOverride
+	if err = yaml.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("parse models override file: %w", err)
+	}
+	normalized := make(ModelsOverride, len(overrides))
+	for provider, models := range overrides {
+		key := strings.ToLower(strings.TrimSpace(provider))
+		if key == "" {
+			continue
+		}
+		for model, override := range models {
+			if override.PremiumMultiplier != nil && *override.PremiumMultiplier < 0 {
+				return nil, fmt.Errorf("models override %s/%s: premium-multiplier must not be negative", key, model)
+			}
+		}
+		normalized[key] = models
+	}
+	return normalized, nil
+}
+
+// PremiumMultipliers returns the premium-multiplier overrides configured for provider.
+func (o ModelsOverride) PremiumMultipliers(provider string) map[string]float64 {
+	models := o[strings.ToLower(strings.TrimSpace(provider))]
+	if len(models) == 0 {
+		return nil
+	}
+	out := make(map[string]float64, len(models))
+	for model, override := range models {
+		if override.PremiumMultiplier != nil {
+			out[model] = *override.PremiumMultiplier
+		}
+	}
+	return out
+}
+
+// CopilotPremiumRequestPrice returns the configured USD price of a Copilot premium request.
+func (cfg *Config) CopilotPremiumRequestPrice() float64 {
+	if cfg == nil || cfg.CopilotPremiumRequestUSD <= 0 {
+		return DefaultCopilotPremiumRequestUSD
+	}
+	return cfg.CopilotPremiumRequestUSD
+}
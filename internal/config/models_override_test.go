@@ -0,0 +1,30 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadModelsOverride_PremiumMultipliers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "models-override.yaml")
+	data := "Copilot:\n  claude-opus-4.6-fast:\n    premium-multiplier: 9\n  gpt-4.1:\n    premium-multiplier: 0\n  gpt-5: {}\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	overrides, err := LoadModelsOverride(path)
+	if err != nil {
+		t.Fatalf("LoadModelsOverride: %v", err)
+	}
+	got := overrides.PremiumMultipliers("copilot")
+	if len(got) != 2 || got["claude-opus-4.6-fast"] != 9 || got["gpt-4.1"] != 0 {
+		t.Fatalf("PremiumMultipliers = %v", got)
+	}
+
+	if err = os.WriteFile(path, []byte("copilot:\n  gpt-5:\n    premium-multiplier: -1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadModelsOverride(path); err == nil {
+		t.Fatalf("negative multiplier must be rejected")
+	}
+}
@@ -0,0 +1,34 @@
+package config
+
+import "strings"
+
+// ModelPreset is a named set of sampling parameters clients select with the X-Preset
+// header. Parameters only fill fields the request leaves unset.
+type ModelPreset struct {
+	// Temperature, when set, is used for requests without a temperature.
+	Temperature *float64 `yaml:"temperature,omitempty" json:"temperature,omitempty"`
+
+	// TopP, when set, is used for requests without top_p.
+	TopP *float64 `yaml:"top-p,omitempty" json:"top-p,omitempty"`
+
+	// ReasoningEffort (e.g. "low", "high") is used for OpenAI chat and Responses requests
+	// that set no effort themselves, neither in the body nor through the model name.
+	ReasoningEffort string `yaml:"reasoning-effort,omitempty" json:"reasoning-effort,omitempty"`
+}
+
+// Preset returns the preset with the given name (case-insensitive).
+func (c *SDKConfig) Preset(name string) (ModelPreset, bool) {
+	name = strings.TrimSpace(name)
+	if c == nil || name == "" {
+		return ModelPreset{}, false
+	}
+	if preset, ok := c.Presets[name]; ok {
+		return preset, true
+	}
+	for key, preset := range c.Presets {
+		if strings.EqualFold(strings.TrimSpace(key), name) {
+			return preset, true
+		}
+	}
+	return ModelPreset{}, false
+}
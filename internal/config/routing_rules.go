@@ -0,0 +1,22 @@
+package config
+
+// RoutingRule pins matching requests to one provider. Every condition that is set must
+// match; a rule without conditions matches every request.
+type RoutingRule struct {
+	// Name identifies the rule in logs.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// APIKeys lists the client API keys the rule applies to.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// Models lists model names ('*' matches any substring, case-insensitive), matched
+	// against the model after prefix and suffix normalization.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Metadata requires these values in the request body "metadata" object, e.g.
+	// {"team": "search"}.
+	Metadata map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+
+	// Provider receives the matching requests, e.g. "chutes" or "copilot".
+	Provider string `yaml:"provider" json:"provider"`
+}
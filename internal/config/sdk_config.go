@@ -5,7 +5,9 @@
 package config
 
 import (
+	"strconv"
 	"strings"
+	"time"
 )
 
 // SDKConfig represents the application's configuration, loaded from a YAML file.
@@ -20,6 +22,10 @@ type SDKConfig struct {
 	// Typical values: "copilot", "codex", "openai", "anthropic", "google".
 	ProxyServices []string `yaml:"proxy-services,omitempty" json:"proxy-services,omitempty"`
 
+	// NoProxy is a comma-separated list of hosts (exact, ".suffix" or "*") that bypass the
+	// outbound proxy, combined with the NO_PROXY environment variable.
+	NoProxy string `yaml:"no-proxy,omitempty" json:"no-proxy,omitempty"`
+
 	// ForceModelPrefix requires explicit model prefixes (e.g., "teamA/gemini-3-pro-preview")
 	// to target prefixed credentials. When false, unprefixed model requests may use prefixed
 	// credentials as well.
@@ -41,6 +47,109 @@ type SDKConfig struct {
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// MultiChoice configures how OpenAI chat requests with `n` > 1 are handled, keyed by provider
+	// (e.g. "codex", "copilot") with an optional "default" entry. Values: "passthrough" (default),
+	// "fan-out" (n single-choice upstream calls merged into one response), or "reject".
+	MultiChoice map[string]string `yaml:"n-handling,omitempty" json:"n-handling,omitempty"`
+
+	// ResponseFormatCoercion configures how OpenAI chat requests with a JSON response_format
+	// (json_object / json_schema) are handled, keyed by provider with an optional "default"
+	// entry. Values: "native" (forward unchanged), "instruct" (inject a JSON-only system
+	// instruction) or "validate" (instruct, then repair and schema-check non-streaming
+	// replies). Providers that ignore response_format default to "instruct".
+	ResponseFormatCoercion map[string]string `yaml:"response-format-coercion,omitempty" json:"response-format-coercion,omitempty"`
+
+	// ModelCatalogOverlay lists static model entries that always appear in model listings,
+	// even when no live credential currently advertises them. Entries without a live
+	// provider are marked as potentially unavailable.
+	ModelCatalogOverlay []ModelCatalogEntry `yaml:"model-catalog-overlay,omitempty" json:"model-catalog-overlay,omitempty"`
+
+	// ValidateUpstreamResponses checks non-streaming responses against the shape expected
+	// for the client API (e.g. chat completions must carry "choices") and returns a 502
+	// instead of passing unparseable bodies such as HTML error pages through.
+	ValidateUpstreamResponses bool `yaml:"validate-upstream-responses,omitempty" json:"validate-upstream-responses,omitempty"`
+
+	// MaxRequestDeadlineMs caps the client deadline requested with the X-Deadline-Ms header.
+	// The deadline bounds non-streaming requests as a whole and streaming requests until the
+	// first payload arrives. <= 0 uses the default of 600000 (10 minutes).
+	MaxRequestDeadlineMs int `yaml:"max-request-deadline-ms,omitempty" json:"max-request-deadline-ms,omitempty"`
+
+	// ContextOverflowRetry retries a request once with older turns dropped and long tool
+	// outputs middle-truncated when the upstream rejects it for exceeding the model context.
+	// Clients opt out with "X-Context-Truncation: disabled" or "truncation": "disabled".
+	ContextOverflowRetry bool `yaml:"context-overflow-retry,omitempty" json:"context-overflow-retry,omitempty"`
+
+	// EmptyResponse selects what happens when a non-streaming response carries no assistant
+	// content (only whitespace and no tool calls), e.g. because the upstream filtered it:
+	// "retry" retries once on another auth and then fails, "error" fails with a 502. Empty
+	// passes such responses through.
+	EmptyResponse string `yaml:"empty-response,omitempty" json:"empty-response,omitempty"`
+
+	// SpeculativePrefetch lets clients send "X-Cliproxy-Prefetch: true" to have the upstream
+	// of the auth that served a response warmed for their next turn once it completes. It is
+	// best effort and never generates: executors without a quota-free warm-up call skip it.
+	SpeculativePrefetch bool `yaml:"speculative-prefetch,omitempty" json:"speculative-prefetch,omitempty"`
+
+	// ModelMaxBodyBytes caps the request body size per model, keyed by model name glob
+	// ('*' matches any substring) with an optional "default" entry used when no glob
+	// matches. The most specific matching glob wins; <= 0 means no cap.
+	ModelMaxBodyBytes map[string]int64 `yaml:"model-max-body-bytes,omitempty" json:"model-max-body-bytes,omitempty"`
+
+	// MaxStreamDuration caps the wall-clock length of a streaming response per model, keyed
+	// like ModelMaxBodyBytes, with Go duration values (e.g. "10m"). Unlike an idle timeout
+	// it fires even while tokens keep arriving: the stream ends with an error event and the
+	// upstream request is cancelled. Unset, invalid or <= 0 durations mean no cap.
+	MaxStreamDuration map[string]string `yaml:"max-stream-duration,omitempty" json:"max-stream-duration,omitempty"`
+
+	// Tenants isolates client API keys and auth files into namespaces. A tenant's keys are
+	// only served by that tenant's auths and only see its models and usage; untenanted keys
+	// only use untenanted auths. Empty disables tenancy.
+	Tenants []TenantConfig `yaml:"tenants,omitempty" json:"tenants,omitempty"`
+
+	// APIKeyDefaults configures per client API key a default model (used when the request
+	// model is missing or a placeholder) and sampling parameter overrides, for clients that
+	// cannot be configured themselves.
+	APIKeyDefaults []APIKeyDefaults `yaml:"api-key-defaults,omitempty" json:"api-key-defaults,omitempty"`
+
+	// Presets defines named parameter sets clients select with the X-Preset header, e.g.
+	// "creative" or "precise". A preset only fills parameters the request leaves unset.
+	Presets map[string]ModelPreset `yaml:"presets,omitempty" json:"presets,omitempty"`
+
+	// RoutingRules pins requests to a provider by client API key, model and request
+	// metadata. Rules are evaluated in order after model normalization; the first match
+	// wins and requests matching none are routed normally.
+	RoutingRules []RoutingRule `yaml:"routing-rules,omitempty" json:"routing-rules,omitempty"`
+
+	// OutputRedaction replaces text matching configured patterns in model output
+	// on every generation endpoint before it reaches the client. Empty patterns disable it.
+	OutputRedaction OutputRedactionConfig `yaml:"output-redaction,omitempty" json:"output-redaction,omitempty"`
+}
+
+// OutputRedactionConfig configures the output redaction post-processor.
+type OutputRedactionConfig struct {
+	// Patterns are regular expressions (RE2 syntax) matched against output text.
+	Patterns []string `yaml:"patterns,omitempty" json:"patterns,omitempty"`
+
+	// Replacement is written in place of each match. Defaults to "[REDACTED]".
+	Replacement string `yaml:"replacement,omitempty" json:"replacement,omitempty"`
+
+	// StreamLookaheadBytes is how much trailing streamed text is held back so a match
+	// spanning two deltas is still caught; it bounds the longest match found across
+	// deltas. <= 0 uses the default of 256.
+	StreamLookaheadBytes int `yaml:"stream-lookahead-bytes,omitempty" json:"stream-lookahead-bytes,omitempty"`
+}
+
+// ModelCatalogEntry is a static model listing entry served by the catalog overlay.
+type ModelCatalogEntry struct {
+	ID                  string `yaml:"id" json:"id"`
+	OwnedBy             string `yaml:"owned-by,omitempty" json:"owned-by,omitempty"`
+	Type                string `yaml:"type,omitempty" json:"type,omitempty"`
+	DisplayName         string `yaml:"display-name,omitempty" json:"display-name,omitempty"`
+	Description         string `yaml:"description,omitempty" json:"description,omitempty"`
+	Created             int64  `yaml:"created,omitempty" json:"created,omitempty"`
+	ContextLength       int    `yaml:"context-length,omitempty" json:"context-length,omitempty"`
+	MaxCompletionTokens int    `yaml:"max-completion-tokens,omitempty" json:"max-completion-tokens,omitempty"`
 }
 
 // ProxyEnabledFor reports whether the global ProxyURL should be applied for the given service name.
@@ -83,4 +192,83 @@ type StreamingConfig struct {
 	// This tells reverse proxies (Nginx, Railway) to not buffer the response.
 	// Useful when SSE streams get corrupted due to proxy chunking. Default is false.
 	DisableProxyBuffering bool `yaml:"disable-proxy-buffering,omitempty" json:"disable-proxy-buffering,omitempty"`
+
+	// ToolDeltaMaxBytes bounds the partial_json size of each Claude input_json_delta event when
+	// the client requests the fine-grained-tool-streaming beta. <= 0 uses the default of 128.
+	ToolDeltaMaxBytes int `yaml:"tool-delta-max-bytes,omitempty" json:"tool-delta-max-bytes,omitempty"`
+
+	// MaxEventBytes splits streamed OpenAI chat/completions and Claude delta events whose data
+	// payload exceeds this many bytes into several equivalent events, for clients that limit
+	// SSE line length. <= 0 disables splitting. Default is 0.
+	MaxEventBytes int `yaml:"max-event-bytes,omitempty" json:"max-event-bytes,omitempty"`
+
+	// MaxEventBytesPerKey overrides MaxEventBytes for individual client API keys. A value of 0
+	// disables splitting for that key.
+	MaxEventBytesPerKey map[string]int `yaml:"max-event-bytes-per-key,omitempty" json:"max-event-bytes-per-key,omitempty"`
+
+	// FinalDelimiter controls the empty line written when an OpenAI Responses stream ends:
+	// "if-needed" (default) only closes an unterminated event, "always" also writes one after
+	// a terminal event that already ended at a boundary, and "never" writes none. Nothing is
+	// written for streams that never carried data.
+	FinalDelimiter string `yaml:"final-delimiter,omitempty" json:"final-delimiter,omitempty"`
+
+	// HeartbeatInterval emits ": ping" SSE comments while a stream is idle: before the
+	// first upstream byte arrives and whenever no data was written for this long. It takes
+	// a duration such as "15s" (a bare number is read as seconds) and replaces the fixed
+	// KeepAliveSeconds ticker when set. Empty or invalid values disable heartbeats.
+	HeartbeatInterval string `yaml:"heartbeat-interval,omitempty" json:"heartbeat-interval,omitempty"`
+}
+
+// Final SSE delimiter modes for StreamingConfig.FinalDelimiter.
+const (
+	FinalDelimiterIfNeeded = "if-needed"
+	FinalDelimiterAlways   = "always"
+	FinalDelimiterNever    = "never"
+)
+
+// FinalDelimiterMode returns the normalized FinalDelimiter, defaulting to "if-needed".
+func (s StreamingConfig) FinalDelimiterMode() string {
+	switch mode := strings.ToLower(strings.TrimSpace(s.FinalDelimiter)); mode {
+	case FinalDelimiterAlways, FinalDelimiterNever:
+		return mode
+	default:
+		return FinalDelimiterIfNeeded
+	}
+}
+
+// HeartbeatDuration returns the parsed HeartbeatInterval, or 0 when it is unset, invalid
+// or not positive.
+func (s StreamingConfig) HeartbeatDuration() time.Duration {
+	raw := strings.TrimSpace(s.HeartbeatInterval)
+	if raw == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(raw)
+	if seconds, errAtoi := strconv.Atoi(raw); errAtoi == nil {
+		d, err = time.Duration(seconds)*time.Second, nil
+	}
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// Empty response modes for SDKConfig.EmptyResponse.
+const (
+	EmptyResponseRetry = "retry"
+	EmptyResponseError = "error"
+)
+
+// EmptyResponseMode returns the normalized EmptyResponse, or "" to pass empty responses
+// through.
+func (c *SDKConfig) EmptyResponseMode() string {
+	if c == nil {
+		return ""
+	}
+	switch mode := strings.ToLower(strings.TrimSpace(c.EmptyResponse)); mode {
+	case EmptyResponseRetry, EmptyResponseError:
+		return mode
+	default:
+		return ""
+	}
 }
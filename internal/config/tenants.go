@@ -0,0 +1,115 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// TenantMetadataKey is the auth file field (or auth attribute) assigning an auth to a tenant.
+const TenantMetadataKey = "tenant"
+
+// TenantConfig declares one tenant namespace.
+type TenantConfig struct {
+	// ID names the tenant.
+	ID string `yaml:"id" json:"id"`
+
+	// APIKeys lists client API keys belonging to the tenant. Keys listed in api-keys of
+	// the form "<tenant-id>:<secret>" belong to that tenant without being listed here.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// AuthFiles lists auth file name globs ('*' matches any substring) assigned to the
+	// tenant, matched against the file name and the auth ID. A "tenant" field inside the
+	// auth file takes precedence.
+	AuthFiles []string `yaml:"auth-files,omitempty" json:"auth-files,omitempty"`
+}
+
+// TenantsEnabled reports whether any tenant is configured.
+func (c *SDKConfig) TenantsEnabled() bool {
+	if c == nil {
+		return false
+	}
+	for _, tenant := range c.Tenants {
+		if strings.TrimSpace(tenant.ID) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantForAPIKey returns the tenant owning the client API key, or "" for untenanted keys.
+func (c *SDKConfig) TenantForAPIKey(apiKey string) string {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" || !c.TenantsEnabled() {
+		return ""
+	}
+	for _, tenant := range c.Tenants {
+		for _, key := range tenant.APIKeys {
+			if strings.TrimSpace(key) == apiKey {
+				return strings.TrimSpace(tenant.ID)
+			}
+		}
+	}
+	if prefix, _, ok := strings.Cut(apiKey, ":"); ok {
+		for _, tenant := range c.Tenants {
+			if id := strings.TrimSpace(tenant.ID); id != "" && id == prefix {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// TenantForAuth returns the tenant owning an auth: the explicit tenant field when set,
+// otherwise the first tenant whose auth-files glob matches the file name or auth ID.
+func (c *SDKConfig) TenantForAuth(explicit, fileName, authID string) string {
+	if explicit = strings.TrimSpace(explicit); explicit != "" {
+		return explicit
+	}
+	if !c.TenantsEnabled() {
+		return ""
+	}
+	names := []string{authID}
+	if fileName != "" {
+		names = append(names, filepath.Base(fileName), fileName)
+	}
+	for _, tenant := range c.Tenants {
+		id := strings.TrimSpace(tenant.ID)
+		if id == "" {
+			continue
+		}
+		for _, pattern := range tenant.AuthFiles {
+			pattern = strings.TrimSpace(pattern)
+			for _, name := range names {
+				if name != "" && pattern != "" && tenantGlobMatch(pattern, name) {
+					return id
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// tenantGlobMatch matches value against pattern, where '*' matches any substring.
+func tenantGlobMatch(pattern, value string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == value
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	if len(value) < len(last) || !strings.HasSuffix(value, last) {
+		return false
+	}
+	value = value[:len(value)-len(last)]
+	for _, segment := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, segment)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(segment):]
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package config
+
+import "testing"
+
+func TestTenantResolution(t *testing.T) {
+	cfg := &SDKConfig{Tenants: []TenantConfig{
+		{ID: "team-a", APIKeys: []string{"sk-a"}, AuthFiles: []string{"team-a-*.json"}},
+		{ID: "team-b", AuthFiles: []string{"codex-b@*"}},
+	}}
+
+	keys := map[string]string{
+		"sk-a":          "team-a",
+		"team-b:secret": "team-b",
+		"team-c:secret": "",
+		"sk-other":      "",
+	}
+	for key, want := range keys {
+		if got := cfg.TenantForAPIKey(key); got != want {
+			t.Errorf("TenantForAPIKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	if got := cfg.TenantForAuth("", "/auths/team-a-claude.json", "team-a-claude.json"); got != "team-a" {
+		t.Errorf("glob on file name = %q", got)
+	}
+	if got := cfg.TenantForAuth("", "", "codex-b@example.com.json"); got != "team-b" {
+		t.Errorf("glob on auth ID = %q", got)
+	}
+	if got := cfg.TenantForAuth("team-b", "/auths/team-a-claude.json", ""); got != "team-b" {
+		t.Errorf("explicit tenant field must win, got %q", got)
+	}
+	if got := cfg.TenantForAuth("", "/auths/shared.json", "shared.json"); got != "" {
+		t.Errorf("unmatched auth = %q, want untenanted", got)
+	}
+
+	if (&SDKConfig{}).TenantForAPIKey("team-a:secret") != "" {
+		t.Errorf("structured keys must not resolve without configured tenants")
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SanitizeMaxConnsPerHost lower-cases provider keys and drops entries without a positive
+// limit.
+func (cfg *Config) SanitizeMaxConnsPerHost() {
+	if cfg == nil || len(cfg.MaxConnsPerHost) == 0 {
+		return
+	}
+	out := make(map[string]int, len(cfg.MaxConnsPerHost))
+	for rawProvider, limit := range cfg.MaxConnsPerHost {
+		provider := strings.ToLower(strings.TrimSpace(rawProvider))
+		if provider == "" {
+			continue
+		}
+		if limit <= 0 {
+			log.Warnf("max-conns-per-host: ignoring %q: limit %d is not positive", rawProvider, limit)
+			continue
+		}
+		out[provider] = limit
+	}
+	cfg.MaxConnsPerHost = out
+}
+
+// MaxConnsPerHostFor returns the connection cap per upstream host configured for
+// provider, or 0 when connections are not capped.
+func (cfg *Config) MaxConnsPerHostFor(provider string) int {
+	if cfg == nil || len(cfg.MaxConnsPerHost) == 0 {
+		return 0
+	}
+	return cfg.MaxConnsPerHost[strings.ToLower(strings.TrimSpace(provider))]
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"crypto/tls"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// UpstreamTLS overrides certificate verification for one provider's upstream connections,
+// for gateways serving self-signed or privately issued certificates.
+type UpstreamTLS struct {
+	// InsecureSkipVerify disables certificate verification entirely. Prefer CAFile.
+	InsecureSkipVerify bool `yaml:"tls-insecure-skip-verify,omitempty" json:"tls-insecure-skip-verify,omitempty"`
+
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string `yaml:"tls-ca-file,omitempty" json:"tls-ca-file,omitempty"`
+}
+
+// upstreamTLSWildcards are keys rejected by SanitizeUpstreamTLS: the settings must name
+// the provider they apply to.
+var upstreamTLSWildcards = map[string]bool{"*": true, "default": true, "all": true}
+
+// SanitizeUpstreamTLS lower-cases provider keys and drops empty entries and entries that
+// would apply to every provider.
+func (cfg *Config) SanitizeUpstreamTLS() {
+	if cfg == nil || len(cfg.UpstreamTLS) == 0 {
+		return
+	}
+	out := make(map[string]UpstreamTLS, len(cfg.UpstreamTLS))
+	for rawProvider, settings := range cfg.UpstreamTLS {
+		provider := strings.ToLower(strings.TrimSpace(rawProvider))
+		settings.CAFile = strings.TrimSpace(settings.CAFile)
+		if provider == "" || (!settings.InsecureSkipVerify && settings.CAFile == "") {
+			continue
+		}
+		if upstreamTLSWildcards[provider] {
+			log.Warnf("upstream-tls: ignoring entry %q; TLS overrides must name a provider", rawProvider)
+			continue
+		}
+		out[provider] = settings
+	}
+	cfg.UpstreamTLS = out
+}
+
+// UpstreamTLSFor returns the TLS override configured for provider.
+func (cfg *Config) UpstreamTLSFor(provider string) (UpstreamTLS, bool) {
+	if cfg == nil || len(cfg.UpstreamTLS) == 0 {
+		return UpstreamTLS{}, false
+	}
+	settings, ok := cfg.UpstreamTLS[strings.ToLower(strings.TrimSpace(provider))]
+	return settings, ok
+}
+
+// InsecureUpstreamTLSProviders lists, sorted, the providers with verification disabled.
+func (cfg *Config) InsecureUpstreamTLSProviders() []string {
+	if cfg == nil {
+		return nil
+	}
+	var providers []string
+	for provider, settings := range cfg.UpstreamTLS {
+		if settings.InsecureSkipVerify {
+			providers = append(providers, provider)
+		}
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// tlsMinVersions are the accepted tls-min-version values. Versions below TLS 1.2 are not
+// offered.
+var tlsMinVersions = map[string]uint16{
+	"1.2":    tls.VersionTLS12,
+	"tls1.2": tls.VersionTLS12,
+	"1.3":    tls.VersionTLS13,
+	"tls1.3": tls.VersionTLS13,
+}
+
+// SanitizeTLSMinVersion normalizes tls-min-version, resetting unknown values to the
+// default with a warning.
+func (cfg *Config) SanitizeTLSMinVersion() {
+	if cfg == nil {
+		return
+	}
+	raw := strings.ToLower(strings.TrimSpace(cfg.TLSMinVersion))
+	if raw == "" {
+		cfg.TLSMinVersion = ""
+		return
+	}
+	if _, ok := tlsMinVersions[raw]; !ok {
+		log.Warnf("tls-min-version: unsupported value %q, using 1.2 (expected 1.2 or 1.3)", cfg.TLSMinVersion)
+		cfg.TLSMinVersion = ""
+		return
+	}
+	cfg.TLSMinVersion = strings.TrimPrefix(raw, "tls")
+}
+
+// UpstreamTLSMinVersion returns the minimum TLS version for upstream connections,
+// TLS 1.2 unless tls-min-version asks for 1.3.
+func (cfg *Config) UpstreamTLSMinVersion() uint16 {
+	if cfg != nil {
+		if version, ok := tlsMinVersions[strings.ToLower(strings.TrimSpace(cfg.TLSMinVersion))]; ok {
+			return version
+		}
+	}
+	return tls.VersionTLS12
+}
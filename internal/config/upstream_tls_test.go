@@ -0,0 +1,42 @@
+package config
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestSanitizeUpstreamTLS_RejectsGlobalEntries(t *testing.T) {
+	cfg := &Config{UpstreamTLS: map[string]UpstreamTLS{
+		"*":       {InsecureSkipVerify: true},
+		"Default": {InsecureSkipVerify: true},
+		" Codex ": {InsecureSkipVerify: true},
+		"empty":   {},
+	}}
+	cfg.SanitizeUpstreamTLS()
+	if len(cfg.UpstreamTLS) != 1 {
+		t.Fatalf("upstream-tls = %v, want only codex", cfg.UpstreamTLS)
+	}
+	if _, ok := cfg.UpstreamTLSFor("CODEX"); !ok {
+		t.Fatalf("codex entry missing: %v", cfg.UpstreamTLS)
+	}
+	if got := cfg.InsecureUpstreamTLSProviders(); len(got) != 1 || got[0] != "codex" {
+		t.Fatalf("insecure providers = %v", got)
+	}
+}
+
+func TestSanitizeTLSMinVersion(t *testing.T) {
+	cases := map[string]string{"": "", " TLS1.3 ": "1.3", "1.2": "1.2", "1.0": "", "bogus": ""}
+	for in, want := range cases {
+		cfg := &Config{TLSMinVersion: in}
+		cfg.SanitizeTLSMinVersion()
+		if cfg.TLSMinVersion != want {
+			t.Fatalf("tls-min-version %q sanitized to %q, want %q", in, cfg.TLSMinVersion, want)
+		}
+	}
+	if got := (&Config{TLSMinVersion: "1.3"}).UpstreamTLSMinVersion(); got != tls.VersionTLS13 {
+		t.Fatalf("UpstreamTLSMinVersion = %#x, want TLS 1.3", got)
+	}
+	if got := (*Config)(nil).UpstreamTLSMinVersion(); got != tls.VersionTLS12 {
+		t.Fatalf("default UpstreamTLSMinVersion = %#x, want TLS 1.2", got)
+	}
+}
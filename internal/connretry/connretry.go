@@ -0,0 +1,156 @@
+// Package connretry retries upstream requests once when the connection is torn down
+// (HTTP/2 GOAWAY, connection reset) before any response was received, and counts that
+// connection churn per provider.
+package connretry
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Kind classifies a connection teardown.
+type Kind string
+
+const (
+	KindGoAway Kind = "goaway"
+	KindReset  Kind = "reset"
+)
+
+// Classify reports whether err is a connection teardown that is safe to retry on a new
+// connection, and which kind it is. It only inspects the error; the caller must make sure
+// no response bytes were delivered.
+func Classify(err error) (Kind, bool) {
+	if err == nil {
+		return "", false
+	}
+	msg := err.Error()
+	if strings.Contains(msg, "GOAWAY") {
+		return KindGoAway, true
+	}
+	if errors.Is(err, syscall.ECONNRESET) {
+		return KindReset, true
+	}
+	for _, marker := range []string{"connection reset by peer", "server closed idle connection", "use of closed network connection"} {
+		if strings.Contains(msg, marker) {
+			return KindReset, true
+		}
+	}
+	return "", false
+}
+
+// Counters are the churn counters of one provider.
+type Counters struct {
+	Provider     string `json:"provider"`
+	GoAway       int64  `json:"goaway"`
+	Reset        int64  `json:"reset"`
+	Retried      int64  `json:"retried"`
+	RetrySuccess int64  `json:"retry_success"`
+}
+
+var (
+	statsMu sync.Mutex
+	stats   = make(map[string]*Counters)
+)
+
+func record(provider string, kind Kind, retried, ok bool) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		provider = "unknown"
+	}
+	statsMu.Lock()
+	defer statsMu.Unlock()
+	c, exists := stats[provider]
+	if !exists {
+		c = &Counters{Provider: provider}
+		stats[provider] = c
+	}
+	switch kind {
+	case KindGoAway:
+		c.GoAway++
+	case KindReset:
+		c.Reset++
+	}
+	if retried {
+		c.Retried++
+		if ok {
+			c.RetrySuccess++
+		}
+	}
+}
+
+// Snapshot returns the churn counters of every provider ordered by name.
+func Snapshot() []Counters {
+	statsMu.Lock()
+	out := make([]Counters, 0, len(stats))
+	for _, c := range stats {
+		out = append(out, *c)
+	}
+	statsMu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
+
+// Transport retries a request once when Base fails with a connection teardown. Errors
+// returned by Base.RoundTrip precede the response, so a retry never duplicates
+// delivered bytes; failures while reading the body are never retried.
+type Transport struct {
+	Base     http.RoundTripper
+	Provider string
+}
+
+// NewTransport wraps base (http.DefaultTransport when nil).
+func NewTransport(base http.RoundTripper, provider string) *Transport {
+	return &Transport{Base: base, Provider: provider}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err == nil {
+		return resp, nil
+	}
+	kind, ok := Classify(err)
+	if !ok {
+		return resp, err
+	}
+	retry, replayable := replayRequest(req)
+	if !replayable || req.Context().Err() != nil {
+		record(t.Provider, kind, false, false)
+		return resp, err
+	}
+	// Drop pooled connections so the retry dials a fresh one.
+	if closer, okCloser := base.(interface{ CloseIdleConnections() }); okCloser {
+		closer.CloseIdleConnections()
+	}
+	log.Debugf("connretry: %s connection %s to %s, retrying once: %v", t.Provider, kind, req.URL.Host, err)
+	resp, err = base.RoundTrip(retry)
+	record(t.Provider, kind, true, err == nil)
+	return resp, err
+}
+
+// replayRequest returns a copy of req with a fresh body, or false when the body cannot be replayed.
+func replayRequest(req *http.Request) (*http.Request, bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req.Clone(req.Context()), true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, true
+}
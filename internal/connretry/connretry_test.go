@@ -0,0 +1,144 @@
+package connretry
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/http2"
+)
+
+// goAwayServer speaks cleartext HTTP/2. The first connection reads one request and
+// answers it with GOAWAY (last stream = that request) before closing; later connections
+// are served normally.
+func goAwayServer(t *testing.T, handler http.Handler) (addr string, conns *atomic.Int32) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	conns = &atomic.Int32{}
+	go func() {
+		for {
+			conn, errAccept := ln.Accept()
+			if errAccept != nil {
+				return
+			}
+			if conns.Add(1) == 1 {
+				go sendGoAway(conn)
+				continue
+			}
+			go (&http2.Server{}).ServeConn(conn, &http2.ServeConnOpts{Handler: handler})
+		}
+	}()
+	return ln.Addr().String(), conns
+}
+
+func sendGoAway(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	preface := make([]byte, len(http2.ClientPreface))
+	if _, err := io.ReadFull(conn, preface); err != nil {
+		return
+	}
+	framer := http2.NewFramer(conn, conn)
+	if err := framer.WriteSettings(); err != nil {
+		return
+	}
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			return
+		}
+		if headers, ok := frame.(*http2.HeadersFrame); ok {
+			_ = framer.WriteGoAway(headers.StreamID, http2.ErrCodeNo, nil)
+			return
+		}
+	}
+}
+
+func h2cTransport() *http2.Transport {
+	return &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+func TestTransport_RetriesOnceAfterGoAwayBeforeResponse(t *testing.T) {
+	var bodies []string
+	addr, conns := goAwayServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(raw))
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	base := h2cTransport()
+	if _, err := (&http.Client{Transport: base}).Post("http://"+addr+"/v1", "application/json", bytes.NewReader([]byte(`{}`))); err == nil || !strings.Contains(err.Error(), "GOAWAY") {
+		t.Fatalf("unwrapped transport error = %v, want GOAWAY", err)
+	}
+
+	conns.Store(0)
+	client := &http.Client{Transport: NewTransport(h2cTransport(), "goaway-test")}
+	resp, err := client.Post("http://"+addr+"/v1", "application/json", bytes.NewReader([]byte(`{"prompt":"hi"}`)))
+	if err != nil {
+		t.Fatalf("POST through retrying transport: %v", err)
+	}
+	raw, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(raw) != "ok" {
+		t.Fatalf("body = %q", raw)
+	}
+	if conns.Load() != 2 {
+		t.Fatalf("connections = %d, want 2 (GOAWAY then fresh)", conns.Load())
+	}
+	if len(bodies) != 1 || bodies[0] != `{"prompt":"hi"}` {
+		t.Fatalf("retried request bodies = %q", bodies)
+	}
+
+	var got Counters
+	for _, c := range Snapshot() {
+		if c.Provider == "goaway-test" {
+			got = c
+		}
+	}
+	if got.GoAway != 1 || got.Retried != 1 || got.RetrySuccess != 1 {
+		t.Fatalf("counters = %+v", got)
+	}
+}
+
+type failingRoundTripper struct {
+	calls int
+	err   error
+}
+
+func (f *failingRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	f.calls++
+	return nil, f.err
+}
+
+func TestTransport_DoesNotRetryOtherErrorsOrUnreplayableBodies(t *testing.T) {
+	reset := &failingRoundTripper{err: &net.OpError{Op: "read", Err: errString("connection reset by peer")}}
+	req, _ := http.NewRequest(http.MethodPost, "http://example.invalid", io.NopCloser(strings.NewReader("stream")))
+	if _, err := NewTransport(reset, "noreplay-test").RoundTrip(req); err == nil || reset.calls != 1 {
+		t.Fatalf("unreplayable body must not be retried: calls=%d err=%v", reset.calls, err)
+	}
+
+	refused := &failingRoundTripper{err: errString("dial tcp: connection refused")}
+	req, _ = http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+	if _, err := NewTransport(refused, "refused-test").RoundTrip(req); err == nil || refused.calls != 1 {
+		t.Fatalf("non-teardown errors must not be retried: calls=%d err=%v", refused.calls, err)
+	}
+}
+
+type errString string
+
+func (e errString) Error() string { return string(e) }
@@ -0,0 +1,113 @@
+// Package dnsretry dials upstream hosts with retried DNS lookups. Resolution failures,
+// including "no such host", are often transient behind flaky resolvers, so a lookup is
+// repeated with a short backoff before the dial fails with a clear error.
+package dnsretry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Resolver looks up the addresses of a host; *net.Resolver implements it.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Dialer resolves the host of every dial itself, retrying DNS failures, then connects to
+// the resolved addresses in order.
+type Dialer struct {
+	// Dialer connects to the resolved addresses; a zero net.Dialer when nil.
+	Dialer *net.Dialer
+	// Resolver looks up hosts; net.DefaultResolver when nil.
+	Resolver Resolver
+	// Attempts is the number of lookups per dial, including the first.
+	Attempts int
+	// Backoff is the wait before the first retry; it doubles for every further retry.
+	Backoff time.Duration
+}
+
+// New returns a Dialer built on dialer with the given attempts and initial backoff.
+func New(dialer *net.Dialer, attempts int, backoff time.Duration) *Dialer {
+	return &Dialer{Dialer: dialer, Attempts: attempts, Backoff: backoff}
+}
+
+// IsDNSError reports whether err is a DNS resolution failure.
+func IsDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+// DialContext connects to addr on the named network. It fits http.Transport.DialContext.
+func (d *Dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := d.Dialer
+	if dialer == nil {
+		dialer = &net.Dialer{}
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	ips, err := d.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	var firstErr error
+	for _, ip := range ips {
+		conn, errDial := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if errDial == nil {
+			return conn, nil
+		}
+		if firstErr == nil {
+			firstErr = errDial
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, firstErr
+}
+
+// lookup resolves host, repeating DNS failures up to Attempts times in total.
+func (d *Dialer) lookup(ctx context.Context, host string) ([]net.IPAddr, error) {
+	resolver := d.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	attempts := max(d.Attempts, 1)
+	backoff := d.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var ips []net.IPAddr
+		ips, err = resolver.LookupIPAddr(ctx, host)
+		if err == nil && len(ips) > 0 {
+			if attempt > 1 {
+				log.Debugf("dnsretry: resolved %s on attempt %d", host, attempt)
+			}
+			return ips, nil
+		}
+		if err == nil {
+			err = &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+		if !IsDNSError(err) || attempt >= attempts {
+			break
+		}
+		log.Debugf("dnsretry: lookup of %s failed (attempt %d/%d), retrying in %s: %v", host, attempt, attempts, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+	if !IsDNSError(err) {
+		return nil, err
+	}
+	return nil, fmt.Errorf("dns resolution failed for upstream host %s after %d attempt(s); check the resolver or the host name: %w", host, attempts, err)
+}
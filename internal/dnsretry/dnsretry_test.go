@@ -0,0 +1,99 @@
+package dnsretry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// flakyResolver fails the first failures lookups with a DNS error, then resolves every
+// host to loopback.
+type flakyResolver struct {
+	failures int32
+	calls    atomic.Int32
+}
+
+func (r *flakyResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	if r.calls.Add(1) <= r.failures {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return []net.IPAddr{{IP: net.IPv4(127, 0, 0, 1)}}, nil
+}
+
+func newFlakyClient(resolver Resolver, attempts int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&Dialer{Resolver: resolver, Attempts: attempts}).DialContext
+	return &http.Client{Transport: transport}
+}
+
+func upstreamURL(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(srv.URL, "http://"))
+	return "http://upstream.invalid:" + port + "/"
+}
+
+func TestDialer_RetriesTransientDNSFailures(t *testing.T) {
+	resolver := &flakyResolver{failures: 2}
+	resp, err := newFlakyClient(resolver, 3).Get(upstreamURL(t))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "ok" {
+		t.Fatalf("body = %q, want ok", body)
+	}
+	if got := resolver.calls.Load(); got != 3 {
+		t.Fatalf("lookups = %d, want 3", got)
+	}
+}
+
+func TestDialer_PersistentDNSFailureIsReported(t *testing.T) {
+	resolver := &flakyResolver{failures: 10}
+	_, err := newFlakyClient(resolver, 2).Get(upstreamURL(t))
+	if err == nil {
+		t.Fatal("expected the request to fail")
+	}
+	if !IsDNSError(err) {
+		t.Fatalf("error %v does not wrap the DNS error", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "dns resolution failed for upstream host upstream.invalid after 2 attempt(s)") {
+		t.Fatalf("error = %q, want a clear DNS failure message", msg)
+	}
+	if got := resolver.calls.Load(); got != 2 {
+		t.Fatalf("lookups = %d, want 2", got)
+	}
+}
+
+func TestDialer_DoesNotRetryOtherErrors(t *testing.T) {
+	failing := resolverFunc(func(context.Context, string) ([]net.IPAddr, error) {
+		return nil, errors.New("resolver exploded")
+	})
+	calls := 0
+	counting := resolverFunc(func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		calls++
+		return failing(ctx, host)
+	})
+	d := &Dialer{Resolver: counting, Attempts: 3}
+	if _, err := d.DialContext(context.Background(), "tcp", "upstream.invalid:443"); err == nil || IsDNSError(err) {
+		t.Fatalf("err = %v, want the resolver error unchanged", err)
+	}
+	if calls != 1 {
+		t.Fatalf("lookups = %d, want 1", calls)
+	}
+}
+
+type resolverFunc func(ctx context.Context, host string) ([]net.IPAddr, error)
+
+func (f resolverFunc) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	return f(ctx, host)
+}
@@ -0,0 +1,99 @@
+// Package faultinject makes a configurable share of upstream requests fail on purpose —
+// delayed, answered with an error status, or with a truncated body — so client and proxy
+// retry behavior can be exercised. It is wired in only with unsafe-fault-injection enabled.
+package faultinject
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Transport applies the configured faults to a random share of the requests it forwards.
+type Transport struct {
+	Base     http.RoundTripper
+	Settings config.FaultInjection
+	Provider string
+}
+
+// NewTransport wraps base (http.DefaultTransport when nil) with fault injection for provider.
+func NewTransport(base http.RoundTripper, settings config.FaultInjection, provider string) *Transport {
+	return &Transport{Base: base, Settings: settings, Provider: provider}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !t.affected() {
+		return base.RoundTrip(req)
+	}
+	log.Warnf("fault injection: injecting faults into %s %s (provider=%s)", req.Method, req.URL.Redacted(), t.Provider)
+
+	if latency := t.Settings.LatencyDuration(); latency > 0 {
+		timer := time.NewTimer(latency)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	if status := t.Settings.Status; status != 0 {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		body := fmt.Sprintf(`{"error":{"message":"fault injected by unsafe-fault-injection","type":"injected_fault","code":%d}}`, status)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+			StatusCode:    status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}},
+			Body:          io.NopCloser(bytes.NewBufferString(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp == nil || resp.Body == nil || t.Settings.TruncateAfterBytes <= 0 {
+		return resp, err
+	}
+	resp.Body = &truncatedBody{ReadCloser: resp.Body, remaining: t.Settings.TruncateAfterBytes}
+	resp.ContentLength = -1
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// affected rolls whether the current request gets the configured faults.
+func (t *Transport) affected() bool {
+	return t.Settings.Active() && rand.Float64() < t.Settings.Probability
+}
+
+// truncatedBody ends the body with io.ErrUnexpectedEOF once remaining bytes were read.
+type truncatedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
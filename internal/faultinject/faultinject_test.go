@@ -0,0 +1,61 @@
+package faultinject
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestTransport_Probability(t *testing.T) {
+	var upstreamCalls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls++
+		_, _ = io.WriteString(w, strings.Repeat("x", 64))
+	}))
+	t.Cleanup(srv.Close)
+
+	run := func(settings config.FaultInjection) (statuses []int, truncated int) {
+		client := &http.Client{Transport: NewTransport(nil, settings, "test")}
+		for range 20 {
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			if _, errRead := io.ReadAll(resp.Body); errors.Is(errRead, io.ErrUnexpectedEOF) {
+				truncated++
+			}
+			_ = resp.Body.Close()
+			statuses = append(statuses, resp.StatusCode)
+		}
+		return statuses, truncated
+	}
+
+	statuses, _ := run(config.FaultInjection{Enabled: true, Probability: 1, Status: http.StatusServiceUnavailable})
+	for _, status := range statuses {
+		if status != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d with probability 1, want 503", status)
+		}
+	}
+	if upstreamCalls != 0 {
+		t.Fatalf("upstream called %d times for injected statuses, want 0", upstreamCalls)
+	}
+
+	if _, truncated := run(config.FaultInjection{Enabled: true, Probability: 1, TruncateAfterBytes: 8}); truncated != 20 {
+		t.Fatalf("truncated bodies = %d with probability 1, want 20", truncated)
+	}
+
+	statuses, truncated := run(config.FaultInjection{Enabled: true, Probability: 0, Status: http.StatusServiceUnavailable, TruncateAfterBytes: 8})
+	for _, status := range statuses {
+		if status != http.StatusOK {
+			t.Fatalf("status = %d with probability 0, want 200", status)
+		}
+	}
+	if truncated != 0 {
+		t.Fatalf("truncated bodies = %d with probability 0, want 0", truncated)
+	}
+}
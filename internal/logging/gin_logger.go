@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/timing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
 )
@@ -45,11 +46,18 @@ func GinLogrusLogger() gin.HandlerFunc {
 
 		// Only generate request ID for AI API paths
 		var requestID string
+		var timer *timing.Timer
 		if isAIAPIPath(path) {
 			requestID = GenerateRequestID()
 			SetGinRequestID(c, requestID)
+			timer = timing.New(start)
+			if c.Request.ContentLength > 0 {
+				timer.AddSize(timing.SizeRequest, c.Request.ContentLength)
+			}
 			ctx := WithRequestID(c.Request.Context(), requestID)
+			ctx = timing.WithTimer(ctx, timer)
 			c.Request = c.Request.WithContext(ctx)
+			c.Writer = newTimingResponseWriter(c.Writer, timer)
 		}
 
 		c.Next()
@@ -83,6 +91,14 @@ func GinLogrusLogger() gin.HandlerFunc {
 		}
 
 		entry := log.WithField("request_id", requestID)
+		if timer != nil {
+			if breakdown := timer.ServerTiming(); breakdown != "" {
+				entry = entry.WithField("timing", breakdown)
+			}
+			if log.IsLevelEnabled(log.DebugLevel) {
+				log.WithField("request_id", requestID).WithFields(log.Fields(timer.Fields())).Debug("request timing breakdown")
+			}
+		}
 
 		switch {
 		case statusCode >= http.StatusInternalServerError:
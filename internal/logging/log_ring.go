@@ -0,0 +1,199 @@
+package logging
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// RingLogEntry is a log entry as returned by the management API. Message and field
+// values are redacted.
+type RingLogEntry struct {
+	Time      time.Time         `json:"time"`
+	Level     string            `json:"level"`
+	Message   string            `json:"message"`
+	RequestID string            `json:"request_id,omitempty"`
+	Caller    string            `json:"caller,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// LogRingQuery filters entries read from the ring buffer.
+type LogRingQuery struct {
+	// Level keeps entries at this severity or above; log.TraceLevel keeps every entry.
+	Level log.Level
+	// RequestID keeps only entries carrying this request id when non-empty.
+	RequestID string
+	// Limit keeps the most recent N matching entries when > 0.
+	Limit int
+}
+
+// ringSlot holds a raw entry. Formatting and redaction are deferred to read time so the
+// hook stays cheap on the logging path.
+type ringSlot struct {
+	time    time.Time
+	level   log.Level
+	message string
+	file    string
+	line    int
+	data    log.Fields
+}
+
+// LogRing is a fixed-size buffer of the most recent log entries.
+type LogRing struct {
+	mu      sync.Mutex
+	slots   []ringSlot
+	next    int
+	full    bool
+	dropped atomic.Int64
+}
+
+// NewLogRing creates a ring holding at most size entries.
+func NewLogRing(size int) *LogRing {
+	if size < 1 {
+		size = 1
+	}
+	return &LogRing{slots: make([]ringSlot, size)}
+}
+
+// Levels implements logrus.Hook.
+func (r *LogRing) Levels() []log.Level { return log.AllLevels }
+
+// Fire implements logrus.Hook. It never waits: when another goroutine holds the ring,
+// the entry is counted as dropped instead.
+func (r *LogRing) Fire(entry *log.Entry) error {
+	if r == nil || entry == nil {
+		return nil
+	}
+	if !r.mu.TryLock() {
+		r.dropped.Add(1)
+		return nil
+	}
+	slot := &r.slots[r.next]
+	slot.time = entry.Time
+	slot.level = entry.Level
+	slot.message = entry.Message
+	slot.file, slot.line = "", 0
+	if entry.Caller != nil {
+		slot.file, slot.line = entry.Caller.File, entry.Caller.Line
+	}
+	// logrus builds a fresh Data map for every WithField(s) call, so it is safe to keep.
+	slot.data = entry.Data
+	r.next++
+	if r.next == len(r.slots) {
+		r.next = 0
+		r.full = true
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// Dropped reports how many entries were skipped because the ring was busy.
+func (r *LogRing) Dropped() int64 {
+	if r == nil {
+		return 0
+	}
+	return r.dropped.Load()
+}
+
+// Entries returns matching entries, oldest first, with secrets redacted.
+func (r *LogRing) Entries(query LogRingQuery) []RingLogEntry {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	var ordered []ringSlot
+	if r.full {
+		ordered = append(ordered, r.slots[r.next:]...)
+	}
+	ordered = append(ordered, r.slots[:r.next]...)
+	r.mu.Unlock()
+
+	out := make([]RingLogEntry, 0, len(ordered))
+	for i := len(ordered) - 1; i >= 0; i-- {
+		if query.Limit > 0 && len(out) >= query.Limit {
+			break
+		}
+		slot := ordered[i]
+		if slot.level > query.Level {
+			continue
+		}
+		requestID, _ := slot.data["request_id"].(string)
+		if query.RequestID != "" && requestID != query.RequestID {
+			continue
+		}
+		out = append(out, slot.view(requestID))
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+func (s ringSlot) view(requestID string) RingLogEntry {
+	level := s.level.String()
+	if level == "warning" {
+		level = "warn"
+	}
+	entry := RingLogEntry{
+		Time:      s.time,
+		Level:     level,
+		Message:   util.RedactSecrets(strings.TrimRight(s.message, "\r\n")),
+		RequestID: requestID,
+	}
+	if s.file != "" {
+		entry.Caller = fmt.Sprintf("%s:%d", filepath.Base(s.file), s.line)
+	}
+	for key, value := range s.data {
+		if key == "request_id" {
+			continue
+		}
+		if entry.Fields == nil {
+			entry.Fields = make(map[string]string, len(s.data))
+		}
+		entry.Fields[key] = util.RedactSecrets(util.MaskSensitiveHeaderValue(key, fmt.Sprint(value)))
+	}
+	return entry
+}
+
+var (
+	logRing          atomic.Pointer[LogRing]
+	logRingHookOnce  sync.Once
+	logRingConfigure sync.Mutex
+)
+
+// ringHook forwards entries to the active ring, if any.
+type ringHook struct{}
+
+func (ringHook) Levels() []log.Level { return log.AllLevels }
+
+func (ringHook) Fire(entry *log.Entry) error {
+	if ring := logRing.Load(); ring != nil {
+		return ring.Fire(entry)
+	}
+	return nil
+}
+
+// ConfigureLogRing enables the in-memory log ring with room for size entries, or disables
+// it when size <= 0. Resizing keeps nothing from the previous ring.
+func ConfigureLogRing(size int) {
+	logRingConfigure.Lock()
+	defer logRingConfigure.Unlock()
+	if size <= 0 {
+		logRing.Store(nil)
+		return
+	}
+	if current := logRing.Load(); current != nil && len(current.slots) == size {
+		return
+	}
+	logRingHookOnce.Do(func() { log.AddHook(ringHook{}) })
+	logRing.Store(NewLogRing(size))
+}
+
+// ActiveLogRing returns the enabled log ring, or nil when it is disabled.
+func ActiveLogRing() *LogRing { return logRing.Load() }
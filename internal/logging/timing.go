@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/timing"
+)
+
+// TimingHeader is the response header carrying the per-phase timing breakdown
+// in Server-Timing syntax.
+const TimingHeader = "X-Cliproxy-Timing"
+
+var timingHeaderEnabled atomic.Bool
+
+// SetTimingHeaderEnabled toggles the X-Cliproxy-Timing response header.
+func SetTimingHeaderEnabled(enabled bool) { timingHeaderEnabled.Store(enabled) }
+
+// TimingHeaderEnabled reports whether the X-Cliproxy-Timing response header is emitted.
+func TimingHeaderEnabled() bool { return timingHeaderEnabled.Load() }
+
+// timingResponseWriter counts response bytes and, when enabled, stamps the timing
+// header just before the response headers are flushed to the client.
+type timingResponseWriter struct {
+	gin.ResponseWriter
+	timer         *timing.Timer
+	emitHeader    bool
+	headerStamped bool
+}
+
+func newTimingResponseWriter(w gin.ResponseWriter, timer *timing.Timer) *timingResponseWriter {
+	return &timingResponseWriter{ResponseWriter: w, timer: timer, emitHeader: TimingHeaderEnabled()}
+}
+
+func (w *timingResponseWriter) stampHeader() {
+	if w.headerStamped || w.ResponseWriter.Written() {
+		return
+	}
+	w.headerStamped = true
+	if !w.emitHeader {
+		return
+	}
+	if value := w.timer.ServerTiming(); value != "" {
+		w.ResponseWriter.Header().Set(TimingHeader, value)
+	}
+}
+
+func (w *timingResponseWriter) WriteHeaderNow() {
+	w.stampHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *timingResponseWriter) Write(data []byte) (int, error) {
+	w.stampHeader()
+	n, err := w.ResponseWriter.Write(data)
+	w.timer.AddSize(timing.SizeResponse, int64(n))
+	return n, err
+}
+
+func (w *timingResponseWriter) WriteString(s string) (int, error) {
+	w.stampHeader()
+	n, err := w.ResponseWriter.WriteString(s)
+	w.timer.AddSize(timing.SizeResponse, int64(n))
+	return n, err
+}
+
+func (w *timingResponseWriter) Flush() {
+	w.stampHeader()
+	w.ResponseWriter.Flush()
+}
@@ -0,0 +1,154 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+const (
+	jsonUsageFile = "usage.json"
+	jsonCacheFile = "cache.json"
+)
+
+// JSONStore keeps each feature in its own JSON file, rewritten atomically on every change.
+type JSONStore struct {
+	dir string
+
+	mu    sync.Mutex
+	cache map[string]CacheEntry
+}
+
+var _ Backend = (*JSONStore)(nil)
+
+// OpenJSON loads the JSON files in dir, creating the directory when missing.
+func OpenJSON(dir string) (*JSONStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("persistence: create directory: %w", err)
+	}
+	s := &JSONStore{dir: dir, cache: make(map[string]CacheEntry)}
+	var entries []CacheEntry
+	if _, err := s.readFile(jsonCacheFile, &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		s.cache[cacheKey(entry.Namespace, entry.Key)] = entry
+	}
+	return s, nil
+}
+
+// Close is a no-op; every change is already on disk.
+func (s *JSONStore) Close() error { return nil }
+
+// LoadUsage implements UsageRepository.
+func (s *JSONStore) LoadUsage(context.Context) (usage.StatisticsSnapshot, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var snapshot usage.StatisticsSnapshot
+	found, err := s.readFile(jsonUsageFile, &snapshot)
+	return snapshot, found, err
+}
+
+// SaveUsage implements UsageRepository.
+func (s *JSONStore) SaveUsage(_ context.Context, snapshot usage.StatisticsSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writeFile(jsonUsageFile, snapshot)
+}
+
+// PutCache implements CacheRepository.
+func (s *JSONStore) PutCache(_ context.Context, entry CacheEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[cacheKey(entry.Namespace, entry.Key)] = entry
+	return s.flushCache()
+}
+
+// GetCache implements CacheRepository.
+func (s *JSONStore) GetCache(_ context.Context, namespace, key string) (CacheEntry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.cache[cacheKey(namespace, key)]
+	if !ok || entry.expired(time.Now()) {
+		return CacheEntry{}, false, nil
+	}
+	return entry, true, nil
+}
+
+// DeleteCache implements CacheRepository.
+func (s *JSONStore) DeleteCache(_ context.Context, namespace, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := cacheKey(namespace, key)
+	if _, ok := s.cache[k]; !ok {
+		return nil
+	}
+	delete(s.cache, k)
+	return s.flushCache()
+}
+
+// PurgeExpiredCache implements CacheRepository.
+func (s *JSONStore) PurgeExpiredCache(_ context.Context, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purged := 0
+	for k, entry := range s.cache {
+		if entry.expired(now) {
+			delete(s.cache, k)
+			purged++
+		}
+	}
+	if purged == 0 {
+		return 0, nil
+	}
+	return purged, s.flushCache()
+}
+
+func (s *JSONStore) flushCache() error {
+	out := make([]CacheEntry, 0, len(s.cache))
+	for _, entry := range s.cache {
+		out = append(out, entry)
+	}
+	return s.writeFile(jsonCacheFile, out)
+}
+
+func (s *JSONStore) readFile(name string, out any) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("persistence: read %s: %w", name, err)
+	}
+	if len(data) == 0 {
+		return false, nil
+	}
+	if err = json.Unmarshal(data, out); err != nil {
+		return false, fmt.Errorf("persistence: decode %s: %w", name, err)
+	}
+	return true, nil
+}
+
+func (s *JSONStore) writeFile(name string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("persistence: encode %s: %w", name, err)
+	}
+	if err = util.AtomicWriteFile(filepath.Join(s.dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("persistence: write %s: %w", name, err)
+	}
+	return nil
+}
+
+func cacheKey(namespace, key string) string {
+	return namespace + "\x00" + key
+}
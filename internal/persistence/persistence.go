@@ -0,0 +1,113 @@
+// Package persistence stores state that should survive restarts behind small repository
+// interfaces. Usage statistics and namespaced cache keys share one backend: JSON files or an
+// embedded SQLite database.
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+const (
+	// BackendJSON keeps one JSON file per feature in a directory.
+	BackendJSON = "json"
+	// BackendSQLite keeps every feature in one embedded SQLite database.
+	BackendSQLite = "sqlite"
+
+	defaultJSONDir    = "persistence"
+	defaultSQLiteFile = "cliproxy.db"
+)
+
+// CacheEntry is a namespaced key/value pair such as the running budget spend.
+type CacheEntry struct {
+	Namespace string
+	Key       string
+	Value     string
+	// ExpiresAt is zero for entries that never expire.
+	ExpiresAt time.Time
+}
+
+func (e CacheEntry) expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !e.ExpiresAt.After(now)
+}
+
+// UsageRepository persists the aggregated usage statistics snapshot.
+type UsageRepository interface {
+	LoadUsage(ctx context.Context) (usage.StatisticsSnapshot, bool, error)
+	SaveUsage(ctx context.Context, snapshot usage.StatisticsSnapshot) error
+}
+
+// CacheRepository persists namespaced cache keys. Expired entries are never returned.
+type CacheRepository interface {
+	PutCache(ctx context.Context, entry CacheEntry) error
+	GetCache(ctx context.Context, namespace, key string) (CacheEntry, bool, error)
+	DeleteCache(ctx context.Context, namespace, key string) error
+	PurgeExpiredCache(ctx context.Context, now time.Time) (int, error)
+}
+
+// Backend bundles every repository of one storage implementation.
+type Backend interface {
+	UsageRepository
+	CacheRepository
+	Close() error
+}
+
+// Open creates the backend selected by cfg.Persistence. It returns nil without error when
+// persistence is disabled. configDir is the base for relative paths when WRITABLE_PATH is unset.
+func Open(ctx context.Context, cfg *config.Config, configDir string) (Backend, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	kind := strings.ToLower(strings.TrimSpace(cfg.Persistence))
+	switch kind {
+	case "":
+		return nil, nil
+	case BackendJSON, BackendSQLite:
+	default:
+		return nil, fmt.Errorf("persistence: unknown backend %q (want %q or %q)", cfg.Persistence, BackendJSON, BackendSQLite)
+	}
+	base := util.WritablePath()
+	if base == "" {
+		base = configDir
+	}
+	fallback := defaultJSONDir
+	if kind == BackendSQLite {
+		fallback = defaultSQLiteFile
+	}
+	path, err := resolvePath(base, cfg.PersistencePath, fallback)
+	if err != nil {
+		return nil, err
+	}
+	if kind == BackendSQLite {
+		return OpenSQLite(ctx, path)
+	}
+	return OpenJSON(path)
+}
+
+// resolvePath joins path onto base and rejects results that escape base.
+func resolvePath(base, path, fallback string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		path = fallback
+	}
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return "", fmt.Errorf("persistence: resolve base directory: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(absBase, path)
+	}
+	path = filepath.Clean(path)
+	rel, err := filepath.Rel(absBase, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("persistence: path %s is outside %s", path, absBase)
+	}
+	return path, nil
+}
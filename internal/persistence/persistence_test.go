@@ -0,0 +1,137 @@
+package persistence
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+type backendFactory struct {
+	name string
+	open func(t *testing.T, dir string) Backend
+}
+
+var backends = []backendFactory{
+	{name: BackendJSON, open: func(t *testing.T, dir string) Backend {
+		s, err := OpenJSON(filepath.Join(dir, "json"))
+		if err != nil {
+			t.Fatalf("OpenJSON: %v", err)
+		}
+		return s
+	}},
+	{name: BackendSQLite, open: func(t *testing.T, dir string) Backend {
+		s, err := OpenSQLite(context.Background(), filepath.Join(dir, "state.db"))
+		if err != nil {
+			t.Fatalf("OpenSQLite: %v", err)
+		}
+		return s
+	}},
+}
+
+func TestBackends_RoundTripAndSurviveReopen(t *testing.T) {
+	for _, factory := range backends {
+		t.Run(factory.name, func(t *testing.T) {
+			ctx := context.Background()
+			dir := t.TempDir()
+			b := factory.open(t, dir)
+
+			if _, found, err := b.LoadUsage(ctx); err != nil || found {
+				t.Fatalf("empty LoadUsage = found %t, err %v", found, err)
+			}
+			snapshot := usage.StatisticsSnapshot{TotalRequests: 3, SuccessCount: 2, FailureCount: 1, TotalTokens: 42}
+			if err := b.SaveUsage(ctx, snapshot); err != nil {
+				t.Fatalf("SaveUsage: %v", err)
+			}
+
+			if err := b.PutCache(ctx, CacheEntry{Namespace: "codex", Key: "k", Value: "v1"}); err != nil {
+				t.Fatalf("PutCache: %v", err)
+			}
+			if err := b.PutCache(ctx, CacheEntry{Namespace: "codex", Key: "k", Value: "v2", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+				t.Fatalf("PutCache overwrite: %v", err)
+			}
+			if err := b.PutCache(ctx, CacheEntry{Namespace: "other", Key: "k", Value: "other"}); err != nil {
+				t.Fatalf("PutCache namespace: %v", err)
+			}
+			if err := b.PutCache(ctx, CacheEntry{Namespace: "codex", Key: "stale", Value: "x", ExpiresAt: time.Now().Add(-time.Second)}); err != nil {
+				t.Fatalf("PutCache expired: %v", err)
+			}
+			if err := b.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			b = factory.open(t, dir)
+			defer func() { _ = b.Close() }()
+
+			got, found, err := b.LoadUsage(ctx)
+			if err != nil || !found || got.TotalRequests != 3 || got.TotalTokens != 42 {
+				t.Fatalf("LoadUsage = %+v, found %t, err %v", got, found, err)
+			}
+
+			entry, found, err := b.GetCache(ctx, "codex", "k")
+			if err != nil || !found || entry.Value != "v2" || entry.ExpiresAt.IsZero() {
+				t.Fatalf("GetCache = %+v, found %t, err %v", entry, found, err)
+			}
+			if entry, _, _ = b.GetCache(ctx, "other", "k"); entry.Value != "other" {
+				t.Fatalf("namespaces collide: %+v", entry)
+			}
+			if _, found, _ = b.GetCache(ctx, "codex", "stale"); found {
+				t.Fatal("expired cache entry returned")
+			}
+			if n, errPurge := b.PurgeExpiredCache(ctx, time.Now()); errPurge != nil || n != 1 {
+				t.Fatalf("PurgeExpiredCache = %d, %v; want 1", n, errPurge)
+			}
+			if err = b.DeleteCache(ctx, "codex", "k"); err != nil {
+				t.Fatalf("DeleteCache: %v", err)
+			}
+			if _, found, _ = b.GetCache(ctx, "codex", "k"); found {
+				t.Fatal("deleted cache entry returned")
+			}
+		})
+	}
+}
+
+func TestOpenSQLite_MigratesOnceAndUsesWAL(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.db")
+	for i := 0; i < 2; i++ {
+		s, err := OpenSQLite(ctx, path)
+		if err != nil {
+			t.Fatalf("OpenSQLite #%d: %v", i, err)
+		}
+		if version, _ := s.SchemaVersion(ctx); version != len(sqliteMigrations) {
+			t.Fatalf("schema version = %d, want %d", version, len(sqliteMigrations))
+		}
+		var mode string
+		if err = s.db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode); err != nil || mode != "wal" {
+			t.Fatalf("journal_mode = %q, %v", mode, err)
+		}
+		_ = s.Close()
+	}
+}
+
+func TestOpen_SelectsBackendAndConfinesPath(t *testing.T) {
+	t.Setenv("WRITABLE_PATH", t.TempDir())
+	ctx := context.Background()
+
+	if b, err := Open(ctx, &config.Config{}, ""); err != nil || b != nil {
+		t.Fatalf("disabled Open = %v, %v", b, err)
+	}
+	b, err := Open(ctx, &config.Config{Persistence: "sqlite"}, "")
+	if err != nil {
+		t.Fatalf("Open sqlite: %v", err)
+	}
+	if _, ok := b.(*SQLiteStore); !ok {
+		t.Fatalf("backend = %T, want *SQLiteStore", b)
+	}
+	_ = b.Close()
+	if _, err = Open(ctx, &config.Config{Persistence: "sqlite", PersistencePath: "../escape.db"}, ""); err == nil {
+		t.Fatal("path outside the writable directory accepted")
+	}
+	if _, err = Open(ctx, &config.Config{Persistence: "bolt"}, ""); err == nil {
+		t.Fatal("unknown backend accepted")
+	}
+}
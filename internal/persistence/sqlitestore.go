@@ -0,0 +1,228 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// sqliteMaxOpenConns bounds the pool; WAL allows concurrent readers next to one writer.
+	sqliteMaxOpenConns = 4
+	sqliteBusyTimeout  = 5 * time.Second
+)
+
+// sqliteMigrations are applied in order at open; index+1 is the schema version.
+// Append new migrations, never edit shipped ones.
+var sqliteMigrations = []string{
+	`CREATE TABLE usage_snapshot (
+		id         INTEGER PRIMARY KEY CHECK (id = 1),
+		payload    TEXT    NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+	CREATE TABLE cache_entries (
+		namespace  TEXT    NOT NULL,
+		key        TEXT    NOT NULL,
+		value      TEXT    NOT NULL,
+		expires_at INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (namespace, key)
+	);
+	CREATE INDEX cache_entries_expires_at ON cache_entries (expires_at) WHERE expires_at > 0;`,
+}
+
+// SQLiteStore keeps every feature in one embedded SQLite database (pure Go, no cgo).
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+var _ Backend = (*SQLiteStore)(nil)
+
+// OpenSQLite opens the database at path in WAL mode, verifies its integrity and runs
+// pending migrations.
+func OpenSQLite(ctx context.Context, path string) (*SQLiteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("persistence: create database directory: %w", err)
+	}
+	query := url.Values{}
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", sqliteBusyTimeout.Milliseconds()))
+	query.Add("_pragma", "synchronous(NORMAL)")
+	query.Add("_pragma", "foreign_keys(ON)")
+	db, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path)+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("persistence: open sqlite database: %w", err)
+	}
+	db.SetMaxOpenConns(sqliteMaxOpenConns)
+	db.SetMaxIdleConns(sqliteMaxOpenConns)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+
+	s := &SQLiteStore{db: db}
+	if err = s.checkIntegrity(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if err = s.migrate(ctx); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close releases the database.
+func (s *SQLiteStore) Close() error {
+	if s == nil || s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) checkIntegrity(ctx context.Context) error {
+	var result string
+	if err := s.db.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("persistence: sqlite integrity check: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("persistence: sqlite integrity check failed: %s", result)
+	}
+	return nil
+}
+
+// SchemaVersion returns the number of applied migrations.
+func (s *SQLiteStore) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	if err := s.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("persistence: read schema version: %w", err)
+	}
+	return version, nil
+}
+
+func (s *SQLiteStore) migrate(ctx context.Context) error {
+	version, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("persistence: database schema version %d is newer than this build (%d)", version, len(sqliteMigrations))
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, errBegin := s.db.BeginTx(ctx, nil)
+		if errBegin != nil {
+			return fmt.Errorf("persistence: begin migration %d: %w", i+1, errBegin)
+		}
+		if _, errExec := tx.ExecContext(ctx, sqliteMigrations[i]); errExec != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("persistence: apply migration %d: %w", i+1, errExec)
+		}
+		if _, errExec := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); errExec != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("persistence: record migration %d: %w", i+1, errExec)
+		}
+		if errCommit := tx.Commit(); errCommit != nil {
+			return fmt.Errorf("persistence: commit migration %d: %w", i+1, errCommit)
+		}
+	}
+	return nil
+}
+
+// LoadUsage implements UsageRepository.
+func (s *SQLiteStore) LoadUsage(ctx context.Context) (usage.StatisticsSnapshot, bool, error) {
+	var snapshot usage.StatisticsSnapshot
+	var payload string
+	err := s.db.QueryRowContext(ctx, "SELECT payload FROM usage_snapshot WHERE id = 1").Scan(&payload)
+	if errors.Is(err, sql.ErrNoRows) {
+		return snapshot, false, nil
+	}
+	if err != nil {
+		return snapshot, false, fmt.Errorf("persistence: load usage: %w", err)
+	}
+	if err = json.Unmarshal([]byte(payload), &snapshot); err != nil {
+		return snapshot, false, fmt.Errorf("persistence: decode usage: %w", err)
+	}
+	return snapshot, true, nil
+}
+
+// SaveUsage implements UsageRepository.
+func (s *SQLiteStore) SaveUsage(ctx context.Context, snapshot usage.StatisticsSnapshot) error {
+	payload, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("persistence: encode usage: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO usage_snapshot (id, payload, updated_at) VALUES (1, ?, ?)
+		 ON CONFLICT (id) DO UPDATE SET payload = excluded.payload, updated_at = excluded.updated_at`,
+		string(payload), time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("persistence: save usage: %w", err)
+	}
+	return nil
+}
+
+// PutCache implements CacheRepository.
+func (s *SQLiteStore) PutCache(ctx context.Context, entry CacheEntry) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO cache_entries (namespace, key, value, expires_at) VALUES (?, ?, ?, ?)
+		 ON CONFLICT (namespace, key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at`,
+		entry.Namespace, entry.Key, entry.Value, toUnixNano(entry.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("persistence: save cache entry: %w", err)
+	}
+	return nil
+}
+
+// GetCache implements CacheRepository.
+func (s *SQLiteStore) GetCache(ctx context.Context, namespace, key string) (CacheEntry, bool, error) {
+	entry := CacheEntry{Namespace: namespace, Key: key}
+	var expiresAt int64
+	err := s.db.QueryRowContext(ctx,
+		"SELECT value, expires_at FROM cache_entries WHERE namespace = ? AND key = ? AND (expires_at = 0 OR expires_at > ?)",
+		namespace, key, time.Now().UnixNano()).Scan(&entry.Value, &expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CacheEntry{}, false, nil
+	}
+	if err != nil {
+		return CacheEntry{}, false, fmt.Errorf("persistence: load cache entry: %w", err)
+	}
+	entry.ExpiresAt = fromUnixNano(expiresAt)
+	return entry, true, nil
+}
+
+// DeleteCache implements CacheRepository.
+func (s *SQLiteStore) DeleteCache(ctx context.Context, namespace, key string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM cache_entries WHERE namespace = ? AND key = ?", namespace, key); err != nil {
+		return fmt.Errorf("persistence: delete cache entry: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpiredCache implements CacheRepository.
+func (s *SQLiteStore) PurgeExpiredCache(ctx context.Context, now time.Time) (int, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM cache_entries WHERE expires_at > 0 AND expires_at <= ?", now.UnixNano())
+	if err != nil {
+		return 0, fmt.Errorf("persistence: purge cache entries: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+func toUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
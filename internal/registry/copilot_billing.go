@@ -0,0 +1,102 @@
+package registry
+
+import "strings"
+
+// DefaultCopilotPremiumMultiplier applies to Copilot models missing from the built-in table.
+const DefaultCopilotPremiumMultiplier = 1.0
+
+// copilotPremiumMultipliers lists premium-request multipliers per Copilot model family.
+// A key matches the model ID itself and any "-<suffix>" variant (reasoning effort aliases,
+// "-fast", ...); the longest matching key wins. Values follow GitHub's published table for
+// paid plans and can be overridden through the models override file.
+var copilotPremiumMultipliers = map[string]float64{
+	"gpt-4.1":                0,
+	"gpt-4o":                 0,
+	"gpt-41-copilot":         0,
+	"gpt-5-mini":             0,
+	"raptor-mini":            0,
+	"oswe-vscode-prime":      0,
+	"grok-code-fast-1":       0.25,
+	"claude-haiku-4.5":       0.33,
+	"gemini-3-flash-preview": 0.33,
+	"gpt-5-codex-mini":       0.33,
+	"gpt-5.1-codex-mini":     0.33,
+	"gpt-5":                  1,
+	"gpt-5-codex":            1,
+	"gpt-5.1":                1,
+	"gpt-5.1-codex":          1,
+	"gpt-5.1-codex-max":      1,
+	"gpt-5.2":                1,
+	"gpt-5.2-codex":          1,
+	"gpt-5.3-codex":          1,
+	"claude-sonnet-4":        1,
+	"claude-sonnet-4.5":      1,
+	"claude-sonnet-4.6":      1,
+	"gemini-2.5-pro":         1,
+	"gemini-3-pro-preview":   1,
+	"claude-opus-4.5":        3,
+	"claude-opus-4.6":        3,
+	"claude-opus-41":         10,
+}
+
+// CopilotPremiumMultiplier returns the built-in premium-request multiplier for a Copilot
+// model ID. The "copilot-" routing prefix is ignored.
+func CopilotPremiumMultiplier(modelID string) float64 {
+	id := strings.ToLower(strings.TrimSpace(modelID))
+	id = strings.TrimPrefix(id, CopilotModelPrefix)
+	if multiplier, ok := copilotPremiumMultipliers[id]; ok {
+		return multiplier
+	}
+	best, multiplier := "", DefaultCopilotPremiumMultiplier
+	for family, value := range copilotPremiumMultipliers {
+		if len(family) > len(best) && strings.HasPrefix(id, family+"-") {
+			best, multiplier = family, value
+		}
+	}
+	return multiplier
+}
+
+// NewCopilotBilling returns billing metadata for a premium-request multiplier.
+func NewCopilotBilling(multiplier float64) *BillingInfo {
+	if multiplier < 0 {
+		multiplier = 0
+	}
+	return &BillingInfo{PremiumMultiplier: multiplier, IsPremium: multiplier > 0}
+}
+
+// ApplyCopilotBilling returns copies of models carrying billing metadata. overrides maps
+// model IDs (with or without the "copilot-" prefix) to multipliers and wins over billing
+// reported by the Copilot API, which in turn wins over the built-in table.
+func ApplyCopilotBilling(models []*ModelInfo, overrides map[string]float64) []*ModelInfo {
+	normalized := make(map[string]float64, len(overrides))
+	for id, multiplier := range overrides {
+		key := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(id)), CopilotModelPrefix)
+		if key != "" {
+			normalized[key] = multiplier
+		}
+	}
+	result := make([]*ModelInfo, 0, len(models))
+	for _, model := range models {
+		if model == nil {
+			continue
+		}
+		clone := *model
+		key := strings.TrimPrefix(strings.ToLower(clone.ID), CopilotModelPrefix)
+		if multiplier, ok := normalized[key]; ok {
+			clone.Billing = NewCopilotBilling(multiplier)
+		} else if clone.Billing == nil {
+			clone.Billing = NewCopilotBilling(CopilotPremiumMultiplier(clone.ID))
+		}
+		result = append(result, &clone)
+	}
+	return result
+}
+
+// CopilotPremiumRequests returns the premium requests one Copilot request to modelID
+// consumes, preferring the billing registered for the model over the built-in table.
+func CopilotPremiumRequests(modelID string) float64 {
+	if info := GetGlobalRegistry().GetModelInfo(modelID, "copilot"); info != nil && info.Billing != nil {
+		return info.Billing.PremiumMultiplier
+	}
+	return CopilotPremiumMultiplier(modelID)
+}
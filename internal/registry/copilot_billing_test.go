@@ -0,0 +1,78 @@
+package registry
+
+import "testing"
+
+func TestCopilotPremiumMultiplier_MatchesFamilies(t *testing.T) {
+	cases := map[string]float64{
+		"gpt-4.1":                0,
+		"gpt-5-mini-high":        0,
+		"gpt-5-minimal":          1,
+		"gpt-5.1-codex-mini-low": 0.33,
+		"copilot-claude-opus-41": 10,
+		"claude-opus-4.6-fast":   3,
+		"unknown-model":          DefaultCopilotPremiumMultiplier,
+	}
+	for model, want := range cases {
+		if got := CopilotPremiumMultiplier(model); got != want {
+			t.Errorf("CopilotPremiumMultiplier(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
+
+func TestApplyCopilotBilling_Precedence(t *testing.T) {
+	upstream := &ModelInfo{ID: "gpt-5", Billing: NewCopilotBilling(2)}
+	models := []*ModelInfo{
+		upstream,
+		{ID: "copilot-gpt-4o"},
+		{ID: "claude-opus-41"},
+	}
+	got := ApplyCopilotBilling(models, map[string]float64{"GPT-4o": 0.5})
+
+	want := map[string]float64{"gpt-5": 2, "copilot-gpt-4o": 0.5, "claude-opus-41": 10}
+	if len(got) != len(want) {
+		t.Fatalf("got %d models, want %d", len(got), len(want))
+	}
+	for _, model := range got {
+		if model.Billing == nil || model.Billing.PremiumMultiplier != want[model.ID] {
+			t.Fatalf("%s billing = %+v, want multiplier %v", model.ID, model.Billing, want[model.ID])
+		}
+		if model.Billing.IsPremium != (want[model.ID] > 0) {
+			t.Fatalf("%s is_premium = %v", model.ID, model.Billing.IsPremium)
+		}
+	}
+	if models[1].Billing != nil {
+		t.Fatalf("ApplyCopilotBilling must not mutate its input")
+	}
+
+	overridden := ApplyCopilotBilling(models, map[string]float64{"gpt-5": 0})
+	if overridden[0].Billing.PremiumMultiplier != 0 || overridden[0].Billing.IsPremium {
+		t.Fatalf("override must win over upstream billing, got %+v", overridden[0].Billing)
+	}
+}
+
+func TestToOpenAIModelMap_Billing(t *testing.T) {
+	out := ToOpenAIModelMap(&ModelInfo{ID: "claude-haiku-4.5", Billing: NewCopilotBilling(0.33)})
+	billing, ok := out["billing"].(map[string]any)
+	if !ok {
+		t.Fatalf("billing missing from %v", out)
+	}
+	if billing["premium_multiplier"] != 0.33 || billing["is_premium"] != true {
+		t.Fatalf("billing = %v", billing)
+	}
+	if _, ok = ToOpenAIModelMap(&ModelInfo{ID: "gpt-5"})["billing"]; ok {
+		t.Fatalf("billing must be omitted for token-priced models")
+	}
+}
+
+func TestCopilotPremiumRequests_PrefersRegisteredBilling(t *testing.T) {
+	reg := GetGlobalRegistry()
+	reg.RegisterClient("copilot-billing-test", "copilot", []*ModelInfo{{ID: "billing-test-model", Billing: NewCopilotBilling(7)}})
+	t.Cleanup(func() { reg.UnregisterClient("copilot-billing-test") })
+
+	if got := CopilotPremiumRequests("billing-test-model"); got != 7 {
+		t.Fatalf("CopilotPremiumRequests = %v, want 7", got)
+	}
+	if got := CopilotPremiumRequests("claude-haiku-4.5"); got != 0.33 {
+		t.Fatalf("fallback CopilotPremiumRequests = %v, want 0.33", got)
+	}
+}
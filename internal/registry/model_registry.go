@@ -52,6 +52,9 @@ type ModelInfo struct {
 	// This is optional and currently used for Gemini thinking budget normalization.
 	Thinking *ThinkingSupport `json:"thinking,omitempty"`
 
+	// Billing holds request-based billing metadata, currently set for GitHub Copilot models.
+	Billing *BillingInfo `json:"billing,omitempty"`
+
 	// UserDefined indicates this model was defined through config file's models[]
 	// array (e.g., openai-compatibility.*.models[], *-api-key.models[]).
 	// UserDefined models have thinking configuration passed through without validation.
@@ -74,6 +77,14 @@ type ThinkingSupport struct {
 	Levels []string `json:"levels,omitempty"`
 }
 
+// BillingInfo describes how a provider bills requests to a model instead of by tokens.
+type BillingInfo struct {
+	// PremiumMultiplier is the number of premium requests one request consumes (0 = included).
+	PremiumMultiplier float64 `json:"premium_multiplier"`
+	// IsPremium reports whether requests count against the premium request allowance.
+	IsPremium bool `json:"is_premium"`
+}
+
 // ModelRegistration tracks a model's availability
 type ModelRegistration struct {
 	// Info contains the model metadata
@@ -987,6 +998,11 @@ func (r *ModelRegistry) GetModelInfo(modelID, provider string) *ModelInfo {
 	return nil
 }
 
+// FormatModel renders model in the listing format used by the given handler type.
+func (r *ModelRegistry) FormatModel(model *ModelInfo, handlerType string) map[string]any {
+	return r.convertModelToMap(model, handlerType)
+}
+
 // convertModelToMap converts ModelInfo to the appropriate format for different handler types
 func (r *ModelRegistry) convertModelToMap(model *ModelInfo, handlerType string) map[string]any {
 	if model == nil {
@@ -12,7 +12,8 @@ package registry
 //   - max_completion_tokens
 //
 // When provider-native limits are available instead (e.g., Gemini's inputTokenLimit /
-// outputTokenLimit), this function falls back to those values.
+// outputTokenLimit), this function falls back to those values. Models billed per
+// request rather than per token also carry a "billing" object.
 func ToOpenAIModelMap(info *ModelInfo) map[string]any {
 	if info == nil {
 		return nil
@@ -54,5 +55,13 @@ func ToOpenAIModelMap(info *ModelInfo) map[string]any {
 		result["outputTokenLimit"] = info.OutputTokenLimit
 	}
 
+	// Request-based billing (e.g. Copilot premium request multipliers).
+	if info.Billing != nil {
+		result["billing"] = map[string]any{
+			"premium_multiplier": info.Billing.PremiumMultiplier,
+			"is_premium":         info.Billing.IsPremium,
+		}
+	}
+
 	return result
 }
@@ -1,22 +1,127 @@
 package executor
 
 import (
+	"container/list"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
 )
 
 type codexCache struct {
 	ID     string
 	Expire time.Time
+	// Model labels the entry in cache metrics.
+	Model string
 }
 
-// codexCacheMap stores prompt cache IDs keyed by model+user_id.
-// Protected by codexCacheMu. Entries expire after 1 hour.
+const (
+	// codexCacheDefaultTTL is how long a prompt cache ID is kept without codex-cache.ttl.
+	codexCacheDefaultTTL = time.Hour
+	// codexCacheDefaultMaxEntries bounds the cache without codex-cache.max-entries.
+	codexCacheDefaultMaxEntries = 10000
+)
+
+// codexCacheMap stores prompt cache IDs keyed by model+user_id, least recently used first
+// in codexCacheLRU. Protected by codexCacheMu. Entries expire after the configured TTL and
+// the least recently used one is evicted once the configured capacity is exceeded.
 var (
-	codexCacheMap = make(map[string]codexCache)
-	codexCacheMu  sync.RWMutex
+	codexCacheMap        = make(map[string]*list.Element)
+	codexCacheLRU        = list.New()
+	codexCacheMaxEntries = codexCacheDefaultMaxEntries
+	codexCacheMu         sync.Mutex
+
+	codexCacheStats struct {
+		hits, misses, evictions, expired atomic.Int64
+	}
 )
 
+type codexCacheItem struct {
+	key   string
+	cache codexCache
+}
+
+// CodexCacheStats reports the Codex prompt cache ID cache since process start.
+type CodexCacheStats struct {
+	Entries    int   `json:"entries"`
+	MaxEntries int   `json:"max_entries"`
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+	// Evictions counts live entries dropped to stay within MaxEntries.
+	Evictions int64 `json:"evictions"`
+	// Expired counts entries dropped after their TTL.
+	Expired int64 `json:"expired"`
+}
+
+// CodexCacheSnapshot returns the current size and counters of the Codex prompt cache.
+func CodexCacheSnapshot() CodexCacheStats {
+	codexCacheMu.Lock()
+	entries, maxEntries := codexCacheLRU.Len(), codexCacheMaxEntries
+	codexCacheMu.Unlock()
+	return CodexCacheStats{
+		Entries:    entries,
+		MaxEntries: maxEntries,
+		Hits:       codexCacheStats.hits.Load(),
+		Misses:     codexCacheStats.misses.Load(),
+		Evictions:  codexCacheStats.evictions.Load(),
+		Expired:    codexCacheStats.expired.Load(),
+	}
+}
+
+// codexCacheSettings returns the cache capacity and TTL: CODEX_CACHE_MAX_ENTRIES and
+// CODEX_CACHE_TTL_SECONDS when set, else codex-cache.max-entries and codex-cache.ttl, with
+// defaults for unset or invalid values.
+func codexCacheSettings(cfg *config.Config) (maxEntries int, ttl time.Duration) {
+	maxEntries, ttl = codexCacheDefaultMaxEntries, codexCacheDefaultTTL
+	if cfg != nil {
+		if cfg.CodexCache.MaxEntries > 0 {
+			maxEntries = cfg.CodexCache.MaxEntries
+		}
+		if raw := strings.TrimSpace(cfg.CodexCache.TTL); raw != "" {
+			if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+				ttl = d
+			} else {
+				log.Warnf("codex cache: ignoring codex-cache.ttl %q, using %s", raw, ttl)
+			}
+		}
+	}
+	if v := codexCacheEnvInt("CODEX_CACHE_MAX_ENTRIES"); v > 0 {
+		maxEntries = v
+	}
+	if v := codexCacheEnvInt("CODEX_CACHE_TTL_SECONDS"); v > 0 {
+		ttl = time.Duration(v) * time.Second
+	}
+	return maxEntries, ttl
+}
+
+// codexCacheEnvWarned remembers invalid env values already warned about.
+var codexCacheEnvWarned sync.Map
+
+// codexCacheEnvInt returns the positive integer in the environment variable key, or 0 when
+// it is unset or invalid.
+func codexCacheEnvInt(key string) int {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return 0
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v <= 0 {
+		if _, warned := codexCacheEnvWarned.LoadOrStore(key+"="+raw, struct{}{}); !warned {
+			log.Warnf("codex cache: ignoring %s=%q (expected a positive integer)", key, raw)
+		}
+		return 0
+	}
+	return v
+}
+
 // codexCacheCleanupInterval controls how often expired entries are purged.
 const codexCacheCleanupInterval = 15 * time.Minute
 
@@ -43,36 +148,101 @@ func purgeExpiredCodexCache() {
 	codexCacheMu.Lock()
 	defer codexCacheMu.Unlock()
 
-	for key, cache := range codexCacheMap {
-		if cache.Expire.Before(now) {
-			delete(codexCacheMap, key)
+	for key, elem := range codexCacheMap {
+		if elem.Value.(*codexCacheItem).cache.Expire.Before(now) {
+			removeCodexCacheLocked(key, elem)
+			codexCacheStats.expired.Add(1)
 		}
 	}
 }
 
-// getCodexCache retrieves a cached entry, returning ok=false if not found or expired.
+// codexPromptCacheID returns the prompt cache entry for model and a Claude
+// metadata.user_id, creating it on a miss, and reports the hit or miss and any evictions
+// to the cache metrics sink.
+func codexPromptCacheID(ctx context.Context, cfg *config.Config, model, userID string) codexCache {
+	key := fmt.Sprintf("%s-%s", model, userID)
+	if cache, ok := getCodexCache(key); ok {
+		observeCodexCache(ctx, CodexCacheOutcomeHit, model)
+		return cache
+	}
+	observeCodexCache(ctx, CodexCacheOutcomeMiss, model)
+	maxEntries, ttl := codexCacheSettings(cfg)
+	cache := codexCache{
+		// Deterministic cache ID (stable across restarts and evictions) to maximize
+		// upstream prompt cache prefix matching.
+		ID:     uuid.NewSHA1(uuid.Nil, []byte(key)).String(),
+		Expire: time.Now().Add(ttl),
+		Model:  model,
+	}
+	for _, evictedModel := range setCodexCache(key, cache, maxEntries) {
+		observeCodexCache(ctx, CodexCacheOutcomeEviction, evictedModel)
+	}
+	return cache
+}
+
+// getCodexCache retrieves a cached entry, returning ok=false if not found or expired. A hit
+// marks the entry as most recently used.
 func getCodexCache(key string) (codexCache, bool) {
 	codexCacheCleanupOnce.Do(startCodexCacheCleanup)
-	codexCacheMu.RLock()
-	cache, ok := codexCacheMap[key]
-	codexCacheMu.RUnlock()
+	codexCacheMu.Lock()
+	var cache codexCache
+	elem, ok := codexCacheMap[key]
+	if ok {
+		codexCacheLRU.MoveToBack(elem)
+		cache = elem.Value.(*codexCacheItem).cache
+	}
+	codexCacheMu.Unlock()
 	if !ok || cache.Expire.Before(time.Now()) {
+		codexCacheStats.misses.Add(1)
 		return codexCache{}, false
 	}
+	codexCacheStats.hits.Add(1)
 	return cache, true
 }
 
-// setCodexCache stores a cache entry.
-func setCodexCache(key string, cache codexCache) {
+// setCodexCache stores a cache entry, evicting the least recently used entries beyond
+// maxEntries. It returns the models of the evicted entries.
+func setCodexCache(key string, cache codexCache, maxEntries int) (evicted []string) {
 	codexCacheCleanupOnce.Do(startCodexCacheCleanup)
 	codexCacheMu.Lock()
-	codexCacheMap[key] = cache
-	codexCacheMu.Unlock()
+	defer codexCacheMu.Unlock()
+	return putCodexCacheLocked(key, cache, maxEntries)
+}
+
+// putCodexCacheLocked stores cache as the most recently used entry and evicts from the
+// least recently used end down to maxEntries, returning the models of the evicted
+// entries. Callers must hold codexCacheMu.
+func putCodexCacheLocked(key string, cache codexCache, maxEntries int) (evicted []string) {
+	if maxEntries > 0 {
+		codexCacheMaxEntries = maxEntries
+	}
+	if elem, ok := codexCacheMap[key]; ok {
+		elem.Value.(*codexCacheItem).cache = cache
+		codexCacheLRU.MoveToBack(elem)
+	} else {
+		codexCacheMap[key] = codexCacheLRU.PushBack(&codexCacheItem{key: key, cache: cache})
+	}
+	for codexCacheLRU.Len() > codexCacheMaxEntries {
+		oldest := codexCacheLRU.Front()
+		item := oldest.Value.(*codexCacheItem)
+		removeCodexCacheLocked(item.key, oldest)
+		codexCacheStats.evictions.Add(1)
+		evicted = append(evicted, item.cache.Model)
+	}
+	return evicted
+}
+
+// removeCodexCacheLocked drops the in-memory entry. Callers must hold codexCacheMu.
+func removeCodexCacheLocked(key string, elem *list.Element) {
+	codexCacheLRU.Remove(elem)
+	delete(codexCacheMap, key)
 }
 
 // deleteCodexCache deletes a cache entry.
 func deleteCodexCache(key string) {
 	codexCacheMu.Lock()
-	delete(codexCacheMap, key)
+	if elem, ok := codexCacheMap[key]; ok {
+		removeCodexCacheLocked(key, elem)
+	}
 	codexCacheMu.Unlock()
 }
@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"regexp"
 	"strconv"
 	"sort"
 	"strings"
@@ -736,33 +735,12 @@ func formatChutesResponseHeaders(h http.Header) map[string]string {
 	return headers
 }
 
-// sensitiveBodyPatterns contains regex patterns for sensitive data in response bodies.
-var sensitiveBodyPatterns = []*regexp.Regexp{
-	// API keys: sk-..., pk-..., key-..., etc.
-	regexp.MustCompile(`(?i)(["\']?(?:api[_-]?key|secret[_-]?key|access[_-]?key|auth[_-]?token|bearer)["\']?\s*[:=]\s*["\']?)([a-zA-Z0-9_-]{8,})(["\']?)`),
-	// Bearer tokens in JSON
-	regexp.MustCompile(`(?i)(["\']?bearer["\']?\s*[:=]\s*["\']?)([a-zA-Z0-9_.-]{20,})(["\']?)`),
-	// Generic long tokens/keys that look like secrets
-	regexp.MustCompile(`(?i)(["\']?(?:token|credential|password|secret)["\']?\s*[:=]\s*["\']?)([a-zA-Z0-9_+/=-]{16,})(["\']?)`),
-}
-
 // sanitizeResponseBody masks sensitive patterns in the response body while preserving structure.
 func sanitizeResponseBody(body []byte) string {
 	if len(body) == 0 {
 		return ""
 	}
-	result := string(body)
-	for _, pattern := range sensitiveBodyPatterns {
-		result = pattern.ReplaceAllStringFunc(result, func(match string) string {
-			submatches := pattern.FindStringSubmatch(match)
-			if len(submatches) >= 4 {
-				// submatches[1] = prefix, submatches[2] = sensitive value, submatches[3] = suffix
-				return submatches[1] + util.HideAPIKey(submatches[2]) + submatches[3]
-			}
-			return match
-		})
-	}
-	return result
+	return util.RedactSecrets(string(body))
 }
 
 func chutesCreds(auth *cliproxyauth.Auth, cfg *config.Config) (apiKey, baseURL string) {
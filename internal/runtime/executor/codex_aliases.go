@@ -0,0 +1,215 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/tidwall/sjson"
+)
+
+// codexBuiltinAliases are the effort aliases ("<base>-<effort>") known without any
+// configuration. codex.aliases entries add bases or replace the efforts of these.
+var codexBuiltinAliases = []config.CodexAlias{
+	{Base: "gpt-5", Efforts: []string{"minimal", "low", "medium", "high"}},
+	{Base: "gpt-5-codex", Efforts: []string{"low", "medium", "high"}},
+	{Base: "gpt-5-codex-mini", Efforts: []string{"medium", "high"}},
+	{Base: "gpt-5.1", Efforts: []string{"none", "low", "medium", "high"}},
+	{Base: "gpt-5.1-codex", Efforts: []string{"low", "medium", "high"}},
+	{Base: "gpt-5.1-codex-mini", Efforts: []string{"medium", "high"}},
+	{Base: "gpt-5.1-codex-max", Efforts: []string{"low", "medium", "high", "xhigh"}},
+	{Base: "gpt-5.2", Efforts: []string{"none", "low", "medium", "high", "xhigh"}},
+	{Base: "gpt-5.2-codex", Efforts: []string{"low", "medium", "high", "xhigh"}},
+	{Base: "gpt-5.3-codex", Efforts: []string{"low", "medium", "high", "xhigh"}},
+	{Base: "gpt-5.3-codex-spark", Efforts: []string{"low", "medium", "high", "xhigh"}},
+}
+
+// codexModelVariants are suffix words that name a different upstream model rather
+// than a reasoning effort (gpt-5-mini, gpt-5.1-max, ...), so they pass through untouched.
+var codexModelVariants = map[string]struct{}{
+	"chat": {}, "codex": {}, "latest": {}, "max": {}, "mini": {}, "nano": {}, "pro": {}, "spark": {},
+}
+
+// codexAliasTable merges the codex.aliases of cfg over the built-in aliases. A configured
+// base replaces the built-in efforts of the same base; the result is sorted longest base
+// first so the most specific base wins a prefix match.
+func codexAliasTable(cfg *config.Config) []config.CodexAlias {
+	var configured []config.CodexAlias
+	if cfg != nil {
+		configured = cfg.Codex.Aliases
+	}
+	table := make([]config.CodexAlias, 0, len(codexBuiltinAliases)+len(configured))
+	overridden := make(map[string]struct{}, len(configured))
+	for _, alias := range configured {
+		if alias.Base == "" || len(alias.Efforts) == 0 {
+			continue
+		}
+		overridden[alias.Base] = struct{}{}
+		table = append(table, alias)
+	}
+	for _, alias := range codexBuiltinAliases {
+		if _, ok := overridden[alias.Base]; !ok {
+			table = append(table, alias)
+		}
+	}
+	sort.SliceStable(table, func(i, j int) bool { return len(table[i].Base) > len(table[j].Base) })
+	return table
+}
+
+// resolveCodexAlias splits an effort alias such as "gpt-5.1-codex-max-xhigh" into its base
+// model and reasoning effort. ok is false for names that are not an alias.
+func resolveCodexAlias(cfg *config.Config, modelName string) (baseModel, effort string, ok bool) {
+	for _, alias := range codexAliasTable(cfg) {
+		suffix, found := strings.CutPrefix(modelName, alias.Base+"-")
+		if !found {
+			continue
+		}
+		for _, candidate := range alias.Efforts {
+			if suffix == candidate {
+				return alias.Base, candidate, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// CodexAliasModels appends a model entry for every effort alias of the registered base
+// models that models does not list yet, such as the codex.aliases of cfg, so model pickers
+// can offer them. An alias entry copies the metadata of its base model.
+func CodexAliasModels(cfg *config.Config, models []*registry.ModelInfo) []*registry.ModelInfo {
+	listed := make(map[string]struct{}, len(models))
+	for _, m := range models {
+		if m != nil {
+			listed[strings.ToLower(m.ID)] = struct{}{}
+		}
+	}
+	table := codexAliasTable(cfg)
+	result := models
+	for _, m := range models {
+		if m == nil {
+			continue
+		}
+		for _, alias := range table {
+			if !strings.EqualFold(alias.Base, m.ID) {
+				continue
+			}
+			for _, effort := range alias.Efforts {
+				id := m.ID + "-" + effort
+				if _, ok := listed[strings.ToLower(id)]; ok {
+					continue
+				}
+				listed[strings.ToLower(id)] = struct{}{}
+				entry := *m
+				entry.ID = id
+				entry.DisplayName = strings.TrimSpace(m.DisplayName + " " + strings.ToUpper(effort[:1]) + effort[1:])
+				entry.Description = fmt.Sprintf("Alias for %s with %s reasoning effort.", m.ID, effort)
+				result = append(result, &entry)
+			}
+			break
+		}
+	}
+	return result
+}
+
+// codexAliasSuffixError reports a 400 for model names that extend a known Codex base
+// model with a suffix that is not one of its reasoning efforts, such as
+// gpt-5.1-codex-max-ultra or gpt-5-medium-high. Without it the raw name goes upstream
+// and the client sees an unhelpful 404. Names that merely share a prefix with a base
+// model, such as dated snapshots or other variants, return nil.
+func codexAliasSuffixError(cfg *config.Config, modelName string) error {
+	name := strings.ToLower(strings.TrimSpace(modelName))
+	table := codexAliasTable(cfg)
+	for _, alias := range table {
+		suffix, found := strings.CutPrefix(name, strings.ToLower(alias.Base)+"-")
+		if !found {
+			continue
+		}
+		first, _, _ := strings.Cut(suffix, "-")
+		if _, variant := codexModelVariants[first]; variant {
+			return nil
+		}
+		for _, r := range suffix {
+			if (r < 'a' || r > 'z') && r != '-' {
+				return nil
+			}
+		}
+		message := fmt.Sprintf("unknown reasoning effort '%s' for Codex model %s (requested %s); valid efforts: %s; valid base models: %s",
+			suffix, alias.Base, modelName, strings.Join(codexAliasEfforts(table), ", "), strings.Join(codexAliasBases(table), ", "))
+		// Shaped like an upstream invalid_request_error so the auth manager neither
+		// retries other credentials nor marks this one as failing.
+		body := []byte(`{"error":{"type":"invalid_request_error","code":"invalid_reasoning_effort"}}`)
+		body, _ = sjson.SetBytes(body, "error.message", message)
+		return statusErr{code: http.StatusBadRequest, msg: string(body)}
+	}
+	return nil
+}
+
+// codexEffortHeader lets clients that cannot change the model name pick a reasoning effort.
+const codexEffortHeader = "X-Reasoning-Effort"
+
+// codexHeaderEffort returns the effort requested with the X-Reasoning-Effort header, or ""
+// when the header is absent. The value must be one of the efforts the alias table lists
+// for model, or any known effort when model is not in the table; anything else is a 400
+// shaped like codexAliasSuffixError so a typo is reported instead of silently dropped.
+func codexHeaderEffort(cfg *config.Config, headers http.Header, model string) (string, error) {
+	effort := strings.ToLower(strings.TrimSpace(headers.Get(codexEffortHeader)))
+	if effort == "" {
+		return "", nil
+	}
+	table := codexAliasTable(cfg)
+	allowed := codexAliasEfforts(append(table, config.CodexAlias{Efforts: codexAliasEffortOrder}))
+	for _, alias := range table {
+		if strings.EqualFold(alias.Base, strings.TrimSpace(model)) {
+			allowed = alias.Efforts
+			break
+		}
+	}
+	for _, candidate := range allowed {
+		if strings.EqualFold(candidate, effort) {
+			return effort, nil
+		}
+	}
+	message := fmt.Sprintf("unknown reasoning effort '%s' in %s header for Codex model %s; valid efforts: %s",
+		effort, codexEffortHeader, model, strings.Join(allowed, ", "))
+	body := []byte(`{"error":{"type":"invalid_request_error","code":"invalid_reasoning_effort"}}`)
+	body, _ = sjson.SetBytes(body, "error.message", message)
+	return "", statusErr{code: http.StatusBadRequest, msg: string(body)}
+}
+
+// codexAliasEffortOrder is the order efforts are listed in, weakest first.
+var codexAliasEffortOrder = []string{"none", "minimal", "low", "medium", "high", "xhigh"}
+
+// codexAliasEfforts lists every effort of table, the standard ones weakest first and
+// configured extras after them in name order.
+func codexAliasEfforts(table []config.CodexAlias) []string {
+	present := make(map[string]struct{})
+	for _, alias := range table {
+		for _, effort := range alias.Efforts {
+			present[effort] = struct{}{}
+		}
+	}
+	efforts := make([]string, 0, len(present))
+	for _, effort := range codexAliasEffortOrder {
+		if _, ok := present[effort]; ok {
+			efforts = append(efforts, effort)
+			delete(present, effort)
+		}
+	}
+	extras := make([]string, 0, len(present))
+	for effort := range present {
+		extras = append(extras, effort)
+	}
+	sort.Strings(extras)
+	return append(efforts, extras...)
+}
+
+func codexAliasBases(table []config.CodexAlias) []string {
+	bases := make([]string, 0, len(table))
+	for _, alias := range table {
+		bases = append(bases, alias.Base)
+	}
+	return bases
+}
@@ -0,0 +1,61 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Outcomes reported in CodexCacheMetrics.Outcome.
+const (
+	CodexCacheOutcomeHit      = "hit"
+	CodexCacheOutcomeMiss     = "miss"
+	CodexCacheOutcomeEviction = "eviction"
+)
+
+// CodexCacheMetrics describes one Codex prompt cache event: a lookup that hit or missed, or
+// an entry evicted to stay within the cache capacity. Sinks exporting to Prometheus or
+// similar are expected to count observations labelled by Outcome and Model.
+type CodexCacheMetrics struct {
+	// Outcome is one of the CodexCacheOutcome* values.
+	Outcome string
+	// Model is the requested model of the lookup, or of the evicted entry.
+	Model string
+}
+
+// CodexCacheMetricsSink receives the Codex prompt cache metrics. Implementations must be
+// safe for concurrent use and should not block: they run on the request path.
+type CodexCacheMetricsSink interface {
+	ObserveCodexCache(ctx context.Context, metrics CodexCacheMetrics)
+}
+
+// CodexCacheMetricsFunc adapts a function to a CodexCacheMetricsSink.
+type CodexCacheMetricsFunc func(ctx context.Context, metrics CodexCacheMetrics)
+
+// ObserveCodexCache calls f.
+func (f CodexCacheMetricsFunc) ObserveCodexCache(ctx context.Context, metrics CodexCacheMetrics) {
+	f(ctx, metrics)
+}
+
+type codexCacheMetricsHolder struct {
+	sink CodexCacheMetricsSink
+}
+
+var codexCacheMetricsSink atomic.Value
+
+// SetCodexCacheMetricsSink installs the sink for Codex prompt cache metrics. Pass nil to
+// remove it; without a sink the metrics are discarded.
+func SetCodexCacheMetricsSink(sink CodexCacheMetricsSink) {
+	codexCacheMetricsSink.Store(codexCacheMetricsHolder{sink: sink})
+}
+
+// observeCodexCache reports a cache event for model to the installed sink.
+func observeCodexCache(ctx context.Context, outcome, model string) {
+	holder, _ := codexCacheMetricsSink.Load().(codexCacheMetricsHolder)
+	if holder.sink == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	holder.sink.ObserveCodexCache(ctx, CodexCacheMetrics{Outcome: outcome, Model: model})
+}
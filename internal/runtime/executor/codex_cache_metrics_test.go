@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func collectCodexCacheMetrics(t *testing.T) func() []CodexCacheMetrics {
+	t.Helper()
+	var mu sync.Mutex
+	var observed []CodexCacheMetrics
+	SetCodexCacheMetricsSink(CodexCacheMetricsFunc(func(_ context.Context, m CodexCacheMetrics) {
+		mu.Lock()
+		observed = append(observed, m)
+		mu.Unlock()
+	}))
+	t.Cleanup(func() { SetCodexCacheMetricsSink(nil) })
+	return func() []CodexCacheMetrics {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]CodexCacheMetrics(nil), observed...)
+	}
+}
+
+func TestCodexCacheHelper_ReportsMissThenHit(t *testing.T) {
+	observed := collectCodexCacheMetrics(t)
+	e := &CodexExecutor{}
+	req := cliproxyexecutor.Request{Model: "gpt-5-metrics", Payload: []byte(`{"metadata":{"user_id":"u1"}}`)}
+	deleteCodexCache("gpt-5-metrics-u1")
+	t.Cleanup(func() { deleteCodexCache("gpt-5-metrics-u1") })
+
+	for i := 0; i < 2; i++ {
+		if _, err := e.cacheHelper(context.Background(), sdktranslator.FormatClaude, "https://example.com/responses", req, []byte(`{"model":"gpt-5","input":[]}`)); err != nil {
+			t.Fatalf("cacheHelper #%d: %v", i+1, err)
+		}
+	}
+
+	want := []CodexCacheMetrics{
+		{Outcome: CodexCacheOutcomeMiss, Model: "gpt-5-metrics"},
+		{Outcome: CodexCacheOutcomeHit, Model: "gpt-5-metrics"},
+	}
+	got := observed()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("metrics = %+v, want %+v", got, want)
+	}
+}
+
+func TestCodexCacheHelper_ReportsEvictionWithEvictedModel(t *testing.T) {
+	e := &CodexExecutor{cfg: &config.Config{CodexCache: config.CodexCacheConfig{MaxEntries: 1}}}
+	t.Cleanup(func() {
+		codexCacheMu.Lock()
+		codexCacheMaxEntries = codexCacheDefaultMaxEntries
+		codexCacheMu.Unlock()
+		deleteCodexCache("gpt-5-evict-a-u1")
+		deleteCodexCache("gpt-5-evict-b-u1")
+	})
+	lookup := func(model string) {
+		req := cliproxyexecutor.Request{Model: model, Payload: []byte(`{"metadata":{"user_id":"u1"}}`)}
+		if _, err := e.cacheHelper(context.Background(), sdktranslator.FormatClaude, "https://example.com/responses", req, []byte(`{"model":"gpt-5","input":[]}`)); err != nil {
+			t.Fatalf("cacheHelper(%s): %v", model, err)
+		}
+	}
+	lookup("gpt-5-evict-a")
+	observed := collectCodexCacheMetrics(t)
+	lookup("gpt-5-evict-b")
+
+	want := []CodexCacheMetrics{
+		{Outcome: CodexCacheOutcomeMiss, Model: "gpt-5-evict-b"},
+		{Outcome: CodexCacheOutcomeEviction, Model: "gpt-5-evict-a"},
+	}
+	got := observed()
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("metrics = %+v, want %+v", got, want)
+	}
+}
@@ -7,6 +7,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,6 +25,7 @@ import (
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 	"github.com/tiktoken-go/tokenizer"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -77,6 +81,22 @@ func (e *CodexExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Auth
 	return httpClient.Do(httpReq)
 }
 
+// Prewarm opens, or keeps alive, the pooled connection to the Codex upstream for the next
+// turn with a HEAD request that carries no credentials and costs no quota. The Responses API
+// has no cache-only call, so the prompt cache is left to the next turn's prompt_cache_key.
+func (e *CodexExecutor) Prewarm(ctx context.Context, auth *cliproxyauth.Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) error {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodHead, codexResponsesURL(auth, ""), nil)
+	if err != nil {
+		return err
+	}
+	httpResp, err := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0, "codex").Do(httpReq)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, httpResp.Body)
+	return httpResp.Body.Close()
+}
+
 // stripCodexPrefix removes the "codex-" prefix from model names if present.
 // This allows users to explicitly route to Codex using "codex-gpt-5.2-xhigh" while
 // the upstream API receives the bare model name "gpt-5.2-xhigh".
@@ -90,10 +110,7 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 	}
 	baseModel := stripCodexPrefix(thinking.ParseSuffix(req.Model).ModelName)
 
-	apiKey, baseURL := codexCreds(auth)
-	if baseURL == "" {
-		baseURL = "https://chatgpt.com/backend-api/codex"
-	}
+	apiKey, _ := codexCreds(auth)
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
 	defer reporter.trackFailure(ctx, &err)
 
@@ -109,9 +126,15 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 	}
 
 	aliasEffort := ""
-	if aliasModel, effort, ok := resolveCodexAlias(modelForUpstream); ok {
+	if aliasModel, effort, ok := resolveCodexAlias(e.cfg, modelForUpstream); ok {
 		modelForUpstream = aliasModel
 		aliasEffort = effort
+	} else if errAlias := codexAliasSuffixError(e.cfg, modelForUpstream); errAlias != nil {
+		return resp, errAlias
+	}
+	headerEffort, errEffort := codexHeaderEffort(e.cfg, opts.Headers, modelForUpstream)
+	if errEffort != nil {
+		return resp, errEffort
 	}
 
 	from := opts.SourceFormat
@@ -126,16 +149,23 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 
 	if aliasEffort != "" {
 		body = setReasoningEffortByAlias(body, modelForUpstream, aliasEffort)
+	} else if effort := e.configuredReasoningEffort(req.Model, req.Payload, from.String(), baseModel, modelForUpstream); effort != "" {
+		body = setReasoningEffortByAlias(body, modelForUpstream, effort)
 	}
 
 	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
 		return resp, err
 	}
+	if headerEffort != "" {
+		// X-Reasoning-Effort wins over both effort aliases and thinking suffixes.
+		body = setReasoningEffortByAlias(body, modelForUpstream, headerEffort)
+	}
 	body = applyTemperatureSuffix(body, req.Model, opts, to.String())
 
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = applyPayloadMetadataMapping(e.cfg, baseModel, to.String(), "", body, req.Payload, requestedModel)
 	body, _ = sjson.SetBytes(body, "model", modelForUpstream)
 	body, _ = sjson.SetBytes(body, "stream", true)
 	body, _ = sjson.DeleteBytes(body, "previous_response_id")
@@ -145,48 +175,37 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 		body, _ = sjson.SetBytes(body, "instructions", "")
 	}
 
-	url := strings.TrimSuffix(baseURL, "/") + "/responses"
-	httpReq, err := e.cacheHelper(ctx, from, url, req, body)
-	if err != nil {
-		return resp, err
-	}
-	applyCodexHeaders(httpReq, auth, apiKey, true)
-	var authID, authLabel, authType, authValue string
-	if auth != nil {
-		authID = auth.ID
-		authLabel = auth.Label
-		authType, authValue = auth.AccountInfo()
-	}
-	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
-		URL:       url,
-		Method:    http.MethodPost,
-		Headers:   httpReq.Header.Clone(),
-		Body:      body,
-		Provider:  e.Identifier(),
-		AuthID:    authID,
-		AuthLabel: authLabel,
-		AuthType:  authType,
-		AuthValue: authValue,
-	})
-	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0, "codex")
-	httpResp, err := httpClient.Do(httpReq)
-	if err != nil {
-		recordAPIResponseError(ctx, e.cfg, err)
-		return resp, err
-	}
-	defer func() {
+	url := codexResponsesURL(auth, "")
+	var httpResp *http.Response
+	for refreshed := false; ; refreshed = true {
+		httpResp, err = e.sendCodexRequest(ctx, auth, apiKey, from, url, req, body)
+		if err != nil {
+			return resp, err
+		}
+		if httpResp.StatusCode >= 200 && httpResp.StatusCode < 300 {
+			break
+		}
+		b, _ := io.ReadAll(httpResp.Body)
 		if errClose := httpResp.Body.Close(); errClose != nil {
 			log.Errorf("codex executor: close response body error: %v", errClose)
 		}
-	}()
-	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
-	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
-		b, _ := io.ReadAll(httpResp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
+		if !refreshed && codexUnauthorized(httpResp.StatusCode, b) {
+			if updated := e.refreshAfterUnauthorized(ctx, auth); updated != nil {
+				auth = updated
+				apiKey, _ = codexCreds(auth)
+				continue
+			}
+		}
 		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
+		err = newCodexStatusErr(httpResp.StatusCode, httpResp.Header, b)
 		return resp, err
 	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("codex executor: close response body error: %v", errClose)
+		}
+	}()
 	data, err := io.ReadAll(httpResp.Body)
 	if err != nil {
 		recordAPIResponseError(ctx, e.cfg, err)
@@ -221,10 +240,7 @@ func (e *CodexExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 func (e *CodexExecutor) executeCompact(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
 	baseModel := stripCodexPrefix(thinking.ParseSuffix(req.Model).ModelName)
 
-	apiKey, baseURL := codexCreds(auth)
-	if baseURL == "" {
-		baseURL = "https://chatgpt.com/backend-api/codex"
-	}
+	apiKey, _ := codexCreds(auth)
 
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
 	defer reporter.trackFailure(ctx, &err)
@@ -247,10 +263,11 @@ func (e *CodexExecutor) executeCompact(ctx context.Context, auth *cliproxyauth.A
 
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = applyPayloadMetadataMapping(e.cfg, baseModel, to.String(), "", body, req.Payload, requestedModel)
 	body, _ = sjson.SetBytes(body, "model", baseModel)
 	body, _ = sjson.DeleteBytes(body, "stream")
 
-	url := strings.TrimSuffix(baseURL, "/") + "/responses/compact"
+	url := codexResponsesURL(auth, "compact")
 	httpReq, err := e.cacheHelper(ctx, from, url, req, body)
 	if err != nil {
 		return resp, err
@@ -289,7 +306,7 @@ func (e *CodexExecutor) executeCompact(ctx context.Context, auth *cliproxyauth.A
 		b, _ := io.ReadAll(httpResp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
 		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
+		err = newCodexStatusErr(httpResp.StatusCode, httpResp.Header, b)
 		return resp, err
 	}
 	data, err := io.ReadAll(httpResp.Body)
@@ -312,10 +329,7 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 	}
 	baseModel := stripCodexPrefix(thinking.ParseSuffix(req.Model).ModelName)
 
-	apiKey, baseURL := codexCreds(auth)
-	if baseURL == "" {
-		baseURL = "https://chatgpt.com/backend-api/codex"
-	}
+	apiKey, _ := codexCreds(auth)
 
 	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
 	defer reporter.trackFailure(ctx, &err)
@@ -331,9 +345,15 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 	}
 
 	aliasEffort := ""
-	if aliasModel, effort, ok := resolveCodexAlias(modelForUpstream); ok {
+	if aliasModel, effort, ok := resolveCodexAlias(e.cfg, modelForUpstream); ok {
 		modelForUpstream = aliasModel
 		aliasEffort = effort
+	} else if errAlias := codexAliasSuffixError(e.cfg, modelForUpstream); errAlias != nil {
+		return nil, errAlias
+	}
+	headerEffort, errEffort := codexHeaderEffort(e.cfg, opts.Headers, modelForUpstream)
+	if errEffort != nil {
+		return nil, errEffort
 	}
 
 	from := opts.SourceFormat
@@ -348,16 +368,23 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 
 	if aliasEffort != "" {
 		body = setReasoningEffortByAlias(body, modelForUpstream, aliasEffort)
+	} else if effort := e.configuredReasoningEffort(req.Model, req.Payload, from.String(), baseModel, modelForUpstream); effort != "" {
+		body = setReasoningEffortByAlias(body, modelForUpstream, effort)
 	}
 
 	body, err = thinking.ApplyThinking(body, req.Model, from.String(), to.String(), e.Identifier())
 	if err != nil {
 		return nil, err
 	}
+	if headerEffort != "" {
+		// X-Reasoning-Effort wins over both effort aliases and thinking suffixes.
+		body = setReasoningEffortByAlias(body, modelForUpstream, headerEffort)
+	}
 	body = applyTemperatureSuffix(body, req.Model, opts, to.String())
 
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = applyPayloadMetadataMapping(e.cfg, baseModel, to.String(), "", body, req.Payload, requestedModel)
 	body, _ = sjson.DeleteBytes(body, "previous_response_id")
 	body, _ = sjson.DeleteBytes(body, "prompt_cache_retention")
 	body, _ = sjson.DeleteBytes(body, "safety_identifier")
@@ -366,38 +393,18 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 		body, _ = sjson.SetBytes(body, "instructions", "")
 	}
 
-	url := strings.TrimSuffix(baseURL, "/") + "/responses"
-	httpReq, err := e.cacheHelper(ctx, from, url, req, body)
-	if err != nil {
-		return nil, err
-	}
-	applyCodexHeaders(httpReq, auth, apiKey, true)
-	var authID, authLabel, authType, authValue string
-	if auth != nil {
-		authID = auth.ID
-		authLabel = auth.Label
-		authType, authValue = auth.AccountInfo()
-	}
-	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
-		URL:       url,
-		Method:    http.MethodPost,
-		Headers:   httpReq.Header.Clone(),
-		Body:      body,
-		Provider:  e.Identifier(),
-		AuthID:    authID,
-		AuthLabel: authLabel,
-		AuthType:  authType,
-		AuthValue: authValue,
-	})
-
-	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0, "codex")
-	httpResp, err := httpClient.Do(httpReq)
-	if err != nil {
-		recordAPIResponseError(ctx, e.cfg, err)
-		return nil, err
-	}
-	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
-	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+	url := codexResponsesURL(auth, "")
+	var httpResp *http.Response
+	// Nothing has been forwarded to the client until the status check passes, so an
+	// expired token can still be refreshed and the request retried once.
+	for refreshed := false; ; refreshed = true {
+		httpResp, err = e.sendCodexRequest(ctx, auth, apiKey, from, url, req, body)
+		if err != nil {
+			return nil, err
+		}
+		if httpResp.StatusCode >= 200 && httpResp.StatusCode < 300 {
+			break
+		}
 		data, readErr := io.ReadAll(httpResp.Body)
 		if errClose := httpResp.Body.Close(); errClose != nil {
 			log.Errorf("codex executor: close response body error: %v", errClose)
@@ -407,8 +414,15 @@ func (e *CodexExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 			return nil, readErr
 		}
 		appendAPIResponseChunk(ctx, e.cfg, data)
+		if !refreshed && codexUnauthorized(httpResp.StatusCode, data) {
+			if updated := e.refreshAfterUnauthorized(ctx, auth); updated != nil {
+				auth = updated
+				apiKey, _ = codexCreds(auth)
+				continue
+			}
+		}
 		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
-		err = statusErr{code: httpResp.StatusCode, msg: string(data)}
+		err = newCodexStatusErr(httpResp.StatusCode, httpResp.Header, data)
 		return nil, err
 	}
 	out := make(chan cliproxyexecutor.StreamChunk)
@@ -463,9 +477,11 @@ func (e *CodexExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth
 	}
 
 	aliasEffort := ""
-	if aliasModel, effort, ok := resolveCodexAlias(modelForUpstream); ok {
+	if aliasModel, effort, ok := resolveCodexAlias(e.cfg, modelForUpstream); ok {
 		modelForUpstream = aliasModel
 		aliasEffort = effort
+	} else if errAlias := codexAliasSuffixError(e.cfg, modelForUpstream); errAlias != nil {
+		return cliproxyexecutor.Response{}, errAlias
 	}
 
 	from := opts.SourceFormat
@@ -474,6 +490,8 @@ func (e *CodexExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth
 
 	if aliasEffort != "" {
 		body = setReasoningEffortByAlias(body, modelForUpstream, aliasEffort)
+	} else if effort := e.configuredReasoningEffort(req.Model, req.Payload, from.String(), baseModel, modelForUpstream); effort != "" {
+		body = setReasoningEffortByAlias(body, modelForUpstream, effort)
 	}
 
 	var err error
@@ -507,91 +525,6 @@ func (e *CodexExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth
 	return cliproxyexecutor.Response{Payload: []byte(translated)}, nil
 }
 
-func resolveCodexAlias(modelName string) (baseModel, effort string, ok bool) {
-	switch modelName {
-	case "gpt-5-minimal":
-		return "gpt-5", "minimal", true
-	case "gpt-5-low":
-		return "gpt-5", "low", true
-	case "gpt-5-medium":
-		return "gpt-5", "medium", true
-	case "gpt-5-high":
-		return "gpt-5", "high", true
-	case "gpt-5-codex-low":
-		return "gpt-5-codex", "low", true
-	case "gpt-5-codex-medium":
-		return "gpt-5-codex", "medium", true
-	case "gpt-5-codex-high":
-		return "gpt-5-codex", "high", true
-	case "gpt-5-codex-mini-medium":
-		return "gpt-5-codex-mini", "medium", true
-	case "gpt-5-codex-mini-high":
-		return "gpt-5-codex-mini", "high", true
-	case "gpt-5.1-none":
-		return "gpt-5.1", "none", true
-	case "gpt-5.1-low":
-		return "gpt-5.1", "low", true
-	case "gpt-5.1-medium":
-		return "gpt-5.1", "medium", true
-	case "gpt-5.1-high":
-		return "gpt-5.1", "high", true
-	case "gpt-5.1-codex-low":
-		return "gpt-5.1-codex", "low", true
-	case "gpt-5.1-codex-medium":
-		return "gpt-5.1-codex", "medium", true
-	case "gpt-5.1-codex-high":
-		return "gpt-5.1-codex", "high", true
-	case "gpt-5.1-codex-mini-medium":
-		return "gpt-5.1-codex-mini", "medium", true
-	case "gpt-5.1-codex-mini-high":
-		return "gpt-5.1-codex-mini", "high", true
-	case "gpt-5.1-codex-max-low":
-		return "gpt-5.1-codex-max", "low", true
-	case "gpt-5.1-codex-max-medium":
-		return "gpt-5.1-codex-max", "medium", true
-	case "gpt-5.1-codex-max-high":
-		return "gpt-5.1-codex-max", "high", true
-	case "gpt-5.1-codex-max-xhigh":
-		return "gpt-5.1-codex-max", "xhigh", true
-	case "gpt-5.2-low":
-		return "gpt-5.2", "low", true
-	case "gpt-5.2-none":
-		return "gpt-5.2", "none", true
-	case "gpt-5.2-medium":
-		return "gpt-5.2", "medium", true
-	case "gpt-5.2-high":
-		return "gpt-5.2", "high", true
-	case "gpt-5.2-xhigh":
-		return "gpt-5.2", "xhigh", true
-	case "gpt-5.2-codex-low":
-		return "gpt-5.2-codex", "low", true
-	case "gpt-5.2-codex-medium":
-		return "gpt-5.2-codex", "medium", true
-	case "gpt-5.2-codex-high":
-		return "gpt-5.2-codex", "high", true
-	case "gpt-5.2-codex-xhigh":
-		return "gpt-5.2-codex", "xhigh", true
-	case "gpt-5.3-codex-low":
-		return "gpt-5.3-codex", "low", true
-	case "gpt-5.3-codex-medium":
-		return "gpt-5.3-codex", "medium", true
-	case "gpt-5.3-codex-high":
-		return "gpt-5.3-codex", "high", true
-	case "gpt-5.3-codex-xhigh":
-		return "gpt-5.3-codex", "xhigh", true
-	case "gpt-5.3-codex-spark-low":
-		return "gpt-5.3-codex-spark", "low", true
-	case "gpt-5.3-codex-spark-medium":
-		return "gpt-5.3-codex-spark", "medium", true
-	case "gpt-5.3-codex-spark-high":
-		return "gpt-5.3-codex-spark", "high", true
-	case "gpt-5.3-codex-spark-xhigh":
-		return "gpt-5.3-codex-spark", "xhigh", true
-	default:
-		return "", "", false
-	}
-}
-
 func setReasoningEffortByAlias(payload []byte, baseModel string, effort string) []byte {
 	if strings.TrimSpace(baseModel) != "" {
 		payload, _ = sjson.SetBytes(payload, "model", baseModel)
@@ -602,6 +535,26 @@ func setReasoningEffortByAlias(payload []byte, baseModel string, effort string)
 	return payload
 }
 
+// configuredReasoningEffort returns the codex.reasoning-effort default of the first listed
+// model that has one, or "" when the client chose an effort itself with a thinking suffix
+// or a thinking config in its payload.
+func (e *CodexExecutor) configuredReasoningEffort(requestModel string, payload []byte, from string, models ...string) string {
+	if e == nil || e.cfg == nil || len(e.cfg.Codex.ReasoningEffort) == 0 {
+		return ""
+	}
+	if thinking.ParseSuffix(requestModel).HasSuffix || thinking.HasThinkingConfig(payload, from) {
+		return ""
+	}
+	for _, model := range models {
+		for name, effort := range e.cfg.Codex.ReasoningEffort {
+			if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(model)) && strings.TrimSpace(effort) != "" {
+				return effort
+			}
+		}
+	}
+	return ""
+}
+
 func tokenizerForCodexModel(model string) (tokenizer.Codec, error) {
 	sanitized := strings.ToLower(strings.TrimSpace(model))
 	switch {
@@ -724,6 +677,11 @@ func countCodexInputTokens(enc tokenizer.Codec, body []byte) (int64, error) {
 	return int64(count), nil
 }
 
+// codexRefreshTokens exchanges a refresh token for new tokens; tests swap it for a fake.
+var codexRefreshTokens = func(ctx context.Context, cfg *config.Config, refreshToken string) (*codexauth.CodexTokenData, error) {
+	return codexauth.NewCodexAuth(cfg).RefreshTokensWithRetry(ctx, refreshToken, 3)
+}
+
 func (e *CodexExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
 	log.Debugf("codex executor: refresh called")
 	if auth == nil {
@@ -738,8 +696,7 @@ func (e *CodexExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*
 	if refreshToken == "" {
 		return auth, nil
 	}
-	svc := codexauth.NewCodexAuth(e.cfg)
-	td, err := svc.RefreshTokensWithRetry(ctx, refreshToken, 3)
+	td, err := codexRefreshTokens(ctx, e.cfg, refreshToken)
 	if err != nil {
 		return nil, err
 	}
@@ -763,22 +720,149 @@ func (e *CodexExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*
 	return auth, nil
 }
 
+// sendCodexRequest builds, logs and sends one upstream Codex request.
+func (e *CodexExecutor) sendCodexRequest(ctx context.Context, auth *cliproxyauth.Auth, apiKey string, from sdktranslator.Format, url string, req cliproxyexecutor.Request, body []byte) (*http.Response, error) {
+	httpReq, err := e.cacheHelper(ctx, from, url, req, body)
+	if err != nil {
+		return nil, err
+	}
+	applyCodexHeaders(httpReq, auth, apiKey, true)
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	recordAPIRequest(ctx, e.cfg, upstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  e.Identifier(),
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0, "codex")
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		recordAPIResponseError(ctx, e.cfg, err)
+		return nil, err
+	}
+	recordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	return httpResp, nil
+}
+
+// codexUnauthorized reports whether an upstream error response rejected the access token.
+func codexUnauthorized(status int, body []byte) bool {
+	return status == http.StatusUnauthorized || gjson.GetBytes(body, "error.code").String() == "invalid_token"
+}
+
+// newCodexStatusErr wraps a non-2xx Codex response. For a 429 it keeps the retry delay,
+// so the auth manager cools this credential down for exactly that long, and carries
+// Retry-After and the x-ratelimit-* headers for the client response.
+func newCodexStatusErr(status int, header http.Header, body []byte) error {
+	err := statusErr{code: status, msg: string(body)}
+	if status != http.StatusTooManyRequests {
+		return err
+	}
+	err.retryAfter = codexRetryAfter(header, body)
+	headers := make(http.Header)
+	for key, values := range header {
+		if strings.HasPrefix(strings.ToLower(key), "x-ratelimit-") {
+			headers[key] = append([]string(nil), values...)
+		}
+	}
+	if err.retryAfter != nil {
+		headers.Set("Retry-After", strconv.FormatInt(int64((*err.retryAfter+time.Second-1)/time.Second), 10))
+	}
+	return statusErrWithHeaders{statusErr: err, headers: headers}
+}
+
+// codexRetryAfter reads the delay of a Codex 429 from Retry-After, then the
+// x-ratelimit-reset-* durations (the longer one, since either limit may be the one
+// exhausted), then the usage-limit body fields resets_in_seconds and resets_at.
+func codexRetryAfter(header http.Header, body []byte) *time.Duration {
+	if retryAfter := parseRetryAfterHeader(header); retryAfter != nil {
+		return retryAfter
+	}
+	var longest time.Duration
+	for _, key := range []string{"x-ratelimit-reset-requests", "x-ratelimit-reset-tokens"} {
+		if d, err := time.ParseDuration(strings.TrimSpace(header.Get(key))); err == nil && d > longest {
+			longest = d
+		}
+	}
+	if longest > 0 {
+		return &longest
+	}
+	if seconds := gjson.GetBytes(body, "error.resets_in_seconds").Float(); seconds > 0 {
+		d := time.Duration(seconds * float64(time.Second))
+		return &d
+	}
+	if resetsAt := gjson.GetBytes(body, "error.resets_at").Int(); resetsAt > 0 {
+		if d := time.Until(time.Unix(resetsAt, 0)); d > 0 {
+			return &d
+		}
+	}
+	return nil
+}
+
+// codexUnauthorizedRefreshes collapses concurrent 401 refreshes of the same auth into one,
+// so parallel requests do not each spend (and rotate) the refresh token.
+var codexUnauthorizedRefreshes singleflight.Group
+
+// refreshAfterUnauthorized refreshes an OAuth auth whose access token upstream rejected
+// and returns the refreshed copy, which is also handed to the auth manager so rotated
+// tokens are stored. Concurrent calls for one auth share a single refresh, and when the
+// manager already holds a different access token (another request refreshed it) that
+// copy is returned without refreshing again. API-key auths, auths without a refresh token
+// and failed refreshes return nil so the original error reaches the client.
+func (e *CodexExecutor) refreshAfterUnauthorized(ctx context.Context, auth *cliproxyauth.Auth) *cliproxyauth.Auth {
+	if auth == nil {
+		return nil
+	}
+	if kind, _ := auth.AccountInfo(); strings.EqualFold(kind, "api_key") {
+		return nil
+	}
+	if auth.Attributes != nil && strings.TrimSpace(auth.Attributes["api_key"]) != "" {
+		return nil
+	}
+	if refreshToken, _ := auth.Metadata["refresh_token"].(string); strings.TrimSpace(refreshToken) == "" {
+		return nil
+	}
+	rejected, _ := auth.Metadata["access_token"].(string)
+	result, err, _ := codexUnauthorizedRefreshes.Do(auth.ID, func() (any, error) {
+		source := auth
+		if current, ok := cliproxyauth.CurrentAuth(ctx, auth.ID); ok {
+			if token, _ := current.Metadata["access_token"].(string); token != "" && token != rejected {
+				log.Debugf("codex executor: access token for %s already refreshed, retrying once", auth.ID)
+				return current, nil
+			}
+			source = current
+		}
+		// The refresh is shared with other waiting requests, so it must not end with this one.
+		updated, errRefresh := e.Refresh(context.WithoutCancel(ctx), source.Clone())
+		if errRefresh != nil || updated == nil {
+			return nil, fmt.Errorf("token refresh after 401 failed for %s: %v", auth.ID, errRefresh)
+		}
+		log.Infof("codex executor: refreshed access token for %s after 401, retrying once", auth.ID)
+		cliproxyauth.NotifyAuthRefreshed(ctx, updated)
+		return updated, nil
+	})
+	if err != nil {
+		log.Warnf("codex executor: %v", err)
+		return nil
+	}
+	return result.(*cliproxyauth.Auth).Clone()
+}
+
 func (e *CodexExecutor) cacheHelper(ctx context.Context, from sdktranslator.Format, url string, req cliproxyexecutor.Request, rawJSON []byte) (*http.Request, error) {
 	var cache codexCache
 	if from == "claude" {
 		userIDResult := gjson.GetBytes(req.Payload, "metadata.user_id")
 		if userIDResult.Exists() {
-			key := fmt.Sprintf("%s-%s", req.Model, userIDResult.String())
-			var ok bool
-			if cache, ok = getCodexCache(key); !ok {
-				cache = codexCache{
-					// Deterministic cache ID (stable across restarts) to maximize upstream
-					// prompt cache prefix matching.
-					ID:     uuid.NewSHA1(uuid.Nil, []byte(key)).String(),
-					Expire: time.Now().Add(1 * time.Hour),
-				}
-				setCodexCache(key, cache)
-			}
+			cache = codexPromptCacheID(ctx, e.cfg, req.Model, userIDResult.String())
 		}
 	} else if from == "openai-response" {
 		promptCacheKey := gjson.GetBytes(req.Payload, "prompt_cache_key")
@@ -790,17 +874,43 @@ func (e *CodexExecutor) cacheHelper(ctx context.Context, from sdktranslator.Form
 	if cache.ID != "" {
 		rawJSON, _ = sjson.SetBytes(rawJSON, "prompt_cache_key", cache.ID)
 	}
+	rawJSON = applyCodexSlidingWindow(ctx, from, req, rawJSON)
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(rawJSON))
 	if err != nil {
 		return nil, err
 	}
-	if cache.ID != "" {
-		httpReq.Header.Set("Conversation_id", cache.ID)
-		httpReq.Header.Set("Session_id", cache.ID)
-	}
+	setCodexCacheKeyHeaders(httpReq.Header, cache.ID)
 	return httpReq, nil
 }
 
+// codexCacheKeyHeaders returns the names of the headers that carry the prompt cache key:
+// CODEX_SESSION_HEADER and CODEX_CONVERSATION_HEADER, Session_id and Conversation_id when
+// unset. A variable set to an empty value turns that header off.
+func codexCacheKeyHeaders() (session, conversation string) {
+	session, conversation = "Session_id", "Conversation_id"
+	if v, ok := os.LookupEnv("CODEX_SESSION_HEADER"); ok {
+		session = strings.TrimSpace(v)
+	}
+	if v, ok := os.LookupEnv("CODEX_CONVERSATION_HEADER"); ok {
+		conversation = strings.TrimSpace(v)
+	}
+	return session, conversation
+}
+
+// setCodexCacheKeyHeaders sends the prompt cache key id in the configured headers.
+func setCodexCacheKeyHeaders(headers http.Header, id string) {
+	if id == "" {
+		return
+	}
+	session, conversation := codexCacheKeyHeaders()
+	if conversation != "" {
+		headers.Set(conversation, id)
+	}
+	if session != "" {
+		headers.Set(session, id)
+	}
+}
+
 func applyCodexHeaders(r *http.Request, auth *cliproxyauth.Auth, token string, stream bool) {
 	r.Header.Set("Content-Type", "application/json")
 	r.Header.Set("Authorization", "Bearer "+token)
@@ -811,7 +921,9 @@ func applyCodexHeaders(r *http.Request, auth *cliproxyauth.Auth, token string, s
 	}
 
 	misc.EnsureHeader(r.Header, ginHeaders, "Version", codexClientVersion)
-	misc.EnsureHeader(r.Header, ginHeaders, "Session_id", uuid.NewString())
+	if session, _ := codexCacheKeyHeaders(); session != "" {
+		misc.EnsureHeader(r.Header, ginHeaders, session, uuid.NewString())
+	}
 	misc.EnsureHeader(r.Header, ginHeaders, "User-Agent", codexUserAgent)
 
 	if stream {
@@ -842,6 +954,47 @@ func applyCodexHeaders(r *http.Request, auth *cliproxyauth.Auth, token string, s
 	util.ApplyCustomHeadersFromAttrs(r, attrs)
 }
 
+// codexDefaultBaseURL is the Codex upstream used when the auth carries no base_url.
+const codexDefaultBaseURL = "https://chatgpt.com/backend-api/codex"
+
+// codexResponsesURL returns the Responses endpoint of auth, with sub (e.g. "compact")
+// appended as a path segment when set. The endpoint is the responses_url attribute when
+// present, otherwise base_url (or the default) joined with base_path and "responses".
+// Joining keeps single slashes and any query string of the configured URL.
+func codexResponsesURL(auth *cliproxyauth.Auth, sub string) string {
+	var responsesURL, baseURL, basePath string
+	if auth != nil && auth.Attributes != nil {
+		responsesURL = strings.TrimSpace(auth.Attributes["responses_url"])
+		baseURL = strings.TrimSpace(auth.Attributes["base_url"])
+		basePath = strings.TrimSpace(auth.Attributes["base_path"])
+	}
+	if responsesURL == "" {
+		if baseURL == "" {
+			baseURL = codexDefaultBaseURL
+		}
+		responsesURL = joinCodexURLPath(baseURL, basePath, "responses")
+	}
+	if sub != "" {
+		responsesURL = joinCodexURLPath(responsesURL, sub)
+	}
+	return responsesURL
+}
+
+// joinCodexURLPath appends path segments to the path of rawURL, keeping its query.
+func joinCodexURLPath(rawURL string, segments ...string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		joined := strings.TrimSuffix(rawURL, "/")
+		for _, segment := range segments {
+			if segment = strings.Trim(segment, "/"); segment != "" {
+				joined += "/" + segment
+			}
+		}
+		return joined
+	}
+	return parsed.JoinPath(segments...).String()
+}
+
 func codexCreds(a *cliproxyauth.Auth) (apiKey, baseURL string) {
 	if a == nil {
 		return "", ""
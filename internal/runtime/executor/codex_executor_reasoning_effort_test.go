@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestCodexExecutor_ConfiguredReasoningEffort(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- body
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", `{"type":"response.completed","response":{"id":"r1","output":[],"usage":{"input_tokens":1,"output_tokens":1,"total_tokens":2}}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	exec := NewCodexExecutor(&config.Config{Codex: config.CodexConfig{ReasoningEffort: map[string]string{
+		"GPT-5-Codex": "high",
+		"gpt-5.1":     "medium",
+	}}})
+	auth := &cliproxyauth.Auth{
+		ID:         "codex-auth-effort",
+		Provider:   "codex",
+		Attributes: map[string]string{"api_key": "test", "base_url": srv.URL},
+	}
+
+	tests := []struct {
+		name    string
+		model   string
+		payload string
+		want    string
+	}{
+		{"configured default", "gpt-5-codex", `{"input":[]}`, "high"},
+		{"client effort wins", "gpt-5-codex", `{"input":[],"reasoning":{"effort":"low"}}`, "low"},
+		{"alias wins", "gpt-5-codex-medium", `{"input":[]}`, "medium"},
+		{"unconfigured model", "gpt-5", `{"input":[]}`, ""},
+	}
+	for _, tt := range tests {
+		req := cliproxyexecutor.Request{Model: tt.model, Payload: []byte(tt.payload)}
+		opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}
+		if _, err := exec.Execute(context.Background(), auth, req, opts); err != nil {
+			t.Fatalf("%s: Execute(): %v", tt.name, err)
+		}
+		body := <-received
+		if got := gjson.GetBytes(body, "reasoning.effort").String(); got != tt.want {
+			t.Fatalf("%s: upstream reasoning.effort = %q, want %q (body %s)", tt.name, got, tt.want, body)
+		}
+	}
+}
+
+func TestCodexExecutor_ConfiguredReasoningEffortStream(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- body
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", `{"type":"response.completed","response":{"id":"r1","output":[],"usage":{"input_tokens":1,"output_tokens":1,"total_tokens":2}}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{Codex: config.CodexConfig{ReasoningEffort: map[string]string{
+		" gpt-5 ":           " Medium ",
+		"gpt-5.1-codex-max": "high",
+		"gpt-5.1":           "  ",
+	}}}
+	cfg.SanitizeCodexReasoningEffort()
+	exec := NewCodexExecutor(cfg)
+	auth := &cliproxyauth.Auth{
+		ID:         "codex-auth-effort-stream",
+		Provider:   "codex",
+		Attributes: map[string]string{"api_key": "test", "base_url": srv.URL},
+	}
+
+	tests := []struct {
+		name    string
+		model   string
+		from    string
+		payload string
+		want    string
+	}{
+		{"bare base model", "gpt-5", "codex", `{"input":[]}`, "medium"},
+		{"max model", "gpt-5.1-codex-max", "codex", `{"input":[]}`, "high"},
+		{"explicit effort wins", "gpt-5.1-codex-max", "codex", `{"input":[],"reasoning":{"effort":"xhigh"}}`, "xhigh"},
+		{"responses effort wins", "gpt-5", "openai-response", `{"input":[],"reasoning":{"effort":"low"}}`, "low"},
+		{"alias wins", "gpt-5-high", "codex", `{"input":[]}`, "high"},
+		{"blank default dropped", "gpt-5.1", "codex", `{"input":[]}`, ""},
+	}
+	for _, tt := range tests {
+		req := cliproxyexecutor.Request{Model: tt.model, Payload: []byte(tt.payload)}
+		opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString(tt.from), Stream: true}
+		result, err := exec.ExecuteStream(context.Background(), auth, req, opts)
+		if err != nil {
+			t.Fatalf("%s: ExecuteStream(): %v", tt.name, err)
+		}
+		for chunk := range result.Chunks {
+			if chunk.Err != nil {
+				t.Fatalf("%s: stream error: %v", tt.name, chunk.Err)
+			}
+		}
+		body := <-received
+		if got := gjson.GetBytes(body, "reasoning.effort").String(); got != tt.want {
+			t.Fatalf("%s: upstream reasoning.effort = %q, want %q (body %s)", tt.name, got, tt.want, body)
+		}
+	}
+}
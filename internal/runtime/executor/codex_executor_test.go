@@ -1,11 +1,22 @@
 package executor
 
 import (
+	"container/list"
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	codexauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/codex"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
 	"github.com/tidwall/gjson"
@@ -162,7 +173,7 @@ func TestResolveCodexAlias(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotBaseModel, gotEffort, gotOk := resolveCodexAlias(tt.modelName)
+			gotBaseModel, gotEffort, gotOk := resolveCodexAlias(nil, tt.modelName)
 			if gotBaseModel != tt.wantBaseModel {
 				t.Errorf("resolveCodexAlias(%q) baseModel = %q, want %q", tt.modelName, gotBaseModel, tt.wantBaseModel)
 			}
@@ -299,6 +310,146 @@ func TestCodexCacheHelper_ClaudePromptCacheKeyDeterministic(t *testing.T) {
 	}
 }
 
+func TestCodexCacheHelper_CustomCacheKeyHeaders(t *testing.T) {
+	e := &CodexExecutor{}
+	req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"metadata":{"user_id":"u-headers"}}`)}
+	raw := []byte(`{"model":"gpt-5","input":[],"instructions":""}`)
+	t.Cleanup(func() { deleteCodexCache("gpt-5-u-headers") })
+
+	t.Setenv("CODEX_SESSION_HEADER", "X-Upstream-Session")
+	t.Setenv("CODEX_CONVERSATION_HEADER", "")
+	httpReq, err := e.cacheHelper(context.Background(), sdktranslator.FormatClaude, "https://example.com/responses", req, raw)
+	if err != nil {
+		t.Fatalf("cacheHelper error: %v", err)
+	}
+	body, _ := io.ReadAll(httpReq.Body)
+	cacheKey := gjson.GetBytes(body, "prompt_cache_key").String()
+	if cacheKey == "" {
+		t.Fatalf("expected prompt_cache_key to be set: %s", body)
+	}
+	if got := httpReq.Header.Get("X-Upstream-Session"); got != cacheKey {
+		t.Fatalf("X-Upstream-Session header = %q, want %q", got, cacheKey)
+	}
+	for _, name := range []string{"Session_id", "Conversation_id"} {
+		if _, ok := httpReq.Header[name]; ok {
+			t.Fatalf("%s header sent although renamed or disabled: %v", name, httpReq.Header)
+		}
+	}
+
+	applyCodexHeaders(httpReq, nil, "token", false)
+	if got := httpReq.Header.Get("X-Upstream-Session"); got != cacheKey {
+		t.Fatalf("X-Upstream-Session header after applyCodexHeaders = %q, want %q", got, cacheKey)
+	}
+	if _, ok := httpReq.Header["Session_id"]; ok {
+		t.Fatalf("applyCodexHeaders added Session_id: %v", httpReq.Header)
+	}
+}
+
+func TestCodexCacheHelper_EvictsLeastRecentlyUsed(t *testing.T) {
+	e := &CodexExecutor{cfg: &config.Config{CodexCache: config.CodexCacheConfig{MaxEntries: 2, TTL: "10m"}}}
+	resetCodexCache := func() {
+		codexCacheMu.Lock()
+		codexCacheMap = make(map[string]*list.Element)
+		codexCacheLRU.Init()
+		codexCacheMaxEntries = codexCacheDefaultMaxEntries
+		codexCacheMu.Unlock()
+	}
+	resetCodexCache()
+	t.Cleanup(resetCodexCache)
+
+	promptCacheKey := func(user string) string {
+		req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"metadata":{"user_id":"` + user + `"}}`)}
+		httpReq, err := e.cacheHelper(context.Background(), sdktranslator.FormatClaude, "https://example.com/responses", req, []byte(`{"model":"gpt-5","input":[]}`))
+		if err != nil {
+			t.Fatalf("cacheHelper(%s): %v", user, err)
+		}
+		body, _ := io.ReadAll(httpReq.Body)
+		return gjson.GetBytes(body, "prompt_cache_key").String()
+	}
+
+	before := CodexCacheSnapshot()
+	keyA := promptCacheKey("lru-a")
+	promptCacheKey("lru-b")
+	promptCacheKey("lru-a") // a becomes most recently used, so c evicts b
+	promptCacheKey("lru-c")
+
+	if _, ok := getCodexCache("gpt-5-lru-b"); ok {
+		t.Fatal("expected the least recently used entry to be evicted")
+	}
+	if cache, ok := getCodexCache("gpt-5-lru-a"); !ok || cache.ID != keyA {
+		t.Fatalf("recently used entry = %+v (ok=%t), want ID %s", cache, ok, keyA)
+	}
+	after := CodexCacheSnapshot()
+	if after.MaxEntries != 2 || after.Entries != 2 {
+		t.Fatalf("snapshot entries = %d/%d, want 2/2", after.Entries, after.MaxEntries)
+	}
+	if after.Evictions-before.Evictions != 1 {
+		t.Fatalf("evictions += %d, want 1", after.Evictions-before.Evictions)
+	}
+	if hits, misses := after.Hits-before.Hits, after.Misses-before.Misses; hits != 2 || misses != 4 {
+		t.Fatalf("hits += %d, misses += %d; want 2 and 4", hits, misses)
+	}
+	if got := promptCacheKey("lru-a"); got != keyA {
+		t.Fatalf("prompt_cache_key = %q, want %q", got, keyA)
+	}
+}
+
+func TestCodexCacheSettings_EnvOverridesConfig(t *testing.T) {
+	cfg := &config.Config{CodexCache: config.CodexCacheConfig{MaxEntries: 50, TTL: "10m"}}
+
+	if maxEntries, ttl := codexCacheSettings(cfg); maxEntries != 50 || ttl != 10*time.Minute {
+		t.Fatalf("config settings = %d, %s; want 50, 10m", maxEntries, ttl)
+	}
+
+	t.Setenv("CODEX_CACHE_MAX_ENTRIES", "3")
+	t.Setenv("CODEX_CACHE_TTL_SECONDS", "90")
+	if maxEntries, ttl := codexCacheSettings(cfg); maxEntries != 3 || ttl != 90*time.Second {
+		t.Fatalf("env settings = %d, %s; want 3, 1m30s", maxEntries, ttl)
+	}
+
+	t.Setenv("CODEX_CACHE_MAX_ENTRIES", "-1")
+	t.Setenv("CODEX_CACHE_TTL_SECONDS", "soon")
+	if maxEntries, ttl := codexCacheSettings(nil); maxEntries != codexCacheDefaultMaxEntries || ttl != codexCacheDefaultTTL {
+		t.Fatalf("invalid env settings = %d, %s; want the defaults", maxEntries, ttl)
+	}
+}
+
+func TestCodexCacheHelper_ExpiredEntryKeepsPromptCacheKey(t *testing.T) {
+	t.Setenv("CODEX_CACHE_TTL_SECONDS", "60")
+	e := &CodexExecutor{}
+	key := "gpt-5-ttl-user"
+	deleteCodexCache(key)
+	t.Cleanup(func() { deleteCodexCache(key) })
+
+	promptCacheKey := func() string {
+		req := cliproxyexecutor.Request{Model: "gpt-5", Payload: []byte(`{"metadata":{"user_id":"ttl-user"}}`)}
+		httpReq, err := e.cacheHelper(context.Background(), sdktranslator.FormatClaude, "https://example.com/responses", req, []byte(`{"model":"gpt-5","input":[]}`))
+		if err != nil {
+			t.Fatalf("cacheHelper: %v", err)
+		}
+		body, _ := io.ReadAll(httpReq.Body)
+		return gjson.GetBytes(body, "prompt_cache_key").String()
+	}
+
+	first := promptCacheKey()
+	cache, ok := getCodexCache(key)
+	if !ok {
+		t.Fatal("expected the entry to be cached")
+	}
+	if remaining := time.Until(cache.Expire); remaining <= 0 || remaining > time.Minute {
+		t.Fatalf("entry expires in %s, want within CODEX_CACHE_TTL_SECONDS", remaining)
+	}
+
+	cache.Expire = time.Now().Add(-time.Second)
+	setCodexCache(key, cache, 0)
+	if _, ok := getCodexCache(key); ok {
+		t.Fatal("expected the expired entry to miss")
+	}
+	if got := promptCacheKey(); got != first {
+		t.Fatalf("prompt_cache_key after expiry = %q, want %q", got, first)
+	}
+}
+
 func TestTokenizerForCodexModel(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -370,3 +521,489 @@ func TestTokenizerForCodexModel(t *testing.T) {
 		})
 	}
 }
+
+func TestCodexExecutor_PrewarmSendsCredentialFreeHead(t *testing.T) {
+	var method, path, authorization string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, authorization = r.Method, r.URL.Path, r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	t.Cleanup(srv.Close)
+
+	auth := &cliproxyauth.Auth{ID: "codex-prewarm", Provider: "codex", Attributes: map[string]string{"api_key": "secret", "base_url": srv.URL + "/"}}
+	if err := NewCodexExecutor(&config.Config{}).Prewarm(context.Background(), auth, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Prewarm: %v", err)
+	}
+	if method != http.MethodHead || path != "/responses" || authorization != "" {
+		t.Fatalf("prewarm request = %s %s (Authorization %q), want a credential-free HEAD /responses", method, path, authorization)
+	}
+}
+
+func TestCodexResponsesURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs map[string]string
+		sub   string
+		want  string
+	}{
+		{"default", nil, "", "https://chatgpt.com/backend-api/codex/responses"},
+		{"base url trailing slash", map[string]string{"base_url": "https://gw.internal/"}, "", "https://gw.internal/responses"},
+		{"base path", map[string]string{"base_url": "https://gw.internal", "base_path": "/openai/v1/"}, "", "https://gw.internal/openai/v1/responses"},
+		{"base url query", map[string]string{"base_url": "https://gw.internal/api/?tenant=a", "base_path": "openai/v1"}, "compact", "https://gw.internal/api/openai/v1/responses/compact?tenant=a"},
+		{"responses url wins", map[string]string{"base_url": "https://ignored", "responses_url": "https://gw.internal/openai/v1/responses?api-version=2"}, "", "https://gw.internal/openai/v1/responses?api-version=2"},
+		{"responses url compact", map[string]string{"responses_url": "https://gw.internal/openai/v1/responses/"}, "compact", "https://gw.internal/openai/v1/responses/compact"},
+	}
+	for _, tt := range tests {
+		if got := codexResponsesURL(&cliproxyauth.Auth{Attributes: tt.attrs}, tt.sub); got != tt.want {
+			t.Errorf("%s: codexResponsesURL = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCodexExecutor_MountedUpstreamPath(t *testing.T) {
+	requests := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r.URL.RequestURI()
+		if r.URL.Path != "/openai/v1/responses" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", `{"type":"response.completed","response":{"id":"r1","output":[],"usage":{"input_tokens":1,"output_tokens":1,"total_tokens":2}}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	exec := NewCodexExecutor(&config.Config{})
+	req := cliproxyexecutor.Request{Model: "gpt-5-codex", Payload: []byte(`{"input":[]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}
+	auths := map[string]*cliproxyauth.Auth{
+		"/openai/v1/responses": {ID: "codex-base-path", Provider: "codex", Attributes: map[string]string{
+			"api_key": "test", "base_url": srv.URL + "/", "base_path": "/openai/v1/",
+		}},
+		"/openai/v1/responses?tenant=a": {ID: "codex-responses-url", Provider: "codex", Attributes: map[string]string{
+			"api_key": "test", "responses_url": srv.URL + "/openai/v1/responses?tenant=a",
+		}},
+	}
+	for want, auth := range auths {
+		if _, err := exec.Execute(context.Background(), auth, req, opts); err != nil {
+			t.Fatalf("%s: Execute: %v", auth.ID, err)
+		}
+		if got := <-requests; got != want {
+			t.Fatalf("%s: Execute hit %s, want %s", auth.ID, got, want)
+		}
+
+		streamOpts := opts
+		streamOpts.Stream = true
+		result, err := exec.ExecuteStream(context.Background(), auth, req, streamOpts)
+		if err != nil {
+			t.Fatalf("%s: ExecuteStream: %v", auth.ID, err)
+		}
+		for chunk := range result.Chunks {
+			if chunk.Err != nil {
+				t.Fatalf("%s: stream chunk: %v", auth.ID, chunk.Err)
+			}
+		}
+		if got := <-requests; got != want {
+			t.Fatalf("%s: ExecuteStream hit %s, want %s", auth.ID, got, want)
+		}
+	}
+}
+
+func TestCodexAliasSuffixError(t *testing.T) {
+	rejected := []string{"gpt-5.1-codex-max-ultra", "gpt-5-medium-high", "gpt-5.1-xlow", "gpt-5.2-codex-extreme"}
+	for _, model := range rejected {
+		err := codexAliasSuffixError(nil, model)
+		if err == nil {
+			t.Fatalf("codexAliasSuffixError(%q) = nil, want error", model)
+		}
+		if code := err.(statusErr).StatusCode(); code != http.StatusBadRequest {
+			t.Fatalf("codexAliasSuffixError(%q) status = %d, want 400", model, code)
+		}
+	}
+	passed := []string{"gpt-5", "gpt-5-mini", "gpt-5.1-max", "gpt-5-2025-08-07", "gpt-5-mini-high", "claude-sonnet", "gpt-4o"}
+	for _, model := range passed {
+		if err := codexAliasSuffixError(nil, model); err != nil {
+			t.Fatalf("codexAliasSuffixError(%q) = %v, want nil", model, err)
+		}
+	}
+}
+
+func TestCodexExecutor_UnknownAliasSuffixRejected(t *testing.T) {
+	models := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		models <- gjson.GetBytes(body, "model").String()
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", `{"type":"response.completed","response":{"id":"r1","output":[],"usage":{"input_tokens":1,"output_tokens":1,"total_tokens":2}}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	exec := NewCodexExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-alias", Provider: "codex", Attributes: map[string]string{"api_key": "test", "base_url": srv.URL}}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}
+
+	_, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "gpt-5.1-codex-max-ultra", Payload: []byte(`{"input":[]}`)}, opts)
+	if err == nil {
+		t.Fatal("Execute with typo alias succeeded, want 400")
+	}
+	if se, ok := err.(interface{ StatusCode() int }); !ok || se.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("Execute error = %v, want status 400", err)
+	}
+	if got := gjson.Get(err.Error(), "error.type").String(); got != "invalid_request_error" {
+		t.Fatalf("error.type = %q, want invalid_request_error", got)
+	}
+	message := gjson.Get(err.Error(), "error.message").String()
+	for _, want := range []string{"'ultra'", "xhigh", "minimal", "gpt-5.1-codex-max"} {
+		if !strings.Contains(message, want) {
+			t.Fatalf("error message %q does not mention %s", message, want)
+		}
+	}
+	select {
+	case model := <-models:
+		t.Fatalf("typo alias reached upstream as %q", model)
+	default:
+	}
+
+	if _, err := exec.Execute(context.Background(), auth, cliproxyexecutor.Request{Model: "claude-sonnet", Payload: []byte(`{"input":[]}`)}, opts); err != nil {
+		t.Fatalf("Execute claude-sonnet: %v", err)
+	}
+	if got := <-models; got != "claude-sonnet" {
+		t.Fatalf("upstream model = %q, want claude-sonnet", got)
+	}
+}
+
+func TestCodexExecutor_ReasoningEffortHeader(t *testing.T) {
+	bodies := make(chan []byte, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", `{"type":"response.completed","response":{"id":"r1","output":[],"usage":{"input_tokens":1,"output_tokens":1,"total_tokens":2}}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	exec := NewCodexExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{ID: "codex-effort-header", Provider: "codex", Attributes: map[string]string{"api_key": "test", "base_url": srv.URL}}
+	req := cliproxyexecutor.Request{Model: "gpt-5.1-codex-max-low", Payload: []byte(`{"input":[]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex"), Headers: http.Header{}}
+
+	opts.Headers.Set("X-Reasoning-Effort", "XHigh")
+	if _, err := exec.Execute(context.Background(), auth, req, opts); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	body := <-bodies
+	if got := gjson.GetBytes(body, "model").String(); got != "gpt-5.1-codex-max" {
+		t.Fatalf("upstream model = %q, want gpt-5.1-codex-max", got)
+	}
+	if got := gjson.GetBytes(body, "reasoning.effort").String(); got != "xhigh" {
+		t.Fatalf("reasoning.effort = %q, want the header's xhigh over the alias's low", got)
+	}
+
+	opts.Headers.Set("X-Reasoning-Effort", "ultra")
+	_, err := exec.Execute(context.Background(), auth, req, opts)
+	if se, ok := err.(interface{ StatusCode() int }); !ok || se.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("Execute with unknown header effort error = %v, want status 400", err)
+	}
+	if message := gjson.Get(err.Error(), "error.message").String(); !strings.Contains(message, "'ultra'") || !strings.Contains(message, "xhigh") {
+		t.Fatalf("error message %q does not name the bad and valid efforts", message)
+	}
+	select {
+	case body := <-bodies:
+		t.Fatalf("unknown header effort reached upstream: %s", body)
+	default:
+	}
+}
+
+func TestCodexExecutor_MetadataMappingReachesUpstream(t *testing.T) {
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", `{"type":"response.completed","response":{"id":"r1","output":[],"usage":{"input_tokens":1,"output_tokens":1,"total_tokens":2}}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	exec := NewCodexExecutor(&config.Config{Payload: config.PayloadConfig{Metadata: []config.PayloadMetadataRule{{
+		Models: []config.PayloadModelRule{{Name: "gpt-5*", Protocol: "codex"}},
+		Map:    map[string]string{"session": "user"},
+	}}}})
+	auth := &cliproxyauth.Auth{ID: "codex-metadata", Provider: "codex", Attributes: map[string]string{"api_key": "test", "base_url": srv.URL}}
+	req := cliproxyexecutor.Request{Model: "gpt-5-codex", Payload: []byte(`{"input":[],"metadata":{"session":"sess-42"}}`)}
+	if _, err := exec.Execute(context.Background(), auth, req, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if got := gjson.GetBytes(<-bodies, "user").String(); got != "sess-42" {
+		t.Fatalf("upstream user = %q, want sess-42 from metadata.session", got)
+	}
+}
+
+func TestResolveCodexAlias_ConfiguredBase(t *testing.T) {
+	cfg := &config.Config{Codex: config.CodexConfig{Aliases: []config.CodexAlias{
+		{Base: "gpt-6", Efforts: []string{"low", "high"}},
+		{Base: "gpt-5", Efforts: []string{"high"}},
+	}}}
+	cases := []struct {
+		model, base, effort string
+		ok                  bool
+	}{
+		{"gpt-6-low", "gpt-6", "low", true},
+		{"gpt-6-high", "gpt-6", "high", true},
+		{"gpt-6-medium", "", "", false},
+		{"gpt-6", "", "", false},
+		// A configured base replaces the built-in efforts of the same base.
+		{"gpt-5-high", "gpt-5", "high", true},
+		{"gpt-5-low", "", "", false},
+		// Other built-in bases still resolve.
+		{"gpt-5.1-codex-max-xhigh", "gpt-5.1-codex-max", "xhigh", true},
+	}
+	for _, tc := range cases {
+		base, effort, ok := resolveCodexAlias(cfg, tc.model)
+		if base != tc.base || effort != tc.effort || ok != tc.ok {
+			t.Fatalf("resolveCodexAlias(%q) = %q, %q, %t; want %q, %q, %t", tc.model, base, effort, ok, tc.base, tc.effort, tc.ok)
+		}
+	}
+
+	err := codexAliasSuffixError(cfg, "gpt-6-medium")
+	if err == nil || !strings.Contains(err.Error(), "gpt-6") {
+		t.Fatalf("codexAliasSuffixError(gpt-6-medium) = %v, want a 400 naming gpt-6", err)
+	}
+	if _, _, ok := resolveCodexAlias(nil, "gpt-6-low"); ok {
+		t.Fatal("gpt-6-low resolved without configuration")
+	}
+}
+
+// newCodexExpiringTokenServer 401s any request not bearing validToken and completes the rest.
+func newCodexExpiringTokenServer(t *testing.T, validToken string, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.Header.Get("Authorization") != "Bearer "+validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"code":"invalid_token","message":"token expired"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", `{"type":"response.completed","response":{"id":"r1","output":[],"usage":{"input_tokens":1,"output_tokens":1,"total_tokens":2}}}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func stubCodexRefreshTokens(t *testing.T, refreshes *atomic.Int32) {
+	t.Helper()
+	orig := codexRefreshTokens
+	codexRefreshTokens = func(_ context.Context, _ *config.Config, refreshToken string) (*codexauth.CodexTokenData, error) {
+		refreshes.Add(1)
+		if refreshToken != "refresh-1" {
+			return nil, fmt.Errorf("unexpected refresh token %q", refreshToken)
+		}
+		return &codexauth.CodexTokenData{AccessToken: "fresh-token", RefreshToken: "refresh-2"}, nil
+	}
+	t.Cleanup(func() { codexRefreshTokens = orig })
+}
+
+func TestCodexExecutor_RefreshesAndRetriesOnceAfter401(t *testing.T) {
+	var calls, refreshes atomic.Int32
+	srv := newCodexExpiringTokenServer(t, "fresh-token", &calls)
+	stubCodexRefreshTokens(t, &refreshes)
+
+	exec := NewCodexExecutor(&config.Config{})
+	newAuth := func() *cliproxyauth.Auth {
+		return &cliproxyauth.Auth{
+			ID:         "codex-oauth",
+			Provider:   "codex",
+			Attributes: map[string]string{"base_url": srv.URL},
+			Metadata:   map[string]any{"access_token": "stale-token", "refresh_token": "refresh-1", "email": "user@example.com"},
+		}
+	}
+	req := cliproxyexecutor.Request{Model: "gpt-5-codex", Payload: []byte(`{"input":[]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}
+
+	if _, err := exec.Execute(context.Background(), newAuth(), req, opts); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if calls.Load() != 2 || refreshes.Load() != 1 {
+		t.Fatalf("upstream calls = %d, refreshes = %d; want 2 and 1", calls.Load(), refreshes.Load())
+	}
+
+	calls.Store(0)
+	refreshes.Store(0)
+	opts.Stream = true
+	result, err := exec.ExecuteStream(context.Background(), newAuth(), req, opts)
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream chunk error: %v", chunk.Err)
+		}
+	}
+	if calls.Load() != 2 || refreshes.Load() != 1 {
+		t.Fatalf("stream upstream calls = %d, refreshes = %d; want 2 and 1", calls.Load(), refreshes.Load())
+	}
+}
+
+func TestCodexExecutor_No401RetryForAPIKeyOrSecondFailure(t *testing.T) {
+	var calls, refreshes atomic.Int32
+	srv := newCodexExpiringTokenServer(t, "never-valid", &calls)
+	stubCodexRefreshTokens(t, &refreshes)
+
+	exec := NewCodexExecutor(&config.Config{})
+	req := cliproxyexecutor.Request{Model: "gpt-5-codex", Payload: []byte(`{"input":[]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}
+
+	apiKeyAuth := &cliproxyauth.Auth{ID: "codex-key", Provider: "codex", Attributes: map[string]string{"api_key": "sk-test", "base_url": srv.URL}}
+	_, err := exec.Execute(context.Background(), apiKeyAuth, req, opts)
+	if se, ok := err.(interface{ StatusCode() int }); !ok || se.StatusCode() != http.StatusUnauthorized {
+		t.Fatalf("api key Execute error = %v, want 401", err)
+	}
+	if calls.Load() != 1 || refreshes.Load() != 0 {
+		t.Fatalf("api key upstream calls = %d, refreshes = %d; want 1 and 0", calls.Load(), refreshes.Load())
+	}
+
+	calls.Store(0)
+	oauth := &cliproxyauth.Auth{
+		ID:         "codex-oauth",
+		Provider:   "codex",
+		Attributes: map[string]string{"base_url": srv.URL},
+		Metadata:   map[string]any{"access_token": "stale-token", "refresh_token": "refresh-1", "email": "user@example.com"},
+	}
+	_, err = exec.Execute(context.Background(), oauth, req, opts)
+	if se, ok := err.(interface{ StatusCode() int }); !ok || se.StatusCode() != http.StatusUnauthorized {
+		t.Fatalf("oauth Execute error = %v, want 401 after the single retry", err)
+	}
+	if calls.Load() != 2 || refreshes.Load() != 1 {
+		t.Fatalf("oauth upstream calls = %d, refreshes = %d; want 2 and 1", calls.Load(), refreshes.Load())
+	}
+}
+
+func TestCodexExecutor_ConcurrentUnauthorizedRefreshOnce(t *testing.T) {
+	var calls, refreshes atomic.Int32
+	srv := newCodexExpiringTokenServer(t, "fresh-token", &calls)
+	stubCodexRefreshTokens(t, &refreshes)
+
+	manager := cliproxyauth.NewManager(nil, &cliproxyauth.RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(NewCodexExecutor(&config.Config{}))
+	if _, err := manager.Register(context.Background(), &cliproxyauth.Auth{
+		ID:         "codex-oauth",
+		Provider:   "codex",
+		Attributes: map[string]string{"base_url": srv.URL},
+		Metadata:   map[string]any{"access_token": "stale-token", "refresh_token": "refresh-1", "email": "user@example.com"},
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient("codex-oauth", "codex", []*registry.ModelInfo{{ID: "gpt-5-codex"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("codex-oauth") })
+
+	req := cliproxyexecutor.Request{Model: "gpt-5-codex", Payload: []byte(`{"input":[]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex"), Metadata: map[string]any{"forced_provider": true}}
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := manager.Execute(context.Background(), []string{"codex"}, req, opts); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Execute: %v", err)
+	}
+	// The refresh token is single use: a second refresh would spend the rotated one.
+	if got := refreshes.Load(); got != 1 {
+		t.Fatalf("refreshes = %d, want 1", got)
+	}
+	stored, _ := manager.GetByID("codex-oauth")
+	if got := stored.Metadata["refresh_token"]; got != "refresh-2" {
+		t.Fatalf("stored refresh_token = %v, want refresh-2", got)
+	}
+}
+
+func TestNewCodexStatusErr_RateLimitDelay(t *testing.T) {
+	cases := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   time.Duration
+	}{
+		{name: "retry-after header", header: http.Header{"Retry-After": {"30"}}, body: `{"error":{"type":"rate_limit_exceeded"}}`, want: 30 * time.Second},
+		{name: "longer ratelimit reset", header: http.Header{"X-Ratelimit-Reset-Requests": {"1s"}, "X-Ratelimit-Reset-Tokens": {"6m0s"}}, body: `{"error":{"type":"rate_limit_exceeded"}}`, want: 6 * time.Minute},
+		{name: "usage limit body", header: http.Header{}, body: `{"error":{"type":"usage_limit_reached","resets_in_seconds":120}}`, want: 2 * time.Minute},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := newCodexStatusErr(http.StatusTooManyRequests, tc.header, []byte(tc.body))
+			ra, ok := err.(interface{ RetryAfter() *time.Duration })
+			if !ok || ra.RetryAfter() == nil || *ra.RetryAfter() != tc.want {
+				t.Fatalf("retry after = %v, want %v", ra, tc.want)
+			}
+			he, ok := err.(interface{ Headers() http.Header })
+			if !ok {
+				t.Fatalf("error %T carries no headers", err)
+			}
+			if got, want := he.Headers().Get("Retry-After"), fmt.Sprint(int(tc.want.Seconds())); got != want {
+				t.Fatalf("Retry-After = %q, want %q", got, want)
+			}
+			if err.Error() != tc.body {
+				t.Fatalf("error body = %q, want the raw upstream JSON %q", err.Error(), tc.body)
+			}
+		})
+	}
+
+	if _, ok := newCodexStatusErr(http.StatusBadGateway, http.Header{"Retry-After": {"5"}}, nil).(statusErr); !ok {
+		t.Fatal("non-429 errors should stay plain statusErr values")
+	}
+}
+
+func TestCodexExecutor_RateLimitedAuthSkippedUntilReset(t *testing.T) {
+	var limitedCalls, healthyCalls atomic.Int32
+	limited := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limitedCalls.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":{"type":"rate_limit_exceeded","message":"slow down"}}`))
+	}))
+	t.Cleanup(limited.Close)
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyCalls.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", `{"type":"response.completed","response":{"id":"r1","output":[],"usage":{"input_tokens":1,"output_tokens":1,"total_tokens":2}}}`)
+	}))
+	t.Cleanup(healthy.Close)
+
+	manager := cliproxyauth.NewManager(nil, &cliproxyauth.RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(NewCodexExecutor(&config.Config{}))
+	for id, srv := range map[string]*httptest.Server{"codex-a": limited, "codex-b": healthy} {
+		auth := &cliproxyauth.Auth{ID: id, Provider: "codex", Attributes: map[string]string{"api_key": "sk-" + id, "base_url": srv.URL}}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register %s: %v", id, err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "codex", []*registry.ModelInfo{{ID: "gpt-5-codex"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+
+	req := cliproxyexecutor.Request{Model: "gpt-5-codex", Payload: []byte(`{"input":[]}`)}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex"), Metadata: map[string]any{"forced_provider": true}}
+	for i := 0; i < 3; i++ {
+		if _, err := manager.Execute(context.Background(), []string{"codex"}, req, opts); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if got := limitedCalls.Load(); got != 1 {
+		t.Fatalf("rate-limited auth called %d times, want 1 before its reset", got)
+	}
+	if got := healthyCalls.Load(); got != 3 {
+		t.Fatalf("healthy auth called %d times, want 3", got)
+	}
+
+	stored, _ := manager.GetByID("codex-a")
+	state := stored.ModelStates["gpt-5-codex"]
+	if state == nil || time.Until(state.NextRetryAfter) < 55*time.Second {
+		t.Fatalf("codex-a model state = %+v, want a cooldown of about 60s", state)
+	}
+}
@@ -0,0 +1,211 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"github.com/tiktoken-go/tokenizer"
+)
+
+// codexSlidingWindowHeader set to "sliding" opts a Codex request into the sliding context
+// window. It shares the header the handlers read for context-overflow retries. A Responses
+// API body with "truncation": "auto" opts in as well.
+const codexSlidingWindowHeader = "X-Context-Truncation"
+
+// codexSlidingWindowTarget is the share of the input budget a new cut trims down to. The
+// headroom lets the following turns reuse the same cut, so the retained prefix, and the
+// upstream prompt cache built on it, stays stable until the window fills up again.
+const codexSlidingWindowTarget = 0.75
+
+// codexWindowCut remembers how many leading conversation items were dropped for a prompt
+// cache key.
+type codexWindowCut struct {
+	dropped int
+	expire  time.Time
+}
+
+var (
+	codexWindowCuts   = make(map[string]codexWindowCut)
+	codexWindowCutsMu sync.Mutex
+)
+
+func getCodexWindowCut(key string) (int, bool) {
+	codexWindowCutsMu.Lock()
+	defer codexWindowCutsMu.Unlock()
+	cut, ok := codexWindowCuts[key]
+	if !ok || time.Now().After(cut.expire) {
+		return 0, false
+	}
+	return cut.dropped, true
+}
+
+func setCodexWindowCut(key string, dropped int) {
+	now := time.Now()
+	codexWindowCutsMu.Lock()
+	defer codexWindowCutsMu.Unlock()
+	for k, cut := range codexWindowCuts {
+		if now.After(cut.expire) {
+			delete(codexWindowCuts, k)
+		}
+	}
+	codexWindowCuts[key] = codexWindowCut{dropped: dropped, expire: now.Add(1 * time.Hour)}
+}
+
+// codexSlidingWindowRequested reports whether the client opted into the sliding window.
+func codexSlidingWindowRequested(ctx context.Context, from sdktranslator.Format, req cliproxyexecutor.Request) bool {
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+			if strings.EqualFold(strings.TrimSpace(ginCtx.GetHeader(codexSlidingWindowHeader)), "sliding") {
+				return true
+			}
+		}
+	}
+	return from == "openai-response" && strings.EqualFold(gjson.GetBytes(req.Payload, "truncation").String(), "auto")
+}
+
+// codexInputBudget returns the input token budget of model: its context window less the
+// tokens reserved for output. It returns 0 when the context window is unknown.
+func codexInputBudget(model string, rawJSON []byte) int64 {
+	info := registry.LookupModelInfo(model, "codex")
+	if info == nil || info.ContextLength <= 0 {
+		return 0
+	}
+	reserve := gjson.GetBytes(rawJSON, "max_output_tokens").Int()
+	if reserve <= 0 {
+		reserve = int64(info.MaxCompletionTokens)
+	}
+	return int64(info.ContextLength) - reserve
+}
+
+// applyCodexSlidingWindow trims the oldest conversation turns of an opted-in request so
+// its input fits the model context. It runs after the prompt cache key is set; the key is
+// not derived from the conversation, so trimming leaves it unchanged.
+func applyCodexSlidingWindow(ctx context.Context, from sdktranslator.Format, req cliproxyexecutor.Request, rawJSON []byte) []byte {
+	if !codexSlidingWindowRequested(ctx, from, req) {
+		return rawJSON
+	}
+	model := gjson.GetBytes(rawJSON, "model").String()
+	budget := codexInputBudget(model, rawJSON)
+	if budget <= 0 {
+		return rawJSON
+	}
+	enc, err := tokenizerForCodexModel(model)
+	if err != nil {
+		log.Debugf("codex sliding window: tokenizer unavailable for %s: %v", model, err)
+		return rawJSON
+	}
+	out, dropped := trimCodexSlidingWindow(enc, rawJSON, gjson.GetBytes(rawJSON, "prompt_cache_key").String(), budget)
+	if dropped > 0 {
+		log.Infof("codex sliding window: dropped %d input items to fit %d tokens", dropped, budget)
+	}
+	return out
+}
+
+// trimCodexSlidingWindow drops leading input items, keeping system and developer messages,
+// until the request fits budget tokens. Cuts land on user turns so tool calls stay paired
+// with their outputs, and the latest turn is always kept. A cut made for cacheKey is reused
+// while it still fits, so consecutive requests of a conversation share their prefix. It
+// returns the number of dropped items.
+func trimCodexSlidingWindow(enc tokenizer.Codec, rawJSON []byte, cacheKey string, budget int64) ([]byte, int) {
+	input := gjson.GetBytes(rawJSON, "input")
+	if !input.IsArray() {
+		return rawJSON, 0
+	}
+	items := input.Array()
+	base, err := countCodexInputTokens(enc, setCodexInput(rawJSON, nil))
+	if err != nil {
+		return rawJSON, 0
+	}
+	total := base
+	costs := make([]int64, len(items))
+	pinned := make([]bool, len(items))
+	// cuts lists the valid cuts: the number of unpinned items before each user turn.
+	onTurn := make(map[int]bool)
+	var cuts []int
+	unpinned := 0
+	for i, item := range items {
+		role := item.Get("role").String()
+		pinned[i] = role == "system" || role == "developer"
+		costs[i], _ = countCodexInputTokens(enc, setCodexInput(nil, []string{item.Raw}))
+		total += costs[i]
+		if pinned[i] {
+			continue
+		}
+		if t := item.Get("type").String(); role == "user" && (t == "" || t == "message") && unpinned > 0 {
+			onTurn[unpinned] = true
+			cuts = append(cuts, unpinned)
+		}
+		unpinned++
+	}
+
+	// sizeAfter returns the token count with the first dropped unpinned items removed.
+	sizeAfter := func(dropped int) int64 {
+		size, seen := total, 0
+		for i := range items {
+			if pinned[i] {
+				continue
+			}
+			if seen == dropped {
+				break
+			}
+			size -= costs[i]
+			seen++
+		}
+		return size
+	}
+
+	dropped := 0
+	if previous, ok := getCodexWindowCut(cacheKey); ok && cacheKey != "" {
+		if onTurn[previous] && sizeAfter(previous) <= budget {
+			dropped = previous
+		}
+	}
+	if dropped == 0 && total > budget && len(cuts) > 0 {
+		target := int64(float64(budget) * codexSlidingWindowTarget)
+		dropped = cuts[len(cuts)-1]
+		for _, cut := range cuts {
+			if sizeAfter(cut) <= target {
+				dropped = cut
+				break
+			}
+		}
+	}
+	if dropped == 0 {
+		return rawJSON, 0
+	}
+	if cacheKey != "" {
+		setCodexWindowCut(cacheKey, dropped)
+	}
+
+	kept := make([]string, 0, len(items))
+	seen := 0
+	for i, item := range items {
+		if !pinned[i] && seen < dropped {
+			seen++
+			continue
+		}
+		kept = append(kept, item.Raw)
+	}
+	return setCodexInput(rawJSON, kept), dropped
+}
+
+// setCodexInput replaces the input array of rawJSON with items.
+func setCodexInput(rawJSON []byte, items []string) []byte {
+	if rawJSON == nil {
+		rawJSON = []byte(`{}`)
+	}
+	out, err := sjson.SetRawBytes(rawJSON, "input", []byte("["+strings.Join(items, ",")+"]"))
+	if err != nil {
+		return rawJSON
+	}
+	return out
+}
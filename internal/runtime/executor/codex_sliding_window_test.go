@@ -0,0 +1,91 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func slidingWindowConversation(turns int) []byte {
+	body := []byte(`{"model":"gpt-5","instructions":"","prompt_cache_key":"conv-1","input":[]}`)
+	body, _ = sjson.SetRawBytes(body, "input.-1", []byte(`{"type":"message","role":"developer","content":[{"type":"input_text","text":"be brief"}]}`))
+	for i := 0; i < turns; i++ {
+		body = appendSlidingWindowTurn(body, i)
+	}
+	return body
+}
+
+func appendSlidingWindowTurn(body []byte, i int) []byte {
+	text := fmt.Sprintf("turn %d: %s", i, strings.Repeat("lorem ipsum dolor ", 20))
+	user := fmt.Sprintf(`{"type":"message","role":"user","content":[{"type":"input_text","text":%q}]}`, text)
+	assistant := fmt.Sprintf(`{"type":"message","role":"assistant","content":[{"type":"output_text","text":%q}]}`, text)
+	body, _ = sjson.SetRawBytes(body, "input.-1", []byte(user))
+	body, _ = sjson.SetRawBytes(body, "input.-1", []byte(assistant))
+	return body
+}
+
+func TestTrimCodexSlidingWindow_FitsAndKeepsPrefixStable(t *testing.T) {
+	enc, err := tokenizerForCodexModel("gpt-5")
+	if err != nil {
+		t.Fatalf("tokenizer: %v", err)
+	}
+	key := "conv-1"
+	codexWindowCutsMu.Lock()
+	delete(codexWindowCuts, key)
+	codexWindowCutsMu.Unlock()
+
+	body := slidingWindowConversation(12)
+	full, _ := countCodexInputTokens(enc, body)
+	budget := full / 2
+
+	trimmed, dropped := trimCodexSlidingWindow(enc, body, key, budget)
+	if dropped == 0 {
+		t.Fatalf("expected items to be dropped from a %d-token conversation with budget %d", full, budget)
+	}
+	if size, _ := countCodexInputTokens(enc, trimmed); size > budget {
+		t.Fatalf("trimmed size = %d, want <= %d", size, budget)
+	}
+	input := gjson.GetBytes(trimmed, "input").Array()
+	if input[0].Get("role").String() != "developer" {
+		t.Fatalf("developer message not kept first: %s", input[0].Raw)
+	}
+	if input[1].Get("role").String() != "user" {
+		t.Fatalf("window does not start on a user turn: %s", input[1].Raw)
+	}
+	if last := input[len(input)-1].Get("content.0.text").String(); !strings.HasPrefix(last, "turn 11:") {
+		t.Fatalf("latest turn not kept: %q", last)
+	}
+	if got := gjson.GetBytes(trimmed, "prompt_cache_key").String(); got != key {
+		t.Fatalf("prompt_cache_key = %q, want %q", got, key)
+	}
+
+	// The next request of the conversation reuses the cut, so the retained prefix is
+	// byte-identical and still matches the upstream prompt cache.
+	next, droppedNext := trimCodexSlidingWindow(enc, appendSlidingWindowTurn(body, 12), key, budget)
+	if droppedNext != dropped {
+		t.Fatalf("dropped = %d on the next turn, want the same cut %d", droppedNext, dropped)
+	}
+	prefix := gjson.GetBytes(trimmed, "input").Raw
+	prefix = prefix[:len(prefix)-1]
+	if !strings.HasPrefix(gjson.GetBytes(next, "input").Raw, prefix) {
+		t.Fatal("retained prefix changed on the next turn")
+	}
+	if got := gjson.GetBytes(next, "prompt_cache_key").String(); got != key {
+		t.Fatalf("prompt_cache_key = %q on the next turn, want %q", got, key)
+	}
+}
+
+func TestTrimCodexSlidingWindow_UnderBudgetUnchanged(t *testing.T) {
+	enc, err := tokenizerForCodexModel("gpt-5")
+	if err != nil {
+		t.Fatalf("tokenizer: %v", err)
+	}
+	body := slidingWindowConversation(3)
+	out, dropped := trimCodexSlidingWindow(enc, body, "", 1<<20)
+	if dropped != 0 || string(out) != string(body) {
+		t.Fatalf("under-budget request was modified: dropped=%d", dropped)
+	}
+}
@@ -0,0 +1,120 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// CodexTokenCount is a local prompt token estimate for a Codex model.
+type CodexTokenCount struct {
+	Model       string `json:"model"`
+	Encoding    string `json:"encoding"`
+	InputTokens int64  `json:"input_tokens"`
+}
+
+// CountCodexPromptTokens estimates the input tokens of payload for model without calling
+// upstream. payload may be a Responses request (instructions/input/tools), a chat
+// completions request (messages/tools) or {"text": "..."}; chat messages are reshaped into
+// Responses input items first so the count matches the Codex executor's CountTokens. The
+// model may carry the codex- prefix, a thinking suffix or an effort alias from cfg.
+func CountCodexPromptTokens(cfg *config.Config, model string, payload []byte) (CodexTokenCount, error) {
+	baseModel := stripCodexPrefix(thinking.ParseSuffix(strings.TrimSpace(model)).ModelName)
+	if aliasModel, _, ok := resolveCodexAlias(cfg, baseModel); ok {
+		baseModel = aliasModel
+	}
+	enc, err := tokenizerForCodexModel(baseModel)
+	if err != nil {
+		return CodexTokenCount{}, fmt.Errorf("tokenizer init failed: %w", err)
+	}
+	count, err := countCodexInputTokens(enc, codexTokenizeBody(payload))
+	if err != nil {
+		return CodexTokenCount{}, fmt.Errorf("token counting failed: %w", err)
+	}
+	return CodexTokenCount{Model: baseModel, Encoding: enc.GetName(), InputTokens: count}, nil
+}
+
+// codexTokenizeBody reshapes payload into the Responses fields countCodexInputTokens reads.
+func codexTokenizeBody(payload []byte) []byte {
+	root := gjson.ParseBytes(payload)
+	body := []byte(`{"input":[]}`)
+	if inst := root.Get("instructions"); inst.Exists() {
+		body, _ = sjson.SetBytes(body, "instructions", inst.String())
+	}
+	if format := root.Get("text.format"); format.Exists() {
+		body, _ = sjson.SetRawBytes(body, "text.format", []byte(format.Raw))
+	}
+
+	input := root.Get("input")
+	switch {
+	case input.IsArray():
+		body, _ = sjson.SetRawBytes(body, "input", []byte(input.Raw))
+	case input.Type == gjson.String:
+		body = appendCodexTokenizeMessage(body, input.String())
+	}
+	for _, msg := range root.Get("messages").Array() {
+		body = appendCodexTokenizeChatMessage(body, msg)
+	}
+	if text := root.Get("text"); text.Type == gjson.String {
+		body = appendCodexTokenizeMessage(body, text.String())
+	}
+
+	for _, tool := range root.Get("tools").Array() {
+		// Chat tools nest their definition under "function"; Responses tools are flat.
+		if fn := tool.Get("function"); fn.IsObject() {
+			tool = fn
+		}
+		item := []byte(`{}`)
+		item, _ = sjson.SetBytes(item, "name", tool.Get("name").String())
+		item, _ = sjson.SetBytes(item, "description", tool.Get("description").String())
+		if params := tool.Get("parameters"); params.Exists() {
+			item, _ = sjson.SetRawBytes(item, "parameters", []byte(params.Raw))
+		}
+		body, _ = sjson.SetRawBytes(body, "tools.-1", item)
+	}
+	return body
+}
+
+// appendCodexTokenizeChatMessage maps a chat completions message onto the Responses input
+// items the openai->codex translation would produce for it.
+func appendCodexTokenizeChatMessage(body []byte, msg gjson.Result) []byte {
+	content := msg.Get("content")
+	text := content.String()
+	if content.IsArray() {
+		parts := make([]string, 0, len(content.Array()))
+		for _, part := range content.Array() {
+			if t := part.Get("text"); t.Exists() {
+				parts = append(parts, t.String())
+			}
+		}
+		text = strings.Join(parts, "\n")
+	}
+	if msg.Get("role").String() == "tool" {
+		item := []byte(`{"type":"function_call_output"}`)
+		item, _ = sjson.SetBytes(item, "output", text)
+		body, _ = sjson.SetRawBytes(body, "input.-1", item)
+		return body
+	}
+	body = appendCodexTokenizeMessage(body, text)
+	for _, call := range msg.Get("tool_calls").Array() {
+		item := []byte(`{"type":"function_call"}`)
+		item, _ = sjson.SetBytes(item, "name", call.Get("function.name").String())
+		item, _ = sjson.SetBytes(item, "arguments", call.Get("function.arguments").String())
+		body, _ = sjson.SetRawBytes(body, "input.-1", item)
+	}
+	return body
+}
+
+func appendCodexTokenizeMessage(body []byte, text string) []byte {
+	if strings.TrimSpace(text) == "" {
+		return body
+	}
+	item := []byte(`{"type":"message","content":[{"type":"input_text"}]}`)
+	item, _ = sjson.SetBytes(item, "content.0.text", text)
+	body, _ = sjson.SetRawBytes(body, "input.-1", item)
+	return body
+}
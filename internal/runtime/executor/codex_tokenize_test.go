@@ -0,0 +1,73 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/tiktoken-go/tokenizer"
+)
+
+func TestCountCodexPromptTokens_Encodings(t *testing.T) {
+	cases := []struct {
+		model        string
+		wantModel    string
+		wantEncoding tokenizer.Encoding
+	}{
+		{"gpt-4o", "gpt-4o", tokenizer.O200kBase},
+		{"gpt-5", "gpt-5", tokenizer.O200kBase},
+		{"gpt-5.1-codex", "gpt-5.1-codex", tokenizer.O200kBase},
+		{"codex-gpt-5.1-codex-max-xhigh", "gpt-5.1-codex-max", tokenizer.O200kBase},
+		{"gpt-5.2(high)", "gpt-5.2", tokenizer.O200kBase},
+		{"mistral-large", "mistral-large", tokenizer.Cl100kBase},
+	}
+	const text = "How many tokens is this prompt, exactly?"
+	for _, tc := range cases {
+		got, err := CountCodexPromptTokens(nil, tc.model, []byte(`{"text":"`+text+`"}`))
+		if err != nil {
+			t.Fatalf("%s: CountCodexPromptTokens: %v", tc.model, err)
+		}
+		enc, err := tokenizer.Get(tc.wantEncoding)
+		if err != nil {
+			t.Fatalf("tokenizer.Get(%s): %v", tc.wantEncoding, err)
+		}
+		want, _ := enc.Count(text)
+		if got.Model != tc.wantModel || got.Encoding != string(tc.wantEncoding) || got.InputTokens != int64(want) {
+			t.Fatalf("%s: got %+v, want model %s encoding %s tokens %d", tc.model, got, tc.wantModel, tc.wantEncoding, want)
+		}
+	}
+}
+
+func TestCountCodexPromptTokens_ChatMatchesResponses(t *testing.T) {
+	chat := []byte(`{"model":"gpt-5.1-codex","messages":[
+		{"role":"system","content":"You are terse."},
+		{"role":"user","content":[{"type":"text","text":"List files"}]},
+		{"role":"assistant","content":null,"tool_calls":[{"id":"c1","type":"function","function":{"name":"ls","arguments":"{\"path\":\".\"}"}}]},
+		{"role":"tool","tool_call_id":"c1","content":"main.go\ngo.mod"}
+	],"tools":[{"type":"function","function":{"name":"ls","description":"List a directory","parameters":{"type":"object"}}}]}`)
+	responses := []byte(`{"model":"gpt-5.1-codex","input":[
+		{"type":"message","role":"developer","content":[{"type":"input_text","text":"You are terse."}]},
+		{"type":"message","role":"user","content":[{"type":"input_text","text":"List files"}]},
+		{"type":"function_call","call_id":"c1","name":"ls","arguments":"{\"path\":\".\"}"},
+		{"type":"function_call_output","call_id":"c1","output":"main.go\ngo.mod"}
+	],"tools":[{"type":"function","name":"ls","description":"List a directory","parameters":{"type":"object"}}]}`)
+
+	fromChat, err := CountCodexPromptTokens(nil, "gpt-5.1-codex", chat)
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	fromResponses, err := CountCodexPromptTokens(nil, "gpt-5.1-codex", responses)
+	if err != nil {
+		t.Fatalf("responses: %v", err)
+	}
+	if fromChat.InputTokens == 0 || fromChat.InputTokens != fromResponses.InputTokens {
+		t.Fatalf("chat count %d, responses count %d; want equal and non-zero", fromChat.InputTokens, fromResponses.InputTokens)
+	}
+
+	enc, _ := tokenizerForCodexModel("gpt-5.1-codex")
+	executorCount, err := countCodexInputTokens(enc, responses)
+	if err != nil {
+		t.Fatalf("countCodexInputTokens: %v", err)
+	}
+	if fromResponses.InputTokens != executorCount {
+		t.Fatalf("tokenize count %d, executor count %d", fromResponses.InputTokens, executorCount)
+	}
+}
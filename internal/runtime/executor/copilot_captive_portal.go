@@ -0,0 +1,98 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/tidwall/sjson"
+)
+
+// copilotCaptivePortalSniffBytes bounds how much of an untyped body is read to tell HTML
+// from JSON or SSE.
+const copilotCaptivePortalSniffBytes = 512
+
+var htmlTitlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// copilotCaptivePortalCheckEnabled reports whether HTML answers to API calls are turned
+// into captive-portal errors; COPILOT_CAPTIVE_PORTAL_CHECK=false passes them through.
+func copilotCaptivePortalCheckEnabled() bool {
+	return envTruthy("COPILOT_CAPTIVE_PORTAL_CHECK", true)
+}
+
+// detectCaptivePortal reports whether resp is an HTML page where the Copilot API answers
+// with JSON or SSE, as served by captive portals and intercepting middleboxes. Only
+// successful responses and 511 Network Authentication Required are checked; error
+// statuses are reported as they are. A body with a JSON or SSE content type is trusted
+// without reading it. Otherwise the first bytes are sniffed for <!DOCTYPE or <html and
+// put back, so the body is unchanged for the caller. title is the page title, if any.
+func detectCaptivePortal(resp *http.Response) (portal bool, title string) {
+	if resp == nil || resp.Body == nil {
+		return false, ""
+	}
+	if resp.StatusCode != http.StatusNetworkAuthenticationRequired && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		return false, ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/json", mediaType == "text/event-stream", strings.HasSuffix(mediaType, "+json"):
+		return false, ""
+	}
+
+	// A single Read returns what has arrived so far, so a slow stream is not held up
+	// waiting for a full sniff buffer.
+	buf := make([]byte, copilotCaptivePortalSniffBytes)
+	n, errRead := resp.Body.Read(buf)
+	head := buf[:n]
+	resp.Body = &sniffedBody{Reader: io.MultiReader(bytes.NewReader(head), &errReader{r: resp.Body, err: errRead}), Closer: resp.Body}
+
+	trimmed := bytes.ToLower(bytes.TrimSpace(head))
+	isHTML := mediaType == "text/html" || mediaType == "application/xhtml+xml" ||
+		bytes.HasPrefix(trimmed, []byte("<!doctype")) || bytes.HasPrefix(trimmed, []byte("<html"))
+	if !isHTML {
+		return false, ""
+	}
+	if m := htmlTitlePattern.FindSubmatch(head); m != nil {
+		title = strings.Join(strings.Fields(string(m[1])), " ")
+		if len(title) > 120 {
+			title = title[:120]
+		}
+	}
+	return true, title
+}
+
+// captivePortalError is the 502 reported instead of an HTML page from transport.
+func captivePortalError(transport string, resp *http.Response, title string) error {
+	message := fmt.Sprintf("upstream returned an HTML page (status %d, Content-Type %q) via the %s transport where JSON or SSE was expected; a captive portal or network interception is likely",
+		resp.StatusCode, resp.Header.Get("Content-Type"), transport)
+	if title != "" {
+		message += fmt.Sprintf(" (page title %q)", title)
+	}
+	body := []byte(`{"error":{"type":"proxy_error","code":"captive_portal_detected"}}`)
+	body, _ = sjson.SetBytes(body, "error.message", message)
+	return statusErr{code: http.StatusBadGateway, msg: string(body)}
+}
+
+// sniffedBody replays the sniffed bytes before the rest of the original body.
+type sniffedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// errReader returns err, the error of the sniffing Read, before reading r again; a
+// nil err reads r directly.
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (e *errReader) Read(p []byte) (int, error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	return e.r.Read(p)
+}
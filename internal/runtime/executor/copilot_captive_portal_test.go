@@ -0,0 +1,127 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+const fakeCaptivePortalPage = "<!DOCTYPE html>\n<html><head><title>Hotel WiFi Login</title></head><body>Accept the terms</body></html>"
+
+func assertCaptivePortalError(t *testing.T, err error, transport string) {
+	t.Helper()
+	se, ok := err.(interface{ StatusCode() int })
+	if !ok || se.StatusCode() != http.StatusBadGateway {
+		t.Fatalf("error = %v, want a 502 captive-portal error", err)
+	}
+	if code := gjson.Get(err.Error(), "error.code").String(); code != "captive_portal_detected" {
+		t.Fatalf("error.code = %q, want captive_portal_detected", code)
+	}
+	message := gjson.Get(err.Error(), "error.message").String()
+	for _, want := range []string{transport + " transport", "captive portal", "Hotel WiFi Login"} {
+		if !strings.Contains(message, want) {
+			t.Fatalf("error message %q does not mention %q", message, want)
+		}
+	}
+}
+
+func TestCopilotDoRequest_CaptivePortalHTML(t *testing.T) {
+	e := NewCopilotExecutor(&config.Config{})
+
+	t.Run("go transport html content type", func(t *testing.T) {
+		t.Setenv("COPILOT_TRANSPORT", "go")
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = io.WriteString(w, fakeCaptivePortalPage)
+		}))
+		defer srv.Close()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/chat/completions", strings.NewReader(`{}`))
+		resp, err := e.copilotDoRequest(context.Background(), nil, req)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		assertCaptivePortalError(t, err, "go")
+	})
+
+	t.Run("go transport untyped html body", func(t *testing.T) {
+		t.Setenv("COPILOT_TRANSPORT", "go")
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header()["Content-Type"] = nil
+			_, _ = io.WriteString(w, "  "+fakeCaptivePortalPage)
+		}))
+		defer srv.Close()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/chat/completions", strings.NewReader(`{}`))
+		_, err := e.copilotDoRequest(context.Background(), nil, req)
+		assertCaptivePortalError(t, err, "go")
+	})
+
+	t.Run("electron page falls back to go transport", func(t *testing.T) {
+		t.Setenv("COPILOT_TRANSPORT", "auto")
+		t.Setenv("CLIPROXY_FAKE_ELECTRON_HTML", "1")
+		fakeCopilotElectronRunner(t)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"ok":true}`)
+		}))
+		defer srv.Close()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/chat/completions", strings.NewReader(`{}`))
+		resp, err := e.copilotDoRequest(context.Background(), nil, req)
+		if err != nil {
+			t.Fatalf("copilotDoRequest: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if body, _ := io.ReadAll(resp.Body); string(body) != `{"ok":true}` {
+			t.Fatalf("body = %q, want the go transport's JSON", body)
+		}
+		if got := resp.Header.Get(CopilotTransportHeader); got != "go-fallback" {
+			t.Fatalf("%s = %q, want go-fallback", CopilotTransportHeader, got)
+		}
+	})
+
+	t.Run("check disabled", func(t *testing.T) {
+		t.Setenv("COPILOT_TRANSPORT", "go")
+		t.Setenv("COPILOT_CAPTIVE_PORTAL_CHECK", "false")
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = io.WriteString(w, fakeCaptivePortalPage)
+		}))
+		defer srv.Close()
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/chat/completions", strings.NewReader(`{}`))
+		resp, err := e.copilotDoRequest(context.Background(), nil, req)
+		if err != nil {
+			t.Fatalf("copilotDoRequest: %v", err)
+		}
+		_ = resp.Body.Close()
+	})
+}
+
+func TestDetectCaptivePortal_KeepsNonHTMLBody(t *testing.T) {
+	cases := []struct {
+		name, contentType, body string
+	}{
+		{name: "untyped json", body: `{"choices":[]}`},
+		{name: "plain text", contentType: "text/plain", body: "data: {}\n\n"},
+		{name: "json", contentType: "application/json", body: "<html>not sniffed</html>"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(tc.body))}
+			if tc.contentType != "" {
+				resp.Header.Set("Content-Type", tc.contentType)
+			}
+			if portal, _ := detectCaptivePortal(resp); portal {
+				t.Fatal("detected a captive portal, want none")
+			}
+			got, err := io.ReadAll(resp.Body)
+			if err != nil || string(got) != tc.body {
+				t.Fatalf("body = %q (err %v), want %q unchanged", got, err, tc.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package executor
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// decodeCopilotContentEncoding replaces a compressed Copilot response body with its
+// plaintext. The SSE and JSON parsers downstream read the body as text, but the Electron
+// shim forwards the bytes exactly as received, and the Go transport only decompresses
+// gzip it asked for itself. Content-Encoding and the compressed Content-Length are
+// dropped. Only a single gzip, deflate, br or zstd coding is decoded; anything else is
+// left as received. The decoder is created on the first Read, so returning resp never
+// waits for body bytes.
+func decodeCopilotContentEncoding(resp *http.Response) {
+	if resp == nil || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "gzip", "x-gzip", "deflate", "br", "zstd":
+	default:
+		return
+	}
+	resp.Body = &lazyDecodedBody{body: resp.Body, encoding: encoding}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// lazyDecodedBody decodes body with encoding, opening the decoder on the first Read.
+type lazyDecodedBody struct {
+	body     io.ReadCloser
+	encoding string
+	decoded  io.ReadCloser
+	err      error
+}
+
+func (b *lazyDecodedBody) Read(p []byte) (int, error) {
+	if b.decoded == nil && b.err == nil {
+		b.decoded, b.err = openCopilotDecoder(b.body, b.encoding)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.decoded.Read(p)
+}
+
+func (b *lazyDecodedBody) Close() error {
+	if b.decoded != nil {
+		return b.decoded.Close()
+	}
+	if b.err != nil {
+		// A failed decoder already closed the body.
+		return nil
+	}
+	return b.body.Close()
+}
+
+// openCopilotDecoder returns a reader of the plaintext of body. HTTP deflate is zlib
+// framed, but some servers send a raw deflate stream, so the zlib header is checked first.
+func openCopilotDecoder(body io.ReadCloser, encoding string) (io.ReadCloser, error) {
+	switch encoding {
+	case "x-gzip":
+		encoding = "gzip"
+	case "deflate":
+		buffered := bufio.NewReader(body)
+		header, _ := buffered.Peek(2)
+		closeBody := func() error { return body.Close() }
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			zr, err := zlib.NewReader(buffered)
+			if err != nil {
+				_ = body.Close()
+				return nil, err
+			}
+			return &compositeReadCloser{Reader: zr, closers: []func() error{zr.Close, closeBody}}, nil
+		}
+		fr := flate.NewReader(buffered)
+		return &compositeReadCloser{Reader: fr, closers: []func() error{fr.Close, closeBody}}, nil
+	}
+	return decodeResponseBody(body, encoding)
+}
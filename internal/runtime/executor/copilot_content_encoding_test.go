@@ -0,0 +1,114 @@
+package executor
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const compressedSSE = "data: {\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\n\ndata: [DONE]\n\n"
+
+func gzipBytes(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = io.WriteString(zw, data)
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestElectronResponseFromShim_DecodesGzipBody(t *testing.T) {
+	compressed := gzipBytes(t, compressedSSE)
+	half := len(compressed) / 2
+	src := &stallingLineSource{stopped: make(chan struct{}), lines: [][]byte{
+		[]byte(`{"type":"meta","status":200,"statusText":"OK","headers":{"Content-Type":"text/event-stream","Content-Encoding":"gzip","Content-Length":"` + strconv.Itoa(len(compressed)) + `"}}` + "\n"),
+		[]byte(`{"type":"chunk","b64":"` + base64.StdEncoding.EncodeToString(compressed[:half]) + `"}` + "\n"),
+		[]byte(`{"type":"chunk","b64":"` + base64.StdEncoding.EncodeToString(compressed[half:]) + `"}` + "\n"),
+		[]byte(`{"type":"end"}` + "\n"),
+	}}
+	req, _ := http.NewRequest(http.MethodPost, "https://api.githubcopilot.com/chat/completions", nil)
+	resp, err := electronResponseFromShim(context.Background(), req, "", src)
+	if err != nil {
+		t.Fatalf("electronResponseFromShim: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if body, errRead := io.ReadAll(resp.Body); errRead != nil || string(body) != compressedSSE {
+		t.Fatalf("body = %q, %v; want the plaintext stream", body, errRead)
+	}
+	if got := resp.Header.Get("Content-Encoding"); got != "" {
+		t.Fatalf("Content-Encoding = %q, want it stripped", got)
+	}
+	if got := resp.Header.Get("Content-Length"); got != "" {
+		t.Fatalf("Content-Length = %q, want the compressed length stripped", got)
+	}
+}
+
+func TestCopilotGoAttempt_DecodesRequestedEncoding(t *testing.T) {
+	t.Setenv("COPILOT_TRANSPORT", "go")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(gzipBytes(t, compressedSSE))
+	}))
+	defer srv.Close()
+
+	// An explicit Accept-Encoding stops net/http from decompressing on its own.
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/chat/completions", strings.NewReader(`{}`))
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := NewCopilotExecutor(&config.Config{}).copilotDoRequest(context.Background(), nil, req)
+	if err != nil {
+		t.Fatalf("copilotDoRequest: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if body, _ := io.ReadAll(resp.Body); string(body) != compressedSSE {
+		t.Fatalf("body = %q, want the plaintext stream", body)
+	}
+}
+
+func TestDecodeCopilotContentEncoding(t *testing.T) {
+	var zlibBuf, flateBuf bytes.Buffer
+	zw := zlib.NewWriter(&zlibBuf)
+	_, _ = io.WriteString(zw, compressedSSE)
+	_ = zw.Close()
+	fw, _ := flate.NewWriter(&flateBuf, flate.DefaultCompression)
+	_, _ = io.WriteString(fw, compressedSSE)
+	_ = fw.Close()
+
+	cases := []struct {
+		name, encoding string
+		body           []byte
+		want           string
+		keepHeader     bool
+	}{
+		{name: "gzip", encoding: "gzip", body: gzipBytes(t, compressedSSE), want: compressedSSE},
+		{name: "zlib deflate", encoding: "deflate", body: zlibBuf.Bytes(), want: compressedSSE},
+		{name: "raw deflate", encoding: "Deflate", body: flateBuf.Bytes(), want: compressedSSE},
+		{name: "identity", encoding: "identity", body: []byte(compressedSSE), want: compressedSSE, keepHeader: true},
+		{name: "stacked codings are left alone", encoding: "gzip, br", body: []byte("opaque"), want: "opaque", keepHeader: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{"Content-Encoding": {tc.encoding}}, Body: io.NopCloser(bytes.NewReader(tc.body))}
+			decodeCopilotContentEncoding(resp)
+			if body, err := io.ReadAll(resp.Body); err != nil || string(body) != tc.want {
+				t.Fatalf("body = %q, %v; want %q", body, err, tc.want)
+			}
+			if kept := resp.Header.Get("Content-Encoding") != ""; kept != tc.keepHeader {
+				t.Fatalf("Content-Encoding kept = %v, want %v", kept, tc.keepHeader)
+			}
+		})
+	}
+}
@@ -3,14 +3,32 @@
 //
 // This is intentionally tiny: it reads a single JSON request from stdin, performs the request
 // using Electron's net stack, and streams a line-delimited JSON response to stdout:
-//   {"type":"meta","status":200,"statusText":"OK","headers":{...}}
+//   {"type":"meta","status":200,"statusText":"OK","headers":{"name":["value",...]},"protocol":"h2"}
 //   {"type":"chunk","b64":"..."}
 //   {"type":"end"}
 //   {"type":"error","message":"..."}
 //
+// With COPILOT_ELECTRON_POOL=1 the process stays up and serves one request per stdin line.
+// Every request carries an "id" that is echoed on each of its response messages so
+// concurrent streams can be demultiplexed; {"id":"...","cancel":true} aborts a request.
+// The process exits once stdin is closed and in-flight requests have finished. Failures
+// not tied to a request are reported as {"type":"fatal","message":"..."}.
+//
+// A request with "cookie_jar" loads that file into the session before it is sent and
+// saves the session cookies back once it finishes, under "<jar>.lock".
+//
+// A request with "ca_cert" (PEM) trusts those certificates as extra roots: a chain Chromium
+// rejects only for an unknown issuer is accepted when it leads to one of them. Any
+// certificate failure is reported with phase "tls" and the failing host.
+//
 // Go parses this stream and exposes it as an *http.Response with a streaming Body.
 
 const { app, net, session } = require("electron");
+const crypto = require("crypto");
+const fs = require("fs");
+const readline = require("readline");
+
+const poolMode = String(process.env.COPILOT_ELECTRON_POOL || "") === "1";
 
 // Prevent Chromium from trying to use a GPU or display server in headless environments.
 app.disableHardwareAcceleration();
@@ -72,6 +90,51 @@ function normalizeHeaders(inHeaders) {
   return out;
 }
 
+// responseHeaders lists every value of each response header, so repeated headers such as
+// Set-Cookie are not joined. rawHeaders keeps the values as received when available.
+function responseHeaders(response) {
+  const out = {};
+  const add = (k, v) => {
+    if (v === undefined || v === null) return;
+    (out[k] || (out[k] = [])).push(String(v));
+  };
+  const raw = response.rawHeaders;
+  if (Array.isArray(raw) && raw.length > 0) {
+    for (const entry of raw) {
+      if (entry && typeof entry === "object" && !Array.isArray(entry)) add(entry.key, entry.value);
+    }
+    for (let i = 0; i + 1 < raw.length; i += 2) {
+      if (typeof raw[i] === "string") add(raw[i], raw[i + 1]);
+    }
+    if (Object.keys(out).length > 0) return out;
+  }
+  for (const [k, v] of Object.entries(response.headers || {})) {
+    if (Array.isArray(v)) v.forEach((item) => add(k, item));
+    else add(k, v);
+  }
+  return out;
+}
+
+// responseProtocol reports the negotiated HTTP version as "h2" or "http/1.x".
+function responseProtocol(response) {
+  const major = Number(response.httpVersionMajor || 0);
+  if (major >= 2) return "h2";
+  if (major === 1) return `http/1.${Number(response.httpVersionMinor || 0)}`;
+  return "";
+}
+
+// withoutFramingHeaders drops Content-Length and Transfer-Encoding: the body is written in
+// one piece and Chromium sets Content-Length from it.
+function withoutFramingHeaders(headers) {
+  const out = {};
+  for (const [k, v] of Object.entries(headers)) {
+    const lower = k.toLowerCase();
+    if (lower === "content-length" || lower === "transfer-encoding") continue;
+    out[k] = v;
+  }
+  return out;
+}
+
 function proxyBypassFromNoProxy(noProxy) {
   const raw = (noProxy || "").trim();
   if (!raw) return "";
@@ -103,7 +166,12 @@ function proxyRulesFromURL(proxyURL) {
   }
 }
 
-function proxyCredentials(proxyURL) {
+// proxyCredentials returns the credentials sent alongside the proxy URL, falling back to
+// userinfo embedded in the URL by older callers.
+function proxyCredentials(req, proxyURL) {
+  if (req.proxy_username) {
+    return { username: req.proxy_username, password: req.proxy_password || "" };
+  }
   if (!proxyURL) return null;
   try {
     const u = new URL(proxyURL);
@@ -139,61 +207,292 @@ function summarizeError(errLike) {
   return String(errLike);
 }
 
-async function main() {
-  const raw = await readAllStdin();
-  const req = JSON.parse(raw || "{}");
+function isCertificateError(errLike) {
+  const msg = String(errLike && errLike.message ? errLike.message : errLike).toUpperCase();
+  return msg.includes("ERR_CERT_") || msg.includes("ERR_SSL_") || msg.includes("ERR_BAD_SSL_CLIENT_AUTH_CERT");
+}
+
+// Chromium's net error for a chain that does not lead to a trusted root.
+const errCertAuthorityInvalid = -202;
+
+// tlsFailures keeps the last certificate verification result per host that was rejected,
+// so the request error can say why.
+const tlsFailures = new Map();
+
+function parseCACerts(pem) {
+  const blocks = String(pem || "").match(/-----BEGIN CERTIFICATE-----[\s\S]+?-----END CERTIFICATE-----/g) || [];
+  const cas = [];
+  for (const block of blocks) {
+    try {
+      cas.push(new crypto.X509Certificate(block));
+    } catch (err) {
+      process.stderr.write(`ca cert: skipping unparsable certificate: ${summarizeError(err)}\n`);
+    }
+  }
+  return cas;
+}
+
+// chainFrom converts Electron's certificate, leaf first and linked by issuerCert, into
+// X509Certificates.
+function chainFrom(certificate) {
+  const chain = [];
+  for (let cert = certificate; cert && chain.length < 10; cert = cert.issuerCert) {
+    chain.push(new crypto.X509Certificate(cert.data));
+    if (cert.issuerCert === cert) break;
+  }
+  return chain;
+}
+
+function leafMatchesHost(leaf, hostname) {
+  if (leaf.checkHost(hostname)) return true;
+  try {
+    return !!leaf.checkIP(hostname);
+  } catch {
+    return false;
+  }
+}
+
+// chainTrustedBy reports whether chain is a currently valid chain for hostname whose
+// signatures lead to one of cas.
+function chainTrustedBy(chain, hostname, cas) {
+  if (!chain.length || !leafMatchesHost(chain[0], hostname)) return false;
+  const now = Date.now();
+  for (let i = 0; i < chain.length; i++) {
+    const cert = chain[i];
+    if (now < Date.parse(cert.validFrom) || now > Date.parse(cert.validTo)) return false;
+    for (const ca of cas) {
+      if (cert.fingerprint256 === ca.fingerprint256 || (cert.checkIssued(ca) && cert.verify(ca.publicKey))) return true;
+    }
+    const issuer = chain[i + 1];
+    if (!issuer || !cert.checkIssued(issuer) || !cert.verify(issuer.publicKey)) return false;
+  }
+  return false;
+}
+
+// certificateVerifier keeps Chromium's verdict except for an unknown issuer, which is
+// re-checked against the configured CA certificates. Expired, mismatched or revoked
+// certificates stay rejected.
+function certificateVerifier(cas) {
+  return (request, callback) => {
+    if (request.errorCode === 0) {
+      callback(-3);
+      return;
+    }
+    if (request.errorCode === errCertAuthorityInvalid) {
+      try {
+        if (chainTrustedBy(chainFrom(request.certificate), request.hostname, cas)) {
+          tlsFailures.delete(request.hostname);
+          callback(0);
+          return;
+        }
+      } catch (err) {
+        process.stderr.write(`ca cert: verifying ${request.hostname} failed: ${summarizeError(err)}\n`);
+      }
+    }
+    tlsFailures.set(request.hostname, request.verificationResult || String(request.errorCode));
+    callback(-3);
+  };
+}
+
+// sessionFor returns the session configured for proxyURL and caCert. One-shot processes use
+// the default session as before; pooled processes keep one partition per proxy and CA so
+// concurrent requests through different proxies do not overwrite each other's rules.
+const sessions = new Map();
+function sessionFor(proxyURL, noProxy, creds, caCert) {
+  let key = proxyURL ? `${proxyURL}\n${noProxy}\n${creds ? creds.username : ""}` : "";
+  if (caCert) key += `\nca=${crypto.createHash("sha256").update(caCert).digest("hex")}`;
+  if (!sessions.has(key)) {
+    const ses = poolMode && key ? session.fromPartition(`cliproxy-proxy-${sessions.size}`) : session.defaultSession;
+    sessions.set(key, configureSession(ses, proxyURL, noProxy, creds, caCert));
+  }
+  return sessions.get(key);
+}
+
+async function configureSession(ses, proxyURL, noProxy, creds, caCert) {
+  if (caCert) {
+    const cas = parseCACerts(caCert);
+    if (cas.length) ses.setCertificateVerifyProc(certificateVerifier(cas));
+  }
+  // Best-effort proxy handling. If this fails, we still attempt the request without proxy.
+  if (!proxyURL) return ses;
+  try {
+    const rules = proxyRulesFromURL(proxyURL);
+    const bypass = proxyBypassFromNoProxy(noProxy);
+    if (rules) {
+      await ses.setProxy({
+        proxyRules: rules,
+        proxyBypassRules: bypass || undefined,
+      });
+    }
+  } catch {
+    // ignore
+  }
+
+  // Handle proxy authentication via the session "login" event.
+  // Electron's net module does not support Proxy-Authorization as a request header;
+  // instead Chromium issues a 407 challenge and expects credentials via this callback.
+  if (creds) {
+    ses.on("login", (event, _webContents, _details, authInfo, callback) => {
+      if (authInfo.isProxy) {
+        event.preventDefault();
+        callback(creds.username, creds.password);
+      }
+    });
+  }
+  return ses;
+}
+
+// A cookie jar lock older than this is left over from a crashed process and is broken.
+const cookieJarLockStaleMs = 10000;
+const cookieJarLockWaitMs = 5000;
+
+// withCookieJarLock runs fn while holding "<jarPath>.lock", created exclusively so
+// concurrent shim processes never read or write the jar at the same time.
+async function withCookieJarLock(jarPath, fn) {
+  const lockPath = `${jarPath}.lock`;
+  const deadline = Date.now() + cookieJarLockWaitMs;
+  for (;;) {
+    try {
+      fs.closeSync(fs.openSync(lockPath, "wx", 0o600));
+      break;
+    } catch (err) {
+      if (!err || err.code !== "EEXIST") throw err;
+    }
+    try {
+      if (Date.now() - fs.statSync(lockPath).mtimeMs > cookieJarLockStaleMs) {
+        fs.rmSync(lockPath, { force: true });
+        continue;
+      }
+    } catch {
+      continue;
+    }
+    if (Date.now() > deadline) throw new Error(`cookie jar ${jarPath} is locked`);
+    await new Promise((resolve) => setTimeout(resolve, 25));
+  }
+  try {
+    return await fn();
+  } finally {
+    fs.rmSync(lockPath, { force: true });
+  }
+}
 
-  const method = (req.method || "GET").toUpperCase();
+function readCookieJar(jarPath) {
+  try {
+    const saved = JSON.parse(fs.readFileSync(jarPath, "utf8"));
+    return Array.isArray(saved) ? saved : [];
+  } catch {
+    return [];
+  }
+}
+
+function cookieKey(c) {
+  return `${c.domain || ""}\n${c.path || "/"}\n${c.name}`;
+}
+
+function cookieExpired(c, nowSeconds) {
+  return typeof c.expirationDate === "number" && c.expirationDate <= nowSeconds;
+}
+
+// loadCookieJar copies the jar's cookies into ses, recording their keys in loaded, and
+// returns how many cookies ses holds.
+async function loadCookieJar(ses, jarPath, loaded) {
+  const saved = await withCookieJarLock(jarPath, async () => readCookieJar(jarPath));
+  const nowSeconds = Date.now() / 1000;
+  for (const c of saved) {
+    if (!c || !c.name || !c.domain || cookieExpired(c, nowSeconds)) continue;
+    const host = String(c.domain).replace(/^\./, "");
+    const details = {
+      url: `${c.secure ? "https" : "http"}://${host}${c.path || "/"}`,
+      name: c.name,
+      value: c.value || "",
+      path: c.path || "/",
+      secure: !!c.secure,
+      httpOnly: !!c.httpOnly,
+    };
+    if (!c.hostOnly) details.domain = c.domain;
+    if (typeof c.expirationDate === "number") details.expirationDate = c.expirationDate;
+    if (c.sameSite) details.sameSite = c.sameSite;
+    try {
+      await ses.cookies.set(details);
+      loaded.add(cookieKey(c));
+    } catch {
+      // skip cookies Chromium rejects
+    }
+  }
+  return (await ses.cookies.get({})).length;
+}
+
+// saveCookieJar merges the session cookies into the jar and replaces the file atomically.
+// Expired cookies and loaded cookies the server has since deleted are dropped; cookies
+// other processes added meanwhile are kept.
+async function saveCookieJar(ses, jarPath, loaded) {
+  const current = await ses.cookies.get({});
+  const live = new Set(current.map(cookieKey));
+  await withCookieJarLock(jarPath, async () => {
+    const nowSeconds = Date.now() / 1000;
+    const merged = new Map();
+    for (const c of readCookieJar(jarPath)) {
+      if (c && c.name && !(loaded.has(cookieKey(c)) && !live.has(cookieKey(c)))) merged.set(cookieKey(c), c);
+    }
+    for (const c of current) merged.set(cookieKey(c), c);
+    const cookies = [...merged.values()].filter((c) => !cookieExpired(c, nowSeconds));
+    const tmpPath = `${jarPath}.${process.pid}.tmp`;
+    fs.writeFileSync(tmpPath, JSON.stringify(cookies), { mode: 0o600 });
+    fs.renameSync(tmpPath, jarPath);
+  });
+}
+
+// runRequest performs req, writing its messages through emit, and calls done(code) once
+// the terminal message is queued. ctl.abort is set once the request is in flight; a
+// request cancelled before then (ctl.cancelled) is never sent.
+async function runRequest(req, emit, done, ctl) {
+  // Methods are case-sensitive; send the one given verbatim, body included for any method.
+  const method = req.method || "GET";
   const url = req.url || "";
-  const headers = normalizeHeaders(req.headers || {});
+  const headers = withoutFramingHeaders(normalizeHeaders(req.headers || {}));
   const bodyB64 = req.body_b64 || "";
   const proxyURL = (req.proxy_url || "").trim();
   const noProxy = (req.no_proxy || "").trim();
+  const proxyCreds = proxyURL ? proxyCredentials(req, proxyURL) : null;
+  const caCert = (req.ca_cert || "").trim();
 
   if (!url) throw new Error("missing url");
 
   const requestStartedAt = Date.now();
   let urlHost = "";
+  let urlHostname = "";
   try {
-    urlHost = new URL(url).host || "";
+    const parsedURL = new URL(url);
+    urlHost = parsedURL.host || "";
+    urlHostname = parsedURL.hostname || "";
   } catch {
     // Keep empty host if URL parsing fails.
   }
 
   await app.whenReady();
+  const ses = await sessionFor(proxyURL, noProxy, proxyCreds, caCert);
 
-  // Best-effort proxy handling. If this fails, we still attempt the request without proxy.
-  if (proxyURL) {
+  const cookieJar = (req.cookie_jar || "").trim();
+  const loadedCookies = new Set();
+  let cookiesStored = 0;
+  if (cookieJar) {
     try {
-      const rules = proxyRulesFromURL(proxyURL);
-      const bypass = proxyBypassFromNoProxy(noProxy);
-      if (rules) {
-        await session.defaultSession.setProxy({
-          proxyRules: rules,
-          proxyBypassRules: bypass || undefined,
-        });
-      }
-    } catch {
-      // ignore
-    }
-
-    // Handle proxy authentication via the session "login" event.
-    // Electron's net module does not support Proxy-Authorization as a request header;
-    // instead Chromium issues a 407 challenge and expects credentials via this callback.
-    const creds = proxyCredentials(proxyURL);
-    if (creds) {
-      session.defaultSession.on("login", (event, _webContents, _details, authInfo, callback) => {
-        if (authInfo.isProxy) {
-          event.preventDefault();
-          callback(creds.username, creds.password);
-        }
-      });
+      cookiesStored = await loadCookieJar(ses, cookieJar, loadedCookies);
+    } catch (err) {
+      process.stderr.write(`cookie jar ${cookieJar}: load failed: ${summarizeError(err)}\n`);
     }
   }
+  // Saving runs before the process may exit; a failure only costs the new cookies.
+  function persistCookies() {
+    if (!cookieJar) return Promise.resolve();
+    return saveCookieJar(ses, cookieJar, loadedCookies).catch((err) => {
+      process.stderr.write(`cookie jar ${cookieJar}: save failed: ${summarizeError(err)}\n`);
+    });
+  }
 
   let resolvedProxy = "UNKNOWN";
   try {
-    resolvedProxy = (await session.defaultSession.resolveProxy(url)) || "UNKNOWN";
+    resolvedProxy = (await ses.resolveProxy(url)) || "UNKNOWN";
   } catch {
     resolvedProxy = "UNRESOLVED";
   }
@@ -206,8 +505,16 @@ async function main() {
   let lastByteAt = 0;
   let bytesReceived = 0;
   let chunksEmitted = 0;
-  const maxAttemptsRaw = Number.parseInt(process.env.COPILOT_ELECTRON_MAX_ATTEMPTS || "2", 10);
-  const maxAttempts = Number.isFinite(maxAttemptsRaw) && maxAttemptsRaw > 0 ? maxAttemptsRaw : 2;
+  // The Go side validates and always forwards max_attempts; the env var is never read here.
+  const maxAttempts = Number.isInteger(req.max_attempts) && req.max_attempts > 0 ? req.max_attempts : 2;
+  const connectTimeoutMs = Number.isInteger(req.connect_timeout_ms) && req.connect_timeout_ms > 0 ? req.connect_timeout_ms : 0;
+  // idle_timeout_ms bounds the silence between response body bytes once headers arrived.
+  const idleTimeoutMs = Number.isInteger(req.idle_timeout_ms) && req.idle_timeout_ms > 0 ? req.idle_timeout_ms : 0;
+  let idleTimer = null;
+  function clearIdleTimer() {
+    if (idleTimer) clearTimeout(idleTimer);
+    idleTimer = null;
+  }
   let attempt = 0;
   function currentPhase() {
     if (!sawResponseHeaders) return "before_headers";
@@ -234,24 +541,75 @@ async function main() {
       node: process.versions.node || "",
     };
   }
-  function finishWithError(errLike) {
+  // phase overrides the telemetry phase for typed failures such as "body-idle"; certificate
+  // errors are reported as "tls" with the failing host.
+  function finishWithError(errLike, phase) {
     if (finished) return;
     finished = true;
-    const message = summarizeError(errLike);
-    queueWrite({ type: "error", message, ...telemetrySnapshot() }).finally(() => flushAndExit(1));
+    clearIdleTimer();
+    let message = summarizeError(errLike);
+    if (!phase && isCertificateError(errLike)) {
+      phase = "tls";
+      const verification = tlsFailures.get(urlHostname);
+      message += ` (certificate of ${urlHost} rejected${verification ? `: ${verification}` : ""}${caCert ? "" : "; set COPILOT_ELECTRON_CA_CERT to trust a private CA"})`;
+    }
+    const snapshot = telemetrySnapshot();
+    if (phase) snapshot.phase = phase;
+    emit({ type: "error", message, ...snapshot }).finally(() => persistCookies().finally(() => done(1)));
   }
   function finishSuccess() {
     if (finished) return;
     finished = true;
-    queueWrite({ type: "end" }).finally(() => flushAndExit(0));
+    clearIdleTimer();
+    emit({ type: "end" }).finally(() => persistCookies().finally(() => done(0)));
+  }
+  if (!poolMode) {
+    process.once("uncaughtException", (err) => finishWithError(err));
+    process.once("unhandledRejection", (err) => finishWithError(err));
   }
-  process.once("uncaughtException", (err) => finishWithError(err));
-  process.once("unhandledRejection", (err) => finishWithError(err));
+  let currentRequest = null;
+  ctl.abort = () => {
+    if (finished) return;
+    finished = true;
+    clearIdleTimer();
+    try {
+      if (currentRequest) currentRequest.abort();
+    } catch {
+      // ignore
+    }
+    done(0);
+  };
 
   function makeAttempt() {
     if (finished) return;
     attempt += 1;
-    const request = net.request({ method, url, session: session.defaultSession });
+    // Session cookies are only sent and stored when a cookie jar is configured.
+    const request = net.request({ method, url, session: ses, useSessionCookies: !!cookieJar });
+    currentRequest = request;
+    let attemptFailed = false;
+    function failAttempt(err, retryable) {
+      if (attemptFailed) return;
+      attemptFailed = true;
+      if (connectTimer) clearTimeout(connectTimer);
+      if (retryable && !sawResponseHeaders && attempt < maxAttempts) {
+        const backoffMs = Math.min(1000, 250 * attempt);
+        setTimeout(makeAttempt, backoffMs);
+        return;
+      }
+      finishWithError(err);
+    }
+    let connectTimer = null;
+    if (connectTimeoutMs > 0) {
+      connectTimer = setTimeout(() => {
+        if (finished || sawResponseHeaders) return;
+        try {
+          request.abort();
+        } catch {
+          // ignore
+        }
+        failAttempt(new Error(`connect timeout after ${connectTimeoutMs}ms`), true);
+      }, connectTimeoutMs);
+    }
     if (!Object.keys(headers).some((k) => k.toLowerCase() === "connection")) {
       request.setHeader("Connection", "keep-alive");
     }
@@ -266,26 +624,57 @@ async function main() {
       }
     }
 
+    // Proxy auth challenges reach the request's "login" event; answer them with the
+    // credentials sent separately from the proxy URL.
+    request.on("login", (authInfo, callback) => {
+      if (authInfo && authInfo.isProxy && proxyCreds) {
+        callback(proxyCreds.username, proxyCreds.password);
+        return;
+      }
+      callback();
+    });
+
     request.on("response", (response) => {
+      if (attemptFailed) return;
+      if (connectTimer) clearTimeout(connectTimer);
       sawResponseHeaders = true;
       responseHeadersAt = Date.now();
-      queueWrite({
+      emit({
         type: "meta",
         status: response.statusCode,
         statusText: response.statusMessage || "",
-        headers: normalizeHeaders(response.headers || {}),
+        headers: responseHeaders(response),
+        protocol: responseProtocol(response),
         attempt,
         maxAttempts,
         resolvedProxy,
         urlHost,
         tHeadersMs: responseHeadersAt - requestStartedAt,
+        cookiesStored,
         electron: process.versions.electron || "",
         chromium: process.versions.chrome || "",
         node: process.versions.node || "",
       }).catch((err) => finishWithError(`failed to write response meta: ${err}`));
 
+      // A response that stops sending body bytes is aborted and reported as "body-idle".
+      function armIdleTimer() {
+        if (!idleTimeoutMs || finished) return;
+        clearIdleTimer();
+        idleTimer = setTimeout(() => {
+          if (finished) return;
+          try {
+            request.abort();
+          } catch {
+            // ignore
+          }
+          finishWithError(`no response bytes for ${idleTimeoutMs}ms`, "body-idle");
+        }, idleTimeoutMs);
+      }
+      armIdleTimer();
+
       response.on("data", (chunk) => {
         if (finished) return;
+        armIdleTimer();
         const now = Date.now();
         if (!firstByteAt) {
           firstByteAt = now;
@@ -293,7 +682,7 @@ async function main() {
         lastByteAt = now;
         bytesReceived += Buffer.byteLength(chunk);
         chunksEmitted += 1;
-        queueWrite({ type: "chunk", b64: Buffer.from(chunk).toString("base64") }).catch((err) =>
+        emit({ type: "chunk", b64: Buffer.from(chunk).toString("base64") }).catch((err) =>
           finishWithError(`failed to write response chunk: ${err}`),
         );
       });
@@ -310,15 +699,7 @@ async function main() {
       });
     });
 
-    request.on("error", (err) => {
-      const retryable = !sawResponseHeaders && isRetryableElectronError(err) && attempt < maxAttempts;
-      if (retryable) {
-        const backoffMs = Math.min(1000, 250 * attempt);
-        setTimeout(makeAttempt, backoffMs);
-        return;
-      }
-      finishWithError(err);
-    });
+    request.on("error", (err) => failAttempt(err, isRetryableElectronError(err)));
 
     if (bodyB64) {
       request.write(Buffer.from(bodyB64, "base64"));
@@ -326,9 +707,77 @@ async function main() {
     request.end();
   }
 
+  if (ctl.cancelled) {
+    ctl.abort();
+    return;
+  }
   makeAttempt();
 }
 
+async function main() {
+  if (poolMode) {
+    servePool();
+    return;
+  }
+  const raw = await readAllStdin();
+  const req = JSON.parse(raw || "{}");
+  await runRequest(req, queueWrite, flushAndExit, {});
+}
+
+// servePool handles one request per stdin line until stdin closes.
+function servePool() {
+  const fatal = (err) => {
+    queueWrite({ type: "fatal", message: summarizeError(err) }).finally(() => flushAndExit(1));
+  };
+  process.on("uncaughtException", fatal);
+  process.on("unhandledRejection", fatal);
+
+  const active = new Map();
+  let stdinClosed = false;
+  const maybeExit = () => {
+    if (stdinClosed && active.size === 0) flushAndExit(0);
+  };
+  const input = readline.createInterface({ input: process.stdin, crlfDelay: Infinity });
+  input.on("line", (line) => {
+    if (!line.trim()) return;
+    let req;
+    try {
+      req = JSON.parse(line);
+    } catch (err) {
+      fatal(`invalid request line: ${summarizeError(err)}`);
+      return;
+    }
+    const id = String(req.id || "");
+    if (req.cancel) {
+      const ctl = active.get(id);
+      if (ctl) {
+        ctl.cancelled = true;
+        if (ctl.abort) ctl.abort();
+      }
+      return;
+    }
+    if (!id || active.has(id)) {
+      queueWrite({ id, type: "error", message: "missing or duplicate request id" });
+      return;
+    }
+    const ctl = {};
+    active.set(id, ctl);
+    const emit = (obj) => queueWrite({ ...obj, id });
+    const done = () => {
+      if (active.get(id) !== ctl) return;
+      active.delete(id);
+      maybeExit();
+    };
+    runRequest(req, emit, done, ctl).catch((err) => {
+      emit({ type: "error", message: summarizeError(err) }).finally(done);
+    });
+  });
+  input.on("close", () => {
+    stdinClosed = true;
+    maybeExit();
+  });
+}
+
 main()
   .catch((err) => {
     queueWrite({ type: "error", message: String(err && err.message ? err.message : err) }).finally(() => flushAndExit(1));
@@ -17,6 +17,7 @@ import (
 	copilotauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/copilot"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/timing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
@@ -107,7 +108,7 @@ func (e *CopilotExecutor) logOutboundProxyDecision(httpReq *http.Request, auth *
 	noProxyRaw := ""
 	noProxyList := []string(nil)
 	if proxyURL != "" {
-		noProxyRaw = noProxyEnvRaw()
+		noProxyRaw = resolveNoProxy(e.cfg)
 		noProxyList = parseNoProxyList(noProxyRaw)
 	}
 
@@ -176,75 +177,203 @@ func (e *CopilotExecutor) PrepareRequest(req *http.Request, auth *cliproxyauth.A
 	return nil
 }
 
-// copilotDoRequest performs the outbound HTTP request for Copilot, attempting
-// Electron/Chromium transport first (when configured) then falling back to Go's
-// net/http transport. This is the single code path used by HttpRequest, Execute,
-// and ExecuteStream to ensure consistent transport selection and proxy logging.
-func (e *CopilotExecutor) copilotDoRequest(ctx context.Context, auth *cliproxyauth.Auth, httpReq *http.Request) (*http.Response, error) {
-	// Parity default: attempt to use Electron/Chromium net stack first (if available),
-	// then fall back to Go's net/http transport.
-	if copilotPreferElectronTransport() {
-		var proxyURL string
-		proxySource := ""
-		if auth != nil {
-			proxyURL = strings.TrimSpace(auth.ProxyURL)
-			if proxyURL != "" {
-				proxySource = "auth"
-			}
+// electronProxy resolves the proxy for an Electron transport request the way
+// newProxyAwareHTTPClient does for the Go transport: the auth proxy first, then the global
+// proxy when enabled for "copilot". It returns an empty proxy URL when the request host is
+// in the no-proxy list, along with that list for the shim.
+func (e *CopilotExecutor) electronProxy(auth *cliproxyauth.Auth, httpReq *http.Request) (proxyURL, noProxy string) {
+	var cfg *config.Config
+	if e != nil {
+		cfg = e.cfg
+	}
+	proxySource := ""
+	if auth != nil {
+		proxyURL = strings.TrimSpace(auth.ProxyURL)
+		if proxyURL != "" {
+			proxySource = "auth"
 		}
-		if proxyURL == "" && e != nil && e.cfg != nil && e.cfg.SDKConfig.ProxyEnabledFor("copilot") {
-			proxyURL = strings.TrimSpace(e.cfg.ProxyURL)
-			if proxyURL != "" {
-				proxySource = "global"
-			}
-		} else if proxyURL == "" && e != nil && e.cfg != nil && strings.TrimSpace(e.cfg.ProxyURL) != "" && !e.cfg.SDKConfig.ProxyEnabledFor("copilot") {
+	}
+	if proxyURL == "" && cfg != nil && cfg.SDKConfig.ProxyEnabledFor("copilot") {
+		proxyURL = strings.TrimSpace(cfg.ProxyURL)
+		if proxyURL != "" {
+			proxySource = "global"
+		}
+	} else if proxyURL == "" && cfg != nil && strings.TrimSpace(cfg.ProxyURL) != "" && !cfg.SDKConfig.ProxyEnabledFor("copilot") {
+		logProxyOnce(
+			"proxy.disabled.copilot.electron",
+			"proxy: service=copilot disabled by OUTBOUND_PROXY_SERVICES (proxy configured but not enabled for service)",
+		)
+	}
+	if proxyURL == "" {
+		return "", ""
+	}
+
+	noProxy = resolveNoProxy(cfg)
+	if httpReq != nil && httpReq.URL != nil {
+		host := strings.TrimSpace(httpReq.URL.Hostname())
+		if shouldBypassProxy(host, parseNoProxyList(noProxy)) {
 			logProxyOnce(
-				"proxy.disabled.copilot.electron",
-				"proxy: service=copilot disabled by OUTBOUND_PROXY_SERVICES (proxy configured but not enabled for service)",
+				"proxy.bypass.copilot.electron."+strings.ToLower(host),
+				"proxy: service=copilot bypass host=%s reason=NO_PROXY transport=electron",
+				host,
 			)
+			return "", noProxy
 		}
+	}
+	logProxyOnce(
+		"proxy.enabled.copilot.electron."+maskProxyURL(proxyURL),
+		"proxy: service=copilot enabled proxy=%s source=%s no_proxy=%q transport=electron",
+		maskProxyURL(proxyURL),
+		proxySource,
+		noProxy,
+	)
+	return proxyURL, noProxy
+}
 
-		if proxyURL != "" {
-			noProxyRaw := noProxyEnvRaw()
-			noProxyList := parseNoProxyList(noProxyRaw)
-			if httpReq.URL != nil {
-				host := strings.TrimSpace(httpReq.URL.Hostname())
-				if shouldBypassProxy(host, noProxyList) {
-					logProxyOnce(
-						"proxy.bypass.copilot.electron."+strings.ToLower(host),
-						"proxy: service=copilot bypass host=%s reason=NO_PROXY transport=electron",
-						host,
-					)
-					proxyURL = ""
+// copilotDoRequest performs the outbound HTTP request for Copilot, walking the
+// COPILOT_TRANSPORT order (Electron/Chromium then Go's net/http by default). This is the
+// single code path used by HttpRequest, Execute, and ExecuteStream to ensure consistent
+// transport selection and proxy logging.
+//
+// The next transport is tried when an attempt fails before returning a response, or
+// returns a Cloudflare challenge. Electron errors surface before the response is
+// returned, so a fallback never replays a request whose response bytes were already
+// delivered; failures while reading the body belong to the caller.
+func (e *CopilotExecutor) copilotDoRequest(ctx context.Context, auth *cliproxyauth.Auth, httpReq *http.Request) (*http.Response, error) {
+	order := copilotTransportOrder()
+	if len(order) > 1 {
+		// An attempt consumes the body; keep it replayable for the next transport.
+		if err := bufferRequestBody(httpReq); err != nil {
+			return nil, fmt.Errorf("copilot executor: read request body: %w", err)
+		}
+	}
+	fellBack := false
+	var lastErr error
+	for i, transport := range order {
+		if i > 0 && httpReq.GetBody != nil {
+			body, errBody := httpReq.GetBody()
+			if errBody != nil {
+				return nil, fmt.Errorf("copilot executor: replay request body: %w", errBody)
+			}
+			httpReq.Body = body
+		}
+		last := i == len(order)-1
+		var resp *http.Response
+		var err error
+		if transport == copilotTransportElectron {
+			resp, err = e.copilotElectronAttempt(ctx, auth, httpReq)
+			if errors.Is(err, errCopilotElectronUnavailable) {
+				// Not an attempt: nothing was sent, so the next transport is no fallback.
+				if lastErr == nil {
+					lastErr = err
 				}
+				continue
 			}
-			if proxyURL != "" {
-				logProxyOnce(
-					"proxy.enabled.copilot.electron."+maskProxyURL(proxyURL),
-					"proxy: service=copilot enabled proxy=%s source=%s no_proxy=%q transport=electron",
-					maskProxyURL(proxyURL),
-					proxySource,
-					noProxyRaw,
-				)
+		} else {
+			resp, err = e.copilotGoAttempt(ctx, auth, httpReq)
+		}
+		if err == nil && copilotCaptivePortalCheckEnabled() {
+			if portal, title := detectCaptivePortal(resp); portal {
+				log.Warnf("copilot executor: %s transport got an HTML page (status %d), likely a captive portal", transport, resp.StatusCode)
+				_ = resp.Body.Close()
+				resp, err = nil, captivePortalError(transport, resp, title)
+			}
+		}
+		if !last && ctx.Err() == nil {
+			if err != nil {
+				log.Warnf("copilot executor: %s transport failed before any response bytes, trying %s transport: %v", transport, order[i+1], err)
+				lastErr, fellBack = err, true
+				continue
 			}
+			if isCloudflareChallenge(resp) {
+				log.Warnf("copilot executor: %s transport got a Cloudflare challenge (status %d), trying %s transport", transport, resp.StatusCode, order[i+1])
+				_ = resp.Body.Close()
+				lastErr, fellBack = fmt.Errorf("copilot executor: cloudflare challenge on %s transport (status %d)", transport, resp.StatusCode), true
+				continue
+			}
+		}
+		recordCopilotTransport(transport, fellBack, err)
+		if err != nil {
+			return nil, err
+		}
+		label := transport
+		if fellBack {
+			label += "-fallback"
 		}
+		resp.Header.Set(CopilotTransportHeader, label)
+		return resp, nil
+	}
+	return nil, lastErr
+}
 
-		// Per-request proxy log (no dedupe) for the actual electron attempt.
-		// If NO_PROXY caused a bypass above, proxyURL will be empty here.
-		e.logOutboundProxyDecision(httpReq, auth, "electron")
+// copilotElectronAttempt sends httpReq through the Electron shim.
+func (e *CopilotExecutor) copilotElectronAttempt(ctx context.Context, auth *cliproxyauth.Auth, httpReq *http.Request) (*http.Response, error) {
+	proxyURL, noProxy := e.electronProxy(auth, httpReq)
+	// Per-request proxy log (no dedupe) for the actual electron attempt.
+	// If NO_PROXY caused a bypass above, proxyURL will be empty here.
+	e.logOutboundProxyDecision(httpReq, auth, "electron")
 
-		if resp, err := httpResponseFromElectron(ctx, httpReq, proxyURL); err == nil {
-			return resp, nil
-		} else if err != nil && !errors.Is(err, errCopilotElectronUnavailable) {
-			log.Debugf("copilot executor: electron transport failed, falling back to go transport: %v", err)
-		}
+	authID := ""
+	if auth != nil {
+		authID = auth.ID
 	}
+	return timing.RoundTrip(httpReq, func(r *http.Request) (*http.Response, error) {
+		return httpResponseFromElectron(ctx, r, copilotElectronOptions{
+			ProxyURL:  proxyURL,
+			NoProxy:   noProxy,
+			PoolSize:  copilotElectronPoolSize(e.cfg),
+			ExtraArgs: copilotElectronExtraArgs(e.cfg),
+			AuthID:    authID,
+		})
+	})
+}
 
+// copilotGoAttempt sends httpReq through Go's net/http transport.
+func (e *CopilotExecutor) copilotGoAttempt(ctx context.Context, auth *cliproxyauth.Auth, httpReq *http.Request) (*http.Response, error) {
 	// Per-request proxy log (no dedupe) for Go net/http transport.
 	e.logOutboundProxyDecision(httpReq, auth, "go")
 
 	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0, "copilot")
-	return httpClient.Do(httpReq)
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	decodeCopilotContentEncoding(resp)
+	return resp, nil
+}
+
+// isCloudflareChallenge reports whether resp is a Cloudflare bot challenge rather than an
+// upstream answer: flagged by cf-mitigated, or an HTML 403/503 served by Cloudflare.
+func isCloudflareChallenge(resp *http.Response) bool {
+	if resp == nil {
+		return false
+	}
+	if strings.EqualFold(resp.Header.Get("Cf-Mitigated"), "challenge") {
+		return true
+	}
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	return strings.EqualFold(resp.Header.Get("Server"), "cloudflare") &&
+		strings.HasPrefix(strings.ToLower(resp.Header.Get("Content-Type")), "text/html")
+}
+
+// bufferRequestBody reads the request body into memory and sets GetBody so the body can
+// be sent again. Requests that already have GetBody are left alone.
+func bufferRequestBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		return nil
+	}
+	data, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return nil
 }
 
 // HttpRequest injects Copilot credentials into the request and executes it.
@@ -522,6 +651,7 @@ func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth,
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	body := sdktranslator.TranslateRequest(from, to, apiModel, bytes.Clone(req.Payload), false)
 	body = applyPayloadConfigWithRoot(e.cfg, apiModel, to.String(), "", body, nil, requestedModel)
+	body = applyPayloadMetadataMapping(e.cfg, apiModel, to.String(), "", body, req.Payload, requestedModel)
 	body = sanitizeCopilotPayload(body, apiModel)
 	body, _ = sjson.SetBytes(body, "stream", false)
 
@@ -599,6 +729,17 @@ func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth,
 	var param any
 	out := sdktranslator.TranslateNonStream(ctx, to, from, translatorModel, bytes.Clone(opts.OriginalRequest), body, data, &param)
 	resp = cliproxyexecutor.Response{Payload: []byte(out)}
+	if e.cfg != nil && e.cfg.CopilotTransportTelemetry {
+		if telemetry := copilotTransportTelemetry(httpResp.Header); telemetry != nil {
+			if withTelemetry, errSet := sjson.SetBytes(resp.Payload, "cliproxy_transport", telemetry); errSet == nil {
+				resp.Payload = withTelemetry
+			}
+			resp.Headers = copilotTransportTelemetryHeaders(httpResp.Header)
+		}
+	}
+	if resp.Headers == nil {
+		resp.Headers = copilotElectronDebugHeaders(httpResp.Header)
+	}
 	return resp, nil
 }
 
@@ -631,6 +772,7 @@ func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	body := sdktranslator.TranslateRequest(from, to, apiModel, bytes.Clone(req.Payload), true)
 	body = applyPayloadConfigWithRoot(e.cfg, apiModel, to.String(), "", body, nil, requestedModel)
+	body = applyPayloadMetadataMapping(e.cfg, apiModel, to.String(), "", body, req.Payload, requestedModel)
 	body = sanitizeCopilotPayload(body, apiModel)
 	body, _ = sjson.SetBytes(body, "stream", true)
 
@@ -1288,6 +1430,9 @@ func (e *CopilotExecutor) FetchModels(ctx context.Context, auth *cliproxyauth.Au
 			desc += " (Preview)"
 		}
 		modelInfo.Description = desc
+		if m.Billing != nil {
+			modelInfo.Billing = registry.NewCopilotBilling(m.Billing.Multiplier)
+		}
 		models = append(models, modelInfo)
 	}
 
@@ -4,19 +4,32 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
 	_ "embed"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/artifacts"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	internallogging "github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -26,44 +39,173 @@ var copilotElectronShimJS []byte
 var (
 	errCopilotElectronUnavailable = errors.New("copilot electron transport unavailable")
 
-	copilotShimOnce sync.Once
-	copilotShimPath string
-	copilotShimErr  error
+	// errCopilotElectronMetaTimeout reports a shim that sent no meta line within the meta timeout.
+	errCopilotElectronMetaTimeout = errors.New("electron transport: meta read timed out")
+
+	// errCopilotElectronIdleTimeout reports a response stream that went silent for the idle
+	// timeout, detected by the shim (phase body-idle) or by the Go side watchdog.
+	errCopilotElectronIdleTimeout = errors.New("electron transport: response stream idle timeout")
+
+	// errCopilotElectronBodyTooLarge reports a response body cut off at
+	// COPILOT_ELECTRON_MAX_BODY_BYTES.
+	errCopilotElectronBodyTooLarge = errors.New("electron transport: response body exceeds COPILOT_ELECTRON_MAX_BODY_BYTES")
+
+	// copilotShimMu guards copilotShim, the last verified state of the shim file.
+	copilotShimMu sync.Mutex
+	copilotShim   copilotShimState
+	// copilotShimNow is the clock used for shim verification; tests override it.
+	copilotShimNow = time.Now
+
+	// copilotElectronCommandContext builds the shim process; tests swap it for a fake runner.
+	copilotElectronCommandContext = exec.CommandContext
+
+	copilotElectronEnvWarned sync.Map
 )
 
+const (
+	// copilotElectronShimMaxAgeDefault bounds how long a shim file that still looks unchanged
+	// (same size and mtime) is trusted before its contents are hashed again.
+	copilotElectronShimMaxAgeDefault = time.Hour
+	copilotElectronShimMaxAgeLimitS  = 7 * 24 * 60 * 60
+
+	copilotElectronMaxAttemptsDefault    = 2
+	copilotElectronMaxAttemptsLimit      = 10
+	copilotElectronConnectTimeoutMinMs   = 100
+	copilotElectronConnectTimeoutLimitMs = 10 * 60 * 1000
+
+	// copilotElectronMetaTimeoutDefault bounds the wait for the shim's first (meta) message.
+	copilotElectronMetaTimeoutDefault = 30 * time.Second
+
+	// copilotElectronIdleTimeoutDefault bounds the silence between messages of a response stream.
+	copilotElectronIdleTimeoutDefault = 120 * time.Second
+	copilotElectronIdleTimeoutLimitMs = 60 * 60 * 1000
+	// copilotElectronIdleGraceMax caps the extra wait the Go side allows past the idle
+	// timeout, so the shim's own body-idle abort normally reports first.
+	copilotElectronIdleGraceMax = 2 * time.Second
+
+	// copilotElectronNetlogKeepDefault is how many netlogs are kept when
+	// COPILOT_ELECTRON_NETLOG_KEEP is unset.
+	copilotElectronNetlogKeepDefault = 5
+	copilotElectronNetlogKeepLimit   = 1000
+)
+
+// copilotElectronPhaseBodyIdle is the phase of the shim error sent when a response body
+// stalls for the idle timeout.
+const copilotElectronPhaseBodyIdle = "body-idle"
+
 type copilotElectronRequest struct {
+	// ID correlates requests and response messages on a pooled shim; one-shot requests omit it.
+	ID       string            `json:"id,omitempty"`
 	Method   string            `json:"method"`
 	URL      string            `json:"url"`
 	Headers  map[string]string `json:"headers,omitempty"`
 	BodyB64  string            `json:"body_b64,omitempty"`
 	ProxyURL string            `json:"proxy_url,omitempty"`
 	NoProxy  string            `json:"no_proxy,omitempty"`
+	// ProxyUsername and ProxyPassword answer the proxy's auth challenge through Chromium's
+	// login callback; ProxyURL itself never carries userinfo.
+	ProxyUsername string `json:"proxy_username,omitempty"`
+	ProxyPassword string `json:"proxy_password,omitempty"`
+	// MaxAttempts and ConnectTimeoutMs tune the shim's connect retry loop; zero keeps the shim defaults.
+	MaxAttempts      int `json:"max_attempts,omitempty"`
+	ConnectTimeoutMs int `json:"connect_timeout_ms,omitempty"`
+	// IdleTimeoutMs makes the shim abort a response whose body stops for this long and
+	// report it with phase "body-idle".
+	IdleTimeoutMs int `json:"idle_timeout_ms,omitempty"`
+	// CookieJar is the file the shim loads session cookies from and saves them back to,
+	// under a lock file; empty keeps each process's cookie store empty and unsaved.
+	CookieJar string `json:"cookie_jar,omitempty"`
+	// CACert holds PEM certificates the shim trusts in addition to Chromium's roots when
+	// verifying the upstream certificate (COPILOT_ELECTRON_CA_CERT).
+	CACert string `json:"ca_cert,omitempty"`
+	// Cancel asks a pooled shim to abort the in-flight request with ID.
+	Cancel bool `json:"cancel,omitempty"`
 }
 
 type copilotElectronResponseMeta struct {
-	Type                string            `json:"type"`
-	Status              int               `json:"status"`
-	StatusText          string            `json:"statusText"`
-	Headers             map[string]string `json:"headers"`
-	Message             string            `json:"message"`
-	Attempt             int               `json:"attempt"`
-	MaxAttempts         int               `json:"maxAttempts"`
-	ResolvedProxy       string            `json:"resolvedProxy"`
-	URLHost             string            `json:"urlHost"`
-	THeadersMs          int64             `json:"tHeadersMs"`
-	Phase               string            `json:"phase"`
-	BytesReceived       int64             `json:"bytesReceived"`
-	ChunksEmitted       int64             `json:"chunksEmitted"`
-	IdleMsSinceLastByte int64             `json:"idleMsSinceLastByte"`
-	ElapsedMs           int64             `json:"elapsedMs"`
-	Electron            string            `json:"electron"`
-	Chromium            string            `json:"chromium"`
-	Node                string            `json:"node"`
+	Type                string                  `json:"type"`
+	Status              int                     `json:"status"`
+	StatusText          string                  `json:"statusText"`
+	Headers             electronResponseHeaders `json:"headers"`
+	Message             string                  `json:"message"`
+	Attempt             int                     `json:"attempt"`
+	MaxAttempts         int                     `json:"maxAttempts"`
+	ResolvedProxy       string                  `json:"resolvedProxy"`
+	URLHost             string                  `json:"urlHost"`
+	THeadersMs          int64                   `json:"tHeadersMs"`
+	Phase               string                  `json:"phase"`
+	BytesReceived       int64                   `json:"bytesReceived"`
+	ChunksEmitted       int64                   `json:"chunksEmitted"`
+	IdleMsSinceLastByte int64                   `json:"idleMsSinceLastByte"`
+	ElapsedMs           int64                   `json:"elapsedMs"`
+	// CookiesStored counts the cookies in the shim session when the request was sent,
+	// after loading the cookie jar.
+	CookiesStored int    `json:"cookiesStored"`
+	Electron      string `json:"electron"`
+	Chromium      string `json:"chromium"`
+	Node          string `json:"node"`
+	// Protocol is the negotiated HTTP version, "h2" or "http/1.1"; empty from older shims.
+	Protocol string `json:"protocol"`
+}
+
+// electronResponseHeaders holds the response headers of a shim meta message. The shim sends
+// every value of a header as a list; older shims sent one joined string, which is read as a
+// single value. Decoding is lenient so one odd header cannot lose the response: numbers and
+// booleans are kept as text, nulls and nested objects are dropped, and folded values
+// (obs-fold line breaks) are unfolded to one line.
+type electronResponseHeaders map[string][]string
+
+func (h *electronResponseHeaders) UnmarshalJSON(data []byte) error {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	out := make(electronResponseHeaders, len(raw))
+	for k, v := range raw {
+		items, ok := v.([]any)
+		if !ok {
+			items = []any{v}
+		}
+		for _, item := range items {
+			if value, ok := electronHeaderValue(item); ok {
+				out[k] = append(out[k], value)
+			}
+		}
+	}
+	*h = out
+	return nil
+}
+
+// electronHeaderValue returns a decoded JSON header value as text.
+func electronHeaderValue(item any) (string, bool) {
+	switch v := item.(type) {
+	case string:
+		return unfoldHeaderValue(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+// unfoldHeaderValue replaces each line break of a folded header value, with the whitespace
+// around it, by a single space.
+func unfoldHeaderValue(value string) string {
+	if !strings.ContainsAny(value, "\r\n") {
+		return value
+	}
+	lines := strings.FieldsFunc(value, func(r rune) bool { return r == '\r' || r == '\n' })
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.Join(slices.DeleteFunc(lines, func(line string) bool { return line == "" }), " ")
 }
 
 type electronResponseBody struct {
 	rc  io.ReadCloser
-	cmd *exec.Cmd
+	src electronLineSource
 	mu  sync.Mutex
 }
 
@@ -73,31 +215,235 @@ func (b *electronResponseBody) Close() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	_ = b.rc.Close()
-	if b.cmd != nil && b.cmd.Process != nil {
-		_ = b.cmd.Process.Kill()
+	b.src.abort()
+	return nil
+}
+
+// electronLineSource yields the shim's line-delimited messages for one request, either
+// from a dedicated one-shot process or from a pooled process serving several requests.
+type electronLineSource interface {
+	// next returns the next message line; io.EOF means the stream ended without a terminal message.
+	next() ([]byte, error)
+	// finish releases the source after a terminal message or a read failure.
+	finish()
+	// abort stops the request early when the response body is closed.
+	abort()
+	// stalled abandons a request whose serving process stopped responding and reaps it.
+	stalled()
+	// stderr returns the trimmed diagnostic output of the serving process.
+	stderr() string
+}
+
+// electronProcessLines reads the single response of a one-shot shim process.
+type electronProcessLines struct {
+	cmd    *exec.Cmd
+	reader *bufio.Reader
+	errBuf *tailBuffer
+	// errLog copies stderr to COPILOT_ELECTRON_STDERR_LOG; nil when that is off.
+	errLog *electronStderrLog
+
+	// waitOnce reaps the process once; the body reader and Close may both finish it.
+	waitOnce sync.Once
+}
+
+func (p *electronProcessLines) next() ([]byte, error) { return p.reader.ReadBytes('\n') }
+
+func (p *electronProcessLines) finish() {
+	p.waitOnce.Do(func() {
+		_ = p.cmd.Wait()
+		p.errLog.flush()
+	})
+}
+
+func (p *electronProcessLines) abort() {
+	if p.cmd.Process != nil {
+		_ = p.cmd.Process.Kill()
 	}
 	// Wait to avoid zombies; if already exited this is cheap.
-	if b.cmd != nil {
-		_ = b.cmd.Wait()
+	p.finish()
+}
+
+func (p *electronProcessLines) stalled() { p.abort() }
+
+func (p *electronProcessLines) stderr() string { return p.errBuf.String() }
+
+// exitStatus reports how the process exited, e.g. "signal: killed", once finish reaped it.
+func (p *electronProcessLines) exitStatus() string {
+	if p.cmd.ProcessState == nil {
+		return ""
 	}
-	return nil
+	return p.cmd.ProcessState.String()
+}
+
+// copilotShimState records the shim file as last written or verified.
+type copilotShimState struct {
+	path       string
+	size       int64
+	modTime    time.Time
+	verifiedAt time.Time
+	// temp marks the per-process temp file used when the shim directory is not writable.
+	temp bool
+}
+
+// copilotElectronShimDigest is the hex SHA-256 of the embedded shim.
+var copilotElectronShimDigest = func() string {
+	sum := sha256.Sum256(copilotElectronShimJS)
+	return hex.EncodeToString(sum[:])
+}()
+
+// copilotElectronShimDir returns the per-user directory the shim is written to:
+// $WRITABLE_PATH/electron-shim when WRITABLE_PATH is set, otherwise
+// cli-proxy-api/electron-shim under the user cache directory ($XDG_CACHE_HOME or
+// ~/.cache on Linux).
+func copilotElectronShimDir() (string, error) {
+	if base := util.WritablePath(); base != "" {
+		return filepath.Join(base, "electron-shim"), nil
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "cli-proxy-api", "electron-shim"), nil
 }
 
+// copilotElectronShimFile returns the path of the shim script, writing it when missing.
+// The shim lives in the per-user copilotElectronShimDir under a name carrying its content
+// hash, so processes running different versions do not share a file. When that directory
+// cannot be written, a temp file private to this process is used instead.
+//
+// The file is checked on every use so cleanup jobs cannot pull it out from under a
+// long-running process: a cheap stat catches deletion and size/mtime changes, and the
+// contents are re-hashed once the last verification is older than
+// COPILOT_ELECTRON_SHIM_MAX_AGE_SECONDS (default one hour). Missing, modified or
+// non-regular files (such as symlinks) are rewritten.
 func copilotElectronShimFile() (string, error) {
-	copilotShimOnce.Do(func() {
-		dir := os.TempDir()
-		if strings.TrimSpace(dir) == "" {
-			dir = "/tmp"
+	copilotShimMu.Lock()
+	defer copilotShimMu.Unlock()
+	now := copilotShimNow()
+
+	dir, errWrite := copilotElectronShimDir()
+	if errWrite == nil {
+		path := filepath.Join(dir, "cliproxy_copilot_electron_shim_"+copilotElectronShimDigest[:12]+".js")
+		if copilotShimReusable(path, now) {
+			return path, nil
 		}
-		path := filepath.Join(dir, "cliproxy_copilot_electron_shim.js")
-		// Always write (tiny file) to avoid drift issues across upgrades.
-		if err := os.WriteFile(path, copilotElectronShimJS, 0o644); err != nil {
-			copilotShimErr = fmt.Errorf("write electron shim: %w", err)
-			return
+		if errWrite = writeCopilotElectronShim(dir, path); errWrite == nil {
+			if errWrite = copilotShimRecord(path, false, now); errWrite == nil {
+				return path, nil
+			}
 		}
-		copilotShimPath = path
-	})
-	return copilotShimPath, copilotShimErr
+	}
+
+	if copilotShim.temp && copilotShimReusable(copilotShim.path, now) {
+		return copilotShim.path, nil
+	}
+	path, err := writeCopilotElectronShimTemp()
+	if err == nil {
+		err = copilotShimRecord(path, true, now)
+	}
+	if err != nil {
+		copilotShim = copilotShimState{}
+		return "", fmt.Errorf("write electron shim: %w (shim dir: %v)", err, errWrite)
+	}
+	log.Warnf("copilot electron transport: cannot write shim to %s (%v), using %s", dir, errWrite, path)
+	return path, nil
+}
+
+// copilotShimReusable reports whether path holds the embedded shim, trusting the last
+// verification while the file's size and mtime are unchanged and the max age has not
+// passed. The caller holds copilotShimMu.
+func copilotShimReusable(path string, now time.Time) bool {
+	info, errStat := os.Lstat(path)
+	if errStat == nil && !info.Mode().IsRegular() {
+		log.Warnf("copilot electron transport: shim %s is not a regular file, rewriting it", path)
+		return false
+	}
+	if errStat == nil && copilotShim.path == path && info.Size() == copilotShim.size && info.ModTime().Equal(copilotShim.modTime) &&
+		now.Sub(copilotShim.verifiedAt) < copilotElectronShimMaxAge() {
+		return true
+	}
+	if errStat == nil && copilotShimFileMatches(path) {
+		copilotShim = copilotShimState{path: path, size: info.Size(), modTime: info.ModTime(), verifiedAt: now, temp: copilotShim.temp && copilotShim.path == path}
+		return true
+	}
+	if copilotShim.path == path {
+		if errStat != nil {
+			log.Warnf("copilot electron transport: shim %s disappeared, rewriting it", path)
+		} else {
+			log.Warnf("copilot electron transport: shim %s was modified, rewriting it", path)
+		}
+	}
+	return false
+}
+
+// copilotShimRecord stores the verified state of a freshly written shim.
+func copilotShimRecord(path string, temp bool, now time.Time) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+	copilotShim = copilotShimState{path: path, size: info.Size(), modTime: info.ModTime(), verifiedAt: now, temp: temp}
+	return nil
+}
+
+// writeCopilotElectronShim writes the shim through a temporary file and a rename, so a
+// concurrently spawned Electron never reads a partially written script. The rename also
+// replaces a symlink planted at path rather than writing through it.
+func writeCopilotElectronShim(dir, path string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "cliproxy_copilot_electron_shim_*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer func() { _ = os.Remove(tmpName) }()
+	if _, err = tmp.Write(copilotElectronShimJS); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpName, path)
+}
+
+// writeCopilotElectronShimTemp writes the shim to a new temp file readable only by the
+// current user.
+func writeCopilotElectronShimTemp() (string, error) {
+	tmp, err := os.CreateTemp("", "cliproxy_copilot_electron_shim_*.js")
+	if err != nil {
+		return "", err
+	}
+	if _, err = tmp.Write(copilotElectronShimJS); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func copilotShimFileMatches(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) == copilotElectronShimDigest
+}
+
+// copilotElectronShimMaxAge returns COPILOT_ELECTRON_SHIM_MAX_AGE_SECONDS (1s-7d), or the
+// one hour default when unset or invalid.
+func copilotElectronShimMaxAge() time.Duration {
+	if v := copilotElectronEnvInt("COPILOT_ELECTRON_SHIM_MAX_AGE_SECONDS", 1, copilotElectronShimMaxAgeLimitS); v > 0 {
+		return time.Duration(v) * time.Second
+	}
+	return copilotElectronShimMaxAgeDefault
 }
 
 func findElectronBinary() (string, error) {
@@ -110,19 +456,54 @@ func findElectronBinary() (string, error) {
 	return exec.LookPath("electron")
 }
 
-func copilotPreferElectronTransport() bool {
-	// Default: try Electron first for parity, fall back to Go transport when unavailable.
-	raw := strings.TrimSpace(os.Getenv("COPILOT_TRANSPORT"))
-	if raw == "" {
-		return true
+// copilotTransportAliases maps the COPILOT_TRANSPORT entries to transports.
+var copilotTransportAliases = map[string]string{
+	"electron": copilotTransportElectron,
+	"chromium": copilotTransportElectron,
+	"go":       copilotTransportGo,
+	"nethttp":  copilotTransportGo,
+	"http":     copilotTransportGo,
+}
+
+var copilotTransportOrderWarned sync.Map
+
+// copilotTransportOrder returns the transports to try for Copilot requests, in order.
+// COPILOT_TRANSPORT is either a shortcut ("auto" or "electron": Electron then Go, the
+// default; "go": Go only) or a comma separated order such as "go,electron". Unknown
+// entries are ignored with a warning, and a value with no valid entry means auto.
+func copilotTransportOrder() []string {
+	raw := strings.ToLower(strings.TrimSpace(os.Getenv("COPILOT_TRANSPORT")))
+	auto := []string{copilotTransportElectron, copilotTransportGo}
+	if !strings.Contains(raw, ",") {
+		if copilotTransportAliases[raw] == copilotTransportGo {
+			return []string{copilotTransportGo}
+		}
+		if raw != "" && raw != "auto" && copilotTransportAliases[raw] == "" {
+			warnCopilotTransportOrder(raw, raw)
+		}
+		return auto
 	}
-	switch strings.ToLower(raw) {
-	case "electron", "auto", "chromium":
-		return true
-	case "go", "nethttp", "http":
-		return false
-	default:
-		return true
+	var order []string
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		transport, ok := copilotTransportAliases[entry]
+		if !ok {
+			warnCopilotTransportOrder(raw, entry)
+			continue
+		}
+		if !slices.Contains(order, transport) {
+			order = append(order, transport)
+		}
+	}
+	if len(order) == 0 {
+		return auto
+	}
+	return order
+}
+
+func warnCopilotTransportOrder(raw, entry string) {
+	if _, seen := copilotTransportOrderWarned.LoadOrStore(raw+"\x00"+entry, struct{}{}); !seen {
+		log.Warnf("copilot transport: ignoring unknown COPILOT_TRANSPORT entry %q (expected electron or go)", entry)
 	}
 }
 
@@ -141,23 +522,216 @@ func envTruthy(key string, defaultValue bool) bool {
 	}
 }
 
-func copilotElectronCommandArgs(shimPath string) []string {
-	args := []string{
-		"--no-sandbox",
-		"--disable-gpu",
-		"--headless=new",
-		"--disable-software-rasterizer",
-		"--disable-dev-shm-usage",
+// copilotElectronNetlogEnabled reports whether Chromium netlogs should be captured.
+// COPILOT_ELECTRON_NETLOG_PATH is still honored as an enable switch, but the file is
+// always placed in the managed artifact directory so it is swept like any other artifact.
+func copilotElectronNetlogEnabled() bool {
+	if envTruthy("COPILOT_ELECTRON_NETLOG", false) {
+		return true
+	}
+	return strings.TrimSpace(os.Getenv("COPILOT_ELECTRON_NETLOG_PATH")) != ""
+}
+
+// pruneCopilotElectronNetlogs removes the oldest netlog artifacts beyond
+// COPILOT_ELECTRON_NETLOG_KEEP (default 5, counting the one about to be written) and
+// COPILOT_ELECTRON_NETLOG_MAX_BYTES (unset: only the artifact-wide limits apply). Only
+// netlog artifacts are touched.
+func pruneCopilotElectronNetlogs() {
+	keep := copilotElectronEnvInt("COPILOT_ELECTRON_NETLOG_KEEP", 1, copilotElectronNetlogKeepLimit)
+	if keep == 0 {
+		keep = copilotElectronNetlogKeepDefault
+	}
+	maxBytes := copilotElectronEnvInt("COPILOT_ELECTRON_NETLOG_MAX_BYTES", 1, math.MaxInt)
+	if removed := artifacts.Default().Prune("netlog", keep, int64(maxBytes)); removed > 0 {
+		log.Debugf("copilot electron transport: pruned %d old netlog(s)", removed)
+	}
+}
+
+// copilotElectronCaptureEnabled reports whether the decoded upstream response body
+// should be teed into a per-request capture artifact.
+func copilotElectronCaptureEnabled() bool {
+	return envTruthy("COPILOT_ELECTRON_CAPTURE", false)
+}
+
+// copilotElectronCookieJar returns the absolute cookie jar path for authID, or "" when
+// the cookie jar is disabled. Each auth gets its own file next to COPILOT_ELECTRON_COOKIE_JAR,
+// named after a hash of its ID (cookies.json becomes cookies-<hash>.json), so accounts never
+// share session cookies. Requests without an auth use the configured path as is.
+func copilotElectronCookieJar(authID string) string {
+	raw := strings.TrimSpace(os.Getenv("COPILOT_ELECTRON_COOKIE_JAR"))
+	if raw == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(raw); err == nil {
+		raw = abs
+	}
+	if authID == "" {
+		return raw
+	}
+	sum := sha256.Sum256([]byte(authID))
+	ext := filepath.Ext(raw)
+	return strings.TrimSuffix(raw, ext) + "-" + hex.EncodeToString(sum[:8]) + ext
+}
+
+// copilotElectronExtraArgs returns the extra Chromium switches for the shim process:
+// COPILOT_ELECTRON_EXTRA_ARGS (comma or space separated) when set, otherwise
+// copilot-electron-extra-args.
+func copilotElectronExtraArgs(cfg *config.Config) []string {
+	if raw := strings.TrimSpace(os.Getenv("COPILOT_ELECTRON_EXTRA_ARGS")); raw != "" {
+		return strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	}
+	if cfg == nil {
+		return nil
 	}
+	var args []string
+	for _, arg := range cfg.CopilotElectronExtraArgs {
+		if arg = strings.TrimSpace(arg); arg != "" {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// validCopilotElectronExtraArgs drops the extra or base args that could change what
+// Electron runs or where it reads from: anything that is not a "--" switch (Electron would
+// take it as the app path), args naming the shim script, and stdin redirections.
+func validCopilotElectronExtraArgs(args []string, shimPath string) []string {
+	valid := make([]string, 0, len(args))
+	for _, arg := range args {
+		if reason := copilotElectronExtraArgProblem(arg, shimPath); reason != "" {
+			log.Warnf("copilot electron transport: ignoring Electron arg %q: %s", arg, reason)
+			continue
+		}
+		valid = append(valid, arg)
+	}
+	return valid
+}
+
+func copilotElectronExtraArgProblem(arg, shimPath string) string {
+	switch {
+	case !strings.HasPrefix(arg, "--") || len(arg) == len("--"):
+		return "not a --switch"
+	case shimPath != "" && strings.Contains(arg, shimPath):
+		return "references the shim script"
+	case strings.ContainsAny(arg, "<|"),
+		strings.Contains(arg, "/dev/stdin"),
+		strings.Contains(arg, "/dev/fd/0"),
+		strings.Contains(arg, "/proc/self/fd/0"):
+		return "redirects stdin"
+	}
+	return ""
+}
+
+// copilotElectronMaxAttempts returns COPILOT_ELECTRON_MAX_ATTEMPTS (1-10), or the default of 2
+// when unset or invalid. The value is always sent to the shim, which never reads the env var.
+func copilotElectronMaxAttempts() int {
+	if v := copilotElectronEnvInt("COPILOT_ELECTRON_MAX_ATTEMPTS", 1, copilotElectronMaxAttemptsLimit); v > 0 {
+		return v
+	}
+	return copilotElectronMaxAttemptsDefault
+}
+
+// copilotElectronConnectTimeoutMs returns COPILOT_ELECTRON_HEADERS_TIMEOUT_MS, or its older
+// name COPILOT_ELECTRON_CONNECT_TIMEOUT_MS (100ms-10m), or 0 when neither is set and valid.
+// The timeout bounds each attempt until response headers arrive.
+func copilotElectronConnectTimeoutMs() int {
+	if ms := copilotElectronEnvInt("COPILOT_ELECTRON_HEADERS_TIMEOUT_MS", copilotElectronConnectTimeoutMinMs, copilotElectronConnectTimeoutLimitMs); ms > 0 {
+		return ms
+	}
+	return copilotElectronEnvInt("COPILOT_ELECTRON_CONNECT_TIMEOUT_MS", copilotElectronConnectTimeoutMinMs, copilotElectronConnectTimeoutLimitMs)
+}
+
+// copilotElectronMetaTimeout returns the startup timeout, which bounds the wait from spawning
+// the shim to its meta line; the body stream is not covered. It reads
+// COPILOT_ELECTRON_STARTUP_TIMEOUT, a duration such as "45s" or plain milliseconds, then the
+// older COPILOT_ELECTRON_META_TIMEOUT_MS, both limited to 100ms-10m, and defaults to 30s.
+func copilotElectronMetaTimeout() time.Duration {
+	if raw := strings.TrimSpace(os.Getenv("COPILOT_ELECTRON_STARTUP_TIMEOUT")); raw != "" {
+		timeout, err := time.ParseDuration(raw)
+		if err != nil {
+			if ms, errInt := strconv.Atoi(raw); errInt == nil {
+				timeout, err = time.Duration(ms)*time.Millisecond, nil
+			}
+		}
+		if err == nil && timeout >= copilotElectronConnectTimeoutMinMs*time.Millisecond && timeout <= copilotElectronConnectTimeoutLimitMs*time.Millisecond {
+			return timeout
+		}
+	}
+	if ms := copilotElectronEnvInt("COPILOT_ELECTRON_META_TIMEOUT_MS", copilotElectronConnectTimeoutMinMs, copilotElectronConnectTimeoutLimitMs); ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return copilotElectronMetaTimeoutDefault
+}
+
+// copilotElectronIdleTimeout returns COPILOT_ELECTRON_IDLE_TIMEOUT_MS (100ms-1h), or the
+// 120s default when unset or invalid.
+func copilotElectronIdleTimeout() time.Duration {
+	if ms := copilotElectronEnvInt("COPILOT_ELECTRON_IDLE_TIMEOUT_MS", copilotElectronConnectTimeoutMinMs, copilotElectronIdleTimeoutLimitMs); ms > 0 {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return copilotElectronIdleTimeoutDefault
+}
+
+// copilotElectronMaxBodyBytes returns COPILOT_ELECTRON_MAX_BODY_BYTES, the most response
+// body bytes passed on per request; 0 means unlimited.
+func copilotElectronMaxBodyBytes() int64 {
+	return int64(copilotElectronEnvInt("COPILOT_ELECTRON_MAX_BODY_BYTES", 0, math.MaxInt))
+}
+
+func copilotElectronEnvInt(key string, minValue, maxValue int) int {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return 0
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < minValue || v > maxValue {
+		if _, warned := copilotElectronEnvWarned.LoadOrStore(key+"="+raw, struct{}{}); !warned {
+			log.Warnf("copilot electron transport: ignoring %s=%q (expected an integer between %d and %d)", key, raw, minValue, maxValue)
+		}
+		return 0
+	}
+	return v
+}
+
+// copilotElectronDefaultBaseArgs are the Chromium switches every shim process starts with
+// unless COPILOT_ELECTRON_BASE_ARGS replaces them.
+var copilotElectronDefaultBaseArgs = []string{
+	"--no-sandbox",
+	"--disable-gpu",
+	"--headless=new",
+	"--disable-software-rasterizer",
+	"--disable-dev-shm-usage",
+}
+
+// copilotElectronBaseArgs returns COPILOT_ELECTRON_BASE_ARGS, comma or space separated and
+// validated like the extra args, or the defaults when it is unset. "none" starts Electron
+// without any base switch.
+func copilotElectronBaseArgs(shimPath string) []string {
+	raw := strings.TrimSpace(os.Getenv("COPILOT_ELECTRON_BASE_ARGS"))
+	switch {
+	case raw == "":
+		return slices.Clone(copilotElectronDefaultBaseArgs)
+	case strings.EqualFold(raw, "none"):
+		return nil
+	}
+	return validCopilotElectronExtraArgs(strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }), shimPath)
+}
+
+// copilotElectronCommandArgs builds the Electron command line: the base switches, the
+// switches from the HTTP/2, direct-egress and netlog settings, the extra args, and the shim
+// path, which is always last.
+func copilotElectronCommandArgs(shimPath, netlogPath string, extraArgs []string) []string {
+	args := copilotElectronBaseArgs(shimPath)
 	if envTruthy("COPILOT_ELECTRON_DISABLE_HTTP2", true) {
 		args = append(args, "--disable-http2")
 	}
 	if envTruthy("COPILOT_ELECTRON_FORCE_DIRECT", false) {
 		args = append(args, "--no-proxy-server")
 	}
-	if netlogPath := strings.TrimSpace(os.Getenv("COPILOT_ELECTRON_NETLOG_PATH")); netlogPath != "" {
+	if netlogPath = strings.TrimSpace(netlogPath); netlogPath != "" {
 		args = append(args, "--log-net-log="+netlogPath)
 	}
+	args = append(args, validCopilotElectronExtraArgs(extraArgs, shimPath)...)
 	args = append(args, shimPath)
 	return args
 }
@@ -198,11 +772,48 @@ func formatElectronTelemetry(meta copilotElectronResponseMeta) string {
 	return strings.Join(parts, " ")
 }
 
-func httpResponseFromElectron(ctx context.Context, req *http.Request, proxyURL string) (*http.Response, error) {
+// splitProxyCredentials removes the userinfo from proxyURL and returns it separately, so
+// the URL handed to Chromium (and recorded in netlogs) holds no credentials. Unparseable
+// values are returned trimmed with no credentials.
+func splitProxyCredentials(proxyURL string) (hostURL, username, password string) {
+	proxyURL = strings.TrimSpace(proxyURL)
+	if proxyURL == "" {
+		return "", "", ""
+	}
+	parsed, err := url.Parse(proxyURL)
+	if err != nil || parsed.User == nil {
+		return proxyURL, "", ""
+	}
+	username = parsed.User.Username()
+	password, _ = parsed.User.Password()
+	parsed.User = nil
+	return parsed.String(), username, password
+}
+
+// copilotElectronOptions carries the per-request settings the executor resolves for the
+// Electron transport.
+type copilotElectronOptions struct {
+	// ProxyURL and NoProxy are the proxy resolved for the request's auth and the no-proxy
+	// list passed to Chromium.
+	ProxyURL string
+	NoProxy  string
+	// PoolSize is the number of warm pooled processes; 0 spawns one process per request.
+	PoolSize int
+	// ExtraArgs are Chromium switches appended after the built-in ones.
+	ExtraArgs []string
+	// AuthID selects the auth's own cookie jar; empty uses the shared path.
+	AuthID string
+}
+
+// httpResponseFromElectron performs req through the Electron shim.
+func httpResponseFromElectron(ctx context.Context, req *http.Request, opts copilotElectronOptions) (*http.Response, error) {
 	electronPath, err := findElectronBinary()
 	if err != nil {
 		return nil, errCopilotElectronUnavailable
 	}
+	if !copilotElectronVersionAllowed(electronPath) {
+		return nil, errCopilotElectronUnavailable
+	}
 	shimPath, err := copilotElectronShimFile()
 	if err != nil {
 		return nil, errCopilotElectronUnavailable
@@ -221,30 +832,51 @@ func httpResponseFromElectron(ctx context.Context, req *http.Request, proxyURL s
 		// net/http expects callers not to reuse req after Do; safe to leave Body consumed.
 	}
 
-	hdrs := make(map[string]string, len(req.Header))
-	for k, vv := range req.Header {
-		if len(vv) == 0 {
-			continue
-		}
-		hdrs[k] = strings.Join(vv, ", ")
-	}
-
-	noProxy := strings.TrimSpace(os.Getenv("NO_PROXY"))
-	if noProxy == "" {
-		noProxy = strings.TrimSpace(os.Getenv("no_proxy"))
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
 	}
 
+	proxyURL, proxyUsername, proxyPassword := splitProxyCredentials(opts.ProxyURL)
 	payload := copilotElectronRequest{
-		Method:   req.Method,
-		URL:      req.URL.String(),
-		Headers:  hdrs,
-		BodyB64:  base64.StdEncoding.EncodeToString(bodyBytes),
-		ProxyURL: strings.TrimSpace(proxyURL),
-		NoProxy:  noProxy,
+		Method:        method,
+		URL:           req.URL.String(),
+		Headers:       electronRequestHeaders(req.Header),
+		BodyB64:       base64.StdEncoding.EncodeToString(bodyBytes),
+		ProxyURL:      proxyURL,
+		NoProxy:       strings.TrimSpace(opts.NoProxy),
+		ProxyUsername: proxyUsername,
+		ProxyPassword: proxyPassword,
+
+		MaxAttempts:      copilotElectronMaxAttempts(),
+		ConnectTimeoutMs: copilotElectronConnectTimeoutMs(),
+		IdleTimeoutMs:    int(copilotElectronIdleTimeout().Milliseconds()),
+		CookieJar:        copilotElectronCookieJar(opts.AuthID),
+		CACert:           copilotElectronCACert(),
+	}
+	if opts.PoolSize > 0 && !copilotElectronNetlogEnabled() {
+		// Netlogs are per process, so requests that capture one keep the one-shot path.
+		return copilotElectronPoolFor(electronPath, shimPath, opts.ExtraArgs, opts.PoolSize).roundTrip(ctx, req, payload)
 	}
 	raw, _ := json.Marshal(payload)
+	log.Debugf(
+		"copilot electron transport: spawning shim max_attempts=%d connect_timeout_ms=%d (0 = shim default)",
+		payload.MaxAttempts,
+		payload.ConnectTimeoutMs,
+	)
 
-	cmd := exec.CommandContext(ctx, electronPath, copilotElectronCommandArgs(shimPath)...)
+	requestID := internallogging.GetRequestID(ctx)
+	netlogPath := ""
+	if copilotElectronNetlogEnabled() {
+		if p, errAlloc := artifacts.Default().Allocate(requestID, "netlog", ".json"); errAlloc != nil {
+			log.WithError(errAlloc).Warn("copilot electron transport: netlog artifact unavailable")
+		} else {
+			netlogPath = p
+			pruneCopilotElectronNetlogs()
+		}
+	}
+
+	cmd := copilotElectronCommandContext(ctx, electronPath, copilotElectronCommandArgs(shimPath, netlogPath, opts.ExtraArgs)...)
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, fmt.Errorf("electron transport: stdin pipe: %w", err)
@@ -253,8 +885,9 @@ func httpResponseFromElectron(ctx context.Context, req *http.Request, proxyURL s
 	if err != nil {
 		return nil, fmt.Errorf("electron transport: stdout pipe: %w", err)
 	}
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
+	stderr := &tailBuffer{limit: copilotElectronStderrTail}
+	var stderrLog *electronStderrLog
+	cmd.Stderr, stderrLog = newElectronStderrWriter(stderr, requestID)
 
 	if err := cmd.Start(); err != nil {
 		return nil, errCopilotElectronUnavailable
@@ -263,27 +896,103 @@ func httpResponseFromElectron(ctx context.Context, req *http.Request, proxyURL s
 	if _, err := stdin.Write(append(raw, '\n')); err != nil {
 		_ = stdin.Close()
 		_ = cmd.Wait()
+		stderrLog.flush()
 		return nil, fmt.Errorf("electron transport: write stdin: %w", err)
 	}
 	_ = stdin.Close()
 
-	reader := bufio.NewReader(stdout)
-	metaLine, err := reader.ReadBytes('\n')
+	return electronResponseFromShim(ctx, req, requestID, &electronProcessLines{cmd: cmd, reader: bufio.NewReader(stdout), errBuf: stderr, errLog: stderrLog})
+}
+
+// readElectronMeta reads the first shim message, giving up after timeout. On expiry the
+// serving process is killed and reaped, and the timeout error, with the shim stderr, also
+// matches errCopilotElectronUnavailable so the next transport is tried.
+func readElectronMeta(src electronLineSource, timeout time.Duration) ([]byte, error) {
+	type result struct {
+		line []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		line, err := src.next()
+		done <- result{line, err}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.line, r.err
+	case <-timer.C:
+		src.stalled()
+		// The pending read returns once the process is gone; drain it so the source is idle.
+		<-done
+		return nil, fmt.Errorf("%w: %w after %s (%s)", errCopilotElectronUnavailable, errCopilotElectronMetaTimeout, timeout, electronStderrDetail(src.stderr(), ""))
+	}
+}
+
+type electronLine struct {
+	line []byte
+	err  error
+}
+
+// electronLineFeed reads src on a goroutine so the caller can wait for a message with a
+// timeout. Each call to request reads one line into the returned channel; nothing is read
+// unless requested, so a finished source is left alone. stop ends the goroutine.
+func electronLineFeed(src electronLineSource) (lines <-chan electronLine, request func(), stop func()) {
+	results := make(chan electronLine, 1)
+	want := make(chan struct{})
+	go func() {
+		for range want {
+			line, err := src.next()
+			results <- electronLine{line, err}
+		}
+	}()
+	var once sync.Once
+	return results, func() { want <- struct{}{} }, func() { once.Do(func() { close(want) }) }
+}
+
+// electronRequestHeaders flattens the request headers for the shim. Body framing headers
+// are left out: the whole body is sent in one piece and Chromium derives Content-Length
+// from it, for any method.
+func electronRequestHeaders(header http.Header) map[string]string {
+	hdrs := make(map[string]string, len(header))
+	for k, vv := range header {
+		if len(vv) == 0 {
+			continue
+		}
+		switch http.CanonicalHeaderKey(k) {
+		case "Content-Length", "Transfer-Encoding":
+			continue
+		}
+		hdrs[k] = strings.Join(vv, ", ")
+	}
+	return hdrs
+}
+
+// electronResponseFromShim turns the shim messages from src into an *http.Response whose
+// body streams the decoded chunks. A stream silent for the idle timeout is abandoned and its
+// process reaped; a cancelled ctx ends the body with the context error.
+func electronResponseFromShim(ctx context.Context, req *http.Request, requestID string, src electronLineSource) (*http.Response, error) {
+	metaLine, err := readElectronMeta(src, copilotElectronMetaTimeout())
+	if errors.Is(err, errCopilotElectronMetaTimeout) {
+		return nil, err
+	}
 	if err != nil {
-		_ = cmd.Wait()
+		src.finish()
 		if errors.Is(err, io.EOF) {
-			return nil, fmt.Errorf("electron transport: no response (stderr=%s)", strings.TrimSpace(stderr.String()))
+			return nil, fmt.Errorf("electron transport: no response (%s)", electronStderrDetail(src.stderr(), electronSourceExitStatus(src)))
 		}
-		return nil, fmt.Errorf("electron transport: read meta: %w (stderr=%s)", err, strings.TrimSpace(stderr.String()))
+		return nil, fmt.Errorf("electron transport: read meta: %w (%s)", err, electronStderrDetail(src.stderr(), electronSourceExitStatus(src)))
 	}
 
 	var meta copilotElectronResponseMeta
 	if err := json.Unmarshal(bytes.TrimSpace(metaLine), &meta); err != nil {
-		_ = cmd.Wait()
+		src.finish()
 		return nil, fmt.Errorf("electron transport: parse meta: %w (line=%s)", err, strings.TrimSpace(string(metaLine)))
 	}
 	if meta.Type == "error" {
-		_ = cmd.Wait()
+		src.finish()
+		observeCopilotElectron(ctx, CopilotElectronOutcomeUpstreamError, meta)
 		detail := strings.TrimSpace(formatElectronTelemetry(meta))
 		if detail == "" {
 			return nil, fmt.Errorf("electron transport: upstream error")
@@ -291,38 +1000,96 @@ func httpResponseFromElectron(ctx context.Context, req *http.Request, proxyURL s
 		return nil, fmt.Errorf("electron transport: upstream error: %s", detail)
 	}
 	if meta.Type != "meta" {
-		_ = cmd.Wait()
+		src.finish()
 		return nil, fmt.Errorf("electron transport: unexpected first message type %q", meta.Type)
 	}
 	log.Debugf(
-		"copilot electron transport: status=%d proxy=%q host=%q attempt=%d/%d t_headers_ms=%d versions={electron:%s chromium:%s node:%s}",
+		"copilot electron transport: status=%d proxy=%q host=%q attempt=%d/%d t_headers_ms=%d cookies_stored=%d versions={electron:%s chromium:%s node:%s}",
 		meta.Status,
 		meta.ResolvedProxy,
 		meta.URLHost,
 		meta.Attempt,
 		meta.MaxAttempts,
 		meta.THeadersMs,
+		meta.CookiesStored,
 		meta.Electron,
 		meta.Chromium,
 		meta.Node,
 	)
 
+	var capture *os.File
+	if copilotElectronCaptureEnabled() {
+		if f, errCreate := artifacts.Default().Create(requestID, "capture", ".log"); errCreate != nil {
+			log.WithError(errCreate).Warn("copilot electron transport: capture artifact unavailable")
+		} else {
+			capture = f
+			_, _ = fmt.Fprintf(capture, "%s %s\nstatus: %d\n\n", req.Method, req.URL.String(), meta.Status)
+		}
+	}
+
 	pr, pw := io.Pipe()
 	go func() {
 		defer func() { _ = pw.Close() }()
+		if capture != nil {
+			defer func() { _ = capture.Close() }()
+		}
+		lines, requestLine, stopLines := electronLineFeed(src)
+		defer stopLines()
+		// The shim aborts a stalled body itself; the watchdog waits a little longer and
+		// kills a shim that went silent altogether.
+		idleTimeout := copilotElectronIdleTimeout()
+		idleTimeout += min(copilotElectronIdleGraceMax, idleTimeout/2)
+		maxBody := copilotElectronMaxBodyBytes()
+		// written counts the body bytes passed on to the reader.
+		var written int64
+		idle := time.NewTimer(idleTimeout)
+		defer idle.Stop()
+		started, lastMessage := time.Now(), time.Now()
+		telemetry := meta
+		report := func(outcome string) {
+			telemetry.IdleMsSinceLastByte = time.Since(lastMessage).Milliseconds()
+			telemetry.ElapsedMs = time.Since(started).Milliseconds()
+			observeCopilotElectron(ctx, outcome, telemetry)
+		}
 		for {
-			line, err := reader.ReadBytes('\n')
+			var line []byte
+			var err error
+			requestLine()
+			select {
+			case r := <-lines:
+				line, err = r.line, r.err
+			case <-idle.C:
+				src.stalled()
+				report(CopilotElectronOutcomeIdleTimeout)
+				_ = pw.CloseWithError(fmt.Errorf("%w: no message for %s (%s %s)", errCopilotElectronIdleTimeout, idleTimeout, formatElectronTelemetry(telemetry), electronStderrDetail(src.stderr(), "")))
+				return
+			}
+			if !idle.Stop() {
+				select {
+				case <-idle.C:
+				default:
+				}
+			}
+			idle.Reset(idleTimeout)
+			lastMessage = time.Now()
 			if err != nil {
-				_ = cmd.Wait()
+				src.finish()
+				if ctx != nil && ctx.Err() != nil {
+					report(CopilotElectronOutcomeCanceled)
+					_ = pw.CloseWithError(fmt.Errorf("electron transport: request canceled: %w", ctx.Err()))
+					return
+				}
+				report(CopilotElectronOutcomeStreamError)
 				if errors.Is(err, io.EOF) {
-					_ = pw.CloseWithError(fmt.Errorf("electron transport: unexpected EOF before end marker (stderr=%s)", strings.TrimSpace(stderr.String())))
+					_ = pw.CloseWithError(fmt.Errorf("electron transport: unexpected EOF before end marker (%s)", electronStderrDetail(src.stderr(), electronSourceExitStatus(src))))
 					return
 				}
-				_ = pw.CloseWithError(fmt.Errorf("electron transport: read chunk: %w (stderr=%s)", err, strings.TrimSpace(stderr.String())))
+				_ = pw.CloseWithError(fmt.Errorf("electron transport: read chunk: %w (%s)", err, electronStderrDetail(src.stderr(), electronSourceExitStatus(src))))
 				return
 			}
 			var msg copilotElectronResponseMeta
 			if err := json.Unmarshal(bytes.TrimSpace(line), &msg); err != nil {
+				report(CopilotElectronOutcomeStreamError)
 				_ = pw.CloseWithError(fmt.Errorf("electron transport: parse chunk: %w", err))
 				return
 			}
@@ -334,6 +1101,7 @@ func httpResponseFromElectron(ctx context.Context, req *http.Request, proxyURL s
 					B64  string `json:"b64"`
 				}
 				if err := json.Unmarshal(bytes.TrimSpace(line), &chunk); err != nil {
+					report(CopilotElectronOutcomeStreamError)
 					_ = pw.CloseWithError(fmt.Errorf("electron transport: parse chunk: %w", err))
 					return
 				}
@@ -342,26 +1110,58 @@ func httpResponseFromElectron(ctx context.Context, req *http.Request, proxyURL s
 				}
 				b, err := base64.StdEncoding.DecodeString(chunk.B64)
 				if err != nil {
+					report(CopilotElectronOutcomeStreamError)
 					_ = pw.CloseWithError(fmt.Errorf("electron transport: decode chunk: %w", err))
 					return
 				}
-				if _, err := pw.Write(b); err != nil {
+				telemetry.BytesReceived += int64(len(b))
+				telemetry.ChunksEmitted++
+				overLimit := maxBody > 0 && written+int64(len(b)) > maxBody
+				if overLimit {
+					b = b[:maxBody-written]
+				}
+				if capture != nil {
+					_, _ = capture.Write(b)
+				}
+				if len(b) > 0 {
+					if _, err := pw.Write(b); err != nil {
+						report(CopilotElectronOutcomeClientClosed)
+						return
+					}
+					written += int64(len(b))
+				}
+				if overLimit {
+					// Stop the upstream transfer: a one-shot shim is killed, a pooled one
+					// cancels just this request.
+					src.abort()
+					report(CopilotElectronOutcomeBodyTooLarge)
+					_ = pw.CloseWithError(fmt.Errorf("%w: %d bytes written, limit %d (%s)", errCopilotElectronBodyTooLarge, written, maxBody, formatElectronTelemetry(telemetry)))
 					return
 				}
 			case "end":
-				_ = cmd.Wait()
+				src.finish()
+				report(CopilotElectronOutcomeOK)
 				return
 			case "error":
 				detail := strings.TrimSpace(formatElectronTelemetry(msg))
 				if detail == "" {
 					detail = "upstream error"
 				}
+				telemetry.Phase = msg.Phase
+				if msg.Phase == copilotElectronPhaseBodyIdle {
+					report(CopilotElectronOutcomeIdleTimeout)
+					_ = pw.CloseWithError(fmt.Errorf("%w: %s", errCopilotElectronIdleTimeout, detail))
+					src.finish()
+					return
+				}
+				report(CopilotElectronOutcomeUpstreamError)
 				_ = pw.CloseWithError(fmt.Errorf("electron transport: upstream error: %s", detail))
-				_ = cmd.Wait()
+				src.finish()
 				return
 			default:
+				report(CopilotElectronOutcomeStreamError)
 				_ = pw.CloseWithError(fmt.Errorf("electron transport: unexpected message type %q", msg.Type))
-				_ = cmd.Wait()
+				src.finish()
 				return
 			}
 		}
@@ -371,14 +1171,61 @@ func httpResponseFromElectron(ctx context.Context, req *http.Request, proxyURL s
 		StatusCode: meta.Status,
 		Status:     fmt.Sprintf("%d %s", meta.Status, strings.TrimSpace(meta.StatusText)),
 		Header:     make(http.Header),
-		Body:       &electronResponseBody{rc: pr, cmd: cmd},
+		Body:       &electronResponseBody{rc: pr, src: src},
 		Request:    req,
 	}
-	for k, v := range meta.Headers {
+	for k, values := range meta.Headers {
 		if strings.TrimSpace(k) == "" {
 			continue
 		}
-		resp.Header.Set(k, v)
+		for _, v := range values {
+			resp.Header.Add(k, v)
+		}
 	}
+	setElectronResponseProto(resp, meta.Protocol)
+	setElectronTelemetryHeaders(resp.Header, meta)
+	setElectronDebugHeaders(resp.Header, meta)
+	decodeCopilotContentEncoding(resp)
 	return resp, nil
 }
+
+// copilotElectronHTTP2Warned limits the HTTP/2 toggle warning to once per process.
+var copilotElectronHTTP2Warned atomic.Bool
+
+// setElectronResponseProto records the protocol negotiated by the shim on resp. A response
+// served over HTTP/2 while COPILOT_ELECTRON_DISABLE_HTTP2 is on means the switch did not take
+// effect, which is logged once.
+func setElectronResponseProto(resp *http.Response, protocol string) {
+	switch strings.ToLower(strings.TrimSpace(protocol)) {
+	case "h2":
+		resp.Proto, resp.ProtoMajor, resp.ProtoMinor = "HTTP/2.0", 2, 0
+		if envTruthy("COPILOT_ELECTRON_DISABLE_HTTP2", true) && copilotElectronHTTP2Warned.CompareAndSwap(false, true) {
+			log.Warn("electron transport: upstream negotiated HTTP/2 although COPILOT_ELECTRON_DISABLE_HTTP2 is set")
+		}
+	case "http/1.0":
+		resp.Proto, resp.ProtoMajor, resp.ProtoMinor = "HTTP/1.0", 1, 0
+	default:
+		resp.Proto, resp.ProtoMajor, resp.ProtoMinor = "HTTP/1.1", 1, 1
+	}
+}
+
+// CopilotElectronStatus reports whether Copilot requests will use the Electron transport,
+// with the binary path or the reason it is unavailable.
+func CopilotElectronStatus() (bool, string) {
+	if !slices.Contains(copilotTransportOrder(), copilotTransportElectron) {
+		return false, "disabled by COPILOT_TRANSPORT=" + strings.TrimSpace(os.Getenv("COPILOT_TRANSPORT"))
+	}
+	path, err := findElectronBinary()
+	if err != nil {
+		return false, "electron binary not found; set ELECTRON_PATH or install electron (falling back to the Go transport)"
+	}
+	if _, errStat := os.Stat(path); errStat != nil {
+		if _, errLook := exec.LookPath(path); errLook != nil {
+			return false, fmt.Sprintf("electron binary %s is not usable: %v", path, errStat)
+		}
+	}
+	if !copilotElectronVersionAllowed(path) {
+		return false, fmt.Sprintf("electron binary %s is older than COPILOT_ELECTRON_MIN_VERSION=%s (falling back to the Go transport)", path, strings.TrimSpace(os.Getenv("COPILOT_ELECTRON_MIN_VERSION")))
+	}
+	return true, path
+}
@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// copilotElectronPhaseTLS is the phase of the shim error sent when the upstream
+// certificate failed verification, with or without COPILOT_ELECTRON_CA_CERT.
+const copilotElectronPhaseTLS = "tls"
+
+// copilotElectronCA caches the COPILOT_ELECTRON_CA_CERT bundle by path, size and mtime so
+// the file is read and parsed again only after it changed.
+var copilotElectronCA struct {
+	sync.Mutex
+	path    string
+	size    int64
+	modTime time.Time
+	pem     string
+}
+
+// copilotElectronCACert returns the PEM certificates of COPILOT_ELECTRON_CA_CERT, which the
+// shim trusts as extra roots for upstream certificates, or "" when unset. A missing or
+// invalid file is reported once per change and leaves default verification in place.
+func copilotElectronCACert() string {
+	path := strings.TrimSpace(os.Getenv("COPILOT_ELECTRON_CA_CERT"))
+	if path == "" {
+		return ""
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		if _, warned := copilotElectronEnvWarned.LoadOrStore("COPILOT_ELECTRON_CA_CERT="+path, struct{}{}); !warned {
+			log.Warnf("copilot electron transport: ignoring COPILOT_ELECTRON_CA_CERT: %v", err)
+		}
+		return ""
+	}
+
+	copilotElectronCA.Lock()
+	defer copilotElectronCA.Unlock()
+	if copilotElectronCA.path == path && copilotElectronCA.size == info.Size() && copilotElectronCA.modTime.Equal(info.ModTime()) {
+		return copilotElectronCA.pem
+	}
+	bundle, err := loadCopilotElectronCACert(path)
+	if err != nil {
+		log.Warnf("copilot electron transport: ignoring COPILOT_ELECTRON_CA_CERT: %v", err)
+	}
+	copilotElectronCA.path, copilotElectronCA.size, copilotElectronCA.modTime, copilotElectronCA.pem = path, info.Size(), info.ModTime(), bundle
+	return bundle
+}
+
+// loadCopilotElectronCACert reads the CERTIFICATE blocks of the PEM file at path and
+// re-encodes them, dropping anything else such as private keys.
+func loadCopilotElectronCACert(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	for rest := raw; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, errParse := x509.ParseCertificate(block.Bytes); errParse != nil {
+			return "", fmt.Errorf("%s: parse certificate: %w", path, errParse)
+		}
+		_ = pem.Encode(&out, block)
+	}
+	if out.Len() == 0 {
+		return "", fmt.Errorf("%s: no PEM certificate found", path)
+	}
+	return out.String(), nil
+}
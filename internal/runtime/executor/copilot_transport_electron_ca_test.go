@@ -0,0 +1,84 @@
+package executor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHTTPResponseFromElectron_SendsCACert(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Corporate Root CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+
+	dir := t.TempDir()
+	bundlePath := filepath.Join(dir, "bundle.pem")
+	if err = os.WriteFile(bundlePath, []byte(keyPEM+certPEM), 0o600); err != nil {
+		t.Fatalf("write bundle: %v", err)
+	}
+	invalidPath := filepath.Join(dir, "invalid.pem")
+	if err = os.WriteFile(invalidPath, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("write invalid bundle: %v", err)
+	}
+
+	capturePath := fakeCopilotElectronRunner(t)
+	sentCACert := func() string {
+		req, _ := http.NewRequest(http.MethodGet, "https://api.githubcopilot.com/models", nil)
+		resp, errDo := httpResponseFromElectron(context.Background(), req, copilotElectronOptions{})
+		if errDo != nil {
+			t.Fatalf("httpResponseFromElectron: %v", errDo)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		raw, errRead := os.ReadFile(capturePath)
+		if errRead != nil {
+			t.Fatalf("read captured payload: %v", errRead)
+		}
+		var payload copilotElectronRequest
+		if errRead = json.Unmarshal(raw, &payload); errRead != nil {
+			t.Fatalf("decode payload %q: %v", raw, errRead)
+		}
+		return payload.CACert
+	}
+
+	if got := sentCACert(); got != "" {
+		t.Fatalf("ca_cert without COPILOT_ELECTRON_CA_CERT = %q, want none", got)
+	}
+	t.Setenv("COPILOT_ELECTRON_CA_CERT", bundlePath)
+	if got := sentCACert(); got != certPEM {
+		t.Fatalf("ca_cert = %q, want only the certificate %q", got, certPEM)
+	}
+	t.Setenv("COPILOT_ELECTRON_CA_CERT", invalidPath)
+	if got := sentCACert(); got != "" {
+		t.Fatalf("ca_cert for an invalid bundle = %q, want none so default verification applies", got)
+	}
+}
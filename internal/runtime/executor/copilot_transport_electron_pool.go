@@ -0,0 +1,518 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	internallogging "github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	copilotElectronPoolSizeLimit          = 16
+	copilotElectronPoolMaxRequestsDefault = 100
+	copilotElectronPoolMaxRequestsLimit   = 100000
+	// copilotElectronPoolStderrTail bounds the stderr kept per pooled process for diagnostics.
+	copilotElectronPoolStderrTail = 8 << 10
+)
+
+var (
+	copilotElectronPoolsMu sync.Mutex
+	copilotElectronPools   = make(map[string]*copilotElectronPool)
+	copilotElectronCallSeq atomic.Uint64
+
+	// copilotElectronPoolRespawnUptime is how long a process must have run before a crash
+	// is answered with an immediate replacement.
+	copilotElectronPoolRespawnUptime = 5 * time.Second
+)
+
+// copilotElectronPoolSize returns the pool size: COPILOT_ELECTRON_POOL_SIZE (0-16) when set
+// and valid, otherwise copilot-electron-pool-size clamped to 16. 0 disables the pool.
+func copilotElectronPoolSize(cfg *config.Config) int {
+	if strings.TrimSpace(os.Getenv("COPILOT_ELECTRON_POOL_SIZE")) == "0" {
+		return 0
+	}
+	if v := copilotElectronEnvInt("COPILOT_ELECTRON_POOL_SIZE", 1, copilotElectronPoolSizeLimit); v > 0 {
+		return v
+	}
+	if cfg == nil || cfg.CopilotElectronPoolSize <= 0 {
+		return 0
+	}
+	return min(cfg.CopilotElectronPoolSize, copilotElectronPoolSizeLimit)
+}
+
+// copilotElectronPoolMaxRequests returns COPILOT_ELECTRON_POOL_MAX_REQUESTS (1-100000), the
+// number of requests a pooled process serves before it is replaced; default 100.
+func copilotElectronPoolMaxRequests() int {
+	if v := copilotElectronEnvInt("COPILOT_ELECTRON_POOL_MAX_REQUESTS", 1, copilotElectronPoolMaxRequestsLimit); v > 0 {
+		return v
+	}
+	return copilotElectronPoolMaxRequestsDefault
+}
+
+// copilotElectronPool keeps warm shim processes that each serve many requests. Requests
+// are tagged with an id so the responses of concurrent calls on one process can be
+// demultiplexed. A process is retired after COPILOT_ELECTRON_POOL_MAX_REQUESTS requests or
+// on its first error, and a replacement is started so the pool stays warm.
+type copilotElectronPool struct {
+	electronPath string
+	shimPath     string
+	extraArgs    []string
+	size         int
+
+	mu      sync.Mutex
+	workers []*copilotElectronWorker
+}
+
+// copilotElectronPoolFor returns the pool for the binary, shim, extra args and size,
+// starting it on first use. A pool left behind by a configuration change is drained.
+func copilotElectronPoolFor(electronPath, shimPath string, extraArgs []string, size int) *copilotElectronPool {
+	key := electronPath + "\x00" + shimPath + "\x00" + strings.Join(extraArgs, "\x01") + "\x00" + strconv.Itoa(size)
+	copilotElectronPoolsMu.Lock()
+	defer copilotElectronPoolsMu.Unlock()
+	if pool, ok := copilotElectronPools[key]; ok {
+		return pool
+	}
+	for oldKey, old := range copilotElectronPools {
+		delete(copilotElectronPools, oldKey)
+		go old.drain()
+	}
+	pool := &copilotElectronPool{electronPath: electronPath, shimPath: shimPath, extraArgs: extraArgs, size: size}
+	pool.mu.Lock()
+	pool.fillLocked()
+	pool.mu.Unlock()
+	copilotElectronPools[key] = pool
+	return pool
+}
+
+// fillLocked starts processes until the pool is at size. Start failures are logged; the
+// next request tries again.
+func (p *copilotElectronPool) fillLocked() {
+	for len(p.workers) < p.size {
+		w, err := startCopilotElectronWorker(p)
+		if err != nil {
+			log.Debugf("copilot electron transport: start pooled shim: %v", err)
+			return
+		}
+		p.workers = append(p.workers, w)
+	}
+}
+
+// remove drops w from the pool. With replace set a replacement is started in the
+// background so the pool stays warm. Processes that crash soon after starting are only
+// replaced by the next request, so a shim that crashes on start cannot respawn in a loop.
+func (p *copilotElectronPool) remove(w *copilotElectronWorker, replace bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, candidate := range p.workers {
+		if candidate == w {
+			p.workers = append(p.workers[:i], p.workers[i+1:]...)
+			if replace {
+				go p.refill()
+			}
+			return
+		}
+	}
+}
+
+func (p *copilotElectronPool) refill() {
+	copilotElectronPoolsMu.Lock()
+	current := false
+	for _, pool := range copilotElectronPools {
+		current = current || pool == p
+	}
+	copilotElectronPoolsMu.Unlock()
+	if !current {
+		return
+	}
+	p.mu.Lock()
+	p.fillLocked()
+	p.mu.Unlock()
+}
+
+// drain retires every process; each exits once its in-flight requests finish.
+func (p *copilotElectronPool) drain() {
+	p.mu.Lock()
+	workers := p.workers
+	p.workers = nil
+	p.mu.Unlock()
+	for _, w := range workers {
+		w.retire("pool replaced")
+	}
+}
+
+// acquire picks the least busy process, starting processes when the pool is short.
+func (p *copilotElectronPool) acquire() (*copilotElectronWorker, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fillLocked()
+	var best *copilotElectronWorker
+	bestLoad := 0
+	for _, w := range p.workers {
+		if load, ok := w.load(); ok && (best == nil || load < bestLoad) {
+			best, bestLoad = w, load
+		}
+	}
+	if best == nil {
+		return nil, errCopilotElectronUnavailable
+	}
+	return best, nil
+}
+
+// roundTrip sends payload to a pooled process and returns the streamed response.
+func (p *copilotElectronPool) roundTrip(ctx context.Context, req *http.Request, payload copilotElectronRequest) (*http.Response, error) {
+	w, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	payload.ID = strconv.FormatUint(copilotElectronCallSeq.Add(1), 10)
+	call, err := w.begin(payload)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("copilot electron transport: pooled shim pid=%d id=%s max_attempts=%d connect_timeout_ms=%d (0 = shim default)",
+		w.pid(), payload.ID, payload.MaxAttempts, payload.ConnectTimeoutMs)
+	stop := context.AfterFunc(ctx, func() { call.cancel(ctx.Err()) })
+	call.stopWatch = stop
+	return electronResponseFromShim(ctx, req, internallogging.GetRequestID(ctx), call)
+}
+
+// copilotElectronWorker is one long-lived shim process started with COPILOT_ELECTRON_POOL=1.
+type copilotElectronWorker struct {
+	pool    *copilotElectronPool
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	errBuf  *tailBuffer
+	errLog  *electronStderrLog
+	started time.Time
+
+	writeMu sync.Mutex
+
+	mu       sync.Mutex
+	calls    map[string]*copilotElectronCall
+	served   int
+	retiring bool
+	exited   bool
+}
+
+func startCopilotElectronWorker(pool *copilotElectronPool) (*copilotElectronWorker, error) {
+	// The process outlives any single request, so it is not bound to a request context.
+	cmd := copilotElectronCommandContext(context.Background(), pool.electronPath, copilotElectronCommandArgs(pool.shimPath, "", pool.extraArgs)...)
+	cmd.Env = append(cmd.Environ(), "COPILOT_ELECTRON_POOL=1")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdin pipe: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdout pipe: %w", err)
+	}
+	errBuf := &tailBuffer{limit: copilotElectronPoolStderrTail}
+	var errLog *electronStderrLog
+	cmd.Stderr, errLog = newElectronStderrWriter(errBuf, "pool")
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	errLog.setPrefix(fmt.Sprintf("pool pid=%d", cmd.Process.Pid))
+	w := &copilotElectronWorker{pool: pool, cmd: cmd, stdin: stdin, errBuf: errBuf, errLog: errLog, started: time.Now(), calls: make(map[string]*copilotElectronCall)}
+	go w.readLoop(stdout)
+	return w, nil
+}
+
+func (w *copilotElectronWorker) pid() int {
+	if w.cmd.Process == nil {
+		return 0
+	}
+	return w.cmd.Process.Pid
+}
+
+// load reports the in-flight requests and whether w still accepts new ones.
+func (w *copilotElectronWorker) load() (int, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.calls), !w.retiring && !w.exited
+}
+
+// begin registers a call for payload.ID and writes the request line.
+func (w *copilotElectronWorker) begin(payload copilotElectronRequest) (*copilotElectronCall, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("electron transport: encode request: %w", err)
+	}
+	call := &copilotElectronCall{id: payload.ID, worker: w, signal: make(chan struct{}, 1)}
+	w.mu.Lock()
+	if w.retiring || w.exited {
+		w.mu.Unlock()
+		return nil, errCopilotElectronUnavailable
+	}
+	w.calls[call.id] = call
+	w.served++
+	exhausted := w.served >= copilotElectronPoolMaxRequests()
+	w.mu.Unlock()
+	if exhausted {
+		w.retire("request limit reached")
+	}
+	if errWrite := w.writeLine(raw); errWrite != nil {
+		w.release(call.id)
+		w.retire("stdin write failed")
+		return nil, fmt.Errorf("electron transport: write stdin: %w", errWrite)
+	}
+	return call, nil
+}
+
+func (w *copilotElectronWorker) writeLine(raw []byte) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	_, err := w.stdin.Write(append(raw, '\n'))
+	return err
+}
+
+// release forgets the call with id and closes stdin when a retiring process goes idle.
+func (w *copilotElectronWorker) release(id string) {
+	w.mu.Lock()
+	delete(w.calls, id)
+	idle := w.retiring && len(w.calls) == 0
+	w.mu.Unlock()
+	if idle {
+		w.closeStdin()
+	}
+}
+
+// retire stops new requests from reaching w. The shim exits after stdin is closed and
+// its in-flight requests finish.
+func (w *copilotElectronWorker) retire(reason string) {
+	w.mu.Lock()
+	if w.retiring || w.exited {
+		w.mu.Unlock()
+		return
+	}
+	w.retiring = true
+	idle := len(w.calls) == 0
+	w.mu.Unlock()
+	log.Debugf("copilot electron transport: retiring pooled shim pid=%d: %s", w.pid(), reason)
+	w.pool.remove(w, true)
+	if idle {
+		w.closeStdin()
+	}
+}
+
+func (w *copilotElectronWorker) closeStdin() {
+	w.writeMu.Lock()
+	_ = w.stdin.Close()
+	w.writeMu.Unlock()
+}
+
+// readLoop routes every message line to the call named by its id until the process exits.
+func (w *copilotElectronWorker) readLoop(stdout io.Reader) {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			w.route(line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	errWait := w.cmd.Wait()
+	w.errLog.flush()
+	w.mu.Lock()
+	w.exited = true
+	crashed := !w.retiring
+	calls := w.calls
+	w.calls = make(map[string]*copilotElectronCall)
+	w.mu.Unlock()
+	if crashed {
+		exitStatus := ""
+		if errWait != nil {
+			exitStatus = errWait.Error()
+		}
+		log.Warnf("copilot electron transport: pooled shim pid=%d exited unexpectedly (%s)", w.pid(), electronStderrDetail(w.errBuf.String(), exitStatus))
+	}
+	w.pool.remove(w, crashed && time.Since(w.started) >= copilotElectronPoolRespawnUptime)
+	for _, call := range calls {
+		call.fail(io.EOF)
+	}
+}
+
+func (w *copilotElectronWorker) route(line []byte) {
+	var envelope struct {
+		ID      string `json:"id"`
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(line), &envelope); err != nil {
+		log.Debugf("copilot electron transport: pooled shim pid=%d sent an unparseable line: %v", w.pid(), err)
+		w.retire("protocol error")
+		return
+	}
+	if envelope.Type == "fatal" {
+		log.Warnf("copilot electron transport: pooled shim pid=%d failed: %s", w.pid(), envelope.Message)
+		w.retire("fatal error")
+		return
+	}
+	w.mu.Lock()
+	call := w.calls[envelope.ID]
+	w.mu.Unlock()
+	if call == nil {
+		// Late messages for a cancelled call.
+		return
+	}
+	call.push(line)
+	switch envelope.Type {
+	case "end":
+		call.complete()
+		w.release(call.id)
+	case "error":
+		call.complete()
+		w.release(call.id)
+		w.retire("request error")
+	}
+}
+
+// copilotElectronCall buffers the messages of one request on a pooled process. The
+// buffer is unbounded so a slow reader cannot stall other streams on the same process.
+type copilotElectronCall struct {
+	id        string
+	worker    *copilotElectronWorker
+	stopWatch func() bool
+
+	mu     sync.Mutex
+	lines  [][]byte
+	done   bool
+	err    error
+	signal chan struct{}
+}
+
+func (c *copilotElectronCall) notify() {
+	select {
+	case c.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (c *copilotElectronCall) push(line []byte) {
+	c.mu.Lock()
+	c.lines = append(c.lines, line)
+	c.mu.Unlock()
+	c.notify()
+}
+
+// complete marks that the terminal message has been queued.
+func (c *copilotElectronCall) complete() {
+	c.mu.Lock()
+	c.done = true
+	c.mu.Unlock()
+	c.notify()
+}
+
+// fail ends the call with err once buffered lines are drained.
+func (c *copilotElectronCall) fail(err error) {
+	c.mu.Lock()
+	if !c.done {
+		c.done, c.err = true, err
+	}
+	c.mu.Unlock()
+	c.notify()
+}
+
+// cancel aborts the call in the shim and ends it with err.
+func (c *copilotElectronCall) cancel(err error) {
+	c.mu.Lock()
+	finished := c.done
+	c.mu.Unlock()
+	if finished {
+		return
+	}
+	c.fail(err)
+	if raw, errMarshal := json.Marshal(copilotElectronRequest{ID: c.id, Cancel: true}); errMarshal == nil {
+		_ = c.worker.writeLine(raw)
+	}
+	c.worker.release(c.id)
+}
+
+func (c *copilotElectronCall) next() ([]byte, error) {
+	for {
+		c.mu.Lock()
+		if len(c.lines) > 0 {
+			line := c.lines[0]
+			c.lines = c.lines[1:]
+			c.mu.Unlock()
+			return line, nil
+		}
+		if c.done {
+			err := c.err
+			c.mu.Unlock()
+			if err == nil {
+				err = io.EOF
+			}
+			return nil, err
+		}
+		c.mu.Unlock()
+		<-c.signal
+	}
+}
+
+func (c *copilotElectronCall) finish() {
+	if c.stopWatch != nil {
+		c.stopWatch()
+	}
+	c.cancel(errors.New("electron transport: request finished"))
+}
+
+func (c *copilotElectronCall) abort() { c.finish() }
+
+func (c *copilotElectronCall) stalled() {
+	c.worker.retire("stalled")
+	c.finish()
+}
+
+func (c *copilotElectronCall) stderr() string { return c.worker.errBuf.String() }
+
+// tailBuffer keeps the last limit bytes written to it.
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	buf   []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.limit; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.TrimSpace(string(t.buf))
+}
+
+// shutdownCopilotElectronPools retires every pooled process. Tests use it to start clean.
+func shutdownCopilotElectronPools() {
+	copilotElectronPoolsMu.Lock()
+	pools := copilotElectronPools
+	copilotElectronPools = make(map[string]*copilotElectronPool)
+	copilotElectronPoolsMu.Unlock()
+	for _, pool := range pools {
+		pool.drain()
+	}
+}
@@ -0,0 +1,197 @@
+package executor
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// serveFakeElectronPool mimics the shim in pool mode: every request line is answered
+// concurrently with id-tagged messages. The body echoes the request path, the
+// X-Fake-Pid header names the serving process, paths containing "fail" produce an
+// error message and paths containing "crash" make the process exit.
+func serveFakeElectronPool() {
+	var writeMu sync.Mutex
+	write := func(msg map[string]any) {
+		raw, _ := json.Marshal(msg)
+		writeMu.Lock()
+		_, _ = os.Stdout.Write(append(raw, '\n'))
+		writeMu.Unlock()
+	}
+	var wg sync.WaitGroup
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 1<<20), 1<<20)
+	for scanner.Scan() {
+		var req copilotElectronRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || req.Cancel {
+			continue
+		}
+		if strings.Contains(req.URL, "crash") {
+			os.Exit(3)
+		}
+		wg.Add(1)
+		go func(req copilotElectronRequest) {
+			defer wg.Done()
+			if strings.Contains(req.URL, "fail") {
+				write(map[string]any{"id": req.ID, "type": "error", "message": "boom"})
+				return
+			}
+			write(map[string]any{"id": req.ID, "type": "meta", "status": 200, "statusText": "OK",
+				"headers": map[string]string{"X-Fake-Pid": strconv.Itoa(os.Getpid())}})
+			for _, part := range []string{req.URL[:len(req.URL)/2], req.URL[len(req.URL)/2:]} {
+				time.Sleep(5 * time.Millisecond)
+				write(map[string]any{"id": req.ID, "type": "chunk", "b64": base64.StdEncoding.EncodeToString([]byte(part))})
+			}
+			write(map[string]any{"id": req.ID, "type": "end"})
+		}(req)
+	}
+	wg.Wait()
+}
+
+func pooledElectronGet(t *testing.T, path string) (pid string, body string, err error) {
+	t.Helper()
+	req, errReq := http.NewRequest(http.MethodGet, "https://api.githubcopilot.com"+path, nil)
+	if errReq != nil {
+		t.Fatalf("NewRequest: %v", errReq)
+	}
+	resp, err := httpResponseFromElectron(context.Background(), req, copilotElectronOptions{PoolSize: copilotElectronPoolSize(nil)})
+	if err != nil {
+		return "", "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	raw, err := io.ReadAll(resp.Body)
+	return resp.Header.Get("X-Fake-Pid"), string(raw), err
+}
+
+func TestHTTPResponseFromElectron_PoolMultiplexesAndRecyclesProcesses(t *testing.T) {
+	fakeCopilotElectronRunner(t)
+	t.Setenv("COPILOT_ELECTRON_POOL_SIZE", "1")
+	t.Setenv("COPILOT_ELECTRON_POOL_MAX_REQUESTS", "6")
+	shutdownCopilotElectronPools()
+	t.Cleanup(shutdownCopilotElectronPools)
+
+	// Concurrent requests share the single warm process and each gets its own stream back.
+	var wg sync.WaitGroup
+	pids := make([]string, 5)
+	errs := make([]error, 5)
+	for i := range pids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path := fmt.Sprintf("/models/%d", i)
+			pid, body, err := pooledElectronGet(t, path)
+			if err == nil && body != "https://api.githubcopilot.com"+path {
+				err = fmt.Errorf("body %q for %s", body, path)
+			}
+			pids[i], errs[i] = pid, err
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		if pids[i] == "" || pids[i] != pids[0] {
+			t.Fatalf("requests were not served by one pooled process: %v", pids)
+		}
+	}
+
+	// The sixth request reaches the request limit; the process is replaced afterwards.
+	sixth, _, err := pooledElectronGet(t, "/models/5")
+	if err != nil || sixth != pids[0] {
+		t.Fatalf("sixth request pid=%s err=%v, want pid %s", sixth, err, pids[0])
+	}
+	replacement, _, err := pooledElectronGet(t, "/models/6")
+	if err != nil || replacement == "" || replacement == pids[0] {
+		t.Fatalf("request after the limit pid=%s err=%v, want a new process", replacement, err)
+	}
+
+	// An error evicts the process on first failure.
+	if _, _, err = pooledElectronGet(t, "/fail"); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("failing request err = %v", err)
+	}
+	afterError, _, err := pooledElectronGet(t, "/models/7")
+	if err != nil || afterError == replacement {
+		t.Fatalf("request after an error pid=%s err=%v, want a process other than %s", afterError, err, replacement)
+	}
+}
+
+func TestHTTPResponseFromElectron_PoolRespawnsCrashedProcess(t *testing.T) {
+	fakeCopilotElectronRunner(t)
+	t.Setenv("COPILOT_ELECTRON_POOL_SIZE", "1")
+	original := copilotElectronPoolRespawnUptime
+	copilotElectronPoolRespawnUptime = 0
+	t.Cleanup(func() { copilotElectronPoolRespawnUptime = original })
+	shutdownCopilotElectronPools()
+	t.Cleanup(shutdownCopilotElectronPools)
+
+	first, _, err := pooledElectronGet(t, "/models/0")
+	if err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if _, _, err = pooledElectronGet(t, "/crash"); err == nil {
+		t.Fatal("request on a crashing process succeeded")
+	}
+
+	// The replacement is started without waiting for the next request.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		copilotElectronPoolsMu.Lock()
+		workers := 0
+		for _, pool := range copilotElectronPools {
+			pool.mu.Lock()
+			for _, w := range pool.workers {
+				if w.pid() != 0 && strconv.Itoa(w.pid()) != first {
+					workers++
+				}
+			}
+			pool.mu.Unlock()
+		}
+		copilotElectronPoolsMu.Unlock()
+		if workers == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("crashed pooled process was not respawned")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	next, _, err := pooledElectronGet(t, "/models/1")
+	if err != nil || next == "" || next == first {
+		t.Fatalf("request after the crash pid=%s err=%v, want a new process", next, err)
+	}
+}
+
+func TestCopilotElectronPoolSize(t *testing.T) {
+	cfg := &config.Config{CopilotElectronPoolSize: 3}
+	cases := []struct {
+		env  string
+		cfg  *config.Config
+		want int
+	}{
+		{"", nil, 0},
+		{"", cfg, 3},
+		{"", &config.Config{CopilotElectronPoolSize: 99}, copilotElectronPoolSizeLimit},
+		{"5", cfg, 5},
+		{"0", cfg, 0},
+		{"lots", cfg, 3},
+	}
+	for _, tc := range cases {
+		t.Setenv("COPILOT_ELECTRON_POOL_SIZE", tc.env)
+		if got := copilotElectronPoolSize(tc.cfg); got != tc.want {
+			t.Errorf("env=%q cfg=%+v: pool size = %d, want %d", tc.env, tc.cfg, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	internallogging "github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// copilotElectronStderrTail bounds the stderr kept in memory for a one-shot shim process.
+	copilotElectronStderrTail = 64 << 10
+	// copilotElectronStderrLogName is the stderr log file in the log directory.
+	copilotElectronStderrLogName = "electron-shim-stderr.log"
+	// copilotElectronStderrTailBytesDefault bounds the stderr quoted in transport errors.
+	copilotElectronStderrTailBytesDefault = 4096
+	copilotElectronStderrTailBytesMin     = 256
+)
+
+// electronCrashSignatures maps lower-cased stderr or exit status fragments to the kind of
+// crash they indicate. Earlier entries win.
+var electronCrashSignatures = []struct{ fragment, kind string }{
+	{"signal: killed", "killed, possibly by the OOM killer"},
+	{"out of memory", "out of memory"},
+	{"oom-kill", "out of memory"},
+	{"segmentation fault", "segfault"},
+	{"sigsegv", "segfault"},
+	{"received signal 11", "segfault"},
+	{"sigbus", "bus error"},
+	{"sigabrt", "abort"},
+	{"signal: aborted", "abort"},
+	{"aborted (core dumped)", "abort"},
+	{"sigill", "illegal instruction"},
+	{"sigtrap", "trap"},
+	{"trace/breakpoint trap", "trap"},
+	{":fatal:", "fatal check"},
+}
+
+var (
+	copilotElectronStderrLogsMu sync.Mutex
+	copilotElectronStderrLogs   = make(map[string]*lumberjack.Logger)
+)
+
+// copilotElectronStderrLogPath returns the file shim stderr is copied to, or "" when
+// COPILOT_ELECTRON_STDERR_LOG is unset or false. A truthy value selects
+// electron-shim-stderr.log in the log directory; any other value is the file path.
+func copilotElectronStderrLogPath() string {
+	raw := strings.TrimSpace(os.Getenv("COPILOT_ELECTRON_STDERR_LOG"))
+	switch strings.ToLower(raw) {
+	case "", "0", "false", "f", "no", "n", "off":
+		return ""
+	case "1", "true", "t", "yes", "y", "on":
+		return filepath.Join(internallogging.ResolveLogDirectory(nil), copilotElectronStderrLogName)
+	}
+	return raw
+}
+
+// copilotElectronStderrLog returns the rotating log for path, shared by all shim processes.
+func copilotElectronStderrLog(path string) *lumberjack.Logger {
+	copilotElectronStderrLogsMu.Lock()
+	defer copilotElectronStderrLogsMu.Unlock()
+	logger, ok := copilotElectronStderrLogs[path]
+	if !ok {
+		logger = &lumberjack.Logger{Filename: path, MaxSize: 10, MaxBackups: 3}
+		copilotElectronStderrLogs[path] = logger
+	}
+	return logger
+}
+
+// electronStderrLog copies shim stderr to the stderr log, one timestamped line at a time
+// prefixed with the request ID (or the pooled process) it belongs to.
+type electronStderrLog struct {
+	mu      sync.Mutex
+	out     io.Writer
+	prefix  string
+	pending []byte
+}
+
+// newElectronStderrWriter returns the stderr destination of a shim process: tail, teed to
+// the stderr log when one is configured. The returned log is nil without one.
+func newElectronStderrWriter(tail *tailBuffer, prefix string) (io.Writer, *electronStderrLog) {
+	path := copilotElectronStderrLogPath()
+	if path == "" {
+		return tail, nil
+	}
+	stderrLog := &electronStderrLog{out: copilotElectronStderrLog(path), prefix: prefix}
+	return io.MultiWriter(tail, stderrLog), stderrLog
+}
+
+func (l *electronStderrLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = append(l.pending, p...)
+	for {
+		i := bytes.IndexByte(l.pending, '\n')
+		if i < 0 {
+			break
+		}
+		l.writeLineLocked(l.pending[:i])
+		l.pending = l.pending[i+1:]
+	}
+	// A shim that never ends its line still cannot grow the buffer past the tail size.
+	if len(l.pending) >= copilotElectronStderrTail {
+		l.writeLineLocked(l.pending)
+		l.pending = nil
+	}
+	return len(p), nil
+}
+
+// setPrefix changes the prefix of later lines, e.g. once a pooled process has a pid.
+func (l *electronStderrLog) setPrefix(prefix string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	l.prefix = prefix
+	l.mu.Unlock()
+}
+
+// flush writes an unterminated last line; call it once the process has been reaped.
+func (l *electronStderrLog) flush() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.pending) > 0 {
+		l.writeLineLocked(l.pending)
+		l.pending = nil
+	}
+}
+
+func (l *electronStderrLog) writeLineLocked(line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	if len(line) == 0 {
+		return
+	}
+	prefix := l.prefix
+	if prefix == "" {
+		prefix = "-"
+	}
+	entry := make([]byte, 0, len(line)+len(prefix)+40)
+	entry = time.Now().UTC().AppendFormat(entry, time.RFC3339Nano)
+	entry = append(entry, " ["...)
+	entry = append(entry, prefix...)
+	entry = append(entry, "] "...)
+	entry = append(entry, line...)
+	entry = append(entry, '\n')
+	_, _ = l.out.Write(entry)
+}
+
+// copilotElectronStderrTailBytes returns COPILOT_ELECTRON_STDERR_TAIL_BYTES (256 up to the
+// in-memory tail), or 4096.
+func copilotElectronStderrTailBytes() int {
+	if n := copilotElectronEnvInt("COPILOT_ELECTRON_STDERR_TAIL_BYTES", copilotElectronStderrTailBytesMin, copilotElectronStderrTail); n > 0 {
+		return n
+	}
+	return copilotElectronStderrTailBytesDefault
+}
+
+// electronCrashKind returns the crash signature found in the shim stderr or exit status,
+// or "" when neither looks like a crash.
+func electronCrashKind(stderr, exitStatus string) string {
+	haystack := strings.ToLower(exitStatus + "\n" + stderr)
+	for _, sig := range electronCrashSignatures {
+		if strings.Contains(haystack, sig.fragment) {
+			return sig.kind
+		}
+	}
+	return ""
+}
+
+// electronStderrDetail formats shim stderr for an error message: only the last
+// COPILOT_ELECTRON_STDERR_TAIL_BYTES, prefixed with a "crash: true" hint when the stderr or
+// exitStatus carries a crash signature. The full stderr goes to the debug log instead.
+func electronStderrDetail(stderr, exitStatus string) string {
+	if stderr != "" {
+		log.Debugf("copilot electron transport: shim stderr (%d bytes): %s", len(stderr), stderr)
+	}
+	detail := "stderr=" + stderr
+	if limit := copilotElectronStderrTailBytes(); len(stderr) > limit {
+		cut := len(stderr) - limit
+		for cut < len(stderr) && !utf8.RuneStart(stderr[cut]) {
+			cut++
+		}
+		detail = fmt.Sprintf("stderr(last %d of %d bytes)=%s", len(stderr)-cut, len(stderr), stderr[cut:])
+	}
+	if exitStatus != "" {
+		detail = "exit=" + exitStatus + " " + detail
+	}
+	if kind := electronCrashKind(stderr, exitStatus); kind != "" {
+		detail = "crash: true (" + kind + ") " + detail
+	}
+	return detail
+}
+
+// electronSourceExitStatus returns how the process behind src exited, once it has been
+// reaped, or "" when src does not own a process or it is still running.
+func electronSourceExitStatus(src electronLineSource) string {
+	if exited, ok := src.(interface{ exitStatus() string }); ok {
+		return exited.exitStatus()
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	internallogging "github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+func TestHTTPResponseFromElectron_StderrLog(t *testing.T) {
+	fakeCopilotElectronRunner(t)
+	logPath := filepath.Join(t.TempDir(), "stderr.log")
+	t.Setenv("COPILOT_ELECTRON_STDERR_LOG", logPath)
+	t.Cleanup(func() {
+		copilotElectronStderrLogsMu.Lock()
+		if logger := copilotElectronStderrLogs[logPath]; logger != nil {
+			_ = logger.Close()
+		}
+		delete(copilotElectronStderrLogs, logPath)
+		copilotElectronStderrLogsMu.Unlock()
+	})
+
+	t.Setenv("CLIPROXY_FAKE_ELECTRON_STDERR", "proxy auth ok\r\nserved")
+	req, _ := http.NewRequest(http.MethodGet, "https://api.githubcopilot.com/models", nil)
+	resp, err := httpResponseFromElectron(internallogging.WithRequestID(context.Background(), "req-ok"), req, copilotElectronOptions{})
+	if err != nil {
+		t.Fatalf("httpResponseFromElectron: %v", err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	t.Setenv("CLIPROXY_FAKE_ELECTRON_STDERR", "ERR_PROXY_AUTH_UNSUPPORTED\n")
+	t.Setenv("CLIPROXY_FAKE_ELECTRON_FAIL", "error")
+	if _, err = httpResponseFromElectron(internallogging.WithRequestID(context.Background(), "req-fail"), req, copilotElectronOptions{}); err == nil {
+		t.Fatal("expected the shim error")
+	}
+
+	raw, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("read stderr log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	want := []string{" [req-ok] proxy auth ok", " [req-ok] served", " [req-fail] ERR_PROXY_AUTH_UNSUPPORTED"}
+	if len(lines) != len(want) {
+		t.Fatalf("stderr log = %q, want %d lines", raw, len(want))
+	}
+	for i, line := range lines {
+		if !strings.HasSuffix(line, want[i]) {
+			t.Fatalf("stderr log line %d = %q, want suffix %q", i, line, want[i])
+		}
+	}
+}
+
+func TestElectronStderrLog_BoundsUnterminatedLine(t *testing.T) {
+	var out bytes.Buffer
+	stderrLog := &electronStderrLog{out: &out, prefix: "req"}
+	_, _ = stderrLog.Write(bytes.Repeat([]byte("x"), copilotElectronStderrTail+10))
+	if len(stderrLog.pending) != 0 || out.Len() == 0 {
+		t.Fatalf("pending = %d bytes, logged = %d bytes; want the long line written out", len(stderrLog.pending), out.Len())
+	}
+
+	tail := &tailBuffer{limit: copilotElectronStderrTail}
+	_, _ = tail.Write(bytes.Repeat([]byte("y"), 3*copilotElectronStderrTail))
+	if got := len(tail.String()); got != copilotElectronStderrTail {
+		t.Fatalf("in-memory stderr = %d bytes, want %d", got, copilotElectronStderrTail)
+	}
+}
+
+// crashingLineSource stands in for a shim that dies mid-stream with a stderr full of noise.
+type crashingLineSource struct {
+	lines  [][]byte
+	errOut string
+	exit   string
+}
+
+func (s *crashingLineSource) next() ([]byte, error) {
+	if len(s.lines) == 0 {
+		return nil, io.EOF
+	}
+	line := s.lines[0]
+	s.lines = s.lines[1:]
+	return line, nil
+}
+
+func (s *crashingLineSource) finish()            {}
+func (s *crashingLineSource) abort()             {}
+func (s *crashingLineSource) stalled()           {}
+func (s *crashingLineSource) stderr() string     { return s.errOut }
+func (s *crashingLineSource) exitStatus() string { return s.exit }
+
+func TestElectronResponseFromShim_CrashReportsStderrTail(t *testing.T) {
+	t.Setenv("COPILOT_ELECTRON_STDERR_TAIL_BYTES", "")
+	noise := strings.Repeat("[1234:ERROR:gpu_init.cc(42)] chromium spew\n", 1000)
+	src := &crashingLineSource{
+		lines: [][]byte{
+			[]byte(`{"type":"meta","status":200,"statusText":"OK","headers":{}}` + "\n"),
+			[]byte(`{"type":"chunk","b64":"aGVsbG8="}` + "\n"),
+		},
+		errOut: noise + "Received signal 11 SEGV_MAPERR 000000000000",
+		exit:   "signal: segmentation fault",
+	}
+	req, _ := http.NewRequest(http.MethodPost, "https://api.githubcopilot.com/chat/completions", nil)
+	resp, err := electronResponseFromShim(context.Background(), req, "", src)
+	if err != nil {
+		t.Fatalf("electronResponseFromShim: %v", err)
+	}
+	_, err = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err == nil {
+		t.Fatal("body read succeeded after the shim died")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "unexpected EOF before end marker") || !strings.Contains(msg, "crash: true (segfault)") {
+		t.Fatalf("error %q lacks the EOF and crash hint", msg)
+	}
+	if !strings.Contains(msg, "Received signal 11") || !strings.Contains(msg, "exit=signal: segmentation fault") {
+		t.Fatalf("error %q lacks the stderr tail or exit status", msg)
+	}
+	if len(msg) > copilotElectronStderrTailBytesDefault+300 {
+		t.Fatalf("error is %d bytes, want the stderr capped near %d", len(msg), copilotElectronStderrTailBytesDefault)
+	}
+}
+
+func TestElectronStderrDetail(t *testing.T) {
+	t.Setenv("COPILOT_ELECTRON_STDERR_TAIL_BYTES", "300")
+	long := strings.Repeat("é", 400)
+	detail := electronStderrDetail(long, "")
+	if !strings.HasPrefix(detail, "stderr(last 300 of 800 bytes)=") {
+		t.Fatalf("detail = %.60q, want a 300 byte tail", detail)
+	}
+	if strings.Contains(detail, "crash") {
+		t.Fatalf("detail %.60q marks noise as a crash", detail)
+	}
+
+	if got := electronStderrDetail("warning: something", ""); got != "stderr=warning: something" {
+		t.Fatalf("short detail = %q", got)
+	}
+	if got := electronStderrDetail("", "signal: killed"); got != "crash: true (killed, possibly by the OOM killer) exit=signal: killed stderr=" {
+		t.Fatalf("OOM kill detail = %q", got)
+	}
+	if got := electronStderrDetail("<--- JS stacktrace --->\nFATAL ERROR: Reached heap limit Allocation failed - JavaScript heap out of memory", "exit status 134"); !strings.HasPrefix(got, "crash: true (out of memory)") {
+		t.Fatalf("heap OOM detail = %q", got)
+	}
+}
@@ -0,0 +1,129 @@
+package executor
+
+import (
+	"cmp"
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// copilotElectronVersionTimeout bounds "electron --version".
+const copilotElectronVersionTimeout = 15 * time.Second
+
+// copilotElectronVersionOutput runs "electron --version"; tests swap it for a fake.
+var copilotElectronVersionOutput = func(electronPath string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), copilotElectronVersionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, electronPath, "--version").Output()
+	return string(out), err
+}
+
+type copilotElectronVersionResult struct {
+	version string
+	err     error
+}
+
+var (
+	copilotElectronVersionsMu sync.Mutex
+	copilotElectronVersions   = make(map[string]copilotElectronVersionResult)
+
+	copilotElectronVersionWarned sync.Map
+)
+
+// copilotElectronVersion returns the version of the Electron binary at electronPath, running
+// it once per path.
+func copilotElectronVersion(electronPath string) (string, error) {
+	copilotElectronVersionsMu.Lock()
+	defer copilotElectronVersionsMu.Unlock()
+	if cached, ok := copilotElectronVersions[electronPath]; ok {
+		return cached.version, cached.err
+	}
+	out, err := copilotElectronVersionOutput(electronPath)
+	result := copilotElectronVersionResult{version: strings.TrimPrefix(strings.TrimSpace(out), "v"), err: err}
+	copilotElectronVersions[electronPath] = result
+	return result.version, result.err
+}
+
+// copilotElectronVersionAllowed reports whether the Electron binary meets
+// COPILOT_ELECTRON_MIN_VERSION. Without a floor every binary is allowed and none is run; a
+// binary whose version cannot be read is refused. A refusal is logged once per binary.
+func copilotElectronVersionAllowed(electronPath string) bool {
+	floor := strings.TrimPrefix(strings.TrimSpace(os.Getenv("COPILOT_ELECTRON_MIN_VERSION")), "v")
+	if floor == "" {
+		return true
+	}
+	version, err := copilotElectronVersion(electronPath)
+	if err == nil && version != "" && compareElectronVersions(version, floor) >= 0 {
+		return true
+	}
+	if _, warned := copilotElectronVersionWarned.LoadOrStore(electronPath+"\x00"+floor, struct{}{}); !warned {
+		if err != nil || version == "" {
+			log.Warnf("copilot electron transport: cannot read the version of %s (%v); COPILOT_ELECTRON_MIN_VERSION=%s requires it, using the next transport", electronPath, err, floor)
+		} else {
+			log.Warnf("copilot electron transport: electron %s at %s is older than COPILOT_ELECTRON_MIN_VERSION=%s, using the next transport", version, electronPath, floor)
+		}
+	}
+	return false
+}
+
+// compareElectronVersions compares two versions such as "28.1.0" and "28.0.0-beta.1" by
+// semver precedence and returns -1, 0 or 1. Missing core parts count as 0 and a pre-release
+// sorts before its release.
+func compareElectronVersions(a, b string) int {
+	coreA, preA, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(a), "v"), "-")
+	coreB, preB, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(b), "v"), "-")
+	partsA, partsB := strings.Split(coreA, "."), strings.Split(coreB, ".")
+	for i := 0; i < max(len(partsA), len(partsB)); i++ {
+		if c := compareVersionPart(partAt(partsA, i), partAt(partsB, i), true); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+	idsA, idsB := strings.Split(preA, "."), strings.Split(preB, ".")
+	for i := 0; i < min(len(idsA), len(idsB)); i++ {
+		if c := compareVersionPart(idsA[i], idsB[i], false); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(idsA), len(idsB))
+}
+
+func partAt(parts []string, i int) string {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return "0"
+}
+
+// compareVersionPart compares numeric identifiers numerically and others lexically, with
+// numeric ones first. In core parts (numericOnly) a non-numeric part counts as 0.
+func compareVersionPart(a, b string, numericOnly bool) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if numericOnly {
+		return cmp.Compare(na, nb)
+	}
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestCompareElectronVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"28.0.0", "28.0.0", 0},
+		{"v28.1.0", "28.0.0", 1},
+		{"27.3.11", "28.0.0", -1},
+		{"28", "28.0.0", 0},
+		{"28.0.0-beta.1", "28.0.0", -1},
+		{"28.0.0", "28.0.0-beta.1", 1},
+		{"28.0.0-beta.1", "28.0.0-beta.2", -1},
+		{"28.0.0-beta.10", "28.0.0-beta.2", 1},
+		{"28.0.0-alpha.3", "28.0.0-beta.1", -1},
+		{"28.0.0-beta", "28.0.0-beta.1", -1},
+		{"28.0.0-beta.1", "27.9.9", 1},
+		{"28.0.0-nightly.20231010", "28.0.0-beta.1", 1},
+	}
+	for _, tt := range tests {
+		if got := compareElectronVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareElectronVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestHTTPResponseFromElectron_BelowMinVersionIsUnavailable(t *testing.T) {
+	electronPath := os.Args[0]
+	t.Setenv("ELECTRON_PATH", electronPath)
+	t.Setenv("COPILOT_ELECTRON_MIN_VERSION", "28.0.0")
+	runs := 0
+	original := copilotElectronVersionOutput
+	copilotElectronVersionOutput = func(string) (string, error) {
+		runs++
+		return "v28.0.0-beta.1\n", nil
+	}
+	t.Cleanup(func() {
+		copilotElectronVersionOutput = original
+		copilotElectronVersionsMu.Lock()
+		delete(copilotElectronVersions, electronPath)
+		copilotElectronVersionsMu.Unlock()
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.githubcopilot.com/models", nil)
+	for i := 0; i < 2; i++ {
+		if _, err := httpResponseFromElectron(context.Background(), req, copilotElectronOptions{}); !errors.Is(err, errCopilotElectronUnavailable) {
+			t.Fatalf("err = %v, want the transport unavailable below the minimum version", err)
+		}
+	}
+	if runs != 1 {
+		t.Fatalf("electron --version ran %d times, want once", runs)
+	}
+	if ok, reason := CopilotElectronStatus(); ok {
+		t.Fatalf("CopilotElectronStatus = ok (%s), want unavailable", reason)
+	}
+
+	t.Setenv("COPILOT_ELECTRON_MIN_VERSION", "27.3")
+	if !copilotElectronVersionAllowed(electronPath) {
+		t.Fatal("a 28.0.0 pre-release should satisfy a 27.3 floor")
+	}
+}
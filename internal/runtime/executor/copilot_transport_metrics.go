@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Outcomes reported in CopilotElectronMetrics.Outcome.
+const (
+	CopilotElectronOutcomeOK            = "ok"
+	CopilotElectronOutcomeUpstreamError = "upstream_error"
+	CopilotElectronOutcomeIdleTimeout   = "idle_timeout"
+	CopilotElectronOutcomeCanceled      = "canceled"
+	CopilotElectronOutcomeStreamError   = "stream_error"
+	CopilotElectronOutcomeClientClosed  = "client_closed"
+	CopilotElectronOutcomeBodyTooLarge  = "body_too_large"
+)
+
+// CopilotElectronMetrics describes one request served by the Electron shim, reported once
+// when it finishes. Sinks exporting to Prometheus or similar are expected to map the
+// fields as follows:
+//
+//   - counters: one request per observation labelled by Outcome; BytesReceived and
+//     ChunksEmitted added to byte and chunk totals; Attempt-1 added to a retry total.
+//   - histograms: THeadersMs (time to response headers), ElapsedMs (total duration),
+//     IdleMs (silence before the request ended, mostly relevant for idle_timeout) and
+//     Attempt (shim attempts per request).
+type CopilotElectronMetrics struct {
+	// Outcome is one of the CopilotElectronOutcome* values.
+	Outcome string
+	// Status is the upstream HTTP status, 0 when the shim failed before headers.
+	Status int
+	// Phase is the shim phase reported with an upstream error.
+	Phase         string
+	Attempt       int
+	MaxAttempts   int
+	THeadersMs    int64
+	BytesReceived int64
+	ChunksEmitted int64
+	IdleMs        int64
+	ElapsedMs     int64
+}
+
+// CopilotElectronMetricsSink receives the Electron transport metrics. Implementations must
+// be safe for concurrent use and should not block: they run on the response stream.
+type CopilotElectronMetricsSink interface {
+	ObserveCopilotElectron(ctx context.Context, metrics CopilotElectronMetrics)
+}
+
+// CopilotElectronMetricsFunc adapts a function to a CopilotElectronMetricsSink.
+type CopilotElectronMetricsFunc func(ctx context.Context, metrics CopilotElectronMetrics)
+
+// ObserveCopilotElectron calls f.
+func (f CopilotElectronMetricsFunc) ObserveCopilotElectron(ctx context.Context, metrics CopilotElectronMetrics) {
+	f(ctx, metrics)
+}
+
+type copilotElectronMetricsHolder struct {
+	sink CopilotElectronMetricsSink
+}
+
+var copilotElectronMetricsSink atomic.Value
+
+// SetCopilotElectronMetricsSink installs the sink for Electron transport metrics. Pass nil
+// to remove it; without a sink the metrics are discarded.
+func SetCopilotElectronMetricsSink(sink CopilotElectronMetricsSink) {
+	copilotElectronMetricsSink.Store(copilotElectronMetricsHolder{sink: sink})
+}
+
+// observeCopilotElectron reports meta, the shim telemetry accumulated for a request, to
+// the installed sink.
+func observeCopilotElectron(ctx context.Context, outcome string, meta copilotElectronResponseMeta) {
+	holder, _ := copilotElectronMetricsSink.Load().(copilotElectronMetricsHolder)
+	if holder.sink == nil {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	holder.sink.ObserveCopilotElectron(ctx, CopilotElectronMetrics{
+		Outcome:       outcome,
+		Status:        meta.Status,
+		Phase:         meta.Phase,
+		Attempt:       meta.Attempt,
+		MaxAttempts:   meta.MaxAttempts,
+		THeadersMs:    meta.THeadersMs,
+		BytesReceived: meta.BytesReceived,
+		ChunksEmitted: meta.ChunksEmitted,
+		IdleMs:        meta.IdleMsSinceLastByte,
+		ElapsedMs:     meta.ElapsedMs,
+	})
+}
@@ -0,0 +1,92 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func collectCopilotElectronMetrics(t *testing.T) <-chan CopilotElectronMetrics {
+	t.Helper()
+	observed := make(chan CopilotElectronMetrics, 4)
+	SetCopilotElectronMetricsSink(CopilotElectronMetricsFunc(func(_ context.Context, m CopilotElectronMetrics) {
+		observed <- m
+	}))
+	t.Cleanup(func() { SetCopilotElectronMetricsSink(nil) })
+	return observed
+}
+
+func awaitCopilotElectronMetrics(t *testing.T, observed <-chan CopilotElectronMetrics) CopilotElectronMetrics {
+	t.Helper()
+	select {
+	case m := <-observed:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("no metrics observed")
+		return CopilotElectronMetrics{}
+	}
+}
+
+func TestElectronResponseFromShim_ReportsMetrics(t *testing.T) {
+	observed := collectCopilotElectronMetrics(t)
+	src := &stallingLineSource{stopped: make(chan struct{}), lines: [][]byte{
+		[]byte(`{"type":"meta","status":200,"statusText":"OK","headers":{},"attempt":2,"maxAttempts":3,"tHeadersMs":41}` + "\n"),
+		[]byte(`{"type":"chunk","b64":"aGVsbG8="}` + "\n"),
+		[]byte(`{"type":"chunk","b64":"IHdvcmxk"}` + "\n"),
+		[]byte(`{"type":"end"}` + "\n"),
+	}}
+	req, _ := http.NewRequest(http.MethodPost, "https://api.githubcopilot.com/chat/completions", nil)
+	resp, err := electronResponseFromShim(context.Background(), req, "", src)
+	if err != nil {
+		t.Fatalf("electronResponseFromShim: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if body, errRead := io.ReadAll(resp.Body); errRead != nil || string(body) != "hello world" {
+		t.Fatalf("body = %q, %v", body, errRead)
+	}
+
+	m := awaitCopilotElectronMetrics(t, observed)
+	if m.Outcome != CopilotElectronOutcomeOK || m.Status != 200 {
+		t.Fatalf("outcome/status = %q/%d, want ok/200", m.Outcome, m.Status)
+	}
+	if m.Attempt != 2 || m.MaxAttempts != 3 || m.THeadersMs != 41 {
+		t.Fatalf("attempt=%d/%d t_headers_ms=%d, want 2/3 41", m.Attempt, m.MaxAttempts, m.THeadersMs)
+	}
+	if m.BytesReceived != 11 || m.ChunksEmitted != 2 {
+		t.Fatalf("bytes=%d chunks=%d, want 11 and 2", m.BytesReceived, m.ChunksEmitted)
+	}
+}
+
+func TestElectronResponseFromShim_ReportsFailureMetrics(t *testing.T) {
+	observed := collectCopilotElectronMetrics(t)
+	req, _ := http.NewRequest(http.MethodPost, "https://api.githubcopilot.com/chat/completions", nil)
+
+	src := &stallingLineSource{stopped: make(chan struct{}), lines: [][]byte{
+		[]byte(`{"type":"error","message":"net::ERR_CONNECTION_CLOSED","phase":"request","attempt":2,"maxAttempts":2}` + "\n"),
+	}}
+	if _, err := electronResponseFromShim(context.Background(), req, "", src); err == nil {
+		t.Fatal("expected an upstream error")
+	}
+	m := awaitCopilotElectronMetrics(t, observed)
+	if m.Outcome != CopilotElectronOutcomeUpstreamError || m.Phase != "request" || m.Attempt != 2 {
+		t.Fatalf("metrics = %+v, want an upstream_error in phase request after 2 attempts", m)
+	}
+
+	t.Setenv("COPILOT_ELECTRON_IDLE_TIMEOUT_MS", "200")
+	src = &stallingLineSource{stopped: make(chan struct{}), lines: [][]byte{
+		[]byte(`{"type":"meta","status":200,"statusText":"OK","headers":{}}` + "\n"),
+		[]byte(`{"type":"chunk","b64":"aGVsbG8="}` + "\n"),
+	}}
+	resp, err := electronResponseFromShim(context.Background(), req, "", src)
+	if err != nil {
+		t.Fatalf("electronResponseFromShim: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.ReadAll(resp.Body)
+	m = awaitCopilotElectronMetrics(t, observed)
+	if m.Outcome != CopilotElectronOutcomeIdleTimeout || m.BytesReceived != 5 || m.IdleMs < 200 {
+		t.Fatalf("metrics = %+v, want an idle_timeout after 5 bytes and at least 200ms idle", m)
+	}
+}
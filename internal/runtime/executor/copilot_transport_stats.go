@@ -0,0 +1,57 @@
+package executor
+
+import "sync/atomic"
+
+// CopilotTransportHeader is set on Copilot upstream responses to the transport that
+// served the request: "electron" or "go", with a "-fallback" suffix ("go-fallback",
+// "electron-fallback") when an earlier transport in the COPILOT_TRANSPORT order failed
+// before any response bytes or returned a Cloudflare challenge.
+const CopilotTransportHeader = "X-Cliproxy-Copilot-Transport"
+
+const (
+	copilotTransportElectron = "electron"
+	copilotTransportGo       = "go"
+)
+
+// CopilotTransportCounters counts Copilot upstream requests by the transport that
+// served them.
+type CopilotTransportCounters struct {
+	Electron int64 `json:"electron"`
+	Go       int64 `json:"go"`
+	// Fallbacks counts requests retried on the next transport after an earlier one failed
+	// before any response bytes were delivered or returned a Cloudflare challenge.
+	Fallbacks int64 `json:"fallbacks"`
+	// FallbackFailures counts fallbacks whose last attempt failed as well.
+	FallbackFailures int64 `json:"fallback_failures"`
+}
+
+var copilotTransportStats struct {
+	electron, goDirect, fallbacks, fallbackFailures atomic.Int64
+}
+
+// CopilotTransportSnapshot returns the transport counters since process start.
+func CopilotTransportSnapshot() CopilotTransportCounters {
+	return CopilotTransportCounters{
+		Electron:         copilotTransportStats.electron.Load(),
+		Go:               copilotTransportStats.goDirect.Load(),
+		Fallbacks:        copilotTransportStats.fallbacks.Load(),
+		FallbackFailures: copilotTransportStats.fallbackFailures.Load(),
+	}
+}
+
+// recordCopilotTransport counts the final attempt of a request on transport.
+func recordCopilotTransport(transport string, fellBack bool, err error) {
+	switch {
+	case fellBack:
+		copilotTransportStats.fallbacks.Add(1)
+		if err != nil {
+			copilotTransportStats.fallbackFailures.Add(1)
+		}
+	case transport == copilotTransportElectron:
+		if err == nil {
+			copilotTransportStats.electron.Add(1)
+		}
+	default:
+		copilotTransportStats.goDirect.Add(1)
+	}
+}
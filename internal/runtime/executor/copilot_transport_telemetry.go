@@ -0,0 +1,154 @@
+package executor
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+// Debug headers set by httpResponseFromElectron only with COPILOT_ELECTRON_DEBUG_HEADERS, so
+// production responses do not carry the egress path.
+const (
+	copilotResolvedProxyHeader = "X-Copilot-Resolved-Proxy"
+	copilotUpstreamHostHeader  = "X-Copilot-Upstream-Host"
+)
+
+// electronTelemetryHeaders maps the shim meta diagnostics to the response headers set by
+// httpResponseFromElectron, and to the field names used by copilotTransportTelemetry.
+var electronTelemetryHeaders = []struct {
+	header  string
+	field   string
+	numeric bool
+}{
+	{"X-Cliproxy-Electron-Attempt", "attempt", true},
+	{"X-Cliproxy-Electron-Max-Attempts", "max_attempts", true},
+	{"X-Cliproxy-Electron-Resolved-Proxy", "resolved_proxy", false},
+	{"X-Cliproxy-Electron-Url-Host", "url_host", false},
+	{"X-Cliproxy-Electron-T-Headers-Ms", "t_headers_ms", true},
+	{"X-Cliproxy-Electron-Version", "electron", false},
+	{"X-Cliproxy-Electron-Chromium", "chromium", false},
+	{"X-Cliproxy-Electron-Protocol", "protocol", false},
+}
+
+// setElectronTelemetryHeaders records the shim diagnostics of a response on its headers,
+// replacing upstream headers of the same name.
+func setElectronTelemetryHeaders(header http.Header, meta copilotElectronResponseMeta) {
+	values := map[string]string{
+		"attempt":        strconv.Itoa(meta.Attempt),
+		"max_attempts":   strconv.Itoa(meta.MaxAttempts),
+		"resolved_proxy": maskElectronResolvedProxy(meta.ResolvedProxy),
+		"url_host":       meta.URLHost,
+		"t_headers_ms":   strconv.FormatInt(meta.THeadersMs, 10),
+		"electron":       meta.Electron,
+		"chromium":       meta.Chromium,
+		"protocol":       meta.Protocol,
+	}
+	for _, h := range electronTelemetryHeaders {
+		header.Del(h.header)
+		if value := strings.TrimSpace(values[h.field]); value != "" {
+			header.Set(h.header, value)
+		}
+	}
+}
+
+// setElectronDebugHeaders records the proxy Chromium resolved (credentials masked) and the
+// upstream host on the response headers when COPILOT_ELECTRON_DEBUG_HEADERS is truthy.
+// Upstream headers of the same name are always dropped.
+func setElectronDebugHeaders(header http.Header, meta copilotElectronResponseMeta) {
+	header.Del(copilotResolvedProxyHeader)
+	header.Del(copilotUpstreamHostHeader)
+	if !envTruthy("COPILOT_ELECTRON_DEBUG_HEADERS", false) {
+		return
+	}
+	if proxy := maskElectronResolvedProxy(meta.ResolvedProxy); proxy != "" {
+		header.Set(copilotResolvedProxyHeader, proxy)
+	}
+	if host := strings.TrimSpace(meta.URLHost); host != "" {
+		header.Set(copilotUpstreamHostHeader, host)
+	}
+}
+
+// maskElectronResolvedProxy masks credentials in a resolved proxy, either a PAC-style list
+// such as "PROXY user:pass@host:3128; DIRECT" or a proxy URL, the way util.MaskProxyURL does.
+func maskElectronResolvedProxy(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	entries := strings.Split(raw, ";")
+	for i, entry := range entries {
+		fields := strings.Fields(entry)
+		for j, field := range fields {
+			switch {
+			case strings.Contains(field, "://"):
+				fields[j] = util.MaskProxyURL(field)
+			case strings.Contains(field, "@"):
+				fields[j] = "****:****@" + field[strings.LastIndex(field, "@")+1:]
+			}
+		}
+		entries[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(entries, "; ")
+}
+
+// copilotTransportTelemetry collects the transport diagnostics of a Copilot response: the
+// serving transport and, for Electron, the shim telemetry. It returns nil when the
+// response carries none.
+func copilotTransportTelemetry(header http.Header) map[string]any {
+	out := make(map[string]any)
+	if transport := header.Get(CopilotTransportHeader); transport != "" {
+		out["transport"] = transport
+	}
+	for _, h := range electronTelemetryHeaders {
+		value := header.Get(h.header)
+		if value == "" {
+			continue
+		}
+		if h.numeric {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				out[h.field] = n
+			}
+			continue
+		}
+		out[h.field] = value
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// copilotTransportTelemetryHeaders returns the transport diagnostic headers of a Copilot
+// response, for passthrough to clients.
+func copilotTransportTelemetryHeaders(header http.Header) http.Header {
+	out := make(http.Header)
+	if transport := header.Get(CopilotTransportHeader); transport != "" {
+		out.Set(CopilotTransportHeader, transport)
+	}
+	for _, h := range electronTelemetryHeaders {
+		if value := header.Get(h.header); value != "" {
+			out.Set(h.header, value)
+		}
+	}
+	for name, values := range copilotElectronDebugHeaders(header) {
+		out[name] = values
+	}
+	return out
+}
+
+// copilotElectronDebugHeaders returns the COPILOT_ELECTRON_DEBUG_HEADERS headers of a
+// Copilot response, or nil when it has none.
+func copilotElectronDebugHeaders(header http.Header) http.Header {
+	var out http.Header
+	for _, name := range []string{copilotResolvedProxyHeader, copilotUpstreamHostHeader} {
+		if value := header.Get(name); value != "" {
+			if out == nil {
+				out = make(http.Header)
+			}
+			out.Set(name, value)
+		}
+	}
+	return out
+}
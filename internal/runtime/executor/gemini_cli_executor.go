@@ -136,6 +136,7 @@ func (e *GeminiCLIExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth
 	basePayload = fixGeminiCLIImageAspectRatio(baseModel, basePayload)
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	basePayload = applyPayloadConfigWithRoot(e.cfg, baseModel, "gemini", "request", basePayload, originalTranslated, requestedModel)
+	basePayload = applyPayloadMetadataMapping(e.cfg, baseModel, "gemini", "request", basePayload, req.Payload, requestedModel)
 
 	action := "generateContent"
 	if req.Metadata != nil {
@@ -291,6 +292,7 @@ func (e *GeminiCLIExecutor) ExecuteStream(ctx context.Context, auth *cliproxyaut
 	basePayload = fixGeminiCLIImageAspectRatio(baseModel, basePayload)
 	requestedModel := payloadRequestedModel(opts, req.Model)
 	basePayload = applyPayloadConfigWithRoot(e.cfg, baseModel, "gemini", "request", basePayload, originalTranslated, requestedModel)
+	basePayload = applyPayloadMetadataMapping(e.cfg, baseModel, "gemini", "request", basePayload, req.Payload, requestedModel)
 
 	projectID := resolveGeminiProjectID(auth)
 
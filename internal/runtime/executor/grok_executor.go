@@ -191,7 +191,7 @@ func (e *GrokExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req
 	}
 	appendAPIResponseChunk(ctx, e.cfg, data)
 	if storage != nil {
-		storage.FailedCount = 0
+		storage.ResetFailures()
 	}
 
 	finalPayload, err := e.extractFinalJSONLine(data)
@@ -296,7 +296,7 @@ func (e *GrokExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Aut
 		return nil, err
 	}
 	if storage != nil {
-		storage.FailedCount = 0
+		storage.ResetFailures()
 	}
 
 	out := make(chan cliproxyexecutor.StreamChunk)
@@ -366,7 +366,7 @@ func (e *GrokExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*c
 	}
 
 	if storage, ok := auth.Storage.(*grokauth.GrokTokenStorage); ok && storage != nil {
-		if storage.Status == "expired" {
+		if storage.Expired() {
 			return nil, statusErr{code: http.StatusUnauthorized, msg: "grok session expired - re-authenticate required"}
 		}
 	}
@@ -453,7 +453,7 @@ func (e *GrokExecutor) getGrokStorage(auth *cliproxyauth.Auth) (*grokauth.GrokTo
 	if !ok || storage == nil {
 		return nil, statusErr{code: http.StatusUnauthorized, msg: "grok executor: invalid storage type"}
 	}
-	if storage.Status == "expired" {
+	if storage.Expired() {
 		return nil, statusErr{code: http.StatusUnauthorized, msg: "grok executor: token expired"}
 	}
 	return storage, nil
@@ -562,13 +562,11 @@ func (e *GrokExecutor) handleError(statusCode int, body []byte, storage *grokaut
 	case http.StatusForbidden:
 		return statusErr{code: http.StatusForbidden, msg: "Cloudflare blocked request - change IP, configure cf_clearance, or use a proxy"}
 	case http.StatusUnauthorized:
+		failedCount := 0
 		if storage != nil {
-			storage.FailedCount++
-			if storage.FailedCount >= grokauth.MaxFailures {
-				storage.Status = "expired"
-			}
+			failedCount = storage.RecordAuthFailure()
 		}
-		log.Warnf("grok executor: authentication failed for token=%s (failed_count=%d)", maskedToken, storageFailedCount(storage))
+		log.Warnf("grok executor: authentication failed for token=%s (failed_count=%d)", maskedToken, failedCount)
 		return statusErr{code: http.StatusUnauthorized, msg: "Grok authentication failed - SSO token may be expired"}
 	case http.StatusTooManyRequests:
 		delay := 30 * time.Second
@@ -578,13 +576,6 @@ func (e *GrokExecutor) handleError(statusCode int, body []byte, storage *grokaut
 	}
 }
 
-func storageFailedCount(storage *grokauth.GrokTokenStorage) int {
-	if storage == nil {
-		return 0
-	}
-	return storage.FailedCount
-}
-
 func (e *GrokExecutor) extractFinalJSONLine(data []byte) ([]byte, error) {
 	if len(data) == 0 {
 		return data, nil
@@ -152,6 +152,53 @@ func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string
 	return out
 }
 
+// applyPayloadMetadataMapping copies values from the "metadata" object of the client
+// request clientPayload into payload fields, following the payload.metadata rules that
+// match the model and protocol. Target paths are relative to root. It runs after the
+// payload rules and never touches metadata it has no mapping for, nor fields already set.
+func applyPayloadMetadataMapping(cfg *config.Config, model, protocol, root string, payload, clientPayload []byte, requestedModel string) []byte {
+	if cfg == nil || len(cfg.Payload.Metadata) == 0 || len(payload) == 0 {
+		return payload
+	}
+	metadata := gjson.GetBytes(clientPayload, "metadata")
+	if !metadata.IsObject() {
+		return payload
+	}
+	candidates := payloadModelCandidates(model, requestedModel)
+	out := payload
+	for i := range cfg.Payload.Metadata {
+		rule := &cfg.Payload.Metadata[i]
+		if !payloadModelRulesMatch(rule.Models, protocol, candidates) {
+			continue
+		}
+		for key, target := range rule.Map {
+			key = strings.TrimSpace(key)
+			fullPath := buildPayloadPath(root, target)
+			if key == "" || fullPath == "" {
+				continue
+			}
+			value := metadata.Get(key)
+			if !value.Exists() || gjson.GetBytes(out, fullPath).Exists() {
+				continue
+			}
+			var (
+				updated []byte
+				errSet  error
+			)
+			if value.Type == gjson.String {
+				updated, errSet = sjson.SetBytes(out, fullPath, value.String())
+			} else {
+				updated, errSet = sjson.SetRawBytes(out, fullPath, []byte(value.Raw))
+			}
+			if errSet != nil {
+				continue
+			}
+			out = updated
+		}
+	}
+	return out
+}
+
 func payloadModelRulesMatch(rules []config.PayloadModelRule, protocol string, models []string) bool {
 	if len(rules) == 0 || len(models) == 0 {
 		return false
@@ -0,0 +1,46 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyPayloadMetadataMapping(t *testing.T) {
+	cfg := &config.Config{Payload: config.PayloadConfig{Metadata: []config.PayloadMetadataRule{{
+		Models: []config.PayloadModelRule{{Name: "gpt-*", Protocol: "openai"}},
+		Map:    map[string]string{"session": "safety_identifier", "tags": "extra.tags", "end_user": "user"},
+	}}}}
+	client := []byte(`{"metadata":{"session":"sess-42","tags":["a","b"],"end_user":"u1","trace":"t-9"}}`)
+	payload := []byte(`{"model":"gpt-4o","user":"explicit"}`)
+
+	out := applyPayloadMetadataMapping(cfg, "gpt-4o", "openai", "", payload, client, "gpt-4o")
+	if got := gjson.GetBytes(out, "safety_identifier").String(); got != "sess-42" {
+		t.Fatalf("safety_identifier = %q, want sess-42", got)
+	}
+	if got := gjson.GetBytes(out, "extra.tags").Raw; got != `["a","b"]` {
+		t.Fatalf("extra.tags = %s, want the raw array", got)
+	}
+	if got := gjson.GetBytes(out, "user").String(); got != "explicit" {
+		t.Fatalf("user = %q, mapping must not overwrite a field the payload sets", got)
+	}
+	if gjson.GetBytes(out, "trace").Exists() || gjson.GetBytes(out, "metadata").Exists() {
+		t.Fatalf("unmapped metadata leaked into the payload: %s", out)
+	}
+
+	if got := applyPayloadMetadataMapping(cfg, "gpt-4o", "claude", "", payload, client, "gpt-4o"); string(got) != string(payload) {
+		t.Fatalf("rule for protocol openai applied to claude: %s", got)
+	}
+	if got := applyPayloadMetadataMapping(cfg, "gemini-2.5-pro", "openai", "", payload, client, ""); string(got) != string(payload) {
+		t.Fatalf("rule for gpt-* applied to gemini: %s", got)
+	}
+
+	rooted := applyPayloadMetadataMapping(&config.Config{Payload: config.PayloadConfig{Metadata: []config.PayloadMetadataRule{{
+		Models: []config.PayloadModelRule{{Name: "gemini-*"}},
+		Map:    map[string]string{"session": "labels.session"},
+	}}}}, "gemini-2.5-pro", "gemini", "request", []byte(`{"request":{}}`), client, "")
+	if got := gjson.GetBytes(rooted, "request.labels.session").String(); got != "sess-42" {
+		t.Fatalf("request.labels.session = %q, want sess-42", got)
+	}
+}
@@ -2,6 +2,8 @@ package executor
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -11,7 +13,12 @@ import (
 	"sync"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/clockskew"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/connretry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/dnsretry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/faultinject"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/timing"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/proxy"
@@ -47,6 +54,18 @@ func noProxyEnvRaw() string {
 	return strings.TrimSpace(os.Getenv("no_proxy"))
 }
 
+// resolveNoProxy combines the configured no-proxy list with the NO_PROXY environment variable.
+func resolveNoProxy(cfg *config.Config) string {
+	env := noProxyEnvRaw()
+	if cfg == nil || strings.TrimSpace(cfg.NoProxy) == "" {
+		return env
+	}
+	if env == "" {
+		return strings.TrimSpace(cfg.NoProxy)
+	}
+	return strings.TrimSpace(cfg.NoProxy) + "," + env
+}
+
 func parseNoProxyList(raw string) []string {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
@@ -121,6 +140,28 @@ func logProxyOnce(key, msg string, args ...any) {
 // Returns:
 //   - *http.Client: An HTTP client with configured proxy or transport
 func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration, service string) *http.Client {
+	client := proxyAwareHTTPClient(ctx, cfg, auth, timeout, service)
+	provider := service
+	if auth != nil && strings.TrimSpace(auth.Provider) != "" {
+		provider = auth.Provider
+	}
+	// Wrap a copy so the cached client's transport stays untouched; connretry closes idle
+	// connections on that shared transport before retrying a torn-down request.
+	base := client.Transport
+	if cfg != nil && cfg.UnsafeFaultInjection.Active() {
+		// Chaos testing only: faults are injected below the retry and timing layers.
+		base = faultinject.NewTransport(base, cfg.UnsafeFaultInjection, provider)
+	}
+	transport := http.RoundTripper(clockskew.NewTransport(connretry.NewTransport(base, provider), provider))
+	if timing.FromContext(ctx) != nil {
+		// Record per-phase timings.
+		transport = timing.NewTransport(transport)
+	}
+	return &http.Client{Transport: transport, Timeout: client.Timeout}
+}
+
+// proxyAwareHTTPClient resolves the proxy-aware (and possibly cached) client for newProxyAwareHTTPClient.
+func proxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration, service string) *http.Client {
 	// Priority 1: Use auth.ProxyURL if configured
 	var proxyURL string
 	proxySource := ""
@@ -151,7 +192,7 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 	noProxyRaw := ""
 	noProxyList := []string(nil)
 	if proxyURL != "" {
-		noProxyRaw = noProxyEnvRaw()
+		noProxyRaw = resolveNoProxy(cfg)
 		noProxyList = parseNoProxyList(noProxyRaw)
 	}
 
@@ -160,6 +201,48 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 	if proxyURL != "" && noProxyRaw != "" {
 		cacheKey = proxyURL + "|no_proxy=" + strings.ToLower(noProxyRaw)
 	}
+	minTLS := cfg.UpstreamTLSMinVersion()
+	if minTLS != tls.VersionTLS12 {
+		cacheKey += fmt.Sprintf("|tls_min=%#x", minTLS)
+	}
+	if attempts, backoff := cfg.DNSRetrySettings(); attempts != config.DefaultDNSRetryAttempts || backoff != config.DefaultDNSRetryBackoff {
+		cacheKey += fmt.Sprintf("|dns=%d:%s", attempts, backoff)
+	}
+	// A connection cap needs a transport of its own per provider so its pool is bounded.
+	connsProvider, maxConns := maxConnsPerHostFor(cfg, auth, service)
+	if maxConns > 0 {
+		cacheKey += fmt.Sprintf("|conns=%s:%d", connsProvider, maxConns)
+	}
+
+	// An upstream-tls override is explicit configuration for the provider and needs its own
+	// transport, so it takes precedence over a RoundTripper from context.
+	if tlsProvider, tlsSettings, ok := upstreamTLSFor(cfg, auth, service); ok {
+		cacheKey += upstreamTLSCacheKey(tlsProvider, tlsSettings)
+		httpClientCacheMutex.Lock()
+		cachedClient, found := httpClientCache[cacheKey]
+		if !found {
+			transport := upstreamTLSTransport(tlsProvider, tlsSettings, minTLS, proxyURL, noProxyList, service)
+			if proxyURL == "" {
+				transport = withDNSRetry(transport, cfg)
+			}
+			cachedClient = &http.Client{Transport: withMaxConnsPerHost(transport, maxConns)}
+			httpClientCache[cacheKey] = cachedClient
+		}
+		httpClientCacheMutex.Unlock()
+		if timeout > 0 {
+			return &http.Client{Transport: cachedClient.Transport, Timeout: timeout}
+		}
+		return cachedClient
+	}
+
+	// Without a proxy, a RoundTripper from context (typically from RoundTripperFor) is
+	// request/auth-specific and must win over the cached default-transport client, unless
+	// the connections have to be capped on a transport built here.
+	if proxyURL == "" && maxConns == 0 {
+		if rt, ok := ctx.Value("cliproxy.roundtripper").(http.RoundTripper); ok && rt != nil {
+			return &http.Client{Transport: rt, Timeout: timeout}
+		}
+	}
 
 	// Check cache first
 	httpClientCacheMutex.RLock()
@@ -182,7 +265,7 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 
 	// If we have a proxy URL configured, set up the transport
 	if proxyURL != "" {
-		transport := buildProxyTransport(proxyURL, noProxyList, service)
+		transport := withMaxConnsPerHost(withTLSMinVersion(buildProxyTransport(proxyURL, noProxyList, service), minTLS), maxConns)
 		if transport != nil {
 			httpClient.Transport = transport
 			// Cache the base client (Timeout=0) for connection reuse.
@@ -207,13 +290,14 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 	}
 
 	// Priority 3: Use RoundTripper from context (typically from RoundTripperFor)
-	if rt, ok := ctx.Value("cliproxy.roundtripper").(http.RoundTripper); ok && rt != nil {
+	if rt, ok := ctx.Value("cliproxy.roundtripper").(http.RoundTripper); ok && rt != nil && maxConns == 0 {
 		httpClient.Transport = rt
 	}
 
 	// Cache the client for the true no-proxy/default-transport case only.
 	// If Transport came from context, it may be request/auth-specific and should not be shared.
 	if proxyURL == "" && httpClient.Transport == nil {
+		httpClient.Transport = withMaxConnsPerHost(withDNSRetry(withTLSMinVersion(http.DefaultTransport.(*http.Transport).Clone(), minTLS), cfg), maxConns)
 		httpClientCacheMutex.Lock()
 		httpClientCache[cacheKey] = httpClient
 		httpClientCacheMutex.Unlock()
@@ -225,6 +309,42 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 	return httpClient
 }
 
+// withDNSRetry makes a direct transport retry failed DNS lookups as configured by
+// dns-retry. Proxied transports resolve at the proxy and are left alone.
+func withDNSRetry(transport *http.Transport, cfg *config.Config) *http.Transport {
+	attempts, backoff := cfg.DNSRetrySettings()
+	if transport == nil || attempts <= 1 {
+		return transport
+	}
+	// Same dial timeouts as http.DefaultTransport.
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport.DialContext = dnsretry.New(dialer, attempts, backoff).DialContext
+	return transport
+}
+
+// maxConnsPerHostFor returns the max-conns-per-host entry for the auth's provider, falling
+// back to the logical service name.
+func maxConnsPerHostFor(cfg *config.Config, auth *cliproxyauth.Auth, service string) (string, int) {
+	if auth != nil {
+		if limit := cfg.MaxConnsPerHostFor(auth.Provider); limit > 0 {
+			return strings.ToLower(strings.TrimSpace(auth.Provider)), limit
+		}
+	}
+	if limit := cfg.MaxConnsPerHostFor(service); limit > 0 {
+		return strings.ToLower(strings.TrimSpace(service)), limit
+	}
+	return "", 0
+}
+
+// withMaxConnsPerHost caps the connections per host of a transport built for upstream
+// requests; 0 leaves it uncapped.
+func withMaxConnsPerHost(transport *http.Transport, maxConns int) *http.Transport {
+	if transport != nil && maxConns > 0 {
+		transport.MaxConnsPerHost = maxConns
+	}
+	return transport
+}
+
 // buildProxyTransport creates an HTTP transport configured for the given proxy URL.
 // It supports SOCKS5, HTTP, and HTTPS proxy protocols.
 //
@@ -264,6 +384,7 @@ func buildProxyTransport(proxyURL string, noProxyList []string, service string)
 		}
 		// Set up a custom transport using the SOCKS5 dialer
 		direct := &net.Dialer{}
+		maskedProxy := maskProxyURL(proxyURL)
 		transport = &http.Transport{
 			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
 				// addr is host:port; apply NO_PROXY at dial time.
@@ -276,7 +397,19 @@ func buildProxyTransport(proxyURL string, noProxyList []string, service string)
 					)
 					return direct.DialContext(ctx, network, addr)
 				}
-				return dialer.Dial(network, addr)
+				var conn net.Conn
+				var errDial error
+				if contextDialer, ok := dialer.(proxy.ContextDialer); ok {
+					conn, errDial = contextDialer.DialContext(ctx, network, addr)
+				} else {
+					conn, errDial = dialer.Dial(network, addr)
+				}
+				if errDial != nil {
+					wrapped := &socksDialError{Proxy: maskedProxy, Target: addr, Reason: classifySOCKSDialError(errDial), Err: errDial}
+					logWithRequestID(ctx).Debugf("proxy: service=%s %v", service, wrapped)
+					return nil, wrapped
+				}
+				return conn, nil
 			},
 		}
 	} else if parsedURL.Scheme == "http" || parsedURL.Scheme == "https" {
@@ -305,3 +438,56 @@ func buildProxyTransport(proxyURL string, noProxyList []string, service string)
 
 	return transport
 }
+
+// socksDialError describes a failed dial through a SOCKS5 proxy. Proxy is masked so the
+// error can be logged and returned to callers without leaking credentials.
+type socksDialError struct {
+	Proxy  string
+	Target string
+	Reason string
+	Err    error
+}
+
+func (e *socksDialError) Error() string {
+	return fmt.Sprintf("socks5 dial to %s via proxy %s failed (%s): %v", e.Target, e.Proxy, e.Reason, e.Err)
+}
+
+func (e *socksDialError) Unwrap() error { return e.Err }
+
+// classifySOCKSDialError distinguishes where a SOCKS5 dial failed, using the SOCKS reply
+// code when the proxy returned one.
+func classifySOCKSDialError(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return "canceled"
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		// The SOCKS dialer wraps a failed connection to the proxy itself in its own OpError.
+		var proxyDialErr *net.OpError
+		if errors.As(opErr.Err, &proxyDialErr) && proxyDialErr.Op == "dial" {
+			return "proxy unreachable"
+		}
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "authentication failed"),
+		strings.Contains(msg, "no acceptable authentication methods"),
+		strings.Contains(msg, "invalid username/password"):
+		return "proxy authentication failed"
+	case strings.Contains(msg, "connection not allowed by ruleset"):
+		return "rejected by proxy ruleset"
+	case strings.Contains(msg, "host unreachable"),
+		strings.Contains(msg, "network unreachable"),
+		strings.Contains(msg, "connection refused"),
+		strings.Contains(msg, "ttl expired"):
+		return "target unreachable"
+	case strings.Contains(msg, "general socks server failure"):
+		return "proxy server failure"
+	case strings.Contains(msg, "timeout"):
+		return "timeout"
+	}
+	return "dial failed"
+}
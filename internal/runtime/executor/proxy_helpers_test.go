@@ -2,11 +2,17 @@ package executor
 
 import (
 	"context"
+	"errors"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 )
 
@@ -62,3 +68,170 @@ func TestNewProxyAwareHTTPClient_DoesNotCacheTimeout_WithProxy(t *testing.T) {
 		t.Fatalf("expected cached Timeout=0, got %v", cached.Timeout)
 	}
 }
+
+// serveFakeSOCKS5 accepts one connection and answers the SOCKS5 handshake: it fails
+// username/password auth when rejectAuth is set, otherwise replies to CONNECT with reply.
+func serveFakeSOCKS5(t *testing.T, rejectAuth bool, reply byte) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		conn, errAccept := ln.Accept()
+		if errAccept != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		buf := make([]byte, 512)
+		// Greeting: VER NMETHODS METHODS...
+		if _, errRead := io.ReadFull(conn, buf[:2]); errRead != nil {
+			return
+		}
+		if _, errRead := io.ReadFull(conn, buf[:buf[1]]); errRead != nil {
+			return
+		}
+		_, _ = conn.Write([]byte{0x05, 0x02})
+		// Username/password: VER ULEN UNAME PLEN PASSWD
+		if _, errRead := io.ReadFull(conn, buf[:2]); errRead != nil {
+			return
+		}
+		userLen := int(buf[1])
+		if _, errRead := io.ReadFull(conn, buf[:userLen+1]); errRead != nil {
+			return
+		}
+		if _, errRead := io.ReadFull(conn, buf[:buf[userLen]]); errRead != nil {
+			return
+		}
+		if rejectAuth {
+			_, _ = conn.Write([]byte{0x01, 0x01})
+			return
+		}
+		_, _ = conn.Write([]byte{0x01, 0x00})
+		// CONNECT request: VER CMD RSV ATYP(0x03) LEN HOST PORT
+		if _, errRead := io.ReadFull(conn, buf[:5]); errRead != nil {
+			return
+		}
+		if _, errRead := io.ReadFull(conn, buf[:int(buf[4])+2]); errRead != nil {
+			return
+		}
+		_, _ = conn.Write([]byte{0x05, reply, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+	}()
+	return ln.Addr().String()
+}
+
+func TestBuildProxyTransport_WrapsSOCKS5DialErrors(t *testing.T) {
+	const target = "api.example.com:443"
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	closedAddr := closed.Addr().String()
+	_ = closed.Close()
+
+	tests := []struct {
+		name       string
+		proxyAddr  string
+		wantReason string
+	}{
+		{name: "auth rejected", proxyAddr: serveFakeSOCKS5(t, true, 0), wantReason: "proxy authentication failed"},
+		{name: "host unreachable", proxyAddr: serveFakeSOCKS5(t, false, 0x04), wantReason: "target unreachable"},
+		{name: "ruleset", proxyAddr: serveFakeSOCKS5(t, false, 0x02), wantReason: "rejected by proxy ruleset"},
+		{name: "proxy down", proxyAddr: closedAddr, wantReason: "proxy unreachable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport := buildProxyTransport("socks5://alice:s3cret@"+tt.proxyAddr, nil, "test")
+			if transport == nil {
+				t.Fatal("expected transport")
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_, errDial := transport.DialContext(ctx, "tcp", target)
+			if errDial == nil {
+				t.Fatal("expected dial error")
+			}
+			msg := errDial.Error()
+			if strings.Contains(msg, "s3cret") || strings.Contains(msg, "alice") {
+				t.Fatalf("error leaks proxy credentials: %s", msg)
+			}
+			wantProxy := "socks5://%2A%2A%2A%2A:%2A%2A%2A%2A@" + tt.proxyAddr
+			if !strings.Contains(msg, wantProxy) || !strings.Contains(msg, target) || !strings.Contains(msg, tt.wantReason) {
+				t.Fatalf("error = %q, want masked proxy %q, target %q and reason %q", msg, wantProxy, target, tt.wantReason)
+			}
+			var dialErr *socksDialError
+			if !errors.As(errDial, &dialErr) || dialErr.Reason != tt.wantReason {
+				t.Fatalf("error %T does not unwrap to socksDialError with reason %q", errDial, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestNewProxyAwareHTTPClient_MaxConnsPerHost(t *testing.T) {
+	resetProxyHTTPClientCacheForTest()
+	t.Cleanup(resetProxyHTTPClientCacheForTest)
+
+	var mu sync.Mutex
+	inFlight, maxInFlight, conns := 0, 0, 0
+	arrived := make(chan struct{}, 3)
+	release := make(chan struct{})
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		arrived <- struct{}{}
+		<-release
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		_, _ = io.WriteString(w, "ok")
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	var releaseOnce sync.Once
+	releaseAll := func() { releaseOnce.Do(func() { close(release) }) }
+	t.Cleanup(releaseAll)
+
+	cfg := &config.Config{MaxConnsPerHost: map[string]int{"capped": 2}}
+	auth := &cliproxyauth.Auth{Provider: "capped"}
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := newProxyAwareHTTPClient(context.Background(), cfg, auth, 0, "capped").Get(srv.URL)
+			if err != nil {
+				t.Errorf("request: %v", err)
+				return
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}()
+	}
+
+	<-arrived
+	<-arrived
+	select {
+	case <-arrived:
+		t.Fatal("third request reached the upstream while both capped connections were busy")
+	case <-time.After(150 * time.Millisecond):
+	}
+	releaseAll()
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxInFlight != 2 || conns != 2 {
+		t.Fatalf("max concurrent requests = %d over %d connections, want 2 and 2", maxInFlight, conns)
+	}
+}
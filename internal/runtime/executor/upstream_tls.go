@@ -0,0 +1,95 @@
+package executor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+var upstreamTLSWarnOnce sync.Map
+
+// upstreamTLSFor returns the upstream-tls entry for the auth's provider, falling back to
+// the logical service name.
+func upstreamTLSFor(cfg *config.Config, auth *cliproxyauth.Auth, service string) (string, config.UpstreamTLS, bool) {
+	if cfg == nil || len(cfg.UpstreamTLS) == 0 {
+		return "", config.UpstreamTLS{}, false
+	}
+	if auth != nil {
+		if settings, ok := cfg.UpstreamTLSFor(auth.Provider); ok {
+			return strings.ToLower(strings.TrimSpace(auth.Provider)), settings, true
+		}
+	}
+	if settings, ok := cfg.UpstreamTLSFor(service); ok {
+		return strings.ToLower(strings.TrimSpace(service)), settings, true
+	}
+	return "", config.UpstreamTLS{}, false
+}
+
+// upstreamTLSCacheKey distinguishes clients built with a TLS override in httpClientCache.
+func upstreamTLSCacheKey(provider string, settings config.UpstreamTLS) string {
+	return fmt.Sprintf("|tls=%s:%t:%s", provider, settings.InsecureSkipVerify, settings.CAFile)
+}
+
+// upstreamTLSClientConfig builds the TLS client config for a provider's override. A CA
+// file that cannot be loaded is logged and leaves the system roots in place, so requests
+// keep failing verification rather than silently trusting the gateway.
+func upstreamTLSClientConfig(provider string, settings config.UpstreamTLS, minVersion uint16) *tls.Config {
+	tlsConfig := &tls.Config{MinVersion: minVersion}
+	if settings.CAFile != "" {
+		roots, err := x509.SystemCertPool()
+		if err != nil || roots == nil {
+			roots = x509.NewCertPool()
+		}
+		pem, errRead := os.ReadFile(settings.CAFile)
+		switch {
+		case errRead != nil:
+			log.Errorf("upstream-tls: provider=%s cannot read tls-ca-file %s: %v", provider, settings.CAFile, errRead)
+		case !roots.AppendCertsFromPEM(pem):
+			log.Errorf("upstream-tls: provider=%s tls-ca-file %s contains no PEM certificates", provider, settings.CAFile)
+		default:
+			tlsConfig.RootCAs = roots
+		}
+	}
+	if settings.InsecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+		if _, loaded := upstreamTLSWarnOnce.LoadOrStore(provider, struct{}{}); !loaded {
+			log.Warnf("upstream-tls: provider=%s TLS CERTIFICATE VERIFICATION IS DISABLED; upstream connections can be intercepted", provider)
+		}
+	}
+	return tlsConfig
+}
+
+// upstreamTLSTransport returns the transport for a provider with a TLS override, based on
+// the proxy transport when a proxy applies.
+func upstreamTLSTransport(provider string, settings config.UpstreamTLS, minVersion uint16, proxyURL string, noProxyList []string, service string) *http.Transport {
+	var transport *http.Transport
+	if proxyURL != "" {
+		transport = buildProxyTransport(proxyURL, noProxyList, service)
+	}
+	if transport == nil {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	transport.TLSClientConfig = upstreamTLSClientConfig(provider, settings, minVersion)
+	return transport
+}
+
+// withTLSMinVersion sets the tls-min-version floor on a transport built for upstream
+// requests.
+func withTLSMinVersion(transport *http.Transport, minVersion uint16) *http.Transport {
+	if transport == nil {
+		return nil
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.MinVersion = minVersion
+	return transport
+}
@@ -0,0 +1,101 @@
+package executor
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestNewProxyAwareHTTPClient_UpstreamTLS(t *testing.T) {
+	resetProxyHTTPClientCacheForTest()
+	t.Cleanup(resetProxyHTTPClientCacheForTest)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "gateway-ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatalf("write CA: %v", err)
+	}
+	cfg := &config.Config{UpstreamTLS: map[string]config.UpstreamTLS{
+		"gateway":  {CAFile: caFile},
+		"insecure": {InsecureSkipVerify: true},
+	}}
+	cfg.SanitizeUpstreamTLS()
+
+	get := func(provider string) error {
+		client := newProxyAwareHTTPClient(context.Background(), cfg, &cliproxyauth.Auth{Provider: provider}, 0, provider)
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("provider %s: status %d", provider, resp.StatusCode)
+		}
+		return nil
+	}
+
+	if err := get("gateway"); err != nil {
+		t.Fatalf("provider trusting the custom CA: %v", err)
+	}
+	if err := get("insecure"); err != nil {
+		t.Fatalf("provider skipping verification: %v", err)
+	}
+	if err := get("codex"); err == nil {
+		t.Fatal("provider without an override accepted the self-signed certificate")
+	}
+}
+
+func TestNewProxyAwareHTTPClient_TLSMinVersion(t *testing.T) {
+	resetProxyHTTPClientCacheForTest()
+	t.Cleanup(resetProxyHTTPClientCacheForTest)
+
+	for _, tc := range []struct {
+		setting string
+		want    uint16
+	}{
+		{"", tls.VersionTLS12},
+		{"1.3", tls.VersionTLS13},
+	} {
+		cfg := &config.Config{TLSMinVersion: tc.setting}
+		cfg.SanitizeTLSMinVersion()
+		transport, ok := proxyAwareHTTPClient(context.Background(), cfg, nil, 0, "codex").Transport.(*http.Transport)
+		if !ok || transport.TLSClientConfig == nil {
+			t.Fatalf("tls-min-version %q: transport %#v has no TLS config", tc.setting, transport)
+		}
+		if transport.TLSClientConfig.MinVersion != tc.want {
+			t.Fatalf("tls-min-version %q: MinVersion = %#x, want %#x", tc.setting, transport.TLSClientConfig.MinVersion, tc.want)
+		}
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	srv.TLS = &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: tls.VersionTLS10}
+	srv.StartTLS()
+	defer srv.Close()
+
+	// Skip verification so only the protocol version can fail the handshake.
+	cfg := &config.Config{UpstreamTLS: map[string]config.UpstreamTLS{"legacy": {InsecureSkipVerify: true}}}
+	client := newProxyAwareHTTPClient(context.Background(), cfg, &cliproxyauth.Auth{Provider: "legacy"}, 0, "legacy")
+	resp, err := client.Get(srv.URL)
+	if err == nil {
+		_ = resp.Body.Close()
+		t.Fatal("client negotiated TLS 1.0 with a TLS-1.0-only server")
+	}
+	if !strings.Contains(err.Error(), "protocol version") {
+		t.Fatalf("error = %v, want a protocol version failure", err)
+	}
+}
@@ -73,7 +73,7 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 		return
 	}
 	r.once.Do(func() {
-		usage.PublishRecord(ctx, usage.Record{
+		record := usage.Record{
 			Provider:    r.provider,
 			Model:       r.model,
 			Source:      r.source,
@@ -83,10 +83,34 @@ func (r *usageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 			RequestedAt: r.requestedAt,
 			Failed:      failed,
 			Detail:      detail,
-		})
+		}
+		if !failed {
+			rememberUsageRecord(ctx, record)
+		}
+		usage.PublishRecord(ctx, record)
 	})
 }
 
+// usageRecordsMu serializes appends to the per-request record list; fan-out requests
+// publish from several goroutines at once.
+var usageRecordsMu sync.Mutex
+
+// rememberUsageRecord appends record to the usage records of the client request in ctx.
+func rememberUsageRecord(ctx context.Context, record usage.Record) {
+	if ctx == nil {
+		return
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return
+	}
+	usageRecordsMu.Lock()
+	defer usageRecordsMu.Unlock()
+	existing, _ := ginCtx.Get(usage.ContextRecordsKey)
+	records, _ := existing.([]usage.Record)
+	ginCtx.Set(usage.ContextRecordsKey, append(records, record))
+}
+
 // ensurePublished guarantees that a usage record is emitted exactly once.
 // It is safe to call multiple times; only the first call wins due to once.Do.
 // This is used to ensure request counting even when upstream responses do not
@@ -1,6 +1,13 @@
 package executor
 
-import "testing"
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
 
 func TestParseOpenAIUsageChatCompletions(t *testing.T) {
 	data := []byte(`{"usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3,"prompt_tokens_details":{"cached_tokens":4},"completion_tokens_details":{"reasoning_tokens":5}}}`)
@@ -41,3 +48,21 @@ func TestParseOpenAIUsageResponses(t *testing.T) {
 		t.Fatalf("reasoning tokens = %d, want %d", detail.ReasoningTokens, 9)
 	}
 }
+
+func TestUsageReporterAttachesRecordToRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	newUsageReporter(ctx, "codex", "gpt-5", nil).publish(ctx, usage.Detail{InputTokens: 10, OutputTokens: 2})
+	newUsageReporter(ctx, "codex", "gpt-5", nil).publishFailure(ctx)
+
+	value, _ := ginCtx.Get(usage.ContextRecordsKey)
+	records, _ := value.([]usage.Record)
+	if len(records) != 1 {
+		t.Fatalf("records = %+v, want only the successful one", records)
+	}
+	if got := records[0]; got.Provider != "codex" || got.Model != "gpt-5" || got.Detail.TotalTokens != 12 {
+		t.Fatalf("record = %+v", got)
+	}
+}
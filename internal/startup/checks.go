@@ -0,0 +1,310 @@
+package startup
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Input is the state the startup checks inspect.
+type Input struct {
+	Host string
+	Port int
+	TLS  bool
+	// PprofAddr is the pprof listener address, empty when pprof is disabled.
+	PprofAddr string
+
+	Debug bool
+	// Production is set when the process runs on a hosting platform (see LooksLikeProduction).
+	Production bool
+
+	AuthDir string
+	// Auths counts the loaded credentials by provider.
+	Auths map[string]int
+	// ConfiguredProviders are providers the configuration refers to explicitly, e.g. in
+	// oauth-model-alias or oauth-excluded-models.
+	ConfiguredProviders []string
+
+	APIKeys []string
+	// ManagementSecret is remote-management.secret-key, usually a bcrypt hash.
+	ManagementSecret string
+	// ManagementPassword is the MANAGEMENT_PASSWORD environment override.
+	ManagementPassword string
+
+	ProxyURL string
+	NoProxy  string
+	// InsecureTLSProviders have upstream certificate verification disabled.
+	InsecureTLSProviders []string
+	Transports           []Transport
+	// FaultInjection describes the active unsafe-fault-injection settings, empty when off.
+	FaultInjection string
+}
+
+// Check is a named startup check.
+type Check struct {
+	Name string
+	Run  func(Input) []Warning
+}
+
+// Checks are the checks run by Build, in report order.
+var Checks = []Check{
+	{"auths", CheckAuthsLoaded},
+	{"provider-auths", CheckConfiguredProviderAuths},
+	{"auth-dir", CheckAuthDirWritable},
+	{"proxy-url", CheckProxyURL},
+	{"upstream-tls", CheckInsecureUpstreamTLS},
+	{"management-key", CheckManagementKeyReuse},
+	{"debug", CheckDebugInProduction},
+	{"fault-injection", CheckFaultInjection},
+}
+
+// productionEnvVars are set by hosting platforms the proxy is commonly deployed to.
+var productionEnvVars = []string{"RAILWAY_ENVIRONMENT", "KUBERNETES_SERVICE_HOST", "FLY_APP_NAME", "RENDER", "DYNO"}
+
+// LooksLikeProduction reports whether the environment carries a hosting platform marker.
+func LooksLikeProduction() bool {
+	for _, key := range productionEnvVars {
+		if strings.TrimSpace(os.Getenv(key)) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Build runs all checks and assembles the summary.
+func Build(in Input) *Summary {
+	scheme := "http"
+	if in.TLS {
+		scheme = "https"
+	}
+	host := in.Host
+	if host == "" {
+		host = "0.0.0.0"
+	}
+	summary := &Summary{
+		GeneratedAt: time.Now().UTC(),
+		Listeners:   []string{fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, fmt.Sprint(in.Port)))},
+		Auths:       make(map[string]int, len(in.Auths)),
+		Transports:  in.Transports,
+		Proxy:       MaskProxyURL(in.ProxyURL),
+		NoProxy:     strings.TrimSpace(in.NoProxy),
+		Warnings:    []Warning{},
+	}
+	if in.PprofAddr != "" {
+		summary.Listeners = append(summary.Listeners, "pprof http://"+in.PprofAddr)
+	}
+	for provider, count := range in.Auths {
+		summary.Auths[provider] = count
+	}
+	for _, check := range Checks {
+		summary.Warnings = append(summary.Warnings, check.Run(in)...)
+	}
+	return summary
+}
+
+// CheckAuthsLoaded warns when no credentials at all were loaded.
+func CheckAuthsLoaded(in Input) []Warning {
+	for _, count := range in.Auths {
+		if count > 0 {
+			return nil
+		}
+	}
+	return []Warning{{
+		Check:   "auths",
+		Message: "no credentials are loaded; every request will fail",
+		Hint:    fmt.Sprintf("log in with one of the -login flags or copy auth files into %s, or configure provider API keys", displayDir(in.AuthDir)),
+	}}
+}
+
+// CheckConfiguredProviderAuths warns about providers the configuration refers to that
+// have no credential loaded.
+func CheckConfiguredProviderAuths(in Input) []Warning {
+	seen := make(map[string]bool, len(in.ConfiguredProviders))
+	var providers []string
+	for _, provider := range in.ConfiguredProviders {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if provider == "" || seen[provider] {
+			continue
+		}
+		seen[provider] = true
+		if in.Auths[provider] == 0 {
+			providers = append(providers, provider)
+		}
+	}
+	sort.Strings(providers)
+	warnings := make([]Warning, 0, len(providers))
+	for _, provider := range providers {
+		warnings = append(warnings, Warning{
+			Check:   "provider-auths",
+			Message: fmt.Sprintf("provider %q is configured but has no credentials loaded", provider),
+			Hint:    fmt.Sprintf("add a %s auth file to %s or remove the %s entries from the config", provider, displayDir(in.AuthDir), provider),
+		})
+	}
+	return warnings
+}
+
+// CheckAuthDirWritable warns when new credentials cannot be saved to the auth directory.
+func CheckAuthDirWritable(in Input) []Warning {
+	dir := strings.TrimSpace(in.AuthDir)
+	if dir == "" {
+		return []Warning{{Check: "auth-dir", Message: "auth-dir is not set", Hint: "set auth-dir in the config file"}}
+	}
+	probe, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		return []Warning{{
+			Check:   "auth-dir",
+			Message: fmt.Sprintf("auth directory %s is not writable: %v", dir, err),
+			Hint:    "fix the directory ownership/permissions or point auth-dir at a writable volume; logins and token refreshes cannot be saved",
+		}}
+	}
+	name := probe.Name()
+	_ = probe.Close()
+	_ = os.Remove(name)
+	return nil
+}
+
+// CheckProxyURL warns about proxy-url values the outbound transports cannot use.
+func CheckProxyURL(in Input) []Warning {
+	raw := strings.TrimSpace(in.ProxyURL)
+	if raw == "" {
+		return nil
+	}
+	warn := func(message string) []Warning {
+		return []Warning{{
+			Check:   "proxy-url",
+			Message: message,
+			Hint:    "use scheme://[user:pass@]host:port with scheme http, https, socks5 or socks5h",
+		}}
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return warn(fmt.Sprintf("proxy-url %s does not parse", MaskProxyURL(raw)))
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https", "socks5", "socks5h":
+	case "":
+		return warn(fmt.Sprintf("proxy-url %s has no scheme", parsed.Redacted()))
+	default:
+		return warn(fmt.Sprintf("proxy-url %s uses unsupported scheme %q", parsed.Redacted(), parsed.Scheme))
+	}
+	if parsed.Hostname() == "" {
+		return warn(fmt.Sprintf("proxy-url %s has no host", parsed.Redacted()))
+	}
+	return nil
+}
+
+// CheckInsecureUpstreamTLS warns about each provider with certificate verification off.
+func CheckInsecureUpstreamTLS(in Input) []Warning {
+	warnings := make([]Warning, 0, len(in.InsecureTLSProviders))
+	for _, provider := range in.InsecureTLSProviders {
+		warnings = append(warnings, Warning{
+			Check:   "upstream-tls",
+			Message: fmt.Sprintf("TLS certificate verification is disabled for provider %q", provider),
+			Hint:    "trust the gateway certificate with tls-ca-file instead of tls-insecure-skip-verify",
+		})
+	}
+	return warnings
+}
+
+// CheckManagementKeyReuse warns when a client API key also unlocks the management API.
+func CheckManagementKeyReuse(in Input) []Warning {
+	secret := strings.TrimSpace(in.ManagementSecret)
+	password := strings.TrimSpace(in.ManagementPassword)
+	if secret == "" && password == "" {
+		return nil
+	}
+	for _, key := range in.APIKeys {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if key == password || matchesSecret(secret, key) {
+			return []Warning{{
+				Check:   "management-key",
+				Message: "the management key equals one of the client api-keys",
+				Hint:    "use a separate remote-management.secret-key so API clients cannot change the configuration",
+			}}
+		}
+	}
+	return nil
+}
+
+// CheckDebugInProduction warns when debug logging is on for a publicly reachable or
+// hosted instance; debug logs include request details.
+func CheckDebugInProduction(in Input) []Warning {
+	if !in.Debug || (!in.Production && isLoopback(in.Host)) {
+		return nil
+	}
+	where := "on a non-loopback address"
+	if in.Production {
+		where = "on a hosting platform"
+	}
+	return []Warning{{
+		Check:   "debug",
+		Message: "debug is enabled " + where,
+		Hint:    "set debug: false outside local troubleshooting",
+	}}
+}
+
+// CheckFaultInjection warns when upstream calls are made to fail on purpose.
+func CheckFaultInjection(in Input) []Warning {
+	if in.FaultInjection == "" {
+		return nil
+	}
+	return []Warning{{
+		Check:   "fault-injection",
+		Message: "unsafe-fault-injection is enabled; upstream calls fail on purpose (" + in.FaultInjection + ")",
+		Hint:    "remove unsafe-fault-injection outside chaos testing",
+	}}
+}
+
+// ListenErrorHint returns a hint for a listener error, or "" when there is none.
+func ListenErrorHint(err error, port int) string {
+	if err == nil {
+		return ""
+	}
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "address already in use") || strings.Contains(msg, "only one usage of each socket address") {
+		return fmt.Sprintf("port %d is already in use; stop the other process or change port in the config", port)
+	}
+	if strings.Contains(msg, "permission denied") && port > 0 && port < 1024 {
+		return fmt.Sprintf("port %d is privileged; use a port above 1023 or grant the binary CAP_NET_BIND_SERVICE", port)
+	}
+	return ""
+}
+
+func matchesSecret(secret, key string) bool {
+	if secret == "" {
+		return false
+	}
+	if strings.HasPrefix(secret, "$2a$") || strings.HasPrefix(secret, "$2b$") || strings.HasPrefix(secret, "$2y$") {
+		return bcrypt.CompareHashAndPassword([]byte(secret), []byte(key)) == nil
+	}
+	return secret == key
+}
+
+func isLoopback(host string) bool {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+func displayDir(dir string) string {
+	if strings.TrimSpace(dir) == "" {
+		return "the auth directory"
+	}
+	return filepath.Clean(dir)
+}
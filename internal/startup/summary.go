@@ -0,0 +1,137 @@
+// Package startup builds the summary printed once the server is up: where it listens,
+// which credentials were loaded, which transports are usable, and warnings for the
+// misconfigurations new installations most often trip over.
+//
+// The latest summary is kept for the management status endpoint.
+package startup
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Transport describes whether an outbound transport is usable and why.
+type Transport struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Warning is a finding of one check, with a hint on how to resolve it.
+type Warning struct {
+	Check   string `json:"check"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// Summary is the startup health summary.
+type Summary struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	Listeners   []string       `json:"listeners"`
+	Auths       map[string]int `json:"auths"`
+	Transports  []Transport    `json:"transports"`
+	Proxy       string         `json:"proxy,omitempty"`
+	NoProxy     string         `json:"no_proxy,omitempty"`
+	Warnings    []Warning      `json:"warnings"`
+}
+
+var (
+	currentMu sync.RWMutex
+	current   *Summary
+)
+
+// SetCurrent records the summary served by the management status endpoint.
+func SetCurrent(summary *Summary) {
+	currentMu.Lock()
+	current = summary
+	currentMu.Unlock()
+}
+
+// Current returns the last recorded summary, or nil before startup completed.
+func Current() *Summary {
+	currentMu.RLock()
+	defer currentMu.RUnlock()
+	return current
+}
+
+// MaskProxyURL hides the password of a proxy URL. Values that do not parse are reduced to
+// their scheme so a mistyped credential is never printed.
+func MaskProxyURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		if scheme, _, found := strings.Cut(raw, "://"); found {
+			return scheme + "://(unparseable)"
+		}
+		return "(unparseable)"
+	}
+	return parsed.Redacted()
+}
+
+// Format renders the summary as the multi-line startup banner.
+func Format(s *Summary) string {
+	if s == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("---- startup summary ----\n")
+	for _, listener := range s.Listeners {
+		fmt.Fprintf(&b, "listening:  %s\n", listener)
+	}
+
+	providers := make([]string, 0, len(s.Auths))
+	total := 0
+	for provider, count := range s.Auths {
+		providers = append(providers, provider)
+		total += count
+	}
+	sort.Strings(providers)
+	parts := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		parts = append(parts, fmt.Sprintf("%s=%d", provider, s.Auths[provider]))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "none")
+	}
+	fmt.Fprintf(&b, "auths:      %d (%s)\n", total, strings.Join(parts, ", "))
+
+	for _, transport := range s.Transports {
+		state := "inactive"
+		if transport.Active {
+			state = "active"
+		}
+		if transport.Detail != "" {
+			fmt.Fprintf(&b, "transport:  %s %s (%s)\n", transport.Name, state, transport.Detail)
+		} else {
+			fmt.Fprintf(&b, "transport:  %s %s\n", transport.Name, state)
+		}
+	}
+
+	proxy := s.Proxy
+	if proxy == "" {
+		proxy = "direct"
+	}
+	if s.NoProxy != "" {
+		proxy += " (no-proxy: " + s.NoProxy + ")"
+	}
+	fmt.Fprintf(&b, "proxy:      %s\n", proxy)
+
+	if len(s.Warnings) > 0 {
+		fmt.Fprintf(&b, "WARNINGS (%d):\n", len(s.Warnings))
+		for _, warning := range s.Warnings {
+			fmt.Fprintf(&b, "  - [%s] %s\n", warning.Check, warning.Message)
+			if warning.Hint != "" {
+				fmt.Fprintf(&b, "    hint: %s\n", warning.Hint)
+			}
+		}
+	}
+	b.WriteString("-------------------------")
+	return b.String()
+}
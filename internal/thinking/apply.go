@@ -335,6 +335,16 @@ func extractThinkingConfig(body []byte, provider string) ThinkingConfig {
 	}
 }
 
+// HasThinkingConfig reports whether a request body in the given format sets a thinking
+// config of its own. "openai-response" bodies are read like Codex ones.
+func HasThinkingConfig(body []byte, format string) bool {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "openai-response" {
+		format = "codex"
+	}
+	return hasThinkingConfig(extractThinkingConfig(body, format))
+}
+
 func hasThinkingConfig(config ThinkingConfig) bool {
 	return config.Mode != ModeBudget || config.Budget != 0 || config.Level != ""
 }
@@ -0,0 +1,247 @@
+// Package timing collects per-request phase timings (parse, auth selection,
+// translation, upstream connect, time to first token, streaming) and payload sizes
+// so slow requests can be broken down in debug logs, the access log, and an
+// optional Server-Timing style response header.
+package timing
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Canonical phase names, listed in the order a request flows through the proxy.
+const (
+	PhaseParse        = "parse"
+	PhaseAuthSelect   = "auth-select"
+	PhaseTranslateIn  = "translate-in"
+	PhaseConnect      = "connect"
+	PhaseTTFT         = "ttft"
+	PhaseStream       = "stream"
+	PhaseTranslateOut = "translate-out"
+)
+
+// Payload size keys.
+const (
+	SizeRequest         = "request_bytes"
+	SizeUpstreamRequest = "upstream_request_bytes"
+	SizeResponse        = "response_bytes"
+)
+
+// Instant marks used to derive phase boundaries.
+const (
+	MarkAuthSelected = "auth-selected"
+	MarkConnected    = "connected"
+	MarkFirstChunk   = "first-chunk"
+)
+
+var phaseOrder = map[string]int{
+	PhaseParse:        0,
+	PhaseAuthSelect:   1,
+	PhaseTranslateIn:  2,
+	PhaseConnect:      3,
+	PhaseTTFT:         4,
+	PhaseStream:       5,
+	PhaseTranslateOut: 6,
+}
+
+// Phase is a named duration accumulated over the life of a request.
+type Phase struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Timer accumulates phase durations, instant marks, and payload sizes for one request.
+// All methods are safe for concurrent use and tolerate a nil receiver.
+type Timer struct {
+	mu        sync.Mutex
+	start     time.Time
+	durations map[string]time.Duration
+	marks     map[string]time.Time
+	sizes     map[string]int64
+	now       func() time.Time
+}
+
+// New creates a timer whose origin is start.
+func New(start time.Time) *Timer {
+	return &Timer{
+		start:     start,
+		durations: make(map[string]time.Duration),
+		marks:     make(map[string]time.Time),
+		sizes:     make(map[string]int64),
+		now:       time.Now,
+	}
+}
+
+type timerContextKey struct{}
+
+// WithTimer attaches t to ctx.
+func WithTimer(ctx context.Context, t *Timer) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, timerContextKey{}, t)
+}
+
+// FromContext returns the timer attached to ctx, or nil.
+func FromContext(ctx context.Context) *Timer {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(timerContextKey{}).(*Timer)
+	return t
+}
+
+// Now returns the timer clock's current time.
+func (t *Timer) Now() time.Time {
+	if t == nil {
+		return time.Now()
+	}
+	return t.now()
+}
+
+// Start returns the timer origin.
+func (t *Timer) Start() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.start
+}
+
+// Add accumulates d into the named phase.
+func (t *Timer) Add(name string, d time.Duration) {
+	if t == nil || d < 0 {
+		return
+	}
+	t.mu.Lock()
+	t.durations[name] += d
+	t.mu.Unlock()
+}
+
+// Since accumulates the time elapsed since from into the named phase.
+func (t *Timer) Since(name string, from time.Time) {
+	if t == nil || from.IsZero() {
+		return
+	}
+	t.Add(name, t.now().Sub(from))
+}
+
+// SetOnce records the named phase as the time elapsed since from, unless it is already set.
+func (t *Timer) SetOnce(name string, from time.Time) {
+	if t == nil || from.IsZero() {
+		return
+	}
+	now := t.now()
+	t.mu.Lock()
+	if _, exists := t.durations[name]; !exists {
+		t.durations[name] = now.Sub(from)
+	}
+	t.mu.Unlock()
+}
+
+// Mark records the current time under name, replacing any earlier mark.
+func (t *Timer) Mark(name string) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	now := t.now()
+	t.mu.Lock()
+	t.marks[name] = now
+	t.mu.Unlock()
+	return now
+}
+
+// Take returns and clears the mark stored under name.
+func (t *Timer) Take(name string) (time.Time, bool) {
+	if t == nil {
+		return time.Time{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	at, ok := t.marks[name]
+	if ok {
+		delete(t.marks, name)
+	}
+	return at, ok
+}
+
+// AddSize accumulates n bytes under the named size key.
+func (t *Timer) AddSize(name string, n int64) {
+	if t == nil || n <= 0 {
+		return
+	}
+	t.mu.Lock()
+	t.sizes[name] += n
+	t.mu.Unlock()
+}
+
+// Size returns the accumulated byte count for name.
+func (t *Timer) Size(name string) int64 {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sizes[name]
+}
+
+// Phases returns the recorded phases in canonical request order; unknown phases
+// follow in name order.
+func (t *Timer) Phases() []Phase {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	out := make([]Phase, 0, len(t.durations))
+	for name, d := range t.durations {
+		out = append(out, Phase{Name: name, Duration: d})
+	}
+	t.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		oi, okI := phaseOrder[out[i].Name]
+		oj, okJ := phaseOrder[out[j].Name]
+		switch {
+		case okI && okJ:
+			return oi < oj
+		case okI != okJ:
+			return okI
+		default:
+			return out[i].Name < out[j].Name
+		}
+	})
+	return out
+}
+
+// ServerTiming renders the phases using Server-Timing header syntax,
+// e.g. `parse;dur=0.41, auth-select;dur=0.05`.
+func (t *Timer) ServerTiming() string {
+	phases := t.Phases()
+	if len(phases) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(phases))
+	for _, p := range phases {
+		parts = append(parts, fmt.Sprintf("%s;dur=%.2f", p.Name, float64(p.Duration)/float64(time.Millisecond)))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Fields returns the phases (in milliseconds) and payload sizes as structured log fields.
+func (t *Timer) Fields() map[string]any {
+	if t == nil {
+		return nil
+	}
+	fields := make(map[string]any)
+	for _, p := range t.Phases() {
+		fields["t_"+strings.ReplaceAll(p.Name, "-", "_")+"_ms"] = float64(p.Duration.Microseconds()) / 1000
+	}
+	t.mu.Lock()
+	for name, n := range t.sizes {
+		fields[name] = n
+	}
+	t.mu.Unlock()
+	return fields
+}
@@ -0,0 +1,89 @@
+package timing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimer_PhasesCanonicalOrder(t *testing.T) {
+	timer := New(time.Now())
+	timer.Add(PhaseTranslateOut, time.Millisecond)
+	timer.Add("custom", time.Millisecond)
+	timer.Add(PhaseConnect, 2*time.Millisecond)
+	timer.Add(PhaseParse, time.Millisecond)
+	timer.Add(PhaseConnect, 3*time.Millisecond)
+
+	phases := timer.Phases()
+	got := make([]string, 0, len(phases))
+	for _, p := range phases {
+		got = append(got, p.Name)
+	}
+	want := []string{PhaseParse, PhaseConnect, PhaseTranslateOut, "custom"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("phases = %v, want %v", got, want)
+	}
+	if phases[1].Duration != 5*time.Millisecond {
+		t.Fatalf("connect = %v, want accumulated 5ms", phases[1].Duration)
+	}
+	if header := timer.ServerTiming(); !strings.HasPrefix(header, "parse;dur=1.00, connect;dur=5.00") {
+		t.Fatalf("ServerTiming() = %q", header)
+	}
+}
+
+func TestTimer_NilSafe(t *testing.T) {
+	var timer *Timer
+	timer.Add(PhaseParse, time.Second)
+	timer.Mark(MarkConnected)
+	timer.AddSize(SizeRequest, 10)
+	if timer.Phases() != nil || timer.ServerTiming() != "" || timer.Size(SizeRequest) != 0 {
+		t.Fatal("nil timer should record nothing")
+	}
+	if FromContext(context.Background()) != nil {
+		t.Fatal("expected no timer in empty context")
+	}
+}
+
+func TestTransport_RecordsUpstreamPhases(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = io.WriteString(w, "hello")
+	}))
+	defer server.Close()
+
+	timer := New(time.Now())
+	timer.Mark(MarkAuthSelected)
+	ctx := WithTimer(context.Background(), timer)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	resp, err := (&http.Client{Transport: NewTransport(nil)}).Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if _, err = io.ReadAll(resp.Body); err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	names := make(map[string]bool)
+	for _, p := range timer.Phases() {
+		names[p.Name] = true
+	}
+	for _, name := range []string{PhaseTranslateIn, PhaseConnect, PhaseTTFT, PhaseStream} {
+		if !names[name] {
+			t.Fatalf("missing phase %q in %v", name, timer.Phases())
+		}
+	}
+	if got := timer.Size(SizeUpstreamRequest); got != int64(len("payload")) {
+		t.Fatalf("upstream_request_bytes = %d", got)
+	}
+	if _, ok := timer.Take(MarkAuthSelected); ok {
+		t.Fatal("auth-selected mark should be consumed by the transport")
+	}
+}
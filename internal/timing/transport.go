@@ -0,0 +1,98 @@
+package timing
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Transport records translate-in, connect, ttft, and stream phases for outbound
+// requests whose context carries a Timer. Requests without a timer pass through untouched.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+// NewTransport wraps base (http.DefaultTransport when nil) with phase recording.
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return RoundTrip(req, base.RoundTrip)
+}
+
+// RoundTrip performs do(req) while recording phases against the request context timer:
+//   - translate-in: from auth selection until the upstream request is dispatched
+//   - connect: until upstream response headers arrive
+//   - ttft: until the first response body byte is read
+//   - stream: from the first body byte until the body is drained or closed
+//
+// It is exported so transports that do not implement http.RoundTripper (such as the
+// Electron shim) can share the same accounting.
+func RoundTrip(req *http.Request, do func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	timer := FromContext(req.Context())
+	if timer == nil {
+		return do(req)
+	}
+	if selected, ok := timer.Take(MarkAuthSelected); ok {
+		timer.Since(PhaseTranslateIn, selected)
+	}
+	if req.ContentLength > 0 {
+		timer.AddSize(SizeUpstreamRequest, req.ContentLength)
+	}
+
+	dispatched := timer.Now()
+	resp, err := do(req)
+	timer.Since(PhaseConnect, dispatched)
+	if err != nil || resp == nil || resp.Body == nil {
+		return resp, err
+	}
+	resp.Body = &timedBody{ReadCloser: resp.Body, timer: timer, connected: timer.Mark(MarkConnected)}
+	return resp, nil
+}
+
+// timedBody records time to first byte and total body transfer time.
+type timedBody struct {
+	io.ReadCloser
+	timer     *Timer
+	connected time.Time
+
+	mu        sync.Mutex
+	firstByte time.Time
+	done      bool
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.mu.Lock()
+	if n > 0 && b.firstByte.IsZero() {
+		b.firstByte = b.timer.Now()
+		b.timer.Add(PhaseTTFT, b.firstByte.Sub(b.connected))
+	}
+	b.mu.Unlock()
+	if err != nil {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *timedBody) finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done || b.firstByte.IsZero() {
+		return
+	}
+	b.done = true
+	b.timer.Since(PhaseStream, b.firstByte)
+}
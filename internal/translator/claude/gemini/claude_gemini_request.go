@@ -102,15 +102,8 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 			out, _ = sjson.Set(out, "top_p", topP.Float())
 		}
 		// Stop sequences configuration for custom termination conditions
-		if stopSeqs := genConfig.Get("stopSequences"); stopSeqs.Exists() && stopSeqs.IsArray() {
-			var stopSequences []string
-			stopSeqs.ForEach(func(_, value gjson.Result) bool {
-				stopSequences = append(stopSequences, value.String())
-				return true
-			})
-			if len(stopSequences) > 0 {
-				out, _ = sjson.Set(out, "stop_sequences", stopSequences)
-			}
+		if stopSequences := util.StopSequences(genConfig.Get("stopSequences")); len(stopSequences) > 0 {
+			out, _ = sjson.Set(out, "stop_sequences", stopSequences)
 		}
 		// Include thoughts configuration for reasoning process visibility
 		// Translator only does format conversion, ApplyThinking handles model capability validation.
@@ -15,6 +15,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -115,19 +116,8 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 	}
 
 	// Stop sequences configuration for custom termination conditions
-	if stop := root.Get("stop"); stop.Exists() {
-		if stop.IsArray() {
-			var stopSequences []string
-			stop.ForEach(func(_, value gjson.Result) bool {
-				stopSequences = append(stopSequences, value.String())
-				return true
-			})
-			if len(stopSequences) > 0 {
-				out, _ = sjson.Set(out, "stop_sequences", stopSequences)
-			}
-		} else {
-			out, _ = sjson.Set(out, "stop_sequences", []string{stop.String()})
-		}
+	if stopSequences := util.StopSequences(root.Get("stop")); len(stopSequences) > 0 {
+		out, _ = sjson.Set(out, "stop_sequences", stopSequences)
 	}
 
 	// Stream configuration to enable or disable streaming responses
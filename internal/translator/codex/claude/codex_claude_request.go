@@ -41,19 +41,26 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 	rootResult := gjson.ParseBytes(rawJSON)
 	template, _ = sjson.Set(template, "model", modelName)
 
-	// Process system messages and convert them to input content format.
+	// Process system messages and convert them to input content format. Every text block
+	// is kept, in order, so multi-block prompts (e.g. cache_control-annotated sections) survive.
 	systemsResult := rootResult.Get("system")
 	if systemsResult.IsArray() {
-		systemResults := systemsResult.Array()
 		message := `{"type":"message","role":"developer","content":[]}`
-		for i := 0; i < len(systemResults); i++ {
-			systemResult := systemResults[i]
-			systemTypeResult := systemResult.Get("type")
-			if systemTypeResult.String() == "text" {
-				message, _ = sjson.Set(message, fmt.Sprintf("content.%d.type", i), "input_text")
-				message, _ = sjson.Set(message, fmt.Sprintf("content.%d.text", i), systemResult.Get("text").String())
+		hasSystem := false
+		systemsResult.ForEach(func(_, systemResult gjson.Result) bool {
+			if systemResult.Get("type").String() == "text" {
+				part, _ := sjson.Set(`{"type":"input_text"}`, "text", systemResult.Get("text").String())
+				message, _ = sjson.SetRaw(message, "content.-1", part)
+				hasSystem = true
 			}
+			return true
+		})
+		if hasSystem {
+			template, _ = sjson.SetRaw(template, "input.-1", message)
 		}
+	} else if systemsResult.Type == gjson.String && systemsResult.String() != "" {
+		message := `{"type":"message","role":"developer","content":[{"type":"input_text","text":""}]}`
+		message, _ = sjson.Set(message, "content.0.text", systemsResult.String())
 		template, _ = sjson.SetRaw(template, "input.-1", message)
 	}
 
@@ -114,23 +121,8 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 					case "text":
 						appendTextContent(messageContentResult.Get("text").String())
 					case "image":
-						sourceResult := messageContentResult.Get("source")
-						if sourceResult.Exists() {
-							data := sourceResult.Get("data").String()
-							if data == "" {
-								data = sourceResult.Get("base64").String()
-							}
-							if data != "" {
-								mediaType := sourceResult.Get("media_type").String()
-								if mediaType == "" {
-									mediaType = sourceResult.Get("mime_type").String()
-								}
-								if mediaType == "" {
-									mediaType = "application/octet-stream"
-								}
-								dataURL := fmt.Sprintf("data:%s;base64,%s", mediaType, data)
-								appendImageContent(dataURL)
-							}
+						if imageURL := claudeImageURL(messageContentResult.Get("source")); imageURL != "" {
+							appendImageContent(imageURL)
 						}
 					case "tool_use":
 						flushMessage()
@@ -152,7 +144,7 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 						flushMessage()
 						functionCallOutputMessage := `{"type":"function_call_output"}`
 						functionCallOutputMessage, _ = sjson.Set(functionCallOutputMessage, "call_id", messageContentResult.Get("tool_use_id").String())
-						functionCallOutputMessage, _ = sjson.Set(functionCallOutputMessage, "output", messageContentResult.Get("content").String())
+						functionCallOutputMessage = setToolResultOutput(functionCallOutputMessage, messageContentResult.Get("content"))
 						template, _ = sjson.SetRaw(template, "input.-1", functionCallOutputMessage)
 					}
 				}
@@ -241,6 +233,66 @@ func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 	return []byte(template)
 }
 
+// claudeImageURL converts a Claude image source into a data URL, or returns the URL of
+// a url-type source. It returns "" when the source carries no image.
+func claudeImageURL(sourceResult gjson.Result) string {
+	if !sourceResult.Exists() {
+		return ""
+	}
+	if sourceResult.Get("type").String() == "url" {
+		return sourceResult.Get("url").String()
+	}
+	data := sourceResult.Get("data").String()
+	if data == "" {
+		data = sourceResult.Get("base64").String()
+	}
+	if data == "" {
+		return ""
+	}
+	mediaType := sourceResult.Get("media_type").String()
+	if mediaType == "" {
+		mediaType = sourceResult.Get("mime_type").String()
+	}
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	return fmt.Sprintf("data:%s;base64,%s", mediaType, data)
+}
+
+// setToolResultOutput sets the function_call_output output from a tool_result content value.
+// String content is kept as is and text-only arrays are joined into a single string; arrays
+// carrying images become input_text and input_image items in their original order.
+func setToolResultOutput(message string, content gjson.Result) string {
+	if !content.IsArray() {
+		message, _ = sjson.Set(message, "output", content.String())
+		return message
+	}
+	var texts []string
+	items := `[]`
+	hasImage := false
+	content.ForEach(func(_, part gjson.Result) bool {
+		switch part.Get("type").String() {
+		case "text":
+			texts = append(texts, part.Get("text").String())
+			item, _ := sjson.Set(`{"type":"input_text"}`, "text", part.Get("text").String())
+			items, _ = sjson.SetRaw(items, "-1", item)
+		case "image":
+			if imageURL := claudeImageURL(part.Get("source")); imageURL != "" {
+				item, _ := sjson.Set(`{"type":"input_image"}`, "image_url", imageURL)
+				items, _ = sjson.SetRaw(items, "-1", item)
+				hasImage = true
+			}
+		}
+		return true
+	})
+	if hasImage {
+		message, _ = sjson.SetRaw(message, "output", items)
+	} else {
+		message, _ = sjson.Set(message, "output", strings.Join(texts, "\n\n"))
+	}
+	return message
+}
+
 // shortenNameIfNeeded applies a simple shortening rule for a single name.
 func shortenNameIfNeeded(name string) string {
 	const limit = 64
@@ -0,0 +1,137 @@
+package claude
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// claudeCodeSystemFixture mirrors the system prompt shape Claude Code sends: several text
+// blocks, the later ones annotated with cache_control.
+const claudeCodeSystemFixture = `{
+	"model": "claude-sonnet-4-5",
+	"max_tokens": 32000,
+	"system": [
+		{"type": "text", "text": "You are Claude Code, Anthropic's official CLI for Claude."},
+		{"type": "text", "text": "You are an interactive CLI tool that helps users with software engineering tasks.", "cache_control": {"type": "ephemeral"}},
+		{"type": "text", "text": "<env>\nWorking directory: /home/dev/project\nPlatform: linux\n</env>", "cache_control": {"type": "ephemeral"}}
+	],
+	"messages": [
+		{"role": "user", "content": [{"type": "text", "text": "What does main.go do?", "cache_control": {"type": "ephemeral"}}]}
+	]
+}`
+
+// claudeCodeToolResultFixture mirrors a Read tool call on a screenshot followed by a Bash
+// call whose result is split across text blocks.
+const claudeCodeToolResultFixture = `{
+	"model": "claude-sonnet-4-5",
+	"messages": [
+		{"role": "user", "content": "Look at the screenshot and run the tests."},
+		{"role": "assistant", "content": [
+			{"type": "text", "text": "Reading the screenshot."},
+			{"type": "tool_use", "id": "toolu_01Read", "name": "Read", "input": {"file_path": "/tmp/shot.png"}},
+			{"type": "tool_use", "id": "toolu_01Bash", "name": "Bash", "input": {"command": "go test ./..."}}
+		]},
+		{"role": "user", "content": [
+			{"type": "tool_result", "tool_use_id": "toolu_01Read", "content": [
+				{"type": "text", "text": "Screenshot of the failing page:"},
+				{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "iVBORw0KGgo="}}
+			]},
+			{"type": "tool_result", "tool_use_id": "toolu_01Bash", "content": [
+				{"type": "text", "text": "ok  \tpkg/a\t0.01s"},
+				{"type": "text", "text": "FAIL\tpkg/b\t0.02s"}
+			], "is_error": true},
+			{"type": "text", "text": "Fix the failure.", "cache_control": {"type": "ephemeral"}}
+		]}
+	]
+}`
+
+func TestConvertClaudeRequestToCodex_KeepsEverySystemBlockInOrder(t *testing.T) {
+	out := ConvertClaudeRequestToCodex("gpt-5-codex", []byte(claudeCodeSystemFixture), true)
+
+	developer := gjson.GetBytes(out, "input.0")
+	if developer.Get("role").String() != "developer" {
+		t.Fatalf("first input item = %s", developer.Raw)
+	}
+	parts := developer.Get("content").Array()
+	want := []string{
+		"You are Claude Code, Anthropic's official CLI for Claude.",
+		"You are an interactive CLI tool that helps users with software engineering tasks.",
+		"<env>\nWorking directory: /home/dev/project\nPlatform: linux\n</env>",
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("developer content = %s, want %d parts", developer.Get("content").Raw, len(want))
+	}
+	for i, part := range parts {
+		if part.Get("type").String() != "input_text" || part.Get("text").String() != want[i] {
+			t.Fatalf("part %d = %s, want input_text %q", i, part.Raw, want[i])
+		}
+	}
+	if got := gjson.GetBytes(out, "input.1.content.0.text").String(); got != "What does main.go do?" {
+		t.Fatalf("cache_control-annotated user text = %q", got)
+	}
+}
+
+func TestConvertClaudeRequestToCodex_StringSystemPrompt(t *testing.T) {
+	out := ConvertClaudeRequestToCodex("gpt-5-codex", []byte(`{"system":"Be terse.","messages":[{"role":"user","content":"hi"}]}`), true)
+
+	if got := gjson.GetBytes(out, "input.0.content.0.text").String(); got != "Be terse." || gjson.GetBytes(out, "input.0.role").String() != "developer" {
+		t.Fatalf("input = %s", gjson.GetBytes(out, "input").Raw)
+	}
+}
+
+func TestConvertClaudeRequestToCodex_ToolResultContentArrays(t *testing.T) {
+	out := ConvertClaudeRequestToCodex("gpt-5-codex", []byte(claudeCodeToolResultFixture), true)
+
+	var outputs []gjson.Result
+	var order []string
+	gjson.GetBytes(out, "input").ForEach(func(_, item gjson.Result) bool {
+		order = append(order, item.Get("type").String())
+		if item.Get("type").String() == "function_call_output" {
+			outputs = append(outputs, item)
+		}
+		return true
+	})
+	wantOrder := []string{"message", "message", "function_call", "function_call", "function_call_output", "function_call_output", "message"}
+	if len(order) != len(wantOrder) {
+		t.Fatalf("input order = %v, want %v", order, wantOrder)
+	}
+	for i := range order {
+		if order[i] != wantOrder[i] {
+			t.Fatalf("input order = %v, want %v", order, wantOrder)
+		}
+	}
+
+	image := outputs[0]
+	if image.Get("call_id").String() != "toolu_01Read" {
+		t.Fatalf("image output = %s", image.Raw)
+	}
+	items := image.Get("output").Array()
+	if len(items) != 2 {
+		t.Fatalf("image output items = %s", image.Get("output").Raw)
+	}
+	if items[0].Get("type").String() != "input_text" || items[0].Get("text").String() != "Screenshot of the failing page:" {
+		t.Fatalf("text item = %s", items[0].Raw)
+	}
+	if items[1].Get("type").String() != "input_image" || items[1].Get("image_url").String() != "data:image/png;base64,iVBORw0KGgo=" {
+		t.Fatalf("image item = %s", items[1].Raw)
+	}
+
+	text := outputs[1]
+	if text.Get("call_id").String() != "toolu_01Bash" || text.Get("output").String() != "ok  \tpkg/a\t0.01s\n\nFAIL\tpkg/b\t0.02s" {
+		t.Fatalf("text output = %s", text.Raw)
+	}
+
+	if got := gjson.GetBytes(out, "input.6.content.0.text").String(); got != "Fix the failure." {
+		t.Fatalf("trailing user text = %q", got)
+	}
+}
+
+func TestConvertClaudeRequestToCodex_StringToolResultUnchanged(t *testing.T) {
+	raw := `{"messages":[{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"plain output"}]}]}`
+	out := ConvertClaudeRequestToCodex("gpt-5-codex", []byte(raw), true)
+
+	if got := gjson.GetBytes(out, "input.0.output").String(); got != "plain output" {
+		t.Fatalf("output = %q", got)
+	}
+}
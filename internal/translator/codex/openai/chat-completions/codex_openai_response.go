@@ -83,25 +83,6 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 	// Extract and set the response ID.
 	template, _ = sjson.Set(template, "id", (*param).(*ConvertCliToOpenAIParams).ResponseID)
 
-	// Extract and set usage metadata (token counts).
-	if usageResult := gjson.GetBytes(rawJSON, "response.usage"); usageResult.Exists() {
-		if outputTokensResult := usageResult.Get("output_tokens"); outputTokensResult.Exists() {
-			template, _ = sjson.Set(template, "usage.completion_tokens", outputTokensResult.Int())
-		}
-		if totalTokensResult := usageResult.Get("total_tokens"); totalTokensResult.Exists() {
-			template, _ = sjson.Set(template, "usage.total_tokens", totalTokensResult.Int())
-		}
-		if inputTokensResult := usageResult.Get("input_tokens"); inputTokensResult.Exists() {
-			template, _ = sjson.Set(template, "usage.prompt_tokens", inputTokensResult.Int())
-		}
-		if cachedTokensResult := usageResult.Get("input_tokens_details.cached_tokens"); cachedTokensResult.Exists() {
-			template, _ = sjson.Set(template, "usage.prompt_tokens_details.cached_tokens", cachedTokensResult.Int())
-		}
-		if reasoningTokensResult := usageResult.Get("output_tokens_details.reasoning_tokens"); reasoningTokensResult.Exists() {
-			template, _ = sjson.Set(template, "usage.completion_tokens_details.reasoning_tokens", reasoningTokensResult.Int())
-		}
-	}
-
 	if dataType == "response.reasoning_summary_text.delta" {
 		if deltaResult := rootResult.Get("delta"); deltaResult.Exists() {
 			template, _ = sjson.Set(template, "choices.0.delta.role", "assistant")
@@ -115,13 +96,34 @@ func ConvertCodexResponseToOpenAI(_ context.Context, modelName string, originalR
 			template, _ = sjson.Set(template, "choices.0.delta.role", "assistant")
 			template, _ = sjson.Set(template, "choices.0.delta.content", deltaResult.String())
 		}
-	} else if dataType == "response.completed" {
+	} else if dataType == "response.completed" || dataType == "response.incomplete" {
 		finishReason := "stop"
 		if (*param).(*ConvertCliToOpenAIParams).FunctionCallIndex != -1 {
 			finishReason = "tool_calls"
 		}
+		switch rootResult.Get("response.incomplete_details.reason").String() {
+		case "max_output_tokens":
+			finishReason = "length"
+		case "content_filter":
+			finishReason = "content_filter"
+		}
 		template, _ = sjson.Set(template, "choices.0.finish_reason", finishReason)
 		template, _ = sjson.Set(template, "choices.0.native_finish_reason", finishReason)
+
+		// Only the terminal event carries usage. Like OpenAI, stream_options.include_usage
+		// sends it as a separate last chunk with empty choices, and include_usage false
+		// leaves it out; clients that set neither keep getting it on the finish chunk.
+		usage := codexUsageToOpenAI(rootResult.Get("response.usage"))
+		includeUsage := gjson.GetBytes(originalRequestRawJSON, "stream_options.include_usage")
+		switch {
+		case usage == "":
+		case includeUsage.Type == gjson.True:
+			usageChunk, _ := sjson.SetRaw(template, "choices", `[]`)
+			usageChunk, _ = sjson.SetRaw(usageChunk, "usage", usage)
+			return []string{template, usageChunk}
+		case !includeUsage.Exists():
+			template, _ = sjson.SetRaw(template, "usage", usage)
+		}
 	} else if dataType == "response.output_item.added" {
 		itemResult := rootResult.Get("item")
 		if !itemResult.Exists() || itemResult.Get("type").String() != "function_call" {
@@ -260,22 +262,8 @@ func ConvertCodexResponseToOpenAINonStream(_ context.Context, _ string, original
 	}
 
 	// Extract and set usage metadata (token counts).
-	if usageResult := responseResult.Get("usage"); usageResult.Exists() {
-		if outputTokensResult := usageResult.Get("output_tokens"); outputTokensResult.Exists() {
-			template, _ = sjson.Set(template, "usage.completion_tokens", outputTokensResult.Int())
-		}
-		if totalTokensResult := usageResult.Get("total_tokens"); totalTokensResult.Exists() {
-			template, _ = sjson.Set(template, "usage.total_tokens", totalTokensResult.Int())
-		}
-		if inputTokensResult := usageResult.Get("input_tokens"); inputTokensResult.Exists() {
-			template, _ = sjson.Set(template, "usage.prompt_tokens", inputTokensResult.Int())
-		}
-		if cachedTokensResult := usageResult.Get("input_tokens_details.cached_tokens"); cachedTokensResult.Exists() {
-			template, _ = sjson.Set(template, "usage.prompt_tokens_details.cached_tokens", cachedTokensResult.Int())
-		}
-		if reasoningTokensResult := usageResult.Get("output_tokens_details.reasoning_tokens"); reasoningTokensResult.Exists() {
-			template, _ = sjson.Set(template, "usage.completion_tokens_details.reasoning_tokens", reasoningTokensResult.Int())
-		}
+	if usage := codexUsageToOpenAI(responseResult.Get("usage")); usage != "" {
+		template, _ = sjson.SetRaw(template, "usage", usage)
 	}
 
 	// Process the output array for content and function calls
@@ -400,3 +388,33 @@ func buildReverseMapFromOriginalOpenAI(original []byte) map[string]string {
 	}
 	return rev
 }
+
+// codexUsageToOpenAI converts a Codex response usage object into a Chat Completions usage
+// object, deriving total_tokens when the upstream leaves it out. It returns "" when usage
+// carries no token counts.
+func codexUsageToOpenAI(usageResult gjson.Result) string {
+	if !usageResult.IsObject() {
+		return ""
+	}
+	inputTokens := usageResult.Get("input_tokens")
+	outputTokens := usageResult.Get("output_tokens")
+	totalTokens := usageResult.Get("total_tokens")
+	if !inputTokens.Exists() && !outputTokens.Exists() && !totalTokens.Exists() {
+		return ""
+	}
+	total := totalTokens.Int()
+	if total == 0 {
+		total = inputTokens.Int() + outputTokens.Int()
+	}
+	usage := `{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0}`
+	usage, _ = sjson.Set(usage, "prompt_tokens", inputTokens.Int())
+	usage, _ = sjson.Set(usage, "completion_tokens", outputTokens.Int())
+	usage, _ = sjson.Set(usage, "total_tokens", total)
+	if cachedTokensResult := usageResult.Get("input_tokens_details.cached_tokens"); cachedTokensResult.Exists() {
+		usage, _ = sjson.Set(usage, "prompt_tokens_details.cached_tokens", cachedTokensResult.Int())
+	}
+	if reasoningTokensResult := usageResult.Get("output_tokens_details.reasoning_tokens"); reasoningTokensResult.Exists() {
+		usage, _ = sjson.Set(usage, "completion_tokens_details.reasoning_tokens", reasoningTokensResult.Int())
+	}
+	return usage
+}
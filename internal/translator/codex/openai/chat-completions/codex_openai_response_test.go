@@ -0,0 +1,122 @@
+package chat_completions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// codexUsageStream is a canned Codex SSE stream ending in response.completed with usage.
+var codexUsageStream = []string{
+	`data: {"type":"response.created","response":{"id":"resp_1","created_at":1700000000,"model":"gpt-5"}}`,
+	`data: {"type":"response.output_text.delta","delta":"Hello"}`,
+	`data: {"type":"response.output_text.delta","delta":" world"}`,
+	`data: {"type":"response.completed","response":{"id":"resp_1","usage":{"input_tokens":12,"input_tokens_details":{"cached_tokens":4},"output_tokens":7,"output_tokens_details":{"reasoning_tokens":3},"total_tokens":19}}}`,
+}
+
+func runCodexChatStream(originalRequest string, lines []string) []string {
+	var param any
+	var out []string
+	for _, line := range lines {
+		out = append(out, ConvertCodexResponseToOpenAI(context.Background(), "gpt-5", []byte(originalRequest), nil, []byte(line), &param)...)
+	}
+	return out
+}
+
+func TestConvertCodexResponseToOpenAI_StreamUsage(t *testing.T) {
+	t.Run("include_usage emits a trailing usage chunk", func(t *testing.T) {
+		out := runCodexChatStream(`{"stream":true,"stream_options":{"include_usage":true}}`, codexUsageStream)
+		if len(out) != 4 {
+			t.Fatalf("expected 4 chunks, got %d: %v", len(out), out)
+		}
+		finish := gjson.Parse(out[2])
+		if finish.Get("choices.0.finish_reason").String() != "stop" {
+			t.Fatalf("finish chunk = %s", out[2])
+		}
+		if finish.Get("usage").Exists() {
+			t.Fatalf("finish chunk should not carry usage: %s", out[2])
+		}
+		usage := gjson.Parse(out[3])
+		if !usage.Get("choices").IsArray() || len(usage.Get("choices").Array()) != 0 {
+			t.Fatalf("usage chunk should have empty choices: %s", out[3])
+		}
+		if usage.Get("id").String() != "resp_1" || usage.Get("object").String() != "chat.completion.chunk" {
+			t.Fatalf("usage chunk id/object = %s", out[3])
+		}
+		checks := map[string]int64{
+			"usage.prompt_tokens":                              12,
+			"usage.completion_tokens":                          7,
+			"usage.total_tokens":                               19,
+			"usage.prompt_tokens_details.cached_tokens":        4,
+			"usage.completion_tokens_details.reasoning_tokens": 3,
+		}
+		for path, want := range checks {
+			if got := usage.Get(path).Int(); got != want {
+				t.Fatalf("%s = %d, want %d", path, got, want)
+			}
+		}
+	})
+
+	t.Run("unset include_usage keeps usage on the finish chunk", func(t *testing.T) {
+		out := runCodexChatStream(`{"stream":true}`, codexUsageStream)
+		if len(out) != 3 {
+			t.Fatalf("expected 3 chunks, got %d: %v", len(out), out)
+		}
+		if got := gjson.Get(out[2], "usage.total_tokens").Int(); got != 19 {
+			t.Fatalf("usage.total_tokens = %d, want 19: %s", got, out[2])
+		}
+		for _, chunk := range out[:2] {
+			if gjson.Get(chunk, "usage").Exists() {
+				t.Fatalf("delta chunk should not carry usage: %s", chunk)
+			}
+		}
+	})
+
+	t.Run("include_usage false omits usage", func(t *testing.T) {
+		out := runCodexChatStream(`{"stream":true,"stream_options":{"include_usage":false}}`, codexUsageStream)
+		if len(out) != 3 {
+			t.Fatalf("expected 3 chunks, got %d: %v", len(out), out)
+		}
+		if gjson.Get(out[2], "usage").Exists() {
+			t.Fatalf("usage should be omitted: %s", out[2])
+		}
+	})
+
+	t.Run("missing total is derived and incomplete maps to length", func(t *testing.T) {
+		lines := []string{
+			codexUsageStream[0],
+			codexUsageStream[1],
+			`data: {"type":"response.incomplete","response":{"id":"resp_1","incomplete_details":{"reason":"max_output_tokens"},"usage":{"input_tokens":5,"output_tokens":9}}}`,
+		}
+		out := runCodexChatStream(`{"stream":true,"stream_options":{"include_usage":true}}`, lines)
+		if len(out) != 3 {
+			t.Fatalf("expected 3 chunks, got %d: %v", len(out), out)
+		}
+		if got := gjson.Get(out[1], "choices.0.finish_reason").String(); got != "length" {
+			t.Fatalf("finish_reason = %q, want length", got)
+		}
+		if got := gjson.Get(out[2], "usage.total_tokens").Int(); got != 14 {
+			t.Fatalf("usage.total_tokens = %d, want 14", got)
+		}
+	})
+}
+
+func TestConvertCodexResponseToOpenAINonStream_Usage(t *testing.T) {
+	completed := strings.TrimPrefix(codexUsageStream[3], "data: ")
+	out := ConvertCodexResponseToOpenAINonStream(context.Background(), "gpt-5", []byte(`{}`), nil, []byte(completed), nil)
+	root := gjson.Parse(out)
+	if root.Get("object").String() != "chat.completion" {
+		t.Fatalf("unexpected response: %s", out)
+	}
+	if got := root.Get("usage.prompt_tokens").Int(); got != 12 {
+		t.Fatalf("usage.prompt_tokens = %d, want 12", got)
+	}
+	if got := root.Get("usage.completion_tokens").Int(); got != 7 {
+		t.Fatalf("usage.completion_tokens = %d, want 7", got)
+	}
+	if got := root.Get("usage.total_tokens").Int(); got != 19 {
+		t.Fatalf("usage.total_tokens = %d, want 19", got)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -407,15 +408,10 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 	}
 
 	// Handle stop sequences
-	if stopSequences := root.Get("stop_sequences"); stopSequences.Exists() && stopSequences.IsArray() {
+	if sequences := util.LimitStopSequences(util.StopSequences(root.Get("stop_sequences")), util.MaxGeminiStopSequences, "gemini"); len(sequences) > 0 {
 		if !gjson.Get(out, "generationConfig").Exists() {
 			out, _ = sjson.SetRaw(out, "generationConfig", `{}`)
 		}
-		var sequences []string
-		stopSequences.ForEach(func(_, seq gjson.Result) bool {
-			sequences = append(sequences, seq.String())
-			return true
-		})
 		out, _ = sjson.Set(out, "generationConfig.stopSequences", sequences)
 	}
 
@@ -1,8 +1,10 @@
 package claude
 
 import (
+	"reflect"
 	"testing"
 
+	chat_completions "github.com/router-for-me/CLIProxyAPI/v6/internal/translator/claude/openai/chat-completions"
 	"github.com/tidwall/gjson"
 )
 
@@ -588,3 +590,57 @@ func TestConvertClaudeRequestToOpenAI_AssistantThinkingToolUseThinkingSplit(t *t
 		t.Fatalf("Expected reasoning_content %q, got %q", "t1\n\nt2", got)
 	}
 }
+
+func stopSequenceList(value gjson.Result) []string {
+	var stops []string
+	if !value.IsArray() {
+		return []string{value.String()}
+	}
+	for _, item := range value.Array() {
+		stops = append(stops, item.String())
+	}
+	return stops
+}
+
+func TestStopSequencesSurviveOpenAIClaudeRoundTrip(t *testing.T) {
+	cases := []struct {
+		name   string
+		stop   string
+		expect []string
+	}{
+		{"single string", `"END"`, []string{"END"}},
+		{"array", `["###","</answer>","\n\nUser:"]`, []string{"###", "</answer>", "\n\nUser:"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			openaiReq := `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"stop":` + tc.stop + `}`
+			claudeReq := chat_completions.ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(openaiReq), false)
+			if got := stopSequenceList(gjson.GetBytes(claudeReq, "stop_sequences")); !reflect.DeepEqual(got, tc.expect) {
+				t.Fatalf("openai -> claude stop_sequences = %q, want %q", got, tc.expect)
+			}
+
+			back := ConvertClaudeRequestToOpenAI("gpt-4o", claudeReq, false)
+			if got := stopSequenceList(gjson.GetBytes(back, "stop")); !reflect.DeepEqual(got, tc.expect) {
+				t.Fatalf("claude -> openai stop = %q, want %q", got, tc.expect)
+			}
+		})
+	}
+}
+
+func TestConvertClaudeRequestToOpenAI_StopSequencesTruncatedToOpenAILimit(t *testing.T) {
+	input := `{"model":"claude-sonnet-4","max_tokens":64,"messages":[{"role":"user","content":"hi"}],"stop_sequences":["a","b","","c","a","d","e","f"]}`
+	out := ConvertClaudeRequestToOpenAI("gpt-4o", []byte(input), false)
+
+	want := []string{"a", "b", "c", "d"}
+	if got := stopSequenceList(gjson.GetBytes(out, "stop")); !reflect.DeepEqual(got, want) {
+		t.Fatalf("stop = %q, want %q", got, want)
+	}
+}
+
+func TestConvertClaudeRequestToOpenAI_StringStopSequence(t *testing.T) {
+	input := `{"model":"claude-sonnet-4","max_tokens":64,"messages":[{"role":"user","content":"hi"}],"stop_sequences":"STOP"}`
+	out := ConvertClaudeRequestToOpenAI("gpt-4o", []byte(input), false)
+	if got := gjson.GetBytes(out, "stop").String(); got != "STOP" {
+		t.Fatalf("stop = %q, want STOP", got)
+	}
+}
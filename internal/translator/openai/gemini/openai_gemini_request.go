@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -65,15 +66,9 @@ func ConvertGeminiRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 		}
 
 		// Stop sequences
-		if stopSequences := genConfig.Get("stopSequences"); stopSequences.Exists() && stopSequences.IsArray() {
-			var stops []string
-			stopSequences.ForEach(func(_, value gjson.Result) bool {
-				stops = append(stops, value.String())
-				return true
-			})
-			if len(stops) > 0 {
-				out, _ = sjson.Set(out, "stop", stops)
-			}
+		stops := util.LimitStopSequences(util.StopSequences(genConfig.Get("stopSequences")), util.MaxOpenAIStopSequences, "openai")
+		if len(stops) > 0 {
+			out, _ = sjson.Set(out, "stop", stops)
 		}
 
 		// Candidate count (OpenAI 'n' parameter)
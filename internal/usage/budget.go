@@ -0,0 +1,585 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// budgetRateWindow is the rolling window used to derive the burn rate.
+	budgetRateWindow = 7 * 24 * time.Hour
+	// budgetMinRateWindow keeps early-month projections from extrapolating a few minutes of data.
+	budgetMinRateWindow = time.Hour
+	// budgetCheckInterval is how often the monitor re-evaluates budgets in the background.
+	budgetCheckInterval = time.Minute
+)
+
+var defaultBudgetThresholds = []float64{50, 80, 100}
+
+// BudgetStatus reports spend and projection for one configured budget in the current period.
+type BudgetStatus struct {
+	ID                string  `json:"id"`
+	Provider          string  `json:"provider,omitempty"`
+	APIKey            string  `json:"api_key,omitempty"`
+	Tenant            string  `json:"tenant,omitempty"`
+	Period            string  `json:"period"`
+	MonthlyUSD        float64 `json:"monthly_usd"`
+	SpentUSD          float64 `json:"spent_usd"`
+	BurnRateUSDPerDay float64 `json:"burn_rate_usd_per_day"`
+	ProjectedUSD      float64 `json:"projected_usd"`
+	ProjectedPercent  float64 `json:"projected_percent"`
+	HardCap           bool    `json:"hard_cap"`
+	Capped            bool    `json:"capped"`
+	UnpricedRequests  int64   `json:"unpriced_requests,omitempty"`
+	// PremiumRequests counts Copilot premium requests this period (request count times
+	// the model's premium multiplier); they are costed at copilot-premium-request-usd each.
+	PremiumRequests float64 `json:"premium_requests,omitempty"`
+}
+
+// BudgetAlert is emitted when projected spend first crosses a threshold within a period.
+type BudgetAlert struct {
+	Timestamp time.Time    `json:"timestamp"`
+	Threshold float64      `json:"threshold_percent"`
+	Budget    BudgetStatus `json:"budget"`
+}
+
+// BudgetMonitor evaluates configured budgets against the recorded usage, raises
+// projection alerts, and tracks which budgets have reached their hard cap. Spend is kept in
+// a store of its own, fed by every usage record while budgets are configured, so budgets
+// work with usage-statistics-enabled off.
+type BudgetMonitor struct {
+	mu         sync.Mutex
+	stats      *RequestStatistics
+	now        func() time.Time
+	notify     func(BudgetAlert)
+	budgets    []config.UsageBudget
+	thresholds []float64
+	pricing    []config.ModelPrice
+	premiumUSD float64
+	webhookURL string
+	alerted    map[string]struct{}
+	capped     []config.UsageBudget
+	tenants    config.SDKConfig
+	client     *http.Client
+	startOnce  sync.Once
+}
+
+var defaultBudgetMonitor = NewBudgetMonitor(NewRequestStatistics())
+
+func init() {
+	coreusage.RegisterPlugin(budgetPlugin{monitor: defaultBudgetMonitor})
+}
+
+// budgetPlugin feeds usage records to a budget monitor.
+type budgetPlugin struct {
+	monitor *BudgetMonitor
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (p budgetPlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	p.monitor.recordSpend(ctx, record)
+}
+
+// GetBudgetMonitor returns the shared budget monitor.
+func GetBudgetMonitor() *BudgetMonitor { return defaultBudgetMonitor }
+
+// NewBudgetMonitor creates a monitor keeping spend in stats.
+func NewBudgetMonitor(stats *RequestStatistics) *BudgetMonitor {
+	m := &BudgetMonitor{
+		stats:   stats,
+		now:     time.Now,
+		alerted: make(map[string]struct{}),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	m.notify = m.deliverAlert
+	return m
+}
+
+// SetBudgetConfig applies the pricing table and budgets from cfg to the shared monitor.
+func SetBudgetConfig(cfg *config.Config) { defaultBudgetMonitor.SetConfig(cfg) }
+
+// StartBudgetMonitor re-evaluates the shared monitor periodically until ctx is done.
+func StartBudgetMonitor(ctx context.Context) { defaultBudgetMonitor.Start(ctx) }
+
+// BudgetCapped reports whether a hard-capped budget blocks requests from apiKey to provider,
+// returning the ID of the blocking budget.
+func BudgetCapped(apiKey, provider string) (string, bool) {
+	return defaultBudgetMonitor.Capped(apiKey, provider)
+}
+
+// SetConfig replaces the pricing table and budgets. Alert history for the current
+// period is kept so a reload does not re-send alerts.
+func (m *BudgetMonitor) SetConfig(cfg *config.Config) {
+	if m == nil {
+		return
+	}
+	var budgets []config.UsageBudget
+	var pricing []config.ModelPrice
+	var thresholds []float64
+	webhookURL := ""
+	if cfg != nil {
+		for i, budget := range cfg.UsageBudgets.Budgets {
+			budget.Provider = strings.ToLower(strings.TrimSpace(budget.Provider))
+			budget.APIKey = strings.TrimSpace(budget.APIKey)
+			budget.Tenant = strings.TrimSpace(budget.Tenant)
+			if budget.MonthlyUSD <= 0 {
+				log.Warnf("usage-budgets.budgets[%d]: monthly-usd must be positive; entry ignored", i)
+				continue
+			}
+			budgets = append(budgets, budget)
+		}
+		for _, price := range cfg.ModelPricing {
+			price.Model = strings.ToLower(strings.TrimSpace(price.Model))
+			if price.Model != "" {
+				pricing = append(pricing, price)
+			}
+		}
+		for _, threshold := range cfg.UsageBudgets.Thresholds {
+			if threshold > 0 {
+				thresholds = append(thresholds, threshold)
+			}
+		}
+		webhookURL = strings.TrimSpace(cfg.UsageBudgets.WebhookURL)
+	}
+	if len(thresholds) == 0 {
+		thresholds = append(thresholds, defaultBudgetThresholds...)
+	}
+	sort.Float64s(thresholds)
+
+	m.mu.Lock()
+	m.budgets = budgets
+	m.pricing = pricing
+	m.premiumUSD = cfg.CopilotPremiumRequestPrice()
+	m.thresholds = thresholds
+	m.webhookURL = webhookURL
+	m.tenants = config.SDKConfig{}
+	if cfg != nil {
+		m.tenants.Tenants = cfg.Tenants
+	}
+	m.mu.Unlock()
+	m.Check()
+}
+
+// recordSpend adds record to the spend store. Nothing is kept while no budget is configured.
+func (m *BudgetMonitor) recordSpend(ctx context.Context, record coreusage.Record) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	active := len(m.budgets) > 0
+	m.mu.Unlock()
+	if active {
+		m.stats.record(ctx, record)
+	}
+}
+
+// SpendSnapshot exports the spend store so it can be persisted across restarts.
+func (m *BudgetMonitor) SpendSnapshot() StatisticsSnapshot {
+	if m == nil {
+		return StatisticsSnapshot{}
+	}
+	return m.stats.Snapshot()
+}
+
+// RestoreSpend merges a snapshot from SpendSnapshot into the spend store.
+func (m *BudgetMonitor) RestoreSpend(snapshot StatisticsSnapshot) MergeResult {
+	if m == nil {
+		return MergeResult{}
+	}
+	return m.stats.MergeSnapshot(snapshot)
+}
+
+// Start runs Check every minute until ctx is done. Only the first call starts a loop.
+func (m *BudgetMonitor) Start(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	m.startOnce.Do(func() {
+		go func() {
+			ticker := time.NewTicker(budgetCheckInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					m.Check()
+				}
+			}
+		}()
+	})
+}
+
+// Statuses computes the current status of every budget without raising alerts.
+func (m *BudgetMonitor) Statuses() []BudgetStatus {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	budgets, pricing, premiumUSD := m.budgets, m.pricing, m.premiumUSD
+	m.mu.Unlock()
+	if len(budgets) == 0 {
+		return nil
+	}
+	return m.evaluate(budgets, pricing, premiumUSD, m.now().UTC())
+}
+
+// Check evaluates every budget, updates hard caps, and raises alerts for thresholds
+// crossed by projected spend, at most once per threshold per budget per period.
+func (m *BudgetMonitor) Check() []BudgetStatus {
+	if m == nil {
+		return nil
+	}
+	now := m.now().UTC()
+	m.mu.Lock()
+	budgets, pricing, premiumUSD, thresholds := m.budgets, m.pricing, m.premiumUSD, m.thresholds
+	m.mu.Unlock()
+	m.stats.pruneDetailsBefore(spendRetentionStart(now))
+
+	var statuses []BudgetStatus
+	if len(budgets) > 0 {
+		statuses = m.evaluate(budgets, pricing, premiumUSD, now)
+	}
+
+	var alerts []BudgetAlert
+	var capped []config.UsageBudget
+	m.mu.Lock()
+	period := budgetPeriod(now)
+	for key := range m.alerted {
+		if !strings.HasPrefix(key, period+"|") {
+			delete(m.alerted, key)
+		}
+	}
+	for i, status := range statuses {
+		for _, threshold := range thresholds {
+			if status.ProjectedPercent < threshold {
+				break
+			}
+			key := period + "|" + status.ID + "|" + formatThreshold(threshold)
+			if _, done := m.alerted[key]; done {
+				continue
+			}
+			m.alerted[key] = struct{}{}
+			alerts = append(alerts, BudgetAlert{Timestamp: now, Threshold: threshold, Budget: status})
+		}
+		if status.Capped {
+			capped = append(capped, budgets[i])
+		}
+	}
+	m.capped = capped
+	notify := m.notify
+	m.mu.Unlock()
+
+	for _, alert := range alerts {
+		log.Warnf("usage budget %s: projected spend $%.2f is %.0f%% of the $%.2f monthly budget (threshold %s%%, spent $%.2f so far)",
+			alert.Budget.ID, alert.Budget.ProjectedUSD, alert.Budget.ProjectedPercent, alert.Budget.MonthlyUSD, formatThreshold(alert.Threshold), alert.Budget.SpentUSD)
+		if notify != nil {
+			notify(alert)
+		}
+	}
+	return statuses
+}
+
+// Capped reports whether a hard-capped budget blocks requests from apiKey to provider.
+func (m *BudgetMonitor) Capped(apiKey, provider string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tenant := m.tenants.TenantForAPIKey(apiKey)
+	for _, budget := range m.capped {
+		if budgetMatches(budget, apiKey, tenant, provider) {
+			return budgetID(budget), true
+		}
+	}
+	return "", false
+}
+
+func (m *BudgetMonitor) evaluate(budgets []config.UsageBudget, pricing []config.ModelPrice, premiumUSD float64, now time.Time) []BudgetStatus {
+	m.mu.Lock()
+	tenants := m.tenants
+	m.mu.Unlock()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	monthEnd := monthStart.AddDate(0, 1, 0)
+	windowStart := now.Add(-budgetRateWindow)
+
+	type accumulator struct {
+		spent, windowSpend float64
+		premium            float64
+		unpriced           int64
+	}
+	acc := make([]accumulator, len(budgets))
+	var dataStart time.Time
+	m.stats.forEachDetail(func(apiKey, model string, detail RequestDetail) {
+		ts := detail.Timestamp.UTC()
+		if ts.After(now) {
+			return
+		}
+		if dataStart.IsZero() || ts.Before(dataStart) {
+			dataStart = ts
+		}
+		inMonth := !ts.Before(monthStart)
+		inWindow := !ts.Before(windowStart)
+		if !inMonth && !inWindow {
+			return
+		}
+		var cost, premium float64
+		priced := true
+		if strings.EqualFold(detail.Provider, "copilot") {
+			premium = copilotPremiumRequests(model, detail)
+			cost = premium * premiumUSD
+		} else {
+			cost, priced = requestCost(pricing, model, detail.Tokens)
+		}
+		tenant := tenants.TenantForAPIKey(apiKey)
+		for i, budget := range budgets {
+			if !budgetMatches(budget, apiKey, tenant, detail.Provider) {
+				continue
+			}
+			if !priced {
+				if inMonth && detail.Tokens.TotalTokens > 0 {
+					acc[i].unpriced++
+				}
+				continue
+			}
+			if inMonth {
+				acc[i].spent += cost
+				acc[i].premium += premium
+			}
+			if inWindow {
+				acc[i].windowSpend += cost
+			}
+		}
+	})
+
+	if dataStart.After(windowStart) {
+		windowStart = dataStart
+	}
+	window := now.Sub(windowStart)
+	if window < budgetMinRateWindow {
+		window = budgetMinRateWindow
+	}
+	remaining := monthEnd.Sub(now)
+
+	statuses := make([]BudgetStatus, 0, len(budgets))
+	for i, budget := range budgets {
+		ratePerSecond := acc[i].windowSpend / window.Seconds()
+		projected := acc[i].spent + ratePerSecond*remaining.Seconds()
+		status := BudgetStatus{
+			ID:                budgetID(budget),
+			Provider:          budget.Provider,
+			Period:            budgetPeriod(now),
+			MonthlyUSD:        budget.MonthlyUSD,
+			SpentUSD:          roundUSD(acc[i].spent),
+			BurnRateUSDPerDay: roundUSD(ratePerSecond * 86400),
+			ProjectedUSD:      roundUSD(projected),
+			ProjectedPercent:  math.Round(projected/budget.MonthlyUSD*1000) / 10,
+			HardCap:           budget.HardCap,
+			Capped:            budget.HardCap && acc[i].spent >= budget.MonthlyUSD,
+			UnpricedRequests:  acc[i].unpriced,
+			PremiumRequests:   math.Round(acc[i].premium*100) / 100,
+		}
+		if budget.APIKey != "" {
+			status.APIKey = util.HideAPIKey(budget.APIKey)
+		}
+		status.Tenant = budget.Tenant
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// deliverAlert posts alert to the configured webhook. Delivery is asynchronous and best-effort.
+func (m *BudgetMonitor) deliverAlert(alert BudgetAlert) {
+	m.mu.Lock()
+	url, client := m.webhookURL, m.client
+	m.mu.Unlock()
+	if url == "" {
+		return
+	}
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return
+	}
+	go func() {
+		req, errReq := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if errReq != nil {
+			log.WithError(errReq).Warn("usage budget: failed to build webhook request")
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, errDo := client.Do(req)
+		if errDo != nil {
+			log.WithError(errDo).Warn("usage budget: webhook delivery failed")
+			return
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Warnf("usage budget: webhook returned status %d", resp.StatusCode)
+		}
+	}()
+}
+
+// spendRetentionStart is the oldest timestamp evaluate reads: the start of the month or of
+// the burn-rate window, whichever is earlier.
+func spendRetentionStart(now time.Time) time.Time {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if windowStart := now.Add(-budgetRateWindow); windowStart.Before(monthStart) {
+		return windowStart
+	}
+	return monthStart
+}
+
+// pruneDetailsBefore drops request details older than cutoff. Only the details are
+// pruned; the totals are left as they are, so it suits stores read through forEachDetail.
+func (s *RequestStatistics) pruneDetailsBefore(cutoff time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for apiKey, stats := range s.apis {
+		if stats == nil {
+			delete(s.apis, apiKey)
+			continue
+		}
+		for model, modelStatsValue := range stats.Models {
+			if modelStatsValue == nil {
+				delete(stats.Models, model)
+				continue
+			}
+			kept := modelStatsValue.Details[:0]
+			for _, detail := range modelStatsValue.Details {
+				if !detail.Timestamp.Before(cutoff) {
+					kept = append(kept, detail)
+				}
+			}
+			modelStatsValue.Details = kept
+			if len(kept) == 0 {
+				delete(stats.Models, model)
+			}
+		}
+		if len(stats.Models) == 0 {
+			delete(s.apis, apiKey)
+		}
+	}
+}
+
+// forEachDetail calls fn for every recorded request detail while holding the read lock.
+func (s *RequestStatistics) forEachDetail(fn func(apiKey, model string, detail RequestDetail)) {
+	if s == nil {
+		return
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for apiKey, stats := range s.apis {
+		if stats == nil {
+			continue
+		}
+		for model, modelStatsValue := range stats.Models {
+			if modelStatsValue == nil {
+				continue
+			}
+			for _, detail := range modelStatsValue.Details {
+				fn(apiKey, model, detail)
+			}
+		}
+	}
+}
+
+// copilotPremiumRequests returns the premium requests a Copilot request consumed.
+// Copilot bills successful requests by the model's premium multiplier, not by tokens.
+func copilotPremiumRequests(model string, detail RequestDetail) float64 {
+	if detail.Failed {
+		return 0
+	}
+	return registry.CopilotPremiumRequests(model)
+}
+
+// requestCost prices one request. It reports false when no price matches model.
+func requestCost(pricing []config.ModelPrice, model string, tokens TokenStats) (float64, bool) {
+	price, ok := lookupModelPrice(pricing, model)
+	if !ok {
+		return 0, false
+	}
+	cachedRate := price.CachedInput
+	if cachedRate == 0 {
+		cachedRate = price.Input
+	}
+	cached := tokens.CachedTokens
+	if cached > tokens.InputTokens {
+		cached = tokens.InputTokens
+	}
+	uncached := tokens.InputTokens - cached
+	cost := float64(uncached)*price.Input + float64(cached)*cachedRate + float64(tokens.OutputTokens+tokens.ReasoningTokens)*price.Output
+	return cost / 1e6, true
+}
+
+// lookupModelPrice prefers an exact model match, then the longest matching "prefix*" entry.
+func lookupModelPrice(pricing []config.ModelPrice, model string) (config.ModelPrice, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	var best config.ModelPrice
+	bestLen := -1
+	for _, price := range pricing {
+		if price.Model == model {
+			return price, true
+		}
+		if prefix, ok := strings.CutSuffix(price.Model, "*"); ok && strings.HasPrefix(model, prefix) && len(prefix) > bestLen {
+			best, bestLen = price, len(prefix)
+		}
+	}
+	return best, bestLen >= 0
+}
+
+func budgetMatches(budget config.UsageBudget, apiKey, tenant, provider string) bool {
+	if budget.Provider != "" && !strings.EqualFold(budget.Provider, provider) {
+		return false
+	}
+	if budget.APIKey != "" && budget.APIKey != apiKey {
+		return false
+	}
+	if budget.Tenant != "" && budget.Tenant != tenant {
+		return false
+	}
+	return true
+}
+
+func budgetID(budget config.UsageBudget) string {
+	var parts []string
+	if budget.Provider != "" {
+		parts = append(parts, "provider="+budget.Provider)
+	}
+	if budget.APIKey != "" {
+		parts = append(parts, "api-key="+util.HideAPIKey(budget.APIKey))
+	}
+	if budget.Tenant != "" {
+		parts = append(parts, "tenant="+budget.Tenant)
+	}
+	if len(parts) == 0 {
+		return "all"
+	}
+	return strings.Join(parts, ",")
+}
+
+func budgetPeriod(now time.Time) string { return now.UTC().Format("2006-01") }
+
+func formatThreshold(threshold float64) string {
+	return strconv.FormatFloat(threshold, 'f', -1, 64)
+}
+
+func roundUSD(v float64) float64 { return math.Round(v*10000) / 10000 }
@@ -0,0 +1,258 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// budgetTimeline records one request per day at noon UTC for days [from, to] of April 2026.
+func budgetTimeline(stats *RequestStatistics, provider, apiKey, model string, inputTokens int64, from, to int) {
+	for day := from; day <= to; day++ {
+		stats.Record(context.Background(), coreusage.Record{
+			Provider:    provider,
+			Model:       model,
+			APIKey:      apiKey,
+			RequestedAt: time.Date(2026, time.April, day, 12, 0, 0, 0, time.UTC),
+			Detail:      coreusage.Detail{InputTokens: inputTokens},
+		})
+	}
+}
+
+func newTestBudgetMonitor(now *time.Time, alerts *[]BudgetAlert, cfg *config.Config) (*BudgetMonitor, *RequestStatistics) {
+	stats := NewRequestStatistics()
+	m := NewBudgetMonitor(stats)
+	m.now = func() time.Time { return *now }
+	m.notify = func(alert BudgetAlert) { *alerts = append(*alerts, alert) }
+	m.SetConfig(cfg)
+	return m, stats
+}
+
+func budgetTestConfig(budgets ...config.UsageBudget) *config.Config {
+	return &config.Config{
+		ModelPricing: []config.ModelPrice{
+			{Model: "gpt-*", Input: 2, Output: 8},
+			{Model: "gpt-5", Input: 1, Output: 4},
+		},
+		UsageBudgets: config.UsageBudgetConfig{Budgets: budgets},
+	}
+}
+
+func TestBudgetMonitor_ProjectsFromRollingBurnRate(t *testing.T) {
+	now := time.Date(2026, time.April, 11, 0, 0, 0, 0, time.UTC)
+	var alerts []BudgetAlert
+	m, stats := newTestBudgetMonitor(&now, &alerts, budgetTestConfig(config.UsageBudget{Provider: "codex", MonthlyUSD: 40}))
+
+	// $1/day for April 1-10 via the exact gpt-5 price, plus traffic the budget must ignore.
+	budgetTimeline(stats, "codex", "k1", "gpt-5", 1_000_000, 1, 10)
+	budgetTimeline(stats, "claude", "k1", "gpt-5", 1_000_000, 1, 10)
+	budgetTimeline(stats, "codex", "k1", "unpriced-model", 500, 9, 10)
+
+	statuses := m.Statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+	got := statuses[0]
+	// Spent $10; the 7-day window holds April 4-10 ($7), so $1/day over the 20 remaining days.
+	if got.SpentUSD != 10 || got.BurnRateUSDPerDay != 1 || got.ProjectedUSD != 30 || got.ProjectedPercent != 75 {
+		t.Fatalf("status = %+v, want spent 10, rate 1/day, projected 30 (75%%)", got)
+	}
+	if got.ID != "provider=codex" || got.Period != "2026-04" || got.UnpricedRequests != 2 {
+		t.Fatalf("status = %+v", got)
+	}
+	if len(alerts) != 0 {
+		t.Fatalf("Statuses must not raise alerts, got %+v", alerts)
+	}
+}
+
+func TestBudgetMonitor_ShortHistoryUsesMinimumWindow(t *testing.T) {
+	now := time.Date(2026, time.April, 1, 0, 30, 0, 0, time.UTC)
+	var alerts []BudgetAlert
+	m, stats := newTestBudgetMonitor(&now, &alerts, budgetTestConfig(config.UsageBudget{MonthlyUSD: 1000}))
+
+	stats.Record(context.Background(), coreusage.Record{
+		Provider:    "codex",
+		Model:       "gpt-4.1",
+		RequestedAt: now.Add(-10 * time.Minute),
+		Detail:      coreusage.Detail{InputTokens: 500_000},
+	})
+
+	got := m.Statuses()[0]
+	// $1 via the gpt-* prefix price, extrapolated over at least one hour rather than ten minutes.
+	if got.SpentUSD != 1 || got.BurnRateUSDPerDay != 24 {
+		t.Fatalf("status = %+v, want spent 1 and rate 24/day", got)
+	}
+}
+
+func TestBudgetMonitor_AlertsOncePerThresholdPerPeriod(t *testing.T) {
+	now := time.Date(2026, time.April, 11, 0, 0, 0, 0, time.UTC)
+	var alerts []BudgetAlert
+	m, stats := newTestBudgetMonitor(&now, &alerts, budgetTestConfig(config.UsageBudget{APIKey: "k1", MonthlyUSD: 40}))
+
+	budgetTimeline(stats, "codex", "k1", "gpt-5", 1_000_000, 1, 10)
+	m.Check()
+	if len(alerts) != 1 || alerts[0].Threshold != 50 {
+		t.Fatalf("first check alerts = %+v, want one 50%% alert", alerts)
+	}
+	m.Check()
+	m.SetConfig(budgetTestConfig(config.UsageBudget{APIKey: "k1", MonthlyUSD: 40}))
+	if len(alerts) != 1 {
+		t.Fatalf("repeat checks and reloads must not re-alert, got %+v", alerts)
+	}
+
+	// A heavy day raises both spend and burn rate past 80% and 100%.
+	budgetTimeline(stats, "codex", "k1", "gpt-5", 10_000_000, 10, 10)
+	m.Check()
+	if len(alerts) != 3 || alerts[1].Threshold != 80 || alerts[2].Threshold != 100 {
+		t.Fatalf("alerts after spike = %+v, want 80%% and 100%%", alerts)
+	}
+	m.Check()
+	if len(alerts) != 3 {
+		t.Fatalf("thresholds re-alerted within the period: %+v", alerts)
+	}
+
+	// A new month starts a new period, so crossed thresholds alert again.
+	now = time.Date(2026, time.May, 11, 0, 0, 0, 0, time.UTC)
+	for day := 1; day <= 10; day++ {
+		stats.Record(context.Background(), coreusage.Record{
+			Provider:    "codex",
+			Model:       "gpt-5",
+			APIKey:      "k1",
+			RequestedAt: time.Date(2026, time.May, day, 12, 0, 0, 0, time.UTC),
+			Detail:      coreusage.Detail{InputTokens: 1_000_000},
+		})
+	}
+	m.Check()
+	if len(alerts) != 4 || alerts[3].Threshold != 50 || alerts[3].Budget.Period != "2026-05" {
+		t.Fatalf("alerts in new period = %+v", alerts)
+	}
+}
+
+func TestBudgetMonitor_HardCapBlocksMatchingKeyOnly(t *testing.T) {
+	now := time.Date(2026, time.April, 11, 0, 0, 0, 0, time.UTC)
+	var alerts []BudgetAlert
+	m, stats := newTestBudgetMonitor(&now, &alerts, budgetTestConfig(
+		config.UsageBudget{APIKey: "k1", MonthlyUSD: 12, HardCap: true},
+		config.UsageBudget{Provider: "codex", MonthlyUSD: 5},
+	))
+
+	budgetTimeline(stats, "codex", "k1", "gpt-5", 1_000_000, 1, 10)
+	m.Check()
+	if _, capped := m.Capped("k1", "codex"); capped {
+		t.Fatal("k1 capped before reaching its budget")
+	}
+	if _, capped := m.Capped("k2", "codex"); capped {
+		t.Fatal("provider budget without hard-cap must not block")
+	}
+
+	budgetTimeline(stats, "claude", "k1", "gpt-5", 1_000_000, 9, 10)
+	m.Check()
+	if id, capped := m.Capped("k1", "gemini"); !capped || id == "" {
+		t.Fatalf("k1 should be capped across providers, got %q %v", id, capped)
+	}
+	if _, capped := m.Capped("k2", "codex"); capped {
+		t.Fatal("other API keys must not be capped")
+	}
+
+	m.SetConfig(budgetTestConfig(config.UsageBudget{APIKey: "k1", MonthlyUSD: 100, HardCap: true}))
+	if _, capped := m.Capped("k1", "codex"); capped {
+		t.Fatal("raising the budget should lift the cap")
+	}
+}
+
+func TestBudgetMonitor_CopilotCostsPremiumRequests(t *testing.T) {
+	now := time.Date(2026, time.April, 11, 0, 0, 0, 0, time.UTC)
+	var alerts []BudgetAlert
+	cfg := budgetTestConfig(config.UsageBudget{Provider: "copilot", MonthlyUSD: 100})
+	cfg.CopilotPremiumRequestUSD = 0.5
+	m, stats := newTestBudgetMonitor(&now, &alerts, cfg)
+
+	// gpt-5 has token pricing configured, but Copilot bills it as one premium request.
+	budgetTimeline(stats, "copilot", "k1", "gpt-5", 1_000_000, 1, 4)
+	budgetTimeline(stats, "copilot", "k1", "claude-opus-41", 10, 5, 5)
+	budgetTimeline(stats, "copilot", "k1", "gpt-4.1", 1_000_000, 6, 10)
+	stats.Record(context.Background(), coreusage.Record{
+		Provider:    "copilot",
+		Model:       "claude-opus-41",
+		RequestedAt: time.Date(2026, time.April, 10, 13, 0, 0, 0, time.UTC),
+		Failed:      true,
+	})
+
+	statuses := m.Statuses()
+	if len(statuses) != 1 {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+	got := statuses[0]
+	// 4 x gpt-5 (1x) + 1 x claude-opus-41 (10x) + 5 x gpt-4.1 (0x) = 14 premium requests at $0.50.
+	if got.PremiumRequests != 14 || got.SpentUSD != 7 || got.UnpricedRequests != 0 {
+		t.Fatalf("status = %+v, want 14 premium requests and $7 spent", got)
+	}
+}
+
+func TestRequestStatistics_RecordsEstimatedCost(t *testing.T) {
+	SetBudgetConfig(budgetTestConfig())
+	t.Cleanup(func() { SetBudgetConfig(nil) })
+
+	stats := NewRequestStatistics()
+	stats.Record(context.Background(), coreusage.Record{
+		Provider: "codex", Model: "gpt-5", APIKey: "k1",
+		Detail: coreusage.Detail{InputTokens: 2000, OutputTokens: 500},
+	})
+	stats.Record(context.Background(), coreusage.Record{
+		Provider: "codex", Model: "unpriced-model", APIKey: "k1",
+		Detail: coreusage.Detail{InputTokens: 2000},
+	})
+
+	models := stats.Snapshot().APIs["k1"].Models
+	// 2000 input at $1/M plus 500 output at $4/M.
+	if got := models["gpt-5"].Details[0].CostUSD; got != 0.004 {
+		t.Fatalf("gpt-5 cost = %v, want 0.004", got)
+	}
+	if got := models["unpriced-model"].Details[0].CostUSD; got != 0 {
+		t.Fatalf("unpriced cost = %v, want 0", got)
+	}
+}
+
+func TestBudgetMonitor_TracksSpendWithStatisticsDisabled(t *testing.T) {
+	SetStatisticsEnabled(false)
+	t.Cleanup(func() { SetStatisticsEnabled(true) })
+
+	now := time.Date(2026, time.April, 11, 0, 0, 0, 0, time.UTC)
+	var alerts []BudgetAlert
+	m, stats := newTestBudgetMonitor(&now, &alerts, budgetTestConfig(config.UsageBudget{APIKey: "k1", MonthlyUSD: 5, HardCap: true}))
+	plugin := budgetPlugin{monitor: m}
+	for day := 1; day <= 10; day++ {
+		plugin.HandleUsage(context.Background(), coreusage.Record{
+			Provider:    "codex",
+			Model:       "gpt-5",
+			APIKey:      "k1",
+			RequestedAt: time.Date(2026, time.April, day, 12, 0, 0, 0, time.UTC),
+			Detail:      coreusage.Detail{InputTokens: 1_000_000},
+		})
+	}
+
+	if snapshot := GetRequestStatistics().Snapshot(); snapshot.APIs["k1"].TotalRequests != 0 {
+		t.Fatalf("statistics recorded while disabled: %+v", snapshot.APIs["k1"])
+	}
+	m.Check()
+	if _, capped := m.Capped("k1", "codex"); !capped {
+		t.Fatalf("hard cap not enforced with statistics disabled: %+v", m.Statuses())
+	}
+
+	// The spend store survives a restart through SpendSnapshot and RestoreSpend.
+	restored, _ := newTestBudgetMonitor(&now, &alerts, budgetTestConfig(config.UsageBudget{APIKey: "k1", MonthlyUSD: 5, HardCap: true}))
+	restored.RestoreSpend(m.SpendSnapshot())
+	if got := restored.Statuses()[0].SpentUSD; got != 10 {
+		t.Fatalf("restored spend = %v, want 10", got)
+	}
+
+	// Details older than the month and burn-rate window are pruned by Check.
+	now = time.Date(2026, time.June, 1, 0, 0, 0, 0, time.UTC)
+	m.Check()
+	if snapshot := stats.Snapshot(); len(snapshot.APIs) != 0 {
+		t.Fatalf("stale spend kept: %+v", snapshot.APIs)
+	}
+}
@@ -0,0 +1,28 @@
+package usage
+
+import (
+	"strings"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// EstimateCost prices one request with the shared model-pricing table. It reports false
+// when no price matches model.
+func EstimateCost(provider, model string, detail coreusage.Detail) (float64, bool) {
+	return defaultBudgetMonitor.EstimateCost(provider, model, normaliseDetail(detail))
+}
+
+// EstimateCost prices one successful request: Copilot requests as premium requests times
+// copilot-premium-request-usd, every other provider by tokens.
+func (m *BudgetMonitor) EstimateCost(provider, model string, tokens TokenStats) (float64, bool) {
+	if m == nil {
+		return 0, false
+	}
+	m.mu.Lock()
+	pricing, premiumUSD := m.pricing, m.premiumUSD
+	m.mu.Unlock()
+	if strings.EqualFold(provider, "copilot") {
+		return copilotPremiumRequests(model, RequestDetail{}) * premiumUSD, true
+	}
+	return requestCost(pricing, model, tokens)
+}
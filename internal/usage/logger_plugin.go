@@ -90,10 +90,14 @@ type modelStats struct {
 // RequestDetail stores the timestamp and token usage for a single request.
 type RequestDetail struct {
 	Timestamp time.Time  `json:"timestamp"`
+	Provider  string     `json:"provider,omitempty"`
 	Source    string     `json:"source"`
 	AuthIndex string     `json:"auth_index"`
 	Tokens    TokenStats `json:"tokens"`
 	Failed    bool       `json:"failed"`
+	// CostUSD is the estimated cost from model-pricing at the time of the request; zero
+	// when the request failed or no price matched.
+	CostUSD float64 `json:"cost_usd,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -152,10 +156,16 @@ func NewRequestStatistics() *RequestStatistics {
 
 // Record ingests a new usage record and updates the aggregates.
 func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record) {
-	if s == nil {
+	if !statisticsEnabled.Load() {
 		return
 	}
-	if !statisticsEnabled.Load() {
+	s.record(ctx, record)
+}
+
+// record ingests record regardless of usage-statistics-enabled; the budget monitor keeps
+// its own store this way.
+func (s *RequestStatistics) record(ctx context.Context, record coreusage.Record) {
+	if s == nil {
 		return
 	}
 	timestamp := record.RequestedAt
@@ -179,6 +189,10 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	}
 	dayKey := timestamp.Format("2006-01-02")
 	hourKey := timestamp.Hour()
+	var cost float64
+	if success {
+		cost, _ = EstimateCost(record.Provider, modelName, record.Detail)
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -198,10 +212,12 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	}
 	s.updateAPIStats(stats, modelName, RequestDetail{
 		Timestamp: timestamp,
+		Provider:  record.Provider,
 		Source:    record.Source,
 		AuthIndex: record.AuthIndex,
 		Tokens:    detail,
 		Failed:    failed,
+		CostUSD:   cost,
 	})
 
 	s.requestsByDay[dayKey]++
@@ -282,6 +298,42 @@ func (s *RequestStatistics) Snapshot() StatisticsSnapshot {
 	return result
 }
 
+// FilterAPIs returns a snapshot restricted to the API keys accepted by keep, with the
+// totals and day/hour breakdowns recomputed from the retained request details.
+func (s StatisticsSnapshot) FilterAPIs(keep func(apiKey string) bool) StatisticsSnapshot {
+	result := StatisticsSnapshot{
+		APIs:           make(map[string]APISnapshot),
+		RequestsByDay:  make(map[string]int64),
+		RequestsByHour: make(map[string]int64),
+		TokensByDay:    make(map[string]int64),
+		TokensByHour:   make(map[string]int64),
+	}
+	for apiName, api := range s.APIs {
+		if !keep(apiName) {
+			continue
+		}
+		result.APIs[apiName] = api
+		result.TotalRequests += api.TotalRequests
+		result.TotalTokens += api.TotalTokens
+		for _, model := range api.Models {
+			for _, detail := range model.Details {
+				if detail.Failed {
+					result.FailureCount++
+				} else {
+					result.SuccessCount++
+				}
+				dayKey := detail.Timestamp.Format("2006-01-02")
+				hourKey := formatHour(detail.Timestamp.Hour())
+				result.RequestsByDay[dayKey]++
+				result.RequestsByHour[hourKey]++
+				result.TokensByDay[dayKey] += detail.Tokens.TotalTokens
+				result.TokensByHour[hourKey] += detail.Tokens.TotalTokens
+			}
+		}
+	}
+	return result
+}
+
 type MergeResult struct {
 	Added   int64 `json:"added"`
 	Skipped int64 `json:"skipped"`
@@ -0,0 +1,66 @@
+package util
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// CredentialFilePerm is the mode credential files are written with and fixed to.
+const CredentialFilePerm os.FileMode = 0o600
+
+// ExposedCredentialFile describes a credential file readable by group or other users.
+type ExposedCredentialFile struct {
+	Path  string
+	Mode  os.FileMode
+	Fixed bool
+}
+
+// CheckCredentialPermissions scans dir for JSON credential files that group or other
+// users can read. With fix set, such files are chmod'd to CredentialFilePerm. Hidden
+// entries (such as file transaction staging directories) are skipped. It does nothing on
+// Windows, where POSIX mode bits do not describe access.
+func CheckCredentialPermissions(dir string, fix bool) ([]ExposedCredentialFile, error) {
+	if runtime.GOOS == "windows" || strings.TrimSpace(dir) == "" {
+		return nil, nil
+	}
+	var exposed []ExposedCredentialFile
+	errWalk := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() || !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+			return nil
+		}
+		info, errInfo := d.Info()
+		if errInfo != nil {
+			return errInfo
+		}
+		mode := info.Mode().Perm()
+		if mode&0o044 == 0 {
+			return nil
+		}
+		file := ExposedCredentialFile{Path: path, Mode: mode}
+		if fix {
+			if errChmod := os.Chmod(path, CredentialFilePerm); errChmod != nil {
+				return fmt.Errorf("fix permissions of %s: %w", path, errChmod)
+			}
+			file.Fixed = true
+		}
+		exposed = append(exposed, file)
+		return nil
+	})
+	if errWalk != nil {
+		return exposed, fmt.Errorf("credential permissions: %w", errWalk)
+	}
+	return exposed, nil
+}
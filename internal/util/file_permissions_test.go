@@ -0,0 +1,60 @@
+//go:build !windows
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCredential(t *testing.T, path string, perm os.FileMode) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"type":"codex"}`), perm); err != nil {
+		t.Fatal(err)
+	}
+	// WriteFile is subject to the umask; set the mode explicitly.
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckCredentialPermissions_WarnsAndOptionallyFixes(t *testing.T) {
+	dir := t.TempDir()
+	exposedPath := filepath.Join(dir, "codex-user.json")
+	nestedPath := filepath.Join(dir, "team", "claude-user.json")
+	writeCredential(t, exposedPath, 0o644)
+	writeCredential(t, nestedPath, 0o640)
+	writeCredential(t, filepath.Join(dir, "private.json"), 0o600)
+	writeCredential(t, filepath.Join(dir, ".txn-abc", "staged-0"), 0o644)
+
+	exposed, err := CheckCredentialPermissions(dir, false)
+	if err != nil {
+		t.Fatalf("CheckCredentialPermissions: %v", err)
+	}
+	if len(exposed) != 2 || exposed[0].Path != exposedPath || exposed[0].Mode != 0o644 || exposed[0].Fixed || exposed[1].Path != nestedPath {
+		t.Fatalf("exposed = %+v", exposed)
+	}
+	if info, _ := os.Stat(exposedPath); info.Mode().Perm() != 0o644 {
+		t.Fatalf("report-only scan changed mode to %o", info.Mode().Perm())
+	}
+
+	exposed, err = CheckCredentialPermissions(dir, true)
+	if err != nil {
+		t.Fatalf("CheckCredentialPermissions fix: %v", err)
+	}
+	if len(exposed) != 2 || !exposed[0].Fixed {
+		t.Fatalf("exposed after fix = %+v", exposed)
+	}
+	for _, path := range []string{exposedPath, nestedPath} {
+		if info, _ := os.Stat(path); info.Mode().Perm() != CredentialFilePerm {
+			t.Fatalf("%s mode = %o, want %o", path, info.Mode().Perm(), CredentialFilePerm)
+		}
+	}
+	if exposed, _ = CheckCredentialPermissions(dir, false); len(exposed) != 0 {
+		t.Fatalf("still exposed after fix: %+v", exposed)
+	}
+}
@@ -0,0 +1,192 @@
+package util
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	fileTxnPrefix        = ".txn-"
+	fileTxnJournalSuffix = ".journal"
+	fileTxnStagedPrefix  = "staged-"
+)
+
+// FileTransaction writes several related files under one directory so that either all of
+// them or none of them become visible, even across a crash.
+//
+// Files are first staged in a hidden ".txn-<id>" directory. Commit then writes a journal
+// listing every staged file and its target, renames the staged files into place and
+// removes the journal. RecoverFileTransactions, run at startup, finishes transactions
+// whose journal exists (roll forward) and discards staging directories without one
+// (roll back).
+type FileTransaction struct {
+	dir      string
+	stageDir string
+	entries  []fileTxnEntry
+	done     bool
+}
+
+type fileTxnEntry struct {
+	Staged string `json:"staged"`
+	Target string `json:"target"`
+}
+
+type fileTxnJournal struct {
+	Files []fileTxnEntry `json:"files"`
+}
+
+// NewFileTransaction starts a transaction whose targets all live under dir.
+func NewFileTransaction(dir string) (*FileTransaction, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("file transaction: create directory failed: %w", err)
+	}
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return nil, fmt.Errorf("file transaction: generate id failed: %w", err)
+	}
+	stageDir := filepath.Join(dir, fileTxnPrefix+hex.EncodeToString(buf[:]))
+	if err := os.Mkdir(stageDir, 0o700); err != nil {
+		return nil, fmt.Errorf("file transaction: create staging directory failed: %w", err)
+	}
+	return &FileTransaction{dir: dir, stageDir: stageDir}, nil
+}
+
+// Stage writes data for the target name, relative to the transaction directory. Nothing
+// is visible at the target until Commit.
+func (t *FileTransaction) Stage(name string, data []byte, perm os.FileMode) error {
+	if t.done {
+		return fmt.Errorf("file transaction: already finished")
+	}
+	target := filepath.Clean(name)
+	if target == "." || !filepath.IsLocal(target) {
+		return fmt.Errorf("file transaction: target %q must be relative to %s", name, t.dir)
+	}
+	staged := fmt.Sprintf("%s%d", fileTxnStagedPrefix, len(t.entries))
+	if err := AtomicWriteFile(filepath.Join(t.stageDir, staged), data, perm); err != nil {
+		return fmt.Errorf("file transaction: stage %s failed: %w", name, err)
+	}
+	t.entries = append(t.entries, fileTxnEntry{Staged: staged, Target: target})
+	return nil
+}
+
+// Commit makes every staged file visible at its target.
+func (t *FileTransaction) Commit() error {
+	journalPath, err := t.writeJournal()
+	if err != nil {
+		return err
+	}
+	// From here on the transaction is durable: a failure is finished by recovery.
+	return applyFileTxn(t.dir, t.stageDir, journalPath, t.entries)
+}
+
+// writeJournal records the staged files, which marks the transaction as committed.
+func (t *FileTransaction) writeJournal() (string, error) {
+	if t.done {
+		return "", fmt.Errorf("file transaction: already finished")
+	}
+	t.done = true
+	raw, err := json.Marshal(fileTxnJournal{Files: t.entries})
+	if err != nil {
+		_ = os.RemoveAll(t.stageDir)
+		return "", fmt.Errorf("file transaction: marshal journal failed: %w", err)
+	}
+	journalPath := t.stageDir + fileTxnJournalSuffix
+	if err = AtomicWriteFile(journalPath, raw, 0o600); err != nil {
+		_ = os.RemoveAll(t.stageDir)
+		return "", fmt.Errorf("file transaction: write journal failed: %w", err)
+	}
+	return journalPath, nil
+}
+
+// Abort discards all staged files.
+func (t *FileTransaction) Abort() {
+	if t.done {
+		return
+	}
+	t.done = true
+	_ = os.RemoveAll(t.stageDir)
+}
+
+// applyFileTxn renames the staged files into place and removes the journal. Entries
+// whose staged file is gone were already applied and are skipped, so it is safe to run
+// more than once.
+func applyFileTxn(dir, stageDir, journalPath string, entries []fileTxnEntry) error {
+	for _, entry := range entries {
+		staged := filepath.Join(stageDir, entry.Staged)
+		if _, err := os.Stat(staged); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		target := filepath.Join(dir, entry.Target)
+		if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+			return fmt.Errorf("file transaction: create directory for %s failed: %w", entry.Target, err)
+		}
+		if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("file transaction: replace %s failed: %w", entry.Target, err)
+		}
+		if err := os.Rename(staged, target); err != nil {
+			return fmt.Errorf("file transaction: commit %s failed: %w", entry.Target, err)
+		}
+	}
+	if err := os.Remove(journalPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("file transaction: remove journal failed: %w", err)
+	}
+	_ = os.RemoveAll(stageDir)
+	return nil
+}
+
+// RecoverFileTransactions completes or discards transactions left in dir by a crash.
+// It must run before anything reads the targets and while no transaction is in progress,
+// i.e. at startup. It returns the number of transactions rolled forward and rolled back.
+func RecoverFileTransactions(dir string) (rolledForward, rolledBack int, err error) {
+	entries, errRead := os.ReadDir(dir)
+	if errRead != nil {
+		if errors.Is(errRead, os.ErrNotExist) {
+			return 0, 0, nil
+		}
+		return 0, 0, fmt.Errorf("file transaction: read %s failed: %w", dir, errRead)
+	}
+	var errs []error
+	journals := make(map[string]bool)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, fileTxnPrefix) || !strings.HasSuffix(name, fileTxnJournalSuffix) {
+			continue
+		}
+		stageDir := filepath.Join(dir, strings.TrimSuffix(name, fileTxnJournalSuffix))
+		journals[filepath.Base(stageDir)] = true
+		journalPath := filepath.Join(dir, name)
+		raw, errJournal := os.ReadFile(journalPath)
+		if errJournal != nil {
+			errs = append(errs, fmt.Errorf("file transaction: read journal %s failed: %w", name, errJournal))
+			continue
+		}
+		var journal fileTxnJournal
+		if errJournal = json.Unmarshal(raw, &journal); errJournal != nil {
+			errs = append(errs, fmt.Errorf("file transaction: parse journal %s failed: %w", name, errJournal))
+			continue
+		}
+		if errApply := applyFileTxn(dir, stageDir, journalPath, journal.Files); errApply != nil {
+			errs = append(errs, errApply)
+			continue
+		}
+		rolledForward++
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || !strings.HasPrefix(name, fileTxnPrefix) || journals[name] {
+			continue
+		}
+		if errRemove := os.RemoveAll(filepath.Join(dir, name)); errRemove != nil {
+			errs = append(errs, fmt.Errorf("file transaction: discard %s failed: %w", name, errRemove))
+			continue
+		}
+		rolledBack++
+	}
+	return rolledForward, rolledBack, errors.Join(errs...)
+}
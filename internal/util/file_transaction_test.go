@@ -0,0 +1,119 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func stageLinkedCredentials(t *testing.T, dir string) *FileTransaction {
+	t.Helper()
+	txn, err := NewFileTransaction(dir)
+	if err != nil {
+		t.Fatalf("NewFileTransaction: %v", err)
+	}
+	if err = txn.Stage("oauth-user.json", []byte(`{"type":"oauth","v":2}`), 0o600); err != nil {
+		t.Fatalf("Stage oauth: %v", err)
+	}
+	if err = txn.Stage("apikey-user.json", []byte(`{"type":"apikey","v":2}`), 0o600); err != nil {
+		t.Fatalf("Stage apikey: %v", err)
+	}
+	return txn
+}
+
+func readFileOrEmpty(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func assertNoTransactionLeftovers(t *testing.T, dir string) {
+	t.Helper()
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), fileTxnPrefix) {
+			t.Fatalf("transaction leftover %s", entry.Name())
+		}
+	}
+}
+
+func TestFileTransaction_CommitWritesAllFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := stageLinkedCredentials(t, dir).Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if got := readFileOrEmpty(t, filepath.Join(dir, "oauth-user.json")); got != `{"type":"oauth","v":2}` {
+		t.Fatalf("oauth file = %q", got)
+	}
+	if got := readFileOrEmpty(t, filepath.Join(dir, "apikey-user.json")); got != `{"type":"apikey","v":2}` {
+		t.Fatalf("apikey file = %q", got)
+	}
+	assertNoTransactionLeftovers(t, dir)
+}
+
+func TestRecoverFileTransactions_RollsBackUncommitted(t *testing.T) {
+	dir := t.TempDir()
+	oauthPath := filepath.Join(dir, "oauth-user.json")
+	if err := os.WriteFile(oauthPath, []byte(`{"type":"oauth","v":1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// Crash after staging, before Commit wrote the journal.
+	stageLinkedCredentials(t, dir)
+
+	forward, back, err := RecoverFileTransactions(dir)
+	if err != nil || forward != 0 || back != 1 {
+		t.Fatalf("RecoverFileTransactions = %d, %d, %v; want 0, 1, nil", forward, back, err)
+	}
+	if got := readFileOrEmpty(t, oauthPath); got != `{"type":"oauth","v":1}` {
+		t.Fatalf("oauth file changed to %q", got)
+	}
+	if got := readFileOrEmpty(t, filepath.Join(dir, "apikey-user.json")); got != "" {
+		t.Fatalf("apikey file appeared: %q", got)
+	}
+	assertNoTransactionLeftovers(t, dir)
+}
+
+func TestRecoverFileTransactions_RollsForwardCommitted(t *testing.T) {
+	dir := t.TempDir()
+	oauthPath := filepath.Join(dir, "oauth-user.json")
+	if err := os.WriteFile(oauthPath, []byte(`{"type":"oauth","v":1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	txn := stageLinkedCredentials(t, dir)
+	if _, err := txn.writeJournal(); err != nil {
+		t.Fatalf("writeJournal: %v", err)
+	}
+	// Crash after the first rename: one target is new, the other is still missing.
+	if err := os.Rename(filepath.Join(txn.stageDir, txn.entries[0].Staged), oauthPath); err != nil {
+		t.Fatal(err)
+	}
+
+	forward, back, err := RecoverFileTransactions(dir)
+	if err != nil || forward != 1 || back != 0 {
+		t.Fatalf("RecoverFileTransactions = %d, %d, %v; want 1, 0, nil", forward, back, err)
+	}
+	if got := readFileOrEmpty(t, oauthPath); got != `{"type":"oauth","v":2}` {
+		t.Fatalf("oauth file = %q", got)
+	}
+	if got := readFileOrEmpty(t, filepath.Join(dir, "apikey-user.json")); got != `{"type":"apikey","v":2}` {
+		t.Fatalf("apikey file = %q", got)
+	}
+	assertNoTransactionLeftovers(t, dir)
+}
+
+func TestFileTransaction_RejectsTargetsOutsideDir(t *testing.T) {
+	txn, err := NewFileTransaction(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer txn.Abort()
+	for _, name := range []string{"../escape.json", "/abs.json", ""} {
+		if err = txn.Stage(name, []byte("{}"), 0o600); err == nil {
+			t.Fatalf("Stage(%q) succeeded", name)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package util
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// PassphraseEnvelopeVersion is the current PassphraseEnvelope format.
+const PassphraseEnvelopeVersion = 1
+
+// scrypt parameters for new envelopes (interactive-login strength, ~100ms).
+const (
+	envelopeScryptN = 1 << 15
+	envelopeScryptR = 8
+	envelopeScryptP = 1
+	envelopeKeyLen  = 32
+	envelopeSaltLen = 16
+
+	// Open accepts at most 4x the sealing memory cost (scrypt uses 128*N*r bytes, 128 MiB
+	// here) and a few parallel passes, so a crafted envelope cannot exhaust memory or CPU.
+	envelopeMaxScryptNR = 4 * envelopeScryptN * envelopeScryptR
+	envelopeMaxScryptP  = 4
+)
+
+// ErrEnvelopeDecrypt is returned when an envelope cannot be opened, either because the
+// passphrase is wrong or because the envelope was modified.
+var ErrEnvelopeDecrypt = errors.New("wrong passphrase or corrupted envelope")
+
+// PassphraseEnvelope is data encrypted with AES-256-GCM under a key derived from a
+// passphrase with scrypt. The KDF parameters travel with the envelope and are
+// authenticated together with the ciphertext.
+type PassphraseEnvelope struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// SealWithPassphrase encrypts plaintext under passphrase.
+func SealWithPassphrase(plaintext []byte, passphrase string) (*PassphraseEnvelope, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("envelope: passphrase is required")
+	}
+	env := &PassphraseEnvelope{
+		Version: PassphraseEnvelopeVersion,
+		KDF:     "scrypt",
+		N:       envelopeScryptN,
+		R:       envelopeScryptR,
+		P:       envelopeScryptP,
+		Salt:    make([]byte, envelopeSaltLen),
+	}
+	if _, err := rand.Read(env.Salt); err != nil {
+		return nil, fmt.Errorf("envelope: generate salt failed: %w", err)
+	}
+	aead, err := env.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(env.Nonce); err != nil {
+		return nil, fmt.Errorf("envelope: generate nonce failed: %w", err)
+	}
+	env.Ciphertext = aead.Seal(nil, env.Nonce, plaintext, env.additionalData())
+	return env, nil
+}
+
+// Open decrypts the envelope. It returns ErrEnvelopeDecrypt for a wrong passphrase or
+// tampered data.
+func (env *PassphraseEnvelope) Open(passphrase string) ([]byte, error) {
+	if env == nil {
+		return nil, fmt.Errorf("envelope: missing")
+	}
+	if env.Version != PassphraseEnvelopeVersion || env.KDF != "scrypt" {
+		return nil, fmt.Errorf("envelope: unsupported version %d (%s)", env.Version, env.KDF)
+	}
+	if env.N <= 1 || env.R <= 0 || env.N > envelopeMaxScryptNR/env.R || env.P <= 0 || env.P > envelopeMaxScryptP || len(env.Salt) == 0 {
+		return nil, fmt.Errorf("envelope: invalid key derivation parameters")
+	}
+	aead, err := env.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, ErrEnvelopeDecrypt
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, env.additionalData())
+	if err != nil {
+		return nil, ErrEnvelopeDecrypt
+	}
+	return plaintext, nil
+}
+
+func (env *PassphraseEnvelope) aead(passphrase string) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), env.Salt, env.N, env.R, env.P, envelopeKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: derive key failed: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: init cipher failed: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: init gcm failed: %w", err)
+	}
+	return aead, nil
+}
+
+// additionalData binds the header fields to the ciphertext.
+func (env *PassphraseEnvelope) additionalData() []byte {
+	header, _ := json.Marshal([]any{env.Version, env.KDF, env.N, env.R, env.P, env.Salt})
+	return header
+}
@@ -0,0 +1,28 @@
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPassphraseEnvelope_RejectsExcessiveKDFCost(t *testing.T) {
+	env, err := SealWithPassphrase([]byte("secret"), "pass")
+	if err != nil {
+		t.Fatalf("SealWithPassphrase: %v", err)
+	}
+	if got, errOpen := env.Open("pass"); errOpen != nil || string(got) != "secret" {
+		t.Fatalf("Open() = %q, %v", got, errOpen)
+	}
+
+	for _, tc := range []struct{ n, r, p int }{
+		{1 << 20, 8, 1},
+		{1 << 17, 32, 1},
+		{1 << 15, 8, 16},
+	} {
+		crafted := *env
+		crafted.N, crafted.R, crafted.P = tc.n, tc.r, tc.p
+		if _, errOpen := crafted.Open("pass"); errOpen == nil || errors.Is(errOpen, ErrEnvelopeDecrypt) {
+			t.Fatalf("N=%d r=%d p=%d: Open() error = %v, want invalid parameters", tc.n, tc.r, tc.p, errOpen)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package util
+
+import "regexp"
+
+// sensitiveValuePatterns match credentials embedded in free-form text such as upstream
+// error bodies and log messages. Each pattern captures prefix, secret, and suffix.
+var sensitiveValuePatterns = []*regexp.Regexp{
+	// API keys: sk-..., pk-..., key-..., etc.
+	regexp.MustCompile(`(?i)(["\']?(?:api[_-]?key|secret[_-]?key|access[_-]?key|auth[_-]?token|bearer)["\']?\s*[:=]\s*["\']?)([a-zA-Z0-9_-]{8,})(["\']?)`),
+	// Bearer tokens in JSON
+	regexp.MustCompile(`(?i)(["\']?bearer["\']?\s*[:=]\s*["\']?)([a-zA-Z0-9_.-]{20,})(["\']?)`),
+	// Generic long tokens/keys that look like secrets
+	regexp.MustCompile(`(?i)(["\']?(?:token|credential|password|secret)["\']?\s*[:=]\s*["\']?)([a-zA-Z0-9_+/=-]{16,})(["\']?)`),
+	// Authorization header values: "Bearer <token>"
+	regexp.MustCompile(`(?i)(\bbearer\s+)([a-zA-Z0-9_.+/=-]{16,})()`),
+}
+
+// RedactSecrets masks credential-looking values in text with HideAPIKey while preserving
+// the surrounding structure.
+func RedactSecrets(text string) string {
+	if text == "" {
+		return ""
+	}
+	for _, pattern := range sensitiveValuePatterns {
+		text = pattern.ReplaceAllStringFunc(text, func(match string) string {
+			submatches := pattern.FindStringSubmatch(match)
+			if len(submatches) >= 4 {
+				// submatches[1] = prefix, submatches[2] = sensitive value, submatches[3] = suffix
+				return submatches[1] + HideAPIKey(submatches[2]) + submatches[3]
+			}
+			return match
+		})
+	}
+	return text
+}
@@ -0,0 +1,51 @@
+package util
+
+import (
+	"github.com/tidwall/gjson"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Maximum number of stop sequences each upstream API accepts per request.
+const (
+	MaxOpenAIStopSequences = 4
+	MaxGeminiStopSequences = 5
+)
+
+// StopSequences collects the stop sequences of an OpenAI `stop`, Claude `stop_sequences`
+// or Gemini `stopSequences` value. Both the string and array forms are accepted; empty
+// and duplicate entries are dropped.
+func StopSequences(value gjson.Result) []string {
+	if !value.Exists() {
+		return nil
+	}
+	var raw []gjson.Result
+	if value.IsArray() {
+		raw = value.Array()
+	} else {
+		raw = []gjson.Result{value}
+	}
+	stops := make([]string, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for _, item := range raw {
+		if item.Type != gjson.String || item.String() == "" {
+			continue
+		}
+		if _, dup := seen[item.String()]; dup {
+			continue
+		}
+		seen[item.String()] = struct{}{}
+		stops = append(stops, item.String())
+	}
+	return stops
+}
+
+// LimitStopSequences truncates stops to the first limit entries, logging a warning when
+// entries are dropped. A limit <= 0 leaves stops unchanged.
+func LimitStopSequences(stops []string, limit int, target string) []string {
+	if limit <= 0 || len(stops) <= limit {
+		return stops
+	}
+	log.Warnf("%s accepts at most %d stop sequences; dropping %d of %d", target, limit, len(stops)-limit, len(stops))
+	return stops[:limit]
+}
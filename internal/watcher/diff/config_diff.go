@@ -39,6 +39,75 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.UsageStatisticsEnabled != newCfg.UsageStatisticsEnabled {
 		changes = append(changes, fmt.Sprintf("usage-statistics-enabled: %t -> %t", oldCfg.UsageStatisticsEnabled, newCfg.UsageStatisticsEnabled))
 	}
+	if oldCfg.FixAuthFilePermissions != newCfg.FixAuthFilePermissions {
+		changes = append(changes, fmt.Sprintf("fix-auth-file-permissions: %t -> %t", oldCfg.FixAuthFilePermissions, newCfg.FixAuthFilePermissions))
+	}
+	if oldCfg.Persistence != newCfg.Persistence {
+		changes = append(changes, fmt.Sprintf("persistence: %s -> %s (restart required)", oldCfg.Persistence, newCfg.Persistence))
+	}
+	if oldCfg.PersistencePath != newCfg.PersistencePath {
+		changes = append(changes, fmt.Sprintf("persistence-path: %s -> %s (restart required)", oldCfg.PersistencePath, newCfg.PersistencePath))
+	}
+	if oldCfg.TimingHeader != newCfg.TimingHeader {
+		changes = append(changes, fmt.Sprintf("timing-header: %t -> %t", oldCfg.TimingHeader, newCfg.TimingHeader))
+	}
+	if !reflect.DeepEqual(oldCfg.MaintenanceWindows, newCfg.MaintenanceWindows) {
+		changes = append(changes, fmt.Sprintf("maintenance-windows count: %d -> %d", len(oldCfg.MaintenanceWindows), len(newCfg.MaintenanceWindows)))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelCatalogOverlay, newCfg.ModelCatalogOverlay) {
+		changes = append(changes, fmt.Sprintf("model-catalog-overlay count: %d -> %d", len(oldCfg.ModelCatalogOverlay), len(newCfg.ModelCatalogOverlay)))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelPricing, newCfg.ModelPricing) {
+		changes = append(changes, fmt.Sprintf("model-pricing count: %d -> %d", len(oldCfg.ModelPricing), len(newCfg.ModelPricing)))
+	}
+	if oldCfg.CopilotPremiumRequestUSD != newCfg.CopilotPremiumRequestUSD {
+		changes = append(changes, fmt.Sprintf("copilot-premium-request-usd: %v -> %v", oldCfg.CopilotPremiumRequestUSD, newCfg.CopilotPremiumRequestUSD))
+	}
+	if oldCfg.CopilotElectronPoolSize != newCfg.CopilotElectronPoolSize {
+		changes = append(changes, fmt.Sprintf("copilot-electron-pool-size: %d -> %d", oldCfg.CopilotElectronPoolSize, newCfg.CopilotElectronPoolSize))
+	}
+	if !reflect.DeepEqual(oldCfg.CopilotElectronExtraArgs, newCfg.CopilotElectronExtraArgs) {
+		changes = append(changes, fmt.Sprintf("copilot-electron-extra-args: %v -> %v", oldCfg.CopilotElectronExtraArgs, newCfg.CopilotElectronExtraArgs))
+	}
+	if oldCfg.CopilotTransportTelemetry != newCfg.CopilotTransportTelemetry {
+		changes = append(changes, fmt.Sprintf("copilot-transport-telemetry: %t -> %t", oldCfg.CopilotTransportTelemetry, newCfg.CopilotTransportTelemetry))
+	}
+	if !reflect.DeepEqual(oldCfg.UpstreamTLS, newCfg.UpstreamTLS) {
+		changes = append(changes, fmt.Sprintf("upstream-tls: updated (%d -> %d providers)", len(oldCfg.UpstreamTLS), len(newCfg.UpstreamTLS)))
+	}
+	if oldCfg.TLSMinVersion != newCfg.TLSMinVersion {
+		changes = append(changes, fmt.Sprintf("tls-min-version: %s -> %s", oldCfg.TLSMinVersion, newCfg.TLSMinVersion))
+	}
+	if oldCfg.DNSRetry != newCfg.DNSRetry {
+		changes = append(changes, fmt.Sprintf("dns-retry: attempts %d -> %d, backoff %q -> %q", oldCfg.DNSRetry.Attempts, newCfg.DNSRetry.Attempts, oldCfg.DNSRetry.Backoff, newCfg.DNSRetry.Backoff))
+	}
+	if !reflect.DeepEqual(oldCfg.MaxConnsPerHost, newCfg.MaxConnsPerHost) {
+		changes = append(changes, fmt.Sprintf("max-conns-per-host: updated (%d -> %d providers)", len(oldCfg.MaxConnsPerHost), len(newCfg.MaxConnsPerHost)))
+	}
+	if oldCfg.ModelsOverrideFile != newCfg.ModelsOverrideFile {
+		changes = append(changes, fmt.Sprintf("models-override-file: %s -> %s", oldCfg.ModelsOverrideFile, newCfg.ModelsOverrideFile))
+	}
+	if !reflect.DeepEqual(oldCfg.UsageBudgets, newCfg.UsageBudgets) {
+		changes = append(changes, fmt.Sprintf("usage-budgets count: %d -> %d", len(oldCfg.UsageBudgets.Budgets), len(newCfg.UsageBudgets.Budgets)))
+	}
+	if !reflect.DeepEqual(oldCfg.Codex.ReasoningEffort, newCfg.Codex.ReasoningEffort) {
+		changes = append(changes, fmt.Sprintf("codex.reasoning-effort: updated (%d -> %d models)", len(oldCfg.Codex.ReasoningEffort), len(newCfg.Codex.ReasoningEffort)))
+	}
+	if !reflect.DeepEqual(oldCfg.Codex.Aliases, newCfg.Codex.Aliases) {
+		changes = append(changes, fmt.Sprintf("codex.aliases: updated (%d -> %d bases)", len(oldCfg.Codex.Aliases), len(newCfg.Codex.Aliases)))
+	}
+	if oldCfg.CodexExposeAliases != newCfg.CodexExposeAliases {
+		changes = append(changes, fmt.Sprintf("codex-expose-aliases: %t -> %t", oldCfg.CodexExposeAliases, newCfg.CodexExposeAliases))
+	}
+	if oldCfg.CodexCache.MaxEntries != newCfg.CodexCache.MaxEntries {
+		changes = append(changes, fmt.Sprintf("codex-cache.max-entries: %d -> %d", oldCfg.CodexCache.MaxEntries, newCfg.CodexCache.MaxEntries))
+	}
+	if oldCfg.CodexCache.TTL != newCfg.CodexCache.TTL {
+		changes = append(changes, fmt.Sprintf("codex-cache.ttl: %s -> %s", oldCfg.CodexCache.TTL, newCfg.CodexCache.TTL))
+	}
+	if oldCfg.UnsafeFaultInjection != newCfg.UnsafeFaultInjection {
+		changes = append(changes, fmt.Sprintf("unsafe-fault-injection: enabled %t -> %t, probability %g -> %g", oldCfg.UnsafeFaultInjection.Enabled, newCfg.UnsafeFaultInjection.Enabled, oldCfg.UnsafeFaultInjection.Probability, newCfg.UnsafeFaultInjection.Probability))
+	}
 	if oldCfg.DisableCooling != newCfg.DisableCooling {
 		changes = append(changes, fmt.Sprintf("disable-cooling: %t -> %t", oldCfg.DisableCooling, newCfg.DisableCooling))
 	}
@@ -51,6 +120,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ErrorLogsMaxFiles != newCfg.ErrorLogsMaxFiles {
 		changes = append(changes, fmt.Sprintf("error-logs-max-files: %d -> %d", oldCfg.ErrorLogsMaxFiles, newCfg.ErrorLogsMaxFiles))
 	}
+	if oldCfg.LogBufferSize != newCfg.LogBufferSize {
+		changes = append(changes, fmt.Sprintf("log-buffer-size: %d -> %d", oldCfg.LogBufferSize, newCfg.LogBufferSize))
+	}
 	if oldCfg.RequestRetry != newCfg.RequestRetry {
 		changes = append(changes, fmt.Sprintf("request-retry: %d -> %d", oldCfg.RequestRetry, newCfg.RequestRetry))
 	}
@@ -60,6 +132,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ProxyURL != newCfg.ProxyURL {
 		changes = append(changes, fmt.Sprintf("proxy-url: %s -> %s", formatProxyURL(oldCfg.ProxyURL), formatProxyURL(newCfg.ProxyURL)))
 	}
+	if oldCfg.NoProxy != newCfg.NoProxy {
+		changes = append(changes, fmt.Sprintf("no-proxy: %s -> %s", oldCfg.NoProxy, newCfg.NoProxy))
+	}
 	if oldCfg.WebsocketAuth != newCfg.WebsocketAuth {
 		changes = append(changes, fmt.Sprintf("ws-auth: %t -> %t", oldCfg.WebsocketAuth, newCfg.WebsocketAuth))
 	}
@@ -69,6 +144,48 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.NonStreamKeepAliveInterval != newCfg.NonStreamKeepAliveInterval {
 		changes = append(changes, fmt.Sprintf("nonstream-keepalive-interval: %d -> %d", oldCfg.NonStreamKeepAliveInterval, newCfg.NonStreamKeepAliveInterval))
 	}
+	if oldCfg.Streaming.HeartbeatInterval != newCfg.Streaming.HeartbeatInterval {
+		changes = append(changes, fmt.Sprintf("streaming.heartbeat-interval: %q -> %q", oldCfg.Streaming.HeartbeatInterval, newCfg.Streaming.HeartbeatInterval))
+	}
+	if !reflect.DeepEqual(oldCfg.ResponseFormatCoercion, newCfg.ResponseFormatCoercion) {
+		changes = append(changes, fmt.Sprintf("response-format-coercion: %v -> %v", oldCfg.ResponseFormatCoercion, newCfg.ResponseFormatCoercion))
+	}
+	if oldCfg.ValidateUpstreamResponses != newCfg.ValidateUpstreamResponses {
+		changes = append(changes, fmt.Sprintf("validate-upstream-responses: %t -> %t", oldCfg.ValidateUpstreamResponses, newCfg.ValidateUpstreamResponses))
+	}
+	if oldCfg.MaxRequestDeadlineMs != newCfg.MaxRequestDeadlineMs {
+		changes = append(changes, fmt.Sprintf("max-request-deadline-ms: %d -> %d", oldCfg.MaxRequestDeadlineMs, newCfg.MaxRequestDeadlineMs))
+	}
+	if oldCfg.ContextOverflowRetry != newCfg.ContextOverflowRetry {
+		changes = append(changes, fmt.Sprintf("context-overflow-retry: %t -> %t", oldCfg.ContextOverflowRetry, newCfg.ContextOverflowRetry))
+	}
+	if oldCfg.EmptyResponse != newCfg.EmptyResponse {
+		changes = append(changes, fmt.Sprintf("empty-response: %s -> %s", oldCfg.EmptyResponse, newCfg.EmptyResponse))
+	}
+	if oldCfg.SpeculativePrefetch != newCfg.SpeculativePrefetch {
+		changes = append(changes, fmt.Sprintf("speculative-prefetch: %t -> %t", oldCfg.SpeculativePrefetch, newCfg.SpeculativePrefetch))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelMaxBodyBytes, newCfg.ModelMaxBodyBytes) {
+		changes = append(changes, fmt.Sprintf("model-max-body-bytes: %v -> %v", oldCfg.ModelMaxBodyBytes, newCfg.ModelMaxBodyBytes))
+	}
+	if !reflect.DeepEqual(oldCfg.MaxStreamDuration, newCfg.MaxStreamDuration) {
+		changes = append(changes, fmt.Sprintf("max-stream-duration: %v -> %v", oldCfg.MaxStreamDuration, newCfg.MaxStreamDuration))
+	}
+	if !reflect.DeepEqual(oldCfg.Tenants, newCfg.Tenants) {
+		changes = append(changes, fmt.Sprintf("tenants: %d -> %d", len(oldCfg.Tenants), len(newCfg.Tenants)))
+	}
+	if !reflect.DeepEqual(oldCfg.APIKeyDefaults, newCfg.APIKeyDefaults) {
+		changes = append(changes, fmt.Sprintf("api-key-defaults: %d -> %d", len(oldCfg.APIKeyDefaults), len(newCfg.APIKeyDefaults)))
+	}
+	if !reflect.DeepEqual(oldCfg.RoutingRules, newCfg.RoutingRules) {
+		changes = append(changes, fmt.Sprintf("routing-rules: %d -> %d", len(oldCfg.RoutingRules), len(newCfg.RoutingRules)))
+	}
+	if !reflect.DeepEqual(oldCfg.Presets, newCfg.Presets) {
+		changes = append(changes, fmt.Sprintf("presets: %d -> %d", len(oldCfg.Presets), len(newCfg.Presets)))
+	}
+	if !reflect.DeepEqual(oldCfg.OutputRedaction, newCfg.OutputRedaction) {
+		changes = append(changes, fmt.Sprintf("output-redaction: %d -> %d patterns", len(oldCfg.OutputRedaction.Patterns), len(newCfg.OutputRedaction.Patterns)))
+	}
 
 	// Quota-exceeded behavior
 	if oldCfg.QuotaExceeded.SwitchProject != newCfg.QuotaExceeded.SwitchProject {
@@ -178,6 +295,12 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
 				changes = append(changes, fmt.Sprintf("codex[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
 			}
+			if strings.TrimSpace(o.BasePath) != strings.TrimSpace(n.BasePath) {
+				changes = append(changes, fmt.Sprintf("codex[%d].base-path: %s -> %s", i, strings.TrimSpace(o.BasePath), strings.TrimSpace(n.BasePath)))
+			}
+			if strings.TrimSpace(o.ResponsesURL) != strings.TrimSpace(n.ResponsesURL) {
+				changes = append(changes, fmt.Sprintf("codex[%d].responses-url: %s -> %s", i, strings.TrimSpace(o.ResponsesURL), strings.TrimSpace(n.ResponsesURL)))
+			}
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("codex[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
@@ -325,6 +325,12 @@ func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreau
 		if ck.BaseURL != "" {
 			attrs["base_url"] = ck.BaseURL
 		}
+		if basePath := strings.TrimSpace(ck.BasePath); basePath != "" {
+			attrs["base_path"] = basePath
+		}
+		if responsesURL := strings.TrimSpace(ck.ResponsesURL); responsesURL != "" {
+			attrs["responses_url"] = responsesURL
+		}
 		if ck.Websockets {
 			attrs["websockets"] = "true"
 		}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+)
+
+// ModelBodyLimit resolves the model-max-body-bytes cap for modelName. Lookup order: the
+// most specific glob matching the model (fewest wildcards, then longest pattern), then
+// "default". A limit <= 0 means the model is uncapped.
+func (h *BaseAPIHandler) ModelBodyLimit(modelName string) int64 {
+	if h == nil || h.Cfg == nil {
+		return 0
+	}
+	limit, _ := modelGlobValue(h.Cfg.ModelMaxBodyBytes, modelName)
+	return limit
+}
+
+// modelGlobValue returns the entry of values whose glob key most specifically matches
+// modelName (thinking suffix stripped, case-insensitive), falling back to the "default"
+// entry. ok is false when neither exists.
+func modelGlobValue[T any](values map[string]T, modelName string) (value T, ok bool) {
+	if len(values) == 0 {
+		return value, false
+	}
+	model := strings.ToLower(strings.TrimSpace(thinking.ParseSuffix(modelName).ModelName))
+	if model == "" {
+		model = strings.ToLower(strings.TrimSpace(modelName))
+	}
+	var (
+		bestPattern  string
+		found        bool
+		defaultValue T
+		hasDefault   bool
+	)
+	for rawPattern, v := range values {
+		pattern := strings.ToLower(strings.TrimSpace(rawPattern))
+		if pattern == "default" {
+			defaultValue, hasDefault = v, true
+			continue
+		}
+		if !matchModelGlob(pattern, model) {
+			continue
+		}
+		if !found || moreSpecificGlob(pattern, bestPattern) {
+			value, bestPattern, found = v, pattern, true
+		}
+	}
+	if found {
+		return value, true
+	}
+	return defaultValue, hasDefault
+}
+
+// checkModelBodyLimit rejects rawJSON with a 413 when it exceeds the cap for modelName.
+func (h *BaseAPIHandler) checkModelBodyLimit(modelName string, rawJSON []byte) *interfaces.ErrorMessage {
+	limit := h.ModelBodyLimit(modelName)
+	if limit <= 0 || int64(len(rawJSON)) <= limit {
+		return nil
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusRequestEntityTooLarge,
+		Error:      fmt.Errorf("request body of %d bytes exceeds the %d byte limit for model %s", len(rawJSON), limit, modelName),
+	}
+}
+
+func moreSpecificGlob(candidate, current string) bool {
+	cw, bw := strings.Count(candidate, "*"), strings.Count(current, "*")
+	if cw != bw {
+		return cw < bw
+	}
+	if len(candidate) != len(current) {
+		return len(candidate) > len(current)
+	}
+	return candidate < current
+}
+
+// matchModelGlob reports whether value matches pattern, where '*' matches any substring.
+func matchModelGlob(pattern, value string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == value
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	if len(value) < len(last) || !strings.HasSuffix(value, last) {
+		return false
+	}
+	value = value[:len(value)-len(last)]
+	for _, segment := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, segment)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(segment):]
+	}
+	return true
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestExecuteWithAuthManager_ModelBodyLimit(t *testing.T) {
+	limits := map[string]int64{
+		"default":          1 << 20,
+		"context-*":        64,
+		"context-retry-m*": 1 << 16,
+	}
+	body := []byte(`{"model":"context-retry-model","messages":[{"role":"user","content":"` + strings.Repeat("x", 1024) + `"}]}`)
+
+	h, executor := newContextRetryHandler(t, &sdkconfig.SDKConfig{ModelMaxBodyBytes: limits})
+	if _, _, errMsg := h.ExecuteWithAuthManager(contextRetryContext(t, ""), "openai", "context-retry-model", body, ""); errMsg != nil {
+		t.Fatalf("large-cap model rejected %d bytes: %v", len(body), errMsg.Error)
+	}
+	if len(executor.payloads) != 1 {
+		t.Fatalf("upstream saw %d requests, want 1", len(executor.payloads))
+	}
+
+	delete(limits, "context-retry-m*")
+	h, executor = newContextRetryHandler(t, &sdkconfig.SDKConfig{ModelMaxBodyBytes: limits})
+	_, _, errMsg := h.ExecuteWithAuthManager(contextRetryContext(t, ""), "openai", "context-retry-model", body, "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("small-cap model accepted the body: %+v", errMsg)
+	}
+	if msg := errMsg.Error.Error(); !strings.Contains(msg, "context-retry-model") || !strings.Contains(msg, "64 byte limit") {
+		t.Fatalf("error = %q, want model name and limit", msg)
+	}
+	if len(executor.payloads) != 0 {
+		t.Fatalf("rejected request reached the upstream")
+	}
+
+	_, _, errChan := h.ExecuteStreamWithAuthManager(contextRetryContext(t, ""), "openai", "context-retry-model", body, "")
+	if errMsg = <-errChan; errMsg == nil || errMsg.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("stream accepted the body: %+v", errMsg)
+	}
+}
+
+func TestModelBodyLimit_Resolution(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{ModelMaxBodyBytes: map[string]int64{
+		"default":     100,
+		"gpt-*":       200,
+		"gpt-4o-mini": 300,
+		"*-mini":      400,
+	}}}
+	cases := map[string]int64{
+		"gpt-4o-mini":       300,
+		"gpt-4o-mini(high)": 300,
+		"GPT-5":             200,
+		"o4-mini":           400,
+		"claude-sonnet-4":   100,
+	}
+	for model, want := range cases {
+		if got := h.ModelBodyLimit(model); got != want {
+			t.Errorf("ModelBodyLimit(%q) = %d, want %d", model, got, want)
+		}
+	}
+	if got := (&BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{}}).ModelBodyLimit("gpt-5"); got != 0 {
+		t.Fatalf("unconfigured limit = %d, want 0", got)
+	}
+}
@@ -53,7 +53,7 @@ func (h *ClaudeCodeAPIHandler) HandlerType() string {
 func (h *ClaudeCodeAPIHandler) Models() []map[string]any {
 	// Get dynamic models from the global registry
 	modelRegistry := registry.GetGlobalRegistry()
-	return modelRegistry.GetAvailableModels("claude")
+	return h.ApplyCatalogOverlay(modelRegistry.GetAvailableModels("claude"), "claude")
 }
 
 // ClaudeMessages handles Claude-compatible streaming chat completions.
@@ -129,7 +129,7 @@ func (h *ClaudeCodeAPIHandler) ClaudeCountTokens(c *gin.Context) {
 // Parameters:
 //   - c: The Gin context for the request.
 func (h *ClaudeCodeAPIHandler) ClaudeModels(c *gin.Context) {
-	models := h.Models()
+	models := h.FilterModelsForTenant(c, h.Models())
 	firstID := ""
 	lastID := ""
 	if len(models) > 0 {
@@ -196,7 +196,8 @@ func (h *ClaudeCodeAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSO
 	}
 
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
-	_, _ = c.Writer.Write(resp)
+	handlers.SetEstimatedCostHeader(c, modelName, resp)
+	_, _ = c.Writer.Write(newClaudeRedactor(h.OutputRedactor()).response(resp))
 	cliCancel()
 }
 
@@ -239,11 +240,24 @@ func (h *ClaudeCodeAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON [
 	}
 
 	// Peek at the first chunk to determine success or failure before setting headers
+	splitter := handlers.NewUTF8ChunkSplitter()
+	redaction := newClaudeRedactor(h.OutputRedactor())
+	heartbeat, stopHeartbeat := h.FirstByteHeartbeat()
+	defer stopHeartbeat()
 	for {
 		select {
 		case <-c.Request.Context().Done():
 			cliCancel(c.Request.Context().Err())
 			return
+		case <-heartbeat:
+			// The upstream is slow to start: commit to streaming so the client sees bytes,
+			// and report any later error in-stream.
+			setSSEHeaders()
+			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+			_, _ = c.Writer.Write([]byte(handlers.StreamHeartbeatComment + "\n\n"))
+			flusher.Flush()
+			h.forwardClaudeStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, splitter, redaction)
+			return
 		case errMsg, ok := <-errChan:
 			if !ok {
 				// Err channel closed cleanly; wait for data channel.
@@ -273,25 +287,31 @@ func (h *ClaudeCodeAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON [
 			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 
 			// Write the first chunk
-			if len(chunk) > 0 {
-				_, _ = c.Writer.Write(chunk)
+			if chunk = splitter.Split(chunk); len(chunk) > 0 {
+				_, _ = c.Writer.Write(h.shapeDeltas(c, redaction.chunk(chunk)))
 				flusher.Flush()
 			}
 
 			// Continue streaming the rest
-			h.forwardClaudeStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+			h.forwardClaudeStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, splitter, redaction)
 			return
 		}
 	}
 }
 
-func (h *ClaudeCodeAPIHandler) forwardClaudeStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+func (h *ClaudeCodeAPIHandler) forwardClaudeStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, splitter *handlers.UTF8ChunkSplitter, redaction *claudeRedactor) {
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
+		Splitter: splitter,
 		WriteChunk: func(chunk []byte) {
 			if len(chunk) == 0 {
 				return
 			}
-			_, _ = c.Writer.Write(chunk)
+			_, _ = c.Writer.Write(h.shapeDeltas(c, redaction.chunk(chunk)))
+		},
+		FlushHeld: func() {
+			if held := redaction.flush(); len(held) > 0 {
+				_, _ = c.Writer.Write(h.shapeDeltas(c, held))
+			}
 		},
 		WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
 			if errMsg == nil {
@@ -309,6 +329,25 @@ func (h *ClaudeCodeAPIHandler) forwardClaudeStream(c *gin.Context, flusher http.
 	})
 }
 
+// shapeDeltas applies fine-grained tool delta re-chunking, then splits events larger than
+// the client's streaming.max-event-bytes limit.
+func (h *ClaudeCodeAPIHandler) shapeDeltas(c *gin.Context, chunk []byte) []byte {
+	return splitOversizedEvents(h.shapeToolDeltas(c, chunk), h.MaxStreamEventBytes(c))
+}
+
+// shapeToolDeltas re-chunks tool argument deltas when the client asked for
+// fine-grained tool streaming; otherwise the chunk is returned unchanged.
+func (h *ClaudeCodeAPIHandler) shapeToolDeltas(c *gin.Context, chunk []byte) []byte {
+	if c == nil || c.Request == nil || !wantsFineGrainedToolStreaming(c.Request.Header) {
+		return chunk
+	}
+	maxBytes := 0
+	if h.Cfg != nil {
+		maxBytes = h.Cfg.Streaming.ToolDeltaMaxBytes
+	}
+	return rechunkToolDeltas(chunk, maxBytes)
+}
+
 type claudeErrorDetail struct {
 	Type    string `json:"type"`
 	Message string `json:"message"`
@@ -0,0 +1,73 @@
+package claude
+
+import (
+	"bytes"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// splittableDeltaFields maps content_block_delta types to the string field that can be
+// split across several events.
+var splittableDeltaFields = map[string]string{
+	"text_delta":       "delta.text",
+	"thinking_delta":   "delta.thinking",
+	"input_json_delta": "delta.partial_json",
+}
+
+// splitOversizedEvents splits content_block_delta events whose data payload is larger
+// than maxBytes into several content_block_delta events for the same block index.
+// Concatenating the text, thinking, or partial_json pieces reproduces the original.
+// Like rechunkToolDeltas it accepts whole events or single passthrough lines, and leaves
+// other events and incomplete lines unchanged.
+func splitOversizedEvents(chunk []byte, maxBytes int) []byte {
+	if maxBytes <= 0 || len(chunk) <= maxBytes {
+		return chunk
+	}
+
+	lines := bytes.Split(chunk, []byte("\n"))
+	changed := false
+	for i, line := range lines {
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		payload := bytes.TrimSpace(line[5:])
+		if len(payload) <= maxBytes || !gjson.ValidBytes(payload) {
+			continue
+		}
+		if gjson.GetBytes(payload, "type").String() != "content_block_delta" {
+			continue
+		}
+		path, ok := splittableDeltaFields[gjson.GetBytes(payload, "delta.type").String()]
+		if !ok {
+			continue
+		}
+		template, err := sjson.SetBytes(bytes.Clone(payload), path, "")
+		if err != nil {
+			continue
+		}
+		budget := handlers.EventPieceBudget(maxBytes, len(template))
+
+		var events [][]byte
+		for rest := gjson.GetBytes(payload, path).String(); rest != ""; {
+			var piece string
+			piece, rest = handlers.CutJSONString(rest, budget)
+			event, errSet := sjson.SetBytes(bytes.Clone(template), path, piece)
+			if errSet != nil {
+				events = nil
+				break
+			}
+			events = append(events, append([]byte("data: "), event...))
+		}
+		if len(events) < 2 {
+			continue
+		}
+		lines[i] = bytes.Join(events, []byte("\n\nevent: content_block_delta\n"))
+		changed = true
+	}
+	if !changed {
+		return chunk
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
@@ -0,0 +1,78 @@
+package claude
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func TestSplitOversizedEvents_ReassemblesToOriginal(t *testing.T) {
+	cases := []struct {
+		deltaType string
+		field     string
+		value     string
+	}{
+		{"text_delta", "delta.text", strings.Repeat(`Some "quoted" text with <html> & ünïcödé 🚀`+"\n", 50)},
+		{"thinking_delta", "delta.thinking", strings.Repeat("Let me think about this… ", 80)},
+		{"input_json_delta", "delta.partial_json", `{"path":"/tmp/файл.txt","content":"` + strings.Repeat(`x = \"y\"\n`, 200) + `"}`},
+	}
+	for _, tc := range cases {
+		payload, _ := sjson.Set(`{"type":"content_block_delta","index":2,"delta":{"type":"`+tc.deltaType+`"}}`, tc.field, tc.value)
+		chunk := []byte("event: content_block_delta\ndata: " + payload + "\n\n")
+
+		for _, maxBytes := range []int{128, 500, 2048} {
+			out := splitOversizedEvents(chunk, maxBytes)
+			events := strings.Split(strings.TrimRight(string(out), "\n"), "\n\n")
+			if len(events) < 2 {
+				t.Fatalf("%s maxBytes=%d: event was not split", tc.deltaType, maxBytes)
+			}
+			var got strings.Builder
+			for _, event := range events {
+				lines := strings.Split(event, "\n")
+				if len(lines) != 2 || lines[0] != "event: content_block_delta" || !strings.HasPrefix(lines[1], "data: ") {
+					t.Fatalf("%s maxBytes=%d: malformed SSE event: %q", tc.deltaType, maxBytes, event)
+				}
+				data := strings.TrimPrefix(lines[1], "data: ")
+				if len(data) > maxBytes {
+					t.Fatalf("%s maxBytes=%d: data payload is %d bytes", tc.deltaType, maxBytes, len(data))
+				}
+				parsed := gjson.Parse(data)
+				if parsed.Get("index").Int() != 2 || parsed.Get("delta.type").String() != tc.deltaType {
+					t.Fatalf("%s maxBytes=%d: event lost its block: %s", tc.deltaType, maxBytes, data)
+				}
+				got.WriteString(parsed.Get(tc.field).String())
+			}
+			if got.String() != tc.value {
+				t.Fatalf("%s maxBytes=%d: reassembled value mismatch", tc.deltaType, maxBytes)
+			}
+		}
+	}
+}
+
+func TestSplitOversizedEvents_LineByLineAndOtherEvents(t *testing.T) {
+	text := strings.Repeat("abcdefghij", 100)
+	payload, _ := sjson.Set(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":""}}`, "delta.text", text)
+
+	var out strings.Builder
+	for _, line := range []string{"event: content_block_delta\n", "data: " + payload + "\n", "\n"} {
+		out.Write(splitOversizedEvents([]byte(line), 256))
+	}
+	var got strings.Builder
+	for _, event := range strings.Split(strings.TrimRight(out.String(), "\n"), "\n\n") {
+		if !strings.HasPrefix(event, "event: content_block_delta\ndata: ") {
+			t.Fatalf("malformed event: %q", event)
+		}
+		got.WriteString(gjson.Get(strings.SplitN(event, "data: ", 2)[1], "delta.text").String())
+	}
+	if got.String() != text {
+		t.Fatalf("line-by-line reassembly mismatch")
+	}
+
+	start, _ := sjson.Set(`{"type":"message_start","message":{"id":"msg_1","content":[]}}`, "message.padding", strings.Repeat("p", 600))
+	chunk := []byte("event: message_start\ndata: " + start + "\n\n")
+	if out := splitOversizedEvents(chunk, 256); string(out) != string(chunk) {
+		t.Fatalf("non-delta event was modified")
+	}
+}
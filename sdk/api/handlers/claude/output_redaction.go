@@ -0,0 +1,122 @@
+package claude
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// redactedDeltaFields maps the content_block_delta types carrying model text to their field.
+var redactedDeltaFields = map[string]string{
+	"text_delta":     "text",
+	"thinking_delta": "thinking",
+}
+
+// claudeRedactor redacts the model text of one Claude Messages response. Streamed deltas
+// are redacted per content block with a lookahead buffer. The held-back text is released
+// as an extra content_block_delta event before the block is stopped, or by flush when the
+// stream ends first.
+type claudeRedactor struct {
+	redactor *handlers.OutputRedactor
+	streams  *handlers.RedactionStreams
+}
+
+func newClaudeRedactor(redactor *handlers.OutputRedactor) *claudeRedactor {
+	if redactor == nil {
+		return nil
+	}
+	return &claudeRedactor{redactor: redactor, streams: redactor.NewStreams()}
+}
+
+// response redacts a non-streaming message.
+func (r *claudeRedactor) response(resp []byte) []byte {
+	if r == nil {
+		return resp
+	}
+	return r.redactor.RedactPaths(resp, "content.#.text", "content.#.thinking")
+}
+
+// chunk redacts an SSE chunk. Like rechunkToolDeltas it accepts whole events or single
+// passthrough lines; the text held for the open blocks is released before the event: line
+// of content_block_stop, message_delta or message_stop, or before a data line of those
+// types when the chunk carries no event: line.
+func (r *claudeRedactor) chunk(chunk []byte) []byte {
+	if r == nil {
+		return chunk
+	}
+	lines := bytes.Split(chunk, []byte("\n"))
+	out := make([][]byte, 0, len(lines))
+	for _, line := range lines {
+		if name, ok := bytes.CutPrefix(line, []byte("event:")); ok {
+			if closesBlocks(string(bytes.TrimSpace(name))) {
+				out = append(out, r.heldEvents(true)...)
+			}
+			out = append(out, line)
+			continue
+		}
+		payload, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok || !gjson.ValidBytes(bytes.TrimSpace(payload)) {
+			out = append(out, line)
+			continue
+		}
+		payload = bytes.TrimSpace(payload)
+		eventType := gjson.GetBytes(payload, "type").String()
+		if closesBlocks(eventType) {
+			out = append(out, r.heldEvents(false)...)
+			out = append(out, line)
+			continue
+		}
+		field, text := redactedDeltaFields[gjson.GetBytes(payload, "delta.type").String()]
+		if eventType != "content_block_delta" || !text {
+			out = append(out, line)
+			continue
+		}
+		key := gjson.GetBytes(payload, "index").Raw + "/" + field
+		redacted, _ := sjson.SetBytes(bytes.Clone(payload), "delta."+field, r.streams.Write(key, gjson.GetBytes(payload, "delta."+field).String()))
+		out = append(out, append([]byte("data: "), redacted...))
+	}
+	return bytes.Join(out, []byte("\n"))
+}
+
+// flush returns the events releasing text still held back when the stream ends.
+func (r *claudeRedactor) flush() []byte {
+	if r == nil {
+		return nil
+	}
+	events := r.heldEvents(true)
+	if len(events) == 0 {
+		return nil
+	}
+	return append(bytes.Join(events, []byte("\n")), '\n')
+}
+
+// heldEvents returns the lines of a content_block_delta event per block with held-back
+// text, each ending in a blank line. withEventLine adds the event: line.
+func (r *claudeRedactor) heldEvents(withEventLine bool) [][]byte {
+	var lines [][]byte
+	for _, held := range r.streams.FlushAll() {
+		rawIndex, field, _ := strings.Cut(held.Key, "/")
+		index, _ := strconv.ParseInt(rawIndex, 10, 64)
+		payload := []byte(`{"type":"content_block_delta","index":0,"delta":{}}`)
+		payload, _ = sjson.SetBytes(payload, "index", index)
+		payload, _ = sjson.SetBytes(payload, "delta.type", field+"_delta")
+		payload, _ = sjson.SetBytes(payload, "delta."+field, held.Text)
+		if withEventLine {
+			lines = append(lines, []byte("event: content_block_delta"))
+		}
+		lines = append(lines, append([]byte("data: "), payload...), nil)
+	}
+	return lines
+}
+
+func closesBlocks(eventType string) bool {
+	switch eventType {
+	case "content_block_stop", "message_delta", "message_stop":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package claude
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestClaudeRedactor_ReleasesHeldTextBeforeBlockStop(t *testing.T) {
+	r := newClaudeRedactor(handlers.NewOutputRedactor(sdkconfig.OutputRedactionConfig{
+		Patterns:             []string{`sk-[A-Za-z0-9]{8,}`},
+		StreamLookaheadBytes: 64,
+	}))
+
+	var out strings.Builder
+	for _, line := range []string{
+		"event: content_block_delta",
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"key sk-abcdef123456"}}`,
+		"",
+		"event: content_block_stop",
+		`data: {"type":"content_block_stop","index":0}`,
+		"",
+	} {
+		out.Write(r.chunk([]byte(line)))
+		out.WriteString("\n")
+	}
+	got := out.String()
+	if strings.Contains(got, "sk-abcdef123456") {
+		t.Fatalf("secret leaked: %s", got)
+	}
+	held := strings.Index(got, `"text":"key [REDACTED]"`)
+	stop := strings.Index(got, "event: content_block_stop")
+	if held < 0 || stop < 0 || held > stop {
+		t.Fatalf("held text must be released before content_block_stop:\n%s", got)
+	}
+	if rest := r.flush(); rest != nil {
+		t.Fatalf("nothing should be left to flush, got %q", rest)
+	}
+}
+
+func TestClaudeRedactor_FlushReleasesTextWhenStreamStops(t *testing.T) {
+	r := newClaudeRedactor(handlers.NewOutputRedactor(sdkconfig.OutputRedactionConfig{
+		Patterns:             []string{`sk-[A-Za-z0-9]{8,}`},
+		StreamLookaheadBytes: 64,
+	}))
+	r.chunk([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"thinking_delta\",\"thinking\":\"saw sk-abcdef123456\"}}\n\n"))
+
+	got := string(r.flush())
+	want := "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"thinking_delta\",\"thinking\":\"saw [REDACTED]\"}}\n\n"
+	if got != want {
+		t.Fatalf("flush = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package claude
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// fineGrainedToolStreamingBeta is the anthropic-beta flag prefix Claude Code sends
+// when it expects small, incremental input_json_delta events.
+const fineGrainedToolStreamingBeta = "fine-grained-tool-streaming"
+
+// defaultToolDeltaMaxBytes bounds partial_json size when streaming.tool-delta-max-bytes is unset.
+const defaultToolDeltaMaxBytes = 128
+
+// wantsFineGrainedToolStreaming reports whether the client opted into the
+// fine-grained tool streaming beta via the anthropic-beta header.
+func wantsFineGrainedToolStreaming(header http.Header) bool {
+	for _, value := range header.Values("Anthropic-Beta") {
+		for _, flag := range strings.Split(value, ",") {
+			if strings.HasPrefix(strings.TrimSpace(strings.ToLower(flag)), fineGrainedToolStreamingBeta) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rechunkToolDeltas splits oversized input_json_delta events in an SSE chunk into
+// multiple content_block_delta events of at most maxBytes of partial_json each.
+// Every emitted event keeps the original block index, and concatenating the
+// partial_json fragments reproduces the original arguments exactly.
+//
+// The chunk may be a full event ("event: ...\ndata: ...\n\n") or a single line, as
+// passthrough executors forward upstream SSE line by line; only data lines are rewritten.
+func rechunkToolDeltas(chunk []byte, maxBytes int) []byte {
+	if maxBytes <= 0 {
+		maxBytes = defaultToolDeltaMaxBytes
+	}
+	if !bytes.Contains(chunk, []byte("input_json_delta")) {
+		return chunk
+	}
+
+	lines := bytes.Split(chunk, []byte("\n"))
+	changed := false
+	for i, line := range lines {
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		payload := bytes.TrimSpace(line[5:])
+		if gjson.GetBytes(payload, "delta.type").String() != "input_json_delta" {
+			continue
+		}
+		partial := gjson.GetBytes(payload, "delta.partial_json").String()
+		if len(partial) <= maxBytes {
+			continue
+		}
+
+		pieces := handlers.SplitUTF8(partial, maxBytes)
+		events := make([][]byte, 0, len(pieces))
+		for _, piece := range pieces {
+			event, err := sjson.SetBytes(bytes.Clone(payload), "delta.partial_json", piece)
+			if err != nil {
+				events = nil
+				break
+			}
+			events = append(events, append([]byte("data: "), event...))
+		}
+		if events == nil {
+			continue
+		}
+		lines[i] = bytes.Join(events, []byte("\n\nevent: content_block_delta\n"))
+		changed = true
+	}
+	if !changed {
+		return chunk
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
@@ -0,0 +1,84 @@
+package claude
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func collectToolDeltas(t *testing.T, out []byte) (string, []int64) {
+	t.Helper()
+	var args strings.Builder
+	var indices []int64
+	events := strings.Split(strings.TrimRight(string(out), "\n"), "\n\n")
+	for _, event := range events {
+		lines := strings.Split(event, "\n")
+		if len(lines) != 2 || lines[0] != "event: content_block_delta" || !strings.HasPrefix(lines[1], "data: ") {
+			t.Fatalf("malformed SSE event: %q", event)
+		}
+		data := strings.TrimPrefix(lines[1], "data: ")
+		if !gjson.Valid(data) {
+			t.Fatalf("invalid JSON payload: %q", data)
+		}
+		args.WriteString(gjson.Get(data, "delta.partial_json").String())
+		indices = append(indices, gjson.Get(data, "index").Int())
+	}
+	return args.String(), indices
+}
+
+func TestRechunkToolDeltas_ConcatenationMatchesOriginal(t *testing.T) {
+	original := `{"path":"/tmp/файл.txt","content":"héllo wörld 🚀 ` + strings.Repeat("x", 97) + `","n":[1,2,3]}`
+	payload, _ := sjson.Set(`{"type":"content_block_delta","index":3,"delta":{"type":"input_json_delta","partial_json":""}}`, "delta.partial_json", original)
+	chunk := []byte("event: content_block_delta\ndata: " + payload + "\n\n")
+
+	for _, maxBytes := range []int{1, 2, 3, 4, 7, 16, 63, 64, 65, len(original) - 1, len(original), len(original) + 1} {
+		out := rechunkToolDeltas(chunk, maxBytes)
+		got, indices := collectToolDeltas(t, out)
+		if got != original {
+			t.Fatalf("maxBytes=%d: concatenated arguments mismatch\n got: %q\nwant: %q", maxBytes, got, original)
+		}
+		for _, idx := range indices {
+			if idx != 3 {
+				t.Fatalf("maxBytes=%d: index = %d, want 3", maxBytes, idx)
+			}
+		}
+		if maxBytes < len(original) && len(indices) < 2 {
+			t.Fatalf("maxBytes=%d: expected delta to be split", maxBytes)
+		}
+	}
+}
+
+func TestRechunkToolDeltas_LineByLinePassthrough(t *testing.T) {
+	original := strings.Repeat(`{"a":"b"},`, 20)
+	payload, _ := sjson.Set(`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":""}}`, "delta.partial_json", original)
+
+	var out strings.Builder
+	for _, line := range []string{"event: content_block_delta\n", "data: " + payload + "\n", "\n"} {
+		out.Write(rechunkToolDeltas([]byte(line), 16))
+	}
+	got, _ := collectToolDeltas(t, []byte(out.String()))
+	if got != original {
+		t.Fatalf("concatenated arguments mismatch: %q", got)
+	}
+}
+
+func TestRechunkToolDeltas_LeavesOtherEventsUntouched(t *testing.T) {
+	chunk := []byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"" + strings.Repeat("t", 500) + "\"}}\n\n")
+	if out := rechunkToolDeltas(chunk, 8); string(out) != string(chunk) {
+		t.Fatalf("text delta should not be modified")
+	}
+}
+
+func TestWantsFineGrainedToolStreaming(t *testing.T) {
+	h := http.Header{}
+	if wantsFineGrainedToolStreaming(h) {
+		t.Fatal("expected false without header")
+	}
+	h.Set("Anthropic-Beta", "interleaved-thinking-2025-05-14, fine-grained-tool-streaming-2025-05-14")
+	if !wantsFineGrainedToolStreaming(h) {
+		t.Fatal("expected true when beta flag present")
+	}
+}
@@ -0,0 +1,52 @@
+package claude
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestClaudeStream_KeepsCodePointsAcrossChunks(t *testing.T) {
+	const text = `héllo—wörld·世界🚀👩‍💻done`
+	gin.SetMode(gin.TestMode)
+	h := NewClaudeCodeAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+
+	for cut := 1; cut < len(text); cut++ {
+		// Both the translated-event shape and line-by-line passthrough chunks.
+		for _, chunks := range [][]string{
+			{"event: content_block_delta\ndata: " + text[:cut] + "\n\n", "event: content_block_delta\ndata: " + text[cut:] + "\n\n"},
+			{"data: " + text[:cut], text[cut:] + "\n", "\n"},
+		} {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+			data := make(chan []byte, len(chunks))
+			for _, chunk := range chunks {
+				data <- []byte(chunk)
+			}
+			close(data)
+			h.forwardClaudeStream(c, c.Writer, func(error) {}, data, make(chan *interfaces.ErrorMessage), nil, nil)
+
+			body := rec.Body.String()
+			if !utf8.ValidString(body) {
+				t.Fatalf("cut=%d: invalid UTF-8 in stream %q", cut, body)
+			}
+			var got strings.Builder
+			for _, line := range strings.Split(body, "\n") {
+				if strings.HasPrefix(line, "data: ") {
+					got.WriteString(strings.TrimPrefix(line, "data: "))
+				}
+			}
+			if got.String() != text {
+				t.Fatalf("cut=%d: payloads reassemble to %q, want %q\nbody: %q", cut, got.String(), text, body)
+			}
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ContextTruncatedHeader reports what was removed from a request that was retried after
+// the upstream rejected it for exceeding the model context.
+const ContextTruncatedHeader = "X-Context-Truncated"
+
+// ContextTruncationHeader set to "disabled" opts a request out of context-overflow retries.
+// A Responses API body with "truncation": "disabled" opts out as well.
+const ContextTruncationHeader = "X-Context-Truncation"
+
+// contextRetryToolOutputLimit is the size, in bytes, tool outputs are middle-truncated to.
+const contextRetryToolOutputLimit = 8 * 1024
+
+// contextLengthMarkers are substrings of upstream errors reporting an oversized prompt.
+var contextLengthMarkers = []string{
+	"context_length_exceeded",
+	"model_max_prompt_tokens_exceeded",
+	"maximum context length",
+	"context window",
+	"context length",
+	"prompt is too long",
+	"input is too long",
+	"too many tokens",
+	"exceeds the maximum number of tokens",
+}
+
+// contextTruncation summarizes what shrinkContext removed.
+type contextTruncation struct {
+	droppedMessages      int
+	truncatedToolOutputs int
+}
+
+func (t contextTruncation) String() string {
+	return fmt.Sprintf("dropped_messages=%d; truncated_tool_outputs=%d", t.droppedMessages, t.truncatedToolOutputs)
+}
+
+// isContextLengthError reports whether err is an upstream rejection for exceeding context.
+func isContextLengthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch statusFromError(err) {
+	case 0, http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+	default:
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, marker := range contextLengthMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// contextRetryPayload returns a smaller copy of rawJSON to retry with when err is a
+// context-length rejection, context-overflow-retry is enabled and the client did not
+// disable truncation.
+func (h *BaseAPIHandler) contextRetryPayload(ctx context.Context, handlerType string, rawJSON []byte, err error) ([]byte, contextTruncation, bool) {
+	if h == nil || h.Cfg == nil || !h.Cfg.ContextOverflowRetry || !isContextLengthError(err) {
+		return nil, contextTruncation{}, false
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		if strings.EqualFold(strings.TrimSpace(ginCtx.GetHeader(ContextTruncationHeader)), "disabled") {
+			return nil, contextTruncation{}, false
+		}
+	}
+	if strings.EqualFold(gjson.GetBytes(rawJSON, "truncation").String(), "disabled") {
+		return nil, contextTruncation{}, false
+	}
+	shrunk, summary, ok := shrinkContext(handlerType, rawJSON)
+	if ok {
+		log.Infof("context overflow: retrying %s request after truncation (%s)", handlerType, summary)
+	}
+	return shrunk, summary, ok
+}
+
+// setContextTruncatedHeader records summary on headers, allocating them when needed.
+func setContextTruncatedHeader(headers http.Header, summary contextTruncation) http.Header {
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Set(ContextTruncatedHeader, summary.String())
+	return headers
+}
+
+// conversationShape describes where a client schema keeps its conversation.
+type conversationShape struct {
+	path string
+	// pinned reports items that are never dropped (system prompts).
+	pinned func(item gjson.Result) bool
+	// turnStart reports items that open a user turn; dropping stops at one so tool calls
+	// stay paired with their results.
+	turnStart func(item gjson.Result) bool
+	// truncateTools middle-truncates tool outputs in item and returns how many it cut.
+	truncateTools func(item string) (string, int)
+}
+
+// shrinkContext truncates oversized tool outputs and drops the oldest half of the user
+// turns, keeping system prompts and the latest turn. ok is false when nothing could be removed.
+func shrinkContext(handlerType string, rawJSON []byte) ([]byte, contextTruncation, bool) {
+	shape, ok := shapeFor(handlerType, rawJSON)
+	if !ok {
+		return nil, contextTruncation{}, false
+	}
+	items := gjson.GetBytes(rawJSON, shape.path)
+	if !items.IsArray() {
+		return nil, contextTruncation{}, false
+	}
+	list := items.Array()
+
+	var starts []int
+	for i, item := range list {
+		if !shape.pinned(item) && shape.turnStart(item) {
+			starts = append(starts, i)
+		}
+	}
+	cut := 0
+	if len(starts) >= 2 {
+		cut = starts[len(starts)/2]
+	}
+
+	var summary contextTruncation
+	kept := make([]string, 0, len(list))
+	for i, item := range list {
+		if i < cut && !shape.pinned(item) {
+			summary.droppedMessages++
+			continue
+		}
+		raw := item.Raw
+		if shape.truncateTools != nil {
+			var cutCount int
+			raw, cutCount = shape.truncateTools(raw)
+			summary.truncatedToolOutputs += cutCount
+		}
+		kept = append(kept, raw)
+	}
+	if summary.droppedMessages == 0 && summary.truncatedToolOutputs == 0 {
+		return nil, contextTruncation{}, false
+	}
+	out, err := sjson.SetRawBytes(rawJSON, shape.path, []byte("["+strings.Join(kept, ",")+"]"))
+	if err != nil {
+		return nil, contextTruncation{}, false
+	}
+	return out, summary, true
+}
+
+func shapeFor(handlerType string, rawJSON []byte) (conversationShape, bool) {
+	role := func(item gjson.Result) string { return item.Get("role").String() }
+	switch handlerType {
+	case "openai":
+		return conversationShape{
+			path:      "messages",
+			pinned:    func(item gjson.Result) bool { r := role(item); return r == "system" || r == "developer" },
+			turnStart: func(item gjson.Result) bool { return role(item) == "user" },
+			truncateTools: func(item string) (string, int) {
+				if gjson.Get(item, "role").String() != "tool" {
+					return item, 0
+				}
+				return truncateStringField(item, "content")
+			},
+		}, true
+	case "claude":
+		return conversationShape{
+			path:   "messages",
+			pinned: func(gjson.Result) bool { return false },
+			turnStart: func(item gjson.Result) bool {
+				if role(item) != "user" {
+					return false
+				}
+				for _, block := range item.Get("content").Array() {
+					if block.Get("type").String() == "tool_result" {
+						return false
+					}
+				}
+				return true
+			},
+			truncateTools: func(item string) (string, int) {
+				total := 0
+				for i, block := range gjson.Get(item, "content").Array() {
+					if block.Get("type").String() != "tool_result" {
+						continue
+					}
+					path := fmt.Sprintf("content.%d.content", i)
+					if block.Get("content").Type == gjson.String {
+						var n int
+						item, n = truncateStringField(item, path)
+						total += n
+						continue
+					}
+					for j, part := range block.Get("content").Array() {
+						if part.Get("type").String() == "text" {
+							var n int
+							item, n = truncateStringField(item, fmt.Sprintf("%s.%d.text", path, j))
+							total += n
+						}
+					}
+				}
+				return item, total
+			},
+		}, true
+	case "openai-response":
+		if !gjson.GetBytes(rawJSON, "input").IsArray() {
+			return conversationShape{}, false
+		}
+		return conversationShape{
+			path:   "input",
+			pinned: func(item gjson.Result) bool { r := role(item); return r == "system" || r == "developer" },
+			turnStart: func(item gjson.Result) bool {
+				t := item.Get("type").String()
+				return role(item) == "user" && (t == "" || t == "message")
+			},
+			truncateTools: func(item string) (string, int) {
+				if gjson.Get(item, "type").String() != "function_call_output" {
+					return item, 0
+				}
+				return truncateStringField(item, "output")
+			},
+		}, true
+	case "gemini", "gemini-cli":
+		path := "contents"
+		if handlerType == "gemini-cli" {
+			path = "request.contents"
+		}
+		return conversationShape{
+			path:   path,
+			pinned: func(gjson.Result) bool { return false },
+			turnStart: func(item gjson.Result) bool {
+				if role(item) != "user" {
+					return false
+				}
+				for _, part := range item.Get("parts").Array() {
+					if part.Get("functionResponse").Exists() {
+						return false
+					}
+				}
+				return true
+			},
+		}, true
+	default:
+		return conversationShape{}, false
+	}
+}
+
+// truncateStringField middle-truncates the string at path in item when it exceeds
+// contextRetryToolOutputLimit.
+func truncateStringField(item, path string) (string, int) {
+	value := gjson.Get(item, path)
+	if value.Type != gjson.String {
+		return item, 0
+	}
+	truncated, ok := middleTruncate(value.String(), contextRetryToolOutputLimit)
+	if !ok {
+		return item, 0
+	}
+	updated, err := sjson.Set(item, path, truncated)
+	if err != nil {
+		return item, 0
+	}
+	return updated, 1
+}
+
+// middleTruncate keeps the head and tail of s within limit bytes, marking the cut.
+func middleTruncate(s string, limit int) (string, bool) {
+	if len(s) <= limit {
+		return s, false
+	}
+	head, tail := limit/2, len(s)-limit/2
+	for head > 0 && !utf8.RuneStart(s[head]) {
+		head--
+	}
+	for tail < len(s) && !utf8.RuneStart(s[tail]) {
+		tail++
+	}
+	return fmt.Sprintf("%s\n... [%d bytes truncated] ...\n%s", s[:head], tail-head, s[tail:]), true
+}
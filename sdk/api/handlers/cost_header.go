@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/tidwall/gjson"
+)
+
+// EstimatedCostHeader carries the estimated USD cost of a non-streaming response, priced
+// with model-pricing (and copilot-premium-request-usd for Copilot). Streaming responses
+// commit their headers before usage is known and never carry it.
+const EstimatedCostHeader = "X-Estimated-Cost-USD"
+
+// SetEstimatedCostHeader prices the upstream calls made for this request and sets
+// EstimatedCostHeader. Usage comes from the records executors attached to the request,
+// which hold upstream-reported usage or the executor's own tokenizer estimate; when no
+// executor attached one, the usage block of resp is priced against model. Nothing is set
+// when no price matches.
+func SetEstimatedCostHeader(c *gin.Context, model string, resp []byte) {
+	if c == nil {
+		return
+	}
+	var total float64
+	priced := false
+	if value, exists := c.Get(coreusage.ContextRecordsKey); exists {
+		records, _ := value.([]coreusage.Record)
+		for _, record := range records {
+			if cost, ok := usage.EstimateCost(record.Provider, record.Model, record.Detail); ok {
+				total += cost
+				priced = true
+			}
+		}
+	} else if detail, ok := responseUsage(resp); ok {
+		total, priced = usage.EstimateCost("", model, detail)
+	}
+	if !priced {
+		return
+	}
+	c.Header(EstimatedCostHeader, strconv.FormatFloat(total, 'f', 6, 64))
+}
+
+// responseUsage reads the usage block of an OpenAI chat/completions, OpenAI Responses,
+// Claude or Gemini response body.
+func responseUsage(resp []byte) (coreusage.Detail, bool) {
+	root := gjson.ParseBytes(resp)
+	if meta := root.Get("usageMetadata"); meta.Exists() {
+		return coreusage.Detail{
+			InputTokens:     meta.Get("promptTokenCount").Int(),
+			OutputTokens:    meta.Get("candidatesTokenCount").Int(),
+			ReasoningTokens: meta.Get("thoughtsTokenCount").Int(),
+			CachedTokens:    meta.Get("cachedContentTokenCount").Int(),
+			TotalTokens:     meta.Get("totalTokenCount").Int(),
+		}, true
+	}
+	node := root.Get("usage")
+	if !node.Exists() {
+		node = root.Get("response.usage")
+	}
+	switch {
+	case node.Get("prompt_tokens").Exists():
+		return coreusage.Detail{
+			InputTokens:  node.Get("prompt_tokens").Int(),
+			OutputTokens: node.Get("completion_tokens").Int(),
+			CachedTokens: node.Get("prompt_tokens_details.cached_tokens").Int(),
+			TotalTokens:  node.Get("total_tokens").Int(),
+		}, true
+	case node.Get("input_tokens").Exists():
+		// Claude reports cache reads apart from input_tokens; Responses counts them in it.
+		cacheRead := node.Get("cache_read_input_tokens").Int()
+		return coreusage.Detail{
+			InputTokens:  node.Get("input_tokens").Int() + cacheRead,
+			OutputTokens: node.Get("output_tokens").Int(),
+			CachedTokens: node.Get("input_tokens_details.cached_tokens").Int() + cacheRead,
+			TotalTokens:  node.Get("total_tokens").Int(),
+		}, true
+	}
+	return coreusage.Detail{}, false
+}
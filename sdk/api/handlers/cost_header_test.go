@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestSetEstimatedCostHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	usage.SetBudgetConfig(&config.Config{ModelPricing: []config.ModelPrice{
+		{Model: "claude-sonnet-*", Input: 3, Output: 15, CachedInput: 0.3},
+		{Model: "gpt-5", Input: 1.25, Output: 10},
+	}})
+	t.Cleanup(func() { usage.SetBudgetConfig(nil) })
+
+	cases := []struct {
+		name    string
+		records []coreusage.Record
+		resp    string
+		want    string
+	}{
+		{
+			// Fan-out and retried calls are all billed.
+			name: "executor records are summed",
+			records: []coreusage.Record{
+				{Provider: "codex", Model: "gpt-5", Detail: coreusage.Detail{InputTokens: 1000, OutputTokens: 100}},
+				{Provider: "codex", Model: "gpt-5", Detail: coreusage.Detail{InputTokens: 1000, OutputTokens: 100}},
+			},
+			resp: `{"usage":{"prompt_tokens":1,"completion_tokens":1}}`,
+			want: "0.004500",
+		},
+		{
+			name: "claude body usage with cache reads",
+			resp: `{"type":"message","usage":{"input_tokens":1000,"cache_read_input_tokens":2000,"output_tokens":200}}`,
+			want: "0.006600",
+		},
+		{
+			name: "no matching price",
+			records: []coreusage.Record{
+				{Provider: "codex", Model: "unpriced", Detail: coreusage.Detail{InputTokens: 1000}},
+			},
+		},
+		{
+			name: "no usage",
+			resp: `{"choices":[]}`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			if tc.records != nil {
+				c.Set(coreusage.ContextRecordsKey, tc.records)
+			}
+			SetEstimatedCostHeader(c, "claude-sonnet-4-5", []byte(tc.resp))
+			if got := rec.Header().Get(EstimatedCostHeader); got != tc.want {
+				t.Fatalf("%s = %q, want %q", EstimatedCostHeader, got, tc.want)
+			}
+		})
+	}
+}
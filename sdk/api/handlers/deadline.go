@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeadlineHeader lets clients bound a request in milliseconds.
+const DeadlineHeader = "X-Deadline-Ms"
+
+const defaultMaxRequestDeadline = 10 * time.Minute
+
+// deadlineExceededError reports a request that ran past its client deadline.
+type deadlineExceededError struct {
+	budget time.Duration
+}
+
+func (e *deadlineExceededError) Error() string {
+	return fmt.Sprintf("request deadline of %dms (%s) exceeded", e.budget.Milliseconds(), DeadlineHeader)
+}
+
+func (e *deadlineExceededError) StatusCode() int { return http.StatusGatewayTimeout }
+
+// RequestDeadline returns the deadline the client requested with X-Deadline-Ms, clamped to
+// max-request-deadline-ms. ok is false when the header is absent or not a positive integer.
+func (h *BaseAPIHandler) RequestDeadline(ctx context.Context) (time.Duration, bool) {
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	if ginCtx == nil || ginCtx.Request == nil {
+		return 0, false
+	}
+	raw := strings.TrimSpace(ginCtx.GetHeader(DeadlineHeader))
+	if raw == "" {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || ms <= 0 {
+		return 0, false
+	}
+	limit := defaultMaxRequestDeadline
+	if h != nil && h.Cfg != nil && h.Cfg.MaxRequestDeadlineMs > 0 {
+		limit = time.Duration(h.Cfg.MaxRequestDeadlineMs) * time.Millisecond
+	}
+	if ms > limit.Milliseconds() {
+		return limit, true
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
+
+// withRequestDeadline bounds ctx by the client deadline, if any.
+func (h *BaseAPIHandler) withRequestDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	budget, ok := h.RequestDeadline(ctx)
+	if !ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, budget, &deadlineExceededError{budget: budget})
+}
+
+// startDeadline cancels the returned context once the client deadline passes unless the
+// returned stop function is called first. Streaming requests stop it on their first payload.
+func (h *BaseAPIHandler) startDeadline(ctx context.Context) (context.Context, func()) {
+	budget, ok := h.RequestDeadline(ctx)
+	if !ok {
+		return ctx, func() {}
+	}
+	bounded, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(budget, func() { cancel(&deadlineExceededError{budget: budget}) })
+	return bounded, func() { timer.Stop() }
+}
+
+// deadlineError returns the deadline error when ctx was cancelled by the client deadline.
+func deadlineError(ctx context.Context) error {
+	var deadlineErr *deadlineExceededError
+	if errors.As(context.Cause(ctx), &deadlineErr) {
+		return deadlineErr
+	}
+	return nil
+}
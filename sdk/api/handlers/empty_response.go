@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// emptyResponseError reports a response without assistant content to the client.
+func emptyResponseError(handlerType, detail string) *interfaces.ErrorMessage {
+	msg := fmt.Sprintf("upstream returned an empty %s generation", handlerType)
+	if detail != "" {
+		msg += ": " + detail
+	}
+	return &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("%s", msg)}
+}
+
+// handleEmptyResponse applies the empty-response mode to a non-streaming response. When
+// the response has no assistant content, "error" fails it and "retry" executes the request
+// once more with the auth that served it excluded.
+func (h *BaseAPIHandler) handleEmptyResponse(ctx context.Context, handlerType, alt string, providers []string, req coreexecutor.Request, opts coreexecutor.Options, resp coreexecutor.Response) (coreexecutor.Response, *interfaces.ErrorMessage) {
+	if h == nil || h.Cfg == nil || alt != "" {
+		return resp, nil
+	}
+	mode := h.Cfg.EmptyResponseMode()
+	if mode == "" || !isEmptyAssistantResponse(handlerType, resp.Payload) {
+		return resp, nil
+	}
+	if mode == config.EmptyResponseError {
+		return resp, emptyResponseError(handlerType, "")
+	}
+
+	authID, _ := opts.Metadata[coreexecutor.SelectedAuthMetadataKey].(string)
+	log.Infof("empty response: retrying %s request without auth %s", handlerType, authID)
+	if authID != "" {
+		if opts.Metadata == nil {
+			opts.Metadata = make(map[string]any)
+		}
+		excluded, _ := opts.Metadata[coreexecutor.ExcludedAuthsMetadataKey].([]string)
+		opts.Metadata[coreexecutor.ExcludedAuthsMetadataKey] = append(append([]string(nil), excluded...), authID)
+	}
+	retried, err := h.AuthManager.Execute(ctx, providers, req, opts)
+	if err != nil {
+		return resp, emptyResponseError(handlerType, "retry failed: "+err.Error())
+	}
+	if isEmptyAssistantResponse(handlerType, retried.Payload) {
+		return resp, emptyResponseError(handlerType, "retry was empty too")
+	}
+	return retried, nil
+}
+
+// isEmptyAssistantResponse reports whether a non-streaming response in the client format
+// carries no assistant text and no tool calls. Reasoning alone counts as empty. Shapes that
+// are not recognized are never reported empty.
+func isEmptyAssistantResponse(handlerType string, payload []byte) bool {
+	root := gjson.ParseBytes(payload)
+	switch handlerType {
+	case "openai":
+		choices := root.Get("choices")
+		if !choices.IsArray() || len(choices.Array()) == 0 {
+			return false
+		}
+		for _, choice := range choices.Array() {
+			message := choice.Get("message")
+			if len(message.Get("tool_calls").Array()) > 0 || message.Get("function_call").Exists() || message.Get("refusal").String() != "" {
+				return false
+			}
+			if !blankText(message.Get("content")) {
+				return false
+			}
+		}
+		return true
+	case "claude":
+		content := root.Get("content")
+		if !content.IsArray() {
+			return false
+		}
+		for _, block := range content.Array() {
+			switch block.Get("type").String() {
+			case "text":
+				if strings.TrimSpace(block.Get("text").String()) != "" {
+					return false
+				}
+			case "thinking", "redacted_thinking":
+			default:
+				return false
+			}
+		}
+		return true
+	case "gemini", "gemini-cli":
+		candidates := root.Get("candidates")
+		if handlerType == "gemini-cli" && !candidates.Exists() {
+			candidates = root.Get("response.candidates")
+		}
+		if !candidates.IsArray() || len(candidates.Array()) == 0 {
+			return false
+		}
+		for _, candidate := range candidates.Array() {
+			for _, part := range candidate.Get("content.parts").Array() {
+				if part.Get("thought").Bool() {
+					continue
+				}
+				if part.Get("text").Type != gjson.String || strings.TrimSpace(part.Get("text").String()) != "" {
+					return false
+				}
+			}
+		}
+		return true
+	case "openai-response":
+		output := root.Get("output")
+		if !output.IsArray() {
+			return false
+		}
+		for _, item := range output.Array() {
+			switch item.Get("type").String() {
+			case "message":
+				if !blankText(item.Get("content")) {
+					return false
+				}
+			case "reasoning":
+			default:
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// blankText reports whether content, a string or a list of text parts, holds only
+// whitespace.
+func blankText(content gjson.Result) bool {
+	if content.IsArray() {
+		for _, part := range content.Array() {
+			if strings.TrimSpace(part.Get("text").String()) != "" || part.Get("refusal").String() != "" {
+				return false
+			}
+		}
+		return true
+	}
+	return strings.TrimSpace(content.String()) == ""
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// emptyOnceExecutor answers the first request with an empty assistant message and later
+// requests with content, recording the auth of every request.
+type emptyOnceExecutor struct {
+	mu    sync.Mutex
+	auths []string
+}
+
+func (e *emptyOnceExecutor) Identifier() string { return "empty-response-test" }
+
+func (e *emptyOnceExecutor) Execute(_ context.Context, auth *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.auths = append(e.auths, auth.ID)
+	if len(e.auths) == 1 {
+		return coreexecutor.Response{Payload: []byte(`{"choices":[{"message":{"role":"assistant","content":"  \n"},"finish_reason":"content_filter"}]}`)}, nil
+	}
+	return coreexecutor.Response{Payload: []byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)}, nil
+}
+
+func (e *emptyOnceExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *emptyOnceExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *emptyOnceExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *emptyOnceExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func newEmptyResponseHandler(t *testing.T, mode string) (*BaseAPIHandler, *emptyOnceExecutor) {
+	t.Helper()
+	executor := &emptyOnceExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	for _, id := range []string{"empty-response-a", "empty-response-b"} {
+		auth := &coreauth.Auth{ID: id, Provider: "empty-response-test", Status: coreauth.StatusActive}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register: %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "empty-response-model"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{EmptyResponse: mode}, manager), executor
+}
+
+func TestExecuteWithAuthManager_EmptyResponseRetriesOnAnotherAuth(t *testing.T) {
+	h, executor := newEmptyResponseHandler(t, "retry")
+
+	resp, _, errMsg := h.ExecuteWithAuthManager(context.Background(), "openai", "empty-response-model", []byte(`{"model":"empty-response-model","messages":[{"role":"user","content":"hi"}]}`), "")
+	if errMsg != nil {
+		t.Fatalf("ExecuteWithAuthManager error: %v", errMsg.Error)
+	}
+	if !strings.Contains(string(resp), `"ok"`) {
+		t.Fatalf("response = %s, want the retried content", resp)
+	}
+	if len(executor.auths) != 2 || executor.auths[0] == executor.auths[1] {
+		t.Fatalf("auths = %v, want a retry on a different auth", executor.auths)
+	}
+}
+
+func TestExecuteWithAuthManager_EmptyResponseError(t *testing.T) {
+	h, executor := newEmptyResponseHandler(t, "error")
+
+	_, _, errMsg := h.ExecuteWithAuthManager(context.Background(), "openai", "empty-response-model", []byte(`{"model":"empty-response-model","messages":[{"role":"user","content":"hi"}]}`), "")
+	if errMsg == nil {
+		t.Fatal("expected an empty generation error")
+	}
+	if errMsg.StatusCode != http.StatusBadGateway || !strings.Contains(errMsg.Error.Error(), "empty openai generation") {
+		t.Fatalf("error = %d %v, want a 502 empty generation error", errMsg.StatusCode, errMsg.Error)
+	}
+	if len(executor.auths) != 1 {
+		t.Fatalf("auths = %v, want no retry", executor.auths)
+	}
+}
+
+func TestIsEmptyAssistantResponse(t *testing.T) {
+	tests := []struct {
+		handlerType string
+		payload     string
+		want        bool
+	}{
+		{"openai", `{"choices":[{"message":{"content":""}}]}`, true},
+		{"openai", `{"choices":[{"message":{"content":null,"tool_calls":[{"id":"c1"}]}}]}`, false},
+		{"openai", `{"choices":[{"message":{"content":"","refusal":"no"}}]}`, false},
+		{"claude", `{"content":[{"type":"thinking","thinking":"hm"},{"type":"text","text":" "}]}`, true},
+		{"claude", `{"content":[{"type":"tool_use","id":"t1"}]}`, false},
+		{"gemini", `{"candidates":[{"content":{"parts":[{"text":"\n"}]}}]}`, true},
+		{"gemini", `{"candidates":[{"content":{"parts":[{"functionCall":{"name":"f"}}]}}]}`, false},
+		{"openai-response", `{"output":[{"type":"reasoning"},{"type":"message","content":[{"type":"output_text","text":""}]}]}`, true},
+		{"openai-response", `{"output":[{"type":"function_call","name":"f"}]}`, false},
+		{"openai", `{"error":"unrecognized"}`, false},
+	}
+	for _, tt := range tests {
+		if got := isEmptyAssistantResponse(tt.handlerType, []byte(tt.payload)); got != tt.want {
+			t.Errorf("isEmptyAssistantResponse(%s, %s) = %v, want %v", tt.handlerType, tt.payload, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// minEventPieceBytes is the smallest escaped string piece a split event carries, so a
+// threshold smaller than an event's fixed overhead still makes progress.
+const minEventPieceBytes = 16
+
+// MaxStreamEventBytes returns the data payload size above which streamed events are
+// split for the client of c: its entry in streaming.max-event-bytes-per-key, else
+// streaming.max-event-bytes. Zero means events are never split.
+func (h *BaseAPIHandler) MaxStreamEventBytes(c *gin.Context) int {
+	if h == nil || h.Cfg == nil {
+		return 0
+	}
+	if c != nil && len(h.Cfg.Streaming.MaxEventBytesPerKey) > 0 {
+		if key := c.GetString("apiKey"); key != "" {
+			if limit, ok := h.Cfg.Streaming.MaxEventBytesPerKey[key]; ok {
+				return max(limit, 0)
+			}
+		}
+	}
+	return max(h.Cfg.Streaming.MaxEventBytes, 0)
+}
+
+// EventPieceBudget returns how many escaped string bytes fit in an event whose payload
+// is overhead bytes long without the string, keeping it within maxBytes when possible.
+func EventPieceBudget(maxBytes, overhead int) int {
+	return max(maxBytes-overhead, minEventPieceBytes)
+}
+
+// CutJSONString splits s after the longest prefix whose JSON-escaped form fits in
+// maxEscaped bytes, never breaking a multi-byte rune. The prefix holds at least one
+// rune, so repeated cuts always consume s.
+func CutJSONString(s string, maxEscaped int) (head, rest string) {
+	size := 0
+	for i, r := range s {
+		width := jsonEscapedRuneLen(r, s[i:])
+		if size+width > maxEscaped && i > 0 {
+			return s[:i], s[i:]
+		}
+		size += width
+	}
+	return s, ""
+}
+
+// jsonEscapedRuneLen is an upper bound of the bytes r occupies inside a JSON string as
+// written by encoding/json or sjson, which may escape HTML characters and invalid bytes.
+func jsonEscapedRuneLen(r rune, rest string) int {
+	switch {
+	case r == '"' || r == '\\' || r == '\n' || r == '\r' || r == '\t':
+		return 2
+	case r < 0x20 || r == '<' || r == '>' || r == '&' || r == '\u2028' || r == '\u2029':
+		return 6
+	case r == utf8.RuneError:
+		if _, size := utf8.DecodeRuneInString(rest); size == 1 {
+			return 6
+		}
+		return utf8.RuneLen(r)
+	default:
+		return utf8.RuneLen(r)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestMaxStreamEventBytes_PerKeyOverride(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{}
+	cfg.Streaming.MaxEventBytes = 65536
+	cfg.Streaming.MaxEventBytesPerKey = map[string]int{"java-client": 8192, "unlimited": 0}
+	h := NewBaseAPIHandlers(cfg, nil)
+
+	for key, want := range map[string]int{"java-client": 8192, "unlimited": 0, "other": 65536, "": 65536} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		if key != "" {
+			c.Set("apiKey", key)
+		}
+		if got := h.MaxStreamEventBytes(c); got != want {
+			t.Fatalf("key %q: MaxStreamEventBytes = %d, want %d", key, got, want)
+		}
+	}
+}
+
+func TestCutJSONString_RespectsEscapedSize(t *testing.T) {
+	value := strings.Repeat("a\"<é🚀\n\\", 20)
+	var rebuilt strings.Builder
+	for rest := value; rest != ""; {
+		var piece string
+		piece, rest = CutJSONString(rest, 16)
+		encoded, _ := json.Marshal(piece)
+		if len(encoded)-2 > 16 {
+			t.Fatalf("piece %q encodes to %d bytes", piece, len(encoded)-2)
+		}
+		rebuilt.WriteString(piece)
+	}
+	if rebuilt.String() != value {
+		t.Fatalf("pieces do not reassemble the original")
+	}
+}
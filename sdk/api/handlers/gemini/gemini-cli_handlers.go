@@ -219,7 +219,8 @@ func (h *GeminiCLIAPIHandler) handleInternalGenerateContent(c *gin.Context, rawJ
 		return
 	}
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
-	_, _ = c.Writer.Write(resp)
+	handlers.SetEstimatedCostHeader(c, modelName, resp)
+	_, _ = c.Writer.Write(newGeminiRedactor(h.OutputRedactor()).response(resp))
 	cliCancel()
 }
 
@@ -230,13 +231,22 @@ func (h *GeminiCLIAPIHandler) forwardCLIStream(c *gin.Context, flusher http.Flus
 		keepAliveInterval = &d
 	}
 
+	redaction := newGeminiRedactor(h.OutputRedactor())
+	writeChunk := func(chunk []byte) {
+		if alt == "" {
+			_ = writeGeminiCLISSEChunk(c.Writer, chunk)
+		} else {
+			_, _ = c.Writer.Write(chunk)
+		}
+	}
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		KeepAliveInterval: keepAliveInterval,
 		WriteChunk: func(chunk []byte) {
-			if alt == "" {
-				_ = writeGeminiCLISSEChunk(c.Writer, chunk)
-			} else {
-				_, _ = c.Writer.Write(chunk)
+			writeChunk(redaction.chunk(chunk))
+		},
+		FlushHeld: func() {
+			for _, chunk := range redaction.flush() {
+				writeChunk(chunk)
 			}
 		},
 		WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
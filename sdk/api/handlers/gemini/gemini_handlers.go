@@ -62,13 +62,13 @@ func (h *GeminiAPIHandler) HandlerType() string {
 func (h *GeminiAPIHandler) Models() []map[string]any {
 	// Get dynamic models from the global registry
 	modelRegistry := registry.GetGlobalRegistry()
-	return modelRegistry.GetAvailableModels("gemini")
+	return h.ApplyCatalogOverlay(modelRegistry.GetAvailableModels("gemini"), "gemini")
 }
 
 // GeminiModels handles the Gemini models listing endpoint.
 // It returns a JSON response containing available Gemini models and their specifications.
 func (h *GeminiAPIHandler) GeminiModels(c *gin.Context) {
-	rawModels := h.Models()
+	rawModels := h.FilterModelsForTenant(c, h.Models())
 	normalizedModels := make([]map[string]any, 0, len(rawModels))
 	defaultMethods := []string{"generateContent"}
 	for _, model := range rawModels {
@@ -115,7 +115,7 @@ func (h *GeminiAPIHandler) GeminiGetHandler(c *gin.Context) {
 	action := strings.TrimPrefix(request.Action, "/")
 
 	// Get dynamic models from the global registry and find the matching one
-	availableModels := h.Models()
+	availableModels := h.FilterModelsForTenant(c, h.Models())
 	var targetModel map[string]any
 
 	for _, model := range availableModels {
@@ -221,11 +221,29 @@ func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName
 	}
 
 	// Peek at the first chunk
+	splitter := handlers.NewUTF8ChunkSplitter()
+	redaction := newGeminiRedactor(h.OutputRedactor())
+	// Heartbeats are SSE comments, so alt=json streams (a single JSON array) never get them.
+	var heartbeat <-chan time.Time
+	if alt == "" {
+		var stopHeartbeat func()
+		heartbeat, stopHeartbeat = h.FirstByteHeartbeat()
+		defer stopHeartbeat()
+	}
 	for {
 		select {
 		case <-c.Request.Context().Done():
 			cliCancel(c.Request.Context().Err())
 			return
+		case <-heartbeat:
+			// The upstream is slow to start: commit to streaming so the client sees bytes,
+			// and report any later error in-stream.
+			setSSEHeaders()
+			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+			_, _ = c.Writer.Write([]byte(handlers.StreamHeartbeatComment + "\n\n"))
+			flusher.Flush()
+			h.forwardGeminiStream(c, flusher, alt, func(err error) { cliCancel(err) }, dataChan, errChan, splitter, redaction)
+			return
 		case errMsg, ok := <-errChan:
 			if !ok {
 				// Err channel closed cleanly; wait for data channel.
@@ -259,6 +277,7 @@ func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName
 			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 
 			// Write first chunk
+			chunk = redaction.chunk(splitter.Split(chunk))
 			if alt == "" {
 				if !writeGeminiSSEData(c.Writer, chunk) {
 					continue
@@ -269,7 +288,7 @@ func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName
 			flusher.Flush()
 
 			// Continue
-			h.forwardGeminiStream(c, flusher, alt, func(err error) { cliCancel(err) }, dataChan, errChan)
+			h.forwardGeminiStream(c, flusher, alt, func(err error) { cliCancel(err) }, dataChan, errChan, splitter, redaction)
 			return
 		}
 	}
@@ -320,11 +339,12 @@ func (h *GeminiAPIHandler) handleGenerateContent(c *gin.Context, modelName strin
 		return
 	}
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
-	_, _ = c.Writer.Write(resp)
+	handlers.SetEstimatedCostHeader(c, modelName, resp)
+	_, _ = c.Writer.Write(newGeminiRedactor(h.OutputRedactor()).response(resp))
 	cliCancel()
 }
 
-func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flusher, alt string, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flusher, alt string, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, splitter *handlers.UTF8ChunkSplitter, redaction *geminiRedactor) {
 	var keepAliveInterval *time.Duration
 	if alt != "" {
 		d := time.Duration(0)
@@ -333,13 +353,24 @@ func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flus
 
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		KeepAliveInterval: keepAliveInterval,
+		Splitter:          splitter,
 		WriteChunk: func(chunk []byte) {
+			chunk = redaction.chunk(chunk)
 			if alt == "" {
 				_ = writeGeminiSSEData(c.Writer, chunk)
 			} else {
 				_, _ = c.Writer.Write(chunk)
 			}
 		},
+		FlushHeld: func() {
+			for _, chunk := range redaction.flush() {
+				if alt == "" {
+					_ = writeGeminiSSEData(c.Writer, chunk)
+				} else {
+					_, _ = c.Writer.Write(chunk)
+				}
+			}
+		},
 		WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
 			if errMsg == nil {
 				return
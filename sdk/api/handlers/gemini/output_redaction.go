@@ -0,0 +1,143 @@
+package gemini
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// geminiRedactor redacts the text parts of one Gemini response. Streamed text is redacted
+// per candidate, with thought parts apart from answer parts, using a lookahead buffer. The
+// held-back text is appended to the chunk carrying the candidate's finishReason, or written
+// as an extra chunk by flush when the stream ends first.
+type geminiRedactor struct {
+	redactor *handlers.OutputRedactor
+	streams  *handlers.RedactionStreams
+	root     string // "response." once a chunk came in the gemini-cli envelope
+}
+
+// geminiResponseRoots are the prefixes of the candidates array: a plain Gemini response and
+// the gemini-cli envelope.
+var geminiResponseRoots = []string{"", "response."}
+
+func newGeminiRedactor(redactor *handlers.OutputRedactor) *geminiRedactor {
+	if redactor == nil {
+		return nil
+	}
+	return &geminiRedactor{redactor: redactor, streams: redactor.NewStreams()}
+}
+
+// response redacts a non-streaming response.
+func (r *geminiRedactor) response(resp []byte) []byte {
+	if r == nil {
+		return resp
+	}
+	for _, root := range geminiResponseRoots {
+		resp = r.redactor.RedactPaths(resp, root+"candidates.#.content.parts.#.text")
+	}
+	return resp
+}
+
+// chunk redacts one streamed response object, with or without a "data:" prefix. Chunks
+// that are not a JSON object pass through.
+func (r *geminiRedactor) chunk(chunk []byte) []byte {
+	if r == nil {
+		return chunk
+	}
+	payload := bytes.TrimSpace(chunk)
+	trailing := chunk[len(bytes.TrimRight(chunk, " \t\r\n")):]
+	prefixed := false
+	if rest, ok := bytes.CutPrefix(payload, []byte("data:")); ok {
+		payload, prefixed = bytes.TrimSpace(rest), true
+	}
+	if !gjson.ValidBytes(payload) || !gjson.ParseBytes(payload).IsObject() {
+		return chunk
+	}
+	var candidates gjson.Result
+	for _, root := range geminiResponseRoots {
+		if candidates = gjson.GetBytes(payload, root+"candidates"); candidates.IsArray() {
+			r.root = root
+			break
+		}
+	}
+	if !candidates.IsArray() {
+		return chunk
+	}
+	payload = bytes.Clone(payload)
+	for ci, candidate := range candidates.Array() {
+		index := candidate.Get("index").Int()
+		partsPath := fmt.Sprintf("%scandidates.%d.content.parts", r.root, ci)
+		last := map[bool]int{}
+		for pi, part := range candidate.Get("content.parts").Array() {
+			text := part.Get("text")
+			if text.Type != gjson.String {
+				continue
+			}
+			thought := part.Get("thought").Bool()
+			last[thought] = pi
+			redacted := r.streams.Write(geminiStreamKey(index, thought), text.String())
+			payload, _ = sjson.SetBytes(payload, fmt.Sprintf("%s.%d.text", partsPath, pi), redacted)
+		}
+		if candidate.Get("finishReason").String() == "" {
+			continue
+		}
+		for _, thought := range []bool{true, false} {
+			held := r.streams.Flush(geminiStreamKey(index, thought))
+			if held == "" {
+				continue
+			}
+			if pi, ok := last[thought]; ok {
+				path := fmt.Sprintf("%s.%d.text", partsPath, pi)
+				payload, _ = sjson.SetBytes(payload, path, gjson.GetBytes(payload, path).String()+held)
+				continue
+			}
+			payload, _ = sjson.SetRawBytes(payload, partsPath+".-1", geminiTextPart(held, thought))
+		}
+	}
+	if prefixed {
+		payload = append([]byte("data: "), payload...)
+	}
+	return append(payload, trailing...)
+}
+
+// flush returns a chunk per candidate releasing text still held back when the stream ends.
+func (r *geminiRedactor) flush() [][]byte {
+	if r == nil {
+		return nil
+	}
+	var chunks [][]byte
+	for _, held := range r.streams.FlushAll() {
+		rawIndex, kind, _ := strings.Cut(held.Key, "/")
+		index, _ := strconv.ParseInt(rawIndex, 10, 64)
+		candidate := []byte(`{"content":{"role":"model","parts":[]}}`)
+		candidate, _ = sjson.SetBytes(candidate, "index", index)
+		candidate, _ = sjson.SetRawBytes(candidate, "content.parts.-1", geminiTextPart(held.Text, kind == "thought"))
+		chunk := []byte(`{"candidates":[]}`)
+		chunk, _ = sjson.SetRawBytes(chunk, "candidates.-1", candidate)
+		if r.root != "" {
+			chunk, _ = sjson.SetRawBytes([]byte(`{}`), strings.TrimSuffix(r.root, "."), chunk)
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+func geminiStreamKey(index int64, thought bool) string {
+	if thought {
+		return strconv.FormatInt(index, 10) + "/thought"
+	}
+	return strconv.FormatInt(index, 10) + "/text"
+}
+
+func geminiTextPart(text string, thought bool) []byte {
+	part, _ := sjson.SetBytes([]byte(`{}`), "text", text)
+	if thought {
+		part, _ = sjson.SetBytes(part, "thought", true)
+	}
+	return part
+}
@@ -0,0 +1,46 @@
+package gemini
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func testGeminiRedactor() *geminiRedactor {
+	return newGeminiRedactor(handlers.NewOutputRedactor(sdkconfig.OutputRedactionConfig{
+		Patterns:             []string{`sk-[A-Za-z0-9]{8,}`},
+		StreamLookaheadBytes: 64,
+	}))
+}
+
+func TestGeminiRedactor_ReleasesHeldTextOnFinishReason(t *testing.T) {
+	r := testGeminiRedactor()
+	first := r.chunk([]byte(`data: {"candidates":[{"index":0,"content":{"role":"model","parts":[{"text":"key sk-abcdef123456"}]}}]}` + "\n\n"))
+	if got := gjson.GetBytes(first[len("data: "):], "candidates.0.content.parts.0.text").String(); got != "" {
+		t.Fatalf("text inside the lookahead was emitted early: %q", got)
+	}
+
+	final := r.chunk([]byte(`{"candidates":[{"index":0,"content":{"role":"model","parts":[]},"finishReason":"STOP"}]}`))
+	if got := gjson.GetBytes(final, "candidates.0.content.parts.0.text").String(); got != "key [REDACTED]" {
+		t.Fatalf("final chunk = %s", final)
+	}
+	if chunks := r.flush(); len(chunks) != 0 {
+		t.Fatalf("nothing should be left to flush, got %q", chunks)
+	}
+}
+
+func TestGeminiRedactor_FlushKeepsCLIEnvelope(t *testing.T) {
+	r := testGeminiRedactor()
+	r.chunk([]byte(`{"response":{"candidates":[{"index":0,"content":{"role":"model","parts":[{"text":"saw sk-abcdef123456","thought":true}]}}]}}`))
+
+	chunks := r.flush()
+	if len(chunks) != 1 {
+		t.Fatalf("flush = %q, want one chunk", chunks)
+	}
+	part := gjson.GetBytes(chunks[0], "response.candidates.0.content.parts.0")
+	if part.Get("text").String() != "saw [REDACTED]" || !part.Get("thought").Bool() {
+		t.Fatalf("flushed chunk = %s", chunks[0])
+	}
+}
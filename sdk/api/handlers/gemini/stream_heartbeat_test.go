@@ -0,0 +1,107 @@
+package gemini
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+type slowStreamExecutor struct {
+	delay time.Duration
+	chunk string
+}
+
+func (e *slowStreamExecutor) Identifier() string { return "gemini-heartbeat-provider" }
+
+func (e *slowStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *slowStreamExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	ch := make(chan coreexecutor.StreamChunk)
+	go func() {
+		defer close(ch)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.delay):
+		}
+		ch <- coreexecutor.StreamChunk{Payload: []byte(e.chunk)}
+	}()
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *slowStreamExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *slowStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *slowStreamExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func TestGeminiStream_HeartbeatOnlyForSSE(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const chunk = `{"candidates":[{"content":{"parts":[{"text":"hi"}]}}]}`
+	executor := &slowStreamExecutor{delay: 80 * time.Millisecond, chunk: chunk}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "gemini-heartbeat-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "gemini-heartbeat-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	h := NewGeminiAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{HeartbeatInterval: "10ms"},
+	}, manager))
+	router := gin.New()
+	router.POST("/v1beta/models/*action", h.GeminiHandler)
+
+	// The upstream waits for several heartbeat intervals; only SSE gets pings.
+	cases := []struct {
+		name, query, wantTail string
+		pings                 bool
+	}{
+		{name: "sse", query: "?alt=sse", wantTail: "data: " + chunk + "\n\n", pings: true},
+		{name: "json", query: "?alt=json", wantTail: chunk},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1beta/models/gemini-heartbeat-model:streamGenerateContent"+tc.query, strings.NewReader(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`))
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+			if resp.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+			}
+			body := resp.Body.String()
+			head, found := strings.CutSuffix(body, tc.wantTail)
+			if !found {
+				t.Fatalf("body = %q, want it to end with %q", body, tc.wantTail)
+			}
+			if tc.pings && (head == "" || strings.ReplaceAll(head, ": ping\n\n", "") != "") {
+				t.Fatalf("want only pings before the data, got %q", head)
+			}
+			if !tc.pings && head != "" {
+				t.Fatalf("unexpected bytes before the data: %q", head)
+			}
+		})
+	}
+}
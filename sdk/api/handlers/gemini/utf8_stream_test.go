@@ -0,0 +1,76 @@
+package gemini
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+const utf8StreamSample = `héllo—wörld·世界🚀👩‍💻done`
+
+func runGeminiUTF8Stream(chunks []string, forward func(c *gin.Context, data <-chan []byte, errs <-chan *interfaces.ErrorMessage)) string {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1beta/models/gemini-2.5-pro:streamGenerateContent", nil)
+	data := make(chan []byte, len(chunks))
+	for _, chunk := range chunks {
+		data <- []byte(chunk)
+	}
+	close(data)
+	forward(c, data, make(chan *interfaces.ErrorMessage))
+	return rec.Body.String()
+}
+
+func dataPayloads(body string) string {
+	var out strings.Builder
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, "data: ") {
+			out.WriteString(strings.TrimPrefix(line, "data: "))
+		}
+	}
+	return out.String()
+}
+
+func TestGeminiStream_KeepsCodePointsAcrossChunks(t *testing.T) {
+	h := NewGeminiAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+	for _, alt := range []string{"", "json"} {
+		for cut := 1; cut < len(utf8StreamSample); cut++ {
+			body := runGeminiUTF8Stream([]string{utf8StreamSample[:cut], utf8StreamSample[cut:]}, func(c *gin.Context, data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+				h.forwardGeminiStream(c, c.Writer, alt, func(error) {}, data, errs, nil, nil)
+			})
+			if !utf8.ValidString(body) {
+				t.Fatalf("alt=%q cut=%d: invalid UTF-8 in stream %q", alt, cut, body)
+			}
+			got := body
+			if alt == "" {
+				got = dataPayloads(body)
+			}
+			if got != utf8StreamSample {
+				t.Fatalf("alt=%q cut=%d: stream reassembles to %q, want %q", alt, cut, got, utf8StreamSample)
+			}
+		}
+	}
+}
+
+func TestGeminiCLIStream_KeepsCodePointsAcrossChunks(t *testing.T) {
+	h := NewGeminiCLIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+	for cut := 1; cut < len(utf8StreamSample); cut++ {
+		body := runGeminiUTF8Stream([]string{"data: " + utf8StreamSample[:cut], "data: " + utf8StreamSample[cut:]}, func(c *gin.Context, data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+			h.forwardCLIStream(c, c.Writer, "", func(error) {}, data, errs)
+		})
+		if !utf8.ValidString(body) {
+			t.Fatalf("cut=%d: invalid UTF-8 in stream %q", cut, body)
+		}
+		if got := dataPayloads(body); got != utf8StreamSample {
+			t.Fatalf("cut=%d: payloads reassemble to %q, want %q", cut, got, utf8StreamSample)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -20,6 +21,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/timing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
@@ -158,6 +160,42 @@ func StreamingKeepAliveInterval(cfg *config.SDKConfig) time.Duration {
 	return time.Duration(seconds) * time.Second
 }
 
+// StreamHeartbeatComment is the SSE comment written while a stream is idle when
+// streaming.heartbeat-interval is set.
+const StreamHeartbeatComment = ": ping"
+
+// StreamingHeartbeatInterval returns the idle SSE heartbeat interval for this server.
+// Returning 0 disables heartbeats (default when unset).
+func StreamingHeartbeatInterval(cfg *config.SDKConfig) time.Duration {
+	if cfg == nil {
+		return 0
+	}
+	return cfg.Streaming.HeartbeatDuration()
+}
+
+// StreamKeepAliveComment returns the SSE comment line, without its line break, that
+// ForwardStream writes while a stream is idle.
+func StreamKeepAliveComment(cfg *config.SDKConfig) string {
+	if StreamingHeartbeatInterval(cfg) > 0 {
+		return StreamHeartbeatComment
+	}
+	return ": keep-alive"
+}
+
+// FirstByteHeartbeat returns a channel that fires once the heartbeat interval passes, for
+// handlers that wait for the first upstream chunk before committing SSE headers. On that
+// tick the handler commits the headers, writes a heartbeat and hands the stream over to
+// ForwardStream, which keeps beating while the stream stays idle. The channel is nil when
+// heartbeats are disabled. stop releases the timer.
+func (h *BaseAPIHandler) FirstByteHeartbeat() (beat <-chan time.Time, stop func()) {
+	interval := StreamingHeartbeatInterval(h.Cfg)
+	if interval <= 0 {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(interval)
+	return timer.C, func() { timer.Stop() }
+}
+
 // NonStreamingKeepAliveInterval returns the keep-alive interval for non-streaming responses.
 // Returning 0 disables keep-alives (default when unset).
 func NonStreamingKeepAliveInterval(cfg *config.SDKConfig) time.Duration {
@@ -343,6 +381,13 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 			parentCtx = logging.WithRequestID(parentCtx, requestID)
 		}
 	}
+	if requestCtx != nil && timing.FromContext(parentCtx) == nil {
+		if timer := timing.FromContext(requestCtx); timer != nil {
+			// Everything up to here (body read, JSON inspection) counts as request parsing.
+			timer.SetOnce(timing.PhaseParse, timer.Start())
+			parentCtx = timing.WithTimer(parentCtx, timer)
+		}
+	}
 	newCtx, cancel := context.WithCancel(parentCtx)
 	if requestCtx != nil && requestCtx != parentCtx {
 		go func() {
@@ -473,11 +518,30 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
-	providers, normalizedModel, extraMeta, errMsg := h.getRequestDetails(modelName)
+	modelName, rawJSON = h.applyKeyDefaults(ctx, handlerType, modelName, rawJSON)
+	rawJSON, errMsg := h.applyPreset(ctx, handlerType, modelName, rawJSON)
+	var (
+		providers       []string
+		normalizedModel string
+		extraMeta       map[string]any
+	)
+	if errMsg == nil {
+		providers, normalizedModel, extraMeta, errMsg = h.getRequestDetails(modelName)
+	}
+	if errMsg == nil {
+		providers, extraMeta = h.applyRoutingRules(ctx, normalizedModel, rawJSON, providers, extraMeta)
+	}
+	if errMsg == nil {
+		errMsg = h.checkModelBodyLimit(normalizedModel, rawJSON)
+	}
+	if errMsg == nil {
+		providers, errMsg = applyBudgetCaps(ctx, providers)
+	}
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
 	reqMeta := requestExecutionMetadata(ctx)
+	h.applyTenant(ctx, reqMeta)
 	if len(extraMeta) > 0 {
 		if reqMeta == nil {
 			reqMeta = make(map[string]any, len(extraMeta))
@@ -503,8 +567,20 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		Headers:         cloneRequestHeaders(ctx),
 	}
 	opts.Metadata = reqMeta
+	ctx, cancelDeadline := h.withRequestDeadline(ctx)
+	defer cancelDeadline()
 	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+	var truncated *contextTruncation
+	if shrunk, summary, ok := h.contextRetryPayload(ctx, handlerType, rawJSON, err); ok {
+		req.Payload, opts.OriginalRequest = shrunk, shrunk
+		if resp, err = h.AuthManager.Execute(ctx, providers, req, opts); err == nil {
+			truncated = &summary
+		}
+	}
 	if err != nil {
+		if errDeadline := deadlineError(ctx); errDeadline != nil {
+			err = errDeadline
+		}
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
 			if code := se.StatusCode(); code > 0 {
@@ -519,20 +595,38 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
-	if !PassthroughHeadersEnabled(h.Cfg) {
-		return resp.Payload, nil, nil
+	if errMsg = h.validateUpstreamResponse(handlerType, alt, resp.Payload); errMsg != nil {
+		return nil, nil, errMsg
 	}
-	return resp.Payload, FilterUpstreamHeaders(resp.Headers), nil
+	if resp, errMsg = h.handleEmptyResponse(ctx, handlerType, alt, providers, req, opts, resp); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	var headers http.Header
+	if PassthroughHeadersEnabled(h.Cfg) {
+		headers = FilterUpstreamHeaders(resp.Headers)
+	}
+	if truncated != nil {
+		headers = setContextTruncatedHeader(headers, *truncated)
+	}
+	h.startPrefetch(ctx, req, opts)
+	return resp.Payload, headers, nil
 }
 
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	modelName, rawJSON = h.applyKeyDefaults(ctx, handlerType, modelName, rawJSON)
+	rawJSON, errMsg := h.applyPreset(ctx, handlerType, modelName, rawJSON)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
 	providers, normalizedModel, extraMeta, errMsg := h.getRequestDetails(modelName)
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	providers, extraMeta = h.applyRoutingRules(ctx, normalizedModel, rawJSON, providers, extraMeta)
 	reqMeta := requestExecutionMetadata(ctx)
+	h.applyTenant(ctx, reqMeta)
 	if len(extraMeta) > 0 {
 		if reqMeta == nil {
 			reqMeta = make(map[string]any, len(extraMeta))
@@ -558,8 +652,13 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 		Headers:         cloneRequestHeaders(ctx),
 	}
 	opts.Metadata = reqMeta
+	ctx, cancelDeadline := h.withRequestDeadline(ctx)
+	defer cancelDeadline()
 	resp, err := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
 	if err != nil {
+		if errDeadline := deadlineError(ctx); errDeadline != nil {
+			err = errDeadline
+		}
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
 			if code := se.StatusCode(); code > 0 {
@@ -584,7 +683,25 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 // This path is the only supported execution route.
 // The returned http.Header carries upstream response headers captured before streaming begins.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
-	providers, normalizedModel, extraMeta, errMsg := h.getRequestDetails(modelName)
+	modelName, rawJSON = h.applyKeyDefaults(ctx, handlerType, modelName, rawJSON)
+	rawJSON, errMsg := h.applyPreset(ctx, handlerType, modelName, rawJSON)
+	var (
+		providers       []string
+		normalizedModel string
+		extraMeta       map[string]any
+	)
+	if errMsg == nil {
+		providers, normalizedModel, extraMeta, errMsg = h.getRequestDetails(modelName)
+	}
+	if errMsg == nil {
+		providers, extraMeta = h.applyRoutingRules(ctx, normalizedModel, rawJSON, providers, extraMeta)
+	}
+	if errMsg == nil {
+		errMsg = h.checkModelBodyLimit(normalizedModel, rawJSON)
+	}
+	if errMsg == nil {
+		providers, errMsg = applyBudgetCaps(ctx, providers)
+	}
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
@@ -592,6 +709,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		return nil, nil, errChan
 	}
 	reqMeta := requestExecutionMetadata(ctx)
+	h.applyTenant(ctx, reqMeta)
 	if len(extraMeta) > 0 {
 		if reqMeta == nil {
 			reqMeta = make(map[string]any, len(extraMeta))
@@ -617,8 +735,29 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		Headers:         cloneRequestHeaders(ctx),
 	}
 	opts.Metadata = reqMeta
-	streamResult, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	// The client deadline bounds time to first payload, including bootstrap retries; once
+	// data flows the stream runs under the request context alone.
+	streamCtx, stopDeadline := h.startDeadline(ctx)
+	// max-stream-duration bounds the whole stream, regardless of activity.
+	streamCtx, durationExpired, stopMaxDuration := h.startMaxStreamDuration(streamCtx, normalizedModel)
+	streamResult, err := h.AuthManager.ExecuteStream(streamCtx, providers, req, opts)
+	// A context-length rejection is retried once with a truncated request, whether it
+	// surfaces here or as the first stream chunk.
+	contextRetried := false
+	var truncated *contextTruncation
+	if shrunk, summary, ok := h.contextRetryPayload(ctx, handlerType, rawJSON, err); ok {
+		contextRetried = true
+		req.Payload, opts.OriginalRequest = shrunk, shrunk
+		if streamResult, err = h.AuthManager.ExecuteStream(streamCtx, providers, req, opts); err == nil {
+			truncated = &summary
+		}
+	}
 	if err != nil {
+		stopDeadline()
+		stopMaxDuration()
+		if errDeadline := deadlineError(streamCtx); errDeadline != nil {
+			err = errDeadline
+		}
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -642,9 +781,12 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	var upstreamHeaders http.Header
 	if passthroughHeadersEnabled {
 		upstreamHeaders = cloneHeader(FilterUpstreamHeaders(streamResult.Headers))
-		if upstreamHeaders == nil {
-			upstreamHeaders = make(http.Header)
-		}
+	}
+	if upstreamHeaders == nil && (passthroughHeadersEnabled || (h.Cfg != nil && h.Cfg.ContextOverflowRetry)) {
+		upstreamHeaders = make(http.Header)
+	}
+	if truncated != nil {
+		setContextTruncatedHeader(upstreamHeaders, *truncated)
 	}
 	chunks := streamResult.Chunks
 	dataChan := make(chan []byte)
@@ -652,6 +794,8 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	go func() {
 		defer close(dataChan)
 		defer close(errChan)
+		defer stopDeadline()
+		defer stopMaxDuration()
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
@@ -705,26 +849,67 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					select {
 					case <-ctx.Done():
 						return
+					case <-durationExpired:
+						_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusGatewayTimeout, Error: streamDurationError(streamCtx)})
+						return
 					case chunk, ok = <-chunks:
 					}
 				} else {
 					chunk, ok = <-chunks
 				}
 				if !ok {
+					if errDuration := streamDurationError(streamCtx); errDuration != nil {
+						_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusGatewayTimeout, Error: errDuration})
+					} else if errDeadline := deadlineError(streamCtx); errDeadline != nil && !sentPayload {
+						_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusGatewayTimeout, Error: errDeadline})
+					} else if sentPayload {
+						h.startPrefetch(ctx, req, opts)
+					}
 					return
 				}
 				if chunk.Err != nil {
 					streamErr := chunk.Err
+					if errDuration := streamDurationError(streamCtx); errDuration != nil {
+						_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusGatewayTimeout, Error: errDuration})
+						return
+					}
+					if errDeadline := deadlineError(streamCtx); errDeadline != nil {
+						_ = sendErr(&interfaces.ErrorMessage{StatusCode: http.StatusGatewayTimeout, Error: errDeadline})
+						return
+					}
 					// Safe bootstrap recovery: if the upstream fails before any payload bytes are sent,
 					// retry a few times (to allow auth rotation / transient recovery) and then attempt model fallback.
+					if !sentPayload && !contextRetried {
+						if shrunk, summary, ok := h.contextRetryPayload(ctx, handlerType, rawJSON, streamErr); ok {
+							contextRetried = true
+							req.Payload, opts.OriginalRequest = shrunk, shrunk
+							retryResult, retryErr := h.AuthManager.ExecuteStream(streamCtx, providers, req, opts)
+							if retryErr == nil {
+								truncated = &summary
+								if passthroughHeadersEnabled {
+									replaceHeader(upstreamHeaders, FilterUpstreamHeaders(retryResult.Headers))
+								}
+								setContextTruncatedHeader(upstreamHeaders, summary)
+								chunks = retryResult.Chunks
+								continue outer
+							}
+							streamErr = retryErr
+						}
+					}
 					if !sentPayload {
 						if bootstrapRetries < maxBootstrapRetries && bootstrapEligible(streamErr) {
 							bootstrapRetries++
-							retryResult, retryErr := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+							retryResult, retryErr := h.AuthManager.ExecuteStream(streamCtx, providers, req, opts)
+							if errDeadline := deadlineError(streamCtx); retryErr != nil && errDeadline != nil {
+								retryErr = errDeadline
+							}
 							if retryErr == nil {
 								if passthroughHeadersEnabled {
 									replaceHeader(upstreamHeaders, FilterUpstreamHeaders(retryResult.Headers))
 								}
+								if truncated != nil {
+									setContextTruncatedHeader(upstreamHeaders, *truncated)
+								}
 								chunks = retryResult.Chunks
 								continue outer
 							}
@@ -748,6 +933,9 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					return
 				}
 				if len(chunk.Payload) > 0 {
+					if !sentPayload {
+						stopDeadline()
+					}
 					sentPayload = true
 					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
 						return
@@ -778,7 +966,8 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 	}
 
 	// Explicit routing via model prefixes.
-	// These bypass client model registration filtering by setting forced_provider=true.
+	// These set forced_provider=true. Execution still skips auths whose registered models
+	// leave out the model, so excluded-models keep applying.
 	rawModel := trimmed
 	forcedProvider := ""
 	lower := strings.ToLower(rawModel)
@@ -838,6 +1027,9 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 		providers = util.GetProviderName(resolvedModelName)
 	}
 	if len(providers) == 0 {
+		if errOverlay := h.catalogOverlayUnavailable(modelName, baseModel); errOverlay != nil {
+			return nil, "", nil, errOverlay
+		}
 		return nil, "", nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("unknown provider for model %s", modelName)}
 	}
 
@@ -910,6 +1102,14 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 		}
 	}
 
+	// Rate-limited clients always get the upstream retry delay so they can back off,
+	// whether or not other upstream headers are passed through.
+	if status == http.StatusTooManyRequests && msg != nil && c.Writer.Header().Get("Retry-After") == "" {
+		if retryAfter := retryAfterFromError(msg.Error); retryAfter > 0 {
+			c.Writer.Header().Set("Retry-After", strconv.FormatInt(int64((retryAfter+time.Second-1)/time.Second), 10))
+		}
+	}
+
 	errText := http.StatusText(status)
 	if msg != nil && msg.Error != nil {
 		if v := strings.TrimSpace(msg.Error.Error()); v != "" {
@@ -942,6 +1142,18 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 	_, _ = c.Writer.Write(body)
 }
 
+// retryAfterFromError returns the retry delay an executor attached to err, or 0.
+func retryAfterFromError(err error) time.Duration {
+	var rap interface{ RetryAfter() *time.Duration }
+	if !errors.As(err, &rap) || rap == nil {
+		return 0
+	}
+	if retryAfter := rap.RetryAfter(); retryAfter != nil {
+		return *retryAfter
+	}
+	return 0
+}
+
 func (h *BaseAPIHandler) LoggingAPIResponseError(ctx context.Context, err *interfaces.ErrorMessage) {
 	if h.Cfg.RequestLog {
 		if ginContext, ok := ctx.Value("gin").(*gin.Context); ok {
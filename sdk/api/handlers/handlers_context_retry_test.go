@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// contextLimitExecutor rejects requests carrying more than maxMessages messages the way
+// OpenAI reports an oversized prompt, and records every payload it receives.
+type contextLimitExecutor struct {
+	maxMessages int
+	mu          sync.Mutex
+	payloads    []string
+}
+
+func (e *contextLimitExecutor) Identifier() string { return "context-retry-test" }
+
+func (e *contextLimitExecutor) admit(payload []byte) error {
+	e.mu.Lock()
+	e.payloads = append(e.payloads, string(payload))
+	e.mu.Unlock()
+	if n := len(gjson.GetBytes(payload, "messages").Array()); n > e.maxMessages {
+		return &coreauth.Error{
+			Code:       "context_length_exceeded",
+			Message:    `{"error":{"message":"This model's maximum context length is 128000 tokens.","code":"context_length_exceeded"}}`,
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return nil
+}
+
+func (e *contextLimitExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	if err := e.admit(req.Payload); err != nil {
+		return coreexecutor.Response{}, err
+	}
+	return coreexecutor.Response{Payload: []byte(`{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)}, nil
+}
+
+func (e *contextLimitExecutor) ExecuteStream(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	err := e.admit(req.Payload)
+	ch := make(chan coreexecutor.StreamChunk, 1)
+	if err != nil {
+		ch <- coreexecutor.StreamChunk{Err: err}
+	} else {
+		ch <- coreexecutor.StreamChunk{Payload: []byte("data: ok")}
+	}
+	close(ch)
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *contextLimitExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *contextLimitExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *contextLimitExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func newContextRetryHandler(t *testing.T, cfg *sdkconfig.SDKConfig) (*BaseAPIHandler, *contextLimitExecutor) {
+	t.Helper()
+	executor := &contextLimitExecutor{maxMessages: 5}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "context-retry-auth", Provider: "context-retry-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "context-retry-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(cfg, manager), executor
+}
+
+func contextRetryContext(t *testing.T, header string) context.Context {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if header != "" {
+		c.Request.Header.Set(ContextTruncationHeader, header)
+	}
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+// longConversation has a system prompt and three user turns, the second with a large tool output.
+func longConversation() []byte {
+	toolOutput := strings.Repeat("x", 3*contextRetryToolOutputLimit)
+	return []byte(`{"model":"context-retry-model","messages":[` +
+		`{"role":"system","content":"be brief"},` +
+		`{"role":"user","content":"turn one"},` +
+		`{"role":"assistant","content":"answer one"},` +
+		`{"role":"user","content":"turn two"},` +
+		`{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"read","arguments":"{}"}}]},` +
+		`{"role":"tool","tool_call_id":"call_1","content":"` + toolOutput + `"},` +
+		`{"role":"user","content":"turn three"}]}`)
+}
+
+func TestExecuteWithAuthManager_RetriesContextOverflowWithTruncation(t *testing.T) {
+	h, executor := newContextRetryHandler(t, &sdkconfig.SDKConfig{ContextOverflowRetry: true})
+
+	resp, headers, errMsg := h.ExecuteWithAuthManager(contextRetryContext(t, ""), "openai", "context-retry-model", longConversation(), "")
+	if errMsg != nil {
+		t.Fatalf("ExecuteWithAuthManager error: %v", errMsg.Error)
+	}
+	if !strings.Contains(string(resp), `"ok"`) {
+		t.Fatalf("unexpected response %s", resp)
+	}
+	if got := headers.Get(ContextTruncatedHeader); got != "dropped_messages=2; truncated_tool_outputs=1" {
+		t.Fatalf("%s = %q", ContextTruncatedHeader, got)
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("upstream saw %d requests, want 2", len(executor.payloads))
+	}
+	retried := gjson.Parse(executor.payloads[1])
+	messages := retried.Get("messages").Array()
+	if len(messages) != 5 || messages[0].Get("role").String() != "system" || messages[1].Get("content").String() != "turn two" {
+		t.Fatalf("retried messages = %s", retried.Get("messages").Raw)
+	}
+	tool := messages[3].Get("content").String()
+	if len(tool) > contextRetryToolOutputLimit+64 || !strings.Contains(tool, "bytes truncated") {
+		t.Fatalf("tool output was not middle-truncated (len %d)", len(tool))
+	}
+}
+
+func TestExecuteStreamWithAuthManager_RetriesContextOverflowBeforeFirstPayload(t *testing.T) {
+	h, executor := newContextRetryHandler(t, &sdkconfig.SDKConfig{ContextOverflowRetry: true})
+
+	dataChan, headers, errChan := h.ExecuteStreamWithAuthManager(contextRetryContext(t, ""), "openai", "context-retry-model", longConversation(), "")
+	var got []string
+	for chunk := range dataChan {
+		got = append(got, string(chunk))
+	}
+	if errMsg := <-errChan; errMsg != nil {
+		t.Fatalf("stream error: %v", errMsg.Error)
+	}
+	if len(got) != 1 || got[0] != "data: ok" {
+		t.Fatalf("stream chunks = %v", got)
+	}
+	if headers.Get(ContextTruncatedHeader) == "" {
+		t.Fatalf("missing %s header", ContextTruncatedHeader)
+	}
+	if len(executor.payloads) != 2 {
+		t.Fatalf("upstream saw %d requests, want 2", len(executor.payloads))
+	}
+}
+
+func TestExecuteWithAuthManager_ContextOverflowRetryRespectsOptOut(t *testing.T) {
+	cases := map[string]*sdkconfig.SDKConfig{
+		"flag off":      {},
+		"client header": {ContextOverflowRetry: true},
+	}
+	for name, cfg := range cases {
+		t.Run(name, func(t *testing.T) {
+			h, executor := newContextRetryHandler(t, cfg)
+			header := ""
+			if cfg.ContextOverflowRetry {
+				header = "disabled"
+			}
+			_, _, errMsg := h.ExecuteWithAuthManager(contextRetryContext(t, header), "openai", "context-retry-model", longConversation(), "")
+			if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+				t.Fatalf("expected the upstream 400 to be relayed, got %+v", errMsg)
+			}
+			if len(executor.payloads) != 1 {
+				t.Fatalf("upstream saw %d requests, want 1", len(executor.payloads))
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// stallingExecutor never answers before its context ends.
+type stallingExecutor struct{}
+
+func (stallingExecutor) Identifier() string { return "deadline-test" }
+
+func (stallingExecutor) Execute(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	<-ctx.Done()
+	return coreexecutor.Response{}, ctx.Err()
+}
+
+func (stallingExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	ch := make(chan coreexecutor.StreamChunk, 1)
+	go func() {
+		defer close(ch)
+		<-ctx.Done()
+		ch <- coreexecutor.StreamChunk{Err: ctx.Err()}
+	}()
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (stallingExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (stallingExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (stallingExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func deadlineContext(t *testing.T, header string) context.Context {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if header != "" {
+		c.Request.Header.Set(DeadlineHeader, header)
+	}
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func newStallingHandler(t *testing.T, cfg *sdkconfig.SDKConfig) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(stallingExecutor{})
+	auth := &coreauth.Auth{ID: "deadline-auth", Provider: "deadline-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "deadline-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(cfg, manager)
+}
+
+func TestRequestDeadline_ParsesAndClamps(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{MaxRequestDeadlineMs: 2000}, nil)
+	cases := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"abc", 0, false},
+		{"-5", 0, false},
+		{"150", 150 * time.Millisecond, true},
+		{"60000", 2 * time.Second, true},
+	}
+	for _, tc := range cases {
+		got, ok := h.RequestDeadline(deadlineContext(t, tc.header))
+		if got != tc.want || ok != tc.ok {
+			t.Fatalf("header %q: RequestDeadline = %v, %t; want %v, %t", tc.header, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	unbounded := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	if got, _ := unbounded.RequestDeadline(deadlineContext(t, "99999999")); got != defaultMaxRequestDeadline {
+		t.Fatalf("default clamp = %v, want %v", got, defaultMaxRequestDeadline)
+	}
+}
+
+func TestExecuteWithAuthManager_DeadlineHeaderShortensTimeout(t *testing.T) {
+	h := newStallingHandler(t, &sdkconfig.SDKConfig{})
+	start := time.Now()
+	_, _, errMsg := h.ExecuteWithAuthManager(deadlineContext(t, "50"), "openai", "deadline-model", []byte(`{"model":"deadline-model"}`), "")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("request took %v despite a 50ms deadline", elapsed)
+	}
+	if errMsg == nil || errMsg.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("error = %+v, want 504", errMsg)
+	}
+	if !strings.Contains(errMsg.Error.Error(), DeadlineHeader) {
+		t.Fatalf("error %q does not mention %s", errMsg.Error, DeadlineHeader)
+	}
+}
+
+func TestExecuteWithAuthManager_OverMaxDeadlineIsClamped(t *testing.T) {
+	h := newStallingHandler(t, &sdkconfig.SDKConfig{MaxRequestDeadlineMs: 50})
+	start := time.Now()
+	_, _, errMsg := h.ExecuteWithAuthManager(deadlineContext(t, "600000"), "openai", "deadline-model", []byte(`{"model":"deadline-model"}`), "")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("request took %v despite a 50ms cap", elapsed)
+	}
+	if errMsg == nil || errMsg.StatusCode != http.StatusGatewayTimeout || !strings.Contains(errMsg.Error.Error(), "50ms") {
+		t.Fatalf("error = %+v, want 504 after the clamped 50ms", errMsg)
+	}
+}
+
+func TestExecuteStreamWithAuthManager_DeadlineBoundsFirstByte(t *testing.T) {
+	h := newStallingHandler(t, &sdkconfig.SDKConfig{})
+	dataChan, _, errChan := h.ExecuteStreamWithAuthManager(deadlineContext(t, "50"), "openai", "deadline-model", []byte(`{"model":"deadline-model"}`), "")
+	if dataChan != nil {
+		for range dataChan {
+			t.Fatalf("unexpected payload")
+		}
+	}
+	select {
+	case errMsg := <-errChan:
+		if errMsg == nil || errMsg.StatusCode != http.StatusGatewayTimeout {
+			t.Fatalf("error = %+v, want 504", errMsg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("stream did not time out")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
@@ -66,3 +67,34 @@ func TestWriteErrorResponse_AddonHeadersEnabled(t *testing.T) {
 		t.Fatalf("X-Request-Id = %#v, want %#v", got, []string{"new-1", "new-2"})
 	}
 }
+
+type rateLimitedError struct{ retryAfter time.Duration }
+
+func (e rateLimitedError) Error() string {
+	return `{"error":{"type":"rate_limit_exceeded","message":"slow down"}}`
+}
+func (e rateLimitedError) StatusCode() int            { return http.StatusTooManyRequests }
+func (e rateLimitedError) RetryAfter() *time.Duration { return &e.retryAfter }
+
+func TestWriteErrorResponse_RateLimitSetsRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	handler := NewBaseAPIHandlers(nil, nil)
+	handler.WriteErrorResponse(c, &interfaces.ErrorMessage{
+		StatusCode: http.StatusTooManyRequests,
+		Error:      rateLimitedError{retryAfter: 1500 * time.Millisecond},
+	})
+
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusTooManyRequests)
+	}
+	if got := recorder.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("Retry-After = %q, want %q", got, "2")
+	}
+	if got, want := recorder.Body.String(), (rateLimitedError{}).Error(); got != want {
+		t.Fatalf("body = %q, want the upstream error JSON", got)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// drippingExecutor streams a chunk every few milliseconds until its context ends.
+type drippingExecutor struct {
+	cancelled chan struct{}
+}
+
+func (drippingExecutor) Identifier() string { return "drip-test" }
+
+func (drippingExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "Execute not implemented"}
+}
+
+func (e drippingExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	ch := make(chan coreexecutor.StreamChunk)
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				close(e.cancelled)
+				return
+			case <-ticker.C:
+				select {
+				case ch <- coreexecutor.StreamChunk{Payload: []byte("data: {}\n\n")}:
+				case <-ctx.Done():
+					close(e.cancelled)
+					return
+				}
+			}
+		}
+	}()
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (drippingExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (drippingExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (drippingExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestModelMaxStreamDuration(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{MaxStreamDuration: map[string]string{
+		"default": "30m",
+		"gpt-5*":  "10m",
+		"o3":      "bogus",
+	}}, nil)
+	cases := map[string]time.Duration{
+		"gpt-5.1-codex":     10 * time.Minute,
+		"gpt-5(high)":       10 * time.Minute,
+		"claude-sonnet-4-5": 30 * time.Minute,
+		"o3":                0,
+	}
+	for model, want := range cases {
+		if got := h.ModelMaxStreamDuration(model); got != want {
+			t.Fatalf("ModelMaxStreamDuration(%q) = %v, want %v", model, got, want)
+		}
+	}
+	if got := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil).ModelMaxStreamDuration("gpt-5"); got != 0 {
+		t.Fatalf("unconfigured ModelMaxStreamDuration = %v, want 0", got)
+	}
+}
+
+func TestExecuteStreamWithAuthManager_MaxStreamDurationAbortsActiveStream(t *testing.T) {
+	exec := drippingExecutor{cancelled: make(chan struct{})}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(exec)
+	auth := &coreauth.Auth{ID: "drip-auth", Provider: "drip-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "drip-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{MaxStreamDuration: map[string]string{"drip-*": "150ms"}}, manager)
+
+	start := time.Now()
+	dataChan, _, errChan := h.ExecuteStreamWithAuthManager(deadlineContext(t, ""), "openai", "drip-model", []byte(`{"model":"drip-model"}`), "")
+	chunks := 0
+	for range dataChan {
+		chunks++
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("stream still running after %v", time.Since(start))
+		}
+	}
+	elapsed := time.Since(start)
+	if chunks < 2 {
+		t.Fatalf("received %d chunks before the cap, want an active stream", chunks)
+	}
+	if elapsed < 150*time.Millisecond {
+		t.Fatalf("stream ended after %v, before the 150ms cap", elapsed)
+	}
+	errMsg := <-errChan
+	if errMsg == nil || errMsg.StatusCode != http.StatusGatewayTimeout {
+		t.Fatalf("error = %+v, want 504", errMsg)
+	}
+	if !strings.Contains(errMsg.Error.Error(), "max-stream-duration") || !strings.Contains(errMsg.Error.Error(), "150ms") {
+		t.Fatalf("error %q does not name max-stream-duration and the limit", errMsg.Error)
+	}
+	select {
+	case <-exec.cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("upstream stream was not cancelled")
+	}
+}
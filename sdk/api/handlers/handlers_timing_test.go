@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/timing"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// upstreamTimingExecutor talks to a real HTTP upstream through the timing transport
+// and runs responses through a translator, like the production executors do.
+type upstreamTimingExecutor struct {
+	upstreamURL string
+	translator  *sdktranslator.Registry
+}
+
+func newUpstreamTimingExecutor(upstreamURL string) *upstreamTimingExecutor {
+	reg := sdktranslator.NewRegistry()
+	reg.Register(sdktranslator.FormatOpenAI, sdktranslator.FormatOpenAI, nil, sdktranslator.ResponseTransform{
+		Stream: func(_ context.Context, _ string, _, _, raw []byte, _ *any) []string {
+			return []string{string(raw)}
+		},
+		NonStream: func(_ context.Context, _ string, _, _, raw []byte, _ *any) string {
+			return string(raw)
+		},
+	})
+	return &upstreamTimingExecutor{upstreamURL: upstreamURL, translator: reg}
+}
+
+func (e *upstreamTimingExecutor) Identifier() string { return "timing-test" }
+
+func (e *upstreamTimingExecutor) do(ctx context.Context, req coreexecutor.Request) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.upstreamURL, strings.NewReader(string(req.Payload)))
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: timing.NewTransport(nil)}
+	return client.Do(httpReq)
+}
+
+func (e *upstreamTimingExecutor) Execute(ctx context.Context, _ *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	resp, err := e.do(ctx, req)
+	if err != nil {
+		return coreexecutor.Response{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return coreexecutor.Response{}, err
+	}
+	out := e.translator.TranslateNonStream(ctx, sdktranslator.FormatOpenAI, opts.SourceFormat, req.Model, opts.OriginalRequest, req.Payload, body, nil)
+	return coreexecutor.Response{Payload: []byte(out)}, nil
+}
+
+func (e *upstreamTimingExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	resp, err := e.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan coreexecutor.StreamChunk)
+	go func() {
+		defer close(ch)
+		defer func() { _ = resp.Body.Close() }()
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+			for _, out := range e.translator.TranslateStream(ctx, sdktranslator.FormatOpenAI, opts.SourceFormat, req.Model, opts.OriginalRequest, req.Payload, line, nil) {
+				ch <- coreexecutor.StreamChunk{Payload: []byte(out + "\n\n")}
+			}
+		}
+	}()
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *upstreamTimingExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *upstreamTimingExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, nil
+}
+
+func (e *upstreamTimingExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestRequestTiming_PhasesPresentAndOrdered(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		time.Sleep(5 * time.Millisecond)
+		flusher, _ := w.(http.Flusher)
+		for i := 0; i < 3; i++ {
+			_, _ = io.WriteString(w, `data: {"choices":[]}`+"\n\n")
+			if flusher != nil {
+				flusher.Flush()
+			}
+			time.Sleep(2 * time.Millisecond)
+		}
+	}))
+	defer upstream.Close()
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(newUpstreamTimingExecutor(upstream.URL))
+	auth := &coreauth.Auth{ID: "timing-auth", Provider: "timing-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "timing-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+
+	logging.SetTimingHeaderEnabled(true)
+	t.Cleanup(func() { logging.SetTimingHeaderEnabled(false) })
+
+	var captured *timing.Timer
+	router := gin.New()
+	router.Use(logging.GinLogrusLogger())
+	router.Use(func(c *gin.Context) {
+		captured = timing.FromContext(c.Request.Context())
+		c.Next()
+	})
+	router.POST("/v1/chat/completions", func(c *gin.Context) {
+		raw, _ := io.ReadAll(c.Request.Body)
+		ctx, cancel := handler.GetContextWithCancel(nil, c, context.Background())
+		defer cancel()
+		if c.Query("stream") == "true" {
+			dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(ctx, "openai", "timing-model", raw, "")
+			for chunk := range dataChan {
+				_, _ = c.Writer.Write(chunk)
+			}
+			for msg := range errChan {
+				if msg != nil {
+					t.Errorf("unexpected stream error: %v", msg.Error)
+				}
+			}
+			return
+		}
+		payload, _, errMsg := handler.ExecuteWithAuthManager(ctx, "openai", "timing-model", raw, "")
+		if errMsg != nil {
+			t.Errorf("unexpected error: %v", errMsg.Error)
+			return
+		}
+		_, _ = c.Writer.Write(payload)
+	})
+
+	want := []string{
+		timing.PhaseParse,
+		timing.PhaseAuthSelect,
+		timing.PhaseTranslateIn,
+		timing.PhaseConnect,
+		timing.PhaseTTFT,
+		timing.PhaseStream,
+		timing.PhaseTranslateOut,
+	}
+
+	for _, stream := range []bool{false, true} {
+		captured = nil
+		target := "/v1/chat/completions"
+		if stream {
+			target += "?stream=true"
+		}
+		body := `{"model":"timing-model","messages":[]}`
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("stream=%v: status = %d, body = %s", stream, rec.Code, rec.Body.String())
+		}
+		if captured == nil {
+			t.Fatalf("stream=%v: expected a request timer in the request context", stream)
+		}
+
+		phases := captured.Phases()
+		if len(phases) != len(want) {
+			t.Fatalf("stream=%v: phases = %+v, want %v", stream, phases, want)
+		}
+		for i, phase := range phases {
+			if phase.Name != want[i] {
+				t.Fatalf("stream=%v: phase[%d] = %q, want %q", stream, i, phase.Name, want[i])
+			}
+		}
+		if captured.Size(timing.SizeRequest) != int64(len(body)) {
+			t.Fatalf("stream=%v: request_bytes = %d, want %d", stream, captured.Size(timing.SizeRequest), len(body))
+		}
+		if captured.Size(timing.SizeUpstreamRequest) == 0 || captured.Size(timing.SizeResponse) == 0 {
+			t.Fatalf("stream=%v: expected upstream request and response sizes, got %v", stream, captured.Fields())
+		}
+
+		header := rec.Header().Get(logging.TimingHeader)
+		if header == "" {
+			t.Fatalf("stream=%v: expected %s header", stream, logging.TimingHeader)
+		}
+		last := -1
+		for _, name := range []string{timing.PhaseParse, timing.PhaseAuthSelect, timing.PhaseTranslateIn, timing.PhaseConnect, timing.PhaseTTFT} {
+			idx := strings.Index(header, name+";dur=")
+			if idx <= last {
+				t.Fatalf("stream=%v: header %q missing or misordered phase %q", stream, header, name)
+			}
+			last = idx
+		}
+	}
+}
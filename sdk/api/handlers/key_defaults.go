@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// AdjustedParamsHeader lists the request parameters the proxy changed on behalf of the
+// client API key, e.g. "model=gpt-4->gpt-5, temperature=0.2".
+const AdjustedParamsHeader = "X-Adjusted-Params"
+
+// applyKeyDefaults applies the api-key-defaults entry of the calling API key to the
+// request before routing. It returns the model to route and the rewritten body.
+func (h *BaseAPIHandler) applyKeyDefaults(ctx context.Context, handlerType, modelName string, rawJSON []byte) (string, []byte) {
+	if h == nil || h.Cfg == nil || len(h.Cfg.APIKeyDefaults) == 0 {
+		return modelName, rawJSON
+	}
+	defaults := h.Cfg.APIKeyDefaultsFor(clientAPIKey(ctx))
+	if defaults == nil {
+		return modelName, rawJSON
+	}
+	var adjusted []string
+	set := func(path string, value any) {
+		if len(rawJSON) == 0 {
+			return
+		}
+		if updated, err := sjson.SetBytes(rawJSON, path, value); err == nil {
+			rawJSON = updated
+		}
+	}
+
+	if target := strings.TrimSpace(defaults.DefaultModel); target != "" && target != modelName && substituteModel(defaults.ForceModel, defaults.PlaceholderModels, modelName) {
+		if strings.TrimSpace(modelName) == "" {
+			adjusted = append(adjusted, "model="+target)
+		} else {
+			adjusted = append(adjusted, fmt.Sprintf("model=%s->%s", modelName, target))
+		}
+		modelName = target
+		// Gemini clients name the model in the URL path, not the body.
+		if handlerType != constant.Gemini {
+			set("model", target)
+		}
+	}
+
+	prefix := ""
+	switch handlerType {
+	case constant.Gemini:
+		prefix = "generationConfig."
+	case constant.GeminiCLI:
+		prefix = "request.generationConfig."
+	}
+	if defaults.Temperature != nil {
+		set(prefix+"temperature", *defaults.Temperature)
+		adjusted = append(adjusted, "temperature="+strconv.FormatFloat(*defaults.Temperature, 'f', -1, 64))
+	}
+	if defaults.MaxTokens > 0 {
+		path := maxTokensPath(handlerType, rawJSON)
+		set(prefix+path, defaults.MaxTokens)
+		adjusted = append(adjusted, fmt.Sprintf("%s=%d", path, defaults.MaxTokens))
+	}
+
+	if len(adjusted) > 0 {
+		summary := strings.Join(adjusted, ", ")
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			ginCtx.Header(AdjustedParamsHeader, summary)
+		}
+		log.Infof("api-key defaults: adjusted %s request (%s)", handlerType, summary)
+	}
+	return modelName, rawJSON
+}
+
+// substituteModel reports whether the default model replaces model: always when forced,
+// otherwise when model is missing or matches a placeholder.
+func substituteModel(force bool, placeholders []string, model string) bool {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if force || model == "" {
+		return true
+	}
+	for _, placeholder := range placeholders {
+		if pattern := strings.ToLower(strings.TrimSpace(placeholder)); pattern != "" && matchModelGlob(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxTokensPath returns the output token limit field for the client schema.
+func maxTokensPath(handlerType string, rawJSON []byte) string {
+	switch handlerType {
+	case constant.OpenaiResponse:
+		return "max_output_tokens"
+	case constant.Gemini, constant.GeminiCLI:
+		return "maxOutputTokens"
+	case constant.OpenAI:
+		if gjson.GetBytes(rawJSON, "max_completion_tokens").Exists() {
+			return "max_completion_tokens"
+		}
+	}
+	return "max_tokens"
+}
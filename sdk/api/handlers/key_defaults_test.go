@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func keyDefaultsContext(apiKey string) (context.Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Set("apiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", c), recorder
+}
+
+func TestExecuteWithAuthManager_APIKeyDefaults(t *testing.T) {
+	temperature := 0.2
+	cfg := &sdkconfig.SDKConfig{APIKeyDefaults: []sdkconfig.APIKeyDefaults{
+		{APIKey: "legacy", DefaultModel: "context-retry-model", PlaceholderModels: []string{"gpt-4", "gpt-3.5-*"}, Temperature: &temperature, MaxTokens: 100},
+		{APIKey: "pinned", DefaultModel: "context-retry-model", ForceModel: true},
+	}}
+
+	cases := []struct {
+		name, apiKey, model, body, wantHeader string
+	}{
+		{
+			name: "placeholder", apiKey: "legacy", model: "GPT-3.5-turbo",
+			body:       `{"model":"GPT-3.5-turbo","temperature":1,"messages":[]}`,
+			wantHeader: "model=GPT-3.5-turbo->context-retry-model, temperature=0.2, max_tokens=100",
+		},
+		{
+			name: "missing model", apiKey: "legacy", model: "",
+			body:       `{"messages":[]}`,
+			wantHeader: "model=context-retry-model, temperature=0.2, max_tokens=100",
+		},
+		{
+			name: "explicit model wins", apiKey: "legacy", model: "context-retry-model",
+			body:       `{"model":"context-retry-model","messages":[]}`,
+			wantHeader: "temperature=0.2, max_tokens=100",
+		},
+		{
+			name: "force-model", apiKey: "pinned", model: "gpt-4o",
+			body:       `{"model":"gpt-4o","messages":[]}`,
+			wantHeader: "model=gpt-4o->context-retry-model",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, executor := newContextRetryHandler(t, cfg)
+			ctx, recorder := keyDefaultsContext(tc.apiKey)
+			if _, _, errMsg := h.ExecuteWithAuthManager(ctx, "openai", tc.model, []byte(tc.body), ""); errMsg != nil {
+				t.Fatalf("ExecuteWithAuthManager: %v", errMsg.Error)
+			}
+			if len(executor.payloads) != 1 {
+				t.Fatalf("upstream saw %d requests, want 1", len(executor.payloads))
+			}
+			payload := executor.payloads[0]
+			if got := gjson.Get(payload, "model").String(); got != "context-retry-model" {
+				t.Fatalf("upstream model = %q: %s", got, payload)
+			}
+			if tc.apiKey == "legacy" && (gjson.Get(payload, "temperature").Float() != 0.2 || gjson.Get(payload, "max_tokens").Int() != 100) {
+				t.Fatalf("parameter overrides not applied: %s", payload)
+			}
+			if got := recorder.Header().Get(AdjustedParamsHeader); got != tc.wantHeader {
+				t.Fatalf("%s = %q, want %q", AdjustedParamsHeader, got, tc.wantHeader)
+			}
+		})
+	}
+
+	// Keys without an entry are left alone.
+	h, executor := newContextRetryHandler(t, cfg)
+	ctx, recorder := keyDefaultsContext("other")
+	if _, _, errMsg := h.ExecuteWithAuthManager(ctx, "openai", "gpt-4", []byte(`{"model":"gpt-4"}`), ""); errMsg == nil {
+		t.Fatalf("placeholder model was routed for a key without defaults: %v", executor.payloads)
+	}
+	if got := recorder.Header().Get(AdjustedParamsHeader); got != "" {
+		t.Fatalf("%s = %q for a key without defaults", AdjustedParamsHeader, got)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// ModelAvailabilityPotentiallyUnavailable marks catalog overlay entries that no live
+// credential currently backs.
+const ModelAvailabilityPotentiallyUnavailable = "potentially_unavailable"
+
+// ApplyCatalogOverlay appends configured model-catalog-overlay entries that are missing
+// from the live model listing. Added entries carry "availability": "potentially_unavailable".
+func (h *BaseAPIHandler) ApplyCatalogOverlay(models []map[string]any, handlerType string) []map[string]any {
+	if h == nil || h.Cfg == nil || len(h.Cfg.ModelCatalogOverlay) == 0 {
+		return models
+	}
+	listed := make(map[string]struct{}, len(models))
+	for _, model := range models {
+		for _, key := range []string{"id", "name"} {
+			if value, ok := model[key].(string); ok && value != "" {
+				listed[strings.ToLower(strings.TrimPrefix(value, "models/"))] = struct{}{}
+			}
+		}
+	}
+	modelRegistry := registry.GetGlobalRegistry()
+	for _, entry := range h.Cfg.ModelCatalogOverlay {
+		id := strings.TrimSpace(entry.ID)
+		if id == "" {
+			continue
+		}
+		if _, ok := listed[strings.ToLower(id)]; ok {
+			continue
+		}
+		model := modelRegistry.FormatModel(catalogModelInfo(entry), handlerType)
+		if model == nil {
+			continue
+		}
+		model["availability"] = ModelAvailabilityPotentiallyUnavailable
+		listed[strings.ToLower(id)] = struct{}{}
+		models = append(models, model)
+	}
+	return models
+}
+
+// catalogOverlayUnavailable returns the error for a request naming an overlay-only model
+// that has no live provider, or nil when modelName is not part of the overlay.
+func (h *BaseAPIHandler) catalogOverlayUnavailable(modelName, baseModel string) *interfaces.ErrorMessage {
+	if h == nil || h.Cfg == nil {
+		return nil
+	}
+	for _, entry := range h.Cfg.ModelCatalogOverlay {
+		id := strings.TrimSpace(entry.ID)
+		if id != "" && (strings.EqualFold(id, baseModel) || strings.EqualFold(id, modelName)) {
+			return &interfaces.ErrorMessage{
+				StatusCode: http.StatusServiceUnavailable,
+				Error:      fmt.Errorf("model %s is configured but currently unavailable: no live provider is serving it", modelName),
+			}
+		}
+	}
+	return nil
+}
+
+func catalogModelInfo(entry config.ModelCatalogEntry) *registry.ModelInfo {
+	id := strings.TrimSpace(entry.ID)
+	info := &registry.ModelInfo{
+		ID:                  id,
+		Object:              "model",
+		Created:             entry.Created,
+		OwnedBy:             strings.TrimSpace(entry.OwnedBy),
+		Type:                strings.TrimSpace(entry.Type),
+		DisplayName:         strings.TrimSpace(entry.DisplayName),
+		Name:                "models/" + id,
+		Description:         strings.TrimSpace(entry.Description),
+		ContextLength:       entry.ContextLength,
+		MaxCompletionTokens: entry.MaxCompletionTokens,
+		InputTokenLimit:     entry.ContextLength,
+		OutputTokenLimit:    entry.MaxCompletionTokens,
+	}
+	if info.DisplayName == "" {
+		info.DisplayName = id
+	}
+	return info
+}
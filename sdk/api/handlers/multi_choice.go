@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Modes for handling OpenAI requests that ask for multiple completions (`n` > 1).
+const (
+	// MultiChoicePassthrough forwards `n` to the upstream unchanged (default).
+	MultiChoicePassthrough = "passthrough"
+	// MultiChoiceFanOut issues `n` single-choice upstream calls and merges their choices.
+	MultiChoiceFanOut = "fan-out"
+	// MultiChoiceReject rejects the request with a 400 explaining that `n` > 1 is unsupported.
+	MultiChoiceReject = "reject"
+)
+
+// maxFanOutChoices caps how many upstream calls a single fan-out request may trigger.
+const maxFanOutChoices = 16
+
+// RequestedChoiceCount returns the `n` value of an OpenAI-style request, defaulting to 1.
+func RequestedChoiceCount(rawJSON []byte) int {
+	n := gjson.GetBytes(rawJSON, "n")
+	if !n.Exists() || n.Type != gjson.Number {
+		return 1
+	}
+	if v := int(n.Int()); v > 1 {
+		return v
+	}
+	return 1
+}
+
+// MultiChoiceMode resolves the configured `n` handling mode for the provider that
+// would serve modelName. Lookup order: the provider's entry, then "default".
+// It also returns the provider name used for the lookup.
+func (h *BaseAPIHandler) MultiChoiceMode(modelName string) (mode string, provider string) {
+	mode = MultiChoicePassthrough
+	if h == nil {
+		return mode, ""
+	}
+	providers, _, _, errMsg := h.getRequestDetails(modelName)
+	if errMsg == nil && len(providers) > 0 {
+		provider = providers[0]
+	}
+	if h.Cfg == nil || len(h.Cfg.MultiChoice) == 0 {
+		return mode, provider
+	}
+	lookup := func(key string) (string, bool) {
+		for k, v := range h.Cfg.MultiChoice {
+			if strings.EqualFold(strings.TrimSpace(k), key) {
+				return normalizeMultiChoiceMode(v), true
+			}
+		}
+		return "", false
+	}
+	if provider != "" {
+		if v, ok := lookup(provider); ok {
+			return v, provider
+		}
+	}
+	if v, ok := lookup("default"); ok {
+		return v, provider
+	}
+	return mode, provider
+}
+
+func normalizeMultiChoiceMode(raw string) string {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "fan-out", "fanout", "fan_out", "merge":
+		return MultiChoiceFanOut
+	case "reject", "error", "deny":
+		return MultiChoiceReject
+	default:
+		return MultiChoicePassthrough
+	}
+}
+
+// MultiChoiceError builds the error returned when `n` > 1 cannot be honored.
+func MultiChoiceError(provider string, n int, stream bool) *interfaces.ErrorMessage {
+	target := "the selected provider"
+	if provider != "" {
+		target = "provider " + provider
+	}
+	msg := fmt.Sprintf("n=%d is not supported by %s; request a single completion (n=1)", n, target)
+	if stream {
+		msg = fmt.Sprintf("streaming with n=%d is not supported by %s; set stream=false or n=1", n, target)
+	}
+	return &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("%s", msg)}
+}
+
+// ExecuteFanOutWithAuthManager serves an OpenAI chat completion request with `n` > 1
+// by issuing n single-choice upstream calls and merging their choices. Calls run
+// sequentially so per-request logging state on the gin context is never shared
+// between concurrent executions. Any failing call fails the whole request.
+func (h *BaseAPIHandler) ExecuteFanOutWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string, n int) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	if n > maxFanOutChoices {
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("n=%d exceeds the maximum of %d for fan-out", n, maxFanOutChoices)}
+	}
+	single, err := sjson.DeleteBytes(rawJSON, "n")
+	if err != nil {
+		single = rawJSON
+	}
+
+	responses := make([][]byte, 0, n)
+	var headers http.Header
+	for i := 0; i < n; i++ {
+		resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(ctx, handlerType, modelName, single, alt)
+		if errMsg != nil {
+			return nil, nil, errMsg
+		}
+		if headers == nil {
+			headers = upstreamHeaders
+		}
+		responses = append(responses, resp)
+	}
+	return MergeChatCompletionChoices(responses), headers, nil
+}
+
+// MergeChatCompletionChoices merges OpenAI chat completion responses into a single
+// response whose choices are re-indexed sequentially. The first response supplies the
+// envelope (id, model, created); numeric usage fields are summed across all responses.
+func MergeChatCompletionChoices(responses [][]byte) []byte {
+	if len(responses) == 0 {
+		return nil
+	}
+	out := responses[0]
+	choices := make([]any, 0, len(responses))
+	var usage map[string]any
+	for _, resp := range responses {
+		for _, choice := range gjson.GetBytes(resp, "choices").Array() {
+			value, ok := choice.Value().(map[string]any)
+			if !ok {
+				continue
+			}
+			value["index"] = len(choices)
+			choices = append(choices, value)
+		}
+		if u, ok := gjson.GetBytes(resp, "usage").Value().(map[string]any); ok {
+			usage = sumUsage(usage, u)
+		}
+	}
+	if merged, err := sjson.SetBytes(out, "choices", choices); err == nil {
+		out = merged
+	}
+	if usage != nil {
+		if merged, err := sjson.SetBytes(out, "usage", usage); err == nil {
+			out = merged
+		}
+	}
+	return out
+}
+
+// sumUsage adds the numeric leaves of src into dst, recursing into nested detail objects.
+func sumUsage(dst, src map[string]any) map[string]any {
+	if dst == nil {
+		dst = make(map[string]any, len(src))
+	}
+	for k, v := range src {
+		switch value := v.(type) {
+		case float64:
+			prev, _ := dst[k].(float64)
+			dst[k] = prev + value
+		case map[string]any:
+			prev, _ := dst[k].(map[string]any)
+			dst[k] = sumUsage(prev, value)
+		default:
+			if _, exists := dst[k]; !exists {
+				dst[k] = v
+			}
+		}
+	}
+	return dst
+}
@@ -0,0 +1,11 @@
+package handlers
+
+import "testing"
+
+func TestMultiChoiceMode_NilHandler(t *testing.T) {
+	var h *BaseAPIHandler
+	mode, provider := h.MultiChoiceMode("gpt-4o")
+	if mode != MultiChoicePassthrough || provider != "" {
+		t.Fatalf("MultiChoiceMode() = %q, %q; want passthrough with no provider", mode, provider)
+	}
+}
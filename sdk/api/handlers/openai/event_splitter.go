@@ -0,0 +1,135 @@
+package openai
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// splitField is one string field of a chat completion chunk that may be split, addressed
+// relative to choices.0.
+type splitField struct {
+	path  string
+	value string
+	// toolIndex is the tool call's "index" for tool argument fields, or -1.
+	toolIndex int64
+}
+
+// splitOpenAIChunk splits a chat completion (or text completion) chunk whose payload is
+// larger than maxBytes into several chunks, each carrying a piece of the content,
+// reasoning_content, text, or tool call arguments. The first chunk keeps the original
+// metadata (role, tool call ids and names); later ones carry only the continued field, as
+// upstream deltas do. finish_reason and usage move to the last chunk. Concatenating the
+// pieces per field reproduces the original values. Chunks that cannot be split are
+// returned unchanged.
+func splitOpenAIChunk(payload []byte, maxBytes int) [][]byte {
+	if maxBytes <= 0 || len(payload) <= maxBytes || !gjson.ValidBytes(payload) {
+		return [][]byte{payload}
+	}
+	choices := gjson.GetBytes(payload, "choices").Array()
+	if len(choices) != 1 {
+		return [][]byte{payload}
+	}
+	choice := choices[0]
+
+	var fields []splitField
+	for _, path := range []string{"delta.reasoning_content", "delta.content", "text"} {
+		if v := choice.Get(path); v.Type == gjson.String && v.String() != "" {
+			fields = append(fields, splitField{path: path, value: v.String(), toolIndex: -1})
+		}
+	}
+	choice.Get("delta.tool_calls").ForEach(func(i, call gjson.Result) bool {
+		if args := call.Get("function.arguments"); args.Type == gjson.String && args.String() != "" {
+			fields = append(fields, splitField{
+				path:      fmt.Sprintf("delta.tool_calls.%d.function.arguments", i.Int()),
+				value:     args.String(),
+				toolIndex: call.Get("index").Int(),
+			})
+		}
+		return true
+	})
+	if len(fields) == 0 {
+		return [][]byte{payload}
+	}
+
+	// head is the first chunk with every split field emptied and the terminal fields removed.
+	head := bytes.Clone(payload)
+	for _, field := range fields {
+		head, _ = sjson.SetBytes(head, "choices.0."+field.path, "")
+	}
+	finish := choice.Get("finish_reason")
+	if finish.Exists() {
+		head, _ = sjson.SetRawBytes(head, "choices.0.finish_reason", []byte("null"))
+	}
+	usage := gjson.GetBytes(payload, "usage")
+	if usage.Exists() {
+		head, _ = sjson.DeleteBytes(head, "usage")
+	}
+	// base is a continuation chunk without any delta content.
+	base, _ := sjson.SetRawBytes(bytes.Clone(head), "choices.0.delta", []byte("{}"))
+	if choice.Get("text").Exists() {
+		base, _ = sjson.SetBytes(base, "choices.0.text", "")
+	}
+
+	var out [][]byte
+	for fi, field := range fields {
+		rest := field.value
+		for first := true; rest != ""; first = false {
+			template, path := continuationTemplate(base, field)
+			if fi == 0 && first {
+				template, path = head, "choices.0."+field.path
+			}
+			var piece string
+			piece, rest = handlers.CutJSONString(rest, handlers.EventPieceBudget(maxBytes, len(template)))
+			event, err := sjson.SetBytes(bytes.Clone(template), path, piece)
+			if err != nil {
+				return [][]byte{payload}
+			}
+			out = append(out, event)
+		}
+	}
+
+	last := out[len(out)-1]
+	if finish.Exists() {
+		last, _ = sjson.SetRawBytes(last, "choices.0.finish_reason", []byte(finish.Raw))
+	}
+	if usage.Exists() {
+		last, _ = sjson.SetRawBytes(last, "usage", []byte(usage.Raw))
+	}
+	out[len(out)-1] = last
+	return out
+}
+
+// continuationTemplate returns the chunk used for a later piece of field, with the field
+// set to "", and the path the piece is written to.
+func continuationTemplate(base []byte, field splitField) ([]byte, string) {
+	switch {
+	case field.toolIndex >= 0:
+		call, _ := sjson.Set(`{"function":{"arguments":""}}`, "index", field.toolIndex)
+		delta := `{"tool_calls":[` + call + `]}`
+		template, _ := sjson.SetRawBytes(bytes.Clone(base), "choices.0.delta", []byte(delta))
+		return template, "choices.0.delta.tool_calls.0.function.arguments"
+	case field.path == "text":
+		return base, "choices.0.text"
+	default:
+		template, _ := sjson.SetBytes(bytes.Clone(base), "choices.0."+field.path, "")
+		return template, "choices.0." + field.path
+	}
+}
+
+// writeOpenAISSEEvents writes chunk like writeOpenAISSEData, first splitting a payload
+// larger than maxBytes into several events.
+func writeOpenAISSEEvents(w http.ResponseWriter, chunk []byte, maxBytes int) bool {
+	if maxBytes <= 0 || len(chunk) <= maxBytes {
+		return writeOpenAISSEData(w, chunk)
+	}
+	wrote := false
+	for _, payload := range splitOpenAIChunk(bytes.TrimSpace(chunk), maxBytes) {
+		wrote = writeOpenAISSEData(w, payload) || wrote
+	}
+	return wrote
+}
@@ -0,0 +1,102 @@
+package openai
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func oversizedChatChunk(t *testing.T) (string, string, string) {
+	t.Helper()
+	content := strings.Repeat(`Line with "quotes", <tags> & émoji 🚀`+"\n", 40)
+	args := `{"path":"/tmp/файл.go","content":"` + strings.Repeat(`package main\n\nfunc main() { println(\"hi\") }\n`, 30) + `"}`
+	chunk := `{"id":"chatcmpl-9","object":"chat.completion.chunk","created":1,"model":"gpt-5","choices":[{"index":0,"delta":{"role":"assistant","content":"","tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"write_file","arguments":""}},{"index":1,"id":"call_b","type":"function","function":{"name":"noop","arguments":"{}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":5,"completion_tokens":900,"total_tokens":905}}`
+	chunk, _ = sjson.Set(chunk, "choices.0.delta.content", content)
+	chunk, _ = sjson.Set(chunk, "choices.0.delta.tool_calls.0.function.arguments", args)
+	return chunk, content, args
+}
+
+func TestSplitOpenAIChunk_ReassemblesToOriginal(t *testing.T) {
+	chunk, content, args := oversizedChatChunk(t)
+
+	for _, maxBytes := range []int{450, 512, 1024, 4096} {
+		events := splitOpenAIChunk([]byte(chunk), maxBytes)
+		if len(events) < 2 {
+			t.Fatalf("maxBytes=%d: chunk was not split", maxBytes)
+		}
+		var gotContent strings.Builder
+		gotArgs := map[int64]*strings.Builder{}
+		for i, event := range events {
+			if len(event) > maxBytes {
+				t.Fatalf("maxBytes=%d: event %d is %d bytes", maxBytes, i, len(event))
+			}
+			if !gjson.ValidBytes(event) {
+				t.Fatalf("maxBytes=%d: event %d is not JSON: %s", maxBytes, i, event)
+			}
+			parsed := gjson.ParseBytes(event)
+			if parsed.Get("id").String() != "chatcmpl-9" || parsed.Get("object").String() != "chat.completion.chunk" {
+				t.Fatalf("maxBytes=%d: event %d lost chunk metadata: %s", maxBytes, i, event)
+			}
+			if hasRole := parsed.Get("choices.0.delta.role").Exists(); hasRole != (i == 0) {
+				t.Fatalf("maxBytes=%d: role on event %d: %s", maxBytes, i, event)
+			}
+			last := i == len(events)-1
+			if finish := parsed.Get("choices.0.finish_reason"); (finish.String() == "tool_calls") != last {
+				t.Fatalf("maxBytes=%d: finish_reason on event %d: %s", maxBytes, i, event)
+			}
+			if parsed.Get("usage").Exists() != last {
+				t.Fatalf("maxBytes=%d: usage on event %d: %s", maxBytes, i, event)
+			}
+			gotContent.WriteString(parsed.Get("choices.0.delta.content").String())
+			parsed.Get("choices.0.delta.tool_calls").ForEach(func(_, call gjson.Result) bool {
+				idx := call.Get("index").Int()
+				if gotArgs[idx] == nil {
+					gotArgs[idx] = &strings.Builder{}
+				}
+				gotArgs[idx].WriteString(call.Get("function.arguments").String())
+				if i > 0 && (call.Get("id").Exists() || call.Get("function.name").Exists()) {
+					t.Fatalf("maxBytes=%d: continuation event %d repeats tool metadata: %s", maxBytes, i, event)
+				}
+				return true
+			})
+		}
+		if gotContent.String() != content {
+			t.Fatalf("maxBytes=%d: content mismatch", maxBytes)
+		}
+		if gotArgs[0].String() != args || gotArgs[1].String() != "{}" {
+			t.Fatalf("maxBytes=%d: arguments mismatch: %q / %q", maxBytes, gotArgs[0].String(), gotArgs[1].String())
+		}
+		first := gjson.ParseBytes(events[0])
+		if first.Get("choices.0.delta.tool_calls.0.id").String() != "call_a" || first.Get("choices.0.delta.tool_calls.1.function.name").String() != "noop" {
+			t.Fatalf("maxBytes=%d: first event lost tool call metadata: %s", maxBytes, events[0])
+		}
+	}
+}
+
+func TestWriteOpenAISSEEvents_BoundsDataLines(t *testing.T) {
+	chunk, _, _ := oversizedChatChunk(t)
+	recorder := httptest.NewRecorder()
+	writeOpenAISSEEvents(recorder, []byte(chunk), 1024)
+
+	events := strings.Split(strings.TrimSuffix(recorder.Body.String(), "\n\n"), "\n\n")
+	if len(events) < 2 {
+		t.Fatalf("events = %d, want several", len(events))
+	}
+	for _, event := range events {
+		if !strings.HasPrefix(event, "data: ") || strings.Contains(event, "\n") {
+			t.Fatalf("malformed event: %q", event)
+		}
+		if len(event) > 1024+len("data: ") {
+			t.Fatalf("data line of %d bytes exceeds limit", len(event))
+		}
+	}
+
+	small := httptest.NewRecorder()
+	writeOpenAISSEEvents(small, []byte(chunk), 0)
+	if small.Body.String() != "data: "+chunk+"\n\n" {
+		t.Fatalf("disabled splitting changed the chunk")
+	}
+}
@@ -74,7 +74,7 @@ func (h *OpenAIAPIHandler) HandlerType() string {
 func (h *OpenAIAPIHandler) Models() []map[string]any {
 	// Get dynamic models from the global registry
 	modelRegistry := registry.GetGlobalRegistry()
-	return modelRegistry.GetAvailableModels("openai")
+	return h.ApplyCatalogOverlay(modelRegistry.GetAvailableModels("openai"), "openai")
 }
 
 // OpenAIModels handles the /v1/models endpoint.
@@ -82,7 +82,7 @@ func (h *OpenAIAPIHandler) Models() []map[string]any {
 // and specifications in OpenAI-compatible format.
 func (h *OpenAIAPIHandler) OpenAIModels(c *gin.Context) {
 	// Get all available models
-	allModels := h.Models()
+	allModels := h.FilterModelsForTenant(c, h.Models())
 
 	c.JSON(http.StatusOK, gin.H{
 		"object": "list",
@@ -121,10 +121,39 @@ func (h *OpenAIAPIHandler) ChatCompletions(c *gin.Context) {
 		stream = gjson.GetBytes(rawJSON, "stream").Bool()
 	}
 
+	// Ask providers that ignore response_format for JSON output; only non-streaming
+	// replies can be repaired and validated against the contract.
+	rawJSON, contract := h.CoerceResponseFormat(gjson.GetBytes(rawJSON, "model").String(), rawJSON)
+
+	reasoning, rawJSON, errMsg := reasoningFormatFromRequest(c, rawJSON)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	post := newChatPostProcessor(reasoning, h.OutputRedactor())
+	fields, errMsg := responseFieldsFromRequest(c)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+
+	if n := handlers.RequestedChoiceCount(rawJSON); n > 1 {
+		modelName := gjson.GetBytes(rawJSON, "model").String()
+		mode, provider := h.MultiChoiceMode(modelName)
+		switch {
+		case mode == handlers.MultiChoiceReject, mode == handlers.MultiChoiceFanOut && stream:
+			h.WriteErrorResponse(c, handlers.MultiChoiceError(provider, n, stream))
+			return
+		case mode == handlers.MultiChoiceFanOut:
+			h.handleFanOutResponse(c, rawJSON, n, contract, post, fields)
+			return
+		}
+	}
+
 	if stream {
-		h.handleStreamingResponse(c, rawJSON)
+		h.handleStreamingResponse(c, rawJSON, post)
 	} else {
-		h.handleNonStreamingResponse(c, rawJSON)
+		h.handleNonStreamingResponse(c, rawJSON, contract, post, fields)
 	}
 
 }
@@ -427,19 +456,47 @@ func convertChatCompletionsStreamChunkToCompletions(chunkData []byte) []byte {
 // Parameters:
 //   - c: The Gin context containing the HTTP request and response
 //   - rawJSON: The raw JSON bytes of the OpenAI-compatible request
-func (h *OpenAIAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []byte) {
+//   - contract: The response_format contract to enforce on the reply, or nil
+//   - post: The reasoning_format and redaction rewrites for the reply, or nil
+func (h *OpenAIAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []byte, contract *handlers.ResponseFormatContract, post *chatPostProcessor, fields responseProjection) {
 	c.Header("Content-Type", "application/json")
 
 	modelName := gjson.GetBytes(rawJSON, "model").String()
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
 	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
+	if errMsg == nil {
+		resp, errMsg = contract.Enforce(post.response(resp))
+	}
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	handlers.SetEstimatedCostHeader(c, modelName, resp)
+	_, _ = c.Writer.Write(fields.apply(resp))
+	cliCancel()
+}
+
+// handleFanOutResponse serves a non-streaming request with n > 1 by merging n
+// single-choice upstream completions.
+func (h *OpenAIAPIHandler) handleFanOutResponse(c *gin.Context, rawJSON []byte, n int, contract *handlers.ResponseFormatContract, post *chatPostProcessor, fields responseProjection) {
+	c.Header("Content-Type", "application/json")
+
+	modelName := gjson.GetBytes(rawJSON, "model").String()
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteFanOutWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c), n)
+	if errMsg == nil {
+		resp, errMsg = contract.Enforce(post.response(resp))
+	}
 	if errMsg != nil {
 		h.WriteErrorResponse(c, errMsg)
 		cliCancel(errMsg.Error)
 		return
 	}
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
-	_, _ = c.Writer.Write(resp)
+	handlers.SetEstimatedCostHeader(c, modelName, resp)
+	_, _ = c.Writer.Write(fields.apply(resp))
 	cliCancel()
 }
 
@@ -450,7 +507,8 @@ func (h *OpenAIAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []
 // Parameters:
 //   - c: The Gin context containing the HTTP request and response
 //   - rawJSON: The raw JSON bytes of the OpenAI-compatible request
-func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byte) {
+//   - post: The reasoning_format and redaction rewrites for the chunks, or nil
+func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byte, post *chatPostProcessor) {
 	// Get the http.Flusher interface to manually flush the response.
 	flusher, ok := c.Writer.(http.Flusher)
 	if !ok {
@@ -478,11 +536,23 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 	}
 
 	// Peek at the first chunk to determine success or failure before setting headers
+	splitter := handlers.NewUTF8ChunkSplitter()
+	heartbeat, stopHeartbeat := h.FirstByteHeartbeat()
+	defer stopHeartbeat()
 	for {
 		select {
 		case <-c.Request.Context().Done():
 			cliCancel(c.Request.Context().Err())
 			return
+		case <-heartbeat:
+			// The upstream is slow to start: commit to streaming so the client sees bytes,
+			// and report any later error in-stream.
+			setSSEHeaders()
+			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+			_, _ = fmt.Fprintf(c.Writer, "%s\n\n", handlers.StreamHeartbeatComment)
+			flusher.Flush()
+			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, splitter, post)
+			return
 		case errMsg, ok := <-errChan:
 			if !ok {
 				// Err channel closed cleanly; wait for data channel.
@@ -508,7 +578,7 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 				return
 			}
 
-			if len(bytes.TrimSpace(chunk)) == 0 {
+			if chunk = splitter.Split(chunk); len(bytes.TrimSpace(chunk)) == 0 {
 				continue
 			}
 
@@ -516,11 +586,11 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 			setSSEHeaders()
 			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 
-			_ = writeOpenAISSEData(c.Writer, chunk)
+			_ = writeOpenAISSEEvents(c.Writer, post.chunk(chunk), h.MaxStreamEventBytes(c))
 			flusher.Flush()
 
 			// Continue streaming the rest
-			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, splitter, post)
 			return
 		}
 	}
@@ -550,7 +620,8 @@ func (h *OpenAIAPIHandler) handleCompletionsNonStreamingResponse(c *gin.Context,
 		return
 	}
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
-	completionsResp := convertChatCompletionsResponseToCompletions(resp)
+	handlers.SetEstimatedCostHeader(c, modelName, resp)
+	completionsResp := newCompletionsPostProcessor(h.OutputRedactor()).response(convertChatCompletionsResponseToCompletions(resp))
 	_, _ = c.Writer.Write(completionsResp)
 	cliCancel()
 }
@@ -592,12 +663,59 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 		}
 	}
 
+	splitter := handlers.NewUTF8ChunkSplitter()
+	post := newCompletionsPostProcessor(h.OutputRedactor())
+	// forward converts the remaining chat completions chunks and streams them to the client.
+	forward := func() {
+		done := make(chan struct{})
+		var doneOnce sync.Once
+		stop := func() { doneOnce.Do(func() { close(done) }) }
+
+		convertedChan := make(chan []byte)
+		go func() {
+			defer close(convertedChan)
+			for {
+				select {
+				case <-done:
+					return
+				case chunk, ok := <-dataChan:
+					if !ok {
+						return
+					}
+					converted := convertChatCompletionsStreamChunkToCompletions(chunk)
+					if converted == nil || len(bytes.TrimSpace(converted)) == 0 {
+						continue
+					}
+					select {
+					case <-done:
+						return
+					case convertedChan <- converted:
+					}
+				}
+			}
+		}()
+
+		h.handleStreamResult(c, flusher, func(err error) {
+			stop()
+			cliCancel(err)
+		}, convertedChan, errChan, splitter, post)
+	}
+
 	// Peek at the first chunk
+	heartbeat, stopHeartbeat := h.FirstByteHeartbeat()
+	defer stopHeartbeat()
 	for {
 		select {
 		case <-c.Request.Context().Done():
 			cliCancel(c.Request.Context().Err())
 			return
+		case <-heartbeat:
+			setSSEHeaders()
+			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+			_, _ = fmt.Fprintf(c.Writer, "%s\n\n", handlers.StreamHeartbeatComment)
+			flusher.Flush()
+			forward()
+			return
 		case errMsg, ok := <-errChan:
 			if !ok {
 				// Err channel closed cleanly; wait for data channel.
@@ -628,50 +746,27 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 			// Write the first chunk
 			converted := convertChatCompletionsStreamChunkToCompletions(chunk)
 			if converted != nil && len(bytes.TrimSpace(converted)) > 0 {
-				_ = writeOpenAISSEData(c.Writer, converted)
+				_ = writeOpenAISSEEvents(c.Writer, post.chunk(splitter.Split(converted)), h.MaxStreamEventBytes(c))
 				flusher.Flush()
 			}
 
-			done := make(chan struct{})
-			var doneOnce sync.Once
-			stop := func() { doneOnce.Do(func() { close(done) }) }
-
-			convertedChan := make(chan []byte)
-			go func() {
-				defer close(convertedChan)
-				for {
-					select {
-					case <-done:
-						return
-					case chunk, ok := <-dataChan:
-						if !ok {
-							return
-						}
-						converted := convertChatCompletionsStreamChunkToCompletions(chunk)
-						if converted == nil || len(bytes.TrimSpace(converted)) == 0 {
-							continue
-						}
-						select {
-						case <-done:
-							return
-						case convertedChan <- converted:
-						}
-					}
-				}
-			}()
-
-			h.handleStreamResult(c, flusher, func(err error) {
-				stop()
-				cliCancel(err)
-			}, convertedChan, errChan)
+			forward()
 			return
 		}
 	}
 }
-func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+
+func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, splitter *handlers.UTF8ChunkSplitter, post *chatPostProcessor) {
+	maxEventBytes := h.MaxStreamEventBytes(c)
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
+		Splitter: splitter,
 		WriteChunk: func(chunk []byte) {
-			_ = writeOpenAISSEData(c.Writer, chunk)
+			_ = writeOpenAISSEEvents(c.Writer, post.chunk(chunk), maxEventBytes)
+		},
+		FlushHeld: func() {
+			for _, chunk := range post.flush() {
+				_ = writeOpenAISSEEvents(c.Writer, chunk, maxEventBytes)
+			}
 		},
 		WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
 			if errMsg == nil {
@@ -0,0 +1,93 @@
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func newModelCatalogRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	registry.GetGlobalRegistry().RegisterClient("catalog-live-auth", "openai", []*registry.ModelInfo{{ID: "catalog-live-model", OwnedBy: "openai"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient("catalog-live-auth")
+	})
+
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		ModelCatalogOverlay: []sdkconfig.ModelCatalogEntry{
+			{ID: "catalog-live-model", OwnedBy: "overlay"},
+			{ID: "catalog-offline-model", OwnedBy: "example", ContextLength: 128000},
+		},
+	}, coreauth.NewManager(nil, nil, nil))
+	h := NewOpenAIAPIHandler(base)
+	router := gin.New()
+	router.GET("/v1/models", h.OpenAIModels)
+	router.POST("/v1/chat/completions", h.ChatCompletions)
+	return router
+}
+
+func TestOpenAIModels_IncludesCatalogOverlay(t *testing.T) {
+	router := newModelCatalogRouter(t)
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", resp.Code, resp.Body.String())
+	}
+
+	var live, offline []gjson.Result
+	for _, model := range gjson.GetBytes(resp.Body.Bytes(), "data").Array() {
+		switch model.Get("id").String() {
+		case "catalog-live-model":
+			live = append(live, model)
+		case "catalog-offline-model":
+			offline = append(offline, model)
+		}
+	}
+	if len(live) != 1 {
+		t.Fatalf("live model listed %d times, want 1: %s", len(live), resp.Body.String())
+	}
+	if live[0].Get("availability").Exists() || live[0].Get("owned_by").String() != "openai" {
+		t.Fatalf("live model should keep its registry entry: %s", live[0].Raw)
+	}
+	if len(offline) != 1 {
+		t.Fatalf("overlay model listed %d times, want 1: %s", len(offline), resp.Body.String())
+	}
+	if got := offline[0].Get("availability").String(); got != handlers.ModelAvailabilityPotentiallyUnavailable {
+		t.Fatalf("availability = %q, want %q", got, handlers.ModelAvailabilityPotentiallyUnavailable)
+	}
+	if got := offline[0].Get("owned_by").String(); got != "example" {
+		t.Fatalf("owned_by = %q, want example", got)
+	}
+}
+
+func TestChatCompletions_CatalogOverlayModelWithoutProvider(t *testing.T) {
+	router := newModelCatalogRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"catalog-offline-model","messages":[{"role":"user","content":"hi"}]}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503; body=%s", resp.Code, resp.Body.String())
+	}
+	if msg := gjson.GetBytes(resp.Body.Bytes(), "error.message").String(); !strings.Contains(msg, "configured but currently unavailable") {
+		t.Fatalf("error message = %q, want configured-but-unavailable", msg)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"catalog-unknown-model","messages":[{"role":"user","content":"hi"}]}`))
+	resp = httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	if resp.Code != http.StatusBadGateway {
+		t.Fatalf("unknown model status = %d, want 502; body=%s", resp.Code, resp.Body.String())
+	}
+}
@@ -0,0 +1,153 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+type singleChoiceExecutor struct {
+	calls    int
+	payloads []string
+}
+
+func (e *singleChoiceExecutor) Identifier() string { return "choice-provider" }
+
+func (e *singleChoiceExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.calls++
+	e.payloads = append(e.payloads, string(req.Payload))
+	body := fmt.Sprintf(`{"id":"chatcmpl-%d","object":"chat.completion","model":"test-model","choices":[{"index":0,"message":{"role":"assistant","content":"answer %d"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":%d,"total_tokens":%d,"completion_tokens_details":{"reasoning_tokens":1}}}`, e.calls, e.calls, e.calls, 10+e.calls)
+	return coreexecutor.Response{Payload: []byte(body)}, nil
+}
+
+func (e *singleChoiceExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *singleChoiceExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *singleChoiceExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *singleChoiceExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newMultiChoiceRouter(t *testing.T, mode string) (*gin.Engine, *singleChoiceExecutor) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	executor := &singleChoiceExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "choice-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "test-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		MultiChoice: map[string]string{executor.Identifier(): mode},
+	}, manager)
+	h := NewOpenAIAPIHandler(base)
+	router := gin.New()
+	router.POST("/v1/chat/completions", h.ChatCompletions)
+	return router, executor
+}
+
+func TestChatCompletions_FanOutMergesChoices(t *testing.T) {
+	router, executor := newMultiChoiceRouter(t, handlers.MultiChoiceFanOut)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"test-model","n":3,"messages":[{"role":"user","content":"hi"}]}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body=%s", resp.Code, resp.Body.String())
+	}
+	if executor.calls != 3 {
+		t.Fatalf("upstream calls = %d, want 3", executor.calls)
+	}
+	for _, payload := range executor.payloads {
+		if gjson.Get(payload, "n").Exists() {
+			t.Fatalf("fan-out call should not forward n: %s", payload)
+		}
+	}
+
+	body := resp.Body.Bytes()
+	choices := gjson.GetBytes(body, "choices").Array()
+	if len(choices) != 3 {
+		t.Fatalf("choices = %d, want 3: %s", len(choices), body)
+	}
+	for i, choice := range choices {
+		if got := choice.Get("index").Int(); got != int64(i) {
+			t.Fatalf("choice %d index = %d", i, got)
+		}
+		if got := choice.Get("message.content").String(); got != fmt.Sprintf("answer %d", i+1) {
+			t.Fatalf("choice %d content = %q", i, got)
+		}
+	}
+	if got := gjson.GetBytes(body, "usage.prompt_tokens").Int(); got != 30 {
+		t.Fatalf("prompt_tokens = %d, want 30", got)
+	}
+	if got := gjson.GetBytes(body, "usage.completion_tokens").Int(); got != 6 {
+		t.Fatalf("completion_tokens = %d, want 6", got)
+	}
+	if got := gjson.GetBytes(body, "usage.total_tokens").Int(); got != 36 {
+		t.Fatalf("total_tokens = %d, want 36", got)
+	}
+	if got := gjson.GetBytes(body, "usage.completion_tokens_details.reasoning_tokens").Int(); got != 3 {
+		t.Fatalf("reasoning_tokens = %d, want 3", got)
+	}
+}
+
+func TestChatCompletions_RejectModeRefusesMultipleChoices(t *testing.T) {
+	router, executor := newMultiChoiceRouter(t, handlers.MultiChoiceReject)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"test-model","n":2,"messages":[{"role":"user","content":"hi"}]}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+	if executor.calls != 0 {
+		t.Fatalf("upstream calls = %d, want 0", executor.calls)
+	}
+	if !strings.Contains(resp.Body.String(), "n=2 is not supported") {
+		t.Fatalf("unexpected error body: %s", resp.Body.String())
+	}
+}
+
+func TestChatCompletions_FanOutRejectsStreaming(t *testing.T) {
+	router, executor := newMultiChoiceRouter(t, handlers.MultiChoiceFanOut)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(`{"model":"test-model","n":2,"stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", resp.Code, http.StatusBadRequest)
+	}
+	if executor.calls != 0 {
+		t.Fatalf("upstream calls = %d, want 0", executor.calls)
+	}
+}
@@ -0,0 +1,186 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const weatherSchemaRequest = `{
+	"model": "format-model",
+	"messages": [
+		{"role": "system", "content": "You are a weather bot."},
+		{"role": "user", "content": "Weather in Paris?"}
+	],
+	"response_format": {
+		"type": "json_schema",
+		"json_schema": {
+			"name": "weather",
+			"schema": {
+				"type": "object",
+				"properties": {
+					"city": {"type": "string"},
+					"celsius": {"type": "number"},
+					"conditions": {"type": "array", "items": {"type": "string", "enum": ["sunny", "cloudy", "rain"]}}
+				},
+				"required": ["city", "celsius"],
+				"additionalProperties": false
+			}
+		}
+	}
+}`
+
+type jsonReplyExecutor struct {
+	content  string
+	payloads []string
+}
+
+func (e *jsonReplyExecutor) Identifier() string { return "format-provider" }
+
+func (e *jsonReplyExecutor) Execute(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+	e.payloads = append(e.payloads, string(req.Payload))
+	body, _ := sjson.Set(`{"id":"chatcmpl-1","object":"chat.completion","model":"format-model","choices":[{"index":0,"message":{"role":"assistant"},"finish_reason":"stop"}]}`, "choices.0.message.content", e.content)
+	return coreexecutor.Response{Payload: []byte(body)}, nil
+}
+
+func (e *jsonReplyExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *jsonReplyExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *jsonReplyExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *jsonReplyExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newResponseFormatRouter(t *testing.T, mode, content string) (*gin.Engine, *jsonReplyExecutor) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	executor := &jsonReplyExecutor{content: content}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "format-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "format-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		ResponseFormatCoercion: map[string]string{executor.Identifier(): mode},
+	}, manager)
+	router := gin.New()
+	router.POST("/v1/chat/completions", NewOpenAIAPIHandler(base).ChatCompletions)
+	return router, executor
+}
+
+func postChat(router *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestChatCompletions_ResponseFormatInjectsInstruction(t *testing.T) {
+	router, executor := newResponseFormatRouter(t, handlers.ResponseFormatInstruct, `{"city":"Paris","celsius":18}`)
+
+	resp := postChat(router, weatherSchemaRequest)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if len(executor.payloads) != 1 {
+		t.Fatalf("upstream calls = %d, want 1", len(executor.payloads))
+	}
+	messages := gjson.Get(executor.payloads[0], "messages").Array()
+	if len(messages) != 3 {
+		t.Fatalf("messages = %s", gjson.Get(executor.payloads[0], "messages").Raw)
+	}
+	if messages[0].Get("content").String() != "You are a weather bot." {
+		t.Fatalf("client system prompt moved: %s", messages[0].Raw)
+	}
+	instruction := messages[1]
+	if instruction.Get("role").String() != "system" {
+		t.Fatalf("instruction message = %s", instruction.Raw)
+	}
+	text := instruction.Get("content").String()
+	for _, want := range []string{"valid JSON", `"weather"`, `"required":["city","celsius"]`} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("instruction %q missing %q", text, want)
+		}
+	}
+	if messages[2].Get("role").String() != "user" {
+		t.Fatalf("user message = %s", messages[2].Raw)
+	}
+}
+
+func TestChatCompletions_ResponseFormatNativeLeavesRequestAlone(t *testing.T) {
+	router, executor := newResponseFormatRouter(t, handlers.ResponseFormatNative, `{"city":"Paris","celsius":18}`)
+
+	if resp := postChat(router, weatherSchemaRequest); resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if got := len(gjson.Get(executor.payloads[0], "messages").Array()); got != 2 {
+		t.Fatalf("messages = %d, want 2", got)
+	}
+}
+
+func TestChatCompletions_ResponseFormatRepairsFencedJSON(t *testing.T) {
+	router, _ := newResponseFormatRouter(t, handlers.ResponseFormatValidate, "Here you go:\n```json\n{\"city\":\"Paris\",\"celsius\":18.5,\"conditions\":[\"sunny\"]}\n```")
+
+	resp := postChat(router, weatherSchemaRequest)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	content := gjson.Get(resp.Body.String(), "choices.0.message.content").String()
+	if content != `{"city":"Paris","celsius":18.5,"conditions":["sunny"]}` {
+		t.Fatalf("content = %q", content)
+	}
+}
+
+func TestChatCompletions_ResponseFormatSchemaViolation(t *testing.T) {
+	router, _ := newResponseFormatRouter(t, handlers.ResponseFormatValidate, `{"city":"Paris","celsius":"warm","conditions":["sunny"]}`)
+
+	resp := postChat(router, weatherSchemaRequest)
+	if resp.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502; body = %s", resp.Code, resp.Body.String())
+	}
+	message := gjson.Get(resp.Body.String(), "error.message").String()
+	for _, want := range []string{"response_format", `schema "weather"`, "$.celsius: expected number, got string"} {
+		if !strings.Contains(message, want) {
+			t.Fatalf("error message %q missing %q", message, want)
+		}
+	}
+
+	router, _ = newResponseFormatRouter(t, handlers.ResponseFormatValidate, `{"city":"Paris","celsius":18,"conditions":["snow"]}`)
+	resp = postChat(router, weatherSchemaRequest)
+	if message := gjson.Get(resp.Body.String(), "error.message").String(); resp.Code != http.StatusBadGateway || !strings.Contains(message, "$.conditions[0]: value") {
+		t.Fatalf("enum violation: status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+
+	router, _ = newResponseFormatRouter(t, handlers.ResponseFormatValidate, "Sorry, I cannot check the weather.")
+	resp = postChat(router, `{"model":"format-model","messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_object"}}`)
+	if message := gjson.Get(resp.Body.String(), "error.message").String(); resp.Code != http.StatusBadGateway || !strings.Contains(message, "not valid JSON") {
+		t.Fatalf("non-JSON reply: status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+}
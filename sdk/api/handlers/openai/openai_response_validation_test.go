@@ -0,0 +1,111 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+const captivePortalPage = "<!DOCTYPE html><html><head><title>Sign in to Wi-Fi</title></head><body>Please accept the terms to continue.</body></html>"
+
+type fixedBodyExecutor struct {
+	body string
+}
+
+func (e *fixedBodyExecutor) Identifier() string { return "fixed-body-provider" }
+
+func (e *fixedBodyExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{Payload: []byte(e.body)}, nil
+}
+
+func (e *fixedBodyExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (e *fixedBodyExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *fixedBodyExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *fixedBodyExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newValidationRouter(t *testing.T, validate bool, body string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	executor := &fixedBodyExecutor{body: body}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "fixed-body-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "validation-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{ValidateUpstreamResponses: validate}, manager)
+	router := gin.New()
+	router.POST("/v1/chat/completions", NewOpenAIAPIHandler(base).ChatCompletions)
+	router.POST("/v1/responses", NewOpenAIResponsesAPIHandler(base).Responses)
+	return router
+}
+
+func postValidation(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	body := `{"model":"validation-model","messages":[{"role":"user","content":"hi"}]}`
+	if path == "/v1/responses" {
+		body = `{"model":"validation-model","input":"hi"}`
+	}
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestChatCompletions_HTMLBodyFailsValidation(t *testing.T) {
+	resp := postValidation(newValidationRouter(t, true, captivePortalPage), "/v1/chat/completions")
+
+	if resp.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want %d; body=%s", resp.Code, http.StatusBadGateway, resp.Body.String())
+	}
+	got := resp.Body.String()
+	if !strings.Contains(got, "upstream returned an invalid openai response: body is not a JSON object") || !strings.Contains(got, "Sign in to Wi-Fi") {
+		t.Fatalf("unexpected error body: %s", got)
+	}
+}
+
+func TestChatCompletions_HTMLBodyPassesThroughWhenValidationDisabled(t *testing.T) {
+	resp := postValidation(newValidationRouter(t, false, captivePortalPage), "/v1/chat/completions")
+
+	if resp.Code != http.StatusOK || resp.Body.String() != captivePortalPage {
+		t.Fatalf("status = %d, body=%s", resp.Code, resp.Body.String())
+	}
+}
+
+func TestResponses_ValidationChecksExpectedFields(t *testing.T) {
+	resp := postValidation(newValidationRouter(t, true, `{"id":"resp_1","object":"response","status":"completed","output":[]}`), "/v1/responses")
+	if resp.Code != http.StatusOK {
+		t.Fatalf("well-formed response rejected: status = %d, body=%s", resp.Code, resp.Body.String())
+	}
+
+	resp = postValidation(newValidationRouter(t, true, `{"choices":[]}`), "/v1/responses")
+	if resp.Code != http.StatusBadGateway || !strings.Contains(resp.Body.String(), "missing output/status") {
+		t.Fatalf("status = %d, body=%s", resp.Code, resp.Body.String())
+	}
+}
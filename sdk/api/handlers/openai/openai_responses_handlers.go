@@ -17,6 +17,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -32,6 +33,8 @@ type responsesSSEWriteState struct {
 	currentEventHasData bool   // true if current event block has non-empty data since last boundary
 	lastWasDelimiter    bool   // true if last write was a delimiter
 	pendingEventLine    []byte // buffered event: line, written only when non-empty data arrives
+	finalDelimiter      string // streaming.final-delimiter mode applied by writeDone; empty means if-needed
+	redaction           *responsesRedactor
 }
 
 func (st *responsesSSEWriteState) writeLine(w http.ResponseWriter, line []byte) {
@@ -53,6 +56,29 @@ func (st *responsesSSEWriteState) writeLine(w http.ResponseWriter, line []byte)
 		return
 	}
 
+	// Comment lines (": keepalive") carry no event; forward them as they arrive so idle
+	// connections stay open through load balancers. An open data block is terminated
+	// first so its event reaches the client now instead of after the next event; a
+	// buffered event: line stays pending for its data.
+	if line[0] == ':' {
+		if st.currentEventHasData && !st.lastWasDelimiter {
+			_, _ = w.Write([]byte("\n"))
+			st.currentEventHasData = false
+		}
+		_, _ = w.Write(line)
+		_, _ = w.Write([]byte("\n"))
+		st.lastWasDelimiter = true
+		return
+	}
+
+	// retry: sets the client's reconnection delay and is valid in a block without data,
+	// so it passes through untouched without opening a data block.
+	if bytes.HasPrefix(line, []byte("retry:")) {
+		_, _ = w.Write(line)
+		_, _ = w.Write([]byte("\n"))
+		return
+	}
+
 	// Buffer event: lines until we see non-empty data.
 	if bytes.HasPrefix(line, []byte("event:")) {
 		st.pendingEventLine = append([]byte(nil), line...) // copy
@@ -65,6 +91,13 @@ func (st *responsesSSEWriteState) writeLine(w http.ResponseWriter, line []byte)
 		if len(bytes.TrimSpace(line[5:])) == 0 {
 			return
 		}
+		if st.redaction != nil {
+			before, payload := st.redaction.event(bytes.TrimSpace(line[5:]))
+			for _, event := range before {
+				st.writeEvent(w, event)
+			}
+			line = append([]byte("data: "), payload...)
+		}
 		// Non-empty data: flush pending event line first.
 		if st.pendingEventLine != nil {
 			// Inject delimiter before event if we have prior data and not already at boundary.
@@ -86,6 +119,25 @@ func (st *responsesSSEWriteState) writeLine(w http.ResponseWriter, line []byte)
 	st.lastWasDelimiter = false
 }
 
+// writeEvent writes an event the proxy adds to the stream as a complete block. A buffered
+// event: line stays pending for the data that follows.
+func (st *responsesSSEWriteState) writeEvent(w http.ResponseWriter, event responsesEvent) {
+	if st.currentEventHasData && !st.lastWasDelimiter {
+		_, _ = w.Write([]byte("\n"))
+	}
+	_, _ = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.name, event.payload)
+	st.wroteNonEmptyData = true
+	st.currentEventHasData = false
+	st.lastWasDelimiter = true
+}
+
+// flushRedaction writes the text output redaction still holds back.
+func (st *responsesSSEWriteState) flushRedaction(w http.ResponseWriter) {
+	for _, event := range st.redaction.flush() {
+		st.writeEvent(w, event)
+	}
+}
+
 func (st *responsesSSEWriteState) writeChunk(w http.ResponseWriter, chunk []byte) {
 	// Handle chunks that contain multiple SSE lines (some translators emit "event: ...\ndata: ...").
 	// Split on newline and run the line-level logic per line so state tracking works correctly.
@@ -118,11 +170,22 @@ func (st *responsesSSEWriteState) writeChunk(w http.ResponseWriter, chunk []byte
 }
 
 func (st *responsesSSEWriteState) writeDone(w http.ResponseWriter) {
-	// Only emit a delimiter if the current event block has non-empty data and we're not already
-	// at an event boundary. This avoids dispatching empty SSE events downstream.
-	if !st.currentEventHasData || st.lastWasDelimiter {
+	// Never write anything for a stream that carried no data.
+	if !st.wroteNonEmptyData {
 		return
 	}
+	switch st.finalDelimiter {
+	case config.FinalDelimiterNever:
+		return
+	case config.FinalDelimiterAlways:
+		// Some clients wait for an explicit empty line even after the terminal event.
+	default:
+		// Only emit a delimiter if the current event block has non-empty data and we're not already
+		// at an event boundary. This avoids dispatching empty SSE events downstream.
+		if !st.currentEventHasData || st.lastWasDelimiter {
+			return
+		}
+	}
 	_, _ = w.Write([]byte("\n"))
 	st.lastWasDelimiter = true
 	st.currentEventHasData = false
@@ -151,7 +214,7 @@ func (h *OpenAIResponsesAPIHandler) HandlerType() string {
 func (h *OpenAIResponsesAPIHandler) Models() []map[string]any {
 	// Get dynamic models from the global registry
 	modelRegistry := registry.GetGlobalRegistry()
-	return modelRegistry.GetAvailableModels("openai")
+	return h.ApplyCatalogOverlay(modelRegistry.GetAvailableModels("openai"), "openai")
 }
 
 // OpenAIResponsesModels handles the /v1/models endpoint.
@@ -160,7 +223,7 @@ func (h *OpenAIResponsesAPIHandler) Models() []map[string]any {
 func (h *OpenAIResponsesAPIHandler) OpenAIResponsesModels(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"object": "list",
-		"data":   h.Models(),
+		"data":   h.FilterModelsForTenant(c, h.Models()),
 	})
 }
 
@@ -233,7 +296,8 @@ func (h *OpenAIResponsesAPIHandler) Compact(c *gin.Context) {
 		return
 	}
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
-	_, _ = c.Writer.Write(resp)
+	handlers.SetEstimatedCostHeader(c, modelName, resp)
+	_, _ = c.Writer.Write(newResponsesRedactor(h.OutputRedactor()).response(resp))
 	cliCancel()
 }
 
@@ -259,7 +323,8 @@ func (h *OpenAIResponsesAPIHandler) handleNonStreamingResponse(c *gin.Context, r
 		return
 	}
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
-	_, _ = c.Writer.Write(resp)
+	handlers.SetEstimatedCostHeader(c, modelName, resp)
+	_, _ = c.Writer.Write(newResponsesRedactor(h.OutputRedactor()).response(resp))
 	cliCancel()
 }
 
@@ -299,12 +364,27 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 	}
 
 	// Peek at the first chunk
-	writeState := &responsesSSEWriteState{}
+	writeState := &responsesSSEWriteState{
+		finalDelimiter: h.Cfg.Streaming.FinalDelimiterMode(),
+		redaction:      newResponsesRedactor(h.OutputRedactor()),
+	}
+	splitter := handlers.NewUTF8ChunkSplitter()
+	heartbeat, stopHeartbeat := h.FirstByteHeartbeat()
+	defer stopHeartbeat()
 	for {
 		select {
 		case <-c.Request.Context().Done():
 			cliCancel(c.Request.Context().Err())
 			return
+		case <-heartbeat:
+			// The upstream is slow to start: commit to streaming so the client sees bytes,
+			// and report any later error in-stream.
+			setSSEHeaders()
+			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+			writeState.writeChunk(c.Writer, []byte(handlers.StreamHeartbeatComment))
+			flusher.Flush()
+			h.forwardResponsesStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, writeState, splitter)
+			return
 		case errMsg, ok := <-errChan:
 			if !ok {
 				// Err channel closed cleanly; wait for data channel.
@@ -335,18 +415,19 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 
 			// Write first chunk logic (matching forwardResponsesStream)
-			writeState.writeChunk(c.Writer, chunk)
+			writeState.writeChunk(c.Writer, splitter.Split(chunk))
 			flusher.Flush()
 
 			// Continue
-			h.forwardResponsesStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, writeState)
+			h.forwardResponsesStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, writeState, splitter)
 			return
 		}
 	}
 }
 
-func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, writeState *responsesSSEWriteState) {
+func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, writeState *responsesSSEWriteState, splitter *handlers.UTF8ChunkSplitter) {
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
+		Splitter: splitter,
 		WriteChunk: func(chunk []byte) {
 			writeState.writeChunk(c.Writer, chunk)
 		},
@@ -368,8 +449,16 @@ func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flush
 			writeState.writeChunk(c.Writer, []byte("event: error\ndata: "+string(body)))
 			writeState.writeChunk(c.Writer, []byte(""))
 		},
+		FlushHeld: func() {
+			writeState.flushRedaction(c.Writer)
+		},
 		WriteDone: func() {
 			writeState.writeDone(c.Writer)
 		},
+		WriteKeepAlive: func() {
+			// Through writeState so a heartbeat closes an open data block instead of
+			// splitting it.
+			writeState.writeChunk(c.Writer, []byte(handlers.StreamKeepAliveComment(h.Cfg)))
+		},
 	})
 }
@@ -2,7 +2,10 @@ package openai
 
 import (
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 )
 
 func TestResponsesSSEWriteState_NoLeadingDelimiterBeforeFirstData(t *testing.T) {
@@ -107,6 +110,48 @@ func TestResponsesSSEWriteState_EmptyDataPayloadFiltered(t *testing.T) {
 	})
 }
 
+func TestResponsesSSEWriteState_CommentBetweenDataBlocks(t *testing.T) {
+	rec := httptest.NewRecorder()
+	st := &responsesSSEWriteState{}
+
+	st.writeChunk(rec, []byte("event: response.created\ndata: {\"type\":\"response.created\"}"))
+	// The keepalive arrives while the first block is still open: the block is closed so
+	// its event is delivered, and the comment goes out without waiting for data.
+	st.writeChunk(rec, []byte(": keepalive"))
+	st.writeChunk(rec, []byte(""))
+	st.writeChunk(rec, []byte("event: response.output_text.delta"))
+	// A comment inside a block leaves the buffered event: line pending for its data.
+	st.writeChunk(rec, []byte(": keepalive\n"))
+	st.writeChunk(rec, []byte(`data: {"delta":"hi"}`))
+	st.writeDone(rec)
+
+	want := "event: response.created\ndata: {\"type\":\"response.created\"}\n\n" +
+		": keepalive\n" +
+		": keepalive\n" +
+		"event: response.output_text.delta\ndata: {\"delta\":\"hi\"}\n\n"
+	if got := rec.Body.String(); got != want {
+		t.Fatalf("unexpected output:\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestResponsesSSEWriteState_RetryAtStreamStart(t *testing.T) {
+	rec := httptest.NewRecorder()
+	st := &responsesSSEWriteState{}
+
+	st.writeChunk(rec, []byte("retry: 3000\n\n"))
+	st.writeChunk(rec, []byte("event: response.created"))
+	st.writeChunk(rec, []byte(""))
+	st.writeChunk(rec, []byte("event: response.created\ndata: {\"type\":\"response.created\"}"))
+	st.writeDone(rec)
+
+	// The retry field passes through as-is; the event-only block after it is still
+	// suppressed.
+	want := "retry: 3000\nevent: response.created\ndata: {\"type\":\"response.created\"}\n\n"
+	if got := rec.Body.String(); got != want {
+		t.Fatalf("unexpected output:\n got: %q\nwant: %q", got, want)
+	}
+}
+
 func TestResponsesSSEWriteState_WriteDoneGated(t *testing.T) {
 	t.Run("does not write delimiter without non-empty data", func(t *testing.T) {
 		rec := httptest.NewRecorder()
@@ -139,6 +184,50 @@ func TestResponsesSSEWriteState_WriteDoneGated(t *testing.T) {
 	})
 }
 
+func TestResponsesSSEWriteState_FinalDelimiterModes(t *testing.T) {
+	terminal := "event: response.completed\ndata: {\"type\":\"response.completed\"}\n\n"
+	cases := []struct {
+		mode       string
+		atBoundary string
+		openEvent  string
+	}{
+		{mode: "", atBoundary: terminal, openEvent: terminal},
+		{mode: config.FinalDelimiterIfNeeded, atBoundary: terminal, openEvent: terminal},
+		{mode: config.FinalDelimiterAlways, atBoundary: terminal + "\n", openEvent: terminal},
+		{mode: config.FinalDelimiterNever, atBoundary: terminal, openEvent: strings.TrimSuffix(terminal, "\n")},
+	}
+	for _, tc := range cases {
+		t.Run("mode="+tc.mode, func(t *testing.T) {
+			// Terminal event that already emitted its boundary.
+			rec := httptest.NewRecorder()
+			st := &responsesSSEWriteState{finalDelimiter: tc.mode}
+			st.writeChunk(rec, []byte("event: response.completed\ndata: {\"type\":\"response.completed\"}\n\n"))
+			st.writeDone(rec)
+			if got := rec.Body.String(); got != tc.atBoundary {
+				t.Fatalf("after boundary: got %q, want %q", got, tc.atBoundary)
+			}
+
+			// Terminal event left open by the upstream.
+			rec = httptest.NewRecorder()
+			st = &responsesSSEWriteState{finalDelimiter: tc.mode}
+			st.writeChunk(rec, []byte("event: response.completed\ndata: {\"type\":\"response.completed\"}"))
+			st.writeDone(rec)
+			if got := rec.Body.String(); got != tc.openEvent {
+				t.Fatalf("open event: got %q, want %q", got, tc.openEvent)
+			}
+
+			// Nothing is written before the first data, whatever the mode.
+			rec = httptest.NewRecorder()
+			st = &responsesSSEWriteState{finalDelimiter: tc.mode}
+			st.writeChunk(rec, []byte(""))
+			st.writeDone(rec)
+			if got := rec.Body.String(); got != "" {
+				t.Fatalf("empty stream: got %q", got)
+			}
+		})
+	}
+}
+
 func TestResponsesSSEWriteState_EventOnlyBlockAfterValidEvent(t *testing.T) {
 	// Regression test: after a valid event+data block, an event line followed by
 	// empty data and delimiter should NOT emit an event-only block (which would
@@ -390,6 +390,33 @@ func (h *OpenAIResponsesAPIHandler) forwardResponsesWebsocket(
 ) ([]byte, error) {
 	completed := false
 	completedOutput := []byte("[]")
+	// Held bytes never form a JSON message on their own, so nothing is flushed at close.
+	splitter := handlers.NewUTF8ChunkSplitter()
+	redaction := newResponsesRedactor(h.OutputRedactor())
+	// writeEvent sends one response event to the client.
+	writeEvent := func(payload []byte) error {
+		markAPIResponseTimestamp(c)
+		appendWebsocketEvent(wsBodyLog, "response", payload)
+		if errWrite := conn.WriteMessage(websocket.TextMessage, payload); errWrite != nil {
+			log.Warnf(
+				"responses websocket: downstream_out write failed id=%s event=%s error=%v",
+				sessionID,
+				websocketPayloadEventType(payload),
+				errWrite,
+			)
+			return errWrite
+		}
+		return nil
+	}
+	// flushRedaction sends the text output redaction still holds back.
+	flushRedaction := func() error {
+		for _, event := range redaction.flush() {
+			if errWrite := writeEvent(event.payload); errWrite != nil {
+				return errWrite
+			}
+		}
+		return nil
+	}
 
 	for {
 		select {
@@ -402,6 +429,10 @@ func (h *OpenAIResponsesAPIHandler) forwardResponsesWebsocket(
 				continue
 			}
 			if errMsg != nil {
+				if errWrite := flushRedaction(); errWrite != nil {
+					cancel(errWrite)
+					return completedOutput, errWrite
+				}
 				h.LoggingAPIResponseError(context.WithValue(context.Background(), "gin", c), errMsg)
 				markAPIResponseTimestamp(c)
 				errorPayload, errWrite := writeResponsesWebsocketError(conn, errMsg)
@@ -432,6 +463,10 @@ func (h *OpenAIResponsesAPIHandler) forwardResponsesWebsocket(
 			return completedOutput, nil
 		case chunk, ok := <-data:
 			if !ok {
+				if errWrite := flushRedaction(); errWrite != nil {
+					cancel(errWrite)
+					return completedOutput, errWrite
+				}
 				if !completed {
 					errMsg := &interfaces.ErrorMessage{
 						StatusCode: http.StatusRequestTimeout,
@@ -465,8 +500,16 @@ func (h *OpenAIResponsesAPIHandler) forwardResponsesWebsocket(
 				return completedOutput, nil
 			}
 
-			payloads := websocketJSONPayloadsFromChunk(chunk)
+			payloads := websocketJSONPayloadsFromChunk(splitter.Split(chunk))
 			for i := range payloads {
+				before, redacted := redaction.event(payloads[i])
+				for _, event := range before {
+					if errWrite := writeEvent(event.payload); errWrite != nil {
+						cancel(errWrite)
+						return completedOutput, errWrite
+					}
+				}
+				payloads[i] = redacted
 				eventType := gjson.GetBytes(payloads[i], "type").String()
 				if eventType == wsEventTypeCompleted {
 					// log.Infof("replace %s with %s", wsEventTypeCompleted, wsEventTypeDone)
@@ -475,22 +518,7 @@ func (h *OpenAIResponsesAPIHandler) forwardResponsesWebsocket(
 					completed = true
 					completedOutput = responseCompletedOutputFromPayload(payloads[i])
 				}
-				markAPIResponseTimestamp(c)
-				appendWebsocketEvent(wsBodyLog, "response", payloads[i])
-				// log.Infof(
-				// 	"responses websocket: downstream_out id=%s type=%d event=%s payload=%s",
-				// 	sessionID,
-				// 	websocket.TextMessage,
-				// 	websocketPayloadEventType(payloads[i]),
-				// 	websocketPayloadPreview(payloads[i]),
-				// )
-				if errWrite := conn.WriteMessage(websocket.TextMessage, payloads[i]); errWrite != nil {
-					log.Warnf(
-						"responses websocket: downstream_out write failed id=%s event=%s error=%v",
-						sessionID,
-						websocketPayloadEventType(payloads[i]),
-						errWrite,
-					)
+				if errWrite := writeEvent(payloads[i]); errWrite != nil {
 					cancel(errWrite)
 					return completedOutput, errWrite
 				}
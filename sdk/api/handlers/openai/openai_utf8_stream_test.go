@@ -0,0 +1,81 @@
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+const utf8StreamSample = `héllo—wörld·世界🚀👩‍💻done`
+
+// runUTF8Stream feeds chunks through forward and returns the response body.
+func runUTF8Stream(t *testing.T, chunks []string, forward func(c *gin.Context, data <-chan []byte, errs <-chan *interfaces.ErrorMessage)) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+	data := make(chan []byte, len(chunks))
+	for _, chunk := range chunks {
+		data <- []byte(chunk)
+	}
+	close(data)
+	forward(c, data, make(chan *interfaces.ErrorMessage))
+	return rec.Body.String()
+}
+
+// assertUTF8DataPayloads checks that every data payload is valid UTF-8 and that the
+// payloads reassemble want.
+func assertUTF8DataPayloads(t *testing.T, cut int, body, want string) {
+	t.Helper()
+	var got strings.Builder
+	for _, line := range strings.Split(body, "\n") {
+		if !strings.HasPrefix(line, "data: ") || line == "data: [DONE]" {
+			continue
+		}
+		payload := strings.TrimPrefix(line, "data: ")
+		if !utf8.ValidString(payload) {
+			t.Fatalf("cut=%d: invalid UTF-8 data payload %q", cut, payload)
+		}
+		got.WriteString(payload)
+	}
+	if got.String() != want {
+		t.Fatalf("cut=%d: payloads reassemble to %q, want %q\nbody: %q", cut, got.String(), want, body)
+	}
+}
+
+func TestChatCompletionsStream_KeepsCodePointsAcrossChunks(t *testing.T) {
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+	for cut := 1; cut < len(utf8StreamSample); cut++ {
+		body := runUTF8Stream(t, []string{utf8StreamSample[:cut], utf8StreamSample[cut:]}, func(c *gin.Context, data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+			h.handleStreamResult(c, c.Writer, func(error) {}, data, errs, nil, nil)
+		})
+		assertUTF8DataPayloads(t, cut, body, utf8StreamSample)
+		if !strings.HasSuffix(body, "data: [DONE]\n\n") {
+			t.Fatalf("cut=%d: missing [DONE] terminator: %q", cut, body)
+		}
+	}
+}
+
+func TestResponsesStream_KeepsCodePointsAcrossChunks(t *testing.T) {
+	h := NewOpenAIResponsesAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+	for cut := 1; cut < len(utf8StreamSample); cut++ {
+		chunks := []string{
+			"event: response.output_text.delta\ndata: " + utf8StreamSample[:cut], "",
+			"event: response.output_text.delta\ndata: " + utf8StreamSample[cut:], "",
+		}
+		body := runUTF8Stream(t, chunks, func(c *gin.Context, data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+			h.forwardResponsesStream(c, c.Writer, func(error) {}, data, errs, &responsesSSEWriteState{}, nil)
+		})
+		assertUTF8DataPayloads(t, cut, body, utf8StreamSample)
+		assertNoEmptySSEEvents(t, body)
+	}
+}
@@ -0,0 +1,196 @@
+package openai
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// chatPostProcessor applies the per-request rewrites of chat completion output: the
+// reasoning_format split first, then output redaction. A nil processor leaves payloads
+// unchanged.
+type chatPostProcessor struct {
+	reasoning *reasoningFormatter
+	redaction *choiceRedactor
+}
+
+// newChatPostProcessor returns nil when no rewrite applies.
+func newChatPostProcessor(reasoning *reasoningFormatter, redactor *handlers.OutputRedactor) *chatPostProcessor {
+	if reasoning == nil && redactor == nil {
+		return nil
+	}
+	p := &chatPostProcessor{reasoning: reasoning}
+	if redactor != nil {
+		p.redaction = newChatRedactor(redactor)
+	}
+	return p
+}
+
+// newCompletionsPostProcessor returns the rewrites of legacy completions output, which
+// only has output redaction, or nil when redaction is off.
+func newCompletionsPostProcessor(redactor *handlers.OutputRedactor) *chatPostProcessor {
+	if redactor == nil {
+		return nil
+	}
+	return &chatPostProcessor{redaction: newCompletionsRedactor(redactor)}
+}
+
+// response rewrites a non-streaming chat completion.
+func (p *chatPostProcessor) response(resp []byte) []byte {
+	if p == nil {
+		return resp
+	}
+	return p.redaction.response(p.reasoning.response(resp))
+}
+
+// flush returns the chunks releasing text still held back when the stream ends.
+func (p *chatPostProcessor) flush() [][]byte {
+	if p == nil {
+		return nil
+	}
+	return p.redaction.flush()
+}
+
+// chunk rewrites one streamed chat completion chunk.
+func (p *chatPostProcessor) chunk(chunk []byte) []byte {
+	if p == nil {
+		return chunk
+	}
+	return p.redaction.chunk(p.reasoning.chunk(chunk))
+}
+
+// chatRedactorFields are the text fields of a message or delta that are redacted. Only
+// string values are rewritten, so the JSON structure is left intact.
+var chatRedactorFields = []string{"content", "reasoning_content"}
+
+// newChatRedactor redacts the message and delta text of chat completion choices.
+func newChatRedactor(redactor *handlers.OutputRedactor) *choiceRedactor {
+	return &choiceRedactor{
+		redactor:      redactor,
+		streams:       redactor.NewStreams(),
+		responsePath:  "message.",
+		chunkPath:     "delta.",
+		fields:        chatRedactorFields,
+		chunkTemplate: `{"object":"chat.completion.chunk","choices":[]}`,
+	}
+}
+
+// newCompletionsRedactor redacts the text of legacy completions choices.
+func newCompletionsRedactor(redactor *handlers.OutputRedactor) *choiceRedactor {
+	return &choiceRedactor{
+		redactor:      redactor,
+		streams:       redactor.NewStreams(),
+		fields:        []string{"text"},
+		chunkTemplate: `{"object":"text_completion","choices":[]}`,
+	}
+}
+
+// choiceRedactor redacts the text fields of OpenAI choices. Streamed text is redacted per
+// choice and field with a lookahead buffer, released on the choice's finish_reason or, when
+// the stream ends without one, by flush.
+type choiceRedactor struct {
+	redactor      *handlers.OutputRedactor
+	streams       *handlers.RedactionStreams
+	responsePath  string // prefix of the text fields in a response choice
+	chunkPath     string // prefix of the text fields in a streamed choice
+	fields        []string
+	chunkTemplate string
+	// id, created and model of the last chunk, copied into chunks written by flush.
+	lastID, lastModel string
+	lastCreated       int64
+}
+
+func (r *choiceRedactor) response(resp []byte) []byte {
+	if r == nil {
+		return resp
+	}
+	paths := make([]string, 0, len(r.fields))
+	for _, field := range r.fields {
+		paths = append(paths, "choices.#."+r.responsePath+field)
+	}
+	return r.redactor.RedactPaths(resp, paths...)
+}
+
+func (r *choiceRedactor) chunk(chunk []byte) []byte {
+	if r == nil {
+		return chunk
+	}
+	root := gjson.ParseBytes(chunk)
+	choices := root.Get("choices")
+	if !choices.IsArray() {
+		return chunk
+	}
+	if id := root.Get("id").String(); id != "" {
+		r.lastID = id
+	}
+	if model := root.Get("model").String(); model != "" {
+		r.lastModel = model
+	}
+	if created := root.Get("created").Int(); created != 0 {
+		r.lastCreated = created
+	}
+	for i, choice := range choices.Array() {
+		// The final chunk of a choice may carry finish_reason without any delta.
+		final := choice.Get("finish_reason").Type == gjson.String
+		if r.chunkPath != "" && !choice.Get(strings.TrimSuffix(r.chunkPath, ".")).IsObject() && !final {
+			continue
+		}
+		index := choice.Get("index").Int()
+		for _, field := range r.fields {
+			value := choice.Get(r.chunkPath + field)
+			if value.Type != gjson.String && !final {
+				continue
+			}
+			key := choiceStreamKey(index, field)
+			text := r.streams.Write(key, value.String())
+			if final {
+				text += r.streams.Flush(key)
+			}
+			if value.Type == gjson.String || text != "" {
+				chunk, _ = sjson.SetBytes(chunk, fmt.Sprintf("choices.%d.%s%s", i, r.chunkPath, field), text)
+			}
+		}
+	}
+	return chunk
+}
+
+// flush returns a chunk per field whose held-back text was never released because the
+// stream ended without a finish_reason for its choice.
+func (r *choiceRedactor) flush() [][]byte {
+	if r == nil {
+		return nil
+	}
+	var chunks [][]byte
+	for _, held := range r.streams.FlushAll() {
+		index, field := parseChoiceStreamKey(held.Key)
+		chunk := []byte(r.chunkTemplate)
+		if r.lastID != "" {
+			chunk, _ = sjson.SetBytes(chunk, "id", r.lastID)
+		}
+		if r.lastCreated != 0 {
+			chunk, _ = sjson.SetBytes(chunk, "created", r.lastCreated)
+		}
+		if r.lastModel != "" {
+			chunk, _ = sjson.SetBytes(chunk, "model", r.lastModel)
+		}
+		chunk, _ = sjson.SetBytes(chunk, "choices.0.index", index)
+		chunk, _ = sjson.SetBytes(chunk, "choices.0."+r.chunkPath+field, held.Text)
+		chunk, _ = sjson.SetRawBytes(chunk, "choices.0.finish_reason", []byte("null"))
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+func choiceStreamKey(index int64, field string) string {
+	return strconv.FormatInt(index, 10) + "/" + field
+}
+
+func parseChoiceStreamKey(key string) (int64, string) {
+	rawIndex, field, _ := strings.Cut(key, "/")
+	index, _ := strconv.ParseInt(rawIndex, 10, 64)
+	return index, field
+}
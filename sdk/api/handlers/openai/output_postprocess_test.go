@@ -0,0 +1,152 @@
+package openai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func testOutputRedactor(lookahead int) *handlers.OutputRedactor {
+	return handlers.NewOutputRedactor(sdkconfig.OutputRedactionConfig{
+		Patterns:             []string{`[a-z.]+@[a-z]+\.[a-z]{2,}`, `sk-[A-Za-z0-9]{8,}`},
+		StreamLookaheadBytes: lookahead,
+	})
+}
+
+func TestChatPostProcessor_RedactsNonStreaming(t *testing.T) {
+	post := newChatPostProcessor(nil, testOutputRedactor(0))
+	resp := []byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"Mail jane.doe@example.com with key sk-abcdef123456.","reasoning_content":"found sk-zyxwvu987654 in the log","tool_calls":[{"function":{"arguments":"{\"to\":\"jane.doe@example.com\"}"}}]},"finish_reason":"stop"}]}`)
+
+	out := post.response(resp)
+	if !gjson.ValidBytes(out) {
+		t.Fatalf("redacted response is not valid JSON: %s", out)
+	}
+	if got := gjson.GetBytes(out, "choices.0.message.content").String(); got != "Mail [REDACTED] with key [REDACTED]." {
+		t.Fatalf("content = %q", got)
+	}
+	if got := gjson.GetBytes(out, "choices.0.message.reasoning_content").String(); got != "found [REDACTED] in the log" {
+		t.Fatalf("reasoning_content = %q", got)
+	}
+	// Only text fields are rewritten; tool call arguments stay as the model produced them.
+	if got := gjson.GetBytes(out, "choices.0.message.tool_calls.0.function.arguments").String(); got != `{"to":"jane.doe@example.com"}` {
+		t.Fatalf("tool call arguments = %q", got)
+	}
+
+	var off *chatPostProcessor
+	if got := off.response(resp); string(got) != string(resp) {
+		t.Fatalf("nil post-processor changed the response: %s", got)
+	}
+}
+
+func TestChatPostProcessor_RedactsAcrossDeltaBoundary(t *testing.T) {
+	post := newChatPostProcessor(nil, testOutputRedactor(32))
+	chunks := []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Contact jane.d"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"oe@exam"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"ple.com for access; this sentence is long enough to release text."},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+	}
+	var content strings.Builder
+	for _, chunk := range chunks {
+		out := post.chunk([]byte(chunk))
+		if !gjson.ValidBytes(out) {
+			t.Fatalf("redacted chunk is not valid JSON: %s", out)
+		}
+		text := gjson.GetBytes(out, "choices.0.delta.content").String()
+		if strings.Contains(text, "jane") || strings.Contains(text, "example") {
+			t.Fatalf("chunk leaked part of the address: %s", out)
+		}
+		content.WriteString(text)
+	}
+	want := "Contact [REDACTED] for access; this sentence is long enough to release text."
+	if content.String() != want {
+		t.Fatalf("streamed content = %q, want %q", content.String(), want)
+	}
+}
+
+func TestChatPostProcessor_ReleasesHeldTextWithoutDelta(t *testing.T) {
+	post := newChatPostProcessor(nil, testOutputRedactor(64))
+	first := post.chunk([]byte(`{"choices":[{"index":0,"delta":{"content":"Mail jane.doe@example.com"},"finish_reason":null}]}`))
+	if got := gjson.GetBytes(first, "choices.0.delta.content").String(); got != "" {
+		t.Fatalf("text inside the lookahead was emitted early: %q", got)
+	}
+	// Some providers end a choice with finish_reason and no delta object at all.
+	final := post.chunk([]byte(`{"choices":[{"index":0,"finish_reason":"stop"}]}`))
+	if got := gjson.GetBytes(final, "choices.0.delta.content").String(); got != "Mail [REDACTED]" {
+		t.Fatalf("final chunk = %s", final)
+	}
+	if chunks := post.flush(); len(chunks) != 0 {
+		t.Fatalf("nothing should be left to flush, got %q", chunks)
+	}
+}
+
+func TestChatPostProcessor_FlushReleasesTextWhenStreamStops(t *testing.T) {
+	post := newChatPostProcessor(nil, testOutputRedactor(64))
+	post.chunk([]byte(`{"id":"c1","created":7,"model":"m","choices":[{"index":1,"delta":{"content":"key sk-abcdef123456"},"finish_reason":null}]}`))
+
+	chunks := post.flush()
+	if len(chunks) != 1 {
+		t.Fatalf("flush = %q, want one chunk", chunks)
+	}
+	root := gjson.ParseBytes(chunks[0])
+	if root.Get("id").String() != "c1" || root.Get("model").String() != "m" || root.Get("object").String() != "chat.completion.chunk" {
+		t.Fatalf("flushed chunk lost the stream identity: %s", chunks[0])
+	}
+	if root.Get("choices.0.index").Int() != 1 || root.Get("choices.0.delta.content").String() != "key [REDACTED]" {
+		t.Fatalf("flushed chunk = %s", chunks[0])
+	}
+}
+
+func TestCompletionsPostProcessor_Redacts(t *testing.T) {
+	post := newCompletionsPostProcessor(testOutputRedactor(64))
+	resp := post.response([]byte(`{"object":"text_completion","choices":[{"index":0,"text":"key sk-abcdef123456","finish_reason":"stop"}]}`))
+	if got := gjson.GetBytes(resp, "choices.0.text").String(); got != "key [REDACTED]" {
+		t.Fatalf("response text = %q", got)
+	}
+
+	var text strings.Builder
+	for _, chunk := range []string{
+		`{"object":"text_completion","choices":[{"index":0,"text":"write to jane.doe@exa","finish_reason":null}]}`,
+		`{"object":"text_completion","choices":[{"index":0,"text":"mple.com","finish_reason":"stop"}]}`,
+	} {
+		text.WriteString(gjson.GetBytes(post.chunk([]byte(chunk)), "choices.0.text").String())
+	}
+	if text.String() != "write to [REDACTED]" {
+		t.Fatalf("streamed text = %q", text.String())
+	}
+}
+
+func TestResponsesRedactor_ReleasesHeldTextBeforeDone(t *testing.T) {
+	r := newResponsesRedactor(testOutputRedactor(64))
+	delta := `{"type":"response.output_text.delta","sequence_number":3,"item_id":"msg_1","output_index":0,"content_index":0,"delta":"Mail jane.doe@example.com"}`
+	before, out := r.event([]byte(delta))
+	if len(before) != 0 || gjson.GetBytes(out, "delta").String() != "" {
+		t.Fatalf("delta inside the lookahead was emitted: %s", out)
+	}
+
+	done := `{"type":"response.output_text.done","item_id":"msg_1","output_index":0,"content_index":0,"text":"Mail jane.doe@example.com"}`
+	before, out = r.event([]byte(done))
+	if len(before) != 1 || before[0].name != "response.output_text.delta" || gjson.GetBytes(before[0].payload, "delta").String() != "Mail [REDACTED]" {
+		t.Fatalf("held text not released before done: %+v", before)
+	}
+	if gjson.GetBytes(before[0].payload, "item_id").String() != "msg_1" || gjson.GetBytes(before[0].payload, "sequence_number").Exists() {
+		t.Fatalf("released delta = %s", before[0].payload)
+	}
+	if got := gjson.GetBytes(out, "text").String(); got != "Mail [REDACTED]" {
+		t.Fatalf("done text = %q", got)
+	}
+
+	completed := `{"type":"response.completed","response":{"output":[{"type":"message","content":[{"type":"output_text","text":"Mail jane.doe@example.com"}]}]}}`
+	if _, out = r.event([]byte(completed)); gjson.GetBytes(out, "response.output.0.content.0.text").String() != "Mail [REDACTED]" {
+		t.Fatalf("completed = %s", out)
+	}
+
+	r.event([]byte(`{"type":"response.reasoning_summary_text.delta","item_id":"rs_1","output_index":1,"summary_index":0,"delta":"key sk-abcdef123456"}`))
+	flushed := r.flush()
+	if len(flushed) != 1 || gjson.GetBytes(flushed[0].payload, "delta").String() != "key [REDACTED]" {
+		t.Fatalf("flush = %+v", flushed)
+	}
+}
@@ -0,0 +1,206 @@
+package openai
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ReasoningFormatHeader selects how reasoning is delivered in chat completions, like the
+// "reasoning_format" request field. The field wins when both are set.
+const ReasoningFormatHeader = "X-Reasoning-Format"
+
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// reasoningFormatFromRequest reads the requested reasoning format and strips the
+// proxy-only field from the body. It returns a nil formatter for the legacy behavior,
+// which passes each provider's output through unchanged.
+func reasoningFormatFromRequest(c *gin.Context, rawJSON []byte) (*reasoningFormatter, []byte, *interfaces.ErrorMessage) {
+	mode := ""
+	if c != nil && c.Request != nil {
+		mode = c.GetHeader(ReasoningFormatHeader)
+	}
+	if field := gjson.GetBytes(rawJSON, "reasoning_format"); field.Exists() {
+		mode = field.String()
+		if updated, err := sjson.DeleteBytes(rawJSON, "reasoning_format"); err == nil {
+			rawJSON = updated
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "legacy", "default":
+		return nil, rawJSON, nil
+	case "separate", "parsed":
+		return &reasoningFormatter{choices: make(map[int64]*thinkSplitter)}, rawJSON, nil
+	default:
+		return nil, rawJSON, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("unsupported reasoning_format %q (expected \"separate\" or \"legacy\")", mode),
+		}
+	}
+}
+
+// reasoningFormatter moves reasoning out of chat completion content into the
+// reasoning_content field: inline <think>...</think> sections and provider-specific
+// fields ("reasoning_text", "reasoning"). It keeps per-choice state across stream chunks.
+// A nil formatter leaves payloads unchanged.
+type reasoningFormatter struct {
+	choices map[int64]*thinkSplitter
+}
+
+// response rewrites a non-streaming chat completion.
+func (f *reasoningFormatter) response(resp []byte) []byte {
+	if f == nil {
+		return resp
+	}
+	return f.rewrite(resp, "message")
+}
+
+// chunk rewrites one streamed chat completion chunk.
+func (f *reasoningFormatter) chunk(chunk []byte) []byte {
+	if f == nil {
+		return chunk
+	}
+	return f.rewrite(chunk, "delta")
+}
+
+func (f *reasoningFormatter) rewrite(payload []byte, field string) []byte {
+	choices := gjson.GetBytes(payload, "choices")
+	if !choices.IsArray() {
+		return payload
+	}
+	for i, choice := range choices.Array() {
+		msg := choice.Get(field)
+		if !msg.IsObject() {
+			continue
+		}
+		base := fmt.Sprintf("choices.%d.%s.", i, field)
+		var reasoning strings.Builder
+		if existing := msg.Get("reasoning_content"); existing.Type == gjson.String {
+			reasoning.WriteString(existing.String())
+		}
+		for _, alias := range []string{"reasoning_text", "reasoning"} {
+			if value := msg.Get(alias); value.Type == gjson.String {
+				reasoning.WriteString(value.String())
+				payload, _ = sjson.DeleteBytes(payload, base+alias)
+			}
+		}
+		// A held-back partial tag is released with the message or the stream's final chunk.
+		final := field == "message" || choice.Get("finish_reason").Type == gjson.String
+		splitter := f.splitter(choice.Get("index").Int(), field == "message")
+		content := msg.Get("content")
+		var text, thought string
+		if content.Type == gjson.String {
+			text, thought = splitter.split(content.String())
+		}
+		if final {
+			text, thought = text+splitter.flushText(), thought+splitter.flushThought()
+		}
+		if content.Type == gjson.String || text != "" {
+			payload, _ = sjson.SetBytes(payload, base+"content", text)
+		}
+		reasoning.WriteString(thought)
+		if reasoning.Len() > 0 {
+			payload, _ = sjson.SetBytes(payload, base+"reasoning_content", reasoning.String())
+		}
+	}
+	return payload
+}
+
+func (f *reasoningFormatter) splitter(index int64, fresh bool) *thinkSplitter {
+	if fresh {
+		return &thinkSplitter{}
+	}
+	s, ok := f.choices[index]
+	if !ok {
+		s = &thinkSplitter{}
+		f.choices[index] = s
+	}
+	return s
+}
+
+// thinkSplitter separates <think>...</think> sections from streamed text. A tag split
+// across chunks is held back until the next chunk decides it.
+type thinkSplitter struct {
+	inThink bool
+	// closed is set right after a closing tag so the newlines separating the reasoning
+	// from the answer are dropped.
+	closed  bool
+	pending string
+}
+
+func (s *thinkSplitter) split(text string) (content, reasoning string) {
+	text = s.pending + text
+	s.pending = ""
+	var out, thought strings.Builder
+	for text != "" {
+		tag := thinkOpenTag
+		if s.inThink {
+			tag = thinkCloseTag
+		}
+		idx := strings.Index(text, tag)
+		if idx < 0 {
+			keep := partialTagSuffix(text, tag)
+			s.emit(&out, &thought, text[:len(text)-keep])
+			s.pending = text[len(text)-keep:]
+			break
+		}
+		s.emit(&out, &thought, text[:idx])
+		text = text[idx+len(tag):]
+		s.inThink = !s.inThink
+		s.closed = !s.inThink
+	}
+	return out.String(), thought.String()
+}
+
+func (s *thinkSplitter) emit(out, thought *strings.Builder, text string) {
+	if s.inThink {
+		thought.WriteString(text)
+		return
+	}
+	if s.closed {
+		text = strings.TrimLeft(text, "\r\n")
+		if text == "" {
+			return
+		}
+		s.closed = false
+	}
+	out.WriteString(text)
+}
+
+// flushText and flushThought release a held-back partial tag at the end of a message.
+func (s *thinkSplitter) flushText() string {
+	if s.inThink {
+		return ""
+	}
+	pending := s.pending
+	s.pending = ""
+	return pending
+}
+
+func (s *thinkSplitter) flushThought() string {
+	if !s.inThink {
+		return ""
+	}
+	pending := s.pending
+	s.pending = ""
+	return pending
+}
+
+// partialTagSuffix returns the length of the longest suffix of text that is a proper
+// prefix of tag.
+func partialTagSuffix(text, tag string) int {
+	for n := len(tag) - 1; n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
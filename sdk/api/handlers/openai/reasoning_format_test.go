@@ -0,0 +1,109 @@
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+func reasoningFormatContext(header string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if header != "" {
+		c.Request.Header.Set(ReasoningFormatHeader, header)
+	}
+	return c
+}
+
+func TestReasoningFormatFromRequest(t *testing.T) {
+	cases := []struct {
+		name, header, body string
+		wantFormatter      bool
+		wantErr            bool
+	}{
+		{name: "default", body: `{"model":"gpt-5"}`},
+		{name: "header", header: "separate", body: `{"model":"gpt-5"}`, wantFormatter: true},
+		{name: "field", body: `{"model":"gpt-5","reasoning_format":"parsed"}`, wantFormatter: true},
+		{name: "field overrides header", header: "separate", body: `{"model":"gpt-5","reasoning_format":"legacy"}`},
+		{name: "unknown", body: `{"model":"gpt-5","reasoning_format":"hidden"}`, wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			formatter, body, errMsg := reasoningFormatFromRequest(reasoningFormatContext(tc.header), []byte(tc.body))
+			if (errMsg != nil) != tc.wantErr {
+				t.Fatalf("err = %v, want error %v", errMsg, tc.wantErr)
+			}
+			if errMsg != nil {
+				if errMsg.StatusCode != http.StatusBadRequest {
+					t.Fatalf("status = %d, want 400", errMsg.StatusCode)
+				}
+				return
+			}
+			if (formatter != nil) != tc.wantFormatter {
+				t.Fatalf("formatter = %v, want %v", formatter != nil, tc.wantFormatter)
+			}
+			if gjson.GetBytes(body, "reasoning_format").Exists() {
+				t.Fatalf("reasoning_format was forwarded upstream: %s", body)
+			}
+		})
+	}
+}
+
+func TestReasoningFormatter_NonStreaming(t *testing.T) {
+	resp := []byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"<think>\nweigh options\n</think>\n\nThe answer is 4.","reasoning_text":"step 0. "},"finish_reason":"stop"}]}`)
+
+	// Without opt-in the provider output is left as it was.
+	var legacy *reasoningFormatter
+	if got := legacy.response(resp); string(got) != string(resp) {
+		t.Fatalf("legacy response changed: %s", got)
+	}
+
+	formatter, _, _ := reasoningFormatFromRequest(reasoningFormatContext("separate"), []byte(`{}`))
+	got := formatter.response(resp)
+	if content := gjson.GetBytes(got, "choices.0.message.content").String(); content != "The answer is 4." {
+		t.Fatalf("content = %q", content)
+	}
+	if reasoning := gjson.GetBytes(got, "choices.0.message.reasoning_content").String(); reasoning != "step 0. \nweigh options\n" {
+		t.Fatalf("reasoning_content = %q", reasoning)
+	}
+	if gjson.GetBytes(got, "choices.0.message.reasoning_text").Exists() {
+		t.Fatalf("reasoning_text was kept: %s", got)
+	}
+}
+
+func TestReasoningFormatter_Streaming(t *testing.T) {
+	chunks := []string{
+		`{"choices":[{"index":0,"delta":{"content":"<thi"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"nk>plan the re"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"ply</th"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"ink>\nHello"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"content":" world <"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+	}
+	formatter, _, _ := reasoningFormatFromRequest(reasoningFormatContext("separate"), []byte(`{}`))
+	var content, reasoning strings.Builder
+	var merged strings.Builder
+	for _, chunk := range chunks {
+		merged.WriteString(gjson.Get(chunk, "choices.0.delta.content").String())
+		out := formatter.chunk([]byte(chunk))
+		content.WriteString(gjson.GetBytes(out, "choices.0.delta.content").String())
+		reasoning.WriteString(gjson.GetBytes(out, "choices.0.delta.reasoning_content").String())
+	}
+	if content.String() != "Hello world <" || reasoning.String() != "plan the reply" {
+		t.Fatalf("separated content=%q reasoning=%q", content.String(), reasoning.String())
+	}
+
+	// Without opt-in the reasoning stays merged into the content.
+	var legacy *reasoningFormatter
+	var legacyContent strings.Builder
+	for _, chunk := range chunks {
+		legacyContent.WriteString(gjson.GetBytes(legacy.chunk([]byte(chunk)), "choices.0.delta.content").String())
+	}
+	if legacyContent.String() != merged.String() {
+		t.Fatalf("legacy content = %q, want %q", legacyContent.String(), merged.String())
+	}
+}
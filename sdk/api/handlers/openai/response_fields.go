@@ -0,0 +1,92 @@
+package openai
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ResponseFieldsHeader projects a non-streaming chat completion down to fewer fields for
+// clients that only need the assistant text. The "response_fields" query parameter does
+// the same and wins when both are set. Streaming responses are not projected.
+const ResponseFieldsHeader = "X-Response-Fields"
+
+// responseProjection is a shape selected with ResponseFieldsHeader.
+type responseProjection int
+
+const (
+	// projectFull returns the complete chat completion.
+	projectFull responseProjection = iota
+	// projectText returns {"text": ...} with the content of the first choice.
+	projectText
+	// projectTextUsage returns projectText plus the usage object.
+	projectTextUsage
+)
+
+// responseFieldsFromRequest reads the requested projection; anything but "text",
+// "text+usage" or "full" is a 400.
+func responseFieldsFromRequest(c *gin.Context) (responseProjection, *interfaces.ErrorMessage) {
+	mode := ""
+	if c != nil && c.Request != nil {
+		mode = c.GetHeader(ResponseFieldsHeader)
+		if query, ok := c.GetQuery("response_fields"); ok {
+			mode = query
+		}
+	}
+	// A '+' in an unescaped query string decodes as a space.
+	switch strings.ToLower(strings.Join(strings.Fields(mode), "+")) {
+	case "", "full":
+		return projectFull, nil
+	case "text":
+		return projectText, nil
+	case "text+usage":
+		return projectTextUsage, nil
+	default:
+		return projectFull, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("unsupported %s %q (expected \"text\", \"text+usage\" or \"full\")", ResponseFieldsHeader, mode),
+		}
+	}
+}
+
+// apply projects a non-streaming chat completion. Responses with several choices also
+// list the content of each in "texts". Tool calls and other message fields are dropped by
+// the text projections.
+func (p responseProjection) apply(resp []byte) []byte {
+	if p == projectFull {
+		return resp
+	}
+	choices := gjson.GetBytes(resp, "choices").Array()
+	out := []byte(`{"text":""}`)
+	if len(choices) > 0 {
+		out, _ = sjson.SetBytes(out, "text", chatMessageText(choices[0].Get("message.content")))
+	}
+	if len(choices) > 1 {
+		texts := make([]string, 0, len(choices))
+		for _, choice := range choices {
+			texts = append(texts, chatMessageText(choice.Get("message.content")))
+		}
+		out, _ = sjson.SetBytes(out, "texts", texts)
+	}
+	if usage := gjson.GetBytes(resp, "usage"); p == projectTextUsage && usage.Exists() {
+		out, _ = sjson.SetRawBytes(out, "usage", []byte(usage.Raw))
+	}
+	return out
+}
+
+// chatMessageText returns message content that is either a string or an array of parts.
+func chatMessageText(content gjson.Result) string {
+	if !content.IsArray() {
+		return content.String()
+	}
+	var text strings.Builder
+	for _, part := range content.Array() {
+		text.WriteString(part.Get("text").String())
+	}
+	return text.String()
+}
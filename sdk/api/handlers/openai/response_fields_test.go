@@ -0,0 +1,67 @@
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+func TestChatCompletions_ResponseFieldsProjection(t *testing.T) {
+	router, _ := newResponseFormatRouter(t, handlers.ResponseFormatNative, "Paris is sunny.")
+	post := func(target, header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"model":"format-model","messages":[{"role":"user","content":"Weather?"}]}`))
+		if header != "" {
+			req.Header.Set(ResponseFieldsHeader, header)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	for _, tc := range []struct{ name, target, header string }{
+		{name: "header", target: "/v1/chat/completions", header: "text"},
+		{name: "query wins", target: "/v1/chat/completions?response_fields=text", header: "full"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := post(tc.target, tc.header)
+			if resp.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+			}
+			if got := resp.Body.String(); got != `{"text":"Paris is sunny."}` {
+				t.Fatalf("text projection = %s", got)
+			}
+		})
+	}
+
+	t.Run("full", func(t *testing.T) {
+		resp := post("/v1/chat/completions", "full")
+		body := resp.Body.String()
+		if gjson.Get(body, "id").String() != "chatcmpl-1" || gjson.Get(body, "choices.0.message.content").String() != "Paris is sunny." {
+			t.Fatalf("full response = %s", body)
+		}
+		if gjson.Get(body, "text").Exists() {
+			t.Fatalf("full response was projected: %s", body)
+		}
+	})
+
+	t.Run("unknown projection", func(t *testing.T) {
+		if resp := post("/v1/chat/completions", "choices"); resp.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want 400; body = %s", resp.Code, resp.Body.String())
+		}
+	})
+}
+
+func TestResponseProjection_TextUsage(t *testing.T) {
+	resp := []byte(`{"id":"chatcmpl-2","choices":[{"index":0,"message":{"role":"assistant","content":[{"type":"text","text":"4"},{"type":"text","text":"2"}]}},{"index":1,"message":{"role":"assistant","content":"forty-two"}}],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`)
+	got := string(projectTextUsage.apply(resp))
+	if want := `{"text":"42","texts":["42","forty-two"],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`; got != want {
+		t.Fatalf("text+usage projection = %s, want %s", got, want)
+	}
+	if got := string(projectText.apply(resp)); gjson.Get(got, "usage").Exists() {
+		t.Fatalf("text projection kept usage: %s", got)
+	}
+}
@@ -0,0 +1,139 @@
+package openai
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// responsesOutputTextPaths are the model text fields of a Responses output array.
+var responsesOutputTextPaths = []string{"content.#.text", "content.#.refusal", "summary.#.text"}
+
+// responsesTextDeltaFields maps the streamed text events of the Responses API to the field
+// holding their text; the matching ".done" event carries the full text.
+var responsesTextDeltaFields = map[string]string{
+	"response.output_text":            "text",
+	"response.refusal":                "refusal",
+	"response.reasoning_text":         "text",
+	"response.reasoning_summary_text": "text",
+}
+
+// responsesEvent is an event written before the one being redacted.
+type responsesEvent struct {
+	name    string
+	payload []byte
+}
+
+// responsesRedactor redacts the model text of one OpenAI Responses response. Streamed
+// deltas are redacted per output part with a lookahead buffer; the held-back text is
+// released as an extra delta event right before the part's ".done" event, or by flush
+// when the stream ends first.
+type responsesRedactor struct {
+	redactor  *handlers.OutputRedactor
+	streams   *handlers.RedactionStreams
+	templates map[string][]byte // delta event of each open part, with an empty delta
+}
+
+func newResponsesRedactor(redactor *handlers.OutputRedactor) *responsesRedactor {
+	if redactor == nil {
+		return nil
+	}
+	return &responsesRedactor{redactor: redactor, streams: redactor.NewStreams(), templates: make(map[string][]byte)}
+}
+
+// response redacts a non-streaming response or a compacted one.
+func (r *responsesRedactor) response(resp []byte) []byte {
+	if r == nil {
+		return resp
+	}
+	return r.redactOutput(resp, "output")
+}
+
+func (r *responsesRedactor) redactOutput(payload []byte, outputPath string) []byte {
+	paths := make([]string, 0, len(responsesOutputTextPaths))
+	for _, path := range responsesOutputTextPaths {
+		paths = append(paths, outputPath+".#."+path)
+	}
+	return r.redactor.RedactPaths(payload, paths...)
+}
+
+// event redacts one streamed event payload. before holds the delta events releasing text
+// held back for a part that this event closes.
+func (r *responsesRedactor) event(payload []byte) (before []responsesEvent, out []byte) {
+	if r == nil || !gjson.ValidBytes(payload) {
+		return nil, payload
+	}
+	eventType := gjson.GetBytes(payload, "type").String()
+	if stem, ok := strings.CutSuffix(eventType, ".delta"); ok {
+		if _, text := responsesTextDeltaFields[stem]; text {
+			key := responsesPartKey(stem, payload)
+			if _, seen := r.templates[key]; !seen {
+				template, _ := sjson.SetBytes(payload, "delta", "")
+				template, _ = sjson.DeleteBytes(template, "sequence_number")
+				r.templates[key] = template
+			}
+			out, _ = sjson.SetBytes(payload, "delta", r.streams.Write(key, gjson.GetBytes(payload, "delta").String()))
+			return nil, out
+		}
+	}
+	if stem, ok := strings.CutSuffix(eventType, ".done"); ok {
+		if field, text := responsesTextDeltaFields[stem]; text {
+			if held := r.release(responsesPartKey(stem, payload)); held != nil {
+				before = append(before, *held)
+			}
+			return before, r.redactor.RedactPaths(payload, field)
+		}
+	}
+	switch eventType {
+	case "response.content_part.added", "response.content_part.done", "response.reasoning_summary_part.added", "response.reasoning_summary_part.done":
+		return nil, r.redactor.RedactPaths(payload, "part.text", "part.refusal")
+	case "response.output_item.added", "response.output_item.done":
+		return nil, r.redactor.RedactPaths(payload, "item.content.#.text", "item.content.#.refusal", "item.summary.#.text")
+	}
+	if gjson.GetBytes(payload, "response.output").IsArray() {
+		return nil, r.redactOutput(payload, "response.output")
+	}
+	return nil, payload
+}
+
+// flush returns the delta events releasing text still held back when the stream ends.
+func (r *responsesRedactor) flush() []responsesEvent {
+	if r == nil {
+		return nil
+	}
+	var events []responsesEvent
+	for _, held := range r.streams.FlushAll() {
+		if event := r.heldEvent(held.Key, held.Text); event != nil {
+			events = append(events, *event)
+		}
+	}
+	return events
+}
+
+func (r *responsesRedactor) release(key string) *responsesEvent {
+	return r.heldEvent(key, r.streams.Flush(key))
+}
+
+func (r *responsesRedactor) heldEvent(key, text string) *responsesEvent {
+	template := r.templates[key]
+	delete(r.templates, key)
+	if text == "" || template == nil {
+		return nil
+	}
+	payload, _ := sjson.SetBytes(template, "delta", text)
+	return &responsesEvent{name: gjson.GetBytes(template, "type").String(), payload: payload}
+}
+
+// responsesPartKey identifies the output part a text event belongs to.
+func responsesPartKey(stem string, payload []byte) string {
+	root := gjson.ParseBytes(payload)
+	return strings.Join([]string{
+		stem,
+		root.Get("item_id").String(),
+		root.Get("output_index").Raw,
+		root.Get("content_index").Raw,
+		root.Get("summary_index").Raw,
+	}, "|")
+}
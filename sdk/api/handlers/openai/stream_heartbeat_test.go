@@ -0,0 +1,162 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// slowStreamExecutor waits before its first chunk, like an upstream still thinking.
+type slowStreamExecutor struct {
+	delay  time.Duration
+	chunks []string
+	err    error
+}
+
+func (e *slowStreamExecutor) Identifier() string { return "heartbeat-provider" }
+
+func (e *slowStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *slowStreamExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	ch := make(chan coreexecutor.StreamChunk)
+	go func() {
+		defer close(ch)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.delay):
+		}
+		for _, chunk := range e.chunks {
+			ch <- coreexecutor.StreamChunk{Payload: []byte(chunk)}
+		}
+		if e.err != nil {
+			ch <- coreexecutor.StreamChunk{Err: e.err}
+		}
+	}()
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *slowStreamExecutor) Refresh(ctx context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *slowStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, errors.New("not implemented")
+}
+
+func (e *slowStreamExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newHeartbeatRouter(t *testing.T, interval string, executor *slowStreamExecutor) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+
+	auth := &coreauth.Auth{ID: "heartbeat-auth", Provider: executor.Identifier(), Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "heartbeat-model"}})
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient(auth.ID)
+	})
+
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{HeartbeatInterval: interval},
+	}, manager)
+	router := gin.New()
+	router.POST("/v1/chat/completions", NewOpenAIAPIHandler(base).ChatCompletions)
+	router.POST("/v1/responses", NewOpenAIResponsesAPIHandler(base).Responses)
+	return router
+}
+
+func postStream(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestChatCompletionsStream_HeartbeatBeforeFirstChunk(t *testing.T) {
+	chunk := `data: {"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"hi"}}]}`
+	router := newHeartbeatRouter(t, "10ms", &slowStreamExecutor{delay: 80 * time.Millisecond, chunks: []string{chunk}})
+
+	resp := postStream(router, "/v1/chat/completions", `{"model":"heartbeat-model","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	if got := resp.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+	body := resp.Body.String()
+	ping := strings.Index(body, ": ping\n\n")
+	data := strings.Index(body, chunk+"\n\n")
+	if ping < 0 || data < 0 || ping > data {
+		t.Fatalf("want a ping before the intact data event, got %q", body)
+	}
+	if !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Fatalf("stream not terminated with [DONE]: %q", body)
+	}
+}
+
+func TestChatCompletionsStream_ErrorAfterHeartbeatIsWrittenInStream(t *testing.T) {
+	upstreamErr := &coreauth.Error{Code: "upstream_down", Message: "upstream down", HTTPStatus: http.StatusBadGateway}
+	router := newHeartbeatRouter(t, "10ms", &slowStreamExecutor{delay: 80 * time.Millisecond, err: upstreamErr})
+
+	resp := postStream(router, "/v1/chat/completions", `{"model":"heartbeat-model","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	// Headers were committed by the first ping, so the failure arrives as an SSE error event.
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	body := resp.Body.String()
+	if !strings.HasPrefix(body, ": ping\n\n") || !strings.Contains(body, "upstream down") {
+		t.Fatalf("want ping then an in-stream error, got %q", body)
+	}
+}
+
+func TestChatCompletionsStream_NoHeartbeatForFastUpstream(t *testing.T) {
+	chunk := `data: {"id":"c1","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"content":"hi"}}]}`
+	router := newHeartbeatRouter(t, "1s", &slowStreamExecutor{chunks: []string{chunk}})
+
+	resp := postStream(router, "/v1/chat/completions", `{"model":"heartbeat-model","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if body := resp.Body.String(); strings.Contains(body, ": ping") {
+		t.Fatalf("unexpected heartbeat in %q", body)
+	}
+}
+
+func TestResponsesStream_HeartbeatGoesThroughWriteState(t *testing.T) {
+	created := "event: response.created\ndata: {\"type\":\"response.created\"}\n\n"
+	completed := "event: response.completed\ndata: {\"type\":\"response.completed\"}\n\n"
+	router := newHeartbeatRouter(t, "10ms", &slowStreamExecutor{delay: 80 * time.Millisecond, chunks: []string{created, completed}})
+
+	resp := postStream(router, "/v1/responses", `{"model":"heartbeat-model","stream":true,"input":"hi"}`)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", resp.Code, resp.Body.String())
+	}
+	body := resp.Body.String()
+	if !strings.HasPrefix(body, ": ping\n") {
+		t.Fatalf("want the stream to open with a ping, got %q", body)
+	}
+	// writeState emits a comment as a single line; no empty event may follow it.
+	if strings.Contains(body, ": ping\n\n") {
+		t.Fatalf("ping followed by an empty event: %q", body)
+	}
+	if !strings.Contains(body, created) || !strings.HasSuffix(body, completed) {
+		t.Fatalf("events not intact: %q", body)
+	}
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultRedactionReplacement    = "[REDACTED]"
+	defaultRedactionLookaheadBytes = 256
+)
+
+// OutputRedactor replaces matches of the output-redaction patterns in model output text.
+type OutputRedactor struct {
+	pattern     *regexp.Regexp
+	replacement string
+	lookahead   int
+}
+
+var (
+	outputRedactorMu    sync.Mutex
+	outputRedactorKey   string
+	outputRedactorCache *OutputRedactor
+)
+
+// OutputRedactor returns the redactor for the configured output-redaction patterns, or nil
+// when redaction is off. Patterns that do not compile are logged and skipped. The compiled
+// redactor is reused until the configuration changes.
+func (h *BaseAPIHandler) OutputRedactor() *OutputRedactor {
+	if h == nil || h.Cfg == nil || len(h.Cfg.OutputRedaction.Patterns) == 0 {
+		return nil
+	}
+	return outputRedactorFor(h.Cfg.OutputRedaction)
+}
+
+func outputRedactorFor(cfg config.OutputRedactionConfig) *OutputRedactor {
+	key := fmt.Sprintf("%q|%q|%d", cfg.Patterns, cfg.Replacement, cfg.StreamLookaheadBytes)
+	outputRedactorMu.Lock()
+	defer outputRedactorMu.Unlock()
+	if outputRedactorCache != nil && outputRedactorKey == key {
+		return outputRedactorCache
+	}
+	redactor := NewOutputRedactor(cfg)
+	outputRedactorKey, outputRedactorCache = key, redactor
+	return redactor
+}
+
+// NewOutputRedactor compiles cfg into a redactor. It returns nil when no pattern compiles.
+func NewOutputRedactor(cfg config.OutputRedactionConfig) *OutputRedactor {
+	var alternatives []string
+	for _, raw := range cfg.Patterns {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		if _, err := regexp.Compile(raw); err != nil {
+			log.Warnf("output-redaction: skipping invalid pattern %q: %v", raw, err)
+			continue
+		}
+		alternatives = append(alternatives, "(?:"+raw+")")
+	}
+	if len(alternatives) == 0 {
+		return nil
+	}
+	replacement := cfg.Replacement
+	if replacement == "" {
+		replacement = defaultRedactionReplacement
+	}
+	lookahead := cfg.StreamLookaheadBytes
+	if lookahead <= 0 {
+		lookahead = defaultRedactionLookaheadBytes
+	}
+	return &OutputRedactor{
+		pattern:     regexp.MustCompile(strings.Join(alternatives, "|")),
+		replacement: replacement,
+		lookahead:   lookahead,
+	}
+}
+
+// Redact returns text with every match replaced.
+func (r *OutputRedactor) Redact(text string) string {
+	if r == nil || text == "" {
+		return text
+	}
+	return r.replace(text, r.pattern.FindAllStringIndex(text, -1), len(text))
+}
+
+// RedactPaths redacts the string values at the gjson paths in payload; other values are
+// left alone, so the JSON structure is unchanged. A ".#" segment stands for every element
+// of an array, as in "choices.#.message.content".
+func (r *OutputRedactor) RedactPaths(payload []byte, paths ...string) []byte {
+	if r == nil {
+		return payload
+	}
+	for _, path := range paths {
+		payload = r.redactPath(payload, path)
+	}
+	return payload
+}
+
+func (r *OutputRedactor) redactPath(payload []byte, path string) []byte {
+	array, rest, found := strings.Cut(path, ".#")
+	if !found {
+		value := gjson.GetBytes(payload, path)
+		if value.Type != gjson.String {
+			return payload
+		}
+		if redacted := r.Redact(value.String()); redacted != value.String() {
+			payload, _ = sjson.SetBytes(payload, path, redacted)
+		}
+		return payload
+	}
+	n := len(gjson.GetBytes(payload, array).Array())
+	for i := 0; i < n; i++ {
+		payload = r.redactPath(payload, array+"."+strconv.Itoa(i)+rest)
+	}
+	return payload
+}
+
+// NewStream returns a redactor for one stream of text deltas.
+func (r *OutputRedactor) NewStream() *RedactionStream {
+	if r == nil {
+		return nil
+	}
+	return &RedactionStream{redactor: r}
+}
+
+// replace rewrites text[:limit], replacing the matches that end within it.
+func (r *OutputRedactor) replace(text string, matches [][]int, limit int) string {
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m[1] > limit {
+			break
+		}
+		if m[0] == m[1] {
+			continue
+		}
+		out.WriteString(text[last:m[0]])
+		out.WriteString(r.replacement)
+		last = m[1]
+	}
+	out.WriteString(text[last:limit])
+	return out.String()
+}
+
+// RedactionStream redacts a sequence of text deltas. The last lookahead bytes are held
+// back, and so is a match reaching into them, because the next delta may complete or
+// extend it.
+type RedactionStream struct {
+	redactor *OutputRedactor
+	pending  string
+}
+
+// Write adds a delta and returns the redacted text that is safe to emit.
+func (s *RedactionStream) Write(delta string) string {
+	if s == nil {
+		return delta
+	}
+	text := s.pending + delta
+	cut := len(text) - s.redactor.lookahead
+	if cut <= 0 {
+		s.pending = text
+		return ""
+	}
+	matches := s.redactor.pattern.FindAllStringIndex(text, -1)
+	for _, m := range matches {
+		if m[0] < cut && m[1] > cut {
+			cut = m[0]
+			break
+		}
+	}
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	s.pending = text[cut:]
+	return s.redactor.replace(text, matches, cut)
+}
+
+// Flush returns the held-back text, redacted, at the end of the stream.
+func (s *RedactionStream) Flush() string {
+	if s == nil {
+		return ""
+	}
+	pending := s.pending
+	s.pending = ""
+	return s.redactor.Redact(pending)
+}
+
+// RedactionStreams redacts the text fields of one streamed response, with a RedactionStream
+// per field key chosen by the caller (such as choice index and field name).
+type RedactionStreams struct {
+	redactor *OutputRedactor
+	streams  map[string]*RedactionStream
+	keys     []string
+}
+
+// HeldText is text a RedactionStreams released for key.
+type HeldText struct {
+	Key  string
+	Text string
+}
+
+// NewStreams returns the streams of one response, or nil when r is nil.
+func (r *OutputRedactor) NewStreams() *RedactionStreams {
+	if r == nil {
+		return nil
+	}
+	return &RedactionStreams{redactor: r, streams: make(map[string]*RedactionStream)}
+}
+
+// Write adds a delta of key and returns the redacted text that is safe to emit.
+func (s *RedactionStreams) Write(key, delta string) string {
+	if s == nil {
+		return delta
+	}
+	stream, ok := s.streams[key]
+	if !ok {
+		stream = s.redactor.NewStream()
+		s.streams[key] = stream
+		s.keys = append(s.keys, key)
+	}
+	return stream.Write(delta)
+}
+
+// Flush returns the held-back text of key, redacted, and ends its stream.
+func (s *RedactionStreams) Flush(key string) string {
+	if s == nil {
+		return ""
+	}
+	stream, ok := s.streams[key]
+	if !ok {
+		return ""
+	}
+	delete(s.streams, key)
+	for i, k := range s.keys {
+		if k == key {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			break
+		}
+	}
+	return stream.Flush()
+}
+
+// FlushAll ends every open stream and returns the non-empty held-back text in the order
+// the streams were opened. Called when the response ends without closing its fields.
+func (s *RedactionStreams) FlushAll() []HeldText {
+	if s == nil {
+		return nil
+	}
+	var held []HeldText
+	for _, key := range s.keys {
+		if text := s.streams[key].Flush(); text != "" {
+			held = append(held, HeldText{Key: key, Text: text})
+		}
+	}
+	s.streams = make(map[string]*RedactionStream)
+	s.keys = nil
+	return held
+}
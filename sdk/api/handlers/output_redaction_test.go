@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestOutputRedactor_Redact(t *testing.T) {
+	r := NewOutputRedactor(sdkconfig.OutputRedactionConfig{
+		Patterns:    []string{`\d{3}-\d{4}`, `(unclosed`, `secret`},
+		Replacement: "***",
+	})
+	if r == nil {
+		t.Fatal("redactor is nil although valid patterns are configured")
+	}
+	if got := r.Redact("call 555-1234 about the secret"); got != "call *** about the ***" {
+		t.Fatalf("Redact = %q", got)
+	}
+	if NewOutputRedactor(sdkconfig.OutputRedactionConfig{Patterns: []string{`(unclosed`}}) != nil {
+		t.Fatal("redactor built from invalid patterns only")
+	}
+}
+
+func TestRedactionStream_HoldsBackPartialMatches(t *testing.T) {
+	r := NewOutputRedactor(sdkconfig.OutputRedactionConfig{Patterns: []string{`token-[a-z]+`}, StreamLookaheadBytes: 8})
+	stream := r.NewStream()
+	var out strings.Builder
+	for _, delta := range []string{"here is tok", "en-abc", "def and then ", "ünïcödé text ", "token-x"} {
+		emitted := stream.Write(delta)
+		if strings.Contains(emitted, "abc") {
+			t.Fatalf("emitted %q before the match was complete", emitted)
+		}
+		out.WriteString(emitted)
+	}
+	out.WriteString(stream.Flush())
+	if want := "here is [REDACTED] and then ünïcödé text [REDACTED]"; out.String() != want {
+		t.Fatalf("stream output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestOutputRedactor_RedactPaths(t *testing.T) {
+	r := NewOutputRedactor(sdkconfig.OutputRedactionConfig{Patterns: []string{`secret`}})
+	payload := []byte(`{"output":[{"content":[{"text":"a secret"},{"text":"none"}]},{"content":[{"text":"secret b","n":1}]}],"note":"secret"}`)
+
+	got := string(r.RedactPaths(payload, "output.#.content.#.text", "output.#.content.#.n"))
+	want := `{"output":[{"content":[{"text":"a [REDACTED]"},{"text":"none"}]},{"content":[{"text":"[REDACTED] b","n":1}]}],"note":"secret"}`
+	if got != want {
+		t.Fatalf("RedactPaths = %s, want %s", got, want)
+	}
+}
+
+func TestRedactionStreams_FlushAllInOpenOrder(t *testing.T) {
+	r := NewOutputRedactor(sdkconfig.OutputRedactionConfig{Patterns: []string{`secret`}, StreamLookaheadBytes: 16})
+	streams := r.NewStreams()
+	streams.Write("b", "the secret")
+	streams.Write("a", "short")
+	streams.Write("c", "done")
+	if got := streams.Flush("c"); got != "done" {
+		t.Fatalf("Flush(c) = %q", got)
+	}
+
+	held := streams.FlushAll()
+	if len(held) != 2 || held[0] != (HeldText{Key: "b", Text: "the [REDACTED]"}) || held[1] != (HeldText{Key: "a", Text: "short"}) {
+		t.Fatalf("FlushAll = %+v", held)
+	}
+	if again := streams.FlushAll(); len(again) != 0 {
+		t.Fatalf("second FlushAll = %+v", again)
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// PrefetchHeader asks for the upstream to be warmed for the client's next turn once the
+// response is complete. It is honored only with speculative-prefetch enabled.
+const PrefetchHeader = "X-Cliproxy-Prefetch"
+
+const (
+	// prefetchTimeout bounds a single warm-up call.
+	prefetchTimeout = 10 * time.Second
+	// prefetchMaxInFlight caps concurrent warm-up calls; further requests skip theirs.
+	prefetchMaxInFlight = 4
+)
+
+var prefetchSlots = make(chan struct{}, prefetchMaxInFlight)
+
+// prefetchRequested reports whether speculative prefetch is enabled and the client asked
+// for it with PrefetchHeader.
+func (h *BaseAPIHandler) prefetchRequested(ctx context.Context) bool {
+	if h == nil || h.Cfg == nil || !h.Cfg.SpeculativePrefetch || ctx == nil {
+		return false
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return false
+	}
+	requested, err := strconv.ParseBool(strings.TrimSpace(ginCtx.GetHeader(PrefetchHeader)))
+	return err == nil && requested
+}
+
+// startPrefetch warms the upstream of the auth that served the request in the background,
+// keeping the session on the same auth. It is best effort: it is skipped when that auth's
+// executor has no warm-up call or prefetchMaxInFlight are already running, and failures
+// are only logged.
+func (h *BaseAPIHandler) startPrefetch(ctx context.Context, req coreexecutor.Request, opts coreexecutor.Options) {
+	if !h.prefetchRequested(ctx) || h.AuthManager == nil {
+		return
+	}
+	authID, _ := opts.Metadata[coreexecutor.SelectedAuthMetadataKey].(string)
+	auth, ok := h.AuthManager.GetByID(authID)
+	if !ok || auth == nil {
+		return
+	}
+	executor, ok := h.AuthManager.Executor(auth.Provider)
+	if !ok {
+		return
+	}
+	prewarmer, ok := executor.(coreauth.Prewarmer)
+	if !ok {
+		return
+	}
+	select {
+	case prefetchSlots <- struct{}{}:
+	default:
+		log.Debugf("prefetch: skipped for auth %s, %d warm-ups in flight", authID, prefetchMaxInFlight)
+		return
+	}
+	// The warm-up outlives the request, so it keeps the request values but not its cancellation.
+	warmCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), prefetchTimeout)
+	go func() {
+		defer func() { <-prefetchSlots }()
+		defer cancel()
+		if err := prewarmer.Prewarm(warmCtx, auth, req, opts); err != nil {
+			log.Debugf("prefetch: warm-up for auth %s failed: %v", authID, err)
+		}
+	}()
+}
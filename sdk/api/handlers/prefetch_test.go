@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// prewarmingExecutor answers requests and reports the auth of every warm-up call.
+type prewarmingExecutor struct {
+	providerEchoExecutor
+	prewarmed chan string
+}
+
+func (e *prewarmingExecutor) Prewarm(_ context.Context, auth *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) error {
+	e.prewarmed <- auth.ID
+	return nil
+}
+
+func TestExecuteWithAuthManager_PrefetchHeaderPrewarmsServingAuth(t *testing.T) {
+	executor := &prewarmingExecutor{providerEchoExecutor: providerEchoExecutor{provider: "prefetch-test"}, prewarmed: make(chan string, 1)}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "prefetch-auth", Provider: "prefetch-test", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "prefetch-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{SpeculativePrefetch: true}, manager)
+
+	run := func(header string) []byte {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		if header != "" {
+			c.Request.Header.Set(PrefetchHeader, header)
+		}
+		ctx := context.WithValue(context.Background(), "gin", c)
+		resp, _, errMsg := h.ExecuteWithAuthManager(ctx, "openai", "prefetch-model", []byte(`{"model":"prefetch-model"}`), "")
+		if errMsg != nil {
+			t.Fatalf("ExecuteWithAuthManager error: %v", errMsg.Error)
+		}
+		return resp
+	}
+
+	if resp := run("true"); string(resp) != `{"provider":"prefetch-test"}` {
+		t.Fatalf("response = %s, want the generation unchanged", resp)
+	}
+	select {
+	case authID := <-executor.prewarmed:
+		if authID != auth.ID {
+			t.Fatalf("prewarmed auth = %s, want %s", authID, auth.ID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected a warm-up call for the serving auth")
+	}
+
+	run("")
+	select {
+	case authID := <-executor.prewarmed:
+		t.Fatalf("unexpected warm-up for %s without %s", authID, PrefetchHeader)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// PresetHeader selects a configured parameter preset for the request.
+const PresetHeader = "X-Preset"
+
+// presetEffortLevels are the effort names model aliases such as "gpt-5-high" end with.
+var presetEffortLevels = []string{"minimal", "none", "low", "medium", "high", "xhigh"}
+
+// applyPreset fills parameters the request leaves unset from the preset named by the
+// X-Preset header. An unknown preset name is rejected with a 400.
+func (h *BaseAPIHandler) applyPreset(ctx context.Context, handlerType, modelName string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	if ctx == nil {
+		return rawJSON, nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return rawJSON, nil
+	}
+	name := strings.TrimSpace(ginCtx.GetHeader(PresetHeader))
+	if name == "" {
+		return rawJSON, nil
+	}
+	preset, found := h.Cfg.Preset(name)
+	if !found {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("unknown preset %q", name)}
+	}
+	if len(rawJSON) == 0 {
+		return rawJSON, nil
+	}
+
+	var temperaturePath, topPPath, effortPath string
+	switch handlerType {
+	case constant.Gemini:
+		temperaturePath, topPPath = "generationConfig.temperature", "generationConfig.topP"
+	case constant.GeminiCLI:
+		temperaturePath, topPPath = "request.generationConfig.temperature", "request.generationConfig.topP"
+	case constant.OpenAI:
+		temperaturePath, topPPath, effortPath = "temperature", "top_p", "reasoning_effort"
+	case constant.OpenaiResponse:
+		temperaturePath, topPPath, effortPath = "temperature", "top_p", "reasoning.effort"
+	default:
+		temperaturePath, topPPath = "temperature", "top_p"
+	}
+	var applied []string
+	fill := func(path string, value any) {
+		if path == "" || gjson.GetBytes(rawJSON, path).Exists() {
+			return
+		}
+		if updated, err := sjson.SetBytes(rawJSON, path, value); err == nil {
+			rawJSON = updated
+			applied = append(applied, path)
+		}
+	}
+	if preset.Temperature != nil {
+		fill(temperaturePath, *preset.Temperature)
+	}
+	if preset.TopP != nil {
+		fill(topPPath, *preset.TopP)
+	}
+	if effort := strings.ToLower(strings.TrimSpace(preset.ReasoningEffort)); effort != "" && !modelEncodesEffort(modelName) {
+		fill(effortPath, effort)
+	}
+	if len(applied) > 0 {
+		log.Debugf("preset %s: filled %s on %s request", name, strings.Join(applied, ", "), handlerType)
+	}
+	return rawJSON, nil
+}
+
+// modelEncodesEffort reports whether the model name already selects a reasoning effort,
+// through a thinking suffix ("gpt-5(high)") or an effort alias ("gpt-5-high").
+func modelEncodesEffort(modelName string) bool {
+	modelName = strings.ToLower(strings.TrimSpace(modelName))
+	if thinking.ParseSuffix(modelName).HasSuffix {
+		return true
+	}
+	for _, level := range presetEffortLevels {
+		if strings.HasSuffix(modelName, "-"+level) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func presetContext(preset string) context.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Request.Header.Set(PresetHeader, preset)
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func TestExecuteWithAuthManager_Presets(t *testing.T) {
+	temperature, topP := 1.1, 0.9
+	cfg := &sdkconfig.SDKConfig{Presets: map[string]sdkconfig.ModelPreset{
+		"creative": {Temperature: &temperature, TopP: &topP, ReasoningEffort: "low"},
+	}}
+
+	cases := []struct {
+		name, model, body string
+		wantTemperature   float64
+		wantEffort        string
+	}{
+		{"fills unset fields", "context-retry-model", `{"model":"context-retry-model","messages":[]}`, 1.1, "low"},
+		{"keeps explicit values", "context-retry-model", `{"model":"context-retry-model","temperature":0.3,"reasoning_effort":"high","messages":[]}`, 0.3, "high"},
+		{"keeps alias effort", "context-retry-model(high)", `{"model":"context-retry-model(high)","messages":[]}`, 1.1, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, executor := newContextRetryHandler(t, cfg)
+			if _, _, errMsg := h.ExecuteWithAuthManager(presetContext("Creative"), "openai", tc.model, []byte(tc.body), ""); errMsg != nil {
+				t.Fatalf("ExecuteWithAuthManager: %v", errMsg.Error)
+			}
+			if len(executor.payloads) != 1 {
+				t.Fatalf("upstream saw %d requests, want 1", len(executor.payloads))
+			}
+			payload := executor.payloads[0]
+			if got := gjson.Get(payload, "temperature").Float(); got != tc.wantTemperature {
+				t.Fatalf("temperature = %v, want %v: %s", got, tc.wantTemperature, payload)
+			}
+			if got := gjson.Get(payload, "top_p").Float(); got != 0.9 {
+				t.Fatalf("top_p = %v, want 0.9: %s", got, payload)
+			}
+			if got := gjson.Get(payload, "reasoning_effort").String(); got != tc.wantEffort {
+				t.Fatalf("reasoning_effort = %q, want %q: %s", got, tc.wantEffort, payload)
+			}
+		})
+	}
+
+	h, executor := newContextRetryHandler(t, cfg)
+	_, _, errMsg := h.ExecuteWithAuthManager(presetContext("unknown"), "openai", "context-retry-model", []byte(`{"model":"context-retry-model"}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest || len(executor.payloads) != 0 {
+		t.Fatalf("unknown preset: errMsg=%+v upstream=%d", errMsg, len(executor.payloads))
+	}
+}
+
+func TestModelEncodesEffort(t *testing.T) {
+	cases := map[string]bool{
+		"gpt-5-high":            true,
+		"gpt-5.1-codex-xhigh":   true,
+		"gpt-5(medium)":         true,
+		"claude-sonnet-4(8192)": true,
+		"gpt-5":                 false,
+		"gpt-5-codex":           false,
+	}
+	for model, want := range cases {
+		if got := modelEncodesEffort(model); got != want {
+			t.Errorf("modelEncodesEffort(%q) = %v, want %v", model, got, want)
+		}
+	}
+}
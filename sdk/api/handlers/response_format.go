@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Modes for handling OpenAI chat requests that carry `response_format`
+// (json_object / json_schema).
+const (
+	// ResponseFormatNative forwards the request unchanged and relies on the upstream.
+	ResponseFormatNative = "native"
+	// ResponseFormatInstruct injects a system instruction asking for JSON output.
+	ResponseFormatInstruct = "instruct"
+	// ResponseFormatValidate injects the instruction, then repairs and validates
+	// non-streaming replies, returning a 502 when they do not satisfy the format.
+	ResponseFormatValidate = "validate"
+)
+
+// responseFormatUnsupportedProviders lists providers whose OpenAI chat translators drop
+// `response_format`. They default to ResponseFormatInstruct. Kiro injects its own hint.
+var responseFormatUnsupportedProviders = map[string]bool{
+	"claude":      true,
+	"gemini":      true,
+	"gemini-cli":  true,
+	"vertex":      true,
+	"aistudio":    true,
+	"antigravity": true,
+}
+
+// ResponseFormatContract describes the JSON output a client asked for and how the
+// proxy enforces it for the selected provider.
+type ResponseFormatContract struct {
+	// Type is "json_object" or "json_schema".
+	Type string
+	// SchemaName is the json_schema name, when given.
+	SchemaName string
+	// Schema is the JSON Schema to validate against; empty for json_object.
+	Schema gjson.Result
+	// Validate enables repair and validation of non-streaming replies.
+	Validate bool
+}
+
+// ResponseFormatMode resolves the configured response_format handling mode for the
+// provider that would serve modelName. Lookup order: the provider's entry, "default",
+// then the provider's built-in capability. It also returns the provider name.
+func (h *BaseAPIHandler) ResponseFormatMode(modelName string) (mode string, provider string) {
+	mode = ResponseFormatNative
+	if h == nil {
+		return mode, ""
+	}
+	providers, _, _, errMsg := h.getRequestDetails(modelName)
+	if errMsg == nil && len(providers) > 0 {
+		provider = providers[0]
+	}
+	if responseFormatUnsupportedProviders[strings.ToLower(provider)] {
+		mode = ResponseFormatInstruct
+	}
+	if h.Cfg == nil || len(h.Cfg.ResponseFormatCoercion) == 0 {
+		return mode, provider
+	}
+	lookup := func(key string) (string, bool) {
+		for k, v := range h.Cfg.ResponseFormatCoercion {
+			if strings.EqualFold(strings.TrimSpace(k), key) {
+				return normalizeResponseFormatMode(v), true
+			}
+		}
+		return "", false
+	}
+	if provider != "" {
+		if v, ok := lookup(provider); ok {
+			return v, provider
+		}
+	}
+	if v, ok := lookup("default"); ok {
+		return v, provider
+	}
+	return mode, provider
+}
+
+func normalizeResponseFormatMode(raw string) string {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "instruct", "inject", "prompt":
+		return ResponseFormatInstruct
+	case "validate", "repair", "strict":
+		return ResponseFormatValidate
+	default:
+		return ResponseFormatNative
+	}
+}
+
+// requestedResponseFormat extracts the JSON contract of an OpenAI chat request, or nil
+// when the request does not ask for JSON output.
+func requestedResponseFormat(rawJSON []byte) *ResponseFormatContract {
+	format := gjson.GetBytes(rawJSON, "response_format")
+	switch format.Get("type").String() {
+	case "json_object":
+		return &ResponseFormatContract{Type: "json_object"}
+	case "json_schema":
+		return &ResponseFormatContract{
+			Type:       "json_schema",
+			SchemaName: format.Get("json_schema.name").String(),
+			Schema:     format.Get("json_schema.schema"),
+		}
+	default:
+		return nil
+	}
+}
+
+// CoerceResponseFormat applies the response_format handling mode for modelName. When the
+// provider needs help it returns the request with a JSON instruction injected after the
+// leading system messages, plus the contract to enforce on the reply. Requests without a
+// JSON response_format, or served natively, are returned unchanged with a nil contract.
+func (h *BaseAPIHandler) CoerceResponseFormat(modelName string, rawJSON []byte) ([]byte, *ResponseFormatContract) {
+	contract := requestedResponseFormat(rawJSON)
+	if contract == nil {
+		return rawJSON, nil
+	}
+	mode, _ := h.ResponseFormatMode(modelName)
+	if mode == ResponseFormatNative {
+		return rawJSON, nil
+	}
+	contract.Validate = mode == ResponseFormatValidate
+	return injectResponseFormatInstruction(rawJSON, contract.instruction()), contract
+}
+
+func (c *ResponseFormatContract) instruction() string {
+	if c.Type != "json_schema" || !c.Schema.Exists() {
+		return "Respond with a single valid JSON object only. Do not wrap it in markdown code fences and do not add any text before or after it."
+	}
+	var schema bytes.Buffer
+	if err := json.Compact(&schema, []byte(c.Schema.Raw)); err != nil {
+		schema.Reset()
+		schema.WriteString(c.Schema.Raw)
+	}
+	text := "Respond with a single valid JSON value only. Do not wrap it in markdown code fences and do not add any text before or after it."
+	if c.SchemaName != "" {
+		return fmt.Sprintf("%s The JSON must conform to the JSON Schema %q: %s", text, c.SchemaName, schema.String())
+	}
+	return fmt.Sprintf("%s The JSON must conform to this JSON Schema: %s", text, schema.String())
+}
+
+// injectResponseFormatInstruction inserts a system message carrying instruction after
+// the request's leading system/developer messages.
+func injectResponseFormatInstruction(rawJSON []byte, instruction string) []byte {
+	messages := gjson.GetBytes(rawJSON, "messages")
+	if !messages.IsArray() {
+		return rawJSON
+	}
+	systemMessage, _ := sjson.Set(`{"role":"system"}`, "content", instruction)
+	items := messages.Array()
+	insertAt := 0
+	for insertAt < len(items) {
+		role := items[insertAt].Get("role").String()
+		if role != "system" && role != "developer" {
+			break
+		}
+		insertAt++
+	}
+	raws := make([]string, 0, len(items)+1)
+	for i, item := range items {
+		if i == insertAt {
+			raws = append(raws, systemMessage)
+		}
+		raws = append(raws, item.Raw)
+	}
+	if insertAt == len(items) {
+		raws = append(raws, systemMessage)
+	}
+	updated, err := sjson.SetRawBytes(rawJSON, "messages", []byte("["+strings.Join(raws, ",")+"]"))
+	if err != nil {
+		return rawJSON
+	}
+	return updated
+}
+
+// Enforce repairs and validates the message content of every choice in a non-streaming
+// chat completion. Content wrapped in code fences or surrounded by prose is reduced to
+// the JSON value; content that still is not valid JSON, or does not match the schema,
+// yields a 502 naming the failing choice and JSON path.
+func (c *ResponseFormatContract) Enforce(payload []byte) ([]byte, *interfaces.ErrorMessage) {
+	if c == nil || !c.Validate {
+		return payload, nil
+	}
+	choices := gjson.GetBytes(payload, "choices").Array()
+	for i, choice := range choices {
+		content := choice.Get("message.content")
+		if content.Type != gjson.String {
+			// Tool calls and refusals carry no JSON body to check.
+			continue
+		}
+		if content.String() == "" && choice.Get("message.tool_calls").Exists() {
+			continue
+		}
+		repaired, ok := extractJSONValue(content.String())
+		if !ok {
+			return nil, responseFormatError(i, "reply is not valid JSON")
+		}
+		value := gjson.Parse(repaired)
+		if c.Type == "json_object" && !value.IsObject() {
+			return nil, responseFormatError(i, "reply is not a JSON object")
+		}
+		if c.Type == "json_schema" && c.Schema.Exists() {
+			if problem := validateJSONSchema(c.Schema, c.Schema, value, "$"); problem != "" {
+				name := c.SchemaName
+				if name == "" {
+					name = "response_format"
+				}
+				return nil, responseFormatError(i, fmt.Sprintf("reply does not match schema %q: %s", name, problem))
+			}
+		}
+		if repaired != content.String() {
+			updated, err := sjson.SetBytes(payload, fmt.Sprintf("choices.%d.message.content", i), repaired)
+			if err == nil {
+				payload = updated
+			}
+		}
+	}
+	return payload, nil
+}
+
+func responseFormatError(choice int, reason string) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadGateway,
+		Error:      fmt.Errorf("response_format not satisfied by upstream (choice %d): %s", choice, reason),
+	}
+}
+
+// extractJSONValue returns the JSON value in text, stripping markdown code fences and
+// any prose around the outermost object or array.
+func extractJSONValue(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		if nl := strings.IndexByte(text, '\n'); nl >= 0 {
+			text = text[nl+1:]
+		} else {
+			text = strings.TrimPrefix(text, "```")
+		}
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+	if text != "" && gjson.Valid(text) {
+		return text, true
+	}
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return "", false
+	}
+	closer := "}"
+	if text[start] == '[' {
+		closer = "]"
+	}
+	end := strings.LastIndex(text, closer)
+	if end <= start {
+		return "", false
+	}
+	candidate := text[start : end+1]
+	if !gjson.Valid(candidate) {
+		return "", false
+	}
+	return candidate, true
+}
+
+// validateJSONSchema checks value against the commonly used subset of JSON Schema:
+// type, enum, const, properties, required, additionalProperties, items, min/maxItems,
+// anyOf/oneOf and local $ref. It returns a description of the first mismatch, or "".
+func validateJSONSchema(root, schema, value gjson.Result, path string) string {
+	if ref := schema.Get(`\$ref`).String(); ref != "" {
+		resolved, ok := resolveSchemaRef(root, ref)
+		if !ok {
+			return ""
+		}
+		return validateJSONSchema(root, resolved, value, path)
+	}
+	for _, key := range []string{"anyOf", "oneOf"} {
+		if branches := schema.Get(key); branches.IsArray() {
+			matched := false
+			for _, branch := range branches.Array() {
+				if validateJSONSchema(root, branch, value, path) == "" {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return fmt.Sprintf("%s: does not match any allowed schema", path)
+			}
+		}
+	}
+	if typ := schema.Get("type"); typ.Exists() {
+		var allowed []string
+		if typ.IsArray() {
+			for _, t := range typ.Array() {
+				allowed = append(allowed, t.String())
+			}
+		} else {
+			allowed = []string{typ.String()}
+		}
+		matched := false
+		for _, t := range allowed {
+			if jsonTypeMatches(t, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(allowed, " or "), jsonTypeName(value))
+		}
+	}
+	if enum := schema.Get("enum"); enum.IsArray() {
+		matched := false
+		for _, option := range enum.Array() {
+			if reflect.DeepEqual(option.Value(), value.Value()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Sprintf("%s: value %s is not one of %s", path, value.Raw, enum.Raw)
+		}
+	}
+	if constant := schema.Get("const"); constant.Exists() && !reflect.DeepEqual(constant.Value(), value.Value()) {
+		return fmt.Sprintf("%s: value %s does not equal %s", path, value.Raw, constant.Raw)
+	}
+
+	if value.IsObject() {
+		for _, required := range schema.Get("required").Array() {
+			if !value.Get(gjsonEscape(required.String())).Exists() {
+				return fmt.Sprintf("%s: missing required property %q", path, required.String())
+			}
+		}
+		properties := schema.Get("properties")
+		additional := schema.Get("additionalProperties")
+		var problem string
+		value.ForEach(func(key, item gjson.Result) bool {
+			childPath := path + "." + key.String()
+			if propSchema := properties.Get(gjsonEscape(key.String())); propSchema.Exists() {
+				problem = validateJSONSchema(root, propSchema, item, childPath)
+			} else if additional.Type == gjson.False {
+				problem = fmt.Sprintf("%s: property is not allowed by the schema", childPath)
+			} else if additional.IsObject() {
+				problem = validateJSONSchema(root, additional, item, childPath)
+			}
+			return problem == ""
+		})
+		if problem != "" {
+			return problem
+		}
+	}
+
+	if value.IsArray() {
+		items := value.Array()
+		if minItems := schema.Get("minItems"); minItems.Exists() && int64(len(items)) < minItems.Int() {
+			return fmt.Sprintf("%s: expected at least %d items, got %d", path, minItems.Int(), len(items))
+		}
+		if maxItems := schema.Get("maxItems"); maxItems.Exists() && int64(len(items)) > maxItems.Int() {
+			return fmt.Sprintf("%s: expected at most %d items, got %d", path, maxItems.Int(), len(items))
+		}
+		if itemSchema := schema.Get("items"); itemSchema.IsObject() {
+			for i, item := range items {
+				if problem := validateJSONSchema(root, itemSchema, item, fmt.Sprintf("%s[%d]", path, i)); problem != "" {
+					return problem
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// resolveSchemaRef resolves local references such as "#/$defs/Item".
+func resolveSchemaRef(root gjson.Result, ref string) (gjson.Result, bool) {
+	if ref == "#" {
+		return root, true
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return gjson.Result{}, false
+	}
+	segments := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+	for i, segment := range segments {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		segments[i] = gjsonEscape(segment)
+	}
+	resolved := root.Get(strings.Join(segments, "."))
+	return resolved, resolved.Exists()
+}
+
+func jsonTypeMatches(typ string, value gjson.Result) bool {
+	switch typ {
+	case "object":
+		return value.IsObject()
+	case "array":
+		return value.IsArray()
+	case "string":
+		return value.Type == gjson.String
+	case "number":
+		return value.Type == gjson.Number
+	case "integer":
+		return value.Type == gjson.Number && value.Float() == math.Trunc(value.Float())
+	case "boolean":
+		return value.Type == gjson.True || value.Type == gjson.False
+	case "null":
+		return value.Type == gjson.Null
+	default:
+		return true
+	}
+}
+
+func jsonTypeName(value gjson.Result) string {
+	switch {
+	case value.IsObject():
+		return "object"
+	case value.IsArray():
+		return "array"
+	case value.Type == gjson.String:
+		return "string"
+	case value.Type == gjson.Number:
+		return "number"
+	case value.Type == gjson.True, value.Type == gjson.False:
+		return "boolean"
+	default:
+		return "null"
+	}
+}
+
+// gjsonEscape escapes gjson path metacharacters in a single object key.
+func gjsonEscape(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch r {
+		case '.', '*', '?', '|', '#', '@', '\\', '!', '=', '<', '>', '%':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,11 @@
+package handlers
+
+import "testing"
+
+func TestResponseFormatMode_NilHandler(t *testing.T) {
+	var h *BaseAPIHandler
+	mode, provider := h.ResponseFormatMode("gpt-4o")
+	if mode != ResponseFormatNative || provider != "" {
+		t.Fatalf("ResponseFormatMode() = %q, %q; want native with no provider", mode, provider)
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// responseSnippetBytes bounds how much of an invalid body is quoted in the proxy error.
+const responseSnippetBytes = 120
+
+// requiredResponseFields lists, per client format, the top-level fields of which at
+// least one must be present in a well-formed non-streaming response.
+var requiredResponseFields = map[sdktranslator.Format][]string{
+	sdktranslator.FormatOpenAI:         {"choices"},
+	sdktranslator.FormatOpenAIResponse: {"output", "status"},
+	sdktranslator.FormatClaude:         {"content"},
+	sdktranslator.FormatGemini:         {"candidates", "promptFeedback"},
+	sdktranslator.FormatGeminiCLI:      {"response", "candidates"},
+}
+
+// validateUpstreamResponse checks a non-streaming payload against the shape expected for
+// handlerType when validate-upstream-responses is enabled. It returns a 502 describing
+// the problem for bodies that are not JSON objects or lack the required fields.
+func (h *BaseAPIHandler) validateUpstreamResponse(handlerType, alt string, payload []byte) *interfaces.ErrorMessage {
+	if h == nil || h.Cfg == nil || !h.Cfg.ValidateUpstreamResponses || alt == "sse" {
+		return nil
+	}
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 {
+		return invalidUpstreamResponse(handlerType, "empty body", nil)
+	}
+	if !gjson.ValidBytes(trimmed) || trimmed[0] != '{' {
+		return invalidUpstreamResponse(handlerType, "body is not a JSON object", trimmed)
+	}
+	// Compact and other alternate endpoints have their own shapes; JSON is all we can check.
+	if alt != "" {
+		return nil
+	}
+	fields, ok := requiredResponseFields[sdktranslator.FromString(handlerType)]
+	if !ok {
+		return nil
+	}
+	for _, field := range fields {
+		if gjson.GetBytes(trimmed, field).Exists() {
+			return nil
+		}
+	}
+	return invalidUpstreamResponse(handlerType, fmt.Sprintf("missing %s", strings.Join(fields, "/")), trimmed)
+}
+
+func invalidUpstreamResponse(handlerType, reason string, body []byte) *interfaces.ErrorMessage {
+	msg := fmt.Sprintf("upstream returned an invalid %s response: %s", handlerType, reason)
+	if len(body) > 0 {
+		msg += fmt.Sprintf(" (body starts with %q)", responseSnippet(body))
+	}
+	return &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("%s", msg)}
+}
+
+// responseSnippet returns at most responseSnippetBytes of body without splitting a code point.
+func responseSnippet(body []byte) string {
+	if len(body) <= responseSnippetBytes {
+		return string(body)
+	}
+	cut := responseSnippetBytes
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	return string(body[:cut]) + "..."
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// routingRuleMetadataKey records the name of the routing rule that pinned a request.
+const routingRuleMetadataKey = "routing_rule"
+
+// applyRoutingRules pins the request to the provider of the first routing rule it matches.
+// model is the normalized model; without a match providers and metadata are returned as is.
+func (h *BaseAPIHandler) applyRoutingRules(ctx context.Context, model string, rawJSON []byte, providers []string, metadata map[string]any) ([]string, map[string]any) {
+	if h == nil || h.Cfg == nil || len(h.Cfg.RoutingRules) == 0 {
+		return providers, metadata
+	}
+	apiKey := strings.TrimSpace(clientAPIKey(ctx))
+	baseModel := strings.ToLower(strings.TrimSpace(thinking.ParseSuffix(model).ModelName))
+	for i := range h.Cfg.RoutingRules {
+		rule := &h.Cfg.RoutingRules[i]
+		provider := strings.ToLower(strings.TrimSpace(rule.Provider))
+		if provider == "" || !routingRuleMatches(rule, apiKey, baseModel, rawJSON) {
+			continue
+		}
+		log.Debugf("routing rule %q: pinning model %s to provider %s", rule.Name, model, provider)
+		if metadata == nil {
+			metadata = make(map[string]any, 1)
+		}
+		// Only the provider is pinned: auths whose registered models leave out the model,
+		// e.g. through excluded-models, are still skipped.
+		metadata[routingRuleMetadataKey] = rule.Name
+		return []string{provider}, metadata
+	}
+	return providers, metadata
+}
+
+func routingRuleMatches(rule *config.RoutingRule, apiKey, model string, rawJSON []byte) bool {
+	if len(rule.APIKeys) > 0 {
+		matched := false
+		for _, key := range rule.APIKeys {
+			if apiKey != "" && strings.TrimSpace(key) == apiKey {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(rule.Models) > 0 {
+		matched := false
+		for _, pattern := range rule.Models {
+			if matchModelGlob(strings.ToLower(strings.TrimSpace(pattern)), model) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for key, want := range rule.Metadata {
+		value := gjson.GetBytes(rawJSON, "metadata."+gjson.Escape(key))
+		if !value.Exists() || value.String() != want {
+			return false
+		}
+	}
+	return true
+}
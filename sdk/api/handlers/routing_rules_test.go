@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// providerEchoExecutor answers with the provider it serves.
+type providerEchoExecutor struct{ provider string }
+
+func (e *providerEchoExecutor) Identifier() string { return e.provider }
+
+func (e *providerEchoExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{Payload: []byte(`{"provider":"` + e.provider + `"}`)}, nil
+}
+
+func (e *providerEchoExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *providerEchoExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *providerEchoExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *providerEchoExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func apiKeyContext(apiKey string) context.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Set("apiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func TestExecuteWithAuthManager_RoutingRulePinsProvider(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	for _, provider := range []string{"rule-default", "rule-pinned"} {
+		manager.RegisterExecutor(&providerEchoExecutor{provider: provider})
+		auth := &coreauth.Auth{ID: provider + "-auth", Provider: provider, Status: coreauth.StatusActive}
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("manager.Register: %v", err)
+		}
+	}
+	for _, provider := range []string{"rule-default", "rule-pinned"} {
+		registry.GetGlobalRegistry().RegisterClient(provider+"-auth", provider, []*registry.ModelInfo{{ID: "rule-model-4o"}})
+	}
+	t.Cleanup(func() {
+		registry.GetGlobalRegistry().UnregisterClient("rule-default-auth")
+		registry.GetGlobalRegistry().UnregisterClient("rule-pinned-auth")
+	})
+
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{RoutingRules: []sdkconfig.RoutingRule{
+		{Name: "unrelated", Models: []string{"other-*"}, Provider: "rule-default"},
+		{Name: "team-a", APIKeys: []string{"sk-team-a"}, Models: []string{"RULE-MODEL-*"}, Metadata: map[string]string{"team": "search"}, Provider: "rule-pinned"},
+		{Name: "fallback", Models: []string{"rule-model-*"}, Provider: "rule-default"},
+	}}, manager)
+
+	tests := []struct {
+		name   string
+		apiKey string
+		body   string
+		want   string
+	}{
+		{"matching key, model and metadata", "sk-team-a", `{"model":"rule-model-4o","metadata":{"team":"search"}}`, "rule-pinned"},
+		{"other key", "sk-team-b", `{"model":"rule-model-4o","metadata":{"team":"search"}}`, "rule-default"},
+		{"missing metadata", "sk-team-a", `{"model":"rule-model-4o"}`, "rule-default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _, errMsg := h.ExecuteWithAuthManager(apiKeyContext(tt.apiKey), "openai", "rule-model-4o", []byte(tt.body), "")
+			if errMsg != nil {
+				t.Fatalf("ExecuteWithAuthManager error: %v", errMsg.Error)
+			}
+			if want := `{"provider":"` + tt.want + `"}`; string(resp) != want {
+				t.Fatalf("response = %s, want %s", resp, want)
+			}
+		})
+	}
+}
+
+// authEchoExecutor answers with the ID of the auth that served the request.
+type authEchoExecutor struct{ providerEchoExecutor }
+
+func (e *authEchoExecutor) Execute(_ context.Context, auth *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func TestExecuteWithAuthManager_PrefixRoutingSkipsAuthExcludingModel(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&authEchoExecutor{providerEchoExecutor{provider: "copilot"}})
+	// copilot-excluding-auth excluded gpt-4o, so its registered models leave it out.
+	models := map[string][]*registry.ModelInfo{
+		"copilot-excluding-auth": {{ID: "gpt-5"}},
+		"copilot-serving-auth":   {{ID: "gpt-5"}, {ID: "gpt-4o"}},
+	}
+	for id, list := range models {
+		if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: id, Provider: "copilot", Status: coreauth.StatusActive}); err != nil {
+			t.Fatalf("manager.Register: %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(id, "copilot", list)
+	}
+	t.Cleanup(func() {
+		for id := range models {
+			registry.GetGlobalRegistry().UnregisterClient(id)
+		}
+	})
+
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+	for i := 0; i < 4; i++ {
+		resp, _, errMsg := h.ExecuteWithAuthManager(context.Background(), "openai", "copilot-gpt-4o", []byte(`{"model":"copilot-gpt-4o"}`), "")
+		if errMsg != nil {
+			t.Fatalf("ExecuteWithAuthManager error: %v", errMsg.Error)
+		}
+		if string(resp) != "copilot-serving-auth" {
+			t.Fatalf("request %d served by %s, want copilot-serving-auth", i+1, resp)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// streamDurationExceededError reports a stream cut off by max-stream-duration.
+type streamDurationExceededError struct {
+	limit time.Duration
+	model string
+}
+
+func (e *streamDurationExceededError) Error() string {
+	return fmt.Sprintf("stream for model %s exceeded max-stream-duration of %s and was aborted", e.model, e.limit)
+}
+
+func (e *streamDurationExceededError) StatusCode() int { return http.StatusGatewayTimeout }
+
+// ModelMaxStreamDuration resolves the max-stream-duration for modelName with the same
+// glob lookup as model-max-body-bytes. Zero means streams run until they end on their own.
+func (h *BaseAPIHandler) ModelMaxStreamDuration(modelName string) time.Duration {
+	if h == nil || h.Cfg == nil {
+		return 0
+	}
+	raw, ok := modelGlobValue(h.Cfg.MaxStreamDuration, modelName)
+	if !ok {
+		return 0
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(raw))
+	if err != nil || d <= 0 {
+		return 0
+	}
+	return d
+}
+
+// startMaxStreamDuration cancels the returned context once the max-stream-duration of
+// modelName passes, whether or not data is still flowing, and closes expired at the same
+// time. Unlike the client deadline it is never stopped by the first payload; stop only
+// releases the timer once the stream has ended. expired is nil when there is no limit.
+func (h *BaseAPIHandler) startMaxStreamDuration(ctx context.Context, modelName string) (context.Context, <-chan struct{}, func()) {
+	limit := h.ModelMaxStreamDuration(modelName)
+	if limit <= 0 {
+		return ctx, nil, func() {}
+	}
+	bounded, cancel := context.WithCancelCause(ctx)
+	expired := make(chan struct{})
+	timer := time.AfterFunc(limit, func() {
+		cancel(&streamDurationExceededError{limit: limit, model: modelName})
+		close(expired)
+	})
+	return bounded, expired, func() { timer.Stop() }
+}
+
+// streamDurationError returns the max-stream-duration error when ctx was cancelled by it.
+func streamDurationError(ctx context.Context) error {
+	var durationErr *streamDurationExceededError
+	if errors.As(context.Cause(ctx), &durationErr) {
+		return durationErr
+	}
+	return nil
+}
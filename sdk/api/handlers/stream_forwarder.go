@@ -9,8 +9,9 @@ import (
 )
 
 type StreamForwardOptions struct {
-	// KeepAliveInterval overrides the configured streaming keep-alive interval.
-	// If nil, the configured default is used. If set to <= 0, keep-alives are disabled.
+	// KeepAliveInterval overrides the configured streaming keep-alive interval and turns off
+	// idle heartbeats. If nil, the configured default is used. If set to <= 0, keep-alives
+	// are disabled.
 	KeepAliveInterval *time.Duration
 
 	// WriteChunk writes a single data chunk to the response body. It should not flush.
@@ -24,9 +25,19 @@ type StreamForwardOptions struct {
 	// without an error (e.g. OpenAI's `[DONE]`). It should not flush.
 	WriteDone func()
 
+	// FlushHeld optionally writes output that a per-request rewrite still holds back, such
+	// as the output-redaction lookahead, when the stream ends with or without an error. It
+	// should not flush.
+	FlushHeld func()
+
 	// WriteKeepAlive optionally writes a keep-alive heartbeat. It should not flush.
-	// When nil, a standard SSE comment heartbeat is used.
+	// When nil, the StreamKeepAliveComment line is written as its own SSE block.
 	WriteKeepAlive func()
+
+	// Splitter keeps multi-byte UTF-8 code points intact across chunks before they reach
+	// WriteChunk. Handlers that write the first chunk themselves pass the splitter they used
+	// for it. When nil, a new splitter is used.
+	Splitter *UTF8ChunkSplitter
 }
 
 func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, opts StreamForwardOptions) {
@@ -41,22 +52,47 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 	if writeChunk == nil {
 		writeChunk = func([]byte) {}
 	}
+	splitter := opts.Splitter
+	if splitter == nil {
+		splitter = NewUTF8ChunkSplitter()
+	}
+	// flushHeld writes bytes still held by the splitter and the caller before the stream is
+	// terminated.
+	flushHeld := func() {
+		if tail := splitter.Flush(); len(tail) > 0 {
+			writeChunk(tail)
+		}
+		if opts.FlushHeld != nil {
+			opts.FlushHeld()
+		}
+	}
 
 	writeKeepAlive := opts.WriteKeepAlive
 	if writeKeepAlive == nil {
+		comment := StreamKeepAliveComment(h.Cfg)
 		writeKeepAlive = func() {
-			_, _ = c.Writer.Write([]byte(": keep-alive\n\n"))
+			_, _ = c.Writer.Write([]byte(comment + "\n\n"))
 		}
 	}
 
 	keepAliveInterval := StreamingKeepAliveInterval(h.Cfg)
+	heartbeatInterval := StreamingHeartbeatInterval(h.Cfg)
 	if opts.KeepAliveInterval != nil {
 		keepAliveInterval = *opts.KeepAliveInterval
+		heartbeatInterval = 0
 	}
-	var keepAlive *time.Ticker
+	// A heartbeat only fires after heartbeatInterval without data, so it is rearmed on
+	// every chunk; the legacy keep-alive ticks at a fixed rate regardless of traffic.
 	var keepAliveC <-chan time.Time
-	if keepAliveInterval > 0 {
-		keepAlive = time.NewTicker(keepAliveInterval)
+	resetIdle := func() {}
+	switch {
+	case heartbeatInterval > 0:
+		idle := time.NewTimer(heartbeatInterval)
+		defer idle.Stop()
+		keepAliveC = idle.C
+		resetIdle = func() { idle.Reset(heartbeatInterval) }
+	case keepAliveInterval > 0:
+		keepAlive := time.NewTicker(keepAliveInterval)
 		defer keepAlive.Stop()
 		keepAliveC = keepAlive.C
 	}
@@ -79,6 +115,7 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 					default:
 					}
 				}
+				flushHeld()
 				if terminalErr != nil {
 					if opts.WriteTerminalError != nil {
 						opts.WriteTerminalError(terminalErr)
@@ -94,14 +131,16 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 				cancel(nil)
 				return
 			}
-			writeChunk(chunk)
+			writeChunk(splitter.Split(chunk))
 			flusher.Flush()
+			resetIdle()
 		case errMsg, ok := <-errs:
 			if !ok {
 				continue
 			}
 			if errMsg != nil {
 				terminalErr = errMsg
+				flushHeld()
 				if opts.WriteTerminalError != nil {
 					opts.WriteTerminalError(errMsg)
 					flusher.Flush()
@@ -116,6 +155,7 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 		case <-keepAliveC:
 			writeKeepAlive()
 			flusher.Flush()
+			resetIdle()
 		}
 	}
 }
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestForwardStream_HeartbeatOnlyDuringGaps(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{HeartbeatInterval: "50ms"},
+	}, nil)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+
+	data := make(chan []byte)
+	go func() {
+		defer close(data)
+		// Chunks faster than the interval keep the stream quiet...
+		for i := 0; i < 4; i++ {
+			data <- []byte("a")
+			time.Sleep(5 * time.Millisecond)
+		}
+		// ...and a long gap is filled with pings.
+		time.Sleep(200 * time.Millisecond)
+		data <- []byte("b")
+	}()
+	h.ForwardStream(c, c.Writer, func(error) {}, data, make(chan *interfaces.ErrorMessage), StreamForwardOptions{
+		WriteChunk: func(chunk []byte) { _, _ = c.Writer.Write(chunk) },
+	})
+
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, "aaaa") {
+		t.Fatalf("heartbeat written while data was flowing: %q", body)
+	}
+	gap := strings.TrimSuffix(strings.TrimPrefix(body, "aaaa"), "b")
+	if gap == "" || strings.ReplaceAll(gap, StreamHeartbeatComment+"\n\n", "") != "" {
+		t.Fatalf("want only pings between the bursts, got %q", body)
+	}
+}
+
+func TestStreamingHeartbeatInterval(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":      0,
+		"15s":   15 * time.Second,
+		"20":    20 * time.Second,
+		"500ms": 500 * time.Millisecond,
+		"-1s":   0,
+		"-1":    0,
+		"soon":  0,
+	}
+	for raw, want := range cases {
+		cfg := &sdkconfig.SDKConfig{Streaming: sdkconfig.StreamingConfig{HeartbeatInterval: raw}}
+		if got := StreamingHeartbeatInterval(cfg); got != want {
+			t.Errorf("StreamingHeartbeatInterval(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestForwardStream_FlushHeldBeforeTerminalError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+
+	data := make(chan []byte, 1)
+	errs := make(chan *interfaces.ErrorMessage, 1)
+	data <- []byte("a")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		errs <- &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway}
+	}()
+	h.ForwardStream(c, c.Writer, func(error) {}, data, errs, StreamForwardOptions{
+		WriteChunk:         func(chunk []byte) { _, _ = c.Writer.Write(chunk) },
+		FlushHeld:          func() { _, _ = c.Writer.Write([]byte("held")) },
+		WriteTerminalError: func(*interfaces.ErrorMessage) { _, _ = c.Writer.Write([]byte("error")) },
+	})
+	if got := rec.Body.String(); got != "ahelderror" {
+		t.Fatalf("body = %q, want held text before the error", got)
+	}
+}
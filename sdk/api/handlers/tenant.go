@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// TenantContextKey is the gin context key holding the caller's tenant ID.
+const TenantContextKey = "tenant"
+
+// RequestTenant returns the tenant of the client API key on ctx, or "" for untenanted
+// callers and when no tenant is configured.
+func (h *BaseAPIHandler) RequestTenant(ctx context.Context) string {
+	if h == nil || h.Cfg == nil || !h.Cfg.TenantsEnabled() {
+		return ""
+	}
+	return h.Cfg.TenantForAPIKey(clientAPIKey(ctx))
+}
+
+// applyTenant scopes execution to the caller's tenant and records it on the gin context
+// for usage accounting.
+func (h *BaseAPIHandler) applyTenant(ctx context.Context, meta map[string]any) {
+	tenant := h.RequestTenant(ctx)
+	if tenant == "" {
+		return
+	}
+	meta[coreexecutor.TenantMetadataKey] = tenant
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		ginCtx.Set(TenantContextKey, tenant)
+	}
+}
+
+// FilterModelsForTenant drops listed models that no auth of the caller's tenant serves.
+// Catalog overlay entries are kept. Without configured tenants models pass through.
+func (h *BaseAPIHandler) FilterModelsForTenant(c *gin.Context, models []map[string]any) []map[string]any {
+	if h == nil || h.Cfg == nil || !h.Cfg.TenantsEnabled() || h.AuthManager == nil || c == nil {
+		return models
+	}
+	tenant := h.RequestTenant(context.WithValue(c.Request.Context(), "gin", c))
+	served := make(map[string]struct{})
+	modelRegistry := registry.GetGlobalRegistry()
+	for _, auth := range h.AuthManager.List() {
+		if auth == nil || auth.Disabled || h.AuthManager.AuthTenant(auth) != tenant {
+			continue
+		}
+		for _, model := range modelRegistry.GetModelsForClient(auth.ID) {
+			if model != nil {
+				served[strings.ToLower(model.ID)] = struct{}{}
+			}
+		}
+	}
+	out := make([]map[string]any, 0, len(models))
+	for _, model := range models {
+		if model["availability"] == ModelAvailabilityPotentiallyUnavailable {
+			out = append(out, model)
+			continue
+		}
+		for _, key := range []string{"id", "name"} {
+			value, _ := model[key].(string)
+			if _, ok := served[strings.ToLower(strings.TrimPrefix(value, "models/"))]; ok && value != "" {
+				out = append(out, model)
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// tenantExecutor records which auth served each request and fails the auths in failing.
+type tenantExecutor struct {
+	id      string
+	failing map[string]bool
+	mu      *sync.Mutex
+	served  *[]string
+}
+
+func (e *tenantExecutor) Identifier() string { return e.id }
+
+func (e *tenantExecutor) Execute(_ context.Context, auth *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	e.mu.Lock()
+	*e.served = append(*e.served, auth.ID)
+	e.mu.Unlock()
+	if e.failing[auth.ID] {
+		return coreexecutor.Response{}, &coreauth.Error{Code: "unavailable", Message: "upstream unavailable", HTTPStatus: http.StatusServiceUnavailable}
+	}
+	return coreexecutor.Response{Payload: []byte(`{"ok":true}`)}, nil
+}
+
+func (e *tenantExecutor) ExecuteStream(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "ExecuteStream not implemented"}
+}
+
+func (e *tenantExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *tenantExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *tenantExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func tenantContext(apiKey string) (context.Context, *gin.Context) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Set("apiKey", apiKey)
+	return context.WithValue(context.Background(), "gin", c), c
+}
+
+func TestExecuteWithAuthManager_TenantIsolationHoldsUnderFallback(t *testing.T) {
+	sdkCfg := sdkconfig.SDKConfig{Tenants: []sdkconfig.TenantConfig{
+		{ID: "team-a", APIKeys: []string{"sk-team-a"}},
+		{ID: "team-b", AuthFiles: []string{"team-b-*.json"}},
+	}}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.SetConfig(&internalconfig.Config{SDKConfig: sdkCfg})
+
+	var mu sync.Mutex
+	var served []string
+	failing := map[string]bool{"tenant-a-auth": true}
+	for _, provider := range []string{"tenant-prov-a", "tenant-prov-b"} {
+		manager.RegisterExecutor(&tenantExecutor{id: provider, failing: failing, mu: &mu, served: &served})
+	}
+	auths := []*coreauth.Auth{
+		{ID: "tenant-a-auth", Provider: "tenant-prov-a", Metadata: map[string]any{"tenant": "team-a"}},
+		{ID: "tenant-b-auth", Provider: "tenant-prov-b", FileName: "/auths/team-b-codex.json"},
+		{ID: "tenant-shared-auth", Provider: "tenant-prov-b"},
+	}
+	for _, auth := range auths {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		models := []*registry.ModelInfo{{ID: "tenant-model"}}
+		if auth.ID == "tenant-b-auth" {
+			models = append(models, &registry.ModelInfo{ID: "tenant-b-only-model"})
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, models)
+		id := auth.ID
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+	h := NewBaseAPIHandlers(&sdkCfg, manager)
+
+	cases := []struct {
+		apiKey   string
+		wantErr  bool
+		wantAuth string
+	}{
+		// team-a's only auth fails; fallback to the other provider must not reach team-b or shared auths.
+		{apiKey: "sk-team-a", wantErr: true, wantAuth: "tenant-a-auth"},
+		{apiKey: "team-b:secret", wantAuth: "tenant-b-auth"},
+		{apiKey: "sk-untenanted", wantAuth: "tenant-shared-auth"},
+	}
+	for _, tc := range cases {
+		for i := 0; i < 3; i++ {
+			mu.Lock()
+			served = nil
+			mu.Unlock()
+			ctx, _ := tenantContext(tc.apiKey)
+			_, _, errMsg := h.ExecuteWithAuthManager(ctx, "openai", "tenant-model", []byte(`{"model":"tenant-model"}`), "")
+			if (errMsg != nil) != tc.wantErr {
+				t.Fatalf("%s: error = %+v, wantErr %t", tc.apiKey, errMsg, tc.wantErr)
+			}
+			mu.Lock()
+			for _, id := range served {
+				if id != tc.wantAuth {
+					t.Fatalf("%s was served by %s, want only %s (attempts %v)", tc.apiKey, id, tc.wantAuth, served)
+				}
+			}
+			// After the first failure team-a's auth cools down and later requests fail without an attempt.
+			if i == 0 && len(served) == 0 {
+				t.Fatalf("%s: no upstream attempt", tc.apiKey)
+			}
+			mu.Unlock()
+		}
+	}
+
+	_, ginCtx := tenantContext("sk-team-a")
+	listed := h.FilterModelsForTenant(ginCtx, []map[string]any{{"id": "tenant-model"}, {"id": "tenant-b-only-model"}})
+	if len(listed) != 1 || listed[0]["id"] != "tenant-model" {
+		t.Fatalf("team-a model listing = %v", listed)
+	}
+}
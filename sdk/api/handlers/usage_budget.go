@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+// applyBudgetCaps drops providers whose hard-capped usage budget has been exhausted
+// for the calling API key. It returns a 429 error when no provider remains.
+func applyBudgetCaps(ctx context.Context, providers []string) ([]string, *interfaces.ErrorMessage) {
+	apiKey := clientAPIKey(ctx)
+	allowed := providers[:0:0]
+	blockedBy := ""
+	for _, provider := range providers {
+		if id, capped := usage.BudgetCapped(apiKey, provider); capped {
+			if blockedBy == "" {
+				blockedBy = id
+			}
+			continue
+		}
+		allowed = append(allowed, provider)
+	}
+	if blockedBy == "" {
+		return providers, nil
+	}
+	if len(allowed) == 0 {
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusTooManyRequests,
+			Error:      fmt.Errorf("monthly usage budget exceeded (%s)", blockedBy),
+		}
+	}
+	return allowed, nil
+}
+
+// clientAPIKey returns the authenticated client API key stored on the gin context.
+func clientAPIKey(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	if v, exists := ginCtx.Get("apiKey"); exists {
+		if key, ok := v.(string); ok {
+			return key
+		}
+		return fmt.Sprint(v)
+	}
+	return ""
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// UTF8ChunkSplitter keeps multi-byte UTF-8 code points intact across stream chunks.
+//
+// Executors and translators may cut a chunk in the middle of a code point. The splitter
+// holds the trailing incomplete bytes of each data payload and prepends them to the next
+// payload, so every payload it returns is valid UTF-8. A payload is the value of a "data:"
+// line, or a whole line when the chunk is not SSE-framed (raw JSON streams, or writers that
+// add the framing themselves). Event, id, retry, and comment lines pass through unchanged.
+//
+// A splitter belongs to a single response stream and is not safe for concurrent use.
+type UTF8ChunkSplitter struct {
+	held     []byte
+	heldData bool
+	// open records the kind of the previous chunk's last line when that chunk did not
+	// end with a newline, so the next chunk's first line continues it.
+	open payloadLineKind
+}
+
+type payloadLineKind int
+
+const (
+	lineClosed payloadLineKind = iota
+	lineOther
+	lineData
+	lineBare
+)
+
+// NewUTF8ChunkSplitter returns a splitter for one response stream.
+func NewUTF8ChunkSplitter() *UTF8ChunkSplitter {
+	return &UTF8ChunkSplitter{}
+}
+
+// Split returns chunk with held bytes from the previous chunk prepended to its first
+// payload, the incomplete code point at the end of its last payload held back, and any
+// other invalid bytes replaced with U+FFFD. Data lines left empty by holding are dropped.
+func (s *UTF8ChunkSplitter) Split(chunk []byte) []byte {
+	if s == nil || len(chunk) == 0 {
+		return chunk
+	}
+	if len(s.held) == 0 && utf8.Valid(chunk) {
+		last := bytes.LastIndexByte(chunk, '\n')
+		switch {
+		case last == len(chunk)-1:
+			s.open = lineClosed
+		case last >= 0:
+			s.open, _, _ = s.classifyLine(chunk[last+1:], false)
+		default:
+			s.open, _, _ = s.classifyLine(chunk, true)
+		}
+		return chunk
+	}
+
+	lines := bytes.Split(chunk, []byte("\n"))
+	out := make([]byte, 0, len(chunk)+len(s.held))
+	wrote := false
+	var kind payloadLineKind
+	for i, line := range lines {
+		var prefix, payload []byte
+		kind, prefix, payload = s.classifyLine(line, i == 0)
+		isData := kind == lineData
+		if kind == lineData || kind == lineBare {
+			var suffix []byte
+			if bytes.HasSuffix(payload, []byte("\r")) {
+				payload, suffix = payload[:len(payload)-1], []byte("\r")
+			}
+			combined := payload
+			if len(s.held) > 0 {
+				combined = append(s.held, payload...)
+				s.held = nil
+			}
+			if cut := incompleteUTF8Suffix(combined); cut < len(combined) {
+				s.held = append([]byte(nil), combined[cut:]...)
+				s.heldData = isData
+				combined = combined[:cut]
+			}
+			if isData && len(combined) == 0 && len(payload) > 0 {
+				continue
+			}
+			line = append(append(append([]byte(nil), prefix...), bytes.ToValidUTF8(combined, []byte("\uFFFD"))...), suffix...)
+		}
+		if wrote {
+			out = append(out, '\n')
+		}
+		out = append(out, line...)
+		wrote = true
+	}
+	s.open = lineClosed
+	if len(lines[len(lines)-1]) > 0 {
+		s.open = kind
+	}
+	return out
+}
+
+// Flush returns the bytes still held at stream end, with the incomplete code point
+// replaced by U+FFFD and framed like the line it came from. It returns nil when
+// nothing is held.
+func (s *UTF8ChunkSplitter) Flush() []byte {
+	if s == nil || len(s.held) == 0 {
+		return nil
+	}
+	tail := bytes.ToValidUTF8(s.held, []byte("\uFFFD"))
+	s.held = nil
+	if s.heldData {
+		return append(append([]byte("data: "), tail...), "\n\n"...)
+	}
+	return tail
+}
+
+// SplitUTF8 splits s into pieces of at most maxBytes without breaking multi-byte runes.
+// A rune wider than maxBytes is emitted as its own piece.
+func SplitUTF8(s string, maxBytes int) []string {
+	var out []string
+	if maxBytes <= 0 {
+		maxBytes = 1
+	}
+	for len(s) > maxBytes {
+		cut := maxBytes
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		if cut == 0 {
+			_, size := utf8.DecodeRuneInString(s)
+			cut = size
+		}
+		out = append(out, s[:cut])
+		s = s[cut:]
+	}
+	if s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+// classifyLine classifies one line of a chunk. Data lines yield their "data:" prefix
+// (including one optional space) and value; SSE event, id, retry, and comment lines and
+// blank lines are not payloads; anything else is a bare payload line. The first line of a
+// chunk continues the previous chunk's unterminated line unless it starts a new data or
+// event field.
+func (s *UTF8ChunkSplitter) classifyLine(line []byte, first bool) (payloadLineKind, []byte, []byte) {
+	newField := bytes.HasPrefix(line, []byte("data:")) || bytes.HasPrefix(line, []byte("event:"))
+	if first && s.open != lineClosed && !newField {
+		return s.open, nil, line
+	}
+	switch {
+	case len(bytes.TrimSuffix(line, []byte("\r"))) == 0:
+		return lineOther, nil, nil
+	case bytes.HasPrefix(line, []byte("data:")):
+		n := len("data:")
+		if len(line) > n && line[n] == ' ' {
+			n++
+		}
+		return lineData, line[:n], line[n:]
+	case newField,
+		line[0] == ':',
+		bytes.HasPrefix(line, []byte("id:")),
+		bytes.HasPrefix(line, []byte("retry:")):
+		return lineOther, nil, nil
+	default:
+		return lineBare, nil, line
+	}
+}
+
+// incompleteUTF8Suffix returns the index where a trailing incomplete (but so far valid)
+// multi-byte sequence starts, or len(b) when b does not end mid code point.
+func incompleteUTF8Suffix(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-(utf8.UTFMax-1); i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(b)
+}
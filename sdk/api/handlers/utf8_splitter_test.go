@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+const utf8SplitterSample = `{"text":"héllo wörld — 世界 🚀👩‍💻 done"}`
+
+// runSplitter feeds chunks through a fresh splitter, flushes it, and returns every output chunk.
+func runSplitter(chunks ...[]byte) [][]byte {
+	splitter := NewUTF8ChunkSplitter()
+	var out [][]byte
+	for _, chunk := range chunks {
+		out = append(out, splitter.Split(bytes.Clone(chunk)))
+	}
+	if tail := splitter.Flush(); tail != nil {
+		out = append(out, tail)
+	}
+	return out
+}
+
+func TestUTF8ChunkSplitter_EveryBoundary(t *testing.T) {
+	framings := map[string]string{
+		"bare":      utf8SplitterSample,
+		"data line": "data: " + utf8SplitterSample,
+		"sse event": "event: delta\ndata: " + utf8SplitterSample + "\n\n",
+	}
+	for name, wire := range framings {
+		for i := 0; i <= len(wire); i++ {
+			for j := i; j <= len(wire); j++ {
+				out := runSplitter([]byte(wire[:i]), []byte(wire[i:j]), []byte(wire[j:]))
+				for k, chunk := range out {
+					if !utf8.Valid(chunk) {
+						t.Fatalf("%s cut at %d,%d: chunk %d is invalid UTF-8: %q", name, i, j, k, chunk)
+					}
+				}
+				if got := string(bytes.Join(out, nil)); got != wire {
+					t.Fatalf("%s cut at %d,%d: reassembled %q, want %q", name, i, j, got, wire)
+				}
+			}
+		}
+	}
+}
+
+func TestUTF8ChunkSplitter_CarriesAcrossSeparatelyFramedEvents(t *testing.T) {
+	wire := "data: " + utf8SplitterSample
+	cut := strings.Index(wire, "🚀") + 2
+
+	out := runSplitter([]byte(wire[:cut]+"\n\n"), []byte("data: "+wire[cut:]+"\n\n"))
+	if len(out) != 2 {
+		t.Fatalf("expected 2 chunks, got %q", out)
+	}
+	first, second := string(out[0]), string(out[1])
+	if strings.Contains(first, "�") || strings.Contains(second, "�") {
+		t.Fatalf("unexpected replacement character: %q %q", first, second)
+	}
+	if !strings.HasPrefix(second, "data: 🚀") {
+		t.Fatalf("held bytes should be prepended to the next data payload, got %q", second)
+	}
+	if strings.TrimSuffix(first, "\n\n")+strings.TrimPrefix(strings.TrimSuffix(second, "\n\n"), "data: ") != wire {
+		t.Fatalf("payloads do not reassemble the original: %q %q", first, second)
+	}
+}
+
+func TestUTF8ChunkSplitter_FlushAndInvalidBytes(t *testing.T) {
+	emoji := "🚀"
+
+	out := runSplitter([]byte("data: ok"+emoji[:3]+"\n\n"), []byte("event: ping\n"))
+	if got := string(bytes.Join(out, nil)); got != "data: ok\n\nevent: ping\ndata: �\n\n" {
+		t.Fatalf("flushed stream = %q", got)
+	}
+
+	out = runSplitter([]byte("raw" + emoji[:2]))
+	if got := string(bytes.Join(out, nil)); got != "raw�" {
+		t.Fatalf("flushed raw stream = %q", got)
+	}
+
+	out = runSplitter([]byte("data: a\xffb\n"))
+	if got := string(out[0]); got != "data: a�b\n" {
+		t.Fatalf("invalid byte should be replaced, got %q", got)
+	}
+
+	out = runSplitter([]byte("data: "+emoji[:1]+"\n"), []byte("data: "+emoji[1:]+"\n"))
+	if got := string(bytes.Join(out, nil)); got != "data: "+emoji+"\n" {
+		t.Fatalf("data line holding only a partial code point should be dropped, got %q", got)
+	}
+}
+
+func TestSplitUTF8_EveryMaxBytes(t *testing.T) {
+	for maxBytes := 0; maxBytes <= len(utf8SplitterSample)+1; maxBytes++ {
+		pieces := SplitUTF8(utf8SplitterSample, maxBytes)
+		if got := strings.Join(pieces, ""); got != utf8SplitterSample {
+			t.Fatalf("maxBytes=%d: reassembled %q", maxBytes, got)
+		}
+		for _, piece := range pieces {
+			if !utf8.ValidString(piece) {
+				t.Fatalf("maxBytes=%d: invalid piece %q", maxBytes, piece)
+			}
+			if maxBytes > 0 && len(piece) > maxBytes && utf8.RuneCountInString(piece) > 1 {
+				t.Fatalf("maxBytes=%d: piece %q exceeds limit", maxBytes, piece)
+			}
+		}
+	}
+}
+
+func TestForwardStream_FlushesHeldBytesBeforeDone(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+	emoji := "🚀"
+	data := make(chan []byte, 3)
+	data <- []byte("a" + emoji[:1])
+	data <- []byte(emoji[1:3])
+	data <- []byte(emoji[3:] + "b" + emoji[:2])
+	close(data)
+	errs := make(chan *interfaces.ErrorMessage)
+
+	var written []string
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	h.ForwardStream(c, c.Writer, func(error) {}, data, errs, StreamForwardOptions{
+		WriteChunk: func(chunk []byte) { written = append(written, string(chunk)) },
+		WriteDone:  func() { written = append(written, "[DONE]") },
+	})
+
+	want := []string{"a", "", emoji + "b", "�", "[DONE]"}
+	if strings.Join(written, "|") != strings.Join(want, "|") {
+		t.Fatalf("written = %q, want %q", written, want)
+	}
+}
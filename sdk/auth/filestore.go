@@ -247,6 +247,7 @@ func (s *FileTokenStore) readAuthFile(path, baseDir string) (*cliproxyauth.Auth,
 			FailedCount:           intField(metadata, "failed_count"),
 			RemainingQueries:      intField(metadata, "remaining_queries"),
 			HeavyRemainingQueries: intField(metadata, "heavy_remaining_queries"),
+			QuotaResetAt:          stringField(metadata, "quota_reset_at"),
 			Note:                  stringField(metadata, "note"),
 			Type:                  "grok",
 		}
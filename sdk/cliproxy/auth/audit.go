@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+// AuditEvent is a redacted record describing which credential served a request.
+// It never carries tokens or API key material; the inbound principal is reduced
+// to a short fingerprint.
+type AuditEvent struct {
+	Timestamp  time.Time `json:"timestamp"`
+	RequestID  string    `json:"request_id,omitempty"`
+	AuthID     string    `json:"auth_id"`
+	AuthLabel  string    `json:"auth_label,omitempty"`
+	Provider   string    `json:"provider"`
+	Model      string    `json:"model"`
+	Principal  string    `json:"principal,omitempty"`
+	Success    bool      `json:"success"`
+	HTTPStatus int       `json:"http_status,omitempty"`
+}
+
+// AuditSink receives audit events. Implementations must be safe for concurrent use.
+type AuditSink interface {
+	WriteAudit(ctx context.Context, event AuditEvent)
+}
+
+// SetAuditSink installs an additional audit sink. Pass nil to remove it.
+// Sinks configured through the `audit` config block are managed by SetConfig.
+func (m *Manager) SetAuditSink(sink AuditSink) {
+	if m == nil {
+		return
+	}
+	m.auditSink.Store(auditSinkHolder{sink: sink})
+}
+
+type auditSinkHolder struct {
+	sink AuditSink
+}
+
+func (m *Manager) applyAuditConfig(cfg *internalconfig.Config) {
+	// Keep the running webhook sink across reloads with the same URL so queued events and
+	// its workers survive; a replaced one is closed below.
+	previous, _ := m.configAuditSink.Load().(auditSinkHolder)
+	var previousWebhook, webhook *WebhookAuditSink
+	if configured, ok := previous.sink.(multiAuditSink); ok {
+		for _, sink := range configured {
+			if w, isWebhook := sink.(*WebhookAuditSink); isWebhook {
+				previousWebhook = w
+			}
+		}
+	}
+	var sinks multiAuditSink
+	if cfg != nil {
+		if path := strings.TrimSpace(cfg.Audit.File); path != "" {
+			sinks = append(sinks, NewFileAuditSink(path))
+		}
+		if url := strings.TrimSpace(cfg.Audit.WebhookURL); url != "" {
+			if previousWebhook != nil && previousWebhook.url == url {
+				webhook = previousWebhook
+			} else {
+				webhook = NewWebhookAuditSink(url)
+			}
+			sinks = append(sinks, webhook)
+		}
+	}
+	var sink AuditSink
+	if len(sinks) > 0 {
+		sink = sinks
+	}
+	m.configAuditSink.Store(auditSinkHolder{sink: sink})
+	if previousWebhook != nil && previousWebhook != webhook {
+		previousWebhook.Close()
+	}
+}
+
+// emitAudit records which credential served an execution attempt.
+func (m *Manager) emitAudit(ctx context.Context, auth *Auth, result Result) {
+	if m == nil || auth == nil {
+		return
+	}
+	configured, _ := m.configAuditSink.Load().(auditSinkHolder)
+	custom, _ := m.auditSink.Load().(auditSinkHolder)
+	if configured.sink == nil && custom.sink == nil {
+		return
+	}
+	event := AuditEvent{
+		Timestamp: time.Now().UTC(),
+		RequestID: logging.GetRequestID(ctx),
+		AuthID:    auth.ID,
+		AuthLabel: auth.Label,
+		Provider:  result.Provider,
+		Model:     result.Model,
+		Principal: auditPrincipal(ctx),
+		Success:   result.Success,
+	}
+	if event.Provider == "" {
+		event.Provider = auth.Provider
+	}
+	if result.Error != nil {
+		event.HTTPStatus = result.Error.HTTPStatus
+	}
+	if configured.sink != nil {
+		configured.sink.WriteAudit(ctx, event)
+	}
+	if custom.sink != nil {
+		custom.sink.WriteAudit(ctx, event)
+	}
+}
+
+// auditPrincipal fingerprints the inbound API key so the audit trail can tell
+// clients apart without storing the key itself.
+func auditPrincipal(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	raw, exists := ginCtx.Get("apiKey")
+	if !exists || raw == nil {
+		return ""
+	}
+	principal := strings.TrimSpace(fmt.Sprintf("%v", raw))
+	if principal == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(principal))
+	return "sha256:" + hex.EncodeToString(sum[:])[:16]
+}
+
+type multiAuditSink []AuditSink
+
+func (s multiAuditSink) WriteAudit(ctx context.Context, event AuditEvent) {
+	for _, sink := range s {
+		sink.WriteAudit(ctx, event)
+	}
+}
+
+// FileAuditSink appends one JSON line per event to an append-only file.
+// Each record is written with a single O_APPEND write so concurrent writers
+// never interleave partial lines.
+type FileAuditSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileAuditSink creates a sink appending to path.
+func NewFileAuditSink(path string) *FileAuditSink {
+	return &FileAuditSink{path: filepath.Clean(path)}
+}
+
+// WriteAudit implements AuditSink.
+func (s *FileAuditSink) WriteAudit(_ context.Context, event AuditEvent) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		log.WithError(err).Warn("audit: failed to create audit log directory")
+		return
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		log.WithError(err).Warn("audit: failed to open audit log")
+		return
+	}
+	if _, err = f.Write(line); err != nil {
+		log.WithError(err).Warn("audit: failed to append audit record")
+	}
+	_ = f.Close()
+}
+
+const (
+	// webhookAuditQueueSize bounds the events waiting for delivery; newer ones are dropped
+	// while it is full.
+	webhookAuditQueueSize = 1024
+	// webhookAuditWorkers is the number of concurrent webhook deliveries.
+	webhookAuditWorkers = 4
+)
+
+// WebhookAuditSink posts each event as JSON to a webhook URL. Delivery is asynchronous
+// and best-effort so a slow receiver never delays requests: events wait in a bounded
+// queue served by a fixed set of workers, and events arriving while the queue is full
+// are dropped and counted.
+type WebhookAuditSink struct {
+	url     string
+	client  *http.Client
+	queue   chan []byte
+	done    chan struct{}
+	dropped atomic.Int64
+
+	startOnce sync.Once
+	closeOnce sync.Once
+}
+
+// NewWebhookAuditSink creates a sink posting to url. Its workers start with the first event.
+func NewWebhookAuditSink(url string) *WebhookAuditSink {
+	return &WebhookAuditSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan []byte, webhookAuditQueueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// WriteAudit implements AuditSink.
+func (s *WebhookAuditSink) WriteAudit(_ context.Context, event AuditEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		for i := 0; i < webhookAuditWorkers; i++ {
+			go s.deliverLoop()
+		}
+	})
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	select {
+	case s.queue <- body:
+	default:
+		if n := s.dropped.Add(1); n == 1 || n%100 == 0 {
+			log.Warnf("audit: webhook queue full, %d event(s) dropped so far", n)
+		}
+	}
+}
+
+// Dropped returns the number of events dropped because the delivery queue was full.
+func (s *WebhookAuditSink) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close stops the delivery workers. Events still queued are discarded.
+func (s *WebhookAuditSink) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+func (s *WebhookAuditSink) deliverLoop() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case body := <-s.queue:
+			s.deliver(body)
+		}
+	}
+}
+
+func (s *WebhookAuditSink) deliver(body []byte) {
+	req, errReq := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if errReq != nil {
+		log.WithError(errReq).Warn("audit: failed to build webhook request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, errDo := s.client.Do(req)
+	if errDo != nil {
+		log.WithError(errDo).Warn("audit: webhook delivery failed")
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Warnf("audit: webhook returned status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestExecute_WritesOneRedactedAuditRecord(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	mgr := NewManager(nil, &mockSelector{}, NoopHook{})
+	mgr.SetConfig(&internalconfig.Config{Audit: internalconfig.AuditConfig{File: auditPath}})
+	mgr.RegisterExecutor(&mockProviderExecutor{id: "copilot"})
+	if _, err := mgr.Register(context.Background(), &Auth{
+		ID:       "copilot-auth-1",
+		Provider: "copilot",
+		Label:    "work",
+		Metadata: map[string]any{"access_token": "secret-upstream-token"},
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient("copilot-auth-1", "copilot", []*registry.ModelInfo{{ID: "gpt-5"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("copilot-auth-1") })
+
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Set("apiKey", "sk-inbound-client-key")
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+	ctx = logging.WithRequestID(ctx, "req12345")
+
+	if _, err := mgr.Execute(ctx, []string{"copilot"}, cliproxyexecutor.Request{Model: "gpt-5"}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	raw, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	if strings.Contains(string(raw), "secret-upstream-token") || strings.Contains(string(raw), "sk-inbound-client-key") {
+		t.Fatalf("audit log leaked credential material: %s", raw)
+	}
+
+	var records []AuditEvent
+	scanner := bufio.NewScanner(strings.NewReader(string(raw)))
+	for scanner.Scan() {
+		var ev AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("decode audit record: %v", err)
+		}
+		records = append(records, ev)
+	}
+	if len(records) != 1 {
+		t.Fatalf("audit records = %d, want 1", len(records))
+	}
+	ev := records[0]
+	if ev.AuthID != "copilot-auth-1" || ev.Provider != "copilot" || ev.Model != "gpt-5" {
+		t.Fatalf("unexpected audit record: %+v", ev)
+	}
+	if ev.RequestID != "req12345" || !ev.Success || ev.Timestamp.IsZero() {
+		t.Fatalf("unexpected audit record: %+v", ev)
+	}
+	if !strings.HasPrefix(ev.Principal, "sha256:") {
+		t.Fatalf("principal = %q, want sha256 fingerprint", ev.Principal)
+	}
+}
+
+func TestWebhookAuditSink_BoundsWorkersAndDropsOnOverflow(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			peak := maxInFlight.Load()
+			if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	sink := NewWebhookAuditSink(srv.URL)
+	defer sink.Close()
+	const extra = 10
+	for i := 0; i < webhookAuditQueueSize+webhookAuditWorkers+extra; i++ {
+		sink.WriteAudit(context.Background(), AuditEvent{AuthID: "a", Provider: "codex"})
+	}
+	if got := sink.Dropped(); got < extra {
+		t.Fatalf("Dropped() = %d, want at least %d", got, extra)
+	}
+	if got := maxInFlight.Load(); got > webhookAuditWorkers {
+		t.Fatalf("concurrent deliveries = %d, want at most %d", got, webhookAuditWorkers)
+	}
+}
+
+func TestApplyAuditConfig_KeepsWebhookSinkForSameURL(t *testing.T) {
+	webhookOf := func(m *Manager) *WebhookAuditSink {
+		holder, _ := m.configAuditSink.Load().(auditSinkHolder)
+		sinks, _ := holder.sink.(multiAuditSink)
+		for _, sink := range sinks {
+			if w, ok := sink.(*WebhookAuditSink); ok {
+				return w
+			}
+		}
+		return nil
+	}
+	mgr := NewManager(nil, &mockSelector{}, NoopHook{})
+	mgr.applyAuditConfig(&internalconfig.Config{Audit: internalconfig.AuditConfig{WebhookURL: "http://127.0.0.1:1/a"}})
+	first := webhookOf(mgr)
+	mgr.applyAuditConfig(&internalconfig.Config{Audit: internalconfig.AuditConfig{WebhookURL: "http://127.0.0.1:1/a"}})
+	if webhookOf(mgr) != first {
+		t.Fatal("reload with the same URL replaced the webhook sink")
+	}
+	mgr.applyAuditConfig(&internalconfig.Config{})
+	select {
+	case <-first.done:
+	default:
+		t.Fatal("removed webhook sink was not closed")
+	}
+}
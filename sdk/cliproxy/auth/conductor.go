@@ -19,6 +19,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/timing"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	log "github.com/sirupsen/logrus"
@@ -42,6 +43,12 @@ type ProviderExecutor interface {
 	HttpRequest(ctx context.Context, auth *Auth, req *http.Request) (*http.Response, error)
 }
 
+// Prewarmer is implemented by executors that can warm the upstream for a client's next turn,
+// e.g. by opening a connection or priming a cache, without generating or using quota.
+type Prewarmer interface {
+	Prewarm(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) error
+}
+
 // ExecutionSessionCloser allows executors to release per-session runtime resources.
 type ExecutionSessionCloser interface {
 	CloseExecutionSession(sessionID string)
@@ -154,6 +161,16 @@ type Manager struct {
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
+	// auditSink and configAuditSink receive credential-use audit events.
+	auditSink       atomic.Value
+	configAuditSink atomic.Value
+
+	// eventEmitter receives dispatch decisions (selection, retries, state changes).
+	eventEmitter atomic.Value
+
+	// maintenance tracks provider maintenance windows that pause background refresh.
+	maintenance maintenanceState
+
 	// Auto refresh state
 	refreshCancel context.CancelFunc
 }
@@ -177,6 +194,7 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 	// atomic.Value requires non-nil initial value.
 	manager.runtimeConfig.Store(&internalconfig.Config{})
 	manager.apiKeyModelAlias.Store(apiKeyModelAliasTable(nil))
+	manager.eventEmitter.Store(eventEmitterHolder{emitter: NoopEventEmitter{}})
 	return manager
 }
 
@@ -217,6 +235,8 @@ func (m *Manager) SetConfig(cfg *internalconfig.Config) {
 	}
 	m.runtimeConfig.Store(cfg)
 	m.rebuildAPIKeyModelAliasFromRuntimeConfig()
+	m.applyAuditConfig(cfg)
+	m.applyMaintenanceConfig(cfg)
 }
 
 func (m *Manager) lookupAPIKeyUpstreamModel(authID, requestedModel string) string {
@@ -516,6 +536,7 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 		if !shouldRetry {
 			break
 		}
+		m.emitRetry(ctx, "", "", req.Model, "cooldown_wait", attempt+1, errExec, wait)
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
@@ -547,6 +568,7 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 		if !shouldRetry {
 			break
 		}
+		m.emitRetry(ctx, "", "", req.Model, "cooldown_wait", attempt+1, errExec, wait)
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
@@ -578,6 +600,7 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 		if !shouldRetry {
 			break
 		}
+		m.emitRetry(ctx, "", "", req.Model, "cooldown_wait", attempt+1, errStream, wait)
 		if errWait := waitForCooldown(ctx, wait); errWait != nil {
 			return nil, errWait
 		}
@@ -594,10 +617,16 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
-	tried := make(map[string]struct{})
+	tried := excludedAuthsFromMetadata(opts.Metadata)
+	timer := timing.FromContext(ctx)
+	pinned := pinnedAuthIDFromMetadata(opts.Metadata) != ""
+	attempt := 0
 	var lastErr error
+	var lastAuthID, lastProvider string
 	for {
+		pickStart := timer.Now()
 		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		timer.Since(timing.PhaseAuthSelect, pickStart)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -608,6 +637,13 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
 		publishSelectedAuthMetadata(opts.Metadata, auth.ID)
+		timer.Mark(timing.MarkAuthSelected)
+
+		attempt++
+		if lastErr != nil {
+			m.emitRetry(ctx, lastProvider, lastAuthID, routeModel, "next_auth", attempt, lastErr, 0)
+		}
+		m.emitSelection(ctx, auth, provider, routeModel, pinned, attempt, lastProvider)
 
 		tried[auth.ID] = struct{}{}
 		execCtx := ctx
@@ -615,11 +651,13 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
-		execReq := req
+		execCtx = context.WithValue(execCtx, authRefreshedContextKey{}, m.storeRefreshedAuth)
+		execCtx = context.WithValue(execCtx, currentAuthContextKey{}, m.GetByID)
+		execReq, execOpts := attemptRequest(req, opts)
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
+		resp, errExec := executor.Execute(execCtx, auth, execReq, execOpts)
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
@@ -634,13 +672,16 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 				result.RetryAfter = ra
 			}
 			m.MarkResult(execCtx, result)
+			m.emitAudit(execCtx, auth, result)
 			if isRequestInvalidError(errExec) {
 				return cliproxyexecutor.Response{}, errExec
 			}
 			lastErr = errExec
+			lastAuthID, lastProvider = auth.ID, provider
 			continue
 		}
 		m.MarkResult(execCtx, result)
+		m.emitAudit(execCtx, auth, result)
 		return resp, nil
 	}
 }
@@ -651,10 +692,16 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
-	tried := make(map[string]struct{})
+	tried := excludedAuthsFromMetadata(opts.Metadata)
+	timer := timing.FromContext(ctx)
+	pinned := pinnedAuthIDFromMetadata(opts.Metadata) != ""
+	attempt := 0
 	var lastErr error
+	var lastAuthID, lastProvider string
 	for {
+		pickStart := timer.Now()
 		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		timer.Since(timing.PhaseAuthSelect, pickStart)
 		if errPick != nil {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -665,6 +712,13 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
 		publishSelectedAuthMetadata(opts.Metadata, auth.ID)
+		timer.Mark(timing.MarkAuthSelected)
+
+		attempt++
+		if lastErr != nil {
+			m.emitRetry(ctx, lastProvider, lastAuthID, routeModel, "next_auth", attempt, lastErr, 0)
+		}
+		m.emitSelection(ctx, auth, provider, routeModel, pinned, attempt, lastProvider)
 
 		tried[auth.ID] = struct{}{}
 		execCtx := ctx
@@ -672,11 +726,13 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
-		execReq := req
+		execCtx = context.WithValue(execCtx, authRefreshedContextKey{}, m.storeRefreshedAuth)
+		execCtx = context.WithValue(execCtx, currentAuthContextKey{}, m.GetByID)
+		execReq, execOpts := attemptRequest(req, opts)
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
+		resp, errExec := executor.CountTokens(execCtx, auth, execReq, execOpts)
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
@@ -691,13 +747,16 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 				result.RetryAfter = ra
 			}
 			m.MarkResult(execCtx, result)
+			m.emitAudit(execCtx, auth, result)
 			if isRequestInvalidError(errExec) {
 				return cliproxyexecutor.Response{}, errExec
 			}
 			lastErr = errExec
+			lastAuthID, lastProvider = auth.ID, provider
 			continue
 		}
 		m.MarkResult(execCtx, result)
+		m.emitAudit(execCtx, auth, result)
 		return resp, nil
 	}
 }
@@ -708,10 +767,16 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	}
 	routeModel := req.Model
 	opts = ensureRequestedModelMetadata(opts, routeModel)
-	tried := make(map[string]struct{})
+	tried := excludedAuthsFromMetadata(opts.Metadata)
+	timer := timing.FromContext(ctx)
+	pinned := pinnedAuthIDFromMetadata(opts.Metadata) != ""
+	attempt := 0
 	var lastErr error
+	var lastAuthID, lastProvider string
 	for {
+		pickStart := timer.Now()
 		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, opts, tried)
+		timer.Since(timing.PhaseAuthSelect, pickStart)
 		if errPick != nil {
 			if lastErr != nil {
 				return nil, lastErr
@@ -722,6 +787,13 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, req.Model)
 		publishSelectedAuthMetadata(opts.Metadata, auth.ID)
+		timer.Mark(timing.MarkAuthSelected)
+
+		attempt++
+		if lastErr != nil {
+			m.emitRetry(ctx, lastProvider, lastAuthID, routeModel, "next_auth", attempt, lastErr, 0)
+		}
+		m.emitSelection(ctx, auth, provider, routeModel, pinned, attempt, lastProvider)
 
 		tried[auth.ID] = struct{}{}
 		execCtx := ctx
@@ -729,11 +801,13 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
-		execReq := req
+		execCtx = context.WithValue(execCtx, authRefreshedContextKey{}, m.storeRefreshedAuth)
+		execCtx = context.WithValue(execCtx, currentAuthContextKey{}, m.GetByID)
+		execReq, execOpts := attemptRequest(req, opts)
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		streamResult, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
+		streamResult, errStream := executor.ExecuteStream(execCtx, auth, execReq, execOpts)
 		if errStream != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
 				return nil, errCtx
@@ -746,10 +820,12 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
 			result.RetryAfter = retryAfterFromError(errStream)
 			m.MarkResult(execCtx, result)
+			m.emitAudit(execCtx, auth, result)
 			if isRequestInvalidError(errStream) {
 				return nil, errStream
 			}
 			lastErr = errStream
+			lastAuthID, lastProvider = auth.ID, provider
 			continue
 		}
 		out := make(chan cliproxyexecutor.StreamChunk)
@@ -765,7 +841,9 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 					if errors.As(chunk.Err, &se) && se != nil {
 						rerr.HTTPStatus = se.StatusCode()
 					}
-					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr})
+					failResult := Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr}
+					m.MarkResult(streamCtx, failResult)
+					m.emitAudit(streamCtx, streamAuth, failResult)
 				}
 				if !forward {
 					continue
@@ -781,7 +859,9 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 				}
 			}
 			if !failed {
-				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
+				okResult := Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true}
+				m.MarkResult(streamCtx, okResult)
+				m.emitAudit(streamCtx, streamAuth, okResult)
 			}
 		}(execCtx, auth.Clone(), provider, streamResult.Chunks)
 		return &cliproxyexecutor.StreamResult{
@@ -848,6 +928,19 @@ func pinnedAuthIDFromMetadata(meta map[string]any) string {
 	}
 }
 
+// excludedAuthsFromMetadata seeds the tried set of an execution with the auths the caller
+// excluded, e.g. one that already returned an unusable response.
+func excludedAuthsFromMetadata(meta map[string]any) map[string]struct{} {
+	tried := make(map[string]struct{})
+	ids, _ := meta[cliproxyexecutor.ExcludedAuthsMetadataKey].([]string)
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			tried[id] = struct{}{}
+		}
+	}
+	return tried
+}
+
 func publishSelectedAuthMetadata(meta map[string]any, authID string) {
 	if len(meta) == 0 {
 		return
@@ -862,6 +955,17 @@ func publishSelectedAuthMetadata(meta map[string]any, authID string) {
 	}
 }
 
+// attemptRequest gives one failover attempt its own copy of the client payload. Each
+// executor translates from opts.SourceFormat into its own upstream format, so copying keeps
+// a failed attempt from leaking a provider-specific rewrite into the next candidate while
+// the client-facing format stays fixed. Metadata stays shared so selection results reach
+// the handler.
+func attemptRequest(req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Request, cliproxyexecutor.Options) {
+	req.Payload = bytes.Clone(req.Payload)
+	opts.OriginalRequest = bytes.Clone(opts.OriginalRequest)
+	return req, opts
+}
+
 func rewriteModelForAuth(model string, auth *Auth) string {
 	if auth == nil || model == "" {
 		return model
@@ -1218,6 +1322,8 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	suspendReason := ""
 	clearModelQuota := false
 	setModelQuota := false
+	wasUnavailable := false
+	var retryAt time.Time
 
 	m.mu.Lock()
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
@@ -1226,6 +1332,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 		if result.Success {
 			if result.Model != "" {
 				state := ensureModelState(auth, result.Model)
+				wasUnavailable = state.Unavailable
 				resetModelState(state, now)
 				updateAggregatedAvailability(auth, now)
 				if !hasModelError(auth, now) {
@@ -1283,10 +1390,11 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 					}
 					state.NextRetryAfter = next
 					state.Quota = QuotaState{
-						Exceeded:      true,
-						Reason:        "quota",
-						NextRecoverAt: next,
-						BackoffLevel:  backoffLevel,
+						Exceeded:          true,
+						Reason:            "quota",
+						NextRecoverAt:     next,
+						BackoffLevel:      backoffLevel,
+						RetryAfterSeconds: retryAfterSeconds(result.RetryAfter),
 					}
 					suspendReason = "quota"
 					shouldSuspendModel = true
@@ -1304,6 +1412,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 
 				auth.Status = StatusError
 				auth.UpdatedAt = now
+				retryAt = state.NextRetryAfter
 				updateAggregatedAvailability(auth, now)
 			} else {
 				applyAuthFailureState(auth, result.Error, result.RetryAfter, now)
@@ -1325,6 +1434,18 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	} else if shouldSuspendModel {
 		registry.GetGlobalRegistry().SuspendClientModel(result.AuthID, result.Model, suspendReason)
 	}
+	if shouldSuspendModel {
+		event := Event{Type: EventAuthState, Provider: result.Provider, AuthID: result.AuthID, Model: result.Model, State: "suspended", Reason: suspendReason}
+		if result.Error != nil {
+			event.HTTPStatus = result.Error.HTTPStatus
+		}
+		if !retryAt.IsZero() {
+			event.WaitMs = time.Until(retryAt).Milliseconds()
+		}
+		m.emitEvent(ctx, event)
+	} else if shouldResumeModel && wasUnavailable {
+		m.emitEvent(ctx, Event{Type: EventAuthState, Provider: result.Provider, AuthID: result.AuthID, Model: result.Model, State: "resumed"})
+	}
 
 	m.hook.OnResult(ctx, result)
 }
@@ -1438,6 +1559,14 @@ func hasModelError(auth *Auth, now time.Time) bool {
 	return false
 }
 
+// retryAfterSeconds rounds a provider retry hint up to whole seconds; 0 without a hint.
+func retryAfterSeconds(retryAfter *time.Duration) int64 {
+	if retryAfter == nil || *retryAfter <= 0 {
+		return 0
+	}
+	return int64((*retryAfter + time.Second - 1) / time.Second)
+}
+
 func clearAuthStateOnSuccess(auth *Auth, now time.Time) {
 	if auth == nil {
 		return
@@ -1560,6 +1689,7 @@ func applyAuthFailureState(auth *Auth, resultErr *Error, retryAfter *time.Durati
 			auth.Quota.BackoffLevel = nextLevel
 		}
 		auth.Quota.NextRecoverAt = next
+		auth.Quota.RetryAfterSeconds = retryAfterSeconds(retryAfter)
 		auth.NextRetryAfter = next
 	case 408, 500, 502, 503, 504:
 		auth.StatusMessage = "transient upstream error"
@@ -1668,6 +1798,10 @@ func (m *Manager) CloseExecutionSession(sessionID string) {
 
 func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, error) {
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
+	tenants := m.tenantFilter(opts.Metadata)
+	if window, ok := m.activeMaintenance(provider, m.clock()); ok && window.Drain {
+		return nil, nil, maintenanceDrainError()
+	}
 
 	m.mu.RLock()
 	executor, okExecutor := m.executors[provider]
@@ -1705,6 +1839,9 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		if pinnedAuthID != "" && candidate.ID != pinnedAuthID {
 			continue
 		}
+		if !tenants.allows(candidate) {
+			continue
+		}
 		if _, used := tried[candidate.ID]; used {
 			continue
 		}
@@ -1742,6 +1879,7 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 
 func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
+	tenants := m.tenantFilter(opts.Metadata)
 
 	providerSet := make(map[string]struct{}, len(providers))
 	for _, provider := range providers {
@@ -1754,6 +1892,8 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 	if len(providerSet) == 0 {
 		return nil, nil, "", &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
+	draining := m.drainingProviders(providerSet, m.clock())
+	drained := false
 
 	m.mu.RLock()
 	candidates := make([]*Auth, 0, len(m.auths))
@@ -1773,6 +1913,9 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		if pinnedAuthID != "" && candidate.ID != pinnedAuthID {
 			continue
 		}
+		if !tenants.allows(candidate) {
+			continue
+		}
 		providerKey := strings.TrimSpace(strings.ToLower(candidate.Provider))
 		if providerKey == "" {
 			continue
@@ -1789,10 +1932,17 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
 			continue
 		}
+		if _, ok := draining[providerKey]; ok {
+			drained = true
+			continue
+		}
 		candidates = append(candidates, candidate)
 	}
 	if len(candidates) == 0 {
 		m.mu.RUnlock()
+		if drained {
+			return nil, nil, "", maintenanceDrainError()
+		}
 		return nil, nil, "", &Error{Code: "auth_not_found", Message: "no auth available"}
 	}
 	selected, errPick := m.selector.Pick(ctx, "mixed", model, opts, candidates)
@@ -1881,7 +2031,7 @@ func (m *Manager) StopAutoRefresh() {
 
 func (m *Manager) checkRefreshes(ctx context.Context) {
 	// log.Debugf("checking refreshes")
-	now := time.Now()
+	now := m.clock()
 	snapshot := m.snapshotAuths()
 	for _, a := range snapshot {
 		typ, _ := a.AccountInfo()
@@ -1889,6 +2039,9 @@ func (m *Manager) checkRefreshes(ctx context.Context) {
 			if !m.shouldRefresh(a, now) {
 				continue
 			}
+			if m.refreshPausedForMaintenance(a.Provider, now) {
+				continue
+			}
 			log.Debugf("checking refresh for %s, %s, %s", a.Provider, a.ID, typ)
 
 			if exec := m.executorFor(a.Provider); exec == nil {
@@ -2211,6 +2364,62 @@ func (m *Manager) executorFor(provider string) ProviderExecutor {
 	return m.executors[provider]
 }
 
+// authRefreshedContextKey carries the callback executors use to hand back credentials
+// they refreshed in the middle of a request.
+type authRefreshedContextKey struct{}
+
+// NotifyAuthRefreshed tells the manager that ran the current request that an executor
+// refreshed auth's tokens itself, so the new tokens are stored instead of being lost with
+// the executor's copy. It is a no-op outside a manager-driven request.
+func NotifyAuthRefreshed(ctx context.Context, auth *Auth) {
+	if ctx == nil || auth == nil {
+		return
+	}
+	if fn, ok := ctx.Value(authRefreshedContextKey{}).(func(context.Context, *Auth)); ok && fn != nil {
+		fn(ctx, auth)
+	}
+}
+
+// currentAuthContextKey carries the lookup executors use to read the manager's current
+// copy of an auth in the middle of a request.
+type currentAuthContextKey struct{}
+
+// CurrentAuth returns the manager's current copy of the auth with id, which may hold
+// tokens refreshed since the request picked its own copy. It reports false outside a
+// manager-driven request or when the auth is gone.
+func CurrentAuth(ctx context.Context, id string) (*Auth, bool) {
+	if ctx == nil || id == "" {
+		return nil, false
+	}
+	if fn, ok := ctx.Value(currentAuthContextKey{}).(func(string) (*Auth, bool)); ok && fn != nil {
+		return fn(id)
+	}
+	return nil, false
+}
+
+// storeRefreshedAuth merges the metadata of an auth refreshed by an executor into the
+// stored auth. Only the metadata is taken so runtime state recorded meanwhile survives.
+func (m *Manager) storeRefreshedAuth(ctx context.Context, refreshed *Auth) {
+	if refreshed == nil || refreshed.ID == "" {
+		return
+	}
+	m.mu.RLock()
+	current := m.auths[refreshed.ID]
+	m.mu.RUnlock()
+	if current == nil {
+		return
+	}
+	updated := current.Clone()
+	updated.Metadata = make(map[string]any, len(refreshed.Metadata))
+	for k, v := range refreshed.Metadata {
+		updated.Metadata[k] = v
+	}
+	now := time.Now()
+	updated.LastRefreshedAt = now
+	updated.UpdatedAt = now
+	_, _ = m.Update(context.WithoutCancel(ctx), updated)
+}
+
 // roundTripperContextKey is an unexported context key type to avoid collisions.
 type roundTripperContextKey struct{}
 
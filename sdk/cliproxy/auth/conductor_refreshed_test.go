@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// refreshingExecutor refreshes the auth's tokens mid-request, like an executor retrying
+// after a 401.
+type refreshingExecutor struct {
+	mockProviderExecutor
+}
+
+func (e *refreshingExecutor) Execute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	refreshed := auth.Clone()
+	refreshed.Metadata = map[string]any{"access_token": "new-access", "refresh_token": "rotated-refresh"}
+	NotifyAuthRefreshed(ctx, refreshed)
+	return cliproxyexecutor.Response{}, nil
+}
+
+func TestManagerExecute_StoresTokensRefreshedByExecutor(t *testing.T) {
+	mgr := NewManager(nil, &mockSelector{}, NoopHook{})
+	mgr.RegisterExecutor(&refreshingExecutor{mockProviderExecutor{id: "codex"}})
+	if _, err := mgr.Register(context.Background(), &Auth{
+		ID:       "codex-oauth",
+		Provider: "codex",
+		Metadata: map[string]any{"access_token": "old-access", "refresh_token": "old-refresh"},
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient("codex-oauth", "codex", []*registry.ModelInfo{{ID: "gpt-5"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("codex-oauth") })
+
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{"forced_provider": true}}
+	if _, err := mgr.Execute(context.Background(), []string{"codex"}, cliproxyexecutor.Request{Model: "gpt-5"}, opts); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	stored, ok := mgr.GetByID("codex-oauth")
+	if !ok {
+		t.Fatal("auth missing after execute")
+	}
+	if got := stored.Metadata["refresh_token"]; got != "rotated-refresh" {
+		t.Fatalf("stored refresh_token = %v, want rotated-refresh", got)
+	}
+	if got := stored.Metadata["access_token"]; got != "new-access" {
+		t.Fatalf("stored access_token = %v, want new-access", got)
+	}
+	if stored.LastRefreshedAt.IsZero() {
+		t.Fatal("LastRefreshedAt not set")
+	}
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+// EventType names a dispatch decision reported through an EventEmitter.
+type EventType string
+
+const (
+	// EventAuthSelected fires when an auth is chosen for an attempt. Reason names the
+	// policy that chose it: "pinned", "round-robin", "fill-first" or "custom".
+	EventAuthSelected EventType = "auth_selected"
+	// EventRetry fires when a failed attempt is followed by another one. Reason is
+	// "next_auth" when another credential is tried immediately and "cooldown_wait" when
+	// the manager sleeps until a cooling credential recovers.
+	EventRetry EventType = "retry"
+	// EventProviderFallback fires when the next attempt moves to a different provider.
+	EventProviderFallback EventType = "provider_fallback"
+	// EventAuthState fires when an auth is suspended for a model after a failure, or
+	// resumed after a success; it is the manager's circuit-breaker transition.
+	EventAuthState EventType = "auth_state"
+)
+
+// Event is a structured record of a queue or retry decision taken while dispatching a
+// request. It never carries credential material.
+type Event struct {
+	Type       EventType `json:"type"`
+	Time       time.Time `json:"time"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Provider   string    `json:"provider,omitempty"`
+	AuthID     string    `json:"auth_id,omitempty"`
+	Model      string    `json:"model,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	Attempt    int       `json:"attempt,omitempty"`
+	ErrorClass string    `json:"error_class,omitempty"`
+	HTTPStatus int       `json:"http_status,omitempty"`
+	From       string    `json:"from,omitempty"`
+	To         string    `json:"to,omitempty"`
+	State      string    `json:"state,omitempty"`
+	WaitMs     int64     `json:"wait_ms,omitempty"`
+}
+
+// EventEmitter receives dispatch events. Emit is called on the request path, so
+// implementations must be safe for concurrent use and must not block.
+type EventEmitter interface {
+	Emit(ctx context.Context, event Event)
+}
+
+// NoopEventEmitter discards every event. It is the manager default.
+type NoopEventEmitter struct{}
+
+// Emit implements EventEmitter.
+func (NoopEventEmitter) Emit(context.Context, Event) {}
+
+type eventEmitterHolder struct {
+	emitter EventEmitter
+}
+
+// SetEventEmitter installs the emitter that receives dispatch events. Pass nil to
+// restore the no-op default.
+func (m *Manager) SetEventEmitter(emitter EventEmitter) {
+	if m == nil {
+		return
+	}
+	if emitter == nil {
+		emitter = NoopEventEmitter{}
+	}
+	m.eventEmitter.Store(eventEmitterHolder{emitter: emitter})
+}
+
+func (m *Manager) emitEvent(ctx context.Context, event Event) {
+	if m == nil {
+		return
+	}
+	holder, _ := m.eventEmitter.Load().(eventEmitterHolder)
+	if holder.emitter == nil {
+		return
+	}
+	if _, noop := holder.emitter.(NoopEventEmitter); noop {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+	if event.RequestID == "" && ctx != nil {
+		event.RequestID = logging.GetRequestID(ctx)
+	}
+	holder.emitter.Emit(ctx, event)
+}
+
+// emitSelection reports the auth picked for an attempt and, when the previous attempt
+// failed on another provider, the provider switch.
+func (m *Manager) emitSelection(ctx context.Context, auth *Auth, provider, model string, pinned bool, attempt int, previousProvider string) {
+	if auth == nil {
+		return
+	}
+	if previousProvider != "" && previousProvider != provider {
+		m.emitEvent(ctx, Event{
+			Type:     EventProviderFallback,
+			Model:    model,
+			Attempt:  attempt,
+			From:     previousProvider,
+			To:       provider,
+			Provider: provider,
+		})
+	}
+	reason := "pinned"
+	if !pinned {
+		m.mu.RLock()
+		reason = selectionPolicy(m.selector)
+		m.mu.RUnlock()
+	}
+	m.emitEvent(ctx, Event{
+		Type:     EventAuthSelected,
+		Provider: provider,
+		AuthID:   auth.ID,
+		Model:    model,
+		Reason:   reason,
+		Attempt:  attempt,
+	})
+}
+
+// emitRetry reports that a failed attempt will be followed by another one.
+func (m *Manager) emitRetry(ctx context.Context, provider, authID, model, reason string, attempt int, err error, wait time.Duration) {
+	m.emitEvent(ctx, Event{
+		Type:       EventRetry,
+		Provider:   provider,
+		AuthID:     authID,
+		Model:      model,
+		Reason:     reason,
+		Attempt:    attempt,
+		ErrorClass: errorClass(err),
+		HTTPStatus: statusCodeFromError(err),
+		WaitMs:     wait.Milliseconds(),
+	})
+}
+
+func selectionPolicy(selector Selector) string {
+	switch selector.(type) {
+	case *RoundRobinSelector:
+		return "round-robin"
+	case *FillFirstSelector:
+		return "fill-first"
+	default:
+		return "custom"
+	}
+}
+
+// errorClass buckets an execution error for dispatch events.
+func errorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+	if isRequestInvalidError(err) {
+		return "invalid_request"
+	}
+	switch status := statusCodeFromError(err); {
+	case status == 0:
+		return "transport"
+	case status == http.StatusUnauthorized:
+		return "unauthorized"
+	case status == http.StatusPaymentRequired || status == http.StatusForbidden:
+		return "forbidden"
+	case status == http.StatusNotFound:
+		return "not_found"
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return "timeout"
+	case status == http.StatusTooManyRequests:
+		return "rate_limited"
+	case status >= 500:
+		return "upstream_error"
+	default:
+		return "client_error"
+	}
+}
+
+// EventBroadcaster is an EventEmitter that fans events out to live subscribers, such
+// as the management event stream. Slow subscribers miss events instead of blocking
+// request dispatch.
+type EventBroadcaster struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   map[int]chan Event
+}
+
+// NewEventBroadcaster returns a broadcaster with no subscribers.
+func NewEventBroadcaster() *EventBroadcaster {
+	return &EventBroadcaster{subs: make(map[int]chan Event)}
+}
+
+// Emit implements EventEmitter.
+func (b *EventBroadcaster) Emit(_ context.Context, event Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a subscriber buffering up to buffer events. The returned cancel
+// function unregisters it and closes the channel.
+func (b *EventBroadcaster) Subscribe(buffer int) (<-chan Event, func()) {
+	if buffer <= 0 {
+		buffer = 64
+	}
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = ch
+	b.mu.Unlock()
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, id)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// scriptedExecutor fails every request with the configured status, or succeeds when it is zero.
+type scriptedExecutor struct {
+	mockProviderExecutor
+	status int
+}
+
+func (e *scriptedExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if e.status != 0 {
+		return cliproxyexecutor.Response{}, &Error{Code: "scripted", Message: "scripted failure", HTTPStatus: e.status}
+	}
+	return cliproxyexecutor.Response{Payload: []byte(`{}`)}, nil
+}
+
+func TestExecute_EmitsDispatchEventSequence(t *testing.T) {
+	mgr := NewManager(nil, &FillFirstSelector{}, NoopHook{})
+	mgr.RegisterExecutor(&scriptedExecutor{mockProviderExecutor: mockProviderExecutor{id: "events-flaky"}, status: http.StatusTooManyRequests})
+	mgr.RegisterExecutor(&scriptedExecutor{mockProviderExecutor: mockProviderExecutor{id: "events-stable"}})
+	for _, a := range []*Auth{
+		{ID: "events-a-flaky", Provider: "events-flaky"},
+		{ID: "events-b-stable", Provider: "events-stable"},
+	} {
+		if _, err := mgr.Register(context.Background(), a); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(a.ID, a.Provider, []*registry.ModelInfo{{ID: "events-model"}})
+		id := a.ID
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+
+	hub := NewEventBroadcaster()
+	mgr.SetEventEmitter(hub)
+	events, cancel := hub.Subscribe(32)
+
+	ctx := logging.WithRequestID(context.Background(), "req-events")
+	if _, err := mgr.Execute(ctx, []string{"events-flaky", "events-stable"}, cliproxyexecutor.Request{Model: "events-model"}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	cancel()
+
+	var got []Event
+	for ev := range events {
+		got = append(got, ev)
+	}
+	want := []Event{
+		{Type: EventAuthSelected, AuthID: "events-a-flaky", Provider: "events-flaky", Reason: "fill-first", Attempt: 1},
+		{Type: EventAuthState, AuthID: "events-a-flaky", State: "suspended", Reason: "quota", HTTPStatus: http.StatusTooManyRequests},
+		{Type: EventRetry, AuthID: "events-a-flaky", Reason: "next_auth", Attempt: 2, ErrorClass: "rate_limited", HTTPStatus: http.StatusTooManyRequests},
+		{Type: EventProviderFallback, From: "events-flaky", To: "events-stable", Attempt: 2},
+		{Type: EventAuthSelected, AuthID: "events-b-stable", Provider: "events-stable", Reason: "fill-first", Attempt: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("events = %+v, want %d events", got, len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Type != w.Type || g.Reason != w.Reason || g.Attempt != w.Attempt || g.ErrorClass != w.ErrorClass ||
+			g.HTTPStatus != w.HTTPStatus || g.From != w.From || g.To != w.To || g.State != w.State {
+			t.Fatalf("event %d = %+v, want %+v", i, g, w)
+		}
+		if w.AuthID != "" && g.AuthID != w.AuthID {
+			t.Fatalf("event %d auth = %q, want %q", i, g.AuthID, w.AuthID)
+		}
+		if g.RequestID != "req-events" || g.Model != "events-model" || g.Time.IsZero() {
+			t.Fatalf("event %d missing request context: %+v", i, g)
+		}
+	}
+}
+
+func TestExecute_PinnedSelectionReason(t *testing.T) {
+	mgr := NewManager(nil, nil, nil)
+	mgr.RegisterExecutor(&scriptedExecutor{mockProviderExecutor: mockProviderExecutor{id: "events-pin"}})
+	if _, err := mgr.Register(context.Background(), &Auth{ID: "events-pinned", Provider: "events-pin"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient("events-pinned", "events-pin", []*registry.ModelInfo{{ID: "events-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("events-pinned") })
+
+	hub := NewEventBroadcaster()
+	mgr.SetEventEmitter(hub)
+	events, cancel := hub.Subscribe(8)
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.PinnedAuthMetadataKey: "events-pinned"}}
+	if _, err := mgr.Execute(context.Background(), []string{"events-pin"}, cliproxyexecutor.Request{Model: "events-model"}, opts); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	cancel()
+	ev, ok := <-events
+	if !ok || ev.Type != EventAuthSelected || ev.Reason != "pinned" {
+		t.Fatalf("first event = %+v, want pinned selection", ev)
+	}
+}
@@ -0,0 +1,379 @@
+package auth
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// MaintenanceSourceConfig marks windows derived from the maintenance-windows config block.
+	MaintenanceSourceConfig = "config"
+	// MaintenanceSourceManual marks windows added through the management API.
+	MaintenanceSourceManual = "manual"
+
+	maxMaintenanceDuration = 7 * 24 * time.Hour
+)
+
+// MaintenanceWindow is a concrete period during which background refreshes for a
+// provider are paused. When Drain is set, user requests for the provider are rejected too.
+type MaintenanceWindow struct {
+	ID       string    `json:"id"`
+	Provider string    `json:"provider"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Drain    bool      `json:"drain"`
+	Source   string    `json:"source"`
+	Reason   string    `json:"reason,omitempty"`
+	Active   bool      `json:"active"`
+}
+
+// maintenanceState holds configured schedules and manually added windows.
+type maintenanceState struct {
+	mu        sync.RWMutex
+	scheduled []scheduledMaintenance
+	manual    []MaintenanceWindow
+	announced map[string]struct{}
+	now       func() time.Time
+}
+
+type scheduledMaintenance struct {
+	provider string
+	schedule *cronSchedule
+	raw      string
+	duration time.Duration
+	drain    bool
+}
+
+func (m *Manager) clock() time.Time {
+	m.maintenance.mu.RLock()
+	now := m.maintenance.now
+	m.maintenance.mu.RUnlock()
+	if now != nil {
+		return now()
+	}
+	return time.Now()
+}
+
+func (m *Manager) applyMaintenanceConfig(cfg *internalconfig.Config) {
+	var scheduled []scheduledMaintenance
+	if cfg != nil {
+		for i, entry := range cfg.MaintenanceWindows {
+			provider := strings.ToLower(strings.TrimSpace(entry.Provider))
+			if provider == "" {
+				log.Warnf("maintenance-windows[%d]: provider is required; entry ignored", i)
+				continue
+			}
+			schedule, errSchedule := parseCronSchedule(entry.Schedule)
+			if errSchedule != nil {
+				log.Warnf("maintenance-windows[%d]: invalid schedule %q: %v; entry ignored", i, entry.Schedule, errSchedule)
+				continue
+			}
+			duration, errDuration := time.ParseDuration(strings.TrimSpace(entry.Duration))
+			if errDuration != nil || duration <= 0 || duration > maxMaintenanceDuration {
+				log.Warnf("maintenance-windows[%d]: invalid duration %q (expected a positive duration up to 168h); entry ignored", i, entry.Duration)
+				continue
+			}
+			scheduled = append(scheduled, scheduledMaintenance{
+				provider: provider,
+				schedule: schedule,
+				raw:      strings.TrimSpace(entry.Schedule),
+				duration: duration,
+				drain:    entry.Drain,
+			})
+		}
+	}
+	m.maintenance.mu.Lock()
+	m.maintenance.scheduled = scheduled
+	m.maintenance.mu.Unlock()
+}
+
+// AddMaintenanceWindow registers a one-off maintenance window. A zero Start means now.
+func (m *Manager) AddMaintenanceWindow(window MaintenanceWindow) (MaintenanceWindow, error) {
+	if m == nil {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance: manager unavailable")
+	}
+	window.Provider = strings.ToLower(strings.TrimSpace(window.Provider))
+	if window.Provider == "" {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance: provider is required")
+	}
+	if window.Start.IsZero() {
+		window.Start = m.clock()
+	}
+	if !window.End.After(window.Start) {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance: end must be after start")
+	}
+	if window.End.Sub(window.Start) > maxMaintenanceDuration {
+		return MaintenanceWindow{}, fmt.Errorf("maintenance: window longer than %s", maxMaintenanceDuration)
+	}
+	window.ID = uuid.NewString()
+	window.Source = MaintenanceSourceManual
+	window.Reason = strings.TrimSpace(window.Reason)
+	window.Active = false
+
+	m.maintenance.mu.Lock()
+	m.maintenance.manual = append(m.maintenance.manual, window)
+	m.maintenance.mu.Unlock()
+	log.Infof("maintenance: window %s scheduled for provider %s from %s to %s (drain=%t)", window.ID, window.Provider, window.Start.UTC().Format(time.RFC3339), window.End.UTC().Format(time.RFC3339), window.Drain)
+	return window, nil
+}
+
+// RemoveMaintenanceWindow removes a manually added window. It reports whether the window existed.
+func (m *Manager) RemoveMaintenanceWindow(id string) bool {
+	if m == nil {
+		return false
+	}
+	id = strings.TrimSpace(id)
+	m.maintenance.mu.Lock()
+	defer m.maintenance.mu.Unlock()
+	for i, window := range m.maintenance.manual {
+		if window.ID == id {
+			m.maintenance.manual = append(m.maintenance.manual[:i], m.maintenance.manual[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// MaintenanceWindows lists active windows (configured or manual) plus upcoming manual
+// windows, ordered by start time. Expired manual windows are pruned.
+func (m *Manager) MaintenanceWindows() []MaintenanceWindow {
+	if m == nil {
+		return nil
+	}
+	now := m.clock()
+
+	m.maintenance.mu.Lock()
+	kept := m.maintenance.manual[:0]
+	for _, window := range m.maintenance.manual {
+		if now.Before(window.End) {
+			kept = append(kept, window)
+		}
+	}
+	m.maintenance.manual = kept
+	out := make([]MaintenanceWindow, 0, len(kept)+len(m.maintenance.scheduled))
+	for _, window := range kept {
+		window.Active = !now.Before(window.Start)
+		out = append(out, window)
+	}
+	for _, entry := range m.maintenance.scheduled {
+		if window, ok := entry.activeWindow(now); ok {
+			out = append(out, window)
+		}
+	}
+	m.maintenance.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// activeMaintenance returns the active window for provider at now, preferring draining windows.
+func (m *Manager) activeMaintenance(provider string, now time.Time) (MaintenanceWindow, bool) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	m.maintenance.mu.RLock()
+	defer m.maintenance.mu.RUnlock()
+
+	var found MaintenanceWindow
+	ok := false
+	consider := func(window MaintenanceWindow) {
+		if !ok || (window.Drain && !found.Drain) {
+			found, ok = window, true
+		}
+	}
+	for _, window := range m.maintenance.manual {
+		if window.Provider == provider && !now.Before(window.Start) && now.Before(window.End) {
+			window.Active = true
+			consider(window)
+		}
+	}
+	for _, entry := range m.maintenance.scheduled {
+		if entry.provider != provider {
+			continue
+		}
+		if window, active := entry.activeWindow(now); active {
+			consider(window)
+		}
+	}
+	return found, ok
+}
+
+// refreshPausedForMaintenance reports whether background refreshes for provider are paused,
+// logging once per window so a long window does not flood the logs.
+func (m *Manager) refreshPausedForMaintenance(provider string, now time.Time) bool {
+	window, ok := m.activeMaintenance(provider, now)
+	if !ok {
+		return false
+	}
+	m.maintenance.mu.Lock()
+	if m.maintenance.announced == nil {
+		m.maintenance.announced = make(map[string]struct{})
+	}
+	_, announced := m.maintenance.announced[window.ID]
+	m.maintenance.announced[window.ID] = struct{}{}
+	m.maintenance.mu.Unlock()
+	if !announced {
+		log.Infof("maintenance: pausing background refresh for provider %s until %s", window.Provider, window.End.UTC().Format(time.RFC3339))
+	}
+	return true
+}
+
+// drainingProviders returns the providers in providers that are currently draining.
+func (m *Manager) drainingProviders(providers map[string]struct{}, now time.Time) map[string]struct{} {
+	var out map[string]struct{}
+	for provider := range providers {
+		if window, ok := m.activeMaintenance(provider, now); ok && window.Drain {
+			if out == nil {
+				out = make(map[string]struct{})
+			}
+			out[provider] = struct{}{}
+		}
+	}
+	return out
+}
+
+func maintenanceDrainError() *Error {
+	return &Error{Code: "provider_maintenance", Message: "provider is in a maintenance window", Retryable: true, HTTPStatus: 503}
+}
+
+// activeWindow returns the occurrence covering now, if any: the latest start within the
+// window duration before now.
+func (s scheduledMaintenance) activeWindow(now time.Time) (MaintenanceWindow, bool) {
+	if s.schedule == nil {
+		return MaintenanceWindow{}, false
+	}
+	start, ok := s.schedule.latestStart(now.UTC(), now.UTC().Add(-s.duration))
+	if !ok {
+		return MaintenanceWindow{}, false
+	}
+	return MaintenanceWindow{
+		ID:       "config:" + s.provider + ":" + strconv.FormatInt(start.Unix(), 10),
+		Provider: s.provider,
+		Start:    start,
+		End:      start.Add(s.duration),
+		Drain:    s.drain,
+		Source:   MaintenanceSourceConfig,
+		Reason:   s.raw,
+		Active:   true,
+	}, true
+}
+
+// cronSchedule is a minimal five-field cron matcher supporting "*", lists, ranges, and steps.
+type cronSchedule struct {
+	minute, hour, dom, month, dow []bool
+	domAny, dowAny                bool
+}
+
+func parseCronSchedule(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(fields))
+	}
+	var err error
+	s := &cronSchedule{domAny: fields[2] == "*", dowAny: fields[4] == "*"}
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day-of-month: %w", err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day-of-week: %w", err)
+	}
+	if s.dow[7] {
+		s.dow[0] = true
+	}
+	return s, nil
+}
+
+func parseCronField(field string, minValue, maxValue int) ([]bool, error) {
+	set := make([]bool, maxValue+1)
+	for _, part := range strings.Split(field, ",") {
+		step, stepped := 1, false
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			step, stepped = n, true
+			part = part[:idx]
+		}
+		lo, hi := minValue, maxValue
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			a, errA := strconv.Atoi(bounds[0])
+			b, errB := strconv.Atoi(bounds[1])
+			if errA != nil || errB != nil || a > b {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+			lo, hi = a, b
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = n, n
+			if stepped {
+				// "N/step" runs from N to the end of the range, as in standard cron.
+				hi = maxValue
+			}
+		}
+		if lo < minValue || hi > maxValue {
+			return nil, fmt.Errorf("value out of range in %q", part)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+func (s *cronSchedule) matches(t time.Time) bool {
+	return s.minute[t.Minute()] && s.hour[t.Hour()] && s.dayMatches(t)
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	if !s.month[int(t.Month())] {
+		return false
+	}
+	domMatch := s.dom[t.Day()]
+	dowMatch := s.dow[int(t.Weekday())]
+	// Standard cron semantics: when both day fields are restricted, either may match.
+	if !s.domAny && !s.dowAny {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
+
+// latestStart returns the latest minute at or before t and after earliest on which the
+// schedule fires. Days and hours that cannot match are skipped whole, so a week-long
+// range takes a few hundred steps instead of one per minute.
+func (s *cronSchedule) latestStart(t, earliest time.Time) (time.Time, bool) {
+	for t = t.Truncate(time.Minute); t.After(earliest); {
+		switch {
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+		case !s.hour[t.Hour()]:
+			t = t.Truncate(time.Hour).Add(-time.Minute)
+		case s.minute[t.Minute()]:
+			return t, true
+		default:
+			t = t.Add(-time.Minute)
+		}
+	}
+	return time.Time{}, false
+}
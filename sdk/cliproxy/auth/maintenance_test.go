@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type refreshCountingExecutor struct {
+	mockProviderExecutor
+	refreshed chan string
+}
+
+func (e *refreshCountingExecutor) Refresh(_ context.Context, auth *Auth) (*Auth, error) {
+	e.refreshed <- auth.ID
+	return auth, nil
+}
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
+
+func newMaintenanceTestManager(t *testing.T, clock *fakeClock, cfg *internalconfig.Config) (*Manager, *refreshCountingExecutor) {
+	t.Helper()
+	mgr := NewManager(nil, &mockSelector{}, NoopHook{})
+	mgr.maintenance.now = clock.Now
+	mgr.SetConfig(cfg)
+	exec := &refreshCountingExecutor{mockProviderExecutor: mockProviderExecutor{id: "copilot"}, refreshed: make(chan string, 4)}
+	mgr.RegisterExecutor(exec)
+	if _, err := mgr.Register(context.Background(), &Auth{
+		ID:       "copilot-maint-1",
+		Provider: "copilot",
+		Metadata: map[string]any{"refresh_interval_seconds": 60},
+	}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	return mgr, exec
+}
+
+func expectRefresh(t *testing.T, exec *refreshCountingExecutor, want bool) {
+	t.Helper()
+	select {
+	case id := <-exec.refreshed:
+		if !want {
+			t.Fatalf("unexpected refresh of %s during maintenance window", id)
+		}
+	case <-time.After(100 * time.Millisecond):
+		if want {
+			t.Fatal("expected a refresh after the maintenance window ended")
+		}
+	}
+}
+
+func TestCheckRefreshes_SkipsDuringScheduledMaintenanceWindow(t *testing.T) {
+	// Sunday 2026-01-04 03:30 UTC, inside a Sunday 03:00 + 1h window.
+	clock := &fakeClock{now: time.Date(2026, 1, 4, 3, 30, 0, 0, time.UTC)}
+	mgr, exec := newMaintenanceTestManager(t, clock, &internalconfig.Config{
+		MaintenanceWindows: []internalconfig.MaintenanceWindow{{Provider: "copilot", Schedule: "0 3 * * 0", Duration: "1h"}},
+	})
+
+	mgr.checkRefreshes(context.Background())
+	expectRefresh(t, exec, false)
+
+	windows := mgr.MaintenanceWindows()
+	if len(windows) != 1 || !windows[0].Active || windows[0].Source != MaintenanceSourceConfig {
+		t.Fatalf("MaintenanceWindows() = %+v, want one active config window", windows)
+	}
+	if !windows[0].Start.Equal(time.Date(2026, 1, 4, 3, 0, 0, 0, time.UTC)) {
+		t.Fatalf("window start = %v", windows[0].Start)
+	}
+
+	clock.Set(time.Date(2026, 1, 4, 4, 1, 0, 0, time.UTC))
+	mgr.checkRefreshes(context.Background())
+	expectRefresh(t, exec, true)
+	if windows = mgr.MaintenanceWindows(); len(windows) != 0 {
+		t.Fatalf("expected no active windows after the window, got %+v", windows)
+	}
+}
+
+func TestCheckRefreshes_ManualWindowResumesAutomatically(t *testing.T) {
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	mgr, exec := newMaintenanceTestManager(t, clock, &internalconfig.Config{})
+
+	if _, err := mgr.AddMaintenanceWindow(MaintenanceWindow{Provider: "Copilot", End: start.Add(30 * time.Minute)}); err != nil {
+		t.Fatalf("AddMaintenanceWindow: %v", err)
+	}
+	if _, err := mgr.AddMaintenanceWindow(MaintenanceWindow{Provider: "copilot", Start: start, End: start}); err == nil {
+		t.Fatal("expected an error for an empty window")
+	}
+
+	mgr.checkRefreshes(context.Background())
+	expectRefresh(t, exec, false)
+
+	clock.Set(start.Add(31 * time.Minute))
+	mgr.checkRefreshes(context.Background())
+	expectRefresh(t, exec, true)
+}
+
+func TestExecute_DrainingWindowRejectsUserTraffic(t *testing.T) {
+	start := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	mgr, _ := newMaintenanceTestManager(t, clock, &internalconfig.Config{})
+	registry.GetGlobalRegistry().RegisterClient("copilot-maint-1", "copilot", []*registry.ModelInfo{{ID: "gpt-5"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("copilot-maint-1") })
+
+	paused, err := mgr.AddMaintenanceWindow(MaintenanceWindow{Provider: "copilot", End: start.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("AddMaintenanceWindow: %v", err)
+	}
+	if _, err = mgr.Execute(context.Background(), []string{"copilot"}, cliproxyexecutor.Request{Model: "gpt-5"}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("non-draining window should keep user traffic working: %v", err)
+	}
+
+	mgr.RemoveMaintenanceWindow(paused.ID)
+	if _, err = mgr.AddMaintenanceWindow(MaintenanceWindow{Provider: "copilot", End: start.Add(time.Hour), Drain: true}); err != nil {
+		t.Fatalf("AddMaintenanceWindow: %v", err)
+	}
+	_, err = mgr.Execute(context.Background(), []string{"copilot"}, cliproxyexecutor.Request{Model: "gpt-5"}, cliproxyexecutor.Options{})
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.Code != "provider_maintenance" || authErr.HTTPStatus != 503 {
+		t.Fatalf("Execute error = %v, want provider_maintenance 503", err)
+	}
+
+	clock.Set(start.Add(2 * time.Hour))
+	if _, err = mgr.Execute(context.Background(), []string{"copilot"}, cliproxyexecutor.Request{Model: "gpt-5"}, cliproxyexecutor.Options{}); err != nil {
+		t.Fatalf("traffic should resume after the draining window: %v", err)
+	}
+}
+
+func TestParseCronSchedule(t *testing.T) {
+	schedule, err := parseCronSchedule("*/15 2-4 * * 1,3")
+	if err != nil {
+		t.Fatalf("parseCronSchedule: %v", err)
+	}
+	// 2026-01-05 is a Monday.
+	if !schedule.matches(time.Date(2026, 1, 5, 3, 45, 0, 0, time.UTC)) {
+		t.Fatal("expected Monday 03:45 to match")
+	}
+	if schedule.matches(time.Date(2026, 1, 5, 3, 40, 0, 0, time.UTC)) {
+		t.Fatal("expected Monday 03:40 not to match")
+	}
+	if schedule.matches(time.Date(2026, 1, 6, 3, 45, 0, 0, time.UTC)) {
+		t.Fatal("expected Tuesday not to match")
+	}
+
+	// "N/step" runs from N to the end of the range.
+	schedule, err = parseCronSchedule("5/20 * * * *")
+	if err != nil {
+		t.Fatalf("parseCronSchedule: %v", err)
+	}
+	for minute, want := range map[int]bool{5: true, 25: true, 45: true, 0: false, 20: false, 50: false} {
+		if got := schedule.matches(time.Date(2026, 1, 5, 3, minute, 0, 0, time.UTC)); got != want {
+			t.Fatalf("5/20 at minute %d = %t, want %t", minute, got, want)
+		}
+	}
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *"} {
+		if _, err = parseCronSchedule(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestScheduledMaintenance_ActiveWindowMatchesMinuteScan(t *testing.T) {
+	now := time.Date(2026, 1, 7, 10, 17, 30, 0, time.UTC) // a Wednesday
+	for _, tc := range []struct {
+		expr     string
+		duration time.Duration
+	}{
+		{"0 3 * * 0", 168 * time.Hour},
+		{"*/15 2-4 * * 1,3", 8 * time.Hour},
+		{"30 23 1 * *", 168 * time.Hour},
+		{"5/20 10 * * *", 30 * time.Minute},
+		{"0 0 29 2 *", 168 * time.Hour},
+	} {
+		schedule, err := parseCronSchedule(tc.expr)
+		if err != nil {
+			t.Fatalf("parseCronSchedule(%q): %v", tc.expr, err)
+		}
+		var want time.Time
+		for start := now.Truncate(time.Minute); start.After(now.Add(-tc.duration)); start = start.Add(-time.Minute) {
+			if schedule.matches(start) {
+				want = start
+				break
+			}
+		}
+		entry := scheduledMaintenance{provider: "copilot", schedule: schedule, duration: tc.duration}
+		window, ok := entry.activeWindow(now)
+		if ok != !want.IsZero() || (ok && !window.Start.Equal(want)) {
+			t.Fatalf("%q: activeWindow = %v, %t; want start %v", tc.expr, window.Start, ok, want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// tenantFilter restricts candidate auths to the tenant of the request.
+type tenantFilter struct {
+	cfg     *internalconfig.Config
+	tenant  string
+	enabled bool
+}
+
+// tenantFilter builds the filter for a request carrying meta. When no tenant is
+// configured every auth is allowed, as before tenancy existed.
+func (m *Manager) tenantFilter(meta map[string]any) tenantFilter {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.TenantsEnabled() {
+		return tenantFilter{}
+	}
+	return tenantFilter{cfg: cfg, tenant: tenantFromMetadata(meta), enabled: true}
+}
+
+// allows reports whether auth belongs to the request tenant. Untenanted requests may
+// only use untenanted auths, so isolation also holds across provider fallback.
+func (f tenantFilter) allows(auth *Auth) bool {
+	if !f.enabled {
+		return true
+	}
+	return authTenant(f.cfg, auth) == f.tenant
+}
+
+// AuthTenant returns the tenant owning auth, or "" when it is untenanted or no tenant is configured.
+func (m *Manager) AuthTenant(auth *Auth) string {
+	if m == nil {
+		return ""
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.TenantsEnabled() {
+		return ""
+	}
+	return authTenant(cfg, auth)
+}
+
+func authTenant(cfg *internalconfig.Config, auth *Auth) string {
+	if auth == nil {
+		return ""
+	}
+	explicit := ""
+	if auth.Attributes != nil {
+		explicit = auth.Attributes[internalconfig.TenantMetadataKey]
+	}
+	if explicit == "" && auth.Metadata != nil {
+		explicit, _ = auth.Metadata[internalconfig.TenantMetadataKey].(string)
+	}
+	return cfg.TenantForAuth(explicit, auth.FileName, auth.ID)
+}
+
+func tenantFromMetadata(meta map[string]any) string {
+	if len(meta) == 0 {
+		return ""
+	}
+	tenant, _ := meta[cliproxyexecutor.TenantMetadataKey].(string)
+	return strings.TrimSpace(tenant)
+}
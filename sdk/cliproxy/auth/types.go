@@ -75,6 +75,9 @@ type QuotaState struct {
 	NextRecoverAt time.Time `json:"next_recover_at"`
 	// BackoffLevel stores the progressive cooldown exponent used for rate limits.
 	BackoffLevel int `json:"backoff_level,omitempty"`
+	// RetryAfterSeconds is the provider supplied retry hint of the last 429, rounded up;
+	// 0 when the cooldown came from the local backoff instead.
+	RetryAfterSeconds int64 `json:"retry_after_seconds,omitempty"`
 }
 
 // ModelState captures the execution state for a specific model under an auth entry.
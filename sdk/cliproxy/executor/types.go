@@ -23,6 +23,10 @@ const (
 	SelectedAuthCallbackMetadataKey = "selected_auth_callback"
 	// ExecutionSessionMetadataKey identifies a long-lived downstream execution session.
 	ExecutionSessionMetadataKey = "execution_session_id"
+	// TenantMetadataKey carries the caller's tenant; only auths of that tenant may serve the request.
+	TenantMetadataKey = "tenant_id"
+	// ExcludedAuthsMetadataKey lists auth IDs ([]string) the scheduler must not select.
+	ExcludedAuthsMetadataKey = "excluded_auth_ids"
 )
 
 // Request encapsulates the translated payload that will be sent to a provider executor.
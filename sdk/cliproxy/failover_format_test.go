@@ -0,0 +1,101 @@
+package cliproxy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+// TestFailover_RetranslatesPerProviderAndKeepsClientFormat fails an OpenAI chat request over
+// from a Responses-API Codex upstream to a Chat Completions upstream. Each upstream must
+// receive its own native format and the client must still get a chat completion.
+func TestFailover_RetranslatesPerProviderAndKeepsClientFormat(t *testing.T) {
+	var mu sync.Mutex
+	bodies := make(map[string][]byte)
+	record := func(name string, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies[name] = raw
+		mu.Unlock()
+	}
+	codexServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record("codex", r)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":{"message":"overloaded"}}`))
+	}))
+	defer codexServer.Close()
+	chatServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record("chat", r)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","created":1,"model":"failover-model","choices":[{"index":0,"message":{"role":"assistant","content":"hello"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`))
+	}))
+	defer chatServer.Close()
+
+	cfg := &config.Config{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.SetConfig(cfg)
+	manager.RegisterExecutor(executor.NewCodexExecutor(cfg))
+	manager.RegisterExecutor(executor.NewOpenAICompatExecutor("failover-chat", cfg))
+	auths := []*coreauth.Auth{
+		{ID: "failover-codex", Provider: "codex", Attributes: map[string]string{"api_key": "k1", "base_url": codexServer.URL}},
+		{ID: "failover-chat", Provider: "failover-chat", Attributes: map[string]string{"api_key": "k2", "base_url": chatServer.URL}},
+	}
+	for _, auth := range auths {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "failover-model"}})
+		id := auth.ID
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+	}
+
+	// Round-robin may start on either upstream; within two requests one of them starts on
+	// Codex and has to fail over.
+	payload := []byte(`{"model":"failover-model","messages":[{"role":"user","content":"hi"}]}`)
+	var resp cliproxyexecutor.Response
+	for i := 0; i < 2; i++ {
+		mu.Lock()
+		_, codexTried := bodies["codex"]
+		mu.Unlock()
+		if codexTried {
+			break
+		}
+		var err error
+		resp, err = manager.Execute(context.Background(), []string{"codex", "failover-chat"},
+			cliproxyexecutor.Request{Model: "failover-model", Payload: payload},
+			cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload})
+		if err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if codexBody := bodies["codex"]; !gjson.GetBytes(codexBody, "input").Exists() || gjson.GetBytes(codexBody, "messages").Exists() {
+		t.Fatalf("codex upstream did not get a Responses request: %s", codexBody)
+	}
+	if chatBody := bodies["chat"]; !gjson.GetBytes(chatBody, "messages").Exists() || gjson.GetBytes(chatBody, "input").Exists() {
+		t.Fatalf("chat upstream did not get a Chat Completions request: %s", chatBody)
+	}
+	if got := gjson.GetBytes(resp.Payload, "object").String(); got != "chat.completion" {
+		t.Fatalf("client response object = %q, want chat.completion: %s", got, resp.Payload)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "hello" {
+		t.Fatalf("client response content = %q: %s", got, resp.Payload)
+	}
+	if string(payload) != `{"model":"failover-model","messages":[{"role":"user","content":"hi"}]}` {
+		t.Fatalf("client payload mutated during failover: %s", payload)
+	}
+}
@@ -0,0 +1,100 @@
+package cliproxy
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/persistence"
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// usagePersistInterval controls how often usage statistics are written to the backend.
+const usagePersistInterval = 5 * time.Minute
+
+// budgetSpendNamespace and budgetSpendKey locate the budget monitor's spend store, kept as
+// a cache entry so it survives restarts whether or not usage statistics are enabled.
+const (
+	budgetSpendNamespace = "usage-budget"
+	budgetSpendKey       = "spend"
+)
+
+// startPersistence opens the configured persistence backend, restores usage statistics
+// from it and keeps them saved until ctx is done. A backend that fails to open is logged
+// and the service continues with in-memory state only.
+func (s *Service) startPersistence(ctx context.Context) {
+	backend, err := persistence.Open(ctx, s.cfg, filepath.Dir(s.configPath))
+	if err != nil {
+		log.Errorf("persistence disabled: %v", err)
+		return
+	}
+	if backend == nil {
+		return
+	}
+	s.persistence = backend
+	log.Infof("persistence enabled (%s)", s.cfg.Persistence)
+
+	if snapshot, found, errLoad := backend.LoadUsage(ctx); errLoad != nil {
+		log.Warnf("persistence: failed to restore usage statistics: %v", errLoad)
+	} else if found {
+		result := internalusage.GetRequestStatistics().MergeSnapshot(snapshot)
+		log.Debugf("persistence: restored usage statistics (added %d, skipped %d)", result.Added, result.Skipped)
+	}
+	if entry, found, errLoad := backend.GetCache(ctx, budgetSpendNamespace, budgetSpendKey); errLoad != nil {
+		log.Warnf("persistence: failed to restore budget spend: %v", errLoad)
+	} else if found {
+		var snapshot internalusage.StatisticsSnapshot
+		if errJSON := json.Unmarshal([]byte(entry.Value), &snapshot); errJSON != nil {
+			log.Warnf("persistence: failed to decode budget spend: %v", errJSON)
+		} else {
+			result := internalusage.GetBudgetMonitor().RestoreSpend(snapshot)
+			log.Debugf("persistence: restored budget spend (added %d, skipped %d)", result.Added, result.Skipped)
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(usagePersistInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.saveUsage(ctx)
+			}
+		}
+	}()
+}
+
+// saveUsage writes the current usage statistics and budget spend to the persistence backend.
+func (s *Service) saveUsage(ctx context.Context) {
+	if s.persistence == nil {
+		return
+	}
+	if err := s.persistence.SaveUsage(ctx, internalusage.GetRequestStatistics().Snapshot()); err != nil {
+		log.Warnf("persistence: failed to save usage statistics: %v", err)
+	}
+	spend, err := json.Marshal(internalusage.GetBudgetMonitor().SpendSnapshot())
+	if err != nil {
+		log.Warnf("persistence: failed to encode budget spend: %v", err)
+		return
+	}
+	entry := persistence.CacheEntry{Namespace: budgetSpendNamespace, Key: budgetSpendKey, Value: string(spend)}
+	if err = s.persistence.PutCache(ctx, entry); err != nil {
+		log.Warnf("persistence: failed to save budget spend: %v", err)
+	}
+}
+
+// stopPersistence saves usage statistics a final time and closes the backend.
+func (s *Service) stopPersistence(ctx context.Context) {
+	if s.persistence == nil {
+		return
+	}
+	s.saveUsage(ctx)
+	if err := s.persistence.Close(); err != nil {
+		log.Warnf("persistence: failed to close backend: %v", err)
+	}
+	s.persistence = nil
+}
@@ -0,0 +1,176 @@
+// Package provider describes upstream providers in one place. The service binds the executor
+// and registers the model table of every registered Descriptor; other provider-specific
+// behaviour (routing prefixes, proxy services, error handling) still lives with the provider.
+// The providertest subpackage checks a Descriptor against a fake upstream.
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// ErrorCase is one row of a provider's error table: an upstream failure and the status
+// the executor must report through cliproxyexecutor.StatusError, which drives cooldown
+// and retry decisions in the auth manager.
+type ErrorCase struct {
+	// Name labels the case in test output.
+	Name string
+	// Status and Body are what the upstream returns.
+	Status int
+	Body   string
+	// WantStatus is the status the executor error must carry.
+	WantStatus int
+}
+
+// Conformance holds what providertest needs to drive the provider against a fake upstream.
+type Conformance struct {
+	// Auth returns a credential the executor accepts. Requests are redirected to the fake
+	// upstream whatever base URL the executor uses.
+	Auth func() *coreauth.Auth
+	// Model is the model requested during the suite; defaults to the first model.
+	Model string
+	// StreamBody is the SSE body the fake upstream returns for streaming calls, in the
+	// provider's TargetFormat.
+	StreamBody string
+	// StreamBodies overrides StreamBody for source formats that the executor sends to a
+	// different upstream API (e.g. Claude requests forwarded to an Anthropic endpoint).
+	// ModelPrefix stripping and DefaultHeaders are not checked for those requests.
+	StreamBodies map[sdktranslator.Format]string
+	// StreamText is text carried by the stream bodies that must reach the client.
+	StreamText string
+	// Streaming runs the stream checks; set it when ExecuteStream is supported.
+	Streaming bool
+	// ModelPrefix, when set, is the prefix every model of this provider carries. The
+	// executor must strip it before calling the upstream.
+	ModelPrefix string
+	// DefaultHeaders are headers every request to the TargetFormat API must carry.
+	DefaultHeaders map[string]string
+	// Errors is the provider's error table.
+	Errors []ErrorCase
+}
+
+// Descriptor bundles what the service needs to run a provider, plus its conformance settings.
+type Descriptor struct {
+	// Name is the provider key: the auth Provider value and executor identifier.
+	Name string
+	// TargetFormat is the request schema the upstream speaks.
+	TargetFormat sdktranslator.Format
+	// SourceFormats are the client schemas the provider can serve.
+	SourceFormats []sdktranslator.Format
+	// NewExecutor builds the provider executor for cfg.
+	NewExecutor func(cfg *config.Config) coreauth.ProviderExecutor
+	// Models returns the static model table registered for each credential.
+	Models func() []*registry.ModelInfo
+	// Conformance configures the providertest suite.
+	Conformance Conformance
+}
+
+// Validate reports missing required fields.
+func (d Descriptor) Validate() error {
+	var problems []string
+	if strings.TrimSpace(d.Name) == "" || d.Name != strings.ToLower(d.Name) {
+		problems = append(problems, "name must be a non-empty lower-case key")
+	}
+	if d.TargetFormat == "" {
+		problems = append(problems, "target format is required")
+	}
+	if len(d.SourceFormats) == 0 {
+		problems = append(problems, "at least one source format is required")
+	}
+	if d.NewExecutor == nil {
+		problems = append(problems, "executor factory is required")
+	}
+	if d.Models == nil {
+		problems = append(problems, "model table is required")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("provider %q: %s", d.Name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+var (
+	descriptorsMu sync.RWMutex
+	descriptors   = map[string]Descriptor{}
+)
+
+// Register adds d to the provider table, replacing any descriptor with the same name.
+func Register(d Descriptor) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	descriptorsMu.Lock()
+	defer descriptorsMu.Unlock()
+	descriptors[d.Name] = d
+	return nil
+}
+
+// MustRegister is Register for package initialisation; it panics on invalid descriptors.
+func MustRegister(d Descriptor) {
+	if err := Register(d); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the descriptor registered for name (case-insensitive).
+func Lookup(name string) (Descriptor, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return Descriptor{}, false
+	}
+	descriptorsMu.RLock()
+	defer descriptorsMu.RUnlock()
+	d, ok := descriptors[name]
+	return d, ok
+}
+
+// Names lists registered provider names in sorted order.
+func Names() []string {
+	descriptorsMu.RLock()
+	defer descriptorsMu.RUnlock()
+	names := make([]string, 0, len(descriptors))
+	for name := range descriptors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OpenAIChatStreamFixture is a minimal OpenAI Chat Completions SSE stream carrying the
+// text "conformance pong", for Conformance.StreamBody.
+const OpenAIChatStreamFixture = `data: {"id":"chatcmpl-conformance","object":"chat.completion.chunk","created":1,"model":"conformance","choices":[{"index":0,"delta":{"role":"assistant","content":"conformance pong"},"finish_reason":null}]}
+
+data: {"id":"chatcmpl-conformance","object":"chat.completion.chunk","created":1,"model":"conformance","choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}
+
+data: [DONE]
+
+`
+
+// ClaudeMessagesStreamFixture is a minimal Anthropic Messages SSE stream carrying the
+// text "conformance pong", for Conformance.StreamBody.
+const ClaudeMessagesStreamFixture = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_conformance","type":"message","role":"assistant","model":"conformance","content":[],"stop_reason":null,"usage":{"input_tokens":3,"output_tokens":0}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"conformance pong"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestRegisterRejectsIncompleteDescriptor(t *testing.T) {
+	err := Register(Descriptor{Name: "Broken"})
+	if err == nil {
+		t.Fatalf("expected validation error")
+	}
+	for _, want := range []string{"lower-case", "target format", "source format", "executor factory", "model table"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q does not mention %q", err, want)
+		}
+	}
+	if _, ok := Lookup("broken"); ok {
+		t.Fatalf("invalid descriptor was registered")
+	}
+}
+
+func TestLookupIsCaseInsensitive(t *testing.T) {
+	MustRegister(Descriptor{
+		Name:          "lookup-test",
+		TargetFormat:  sdktranslator.FormatOpenAI,
+		SourceFormats: []sdktranslator.Format{sdktranslator.FormatOpenAI},
+		NewExecutor:   func(*config.Config) coreauth.ProviderExecutor { return nil },
+		Models:        func() []*registry.ModelInfo { return nil },
+	})
+	t.Cleanup(func() {
+		descriptorsMu.Lock()
+		delete(descriptors, "lookup-test")
+		descriptorsMu.Unlock()
+	})
+	if _, ok := Lookup(" Lookup-Test "); !ok {
+		t.Fatalf("lookup by mixed-case name failed")
+	}
+}
@@ -0,0 +1,268 @@
+// Package providertest is the conformance suite for provider descriptors. A provider is
+// ready to ship when providertest.Run passes for its descriptor.
+package providertest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+
+	_ "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator/builtin"
+)
+
+// prompt is the user text every sample request carries; the fake upstream checks that
+// it survives translation.
+const prompt = "conformance ping"
+
+// sampleRequests are minimal client requests per source format.
+var sampleRequests = map[sdktranslator.Format]string{
+	sdktranslator.FormatOpenAI:         `{"model":"MODEL","stream":true,"messages":[{"role":"user","content":"conformance ping"}]}`,
+	sdktranslator.FormatOpenAIResponse: `{"model":"MODEL","stream":true,"input":"conformance ping"}`,
+	sdktranslator.FormatClaude:         `{"model":"MODEL","stream":true,"max_tokens":64,"messages":[{"role":"user","content":"conformance ping"}]}`,
+	sdktranslator.FormatGemini:         `{"contents":[{"role":"user","parts":[{"text":"conformance ping"}]}]}`,
+	sdktranslator.FormatGeminiCLI:      `{"model":"MODEL","request":{"contents":[{"role":"user","parts":[{"text":"conformance ping"}]}]}}`,
+}
+
+// Run checks d: descriptor validity, registration, a streaming call per source format
+// against a fake upstream, the error table, and request/response translation.
+func Run(t *testing.T, d provider.Descriptor) {
+	t.Helper()
+	if err := d.Validate(); err != nil {
+		t.Fatalf("invalid descriptor: %v", err)
+	}
+	if d.Conformance.Auth == nil {
+		t.Fatalf("provider %q: Conformance.Auth is required", d.Name)
+	}
+	t.Run("Registration", func(t *testing.T) { testRegistration(t, d) })
+	t.Run("Translation", func(t *testing.T) { testTranslation(t, d) })
+	if d.Conformance.Streaming {
+		t.Run("Stream", func(t *testing.T) { testStream(t, d) })
+	}
+	t.Run("Errors", func(t *testing.T) { testErrors(t, d) })
+}
+
+func testRegistration(t *testing.T, d provider.Descriptor) {
+	registered, ok := provider.Lookup(d.Name)
+	if !ok {
+		t.Fatalf("provider %q is not registered", d.Name)
+	}
+	if registered.NewExecutor == nil || registered.Models == nil {
+		t.Fatalf("registered descriptor for %q is incomplete", d.Name)
+	}
+	if id := d.NewExecutor(&config.Config{}).Identifier(); id != d.Name {
+		t.Fatalf("executor identifier = %q, want %q", id, d.Name)
+	}
+
+	models := d.Models()
+	if len(models) == 0 {
+		t.Fatalf("model table is empty")
+	}
+	seen := make(map[string]bool, len(models))
+	for _, model := range models {
+		if model == nil || model.ID == "" {
+			t.Fatalf("model table has an entry without an ID")
+		}
+		if seen[model.ID] {
+			t.Fatalf("model %q is listed twice", model.ID)
+		}
+		seen[model.ID] = true
+		if d.Conformance.ModelPrefix != "" && !strings.HasPrefix(model.ID, d.Conformance.ModelPrefix) {
+			t.Fatalf("model %q lacks prefix %q", model.ID, d.Conformance.ModelPrefix)
+		}
+	}
+
+	clientID := "providertest-" + d.Name
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(clientID, d.Name, models)
+	t.Cleanup(func() { reg.UnregisterClient(clientID) })
+	if !reg.ClientSupportsModel(clientID, conformanceModel(d)) {
+		t.Fatalf("registry does not route %q to %s", conformanceModel(d), d.Name)
+	}
+}
+
+func testTranslation(t *testing.T, d provider.Descriptor) {
+	model := conformanceModel(d)
+	for _, from := range d.SourceFormats {
+		sample, ok := sampleRequests[from]
+		if !ok {
+			t.Fatalf("no sample request for source format %q", from)
+		}
+		if from == d.TargetFormat {
+			continue
+		}
+		out := sdktranslator.TranslateRequest(from, d.TargetFormat, model, []byte(strings.ReplaceAll(sample, "MODEL", model)), true)
+		if !gjson.ValidBytes(out) || !bytes.Contains(out, []byte(prompt)) {
+			t.Fatalf("%s -> %s request translation lost the prompt: %s", from, d.TargetFormat, out)
+		}
+		if !sdktranslator.HasResponseTransformer(from, d.TargetFormat) {
+			t.Fatalf("no response translator from %s back to %s", d.TargetFormat, from)
+		}
+	}
+}
+
+func testStream(t *testing.T, d provider.Descriptor) {
+	model := conformanceModel(d)
+	for _, from := range d.SourceFormats {
+		t.Run(from.String(), func(t *testing.T) {
+			body := d.Conformance.StreamBody
+			override, overridden := d.Conformance.StreamBodies[from]
+			if overridden {
+				body = override
+			}
+			upstream := newFakeUpstream(t, http.StatusOK, "text/event-stream", body)
+			payload := []byte(strings.ReplaceAll(sampleRequests[from], "MODEL", model))
+
+			ctx, cancel := context.WithTimeout(upstream.context(), 10*time.Second)
+			defer cancel()
+			result, err := d.NewExecutor(&config.Config{}).ExecuteStream(ctx, d.Conformance.Auth(), cliproxyexecutor.Request{
+				Model:   model,
+				Payload: payload,
+				Format:  from,
+			}, cliproxyexecutor.Options{Stream: true, SourceFormat: from, OriginalRequest: payload})
+			if err != nil {
+				t.Fatalf("ExecuteStream: %v", err)
+			}
+			var out bytes.Buffer
+			for chunk := range result.Chunks {
+				if chunk.Err != nil {
+					t.Fatalf("stream error: %v", chunk.Err)
+				}
+				out.Write(chunk.Payload)
+				out.WriteByte('\n')
+			}
+			if d.Conformance.StreamText != "" && !strings.Contains(out.String(), d.Conformance.StreamText) {
+				t.Fatalf("stream output does not carry %q:\n%s", d.Conformance.StreamText, out.String())
+			}
+			upstream.checkRequest(t, d, !overridden)
+		})
+	}
+}
+
+func testErrors(t *testing.T, d provider.Descriptor) {
+	model := conformanceModel(d)
+	from := d.SourceFormats[0]
+	payload := []byte(strings.ReplaceAll(sampleRequests[from], "MODEL", model))
+	payload = bytes.Replace(payload, []byte(`"stream":true`), []byte(`"stream":false`), 1)
+	for _, tc := range d.Conformance.Errors {
+		t.Run(tc.Name, func(t *testing.T) {
+			upstream := newFakeUpstream(t, tc.Status, "application/json", tc.Body)
+			ctx, cancel := context.WithTimeout(upstream.context(), 10*time.Second)
+			defer cancel()
+			_, err := d.NewExecutor(&config.Config{}).Execute(ctx, d.Conformance.Auth(), cliproxyexecutor.Request{
+				Model:   model,
+				Payload: payload,
+				Format:  from,
+			}, cliproxyexecutor.Options{SourceFormat: from, OriginalRequest: payload})
+			if err == nil {
+				t.Fatalf("Execute succeeded for upstream status %d", tc.Status)
+			}
+			var statusErr cliproxyexecutor.StatusError
+			if !errors.As(err, &statusErr) {
+				t.Fatalf("error %v does not carry a status code", err)
+			}
+			if got := statusErr.StatusCode(); got != tc.WantStatus {
+				t.Fatalf("status = %d, want %d (%v)", got, tc.WantStatus, err)
+			}
+		})
+	}
+}
+
+func conformanceModel(d provider.Descriptor) string {
+	if d.Conformance.Model != "" {
+		return d.Conformance.Model
+	}
+	if models := d.Models(); len(models) > 0 && models[0] != nil {
+		return models[0].ID
+	}
+	return ""
+}
+
+// fakeUpstream answers every request with a fixed response and records what it got.
+type fakeUpstream struct {
+	server *httptest.Server
+	mu     sync.Mutex
+	got    []recordedRequest
+}
+
+type recordedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func newFakeUpstream(t *testing.T, status int, contentType, body string) *fakeUpstream {
+	t.Helper()
+	u := &fakeUpstream{}
+	u.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		u.mu.Lock()
+		u.got = append(u.got, recordedRequest{header: r.Header.Clone(), body: data})
+		u.mu.Unlock()
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(u.server.Close)
+	return u
+}
+
+// context returns a context whose round tripper sends every upstream request to the
+// fake server, whatever host the executor targets.
+func (u *fakeUpstream) context() context.Context {
+	target, _ := url.Parse(u.server.URL)
+	return context.WithValue(context.Background(), "cliproxy.roundtripper", redirectTransport{target: target})
+}
+
+// checkRequest verifies the last upstream request. primary reports whether it went to the
+// TargetFormat API, whose model naming and default headers the descriptor describes.
+func (u *fakeUpstream) checkRequest(t *testing.T, d provider.Descriptor, primary bool) {
+	t.Helper()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.got) == 0 {
+		t.Fatalf("upstream received no request")
+	}
+	req := u.got[len(u.got)-1]
+	if !bytes.Contains(req.body, []byte(prompt)) {
+		t.Fatalf("upstream request lost the prompt: %s", req.body)
+	}
+	if !primary {
+		return
+	}
+	if d.Conformance.ModelPrefix != "" {
+		if model := gjson.GetBytes(req.body, "model").String(); strings.HasPrefix(model, d.Conformance.ModelPrefix) {
+			t.Fatalf("upstream model %q still carries prefix %q", model, d.Conformance.ModelPrefix)
+		}
+	}
+	for name, want := range d.Conformance.DefaultHeaders {
+		if got := req.header.Get(name); got != want {
+			t.Fatalf("upstream header %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	clone := req.Clone(req.Context())
+	clone.URL.Scheme = rt.target.Scheme
+	clone.URL.Host = rt.target.Host
+	clone.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(clone)
+}
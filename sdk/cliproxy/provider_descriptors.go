@@ -0,0 +1,82 @@
+package cliproxy
+
+import (
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyprovider "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// Providers migrated onto descriptors. The service binds their executors and model tables
+// through cliproxyprovider.Lookup instead of switching on the provider name.
+func init() {
+	cliproxyprovider.MustRegister(kimiDescriptor())
+	cliproxyprovider.MustRegister(qwenDescriptor())
+}
+
+// standardErrorTable is the error table of providers that pass upstream statuses through.
+var standardErrorTable = []cliproxyprovider.ErrorCase{
+	{Name: "unauthorized", Status: http.StatusUnauthorized, Body: `{"error":{"message":"invalid token"}}`, WantStatus: http.StatusUnauthorized},
+	{Name: "rate_limited", Status: http.StatusTooManyRequests, Body: `{"error":{"message":"rate limit reached"}}`, WantStatus: http.StatusTooManyRequests},
+	{Name: "upstream_failure", Status: http.StatusInternalServerError, Body: `{"error":{"message":"internal error"}}`, WantStatus: http.StatusInternalServerError},
+}
+
+func kimiDescriptor() cliproxyprovider.Descriptor {
+	return cliproxyprovider.Descriptor{
+		Name:          "kimi",
+		TargetFormat:  sdktranslator.FormatOpenAI,
+		SourceFormats: []sdktranslator.Format{sdktranslator.FormatOpenAI, sdktranslator.FormatOpenAIResponse, sdktranslator.FormatClaude},
+		NewExecutor: func(cfg *config.Config) coreauth.ProviderExecutor {
+			return executor.NewKimiExecutor(cfg)
+		},
+		Models: registry.GetKimiModels,
+		Conformance: cliproxyprovider.Conformance{
+			Auth: func() *coreauth.Auth {
+				return &coreauth.Auth{
+					ID:         "conformance-kimi",
+					Provider:   "kimi",
+					Attributes: map[string]string{"api_key": "sk-conformance"},
+					Metadata:   map[string]any{"access_token": "sk-conformance"},
+				}
+			},
+			StreamBody: cliproxyprovider.OpenAIChatStreamFixture,
+			// Kimi forwards Claude requests to its Anthropic-compatible endpoint.
+			StreamBodies:   map[sdktranslator.Format]string{sdktranslator.FormatClaude: cliproxyprovider.ClaudeMessagesStreamFixture},
+			StreamText:     "conformance pong",
+			Streaming:      true,
+			ModelPrefix:    "kimi-",
+			DefaultHeaders: map[string]string{"X-Msh-Platform": "kimi_cli"},
+			Errors:         standardErrorTable,
+		},
+	}
+}
+
+func qwenDescriptor() cliproxyprovider.Descriptor {
+	return cliproxyprovider.Descriptor{
+		Name:          "qwen",
+		TargetFormat:  sdktranslator.FormatOpenAI,
+		SourceFormats: []sdktranslator.Format{sdktranslator.FormatOpenAI, sdktranslator.FormatOpenAIResponse, sdktranslator.FormatClaude, sdktranslator.FormatGemini},
+		NewExecutor: func(cfg *config.Config) coreauth.ProviderExecutor {
+			return executor.NewQwenExecutor(cfg)
+		},
+		Models: registry.GetQwenModels,
+		Conformance: cliproxyprovider.Conformance{
+			Auth: func() *coreauth.Auth {
+				return &coreauth.Auth{
+					ID:         "conformance-qwen",
+					Provider:   "qwen",
+					Attributes: map[string]string{"api_key": "sk-conformance"},
+				}
+			},
+			StreamBody:     cliproxyprovider.OpenAIChatStreamFixture,
+			StreamText:     "conformance pong",
+			Streaming:      true,
+			DefaultHeaders: map[string]string{"X-Dashscope-Authtype": "qwen-oauth"},
+			Errors:         standardErrorTable,
+		},
+	}
+}
@@ -0,0 +1,18 @@
+package cliproxy
+
+import (
+	"testing"
+
+	cliproxyprovider "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider/providertest"
+)
+
+func TestProviderDescriptorsConformance(t *testing.T) {
+	for _, name := range []string{"kimi", "qwen"} {
+		d, ok := cliproxyprovider.Lookup(name)
+		if !ok {
+			t.Fatalf("provider %q has no descriptor", name)
+		}
+		t.Run(name, func(t *testing.T) { providertest.Run(t, d) })
+	}
+}
@@ -2,6 +2,7 @@ package cliproxy
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
 	"net/url"
@@ -18,10 +19,12 @@ import (
 type defaultRoundTripperProvider struct {
 	mu    sync.RWMutex
 	cache map[string]http.RoundTripper
+	// minTLS is the tls-min-version floor applied to every transport built here.
+	minTLS uint16
 }
 
-func newDefaultRoundTripperProvider() *defaultRoundTripperProvider {
-	return &defaultRoundTripperProvider{cache: make(map[string]http.RoundTripper)}
+func newDefaultRoundTripperProvider(minTLS uint16) *defaultRoundTripperProvider {
+	return &defaultRoundTripperProvider{cache: make(map[string]http.RoundTripper), minTLS: minTLS}
 }
 
 // RoundTripperFor implements coreauth.RoundTripperProvider.
@@ -70,6 +73,7 @@ func (p *defaultRoundTripperProvider) RoundTripperFor(auth *coreauth.Auth) http.
 		log.Errorf("unsupported proxy scheme: %s", proxyURL.Scheme)
 		return nil
 	}
+	transport.TLSClientConfig = &tls.Config{MinVersion: p.minTLS}
 	p.mu.Lock()
 	p.cache[proxyStr] = transport
 	p.mu.Unlock()
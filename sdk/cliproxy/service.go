@@ -16,14 +16,18 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
 	grokauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/grok"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/persistence"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
-	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/startup"
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyprovider "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
@@ -87,6 +91,9 @@ type Service struct {
 	// coreManager handles core authentication and execution.
 	coreManager *coreauth.Manager
 
+	// persistence is the backend for state kept across restarts; nil when disabled.
+	persistence persistence.Backend
+
 	// shutdownOnce ensures shutdown is called only once.
 	shutdownOnce sync.Once
 
@@ -415,6 +422,10 @@ func (s *Service) ensureExecutorsForAuthWithMode(a *coreauth.Auth, forceReplace
 		s.coreManager.RegisterExecutor(executor.NewOpenAICompatExecutor(compatProviderKey, s.cfg))
 		return
 	}
+	if descriptor, ok := cliproxyprovider.Lookup(a.Provider); ok {
+		s.coreManager.RegisterExecutor(descriptor.NewExecutor(s.cfg))
+		return
+	}
 	switch strings.ToLower(a.Provider) {
 	case "gemini":
 		s.coreManager.RegisterExecutor(executor.NewGeminiExecutor(s.cfg))
@@ -435,8 +446,6 @@ func (s *Service) ensureExecutorsForAuthWithMode(a *coreauth.Auth, forceReplace
 		s.coreManager.RegisterExecutor(executor.NewCodexExecutor(s.cfg))
 	case "copilot":
 		s.coreManager.RegisterExecutor(executor.NewCopilotExecutor(s.cfg))
-	case "qwen":
-		s.coreManager.RegisterExecutor(executor.NewQwenExecutor(s.cfg))
 	case "iflow":
 		s.coreManager.RegisterExecutor(executor.NewIFlowExecutor(s.cfg))
 	case "grok":
@@ -445,8 +454,6 @@ func (s *Service) ensureExecutorsForAuthWithMode(a *coreauth.Auth, forceReplace
 		s.coreManager.RegisterExecutor(executor.NewChutesExecutor(s.cfg))
 	case "kiro":
 		s.coreManager.RegisterExecutor(executor.NewKiroExecutor(s.cfg))
-	case "kimi":
-		s.coreManager.RegisterExecutor(executor.NewKimiExecutor(s.cfg))
 	default:
 		providerKey := strings.ToLower(strings.TrimSpace(a.Provider))
 		if providerKey == "" {
@@ -492,6 +499,8 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	usage.StartDefault(ctx)
+	internalusage.StartBudgetMonitor(ctx)
+	s.startPersistence(ctx)
 
 	// Register Chutes priority hook with 500ms debounce
 	hook := newChutesPriorityHook(s, 500*time.Millisecond)
@@ -577,6 +586,17 @@ func (s *Service) Run(ctx context.Context) error {
 	}()
 
 	time.Sleep(100 * time.Millisecond)
+	select {
+	case errStart := <-s.serverErr:
+		if errStart != nil {
+			if hint := startup.ListenErrorHint(errStart, s.cfg.Port); hint != "" {
+				log.Errorf("%v (hint: %s)", errStart, hint)
+			}
+			return errStart
+		}
+		s.serverErr <- nil
+	default:
+	}
 	fmt.Printf("API server started successfully on: %s:%d\n", s.cfg.Host, s.cfg.Port)
 
 	s.applyPprofConfig(s.cfg)
@@ -638,6 +658,7 @@ func (s *Service) Run(ctx context.Context) error {
 			s.coreManager.SetOAuthModelAlias(newCfg.OAuthModelAlias)
 		}
 		s.rebindExecutors()
+		s.recordStartupSummary()
 	}
 
 	watcherWrapper, err = s.watcherFactory(s.configPath, s.cfg.AuthDir, reloadCallback)
@@ -665,6 +686,10 @@ func (s *Service) Run(ctx context.Context) error {
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
 	}
 
+	if summary := s.recordStartupSummary(); summary != nil {
+		fmt.Println(startup.Format(summary))
+	}
+
 	select {
 	case <-ctx.Done():
 		log.Debug("service context cancelled, shutting down...")
@@ -741,6 +766,7 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 
 		usage.StopDefault()
+		s.stopPersistence(ctx)
 	})
 	return shutdownErr
 }
@@ -760,9 +786,39 @@ func (s *Service) ensureAuthDir() error {
 	if !info.IsDir() {
 		return fmt.Errorf("cliproxy: auth path exists but is not a directory: %s", s.cfg.AuthDir)
 	}
+	s.recoverAuthTransactions()
+	s.checkAuthFilePermissions()
 	return nil
 }
 
+// recoverAuthTransactions finishes or discards multi-file credential writes that were
+// interrupted by a crash, so the loader never sees half of a linked credential set.
+// checkAuthFilePermissions warns about credential files other users can read and, when
+// fix-auth-file-permissions is set, restricts them to 0600.
+func (s *Service) checkAuthFilePermissions() {
+	exposed, err := util.CheckCredentialPermissions(s.cfg.AuthDir, s.cfg.FixAuthFilePermissions)
+	if err != nil {
+		log.Warnf("auth directory: checking credential file permissions failed: %v", err)
+	}
+	for _, file := range exposed {
+		if file.Fixed {
+			log.Warnf("auth directory: credential file %s was readable by other users (mode %04o); changed to %04o", file.Path, file.Mode, util.CredentialFilePerm)
+			continue
+		}
+		log.Warnf("auth directory: credential file %s is readable by other users (mode %04o); run chmod 600 or set fix-auth-file-permissions", file.Path, file.Mode)
+	}
+}
+
+func (s *Service) recoverAuthTransactions() {
+	forward, back, err := util.RecoverFileTransactions(s.cfg.AuthDir)
+	if err != nil {
+		log.Warnf("auth directory: recovering interrupted writes failed: %v", err)
+	}
+	if forward > 0 || back > 0 {
+		log.Infof("auth directory: completed %d and rolled back %d interrupted credential writes", forward, back)
+	}
+}
+
 // registerModelsForAuth (re)binds provider models in the global registry using the core auth ID as client identifier.
 func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 	if a == nil || a.ID == "" {
@@ -947,6 +1003,9 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 				excluded = entry.ExcludedModels
 			}
 		}
+		if s.cfg != nil && s.cfg.CodexExposeAliases {
+			models = executor.CodexAliasModels(s.cfg, models)
+		}
 		models = applyExcludedModels(models, excluded)
 		models = registry.GenerateCodexAliases(models)
 	case "copilot":
@@ -957,9 +1016,7 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			log.Warnf("copilot: using static fallback models for auth %s", a.ID)
 			models = registry.GetCopilotModels()
 		}
-	case "qwen":
-		models = registry.GetQwenModels()
-		models = applyExcludedModels(models, excluded)
+		models = registry.ApplyCopilotBilling(models, s.copilotPremiumMultipliers())
 	case "iflow":
 		models = registry.GetIFlowModels()
 	case "kiro":
@@ -976,10 +1033,11 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 			log.Warnf("chutes: using static fallback models for auth %s", a.ID)
 			models = registry.GetChutesModels()
 		}
-	case "kimi":
-		models = registry.GetKimiModels()
-		models = applyExcludedModels(models, excluded)
 	default:
+		if descriptor, ok := cliproxyprovider.Lookup(provider); ok {
+			models = applyExcludedModels(descriptor.Models(), excluded)
+			break
+		}
 		// Handle OpenAI-compatibility providers by name using config
 		if s.cfg != nil {
 			providerKey := provider
@@ -1212,6 +1270,20 @@ func (s *Service) oauthExcludedModels(provider, authKind string) []string {
 	return cfg.OAuthExcludedModels[providerKey]
 }
 
+// copilotPremiumMultipliers returns the Copilot premium multipliers configured in
+// models-override-file. A missing or invalid file is logged and ignored.
+func (s *Service) copilotPremiumMultipliers() map[string]float64 {
+	if s == nil || s.cfg == nil || strings.TrimSpace(s.cfg.ModelsOverrideFile) == "" {
+		return nil
+	}
+	overrides, err := config.LoadModelsOverride(s.cfg.ModelsOverrideFile)
+	if err != nil {
+		log.Warnf("copilot: ignoring models override file: %v", err)
+		return nil
+	}
+	return overrides.PremiumMultipliers("copilot")
+}
+
 func applyExcludedModels(models []*ModelInfo, excluded []string) []*ModelInfo {
 	if len(models) == 0 || len(excluded) == 0 {
 		return models
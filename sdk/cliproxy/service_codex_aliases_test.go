@@ -0,0 +1,56 @@
+package cliproxy
+
+import (
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestRegisterModelsForAuth_CodexExposeAliases(t *testing.T) {
+	for _, expose := range []bool{false, true} {
+		service := &Service{cfg: &config.Config{
+			CodexExposeAliases: expose,
+			Codex: internalconfig.CodexConfig{Aliases: []internalconfig.CodexAlias{
+				{Base: "gpt-5.2", Efforts: []string{"minimal", "high"}},
+			}},
+		}}
+		auth := &coreauth.Auth{
+			ID:         "auth-codex-expose-aliases",
+			Provider:   "codex",
+			Status:     coreauth.StatusActive,
+			Attributes: map[string]string{"auth_kind": "oauth"},
+		}
+		reg := registry.GetGlobalRegistry()
+		reg.UnregisterClient(auth.ID)
+		t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+
+		service.registerModelsForAuth(auth)
+
+		models := map[string]*ModelInfo{}
+		for _, m := range reg.GetModelsForClient(auth.ID) {
+			models[m.ID] = m
+		}
+		if models["gpt-5.2"] == nil || models["gpt-5.2-high"] == nil {
+			t.Fatalf("expose=%t: expected the built-in gpt-5.2 entries to be registered", expose)
+		}
+		for _, id := range []string{"gpt-5.2-minimal", "codex-gpt-5.2-minimal"} {
+			alias, ok := models[id]
+			if ok != expose {
+				t.Fatalf("expose=%t: %s registered = %t", expose, id, ok)
+			}
+			if !ok {
+				continue
+			}
+			base := models["gpt-5.2"]
+			if alias.ContextLength != base.ContextLength || alias.OwnedBy != base.OwnedBy || alias.MaxCompletionTokens != base.MaxCompletionTokens {
+				t.Fatalf("%s metadata = %d/%s/%d, want the base's %d/%s/%d", id,
+					alias.ContextLength, alias.OwnedBy, alias.MaxCompletionTokens,
+					base.ContextLength, base.OwnedBy, base.MaxCompletionTokens)
+			}
+		}
+		reg.UnregisterClient(auth.ID)
+	}
+}
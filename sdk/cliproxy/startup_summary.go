@@ -0,0 +1,104 @@
+package cliproxy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/startup"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// recordStartupSummary builds the startup summary from the current configuration and
+// credentials and stores it for the management status endpoint.
+func (s *Service) recordStartupSummary() *startup.Summary {
+	s.cfgMu.RLock()
+	cfg := s.cfg
+	s.cfgMu.RUnlock()
+	if cfg == nil {
+		return nil
+	}
+	if faults := cfg.UnsafeFaultInjection; faults.Active() {
+		log.Warnf("UNSAFE: fault injection is enabled, %.0f%% of upstream calls are made to fail (%s); never use this in production", faults.Probability*100, describeFaultInjection(faults))
+	}
+	summary := startup.Build(s.startupInput(cfg))
+	startup.SetCurrent(summary)
+	return summary
+}
+
+func (s *Service) startupInput(cfg *config.Config) startup.Input {
+	in := startup.Input{
+		Host:                 cfg.Host,
+		Port:                 cfg.Port,
+		TLS:                  cfg.TLS.Enable,
+		Debug:                cfg.Debug,
+		Production:           startup.LooksLikeProduction(),
+		AuthDir:              cfg.AuthDir,
+		Auths:                make(map[string]int),
+		APIKeys:              cfg.APIKeys,
+		ManagementSecret:     cfg.RemoteManagement.SecretKey,
+		ManagementPassword:   os.Getenv("MANAGEMENT_PASSWORD"),
+		ProxyURL:             cfg.ProxyURL,
+		NoProxy:              cfg.NoProxy,
+		InsecureTLSProviders: cfg.InsecureUpstreamTLSProviders(),
+	}
+	if cfg.UnsafeFaultInjection.Active() {
+		in.FaultInjection = describeFaultInjection(cfg.UnsafeFaultInjection)
+	}
+	if cfg.Pprof.Enable {
+		in.PprofAddr = strings.TrimSpace(cfg.Pprof.Addr)
+		if in.PprofAddr == "" {
+			in.PprofAddr = config.DefaultPprofAddr
+		}
+	}
+	for provider := range cfg.OAuthModelAlias {
+		in.ConfiguredProviders = append(in.ConfiguredProviders, provider)
+	}
+	for provider := range cfg.OAuthExcludedModels {
+		in.ConfiguredProviders = append(in.ConfiguredProviders, provider)
+	}
+
+	// The watcher snapshot covers config API keys whose updates may still be queued.
+	var auths []*coreauth.Auth
+	if s.coreManager != nil {
+		auths = append(auths, s.coreManager.List()...)
+	}
+	if s.watcher != nil {
+		auths = append(auths, s.watcher.SnapshotAuths()...)
+	}
+	seen := make(map[string]bool, len(auths))
+	for _, auth := range auths {
+		if auth == nil || auth.Disabled || seen[auth.ID] {
+			continue
+		}
+		seen[auth.ID] = true
+		if provider := strings.ToLower(strings.TrimSpace(auth.Provider)); provider != "" {
+			in.Auths[provider]++
+		}
+	}
+
+	electronActive, electronDetail := executor.CopilotElectronStatus()
+	in.Transports = []startup.Transport{
+		{Name: "go", Active: true},
+		{Name: "copilot-electron", Active: electronActive, Detail: electronDetail},
+	}
+	return in
+}
+
+// describeFaultInjection summarizes the configured faults, e.g. "probability=0.1 status=503".
+func describeFaultInjection(faults config.FaultInjection) string {
+	parts := []string{fmt.Sprintf("probability=%g", faults.Probability)}
+	if latency := faults.LatencyDuration(); latency > 0 {
+		parts = append(parts, "latency="+latency.String())
+	}
+	if faults.Status != 0 {
+		parts = append(parts, fmt.Sprintf("status=%d", faults.Status))
+	}
+	if faults.TruncateAfterBytes > 0 {
+		parts = append(parts, fmt.Sprintf("truncate-after-bytes=%d", faults.TruncateAfterBytes))
+	}
+	return strings.Join(parts, " ")
+}
@@ -179,3 +179,8 @@ func StartDefault(ctx context.Context) { DefaultManager().Start(ctx) }
 
 // StopDefault stops the default manager's dispatcher.
 func StopDefault() { DefaultManager().Stop() }
+
+// ContextRecordsKey is the gin context key under which executors collect the successful
+// usage records ([]Record) of the current client request, so handlers can price a
+// response before it is written.
+const ContextRecordsKey = "cliproxy.usage_records"
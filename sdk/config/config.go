@@ -19,6 +19,14 @@ type PayloadConfig = internalconfig.PayloadConfig
 type PayloadRule = internalconfig.PayloadRule
 type PayloadFilterRule = internalconfig.PayloadFilterRule
 type PayloadModelRule = internalconfig.PayloadModelRule
+type ModelCatalogEntry = internalconfig.ModelCatalogEntry
+type ModelsOverride = internalconfig.ModelsOverride
+type ModelOverride = internalconfig.ModelOverride
+type TenantConfig = internalconfig.TenantConfig
+type APIKeyDefaults = internalconfig.APIKeyDefaults
+type RoutingRule = internalconfig.RoutingRule
+type ModelPreset = internalconfig.ModelPreset
+type OutputRedactionConfig = internalconfig.OutputRedactionConfig
 
 type GeminiKey = internalconfig.GeminiKey
 type CodexKey = internalconfig.CodexKey
@@ -33,6 +41,13 @@ type TLS = internalconfig.TLSConfig
 
 const (
 	DefaultPanelGitHubRepository = internalconfig.DefaultPanelGitHubRepository
+
+	FinalDelimiterIfNeeded = internalconfig.FinalDelimiterIfNeeded
+	FinalDelimiterAlways   = internalconfig.FinalDelimiterAlways
+	FinalDelimiterNever    = internalconfig.FinalDelimiterNever
+
+	EmptyResponseRetry = internalconfig.EmptyResponseRetry
+	EmptyResponseError = internalconfig.EmptyResponseError
 )
 
 func LoadConfig(configFile string) (*Config, error) { return internalconfig.LoadConfig(configFile) }
@@ -41,6 +56,10 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	return internalconfig.LoadConfigOptional(configFile, optional)
 }
 
+func LoadModelsOverride(path string) (ModelsOverride, error) {
+	return internalconfig.LoadModelsOverride(path)
+}
+
 func SaveConfigPreserveComments(configFile string, cfg *Config) error {
 	return internalconfig.SaveConfigPreserveComments(configFile, cfg)
 }
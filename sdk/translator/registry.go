@@ -3,6 +3,8 @@ package translator
 import (
 	"context"
 	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/timing"
 )
 
 // Registry manages translation functions across schemas.
@@ -72,6 +74,9 @@ func (r *Registry) TranslateStream(ctx context.Context, from, to Format, model s
 
 	if byTarget, ok := r.responses[to]; ok {
 		if fn, isOk := byTarget[from]; isOk && fn.Stream != nil {
+			if timer := timing.FromContext(ctx); timer != nil {
+				defer timer.Since(timing.PhaseTranslateOut, timer.Now())
+			}
 			return fn.Stream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 		}
 	}
@@ -85,6 +90,9 @@ func (r *Registry) TranslateNonStream(ctx context.Context, from, to Format, mode
 
 	if byTarget, ok := r.responses[to]; ok {
 		if fn, isOk := byTarget[from]; isOk && fn.NonStream != nil {
+			if timer := timing.FromContext(ctx); timer != nil {
+				defer timer.Since(timing.PhaseTranslateOut, timer.Now())
+			}
 			return fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
 		}
 	}